@@ -10,6 +10,8 @@ import (
 	"github.com/reshimahendra/lbw-go/internal/app/account"
 	"github.com/reshimahendra/lbw-go/internal/config"
 	"github.com/reshimahendra/lbw-go/internal/database"
+	"github.com/reshimahendra/lbw-go/internal/middleware"
+	"github.com/reshimahendra/lbw-go/internal/pkg/auth"
 	"github.com/reshimahendra/lbw-go/internal/pkg/logger"
 )
 
@@ -20,6 +22,11 @@ func Run(){
         logger.Errorf("fail loading configuration: %v", err)
     }
 
+    // load the key ring to sign and verify auth token
+    if err := auth.SetupKeyRing(config.Get().Server); err != nil {
+        logger.Errorf("fail loading signing key: %v", err)
+    }
+
     // connect to database
     pool, _, err := database.NewDBPool(config.Get().Database)
     if err != nil {
@@ -44,6 +51,9 @@ func Run(){
     } else {
         router = gin.Default()
     }
+
+    // send the error put on the request context by the handler
+    router.Use(middleware.ErrorHandler())
     
     // prepare router for account app
     account.Router(pool, router)
@@ -0,0 +1,161 @@
+/*
+   package datastore
+   audit.go
+   - datastore layer for audit log (record of change made on the account app)
+   NOTE of method:
+       * Create method to append new audit log, audit log can not be updated nor deleted
+       * Gets method to get audit log matching the list query
+*/
+package datastore
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/reshimahendra/lbw-go/internal/database"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/logger"
+)
+
+const (
+    // query command to append new audit log
+    sqlAuditC = `INSERT INTO public.audit_log (actor_id,"action",target_type,target_id,changes,ip_address) VALUES ($1,$2,$3,$4,$5,$6) RETURNING id,created_at`
+
+    // query command to get audit log, the filter, sort and pagination clause is added on query
+    sqlAuditR = `SELECT id,actor_id,"action",target_type,target_id,changes,ip_address,created_at FROM public.audit_log`
+
+    // query command to count audit log, the filter clause is added on query
+    sqlAuditCount = `SELECT COUNT(id) FROM public.audit_log`
+)
+
+// IAuditStore is audit interface for audit log operation directly to the database
+type IAuditStore interface {
+    // Create will append new audit log
+    Create(input d.AuditLog) (*d.AuditLog, error)
+
+    // Gets will get audit log matching the list query filter, sorted and paginated
+    // as requested along with the list metadata
+    Gets(q d.ListQuery) ([]*d.AuditLog, *d.ListMeta, error)
+}
+
+// AuditStore is instance wrapper for IDatabase interface
+type AuditStore struct {
+    // DB is IDatabase interface instance
+    DB database.IDatabase
+}
+
+// NewAuditStore will create instance of AuditStore
+func NewAuditStore(iDB database.IDatabase) *AuditStore {
+    return &AuditStore{DB: iDB}
+}
+
+// Create will append new audit log record to database
+func (st *AuditStore) Create(input d.AuditLog) (*d.AuditLog, error) {
+    // check whether input is invalid
+    if !input.IsValid() {
+        return nil, E.New(E.ErrDataIsInvalid)
+    }
+    if input.Changes == nil {
+        input.Changes = d.AuditChanges{}
+    }
+
+    // execute sql command to append the audit log
+    err := st.DB.QueryRow(context.Background(), sqlAuditC,
+        input.ActorID,
+        input.Action,
+        input.TargetType,
+        input.TargetID,
+        input.Changes,
+        input.IPAddress,
+    ).Scan(&input.ID, &input.CreatedAt)
+    if err != nil {
+        logger.Errorf("audit.create datastore fail: %v", err)
+        return nil, E.New(E.ErrDatabase)
+    }
+
+    return &input, nil
+}
+
+// Gets will get audit log record from the database matching the list query filter,
+// sorted and paginated as requested along with the list metadata
+func (st *AuditStore) Gets(q d.ListQuery) ([]*d.AuditLog, *d.ListMeta, error) {
+    // audit log is only sorted by the time it was recorded
+    if q.Sort != "created_at" {
+        return nil, nil, E.New(E.ErrDataIsInvalid)
+    }
+
+    // prepare the filter and count all audit log matching it
+    lc := newListClause()
+    if q.ActorID != nil {
+        lc.add("actor_id = $%d", *q.ActorID)
+    }
+    if q.Action != "" {
+        lc.add(`"action" = $%d`, q.Action)
+    }
+    if q.TargetType != "" {
+        lc.add("target_type = $%d", q.TargetType)
+    }
+    if q.TargetID != "" {
+        lc.add("target_id = $%d", q.TargetID)
+    }
+    lc.addCreated(q)
+
+    var total int64
+    if err := st.DB.QueryRow(context.Background(), sqlAuditCount+lc.String(), lc.args...).Scan(&total); err != nil {
+        return nil, nil, E.New(E.ErrDatabase)
+    }
+
+    // keyset pagination continue after the last record of the previous page
+    if q.Cursor != nil {
+        value, id, err := parseAuditCursor(q.Cursor)
+        if err != nil {
+            return nil, nil, E.New(E.ErrDataIsInvalid)
+        }
+        lc.after(q.Sort, q.Order, value, id)
+    }
+
+    // execute sql command to get audit log record
+    results, err := st.DB.Query(context.Background(), sqlAuditR+lc.page(q.Sort, q), lc.args...)
+    if err != nil {
+        return nil, nil, E.New(E.ErrDatabase)
+    }
+    defer results.Close()
+
+    // scanAllFunc is locate at datastore - user.go
+    logs := make([]*d.AuditLog, 0)
+    if err = scanAllFunc(&logs, results); err != nil {
+        return nil, nil, E.New(E.ErrDatabase)
+    }
+
+    // the extra record mean there is next page, it start after the last record of this page
+    meta := listMeta(q, total)
+    if len(logs) > q.Limit {
+        logs = logs[:q.Limit]
+        last := logs[len(logs)-1]
+        meta.NextCursor = (&d.ListCursor{
+            Sort  : q.Sort,
+            Order : q.Order,
+            Value : last.CreatedAt.UTC().Format(time.RFC3339Nano),
+            ID    : strconv.FormatInt(last.ID, 10),
+        }).Encode()
+    }
+
+    return logs, meta, nil
+}
+
+// parseAuditCursor will convert cursor value into the sort column and id type
+func parseAuditCursor(cursor *d.ListCursor) (interface{}, interface{}, error) {
+    id, err := strconv.ParseInt(cursor.ID, 10, 64)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    t, err := time.Parse(time.RFC3339Nano, cursor.Value)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    return t.UTC(), id, nil
+}
@@ -0,0 +1,255 @@
+/*
+   package datastore (test)
+   - 'audit' test unit
+*/
+package datastore
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/pashagolub/pgxmock"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+    auditHeader = []string{"id","actor_id","action","target_type","target_id","changes","ip_address","created_at"}
+    auditActor  = uuid.New()
+    audits      = []*d.AuditLog{
+        {
+            ID         : 1,
+            ActorID    : &auditActor,
+            Action     : d.AuditActionCreate,
+            TargetType : d.AuditTargetRole,
+            TargetID   : "4",
+            Changes    : d.AuditChanges{"role_name": {After: "Editor"}},
+            IPAddress  : "203.0.113.7",
+            CreatedAt  : time.Date(2022, 3, 1, 10, 0, 0, 0, time.UTC),
+        },
+        {
+            ID         : 2,
+            ActorID    : &auditActor,
+            Action     : d.AuditActionUpdate,
+            TargetType : d.AuditTargetRole,
+            TargetID   : "4",
+            Changes    : d.AuditChanges{"role_name": {Before: "Editor", After: "Writer"}},
+            IPAddress  : "203.0.113.7",
+            CreatedAt  : time.Date(2022, 3, 1, 11, 0, 0, 0, time.UTC),
+        },
+        {
+            ID         : 3,
+            Action     : d.AuditActionPasswordReset,
+            TargetType : d.AuditTargetUser,
+            TargetID   : uuid.NewString(),
+            Changes    : d.AuditChanges{},
+            IPAddress  : "198.51.100.20",
+            CreatedAt  : time.Date(2022, 3, 1, 12, 0, 0, 0, time.UTC),
+        },
+    }
+)
+
+// addAuditRow will add the audit log to the mocked rows
+func addAuditRow(rows *pgxmock.Rows, a *d.AuditLog) *pgxmock.Rows {
+    return rows.AddRow(a.ID, a.ActorID, a.Action, a.TargetType, a.TargetID, a.Changes, a.IPAddress, a.CreatedAt)
+}
+
+// TestAuditCreate will test Create method of audit datastore
+func TestAuditCreate(t *testing.T) {
+    mock := PrepareMock(t)
+    input := *audits[1]
+
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlAuditC)).
+            WithArgs(input.ActorID, input.Action, input.TargetType, input.TargetID, input.Changes, input.IPAddress).
+            WillReturnRows(pgxmock.NewRows([]string{"id","created_at"}).AddRow(input.ID, input.CreatedAt))
+
+        store := NewAuditStore(mock)
+        got, err := store.Create(d.AuditLog{
+            ActorID    : input.ActorID,
+            Action     : input.Action,
+            TargetType : input.TargetType,
+            TargetID   : input.TargetID,
+            Changes    : input.Changes,
+            IPAddress  : input.IPAddress,
+        })
+
+        assert.NoError(t, err)
+        assert.Equal(t, &input, got)
+    })
+
+    // EXPECT SUCCESS audit log without changes is recorded with empty changes
+    t.Run("EXPECT SUCCESS no changes", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlAuditC)).
+            WithArgs(input.ActorID, d.AuditActionPurge, input.TargetType, input.TargetID, d.AuditChanges{}, input.IPAddress).
+            WillReturnRows(pgxmock.NewRows([]string{"id","created_at"}).AddRow(int64(4), time.Now()))
+
+        store := NewAuditStore(mock)
+        got, err := store.Create(d.AuditLog{
+            ActorID    : input.ActorID,
+            Action     : d.AuditActionPurge,
+            TargetType : input.TargetType,
+            TargetID   : input.TargetID,
+            IPAddress  : input.IPAddress,
+        })
+
+        assert.NoError(t, err)
+        assert.Equal(t, int64(4), got.ID)
+    })
+
+    t.Run("EXPECT FAIL data is invalid", func(t *testing.T){
+        store := NewAuditStore(mock)
+        got, err := store.Create(d.AuditLog{Action: d.AuditActionUpdate, TargetType: d.AuditTargetRole})
+
+        assert.Nil(t, got)
+        assert.Equal(t, E.New(E.ErrDataIsInvalid), err)
+    })
+
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlAuditC)).
+            WillReturnError(pgx.ErrTxClosed)
+
+        store := NewAuditStore(mock)
+        got, err := store.Create(input)
+
+        assert.Nil(t, got)
+        assert.Equal(t, E.New(E.ErrDatabase), err)
+    })
+}
+
+// TestAuditGets will test Gets method of audit datastore
+func TestAuditGets(t *testing.T) {
+    mock := PrepareMock(t)
+    q := d.ListQuery{Page: 1, Limit: 2, Sort: "created_at", Order: d.SortAsc}
+    countHeader := []string{"count"}
+
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlAuditCount)).
+            WillReturnRows(pgxmock.NewRows(countHeader).AddRow(int64(3)))
+        rows := pgxmock.NewRows(auditHeader)
+        for _, a := range audits {
+            addAuditRow(rows, a)
+        }
+        mock.ExpectQuery(regexp.QuoteMeta(sqlAuditR + " ORDER BY created_at ASC,id ASC LIMIT $1")).
+            WithArgs(3).
+            WillReturnRows(rows)
+
+        store := NewAuditStore(mock)
+        got, meta, err := store.Gets(q)
+
+        assert.NoError(t, err)
+        assert.Len(t, got, 2)
+        assert.Equal(t, audits[1].Changes, got[1].Changes)
+        assert.Equal(t, int64(3), meta.Total)
+
+        cursor, err := d.DecodeListCursor(meta.NextCursor)
+        assert.NoError(t, err)
+        assert.Equal(t, &d.ListCursor{Sort: "created_at", Order: d.SortAsc, Value: "2022-03-01T11:00:00Z", ID: "2"}, cursor)
+    })
+
+    // EXPECT SUCCESS change of the target by the actor continue after the cursor
+    t.Run("EXPECT SUCCESS filter and cursor", func(t *testing.T){
+        from := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+        cq := d.ListQuery{
+            Page        : 1,
+            Limit       : 2,
+            Sort        : "created_at",
+            Order       : d.SortDesc,
+            Cursor      : &d.ListCursor{Sort: "created_at", Order: d.SortDesc, Value: "2022-03-01T11:00:00Z", ID: "2"},
+            CreatedFrom : &from,
+            ActorID     : &auditActor,
+            Action      : d.AuditActionCreate,
+            TargetType  : d.AuditTargetRole,
+            TargetID    : "4",
+        }
+        filter := ` WHERE actor_id = $1 AND "action" = $2 AND target_type = $3 AND target_id = $4 AND created_at >= $5`
+
+        mock.ExpectQuery(regexp.QuoteMeta(sqlAuditCount + filter)).
+            WithArgs(auditActor, d.AuditActionCreate, d.AuditTargetRole, "4", from).
+            WillReturnRows(pgxmock.NewRows(countHeader).AddRow(int64(1)))
+        mock.ExpectQuery(regexp.QuoteMeta(sqlAuditR + filter +
+            " AND (created_at,id) < ($6,$7) ORDER BY created_at DESC,id DESC LIMIT $8")).
+            WithArgs(auditActor, d.AuditActionCreate, d.AuditTargetRole, "4", from, time.Date(2022, 3, 1, 11, 0, 0, 0, time.UTC), int64(2), 3).
+            WillReturnRows(addAuditRow(pgxmock.NewRows(auditHeader), audits[0]))
+
+        store := NewAuditStore(mock)
+        got, meta, err := store.Gets(cq)
+
+        assert.NoError(t, err)
+        assert.Len(t, got, 1)
+        assert.Equal(t, audits[0].TargetID, got[0].TargetID)
+        assert.Empty(t, meta.NextCursor)
+    })
+
+    t.Run("EXPECT FAIL unknown sort field", func(t *testing.T){
+        store := NewAuditStore(mock)
+        got, _, err := store.Gets(d.ListQuery{Page: 1, Limit: 2, Sort: "action", Order: d.SortAsc})
+
+        assert.Nil(t, got)
+        assert.Equal(t, E.New(E.ErrDataIsInvalid), err)
+    })
+
+    // EXPECT FAIL cursor id is not number
+    t.Run("EXPECT FAIL invalid cursor", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlAuditCount)).
+            WillReturnRows(pgxmock.NewRows(countHeader).AddRow(int64(3)))
+
+        cq := q
+        cq.Cursor = &d.ListCursor{Sort: "created_at", Order: d.SortAsc, Value: "2022-03-01T11:00:00Z", ID: "two"}
+        store := NewAuditStore(mock)
+        got, _, err := store.Gets(cq)
+
+        assert.Nil(t, got)
+        assert.Equal(t, E.New(E.ErrDataIsInvalid), err)
+    })
+
+    t.Run("EXPECT FAIL count error", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlAuditCount)).
+            WillReturnError(pgx.ErrTxClosed)
+
+        store := NewAuditStore(mock)
+        got, _, err := store.Gets(q)
+
+        assert.Nil(t, got)
+        assert.Equal(t, E.New(E.ErrDatabase), err)
+    })
+
+    t.Run("EXPECT FAIL query error", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlAuditCount)).
+            WillReturnRows(pgxmock.NewRows(countHeader).AddRow(int64(3)))
+        mock.ExpectQuery(regexp.QuoteMeta(sqlAuditR)).
+            WillReturnError(pgx.ErrTxClosed)
+
+        store := NewAuditStore(mock)
+        got, _, err := store.Gets(q)
+
+        assert.Nil(t, got)
+        assert.Equal(t, E.New(E.ErrDatabase), err)
+    })
+
+    // EXPECT FAIL scan data error. Simulated by mocking ScanAll func of the pgxscan
+    t.Run("EXPECT FAIL scan data error", func(t *testing.T){
+        scanAll := scanAllFunc
+        scanAllFunc = func(dst interface{}, rows pgx.Rows) error {
+            return E.New(E.ErrDatabase)
+        }
+        defer func() {
+            scanAllFunc = scanAll
+        }()
+
+        mock.ExpectQuery(regexp.QuoteMeta(sqlAuditCount)).
+            WillReturnRows(pgxmock.NewRows(countHeader).AddRow(int64(3)))
+        mock.ExpectQuery(regexp.QuoteMeta(sqlAuditR)).
+            WillReturnRows(addAuditRow(pgxmock.NewRows(auditHeader), audits[0]))
+
+        store := NewAuditStore(mock)
+        got, _, err := store.Gets(q)
+
+        assert.Nil(t, got)
+        assert.Error(t, err)
+    })
+}
@@ -0,0 +1,137 @@
+/*
+   datastore package
+   auth.attempt.go
+   - datastore layer for failed signin attempt
+   NOTE of method:
+       * Get method
+       * Fail method
+       * Lock method
+       * Reset method
+*/
+package datastore
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/reshimahendra/lbw-go/internal/database"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/logger"
+)
+
+const (
+    // query command to get the failed signin attempt of the subject
+    sqlSigninAttemptR1 = `SELECT scope,subject,failed_count,last_failed_at,locked_until FROM public.signin_attempt WHERE scope=$1 AND subject=$2`
+
+    // query command to count the failed signin attempt. the count restart when
+    // the last failed attempt is older than the given window
+    sqlSigninAttemptC = `INSERT INTO public.signin_attempt (scope,subject,failed_count,last_failed_at) VALUES ($1,$2,1,$3) ON CONFLICT (scope,subject) DO UPDATE SET failed_count=CASE WHEN public.signin_attempt.last_failed_at < $4 THEN 1 ELSE public.signin_attempt.failed_count+1 END,last_failed_at=EXCLUDED.last_failed_at RETURNING scope,subject,failed_count,last_failed_at,locked_until`
+
+    // query command to lock the subject, the count restart so the subject get
+    // a fresh set of attempt once the lock is over
+    sqlSigninAttemptU = `UPDATE public.signin_attempt SET failed_count=0,locked_until=$3 WHERE scope=$1 AND subject=$2`
+
+    // query command to remove the failed signin attempt of the subject
+    sqlSigninAttemptD = `DELETE FROM public.signin_attempt WHERE scope=$1 AND subject=$2`
+)
+
+// ISigninAttemptStore is interface for failed signin attempt operation directly
+// to the database
+type ISigninAttemptStore interface {
+    // Get will get the failed signin attempt of the subject
+    Get(scope, subject string) (*d.SigninAttempt, error)
+
+    // Fail will count new failed signin attempt of the subject at the given time.
+    // attempt that failed before windowStart is not counted anymore
+    Fail(scope, subject string, failedAt, windowStart time.Time) (*d.SigninAttempt, error)
+
+    // Lock will reject signin of the subject until the given time
+    Lock(scope, subject string, until time.Time) error
+
+    // Reset will remove the failed signin attempt of the subject
+    Reset(scope, subject string) error
+}
+
+// SigninAttemptStore is instance wrapper for IDatabase interface
+type SigninAttemptStore struct {
+    // DB is IDatabase interface instance
+    DB database.IDatabase
+}
+
+// NewSigninAttemptStore will create instance of SigninAttemptStore
+func NewSigninAttemptStore(iDB database.IDatabase) *SigninAttemptStore {
+    return &SigninAttemptStore{DB: iDB}
+}
+
+// Get will get the failed signin attempt record of the subject
+func (st *SigninAttemptStore) Get(scope, subject string) (*d.SigninAttempt, error) {
+    // execute sql command to get the record
+    result := st.DB.QueryRow(context.Background(), sqlSigninAttemptR1, scope, subject)
+
+    attempt, err := scanSigninAttempt(result)
+    if err == pgx.ErrNoRows {
+        return nil, E.New(E.ErrDataIsEmpty)
+    } else if err != nil {
+        logger.Errorf("auth.attempt.get datastore fail: %v", err)
+        return nil, E.New(E.ErrDatabase)
+    }
+
+    return attempt, nil
+}
+
+// Fail will insert or increment the failed signin attempt record of the subject
+func (st *SigninAttemptStore) Fail(scope, subject string, failedAt, windowStart time.Time) (*d.SigninAttempt, error) {
+    // execute sql command to count the failed attempt
+    result := st.DB.QueryRow(context.Background(), sqlSigninAttemptC, scope, subject, failedAt, windowStart)
+
+    attempt, err := scanSigninAttempt(result)
+    if err != nil {
+        logger.Errorf("auth.attempt.fail datastore fail: %v", err)
+        return nil, E.New(E.ErrDatabase)
+    }
+
+    return attempt, nil
+}
+
+// Lock will set the lock time of the subject and restart its failed attempt count
+func (st *SigninAttemptStore) Lock(scope, subject string, until time.Time) error {
+    // execute sql command to lock the subject
+    _, err := st.DB.Exec(context.Background(), sqlSigninAttemptU, scope, subject, until)
+    if err != nil {
+        logger.Errorf("auth.attempt.lock datastore fail: %v", err)
+        return E.New(E.ErrDatabase)
+    }
+
+    return nil
+}
+
+// Reset will delete the failed signin attempt record of the subject
+func (st *SigninAttemptStore) Reset(scope, subject string) error {
+    // execute sql command to remove the record
+    _, err := st.DB.Exec(context.Background(), sqlSigninAttemptD, scope, subject)
+    if err != nil {
+        logger.Errorf("auth.attempt.reset datastore fail: %v", err)
+        return E.New(E.ErrDatabase)
+    }
+
+    return nil
+}
+
+// scanSigninAttempt will scan the signin attempt record
+func scanSigninAttempt(row pgx.Row) (*d.SigninAttempt, error) {
+    attempt := new(d.SigninAttempt)
+    err := row.Scan(
+        &attempt.Scope,
+        &attempt.Subject,
+        &attempt.FailedCount,
+        &attempt.LastFailedAt,
+        &attempt.LockedUntil,
+    )
+    if err != nil {
+        return nil, err
+    }
+
+    return attempt, nil
+}
@@ -0,0 +1,201 @@
+/*
+   datastore package
+   auth.attempt_test.go
+   - test unit for failed signin attempt datastore
+*/
+package datastore
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/pashagolub/pgxmock"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+    // saLockedUntil is lock time mock data
+    saLockedUntil = time.Now().Add(15 * time.Minute)
+
+    // sa is signin attempt mock data
+    sa = d.SigninAttempt{
+        Scope        : d.SigninScopeUser,
+        Subject      : u[0].ID.String(),
+        FailedCount  : 3,
+        LastFailedAt : time.Now(),
+        LockedUntil  : &saLockedUntil,
+    }
+
+    // saHeader is signin_attempt table header mock data
+    saHeader = []string{"scope", "subject", "failed_count", "last_failed_at", "locked_until"}
+)
+
+// TestSigninAttemptStoreGet will test Get method of signin attempt datastore
+func TestSigninAttemptStoreGet(t *testing.T) {
+    // prepare mock and store
+    mock := PrepareMock(t)
+    store := NewSigninAttemptStore(mock)
+
+    // EXPECT SUCCESS is typical test simulation with expectation that
+    // the operation will run normally
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        rows := pgxmock.NewRows(saHeader).
+            AddRow(sa.Scope, sa.Subject, sa.FailedCount, sa.LastFailedAt, sa.LockedUntil)
+        mock.ExpectQuery(regexp.QuoteMeta(sqlSigninAttemptR1)).
+            WithArgs(sa.Scope, sa.Subject).
+            WillReturnRows(rows)
+
+        // actual method test
+        got, err := store.Get(sa.Scope, sa.Subject)
+
+        // validation and verification
+        assert.NoError(t, err)
+        assert.Equal(t, sa.FailedCount, got.FailedCount)
+        assert.Equal(t, sa.LockedUntil, got.LockedUntil)
+    })
+
+    // EXPECT FAIL data not found. Simulated by returning pgx.ErrNoRows
+    t.Run("EXPECT FAIL data not found", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlSigninAttemptR1)).
+            WithArgs(sa.Scope, sa.Subject).
+            WillReturnError(pgx.ErrNoRows)
+
+        // actual method test
+        got, err := store.Get(sa.Scope, sa.Subject)
+
+        // validation and verification
+        assert.Error(t, err)
+        assert.Nil(t, got)
+        assert.Equal(t, E.New(E.ErrDataIsEmpty), err)
+    })
+
+    // EXPECT FAIL database error. Simulated by triggering E.ErrDatabase on mock
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlSigninAttemptR1)).
+            WithArgs(sa.Scope, sa.Subject).
+            WillReturnError(E.New(E.ErrDatabase))
+
+        // actual method test
+        got, err := store.Get(sa.Scope, sa.Subject)
+
+        // validation and verification
+        assert.Error(t, err)
+        assert.Nil(t, got)
+        assert.Equal(t, E.New(E.ErrDatabase), err)
+    })
+}
+
+// TestSigninAttemptStoreFail will test Fail method of signin attempt datastore
+func TestSigninAttemptStoreFail(t *testing.T) {
+    // prepare mock and store
+    mock := PrepareMock(t)
+    store := NewSigninAttemptStore(mock)
+    windowStart := sa.LastFailedAt.Add(-15 * time.Minute)
+
+    // EXPECT SUCCESS is typical test simulation with expectation that
+    // the operation will run normally
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        rows := pgxmock.NewRows(saHeader).
+            AddRow(sa.Scope, sa.Subject, sa.FailedCount, sa.LastFailedAt, nil)
+        mock.ExpectQuery(regexp.QuoteMeta(sqlSigninAttemptC)).
+            WithArgs(sa.Scope, sa.Subject, sa.LastFailedAt, windowStart).
+            WillReturnRows(rows)
+
+        // actual method test
+        got, err := store.Fail(sa.Scope, sa.Subject, sa.LastFailedAt, windowStart)
+
+        // validation and verification
+        assert.NoError(t, err)
+        assert.Equal(t, sa.FailedCount, got.FailedCount)
+        assert.Nil(t, got.LockedUntil)
+    })
+
+    // EXPECT FAIL database error. Simulated by triggering E.ErrDatabase on mock
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlSigninAttemptC)).
+            WithArgs(sa.Scope, sa.Subject, sa.LastFailedAt, windowStart).
+            WillReturnError(E.New(E.ErrDatabase))
+
+        // actual method test
+        got, err := store.Fail(sa.Scope, sa.Subject, sa.LastFailedAt, windowStart)
+
+        // validation and verification
+        assert.Error(t, err)
+        assert.Nil(t, got)
+        assert.Equal(t, E.New(E.ErrDatabase), err)
+    })
+}
+
+// TestSigninAttemptStoreLock will test Lock method of signin attempt datastore
+func TestSigninAttemptStoreLock(t *testing.T) {
+    // prepare mock and store
+    mock := PrepareMock(t)
+    store := NewSigninAttemptStore(mock)
+
+    // EXPECT SUCCESS is typical test simulation with expectation that
+    // the operation will run normally
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlSigninAttemptU)).
+            WithArgs(sa.Scope, sa.Subject, saLockedUntil).
+            WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+        // actual method test
+        err := store.Lock(sa.Scope, sa.Subject, saLockedUntil)
+
+        // validation and verification
+        assert.NoError(t, err)
+    })
+
+    // EXPECT FAIL database error. Simulated by triggering E.ErrDatabase on mock
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlSigninAttemptU)).
+            WithArgs(sa.Scope, sa.Subject, saLockedUntil).
+            WillReturnError(E.New(E.ErrDatabase))
+
+        // actual method test
+        err := store.Lock(sa.Scope, sa.Subject, saLockedUntil)
+
+        // validation and verification
+        assert.Error(t, err)
+        assert.Equal(t, E.New(E.ErrDatabase), err)
+    })
+}
+
+// TestSigninAttemptStoreReset will test Reset method of signin attempt datastore
+func TestSigninAttemptStoreReset(t *testing.T) {
+    // prepare mock and store
+    mock := PrepareMock(t)
+    store := NewSigninAttemptStore(mock)
+
+    // EXPECT SUCCESS is typical test simulation with expectation that
+    // the operation will run normally
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlSigninAttemptD)).
+            WithArgs(sa.Scope, sa.Subject).
+            WillReturnResult(pgxmock.NewResult("DELETE", 1))
+
+        // actual method test
+        err := store.Reset(sa.Scope, sa.Subject)
+
+        // validation and verification
+        assert.NoError(t, err)
+    })
+
+    // EXPECT FAIL database error. Simulated by triggering E.ErrDatabase on mock
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlSigninAttemptD)).
+            WithArgs(sa.Scope, sa.Subject).
+            WillReturnError(E.New(E.ErrDatabase))
+
+        // actual method test
+        err := store.Reset(sa.Scope, sa.Subject)
+
+        // validation and verification
+        assert.Error(t, err)
+        assert.Equal(t, E.New(E.ErrDatabase), err)
+    })
+}
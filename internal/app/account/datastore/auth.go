@@ -2,6 +2,187 @@
    datastore package
    auth.go
    - datastore layer for authentification
+   NOTE of method:
+       * RevokeToken method
+       * IsTokenRevoked method
+       * CreateRefreshToken method
+       * RotateRefreshToken method
+       * RevokeTokenFamily method
+       * RevokeUserTokens method
 */
 package datastore
 
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/reshimahendra/lbw-go/internal/database"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/logger"
+)
+
+const (
+    // query command to put token on the denylist
+    sqlRevokedTokenC = `INSERT INTO public.revoked_token (id,expires_at) VALUES ($1,$2) ON CONFLICT (id) DO NOTHING`
+
+    // query command to check whether token is on the denylist, issued on or before the cutoff of its user
+    // tokens revocation (second precision like the 'iat' claim) or belong to a revoked refresh token family
+    sqlRevokedTokenR1 = `SELECT (SELECT COUNT(id) FROM public.revoked_token WHERE id = $1) + (SELECT COUNT(user_id) FROM public.revoked_user_token WHERE user_id = $2 AND revoked_before >= $3) + (SELECT COUNT(id) FROM public.refresh_token_family WHERE id = NULLIF($4,'')::uuid AND revoked_at IS NOT NULL)`
+
+    // query command to record the refresh token, the family and its session is created on its
+    // first token (signin). the session client and refresh datetime is updated on later token
+    sqlRefreshTokenC = `WITH f AS (INSERT INTO public.refresh_token_family (id,user_id) VALUES ($2,$3) ON CONFLICT (id) DO NOTHING), s AS (INSERT INTO public.user_session (id,user_id,user_agent,ip_address) VALUES ($2,$3,$5,$6) ON CONFLICT (id) DO UPDATE SET user_agent=EXCLUDED.user_agent,ip_address=EXCLUDED.ip_address,last_refreshed_at=CURRENT_TIMESTAMP) INSERT INTO public.refresh_token (id,family_id,expires_at) VALUES ($1,$2,$4)`
+
+    // query command to check whether the refresh token was recorded
+    sqlRefreshTokenR1 = `SELECT COUNT(id) FROM public.refresh_token WHERE id = $1 AND family_id = $2`
+
+    // query command to mark the refresh token as used (rotated). only unused token is updated
+    sqlRefreshTokenU = `UPDATE public.refresh_token SET used_at = CURRENT_TIMESTAMP WHERE id = $1 AND family_id = $2 AND used_at IS NULL`
+
+    // query command to revoke all token of the refresh token family
+    sqlRefreshTokenFamilyD = `UPDATE public.refresh_token_family SET revoked_at = CURRENT_TIMESTAMP WHERE id = $1 AND revoked_at IS NULL`
+
+    // query command to revoke all token of the user issued on or before the given time
+    sqlRevokedUserTokenC = `INSERT INTO public.revoked_user_token (user_id,revoked_before) VALUES ($1,$2) ON CONFLICT (user_id) DO UPDATE SET revoked_before=EXCLUDED.revoked_before`
+)
+
+// IAuthStore is auth interface for authentification operation directly
+// to the database
+type IAuthStore interface {
+    // RevokeToken will put the token on the denylist so it can not be used anymore
+    RevokeToken(token d.TokenMetadata) error
+
+    // IsTokenRevoked will check whether token is on the denylist, issued on or before
+    // the cutoff of its user tokens revocation or its family was revoked
+    IsTokenRevoked(token d.TokenMetadata) (bool, error)
+
+    // CreateRefreshToken will record the issued refresh token on its family and
+    // record the client on the session of the family
+    CreateRefreshToken(token d.TokenMetadata, client d.SessionClient) error
+
+    // RotateRefreshToken will mark the refresh token as used. it return false when
+    // the token was already used before (reused)
+    RotateRefreshToken(token d.TokenMetadata) (bool, error)
+
+    // RevokeTokenFamily will revoke all token of the refresh token family
+    RevokeTokenFamily(familyID string) error
+
+    // RevokeUserTokens will revoke all token of the user issued on or before the given time
+    RevokeUserTokens(userID uuid.UUID, revokedBefore time.Time) error
+}
+
+// AuthStore is instance wrapper for IDatabase interface
+type AuthStore struct {
+    // DB is IDatabase interface instance
+    DB database.IDatabase
+}
+
+// NewAuthStore will create instance of AuthStore
+func NewAuthStore(iDB database.IDatabase) *AuthStore {
+    return &AuthStore{DB: iDB}
+}
+
+// RevokeToken will insert the token id into the denylist
+func (st *AuthStore) RevokeToken(token d.TokenMetadata) error {
+    // execute sql command to insert token into denylist
+    _, err := st.DB.Exec(context.Background(), sqlRevokedTokenC, token.ID, token.ExpiresAt)
+    if err != nil {
+        logger.Errorf("auth.revoke datastore fail: %v", err)
+        return E.New(E.ErrDatabase)
+    }
+
+    return nil
+}
+
+// IsTokenRevoked will check whether the token id is on the denylist, the token was
+// issued on or before the cutoff of its user tokens revocation or its family was revoked
+func (st *AuthStore) IsTokenRevoked(token d.TokenMetadata) (bool, error) {
+    var count int
+    err := st.DB.QueryRow(context.Background(), sqlRevokedTokenR1,
+        token.ID,
+        token.UserID,
+        token.IssuedAt,
+        token.FamilyID,
+    ).Scan(&count)
+    if err != nil {
+        logger.Errorf("auth.isTokenRevoked datastore fail: %v", err)
+        return false, E.New(E.ErrDatabase)
+    }
+
+    return count >= 1, nil
+}
+
+// CreateRefreshToken will insert the refresh token record and create its family and
+// session when it is the first token of the family, otherwise the session is updated
+func (st *AuthStore) CreateRefreshToken(token d.TokenMetadata, client d.SessionClient) error {
+    // execute sql command to insert refresh token record
+    _, err := st.DB.Exec(context.Background(), sqlRefreshTokenC,
+        token.ID,
+        token.FamilyID,
+        token.UserID,
+        token.ExpiresAt,
+        client.UserAgent,
+        client.IPAddress,
+    )
+    if err != nil {
+        logger.Errorf("auth.createRefreshToken datastore fail: %v", err)
+        return E.New(E.ErrDatabase)
+    }
+
+    return nil
+}
+
+// RotateRefreshToken will mark the unused refresh token as used. when no token is updated,
+// it will check whether the token was used before or it is never recorded
+func (st *AuthStore) RotateRefreshToken(token d.TokenMetadata) (bool, error) {
+    // execute sql command to mark the token as used
+    tag, err := st.DB.Exec(context.Background(), sqlRefreshTokenU, token.ID, token.FamilyID)
+    if err != nil {
+        logger.Errorf("auth.rotateRefreshToken datastore fail: %v", err)
+        return false, E.New(E.ErrDatabase)
+    }
+
+    if tag.RowsAffected() == 1 {
+        return true, nil
+    }
+
+    // no token updated, token is already used or unknown
+    var count int
+    err = st.DB.QueryRow(context.Background(), sqlRefreshTokenR1, token.ID, token.FamilyID).Scan(&count)
+    if err != nil {
+        logger.Errorf("auth.rotateRefreshToken datastore fail: %v", err)
+        return false, E.New(E.ErrDatabase)
+    }
+
+    if count == 0 {
+        return false, E.New(E.ErrDataIsEmpty)
+    }
+
+    return false, nil
+}
+
+// RevokeTokenFamily will mark the refresh token family as revoked
+func (st *AuthStore) RevokeTokenFamily(familyID string) error {
+    // execute sql command to revoke the family
+    _, err := st.DB.Exec(context.Background(), sqlRefreshTokenFamilyD, familyID)
+    if err != nil {
+        logger.Errorf("auth.revokeTokenFamily datastore fail: %v", err)
+        return E.New(E.ErrDatabase)
+    }
+
+    return nil
+}
+
+// RevokeUserTokens will revoke all token of the user issued on or before the given time
+func (st *AuthStore) RevokeUserTokens(userID uuid.UUID, revokedBefore time.Time) error {
+    // execute sql command to record the user token revocation
+    _, err := st.DB.Exec(context.Background(), sqlRevokedUserTokenC, userID, revokedBefore)
+    if err != nil {
+        logger.Errorf("auth.revokeUserTokens datastore fail: %v", err)
+        return E.New(E.ErrDatabase)
+    }
+
+    return nil
+}
@@ -0,0 +1,127 @@
+/*
+   datastore package
+   auth.key.go
+   - datastore layer for the state of the auth token signing key ring
+   NOTE of method:
+       * Record method
+       * Gets method
+       * Promote method
+       * Retire method
+*/
+package datastore
+
+import (
+	"context"
+	"time"
+
+	"github.com/reshimahendra/lbw-go/internal/database"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/logger"
+)
+
+const (
+    // query command to record the key not recorded yet. the given active key is only
+    // recorded as active when no key is active yet, other key is deactivated at $3
+    sqlSigningKeyC = `INSERT INTO public.signing_key (id,active,deactivated_at) SELECT k.id,a.active,CASE WHEN a.active THEN NULL ELSE $3::timestamptz END FROM unnest($1::text[]) AS k(id), LATERAL (SELECT k.id=$2 AND NOT EXISTS (SELECT 1 FROM public.signing_key WHERE active) AS active) a ON CONFLICT (id) DO NOTHING`
+
+    // query command to get the state of all keys
+    sqlSigningKeyR = `SELECT id,active,deactivated_at,retired_at FROM public.signing_key ORDER BY id`
+
+    // query command to deactivate the active key other than the promoted key
+    sqlSigningKeyDeactivateU = `UPDATE public.signing_key SET active=false,deactivated_at=$2 WHERE active AND id<>$1`
+
+    // query command to make the key the active key
+    sqlSigningKeyPromoteU = `INSERT INTO public.signing_key (id,active) VALUES ($1,true) ON CONFLICT (id) DO UPDATE SET active=true,deactivated_at=NULL,retired_at=NULL`
+
+    // query command to retire the key, active key can not be retired
+    sqlSigningKeyRetireU = `UPDATE public.signing_key SET retired_at=$2 WHERE id=$1 AND NOT active AND retired_at IS NULL`
+)
+
+// ISigningKeyStore is interface for signing key ring state operation directly to the database
+type ISigningKeyStore interface {
+    // Record will record the key not recorded yet. the active key is only recorded as
+    // active when no key is active yet, other key is recorded as deactivated at the given time
+    Record(ids []string, activeID string, at time.Time) error
+
+    // Gets will get the state of all recorded keys
+    Gets() ([]d.SigningKeyState, error)
+
+    // Promote will make the key the active key, the previous active key is deactivated at the given time
+    Promote(kid string, at time.Time) error
+
+    // Retire will mark the key as retired at the given time
+    Retire(kid string, at time.Time) error
+}
+
+// SigningKeyStore is instance wrapper for IDatabase interface
+type SigningKeyStore struct {
+    // DB is IDatabase interface instance
+    DB database.IDatabase
+}
+
+// NewSigningKeyStore will create instance of SigningKeyStore
+func NewSigningKeyStore(iDB database.IDatabase) *SigningKeyStore {
+    return &SigningKeyStore{DB: iDB}
+}
+
+// Record will insert the key record that is not recorded yet
+func (st *SigningKeyStore) Record(ids []string, activeID string, at time.Time) error {
+    // execute sql command to record the keys
+    _, err := st.DB.Exec(context.Background(), sqlSigningKeyC, ids, activeID, at)
+    if err != nil {
+        logger.Errorf("auth.key.record datastore fail: %v", err)
+        return E.New(E.ErrDatabase)
+    }
+
+    return nil
+}
+
+// Gets will get the state record of all keys
+func (st *SigningKeyStore) Gets() ([]d.SigningKeyState, error) {
+    // execute sql command to get the records
+    results, err := st.DB.Query(context.Background(), sqlSigningKeyR)
+    if err != nil {
+        logger.Errorf("auth.key.gets datastore fail: %v", err)
+        return nil, E.New(E.ErrDatabase)
+    }
+    defer results.Close()
+
+    // scanAllFunc is locate at datastore - user.go
+    states := []d.SigningKeyState{}
+    if err = scanAllFunc(&states, results); err != nil {
+        logger.Errorf("auth.key.gets datastore fail: %v", err)
+        return nil, E.New(E.ErrDatabase)
+    }
+
+    return states, nil
+}
+
+// Promote will deactivate the active key and activate the promoted key
+func (st *SigningKeyStore) Promote(kid string, at time.Time) error {
+    // execute sql command to deactivate the previous active key
+    if _, err := st.DB.Exec(context.Background(), sqlSigningKeyDeactivateU, kid, at); err != nil {
+        logger.Errorf("auth.key.promote datastore fail: %v", err)
+        return E.New(E.ErrDatabase)
+    }
+
+    // execute sql command to activate the key
+    if _, err := st.DB.Exec(context.Background(), sqlSigningKeyPromoteU, kid); err != nil {
+        logger.Errorf("auth.key.promote datastore fail: %v", err)
+        return E.New(E.ErrDatabase)
+    }
+
+    return nil
+}
+
+// Retire will set the retire time of the key
+func (st *SigningKeyStore) Retire(kid string, at time.Time) error {
+    // execute sql command to retire the key
+    _, err := st.DB.Exec(context.Background(), sqlSigningKeyRetireU, kid, at)
+    if err != nil {
+        logger.Errorf("auth.key.retire datastore fail: %v", err)
+        return E.New(E.ErrDatabase)
+    }
+
+    return nil
+}
@@ -0,0 +1,170 @@
+/*
+   datastore package
+   auth.key_test.go
+   - test unit for signing key ring state datastore
+*/
+package datastore
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+    // skAt is signing key state time mock data
+    skAt = time.Date(2022, 2, 3, 3, 30, 15, 0, time.UTC)
+
+    // sk is signing key state mock data
+    sk = []d.SigningKeyState{
+        {ID: "ed-1", Active: true},
+        {ID: "hs-1", DeactivatedAt: &skAt},
+        {ID: "hs-0", DeactivatedAt: &skAt, RetiredAt: &skAt},
+    }
+
+    // skHeader is signing_key table header mock data
+    skHeader = []string{"id", "active", "deactivated_at", "retired_at"}
+)
+
+// TestSigningKeyStoreRecord will test Record method of signing key datastore
+func TestSigningKeyStoreRecord(t *testing.T) {
+    // prepare mock and store
+    mock := PrepareMock(t)
+    store := NewSigningKeyStore(mock)
+    ids := []string{"ed-1", "hs-1"}
+
+    // EXPECT SUCCESS is typical test simulation with expectation that
+    // the operation will run normally
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlSigningKeyC)).
+            WithArgs(ids, "ed-1", skAt).
+            WillReturnResult(pgxmock.NewResult("INSERT", 2))
+
+        // actual method test and validation
+        assert.NoError(t, store.Record(ids, "ed-1", skAt))
+    })
+
+    // EXPECT FAIL database error. Simulated by triggering E.ErrDatabase on mock
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlSigningKeyC)).
+            WithArgs(ids, "ed-1", skAt).
+            WillReturnError(E.New(E.ErrDatabase))
+
+        // actual method test and validation
+        assert.Equal(t, E.New(E.ErrDatabase), store.Record(ids, "ed-1", skAt))
+    })
+}
+
+// TestSigningKeyStoreGets will test Gets method of signing key datastore
+func TestSigningKeyStoreGets(t *testing.T) {
+    // prepare mock and store
+    mock := PrepareMock(t)
+    store := NewSigningKeyStore(mock)
+
+    // EXPECT SUCCESS is typical test simulation with expectation that
+    // the operation will run normally
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        rows := pgxmock.NewRows(skHeader)
+        for _, state := range sk {
+            rows.AddRow(state.ID, state.Active, state.DeactivatedAt, state.RetiredAt)
+        }
+        mock.ExpectQuery(regexp.QuoteMeta(sqlSigningKeyR)).WillReturnRows(rows)
+
+        // actual method test
+        got, err := store.Gets()
+
+        // validation and verification
+        assert.NoError(t, err)
+        assert.Equal(t, sk, got)
+    })
+
+    // EXPECT FAIL database error. Simulated by triggering E.ErrDatabase on mock
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlSigningKeyR)).
+            WillReturnError(E.New(E.ErrDatabase))
+
+        // actual method test
+        got, err := store.Gets()
+
+        // validation and verification
+        assert.Nil(t, got)
+        assert.Equal(t, E.New(E.ErrDatabase), err)
+    })
+}
+
+// TestSigningKeyStorePromote will test Promote method of signing key datastore
+func TestSigningKeyStorePromote(t *testing.T) {
+    // prepare mock and store
+    mock := PrepareMock(t)
+    store := NewSigningKeyStore(mock)
+
+    // EXPECT SUCCESS is typical test simulation with expectation that
+    // the operation will run normally
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlSigningKeyDeactivateU)).
+            WithArgs("hs-1", skAt).
+            WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+        mock.ExpectExec(regexp.QuoteMeta(sqlSigningKeyPromoteU)).
+            WithArgs("hs-1").
+            WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+        // actual method test and validation
+        assert.NoError(t, store.Promote("hs-1", skAt))
+    })
+
+    // EXPECT FAIL database error on deactivating the active key
+    t.Run("EXPECT FAIL database error deactivate", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlSigningKeyDeactivateU)).
+            WithArgs("hs-1", skAt).
+            WillReturnError(E.New(E.ErrDatabase))
+
+        // actual method test and validation
+        assert.Equal(t, E.New(E.ErrDatabase), store.Promote("hs-1", skAt))
+    })
+
+    // EXPECT FAIL database error on activating the key
+    t.Run("EXPECT FAIL database error activate", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlSigningKeyDeactivateU)).
+            WithArgs("hs-1", skAt).
+            WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+        mock.ExpectExec(regexp.QuoteMeta(sqlSigningKeyPromoteU)).
+            WithArgs("hs-1").
+            WillReturnError(E.New(E.ErrDatabase))
+
+        // actual method test and validation
+        assert.Equal(t, E.New(E.ErrDatabase), store.Promote("hs-1", skAt))
+    })
+}
+
+// TestSigningKeyStoreRetire will test Retire method of signing key datastore
+func TestSigningKeyStoreRetire(t *testing.T) {
+    // prepare mock and store
+    mock := PrepareMock(t)
+    store := NewSigningKeyStore(mock)
+
+    // EXPECT SUCCESS is typical test simulation with expectation that
+    // the operation will run normally
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlSigningKeyRetireU)).
+            WithArgs("hs-1", skAt).
+            WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+        // actual method test and validation
+        assert.NoError(t, store.Retire("hs-1", skAt))
+    })
+
+    // EXPECT FAIL database error. Simulated by triggering E.ErrDatabase on mock
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlSigningKeyRetireU)).
+            WithArgs("hs-1", skAt).
+            WillReturnError(E.New(E.ErrDatabase))
+
+        // actual method test and validation
+        assert.Equal(t, E.New(E.ErrDatabase), store.Retire("hs-1", skAt))
+    })
+}
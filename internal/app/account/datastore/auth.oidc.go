@@ -0,0 +1,175 @@
+/*
+   package datastore
+   auth.oidc.go
+   - datastore layer for signin with openid connect provider (social login)
+   NOTE of method:
+       * CreateState method
+       * ConsumeState method to get and remove the pending signin state
+       * GetIdentityUser method to get user owning the provider identity
+       * LinkIdentity method to link provider identity to existing user
+       * CreateUser method to create new user with its provider identity
+*/
+package datastore
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/reshimahendra/lbw-go/internal/database"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/logger"
+)
+
+const (
+    // query command to insert new signin state. expired state is removed at the same time
+    sqlOIDCStateC = `WITH t AS (DELETE FROM public.oidc_state WHERE expires_at < CURRENT_TIMESTAMP) INSERT INTO public.oidc_state (state_hash,provider,code_verifier,nonce,expires_at) VALUES ($1,$2,$3,$4,$5)`
+
+    // query command to remove the signin state so it can only be used once
+    sqlOIDCStateD = `DELETE FROM public.oidc_state WHERE state_hash=$1 AND provider=$2 AND expires_at > CURRENT_TIMESTAMP RETURNING state_hash,provider,code_verifier,nonce,expires_at`
+
+    // query command to record the signin with the identity and get its user
+    sqlUserIdentityR = `UPDATE public.user_identity i SET last_signin_at=CURRENT_TIMESTAMP FROM public.users u WHERE i.provider=$1 AND i.subject=$2 AND u.id=i.user_id AND u.deleted_at IS NULL RETURNING u.id,u.username,u.firstname,u.lastname,u.email,u.status_id,u.role_id,u.created_at,u.updated_at`
+
+    // query command to link the identity to existing user
+    sqlUserIdentityC = `INSERT INTO public.user_identity (provider,subject,user_id,email,last_signin_at) VALUES ($1,$2,$3,$4,CURRENT_TIMESTAMP)`
+
+    // query command to insert new (active) user and link the identity to it in one statement
+    sqlUserIdentityUserC = `WITH u AS (INSERT INTO public.users (id,username,firstname,lastname,email,passkey,status_id,role_id,updated_at,activated_at) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,CURRENT_TIMESTAMP,CURRENT_TIMESTAMP) RETURNING id,username,firstname,lastname,email,status_id,role_id,created_at,updated_at), i AS (INSERT INTO public.user_identity (provider,subject,user_id,email,last_signin_at) SELECT $9,$10,id,email,CURRENT_TIMESTAMP FROM u) SELECT id,username,firstname,lastname,email,status_id,role_id,created_at,updated_at FROM u`
+)
+
+// IOIDCStore is interface for openid connect signin operation directly to the database
+type IOIDCStore interface {
+    // CreateState will insert new signin state
+    CreateState(input d.OIDCState) error
+
+    // ConsumeState will get and remove the signin state of the provider
+    ConsumeState(stateHash, provider string) (*d.OIDCState, error)
+
+    // GetIdentityUser will get user owning the provider identity
+    GetIdentityUser(provider, subject string) (*d.User, error)
+
+    // LinkIdentity will link the provider identity to existing user
+    LinkIdentity(input d.UserIdentity) error
+
+    // CreateUser will create new user and link the provider identity to it
+    CreateUser(user d.User, identity d.UserIdentity) (*d.User, error)
+}
+
+// OIDCStore is instance wrapper for IDatabase interface
+type OIDCStore struct {
+    // DB is IDatabase interface instance
+    DB database.IDatabase
+}
+
+// NewOIDCStore will create instance of OIDCStore
+func NewOIDCStore(iDB database.IDatabase) *OIDCStore {
+    return &OIDCStore{DB: iDB}
+}
+
+// CreateState will insert new signin state record to database
+func (st *OIDCStore) CreateState(input d.OIDCState) error {
+    _, err := st.DB.Exec(context.Background(), sqlOIDCStateC,
+        input.StateHash,
+        input.Provider,
+        input.CodeVerifier,
+        input.Nonce,
+        input.ExpiresAt,
+    )
+    if err != nil {
+        logger.Errorf("oidc.state.create datastore fail: %v", err)
+        return E.New(E.ErrDatabase)
+    }
+
+    return nil
+}
+
+// ConsumeState will get and remove the signin state of the provider. unknown, used
+// or expired state will return E.ErrDataIsEmpty
+func (st *OIDCStore) ConsumeState(stateHash, provider string) (*d.OIDCState, error) {
+    state := new(d.OIDCState)
+    err := st.DB.QueryRow(context.Background(), sqlOIDCStateD, stateHash, provider).Scan(
+        &state.StateHash,
+        &state.Provider,
+        &state.CodeVerifier,
+        &state.Nonce,
+        &state.ExpiresAt,
+    )
+    if err == pgx.ErrNoRows {
+        return nil, E.New(E.ErrDataIsEmpty)
+    } else if err != nil {
+        logger.Errorf("oidc.state.consume datastore fail: %v", err)
+        return nil, E.New(E.ErrDatabase)
+    }
+
+    return state, nil
+}
+
+// GetIdentityUser will get user owning the provider identity and record the signin.
+// identity that is not linked, or linked to deleted user, will return E.ErrDataIsEmpty
+func (st *OIDCStore) GetIdentityUser(provider, subject string) (*d.User, error) {
+    result := st.DB.QueryRow(context.Background(), sqlUserIdentityR, provider, subject)
+
+    return scanIdentityUser(result, "oidc.identity.get")
+}
+
+// LinkIdentity will link the provider identity to existing user. identity already
+// linked will return E.ErrUniqueViolation
+func (st *OIDCStore) LinkIdentity(input d.UserIdentity) error {
+    _, err := st.DB.Exec(context.Background(), sqlUserIdentityC,
+        input.Provider,
+        input.Subject,
+        input.UserID,
+        input.Email,
+    )
+    if err != nil {
+        logger.Errorf("oidc.identity.link datastore fail: %v", err)
+        return pgError(err)
+    }
+
+    return nil
+}
+
+// CreateUser will create new user and link the provider identity to it in one statement.
+// username, email or identity already exist will return E.ErrUniqueViolation
+func (st *OIDCStore) CreateUser(user d.User, identity d.UserIdentity) (*d.User, error) {
+    result := st.DB.QueryRow(context.Background(), sqlUserIdentityUserC,
+        user.ID,
+        user.Username,
+        user.Firstname,
+        user.Lastname,
+        user.Email,
+        user.PassKey,
+        user.StatusID,
+        user.RoleID,
+        identity.Provider,
+        identity.Subject,
+    )
+
+    return scanIdentityUser(result, "oidc.identity.create")
+}
+
+// scanIdentityUser will scan the user record returned by the identity query
+func scanIdentityUser(result pgx.Row, operation string) (*d.User, error) {
+    user := new(d.User)
+    err := result.Scan(
+        &user.ID,
+        &user.Username,
+        &user.Firstname,
+        &user.Lastname,
+        &user.Email,
+        &user.StatusID,
+        &user.RoleID,
+        &user.CreatedAt,
+        &user.UpdatedAt,
+    )
+
+    if err == pgx.ErrNoRows {
+        return nil, E.New(E.ErrDataIsEmpty)
+    } else if err != nil {
+        logger.Errorf("%s datastore fail: %v", operation, err)
+        return nil, pgError(err)
+    }
+
+    return user, nil
+}
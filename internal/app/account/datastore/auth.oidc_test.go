@@ -0,0 +1,227 @@
+/*
+   package datastore
+   auth.oidc_test.go
+   - test unit for openid connect signin datastore
+*/
+package datastore
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/pashagolub/pgxmock"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+    // ost is oidc.state mock data
+    ost = d.OIDCState{
+        StateHash    : "3c469e9d6c5875d37a43f353d4f88e61fcf812c66eee3457465a40b0da4153e0",
+        Provider     : "google",
+        CodeVerifier : "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk",
+        Nonce        : "n-0S6_WzA2Mj",
+        ExpiresAt    : time.Now().Add(10 * time.Minute),
+    }
+
+    // ui is user.identity mock data
+    ui = d.UserIdentity{
+        Provider : "google",
+        Subject  : "248289761001",
+        UserID   : u[0].ID,
+        Email    : u[0].Email,
+    }
+)
+
+// TestOIDCStoreCreateState will test CreateState method of oidc datastore
+func TestOIDCStoreCreateState(t *testing.T) {
+    mock := PrepareMock(t)
+    store := NewOIDCStore(mock)
+
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlOIDCStateC)).
+            WithArgs(ost.StateHash, ost.Provider, ost.CodeVerifier, ost.Nonce, ost.ExpiresAt).
+            WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+        assert.NoError(t, store.CreateState(ost))
+    })
+
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlOIDCStateC)).
+            WithArgs(ost.StateHash, ost.Provider, ost.CodeVerifier, ost.Nonce, ost.ExpiresAt).
+            WillReturnError(fmt.Errorf("connection lost"))
+
+        err := store.CreateState(ost)
+        assert.EqualValues(t, E.ErrDatabase, err.(*E.Error).Code)
+    })
+}
+
+// TestOIDCStoreConsumeState will test ConsumeState method of oidc datastore
+func TestOIDCStoreConsumeState(t *testing.T) {
+    mock := PrepareMock(t)
+    store := NewOIDCStore(mock)
+    header := []string{"state_hash","provider","code_verifier","nonce","expires_at"}
+
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlOIDCStateD)).
+            WithArgs(ost.StateHash, ost.Provider).
+            WillReturnRows(pgxmock.NewRows(header).
+                AddRow(ost.StateHash, ost.Provider, ost.CodeVerifier, ost.Nonce, ost.ExpiresAt))
+
+        got, err := store.ConsumeState(ost.StateHash, ost.Provider)
+
+        assert.NoError(t, err)
+        assert.Equal(t, ost, *got)
+    })
+
+    // EXPECT FAIL state is unknown, used, expired or started on other provider
+    t.Run("EXPECT FAIL not found", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlOIDCStateD)).
+            WithArgs(ost.StateHash, ost.Provider).
+            WillReturnError(pgx.ErrNoRows)
+
+        got, err := store.ConsumeState(ost.StateHash, ost.Provider)
+
+        assert.Nil(t, got)
+        assert.EqualValues(t, E.ErrDataIsEmpty, err.(*E.Error).Code)
+    })
+
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlOIDCStateD)).
+            WithArgs(ost.StateHash, ost.Provider).
+            WillReturnError(fmt.Errorf("connection lost"))
+
+        got, err := store.ConsumeState(ost.StateHash, ost.Provider)
+
+        assert.Nil(t, got)
+        assert.EqualValues(t, E.ErrDatabase, err.(*E.Error).Code)
+    })
+}
+
+// TestOIDCStoreGetIdentityUser will test GetIdentityUser method of oidc datastore
+func TestOIDCStoreGetIdentityUser(t *testing.T) {
+    mock := PrepareMock(t)
+    store := NewOIDCStore(mock)
+
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserIdentityR)).
+            WithArgs(ui.Provider, ui.Subject).
+            WillReturnRows(pgxmock.NewRows(uHeader).
+                AddRow(u[0].ID, u[0].Username, u[0].Firstname, u[0].Lastname, u[0].Email,
+                    u[0].StatusID, u[0].RoleID, u[0].CreatedAt, u[0].UpdatedAt))
+
+        got, err := store.GetIdentityUser(ui.Provider, ui.Subject)
+
+        assert.NoError(t, err)
+        assert.Equal(t, u[0].ID, got.ID)
+        assert.Equal(t, u[0].Email, got.Email)
+    })
+
+    t.Run("EXPECT FAIL not linked", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserIdentityR)).
+            WithArgs(ui.Provider, ui.Subject).
+            WillReturnError(pgx.ErrNoRows)
+
+        got, err := store.GetIdentityUser(ui.Provider, ui.Subject)
+
+        assert.Nil(t, got)
+        assert.EqualValues(t, E.ErrDataIsEmpty, err.(*E.Error).Code)
+    })
+
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserIdentityR)).
+            WithArgs(ui.Provider, ui.Subject).
+            WillReturnError(fmt.Errorf("connection lost"))
+
+        got, err := store.GetIdentityUser(ui.Provider, ui.Subject)
+
+        assert.Nil(t, got)
+        assert.EqualValues(t, E.ErrDatabase, err.(*E.Error).Code)
+    })
+}
+
+// TestOIDCStoreLinkIdentity will test LinkIdentity method of oidc datastore
+func TestOIDCStoreLinkIdentity(t *testing.T) {
+    mock := PrepareMock(t)
+    store := NewOIDCStore(mock)
+
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlUserIdentityC)).
+            WithArgs(ui.Provider, ui.Subject, ui.UserID, ui.Email).
+            WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+        assert.NoError(t, store.LinkIdentity(ui))
+    })
+
+    t.Run("EXPECT FAIL already linked", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlUserIdentityC)).
+            WithArgs(ui.Provider, ui.Subject, ui.UserID, ui.Email).
+            WillReturnError(&pgconn.PgError{Code: pgUniqueViolation, ConstraintName: "user_identity_pkey",
+                Detail: "Key (provider, subject)=(google, 1234) already exists."})
+
+        err := store.LinkIdentity(ui)
+        assert.Equal(t, E.NewConstraint(E.ErrUniqueViolation, "user_identity_pkey", "provider, subject"), err)
+    })
+
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlUserIdentityC)).
+            WithArgs(ui.Provider, ui.Subject, ui.UserID, ui.Email).
+            WillReturnError(fmt.Errorf("connection lost"))
+
+        err := store.LinkIdentity(ui)
+        assert.EqualValues(t, E.ErrDatabase, err.(*E.Error).Code)
+    })
+}
+
+// TestOIDCStoreCreateUser will test CreateUser method of oidc datastore
+func TestOIDCStoreCreateUser(t *testing.T) {
+    mock := PrepareMock(t)
+    store := NewOIDCStore(mock)
+    user := *u[0]
+
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserIdentityUserC)).
+            WithArgs(user.ID, user.Username, user.Firstname, user.Lastname, user.Email,
+                user.PassKey, user.StatusID, user.RoleID, ui.Provider, ui.Subject).
+            WillReturnRows(pgxmock.NewRows(uHeader).
+                AddRow(user.ID, user.Username, user.Firstname, user.Lastname, user.Email,
+                    user.StatusID, user.RoleID, user.CreatedAt, user.UpdatedAt))
+
+        got, err := store.CreateUser(user, ui)
+
+        assert.NoError(t, err)
+        assert.Equal(t, user.Username, got.Username)
+        assert.Empty(t, got.PassKey)
+    })
+
+    t.Run("EXPECT FAIL username already exist", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserIdentityUserC)).
+            WithArgs(user.ID, user.Username, user.Firstname, user.Lastname, user.Email,
+                user.PassKey, user.StatusID, user.RoleID, ui.Provider, ui.Subject).
+            WillReturnError(&pgconn.PgError{Code: pgUniqueViolation, ConstraintName: "users_username_key",
+                Detail: "Key (username)=(johndoe) already exists."})
+
+        got, err := store.CreateUser(user, ui)
+
+        assert.Nil(t, got)
+        assert.Equal(t, E.NewConstraint(E.ErrUniqueViolation, "users_username_key", "username"), err)
+    })
+
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserIdentityUserC)).
+            WithArgs(user.ID, user.Username, user.Firstname, user.Lastname, user.Email,
+                user.PassKey, user.StatusID, user.RoleID, ui.Provider, ui.Subject).
+            WillReturnError(fmt.Errorf("connection lost"))
+
+        got, err := store.CreateUser(user, ui)
+
+        assert.Nil(t, got)
+        assert.EqualValues(t, E.ErrDatabase, err.(*E.Error).Code)
+        assert.NoError(t, mock.ExpectationsWereMet())
+    })
+}
@@ -0,0 +1,311 @@
+/*
+   package datastore
+   auth_test.go
+   - test unit for auth datastore
+*/
+package datastore
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+    // tm is token metadata mock data
+    tm = d.TokenMetadata{
+        ID        : uuid.NewString(),
+        UserID    : uuid.New(),
+        FamilyID  : uuid.NewString(),
+        IssuedAt  : time.Now(),
+        ExpiresAt : time.Now().Add(time.Hour),
+    }
+
+    // sc is session client mock data
+    sc = d.NewSessionClient("Mozilla/5.0 (X11; Linux x86_64) Firefox/102.0", "203.0.113.7")
+)
+
+// TestAuthStoreRevokeToken will test RevokeToken method of auth datastore
+func TestAuthStoreRevokeToken(t *testing.T) {
+    // prepare mock and store
+    mock := PrepareMock(t)
+    store := NewAuthStore(mock)
+
+    // EXPECT SUCCESS is typical test simulation with expectation that
+    // the operation will run normally
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlRevokedTokenC)).
+            WithArgs(tm.ID, tm.ExpiresAt).
+            WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+        // actual method test
+        err := store.RevokeToken(tm)
+
+        // validation and verification
+        assert.NoError(t, err)
+    })
+
+    // EXPECT FAIL database error. Simulated by triggering E.ErrDatabase on mock
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlRevokedTokenC)).
+            WithArgs(tm.ID, tm.ExpiresAt).
+            WillReturnError(E.New(E.ErrDatabase))
+
+        // actual method test
+        err := store.RevokeToken(tm)
+
+        // validation and verification
+        assert.Error(t, err)
+        assert.Equal(t, E.New(E.ErrDatabase), err)
+    })
+}
+
+// TestAuthStoreIsTokenRevoked will test IsTokenRevoked method of auth datastore
+func TestAuthStoreIsTokenRevoked(t *testing.T) {
+    // prepare mock and store
+    mock := PrepareMock(t)
+    store := NewAuthStore(mock)
+
+    // EXPECT SUCCESS token is revoked
+    t.Run("EXPECT SUCCESS token revoked", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlRevokedTokenR1)).
+            WithArgs(tm.ID, tm.UserID, tm.IssuedAt, tm.FamilyID).
+            WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(1))
+
+        // actual method test
+        got, err := store.IsTokenRevoked(tm)
+
+        // validation and verification
+        assert.NoError(t, err)
+        assert.Equal(t, true, got)
+    })
+
+    // EXPECT SUCCESS token is not revoked
+    t.Run("EXPECT SUCCESS token not revoked", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlRevokedTokenR1)).
+            WithArgs(tm.ID, tm.UserID, tm.IssuedAt, tm.FamilyID).
+            WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(0))
+
+        // actual method test
+        got, err := store.IsTokenRevoked(tm)
+
+        // validation and verification
+        assert.NoError(t, err)
+        assert.Equal(t, false, got)
+    })
+
+    // EXPECT FAIL database error. Simulated by triggering E.ErrDatabase on mock
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlRevokedTokenR1)).
+            WithArgs(tm.ID, tm.UserID, tm.IssuedAt, tm.FamilyID).
+            WillReturnError(E.New(E.ErrDatabase))
+
+        // actual method test
+        got, err := store.IsTokenRevoked(tm)
+
+        // validation and verification
+        assert.Error(t, err)
+        assert.Equal(t, false, got)
+    })
+}
+
+// TestAuthStoreCreateRefreshToken will test CreateRefreshToken method of auth datastore
+func TestAuthStoreCreateRefreshToken(t *testing.T) {
+    // prepare mock and store
+    mock := PrepareMock(t)
+    store := NewAuthStore(mock)
+
+    // EXPECT SUCCESS is typical test simulation with expectation that
+    // the operation will run normally
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlRefreshTokenC)).
+            WithArgs(tm.ID, tm.FamilyID, tm.UserID, tm.ExpiresAt, sc.UserAgent, sc.IPAddress).
+            WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+        // actual method test
+        err := store.CreateRefreshToken(tm, sc)
+
+        // validation and verification
+        assert.NoError(t, err)
+    })
+
+    // EXPECT FAIL database error. Simulated by triggering E.ErrDatabase on mock
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlRefreshTokenC)).
+            WithArgs(tm.ID, tm.FamilyID, tm.UserID, tm.ExpiresAt, sc.UserAgent, sc.IPAddress).
+            WillReturnError(E.New(E.ErrDatabase))
+
+        // actual method test
+        err := store.CreateRefreshToken(tm, sc)
+
+        // validation and verification
+        assert.Error(t, err)
+        assert.Equal(t, E.New(E.ErrDatabase), err)
+    })
+}
+
+// TestAuthStoreRotateRefreshToken will test RotateRefreshToken method of auth datastore
+func TestAuthStoreRotateRefreshToken(t *testing.T) {
+    // prepare mock and store
+    mock := PrepareMock(t)
+    store := NewAuthStore(mock)
+
+    // EXPECT SUCCESS unused token is rotated
+    t.Run("EXPECT SUCCESS token rotated", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlRefreshTokenU)).
+            WithArgs(tm.ID, tm.FamilyID).
+            WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+        // actual method test
+        got, err := store.RotateRefreshToken(tm)
+
+        // validation and verification
+        assert.NoError(t, err)
+        assert.Equal(t, true, got)
+    })
+
+    // EXPECT SUCCESS used token is not rotated
+    t.Run("EXPECT SUCCESS token reused", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlRefreshTokenU)).
+            WithArgs(tm.ID, tm.FamilyID).
+            WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+        mock.ExpectQuery(regexp.QuoteMeta(sqlRefreshTokenR1)).
+            WithArgs(tm.ID, tm.FamilyID).
+            WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(1))
+
+        // actual method test
+        got, err := store.RotateRefreshToken(tm)
+
+        // validation and verification
+        assert.NoError(t, err)
+        assert.Equal(t, false, got)
+    })
+
+    // EXPECT FAIL token not found. Simulated by returning no recorded token
+    t.Run("EXPECT FAIL token not found", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlRefreshTokenU)).
+            WithArgs(tm.ID, tm.FamilyID).
+            WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+        mock.ExpectQuery(regexp.QuoteMeta(sqlRefreshTokenR1)).
+            WithArgs(tm.ID, tm.FamilyID).
+            WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(0))
+
+        // actual method test
+        got, err := store.RotateRefreshToken(tm)
+
+        // validation and verification
+        assert.Error(t, err)
+        assert.Equal(t, E.New(E.ErrDataIsEmpty), err)
+        assert.Equal(t, false, got)
+    })
+
+    // EXPECT FAIL database error on update. Simulated by triggering E.ErrDatabase on mock
+    t.Run("EXPECT FAIL database error on update", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlRefreshTokenU)).
+            WithArgs(tm.ID, tm.FamilyID).
+            WillReturnError(E.New(E.ErrDatabase))
+
+        // actual method test
+        got, err := store.RotateRefreshToken(tm)
+
+        // validation and verification
+        assert.Error(t, err)
+        assert.Equal(t, E.New(E.ErrDatabase), err)
+        assert.Equal(t, false, got)
+    })
+
+    // EXPECT FAIL database error on select. Simulated by triggering E.ErrDatabase on mock
+    t.Run("EXPECT FAIL database error on select", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlRefreshTokenU)).
+            WithArgs(tm.ID, tm.FamilyID).
+            WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+        mock.ExpectQuery(regexp.QuoteMeta(sqlRefreshTokenR1)).
+            WithArgs(tm.ID, tm.FamilyID).
+            WillReturnError(E.New(E.ErrDatabase))
+
+        // actual method test
+        got, err := store.RotateRefreshToken(tm)
+
+        // validation and verification
+        assert.Error(t, err)
+        assert.Equal(t, E.New(E.ErrDatabase), err)
+        assert.Equal(t, false, got)
+    })
+}
+
+// TestAuthStoreRevokeTokenFamily will test RevokeTokenFamily method of auth datastore
+func TestAuthStoreRevokeTokenFamily(t *testing.T) {
+    // prepare mock and store
+    mock := PrepareMock(t)
+    store := NewAuthStore(mock)
+
+    // EXPECT SUCCESS is typical test simulation with expectation that
+    // the operation will run normally
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlRefreshTokenFamilyD)).
+            WithArgs(tm.FamilyID).
+            WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+        // actual method test
+        err := store.RevokeTokenFamily(tm.FamilyID)
+
+        // validation and verification
+        assert.NoError(t, err)
+    })
+
+    // EXPECT FAIL database error. Simulated by triggering E.ErrDatabase on mock
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlRefreshTokenFamilyD)).
+            WithArgs(tm.FamilyID).
+            WillReturnError(E.New(E.ErrDatabase))
+
+        // actual method test
+        err := store.RevokeTokenFamily(tm.FamilyID)
+
+        // validation and verification
+        assert.Error(t, err)
+        assert.Equal(t, E.New(E.ErrDatabase), err)
+    })
+}
+
+// TestAuthStoreRevokeUserTokens will test RevokeUserTokens method of auth datastore
+func TestAuthStoreRevokeUserTokens(t *testing.T) {
+    // prepare mock and store
+    mock := PrepareMock(t)
+    store := NewAuthStore(mock)
+    revokedBefore := time.Now().UTC()
+
+    // EXPECT SUCCESS is typical test simulation with expectation that
+    // the operation will run normally
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlRevokedUserTokenC)).
+            WithArgs(tm.UserID, revokedBefore).
+            WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+        // actual method test
+        err := store.RevokeUserTokens(tm.UserID, revokedBefore)
+
+        // validation and verification
+        assert.NoError(t, err)
+    })
+
+    // EXPECT FAIL database error. Simulated by triggering E.ErrDatabase on mock
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlRevokedUserTokenC)).
+            WithArgs(tm.UserID, revokedBefore).
+            WillReturnError(E.New(E.ErrDatabase))
+
+        // actual method test
+        err := store.RevokeUserTokens(tm.UserID, revokedBefore)
+
+        // validation and verification
+        assert.Error(t, err)
+        assert.Equal(t, E.New(E.ErrDatabase), err)
+    })
+}
@@ -0,0 +1,105 @@
+/*
+   package datastore
+   list.go
+   - building filter, sort and pagination clause of the list (gets) query
+   NOTE of method:
+       * listClause type to collect the where condition and its argument
+       * likePrefix to escape prefix filter value
+*/
+package datastore
+
+import (
+	"fmt"
+	"strings"
+
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+)
+
+// likeEscaper is escaper of the LIKE pattern special character
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// listClause is where condition and its argument of the list query
+type listClause struct {
+    where []string
+    args  []interface{}
+}
+
+// newListClause will create list clause with the given condition that has no argument
+func newListClause(conds ...string) *listClause {
+    return &listClause{where: conds}
+}
+
+// add will add condition with one argument, the condition use '%d' as the argument position
+func (lc *listClause) add(cond string, arg interface{}) {
+    lc.args = append(lc.args, arg)
+    lc.where = append(lc.where, fmt.Sprintf(cond, len(lc.args)))
+}
+
+// addCreated will add filter of the record creation date range
+func (lc *listClause) addCreated(q d.ListQuery) {
+    if q.CreatedFrom != nil {
+        lc.add("created_at >= $%d", q.CreatedFrom.UTC())
+    }
+    if q.CreatedTo != nil {
+        lc.add("created_at < $%d", q.CreatedTo.UTC())
+    }
+}
+
+// after will add keyset condition to get the record after the cursor
+func (lc *listClause) after(column, order string, value, id interface{}) {
+    op := ">"
+    if order == d.SortDesc {
+        op = "<"
+    }
+
+    lc.args = append(lc.args, value, id)
+    lc.where = append(lc.where, fmt.Sprintf("(%s,id) %s ($%d,$%d)", column, op, len(lc.args)-1, len(lc.args)))
+}
+
+// String will build the where clause
+func (lc *listClause) String() string {
+    if len(lc.where) == 0 {
+        return ""
+    }
+
+    return " WHERE " + strings.Join(lc.where, " AND ")
+}
+
+// page will build the where, order and limit clause of the page. one more record than
+// the limit is requested to find out whether there is next page
+func (lc *listClause) page(column string, q d.ListQuery) string {
+    dir := "ASC"
+    if q.Order == d.SortDesc {
+        dir = "DESC"
+    }
+
+    clause := lc.String()
+    lc.args = append(lc.args, q.Limit+1)
+    clause += fmt.Sprintf(" ORDER BY %s %s,id %s LIMIT $%d", column, dir, dir, len(lc.args))
+    if offset := q.Offset(); offset > 0 {
+        lc.args = append(lc.args, offset)
+        clause += fmt.Sprintf(" OFFSET $%d", len(lc.args))
+    }
+
+    return clause
+}
+
+// listMeta will create list metadata of the query
+func listMeta(q d.ListQuery, total int64) *d.ListMeta {
+    meta := &d.ListMeta{
+        Total : total,
+        Limit : q.Limit,
+        Sort  : q.Sort,
+        Order : q.Order,
+    }
+    if q.Cursor == nil {
+        meta.Page = q.Page
+    }
+
+    return meta
+}
+
+// likePrefix will create LIKE pattern to match value starting with the prefix
+func likePrefix(prefix string) string {
+    return likeEscaper.Replace(prefix) + "%"
+}
@@ -0,0 +1,38 @@
+/*
+   package datastore (test)
+   - 'list' clause test unit
+*/
+package datastore
+
+import (
+	"testing"
+
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestLikePrefix will test escaping the LIKE pattern special character of the prefix
+func TestLikePrefix(t *testing.T) {
+    assert.Equal(t, "reshi%", likePrefix("reshi"))
+    assert.Equal(t, `50\%\_off\\%`, likePrefix(`50%_off\`))
+}
+
+// TestListClause will test building the list query clause
+func TestListClause(t *testing.T) {
+    t.Run("EXPECT SUCCESS without condition", func(t *testing.T){
+        lc := newListClause()
+        got := lc.page("id", d.ListQuery{Page: 2, Limit: 5, Sort: "id", Order: d.SortDesc})
+
+        assert.Equal(t, " ORDER BY id DESC,id DESC LIMIT $1 OFFSET $2", got)
+        assert.Equal(t, []interface{}{6, 5}, lc.args)
+    })
+
+    t.Run("EXPECT SUCCESS keyset condition", func(t *testing.T){
+        lc := newListClause("deleted_at IS NULL")
+        lc.add("role_id = $%d", 1)
+        lc.after("email", d.SortAsc, "a@mail.com", "id")
+
+        assert.Equal(t, " WHERE deleted_at IS NULL AND role_id = $1 AND (email,id) > ($2,$3)", lc.String())
+        assert.Equal(t, []interface{}{1, "a@mail.com", "id"}, lc.args)
+    })
+}
@@ -0,0 +1,45 @@
+/*
+   package datastore
+   patch.go
+   - building set clause of the partial update (merge patch) query
+   NOTE of method:
+       * patchSet to build the set clause of the patched column and its argument
+       * patchError to map error of applying the patch into the app error
+*/
+package datastore
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+)
+
+// patchSet will build set clause of the patched columns and its argument. fields is
+// pointer to the column value keyed by column name. the argument start from $2 since
+// $1 is reserved for the record id. cleared nullable column (d.NullString) is saved as NULL
+func patchSet(columns []string, fields map[string]interface{}) (string, []interface{}) {
+    set := make([]string, 0, len(columns)+1)
+    args := make([]interface{}, 0, len(columns))
+    for _, column := range columns {
+        args = append(args, reflect.ValueOf(fields[column]).Elem().Interface())
+        set = append(set, fmt.Sprintf("%s=$%d", column, len(args)+1))
+    }
+    set = append(set, "updated_at=CURRENT_TIMESTAMP")
+
+    return strings.Join(set, ","), args
+}
+
+// patchError will map error of applying the patch into the app error. null member of column
+// that require a value is E.ErrNotNullViolation on the member, any other error is E.ErrDataIsInvalid
+func patchError(err error) error {
+    var nullErr *d.MergePatchNullError
+    if errors.As(err, &nullErr) {
+        return E.NewConstraint(E.ErrNotNullViolation, "", nullErr.Member)
+    }
+
+    return E.New(E.ErrDataIsInvalid)
+}
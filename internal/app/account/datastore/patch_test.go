@@ -0,0 +1,58 @@
+/*
+   package datastore (test)
+   - 'patch' clause test unit
+*/
+package datastore
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"testing"
+
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPatchSet will test building the set clause of the patched column
+func TestPatchSet(t *testing.T) {
+    t.Run("EXPECT SUCCESS patched column", func(t *testing.T){
+        user := &d.User{Username: "reshi", Lastname: "Mahendra", RoleID: 2}
+        patch, err := d.DecodeMergePatch([]byte(`{"role_id":3,"lastname":null}`))
+        require.NoError(t, err)
+        columns, err := user.ApplyPatch(patch)
+        require.NoError(t, err)
+
+        set, args := patchSet(columns, user.PatchFields())
+
+        assert.Equal(t, "lastname=$2,role_id=$3,updated_at=CURRENT_TIMESTAMP", set)
+        assert.Equal(t, []interface{}{d.NullString(""), 3}, args)
+        assert.Equal(t, "reshi", user.Username)
+
+        // cleared nullable column is saved as NULL
+        value, err := args[0].(driver.Valuer).Value()
+        assert.NoError(t, err)
+        assert.Nil(t, value)
+    })
+
+    t.Run("EXPECT FAIL null on column that require a value", func(t *testing.T){
+        user := &d.User{Username: "reshi", RoleID: 2}
+        patch, err := d.DecodeMergePatch([]byte(`{"role_id":null}`))
+        require.NoError(t, err)
+
+        columns, err := user.ApplyPatch(patch)
+
+        assert.Nil(t, columns)
+        assert.Equal(t, E.NewConstraint(E.ErrNotNullViolation, "", "role_id"), patchError(err))
+        assert.Equal(t, 2, user.RoleID)
+        assert.Equal(t, E.New(E.ErrDataIsInvalid), patchError(fmt.Errorf("member 'id' can not be patched")))
+    })
+
+    t.Run("EXPECT SUCCESS without column", func(t *testing.T){
+        set, args := patchSet(nil, nil)
+
+        assert.Equal(t, "updated_at=CURRENT_TIMESTAMP", set)
+        assert.Empty(t, args)
+    })
+}
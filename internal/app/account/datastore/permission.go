@@ -0,0 +1,131 @@
+/*
+   package datastore
+   permission.go
+   - persistent/ datastore layer for permission catalogue and permission granted to user.role
+   NOTE of method:
+       * Gets method to get the permission catalogue
+       * GetsByRole method to get permission granted to user.role
+       * Grant method
+       * Revoke method
+*/
+package datastore
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/reshimahendra/lbw-go/internal/database"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/logger"
+)
+
+const (
+    // query to get the permission catalogue
+    sqlPermissionR = `SELECT code,description FROM public.permission ORDER BY code`
+
+    // query to get permission granted to user.role, deleted user.role has no permission
+    sqlRolePermissionR = `SELECT p.code,p.description FROM public.role_permission rp
+        JOIN public.permission p ON p.code=rp.permission_code
+        JOIN public.user_role r ON r.id=rp.role_id
+        WHERE rp.role_id = $1 AND r.deleted_at IS NULL ORDER BY p.code`
+
+    // query to grant permission to user.role. granting permission already granted keep the
+    // grant as is, no record is returned when the user.role or the permission is not found
+    sqlRolePermissionC = `INSERT INTO public.role_permission (role_id,permission_code)
+        SELECT r.id,p.code FROM public.user_role r, public.permission p
+        WHERE r.id = $1 AND r.deleted_at IS NULL AND p.code = $2
+        ON CONFLICT (role_id,permission_code) DO UPDATE SET granted_at=role_permission.granted_at
+        RETURNING role_id`
+
+    // query to revoke permission from user.role
+    sqlRolePermissionD = `DELETE FROM public.role_permission WHERE role_id = $1 AND permission_code = $2`
+)
+
+// IPermissionStore is permission interface for the permission catalogue and the
+// permission granted to user.role directly to the database
+type IPermissionStore interface {
+    // Gets will get all permission on the catalogue
+    Gets() ([]*d.Permission, error)
+
+    // GetsByRole will get all permission granted to user.role with the given id
+    GetsByRole(roleID int) ([]*d.Permission, error)
+
+    // Grant will grant the permission to user.role
+    Grant(roleID int, code string) error
+
+    // Revoke will revoke the permission from user.role
+    Revoke(roleID int, code string) error
+}
+
+// PermissionStore is instance wrapper for IDatabase interface
+type PermissionStore struct {
+    // DB is instance of IDatabase interface
+    DB database.IDatabase
+}
+
+// NewPermissionStore will create instance of PermissionStore
+func NewPermissionStore(iDB database.IDatabase) *PermissionStore {
+    return &PermissionStore{DB: iDB}
+}
+
+// Gets will get all permission on the catalogue
+func (st *PermissionStore) Gets() ([]*d.Permission, error) {
+    return st.query(sqlPermissionR)
+}
+
+// GetsByRole will get all permission granted to user.role with the given id. user.role
+// that is not found or deleted has no permission
+func (st *PermissionStore) GetsByRole(roleID int) ([]*d.Permission, error) {
+    return st.query(sqlRolePermissionR, roleID)
+}
+
+// query will execute the sql query and scan the permission record
+func (st *PermissionStore) query(sql string, args ...interface{}) ([]*d.Permission, error) {
+    results, err := st.DB.Query(context.Background(), sql, args...)
+    if err != nil {
+        logger.Errorf("permission.gets datastore fail: %v", err)
+        return nil, E.New(E.ErrDatabase)
+    }
+    defer results.Close()
+
+    // scanAllFunc is locate at datastore - user.go
+    perms := []*d.Permission{}
+    if err = scanAllFunc(&perms, results); err != nil {
+        logger.Errorf("permission.gets datastore fail: %v", err)
+        return nil, E.New(E.ErrDatabase)
+    }
+
+    return perms, nil
+}
+
+// Grant will grant the permission to user.role. user.role or permission that is not
+// found will return E.ErrDataIsEmpty
+func (st *PermissionStore) Grant(roleID int, code string) error {
+    var id int
+    err := st.DB.QueryRow(context.Background(), sqlRolePermissionC, roleID, code).Scan(&id)
+    if err == pgx.ErrNoRows {
+        return E.New(E.ErrDataIsEmpty)
+    } else if err != nil {
+        logger.Errorf("permission.grant datastore fail: %v", err)
+        return pgError(err)
+    }
+
+    return nil
+}
+
+// Revoke will revoke the permission from user.role. permission that is not granted
+// will return E.ErrDataIsEmpty
+func (st *PermissionStore) Revoke(roleID int, code string) error {
+    tag, err := st.DB.Exec(context.Background(), sqlRolePermissionD, roleID, code)
+    if err != nil {
+        logger.Errorf("permission.revoke datastore fail: %v", err)
+        return E.New(E.ErrDatabase)
+    }
+
+    if tag.RowsAffected() == 0 {
+        return E.New(E.ErrDataIsEmpty)
+    }
+
+    return nil
+}
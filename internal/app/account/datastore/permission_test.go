@@ -0,0 +1,173 @@
+/*
+   package datastore (test)
+   permission_test.go
+   - permission test unit
+*/
+package datastore
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/pashagolub/pgxmock"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+    permHeader = []string{"code","description"}
+    perms = []*d.Permission{
+        {Code: d.PermRoleRead, Description: "read user role"},
+        {Code: d.PermUserRead, Description: "read other user"},
+    }
+)
+
+// TestPermissionStoreGets will test getting the permission catalogue
+func TestPermissionStoreGets(t *testing.T) {
+    mock := PrepareMock(t)
+    store := NewPermissionStore(mock)
+
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlPermissionR)).
+            WillReturnRows(pgxmock.NewRows(permHeader).
+                AddRow(perms[0].Code, perms[0].Description).
+                AddRow(perms[1].Code, perms[1].Description),
+            )
+
+        got, err := store.Gets()
+
+        assert.NoError(t, err)
+        assert.Equal(t, perms, got)
+    })
+
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlPermissionR)).
+            WillReturnError(fmt.Errorf("connection lost"))
+
+        got, err := store.Gets()
+
+        assert.Error(t, err)
+        assert.Nil(t, got)
+    })
+}
+
+// TestPermissionStoreGetsByRole will test getting permission granted to user.role
+func TestPermissionStoreGetsByRole(t *testing.T) {
+    mock := PrepareMock(t)
+    store := NewPermissionStore(mock)
+
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlRolePermissionR)).
+            WithArgs(d.RoleAdministrator).
+            WillReturnRows(pgxmock.NewRows(permHeader).
+                AddRow(perms[1].Code, perms[1].Description),
+            )
+
+        got, err := store.GetsByRole(d.RoleAdministrator)
+
+        assert.NoError(t, err)
+        assert.Equal(t, perms[1:], got)
+    })
+
+    // EXPECT SUCCESS role without permission get empty list
+    t.Run("EXPECT SUCCESS no permission", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlRolePermissionR)).
+            WithArgs(d.RoleGuest).
+            WillReturnRows(pgxmock.NewRows(permHeader))
+
+        got, err := store.GetsByRole(d.RoleGuest)
+
+        assert.NoError(t, err)
+        assert.NotNil(t, got)
+        assert.Len(t, got, 0)
+    })
+
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlRolePermissionR)).
+            WithArgs(d.RoleGuest).
+            WillReturnError(fmt.Errorf("connection lost"))
+
+        got, err := store.GetsByRole(d.RoleGuest)
+
+        assert.Error(t, err)
+        assert.Nil(t, got)
+    })
+}
+
+// TestPermissionStoreGrant will test granting permission to user.role
+func TestPermissionStoreGrant(t *testing.T) {
+    mock := PrepareMock(t)
+    store := NewPermissionStore(mock)
+
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlRolePermissionC)).
+            WithArgs(d.RoleStaff, d.PermUserRead).
+            WillReturnRows(pgxmock.NewRows([]string{"role_id"}).AddRow(d.RoleStaff))
+
+        err := store.Grant(d.RoleStaff, d.PermUserRead)
+
+        assert.NoError(t, err)
+    })
+
+    // EXPECT FAIL role or permission is not found
+    t.Run("EXPECT FAIL not found", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlRolePermissionC)).
+            WithArgs(d.RoleStaff, "blog:write").
+            WillReturnError(pgx.ErrNoRows)
+
+        err := store.Grant(d.RoleStaff, "blog:write")
+
+        assert.EqualValues(t, E.ErrDataIsEmpty, err.(*E.Error).Code)
+    })
+
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlRolePermissionC)).
+            WithArgs(d.RoleStaff, d.PermUserRead).
+            WillReturnError(fmt.Errorf("connection lost"))
+
+        err := store.Grant(d.RoleStaff, d.PermUserRead)
+
+        assert.EqualValues(t, E.ErrDatabase, err.(*E.Error).Code)
+    })
+}
+
+// TestPermissionStoreRevoke will test revoking permission from user.role
+func TestPermissionStoreRevoke(t *testing.T) {
+    mock := PrepareMock(t)
+    store := NewPermissionStore(mock)
+
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlRolePermissionD)).
+            WithArgs(d.RoleStaff, d.PermUserRead).
+            WillReturnResult(pgxmock.NewResult("DELETE", 1))
+
+        err := store.Revoke(d.RoleStaff, d.PermUserRead)
+
+        assert.NoError(t, err)
+    })
+
+    // EXPECT FAIL permission is not granted
+    t.Run("EXPECT FAIL not granted", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlRolePermissionD)).
+            WithArgs(d.RoleStaff, d.PermUserWrite).
+            WillReturnResult(pgxmock.NewResult("DELETE", 0))
+
+        err := store.Revoke(d.RoleStaff, d.PermUserWrite)
+
+        assert.EqualValues(t, E.ErrDataIsEmpty, err.(*E.Error).Code)
+    })
+
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlRolePermissionD)).
+            WithArgs(d.RoleStaff, d.PermUserRead).
+            WillReturnError(fmt.Errorf("connection lost"))
+
+        err := store.Revoke(d.RoleStaff, d.PermUserRead)
+
+        assert.EqualValues(t, E.ErrDatabase, err.(*E.Error).Code)
+        assert.NoError(t, mock.ExpectationsWereMet())
+    })
+}
@@ -0,0 +1,102 @@
+/*
+   package datastore
+   pgerror.go
+   - reading postgres error code (SQLSTATE) of the failed sql command
+   NOTE of method:
+       * pgErrorCode to get the error code of postgres error
+       * pgError to map postgres error into the app error
+*/
+package datastore
+
+import (
+	"errors"
+	"regexp"
+
+	"github.com/jackc/pgconn"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+)
+
+const (
+    // pgUniqueViolation is postgres error code of unique constraint violation
+    pgUniqueViolation = "23505"
+
+    // pgForeignKeyViolation is postgres error code of foreign key constraint violation
+    pgForeignKeyViolation = "23503"
+
+    // pgCheckViolation is postgres error code of check constraint violation
+    pgCheckViolation = "23514"
+
+    // pgNotNullViolation is postgres error code of not null constraint violation
+    pgNotNullViolation = "23502"
+
+    // pgStringDataRightTruncation is postgres error code of string too long for its column
+    pgStringDataRightTruncation = "22001"
+
+    // pgInvalidTextRepresentation is postgres error code of value that can not be read
+    // as the column type, such as malformed uuid
+    pgInvalidTextRepresentation = "22P02"
+
+    // pgSerializationFailure is postgres error code of transaction that could not be
+    // serialized with the concurrent transaction
+    pgSerializationFailure = "40001"
+)
+
+// pgKeyField is pattern of the violated key on postgres error detail,
+// such as 'Key (email)=(john@mail.com) already exists.'
+var pgKeyField = regexp.MustCompile(`^Key \(([^)]+)\)=`)
+
+// pgErrorCode will get postgres error code of the error, it is empty when the error
+// is not returned by postgres
+func pgErrorCode(err error) string {
+    var pgErr *pgconn.PgError
+    if errors.As(err, &pgErr) {
+        return pgErr.Code
+    }
+
+    return ""
+}
+
+// pgError will map postgres error of the failed sql command into the app error. constraint
+// violation carry the violated constraint and field name, value not fitting its column is
+// E.ErrDataIsInvalid, serialization failure is E.ErrSerializationFailure and any other error
+// is E.ErrDatabase
+func pgError(err error) error {
+    var pgErr *pgconn.PgError
+    if !errors.As(err, &pgErr) {
+        return E.New(E.ErrDatabase)
+    }
+
+    var code uint
+    switch pgErr.Code {
+    case pgUniqueViolation:
+        code = E.ErrUniqueViolation
+    case pgForeignKeyViolation:
+        code = E.ErrForeignKeyViolation
+    case pgCheckViolation:
+        code = E.ErrCheckViolation
+    case pgNotNullViolation:
+        code = E.ErrNotNullViolation
+    case pgStringDataRightTruncation, pgInvalidTextRepresentation:
+        return E.New(E.ErrDataIsInvalid)
+    case pgSerializationFailure:
+        return E.New(E.ErrSerializationFailure)
+    default:
+        return E.New(E.ErrDatabase)
+    }
+
+    return E.NewConstraint(code, pgErr.ConstraintName, pgErrorField(pgErr))
+}
+
+// pgErrorField will get field name of the postgres error, it is taken from the violated
+// key on the error detail when postgres does not report the column
+func pgErrorField(pgErr *pgconn.PgError) string {
+    if pgErr.ColumnName != "" {
+        return pgErr.ColumnName
+    }
+
+    if m := pgKeyField.FindStringSubmatch(pgErr.Detail); m != nil {
+        return m[1]
+    }
+
+    return ""
+}
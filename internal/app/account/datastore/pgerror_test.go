@@ -0,0 +1,95 @@
+/*
+   package datastore (test)
+   - 'pgerror' test unit
+*/
+package datastore
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgconn"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPgError will test mapping postgres error into the app error
+func TestPgError(t *testing.T) {
+    cases := []struct{
+        name string
+        err  error
+        want error
+    }{
+        {
+            "EXPECT SUCCESS unique violation",
+            &pgconn.PgError{Code: pgUniqueViolation, ConstraintName: "users_username_key",
+                Detail: "Key (username)=(johndoe) already exists."},
+            E.NewConstraint(E.ErrUniqueViolation, "users_username_key", "username"),
+        },
+        {
+            "EXPECT SUCCESS foreign key violation",
+            &pgconn.PgError{Code: pgForeignKeyViolation, ConstraintName: "users_status_id_fkey",
+                Detail: `Key (status_id)=(7) is not present in table "user_status".`},
+            E.NewConstraint(E.ErrForeignKeyViolation, "users_status_id_fkey", "status_id"),
+        },
+        {
+            "EXPECT SUCCESS check violation with column",
+            &pgconn.PgError{Code: pgCheckViolation, ConstraintName: "users_email_check", ColumnName: "email",
+                Detail: "Failing row contains (...)."},
+            E.NewConstraint(E.ErrCheckViolation, "users_email_check", "email"),
+        },
+        {
+            "EXPECT SUCCESS check violation without column",
+            &pgconn.PgError{Code: pgCheckViolation, ConstraintName: "user_role_name_check"},
+            E.NewConstraint(E.ErrCheckViolation, "user_role_name_check", ""),
+        },
+        {
+            "EXPECT SUCCESS not null violation",
+            &pgconn.PgError{Code: pgNotNullViolation, ColumnName: "firstname"},
+            E.NewConstraint(E.ErrNotNullViolation, "", "firstname"),
+        },
+        {
+            "EXPECT SUCCESS string too long",
+            &pgconn.PgError{Code: pgStringDataRightTruncation},
+            E.New(E.ErrDataIsInvalid),
+        },
+        {
+            "EXPECT SUCCESS invalid text representation",
+            &pgconn.PgError{Code: pgInvalidTextRepresentation},
+            E.New(E.ErrDataIsInvalid),
+        },
+        {
+            "EXPECT SUCCESS serialization failure",
+            &pgconn.PgError{Code: pgSerializationFailure},
+            E.New(E.ErrSerializationFailure),
+        },
+        {
+            "EXPECT SUCCESS wrapped postgres error",
+            fmt.Errorf("insert: %w", &pgconn.PgError{Code: pgUniqueViolation, ConstraintName: "user_role_role_name_key"}),
+            E.NewConstraint(E.ErrUniqueViolation, "user_role_role_name_key", ""),
+        },
+        {
+            "EXPECT SUCCESS other postgres error",
+            &pgconn.PgError{Code: "57014"},
+            E.New(E.ErrDatabase),
+        },
+        {
+            "EXPECT SUCCESS non postgres error",
+            fmt.Errorf("connection lost"),
+            E.New(E.ErrDatabase),
+        },
+    }
+
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            assert.Equal(t, tt.want, pgError(tt.err))
+        })
+    }
+}
+
+// TestPgErrorCode will test getting postgres error code of the error
+func TestPgErrorCode(t *testing.T) {
+    assert.Equal(t, pgUniqueViolation, pgErrorCode(&pgconn.PgError{Code: pgUniqueViolation}))
+    assert.Empty(t, pgErrorCode(fmt.Errorf("connection lost")))
+    assert.Empty(t, pgErrorCode(nil))
+}
@@ -0,0 +1,97 @@
+/*
+   package datastore
+   user.activation.go
+   - datastore layer for user account activation
+   NOTE of method:
+       * Create method
+       * Activate method
+*/
+package datastore
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/reshimahendra/lbw-go/internal/database"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/logger"
+)
+
+const (
+    // query command to insert new activation token. any unused token of the user
+    // will be removed so only the latest token can be used
+    sqlUserActivationC = `WITH t AS (DELETE FROM public.user_activation WHERE user_id=$2 AND used_at IS NULL) INSERT INTO public.user_activation (token_hash,user_id,expires_at) VALUES ($1,$2,$3)`
+
+    // query command to mark the token as used and activate its user in one statement
+    sqlUserActivationU = `WITH t AS (UPDATE public.user_activation SET used_at=CURRENT_TIMESTAMP WHERE token_hash=$1 AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP RETURNING user_id) UPDATE public.users u SET status_id=1,activated_at=CURRENT_TIMESTAMP,updated_at=CURRENT_TIMESTAMP FROM t WHERE u.id=t.user_id AND u.status_id=0 AND u.deleted_at IS NULL RETURNING u.id,u.username,u.firstname,u.lastname,u.email,u.status_id,u.role_id,u.created_at,u.updated_at`
+)
+
+// IUserActivationStore is user.activation interface for account activation
+// operation directly to the database
+type IUserActivationStore interface {
+    // Create will insert new activation token and remove the unused old one
+    Create(input d.UserActivation) error
+
+    // Activate will use the activation token and activate its user
+    Activate(tokenHash string) (*d.User, error)
+}
+
+// UserActivationStore is instance wrapper for IDatabase interface
+type UserActivationStore struct {
+    // DB is IDatabase interface instance
+    DB database.IDatabase
+}
+
+// NewUserActivationStore will create instance of UserActivationStore
+func NewUserActivationStore(iDB database.IDatabase) *UserActivationStore {
+    return &UserActivationStore{DB: iDB}
+}
+
+// Create will insert new activation token record to database
+func (st *UserActivationStore) Create(input d.UserActivation) error {
+    // execute sql command to insert new activation token
+    _, err := st.DB.Exec(context.Background(), sqlUserActivationC,
+        input.TokenHash,
+        input.UserID,
+        input.ExpiresAt,
+    )
+    if err != nil {
+        logger.Errorf("user.activation.create datastore fail: %v", err)
+        return E.New(E.ErrDatabase)
+    }
+
+    return nil
+}
+
+// Activate will mark the activation token as used and activate the user.
+// unknown, used or expired token will return E.ErrDataIsEmpty
+func (st *UserActivationStore) Activate(tokenHash string) (*d.User, error) {
+    // execute sql command to activate user
+    result := st.DB.QueryRow(context.Background(), sqlUserActivationU, tokenHash)
+
+    // prepare to scan record data
+    user := new(d.User)
+    err := result.Scan(
+        &user.ID,
+        &user.Username,
+        &user.Firstname,
+        &user.Lastname,
+        &user.Email,
+        &user.StatusID,
+        &user.RoleID,
+        &user.CreatedAt,
+        &user.UpdatedAt,
+    )
+
+    // check if error occur during scan
+    if err == pgx.ErrNoRows{
+        logger.Errorf("user.activation.activate datastore fail: %v", err)
+        return nil, E.New(E.ErrDataIsEmpty)
+    } else if err != nil {
+        logger.Errorf("user.activation.activate datastore fail: %v", err)
+        return nil, E.New(E.ErrDatabase)
+    }
+
+    return user, nil
+}
@@ -0,0 +1,118 @@
+/*
+   package datastore
+   user.activation_test.go
+   - test unit for user.activation datastore
+*/
+package datastore
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/pashagolub/pgxmock"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+    // ua is user.activation mock data
+    ua = d.UserActivation{
+        TokenHash : "3c469e9d6c5875d37a43f353d4f88e61fcf812c66eee3457465a40b0da4153e0",
+        UserID    : u[0].ID,
+        ExpiresAt : time.Now().Add(24 * time.Hour),
+    }
+)
+
+// TestUserActivationStoreCreate will test Create method of user.activation datastore
+func TestUserActivationStoreCreate(t *testing.T) {
+    // prepare mock and store
+    mock := PrepareMock(t)
+    store := NewUserActivationStore(mock)
+
+    // EXPECT SUCCESS is typical test simulation with expectation that
+    // the operation will run normally
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlUserActivationC)).
+            WithArgs(ua.TokenHash, ua.UserID, ua.ExpiresAt).
+            WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+        // actual method test
+        err := store.Create(ua)
+
+        // validation and verification
+        assert.NoError(t, err)
+    })
+
+    // EXPECT FAIL database error. Simulated by triggering E.ErrDatabase on mock
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlUserActivationC)).
+            WithArgs(ua.TokenHash, ua.UserID, ua.ExpiresAt).
+            WillReturnError(E.New(E.ErrDatabase))
+
+        // actual method test
+        err := store.Create(ua)
+
+        // validation and verification
+        assert.Error(t, err)
+        assert.Equal(t, E.New(E.ErrDatabase), err)
+    })
+}
+
+// TestUserActivationStoreActivate will test Activate method of user.activation datastore
+func TestUserActivationStoreActivate(t *testing.T) {
+    // prepare mock and store
+    mock := PrepareMock(t)
+    store := NewUserActivationStore(mock)
+
+    // EXPECT SUCCESS is typical test simulation with expectation that
+    // the operation will run normally
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        rows := pgxmock.NewRows(uHeader).
+            AddRow(u[0].ID, u[0].Username, u[0].Firstname, u[0].Lastname, u[0].Email,
+                u[0].StatusID, u[0].RoleID, u[0].CreatedAt, u[0].UpdatedAt)
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserActivationU)).
+            WithArgs(ua.TokenHash).
+            WillReturnRows(rows)
+
+        // actual method test
+        got, err := store.Activate(ua.TokenHash)
+
+        // validation and verification
+        assert.NoError(t, err)
+        assert.Equal(t, u[0].ID, got.ID)
+        assert.Equal(t, u[0].StatusID, got.StatusID)
+    })
+
+    // EXPECT FAIL token invalid. Simulated by returning pgx.ErrNoRows
+    t.Run("EXPECT FAIL token invalid", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserActivationU)).
+            WithArgs(ua.TokenHash).
+            WillReturnError(pgx.ErrNoRows)
+
+        // actual method test
+        got, err := store.Activate(ua.TokenHash)
+
+        // validation and verification
+        assert.Error(t, err)
+        assert.Nil(t, got)
+        assert.Equal(t, E.New(E.ErrDataIsEmpty), err)
+    })
+
+    // EXPECT FAIL database error. Simulated by triggering E.ErrDatabase on mock
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserActivationU)).
+            WithArgs(ua.TokenHash).
+            WillReturnError(E.New(E.ErrDatabase))
+
+        // actual method test
+        got, err := store.Activate(ua.TokenHash)
+
+        // validation and verification
+        assert.Error(t, err)
+        assert.Nil(t, got)
+        assert.Equal(t, E.New(E.ErrDatabase), err)
+    })
+}
@@ -0,0 +1,148 @@
+/*
+   package datastore
+   user.apikey.go
+   - datastore layer for user api key (personal access token)
+   NOTE of method:
+       * Create method
+       * Gets method to get active api key of the user
+       * Revoke method
+       * Authenticate method to get the key owner and mark the key as used
+*/
+package datastore
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/reshimahendra/lbw-go/internal/database"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/logger"
+)
+
+const (
+    // query command to insert new api key
+    sqlUserAPIKeyC = `INSERT INTO public.user_api_key (id,user_id,name,prefix,key_hash,scopes,expires_at) VALUES ($1,$2,$3,$4,$5,$6,$7) RETURNING created_at`
+
+    // query to get api key of the user that is not revoked yet (expired key is included)
+    sqlUserAPIKeyR = `SELECT id,user_id,name,prefix,scopes,expires_at,last_used_at,created_at FROM public.user_api_key WHERE user_id = $1 AND revoked_at IS NULL ORDER BY created_at DESC`
+
+    // query command to revoke api key of the user
+    sqlUserAPIKeyD = `UPDATE public.user_api_key SET revoked_at=CURRENT_TIMESTAMP WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`
+
+    // query command to mark the api key as used and get its owner in one statement. revoked
+    // or expired key, and key of deleted user, return no record
+    sqlUserAPIKeyAuth = `UPDATE public.user_api_key k SET last_used_at=CURRENT_TIMESTAMP FROM public.users u WHERE k.key_hash = $1 AND k.revoked_at IS NULL AND (k.expires_at IS NULL OR k.expires_at > CURRENT_TIMESTAMP) AND u.id=k.user_id AND u.deleted_at IS NULL RETURNING k.id,k.scopes,u.id,u.email,u.role_id,u.status_id`
+)
+
+// IUserAPIKeyStore is user.apikey interface for api key operation directly to the database
+type IUserAPIKeyStore interface {
+    // Create will insert new api key
+    Create(input d.APIKey) (*d.APIKey, error)
+
+    // Gets will get api key of the user that is not revoked yet
+    Gets(userID uuid.UUID) ([]*d.APIKey, error)
+
+    // Revoke will revoke api key of the user
+    Revoke(userID, id uuid.UUID) error
+
+    // Authenticate will get the owner of the api key with given hash and mark the key as used
+    Authenticate(keyHash string) (*d.Principal, error)
+}
+
+// UserAPIKeyStore is instance wrapper for IDatabase interface
+type UserAPIKeyStore struct {
+    // DB is IDatabase interface instance
+    DB database.IDatabase
+}
+
+// NewUserAPIKeyStore will create instance of UserAPIKeyStore
+func NewUserAPIKeyStore(iDB database.IDatabase) *UserAPIKeyStore {
+    return &UserAPIKeyStore{DB: iDB}
+}
+
+// Create will insert new api key record to database
+func (st *UserAPIKeyStore) Create(input d.APIKey) (*d.APIKey, error) {
+    // execute sql command to insert new api key
+    err := st.DB.QueryRow(context.Background(), sqlUserAPIKeyC,
+        input.ID,
+        input.UserID,
+        input.Name,
+        input.Prefix,
+        input.KeyHash,
+        input.Scopes,
+        input.ExpiresAt,
+    ).Scan(&input.CreatedAt)
+    if err != nil {
+        logger.Errorf("user.apikey.create datastore fail: %v", err)
+        return nil, E.New(E.ErrDatabase)
+    }
+
+    return &input, nil
+}
+
+// Gets will get api key of the user that is not revoked yet
+func (st *UserAPIKeyStore) Gets(userID uuid.UUID) ([]*d.APIKey, error) {
+    results, err := st.DB.Query(context.Background(), sqlUserAPIKeyR, userID)
+    if err != nil {
+        logger.Errorf("user.apikey.gets datastore fail: %v", err)
+        return nil, E.New(E.ErrDatabase)
+    }
+    defer results.Close()
+
+    // scanAllFunc is locate at datastore - user.go
+    keys := []*d.APIKey{}
+    if err = scanAllFunc(&keys, results); err != nil {
+        logger.Errorf("user.apikey.gets datastore fail: %v", err)
+        return nil, E.New(E.ErrDatabase)
+    }
+
+    return keys, nil
+}
+
+// Revoke will revoke api key of the user. key that is not found or already
+// revoked will return E.ErrDataIsEmpty
+func (st *UserAPIKeyStore) Revoke(userID, id uuid.UUID) error {
+    tag, err := st.DB.Exec(context.Background(), sqlUserAPIKeyD, id, userID)
+    if err != nil {
+        logger.Errorf("user.apikey.revoke datastore fail: %v", err)
+        return E.New(E.ErrDatabase)
+    }
+
+    if tag.RowsAffected() == 0 {
+        return E.New(E.ErrDataIsEmpty)
+    }
+
+    return nil
+}
+
+// Authenticate will get the owner of the api key with given hash and mark the key as used.
+// unknown, revoked or expired key will return E.ErrDataIsEmpty
+func (st *UserAPIKeyStore) Authenticate(keyHash string) (*d.Principal, error) {
+    // execute sql command to mark the key as used
+    result := st.DB.QueryRow(context.Background(), sqlUserAPIKeyAuth, keyHash)
+
+    // prepare to scan record data
+    var keyID uuid.UUID
+    principal := new(d.Principal)
+    err := result.Scan(
+        &keyID,
+        &principal.Scopes,
+        &principal.UserID,
+        &principal.Email,
+        &principal.RoleID,
+        &principal.StatusID,
+    )
+
+    // check if error occur during scan
+    if err == pgx.ErrNoRows {
+        return nil, E.New(E.ErrDataIsEmpty)
+    } else if err != nil {
+        logger.Errorf("user.apikey.authenticate datastore fail: %v", err)
+        return nil, E.New(E.ErrDatabase)
+    }
+    principal.APIKeyID = keyID.String()
+
+    return principal, nil
+}
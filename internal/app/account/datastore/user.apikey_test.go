@@ -0,0 +1,182 @@
+/*
+   package datastore
+   user.apikey_test.go
+   - test unit for user.apikey datastore
+*/
+package datastore
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/pashagolub/pgxmock"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+    // ak is user.apikey mock data
+    ak = d.APIKey{
+        ID        : uuid.New(),
+        UserID    : u[0].ID,
+        Name      : "deploy bot",
+        Prefix    : "lbw_Zm9vYmFy",
+        KeyHash   : "3c469e9d6c5875d37a43f353d4f88e61fcf812c66eee3457465a40b0da4153e0",
+        Scopes    : []string{d.PermUserRead},
+        CreatedAt : time.Now(),
+    }
+    akHeader = []string{"id","user_id","name","prefix","scopes","expires_at","last_used_at","created_at"}
+)
+
+// TestUserAPIKeyStoreCreate will test Create method of user.apikey datastore
+func TestUserAPIKeyStoreCreate(t *testing.T) {
+    mock := PrepareMock(t)
+    store := NewUserAPIKeyStore(mock)
+
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserAPIKeyC)).
+            WithArgs(ak.ID, ak.UserID, ak.Name, ak.Prefix, ak.KeyHash, ak.Scopes, ak.ExpiresAt).
+            WillReturnRows(pgxmock.NewRows([]string{"created_at"}).AddRow(ak.CreatedAt))
+
+        got, err := store.Create(ak)
+
+        assert.NoError(t, err)
+        assert.Equal(t, ak, *got)
+    })
+
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserAPIKeyC)).
+            WithArgs(ak.ID, ak.UserID, ak.Name, ak.Prefix, ak.KeyHash, ak.Scopes, ak.ExpiresAt).
+            WillReturnError(fmt.Errorf("connection lost"))
+
+        got, err := store.Create(ak)
+
+        assert.EqualValues(t, E.ErrDatabase, err.(*E.Error).Code)
+        assert.Nil(t, got)
+    })
+}
+
+// TestUserAPIKeyStoreGets will test Gets method of user.apikey datastore
+func TestUserAPIKeyStoreGets(t *testing.T) {
+    mock := PrepareMock(t)
+    store := NewUserAPIKeyStore(mock)
+
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserAPIKeyR)).
+            WithArgs(ak.UserID).
+            WillReturnRows(pgxmock.NewRows(akHeader).
+                AddRow(ak.ID, ak.UserID, ak.Name, ak.Prefix, ak.Scopes, ak.ExpiresAt, ak.LastUsedAt, ak.CreatedAt),
+            )
+
+        got, err := store.Gets(ak.UserID)
+
+        want := ak
+        want.KeyHash = ""
+        assert.NoError(t, err)
+        assert.Equal(t, []*d.APIKey{&want}, got)
+    })
+
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserAPIKeyR)).
+            WithArgs(ak.UserID).
+            WillReturnError(fmt.Errorf("connection lost"))
+
+        got, err := store.Gets(ak.UserID)
+
+        assert.EqualValues(t, E.ErrDatabase, err.(*E.Error).Code)
+        assert.Nil(t, got)
+    })
+}
+
+// TestUserAPIKeyStoreRevoke will test Revoke method of user.apikey datastore
+func TestUserAPIKeyStoreRevoke(t *testing.T) {
+    mock := PrepareMock(t)
+    store := NewUserAPIKeyStore(mock)
+
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlUserAPIKeyD)).
+            WithArgs(ak.ID, ak.UserID).
+            WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+        err := store.Revoke(ak.UserID, ak.ID)
+
+        assert.NoError(t, err)
+    })
+
+    // EXPECT FAIL key is not found, owned by other user or already revoked
+    t.Run("EXPECT FAIL not found", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlUserAPIKeyD)).
+            WithArgs(ak.ID, ak.UserID).
+            WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+        err := store.Revoke(ak.UserID, ak.ID)
+
+        assert.EqualValues(t, E.ErrDataIsEmpty, err.(*E.Error).Code)
+    })
+
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlUserAPIKeyD)).
+            WithArgs(ak.ID, ak.UserID).
+            WillReturnError(fmt.Errorf("connection lost"))
+
+        err := store.Revoke(ak.UserID, ak.ID)
+
+        assert.EqualValues(t, E.ErrDatabase, err.(*E.Error).Code)
+    })
+}
+
+// TestUserAPIKeyStoreAuthenticate will test Authenticate method of user.apikey datastore
+func TestUserAPIKeyStoreAuthenticate(t *testing.T) {
+    mock := PrepareMock(t)
+    store := NewUserAPIKeyStore(mock)
+    header := []string{"id","scopes","user_id","email","role_id","status_id"}
+
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserAPIKeyAuth)).
+            WithArgs(ak.KeyHash).
+            WillReturnRows(pgxmock.NewRows(header).
+                AddRow(ak.ID, ak.Scopes, u[0].ID, u[0].Email, u[0].RoleID, u[0].StatusID),
+            )
+
+        got, err := store.Authenticate(ak.KeyHash)
+
+        assert.NoError(t, err)
+        assert.Equal(t, &d.Principal{
+            UserID   : u[0].ID,
+            Email    : u[0].Email,
+            RoleID   : u[0].RoleID,
+            StatusID : u[0].StatusID,
+            APIKeyID : ak.ID.String(),
+            Scopes   : ak.Scopes,
+        }, got)
+    })
+
+    // EXPECT FAIL key is unknown, revoked or expired
+    t.Run("EXPECT FAIL not found", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserAPIKeyAuth)).
+            WithArgs(ak.KeyHash).
+            WillReturnError(pgx.ErrNoRows)
+
+        got, err := store.Authenticate(ak.KeyHash)
+
+        assert.EqualValues(t, E.ErrDataIsEmpty, err.(*E.Error).Code)
+        assert.Nil(t, got)
+    })
+
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserAPIKeyAuth)).
+            WithArgs(ak.KeyHash).
+            WillReturnError(fmt.Errorf("connection lost"))
+
+        got, err := store.Authenticate(ak.KeyHash)
+
+        assert.EqualValues(t, E.ErrDatabase, err.(*E.Error).Code)
+        assert.Nil(t, got)
+        assert.NoError(t, mock.ExpectationsWereMet())
+    })
+}
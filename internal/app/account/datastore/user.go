@@ -6,15 +6,23 @@
        * Get method
        * Gets method
        * Update method
+       * UpdateProfile method
+       * Patch method
        * Delete method
+       * Restore method
+       * Purge method
+       * PurgeDeleted method
        * CheckCredential for login/signin operation
        * UserActivation method
        * UserExist method
+       * UpdatePassKey method
 */
 package datastore
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/georgysavva/scany/pgxscan"
 	"github.com/google/uuid"
@@ -22,25 +30,52 @@ import (
 	"github.com/reshimahendra/lbw-go/internal/database"
 	d "github.com/reshimahendra/lbw-go/internal/domain"
 	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/helper"
 	"github.com/reshimahendra/lbw-go/internal/pkg/logger"
 )
 
 const (
     // prepare sql command to insert new user record
-    sqlUserC = `INSERT INTO public.users (id,username,firstname,lastname,email,passkey,updated_at,user_status_id,user_role_id) VALUES ($1,$2,$3,$4,$5,$6,CURRENT_TIMESTAMP,$7,$8) RETURNING id,username,firstname,lastname,email,status_id,role_id,created_at,updated_at`
+    sqlUserC = `INSERT INTO public.users (id,username,firstname,lastname,email,passkey,updated_at,status_id,role_id) VALUES ($1,$2,$3,$4,$5,$6,CURRENT_TIMESTAMP,$7,$8) RETURNING id,username,firstname,lastname,email,status_id,role_id,created_at,updated_at`
     sqlUserR1 = `SELECT id,username,firstname,lastname,email,status_id,role_id,created_at,updated_at FROM public.users WHERE id = $1 AND deleted_at IS NULL`
-    sqlUserR = `SELECT id,username,firstname,lastname,email,status_id,role_id,created_at,updated_at FROM public.users WHERE deleted_at IS NULL ORDER BY created_at`
-    sqlUserU = `UPDATE public.users SET username=$2,firstname=$3,lastname=$4,email=$5,passkey=$6,status_id=$7,role_id=$8,updated_at=CURRENT_TIMESTAMP WHERE id=$1 RETURNING id,username,firstname,lastname,email,status_id,role_id,created_at,updated_at`
-    sqlUserD = `UPDATE public.users SET updated_at=CURRENT_TIMESTAMP,deleted_at=CURRENT_TIMESTAMP WHERE id=$1 RETURNING id, username,firstname,lastname,email,status_id,role_id,created_at,updated_at`
-    sqlGetUserByEmail = `SELECT id,username,passkey,status_id FROM public.users WHERE email=$1`
-    sqlCredentialR = `SELECT id,username,passkey,status_id FROM public.users WHERE username=$1 AND passkey=$2`
-    sqlIsUserExist = `SELECT COUNT(id) FROM public.users WHERE username=$1 OR email=$2`
+    sqlUserR = `SELECT id,username,firstname,lastname,email,status_id,role_id,created_at,updated_at FROM public.users`
+    sqlUserCount = `SELECT COUNT(id) FROM public.users`
+    // sql command to get (soft) deleted user record, the filter clause is added on query
+    sqlUserTrashR = `SELECT id,username,firstname,lastname,email,status_id,role_id,created_at,updated_at,deleted_at FROM public.users`
+    sqlUserU = `UPDATE public.users SET username=$2,firstname=$3,lastname=$4,email=$5,status_id=$6,role_id=$7,updated_at=CURRENT_TIMESTAMP WHERE id=$1 AND deleted_at IS NULL RETURNING id,username,firstname,lastname,email,status_id,role_id,created_at,updated_at`
+    // sql command to update only the patched column, the set clause is added on query
+    sqlUserPatchU = `UPDATE public.users SET %s WHERE id=$1 AND deleted_at IS NULL RETURNING id,username,firstname,lastname,email,status_id,role_id,created_at,updated_at`
+    sqlUserProfileU = `UPDATE public.users SET username=$2,firstname=$3,lastname=$4,email=$5,updated_at=CURRENT_TIMESTAMP WHERE id=$1 AND deleted_at IS NULL RETURNING id,username,firstname,lastname,email,status_id,role_id,created_at,updated_at`
+    sqlUserD = `UPDATE public.users SET updated_at=CURRENT_TIMESTAMP,deleted_at=CURRENT_TIMESTAMP WHERE id=$1 AND deleted_at IS NULL RETURNING id, username,firstname,lastname,email,status_id,role_id,created_at,updated_at`
+    sqlGetUserByEmail = `SELECT id,username,passkey,status_id,role_id FROM public.users WHERE email=$1 AND deleted_at IS NULL`
+    sqlCredentialR = `SELECT id,username,passkey,status_id,role_id FROM public.users WHERE username=$1 AND passkey=$2 AND deleted_at IS NULL`
+    // sql command to restore (soft) deleted user record
+    sqlUserRestoreU = `UPDATE public.users SET deleted_at=NULL,updated_at=CURRENT_TIMESTAMP WHERE id=$1 AND deleted_at IS NOT NULL RETURNING id,username,firstname,lastname,email,status_id,role_id,created_at,updated_at`
+    // sql command to permanently remove (soft) deleted user record. user still referenced by
+    // mail app membership is kept, the same way as the scheduled purge
+    sqlUserPurgeD = `WITH t AS (SELECT id, EXISTS (SELECT 1 FROM public.membership_mail_app m WHERE m.id=users.id) AS in_use FROM public.users WHERE id=$1 AND deleted_at IS NOT NULL), p AS (DELETE FROM public.users USING t WHERE users.id=t.id AND NOT t.in_use) SELECT in_use FROM t`
+    // sql command to permanently remove user deleted longer than the retention days. user
+    // still referenced by mail app membership is kept
+    sqlUserPurgeExpiredD = `DELETE FROM public.users WHERE deleted_at < CURRENT_TIMESTAMP - make_interval(days => $1) AND NOT EXISTS (SELECT 1 FROM public.membership_mail_app m WHERE m.id=users.id)`
+    sqlIsUserExist = `SELECT COUNT(id) FROM public.users WHERE (username=$1 OR email=$2) AND deleted_at IS NULL`
+    // sql command to replace the passkey with the same password hashed again, only when it is not changed meanwhile
+    sqlUserPassKeyU = `UPDATE public.users SET passkey=$3 WHERE id=$1 AND passkey=$2 AND deleted_at IS NULL`
 )
 
 var (
     // userScanAll is pgxscan.ScanAll func wrapper for user
     scanAllFunc = pgxscan.ScanAll
+
+    // userSortColumns is user column the list can be sorted by and its cursor value
+    userSortColumns = map[string]func(u *d.User) string{
+        "created_at" : func(u *d.User) string { return u.CreatedAt.UTC().Format(time.RFC3339Nano) },
+        "username"   : func(u *d.User) string { return u.Username },
+        "email"      : func(u *d.User) string { return u.Email },
+        "firstname"  : func(u *d.User) string { return u.Firstname },
+        "deleted_at" : func(u *d.User) string { return u.DeletedAt.UTC().Format(time.RFC3339Nano) },
+    }
 )
+
 // IUserStore is user interface for CRUD operation directly
 // to the database
 type IUserStore interface {
@@ -54,22 +89,42 @@ type IUserStore interface {
     // GetByEmail will get credential data by email from user record
     GetByEmail(email string) (*d.UserCredential, error)
 
-    // Gets will execute sql query to get all user record from database
-    Gets() ([]*d.User, error)
+    // Gets will execute sql query to get user record from database matching
+    // the list query along with the list metadata
+    Gets(q d.ListQuery) ([]*d.User, *d.ListMeta, error)
 
     // Update will execute sql query to update user record
     // based on given input id and input data 
     Update(id uuid.UUID, input d.User) (*d.User, error)
 
+    // UpdateProfile will execute sql query to update only the profile field
+    // (username, firstname, lastname, email) of user record
+    UpdateProfile(id uuid.UUID, input d.User) (*d.User, error)
+
+    // Patch will apply merge patch to user record and update only the patched field
+    Patch(id uuid.UUID, patch d.MergePatch) (*d.User, error)
+
     // Delete will do 'soft delete' instead of deleting the user record
     // from the database. Data should be persistant in the database
     Delete(id uuid.UUID) (*d.User, error)
+
+    // Restore will restore (soft) deleted user record
+    Restore(id uuid.UUID) (*d.User, error)
+
+    // Purge will permanently remove (soft) deleted user record
+    Purge(id uuid.UUID) error
+
+    // PurgeDeleted will permanently remove user record deleted longer than the retention days
+    PurgeDeleted(retentionDays int) (int64, error)
     
     // GetCredential will get credential data from user record
     GetCredential(username,passkey string) (*d.UserCredential, error)
 
     // IsUserExist will check whether username/ email is already exist
     IsUserExist(username,email string) (bool, error)
+
+    // UpdatePassKey will replace the passkey with the same password hashed again
+    UpdatePassKey(id uuid.UUID, oldPassKey, newPassKey string) error
 }
 
 // UserStore is instance wrapper for IDatabase interface
@@ -117,7 +172,7 @@ func (st *UserStore) Create(input d.User) (*d.User, error) {
         return nil, E.New(E.ErrDataIsEmpty)
     } else if err != nil {
         logger.Errorf("user.create datastore fail: %v", err)
-        return nil, E.New(E.ErrDatabase)
+        return nil, pgError(err)
     }
 
     return user, nil
@@ -154,13 +209,55 @@ func (st *UserStore) Get(id uuid.UUID) (*d.User, error) {
     return user, nil
 }
 
-// Gets will get all user data from database
-func (st *UserStore) Gets() ([]*d.User, error) {
+// Gets will get user data from database matching the list query filter, sorted and
+// paginated as requested. it also return the list metadata (total record and next cursor)
+func (st *UserStore) Gets(q d.ListQuery) ([]*d.User, *d.ListMeta, error) {
+    // sort field must be one of the known column as it is put into the sql command
+    cursorValue, ok := userSortColumns[q.Sort]
+    if !ok {
+        return nil, nil, E.New(E.ErrDataIsInvalid)
+    }
+
+    // prepare the filter, deleted user is listed from the trash
+    sqlSelect, lc := sqlUserR, newListClause("deleted_at IS NULL")
+    if q.Deleted {
+        sqlSelect, lc = sqlUserTrashR, newListClause("deleted_at IS NOT NULL")
+    }
+    if q.StatusID != nil {
+        lc.add("status_id = $%d", *q.StatusID)
+    }
+    if q.RoleID != nil {
+        lc.add("role_id = $%d", *q.RoleID)
+    }
+    lc.addCreated(q)
+    if q.Username != "" {
+        lc.add("username ILIKE $%d", likePrefix(q.Username))
+    }
+    if q.Email != "" {
+        lc.add("email ILIKE $%d", likePrefix(q.Email))
+    }
+
+    // count all user record matching the filter
+    var total int64
+    if err := st.DB.QueryRow(context.Background(), sqlUserCount+lc.String(), lc.args...).Scan(&total); err != nil {
+        logger.Errorf("user.gets datastore count fail: %v", err)
+        return nil, nil, E.New(E.ErrDatabase)
+    }
+
+    // keyset pagination continue after the last record of the previous page
+    if q.Cursor != nil {
+        value, id, err := parseUserCursor(q.Cursor)
+        if err != nil {
+            return nil, nil, E.New(E.ErrDataIsInvalid)
+        }
+        lc.after(q.Sort, q.Order, value, id)
+    }
+
     // execute sql command to retreive user record
-    results, err := st.DB.Query(context.Background(), sqlUserR)
+    results, err := st.DB.Query(context.Background(), sqlSelect+lc.page(q.Sort, q), lc.args...)
     if err != nil {
         logger.Errorf("user.gets datastore fail: %v", err)
-        return nil, E.New(E.ErrDataIsEmpty)
+        return nil, nil, E.New(E.ErrDataIsEmpty)
     }
     defer results.Close()
 
@@ -168,14 +265,46 @@ func (st *UserStore) Gets() ([]*d.User, error) {
     users := make([]*d.User, 0)
     if err = scanAllFunc(&users, results); err != nil { 
         logger.Errorf("user.gets datastore scan fail: %v", err)
-        return nil, E.New(E.ErrDatabase)
+        return nil, nil, E.New(E.ErrDatabase)
+    }
+
+    // the extra record mean there is next page, it start after the last record of this page
+    meta := listMeta(q, total)
+    if len(users) > q.Limit {
+        users = users[:q.Limit]
+        last := users[len(users)-1]
+        meta.NextCursor = (&d.ListCursor{
+            Sort  : q.Sort,
+            Order : q.Order,
+            Value : cursorValue(last),
+            ID    : last.ID.String(),
+        }).Encode()
     }
 
     // return user slice
-    return users, nil
+    return users, meta, nil
+}
+
+// parseUserCursor will convert cursor value into the sort column and id type
+func parseUserCursor(cursor *d.ListCursor) (interface{}, interface{}, error) {
+    id, err := uuid.Parse(cursor.ID)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    if cursor.Sort == "created_at" || cursor.Sort == "deleted_at" {
+        t, err := time.Parse(time.RFC3339Nano, cursor.Value)
+        if err != nil {
+            return nil, nil, err
+        }
+        return t.UTC(), id, nil
+    }
+
+    return cursor.Value, id, nil
 }
 
-// Update will update user based on given id
+// Update will update user based on given id. passkey is changed through user.password
+// datastore only
 func (st *UserStore) Update(id uuid.UUID, input d.User) (*d.User, error) {
     // execute sql command to update user record
     result := st.DB.QueryRow(context.Background(), sqlUserU,
@@ -184,7 +313,6 @@ func (st *UserStore) Update(id uuid.UUID, input d.User) (*d.User, error) {
         input.Firstname,
         input.Lastname,
         input.Email,
-        input.PassKey,
         input.StatusID,
         input.RoleID,
     )
@@ -209,7 +337,104 @@ func (st *UserStore) Update(id uuid.UUID, input d.User) (*d.User, error) {
         return nil, E.New(E.ErrDataIsEmpty)
     } else if err != nil {
         logger.Errorf("user.update datastore fail: %v", err)
-        return nil, E.New(E.ErrDatabase)
+        return nil, pgError(err)
+    }
+
+    return user, nil
+}
+
+// Patch will apply merge patch (RFC 7396) to user record based on given id. the merged
+// record must be valid and only the patched column are updated
+func (st *UserStore) Patch(id uuid.UUID, patch d.MergePatch) (*d.User, error) {
+    // get current record to merge the patch with
+    user, err := st.Get(id)
+    if err != nil {
+        return nil, err
+    }
+
+    // apply the patch and validate the merged record
+    columns, err := user.ApplyPatch(patch)
+    if err != nil {
+        logger.Errorf("user.patch datastore fail: %v", err)
+        return nil, patchError(err)
+    }
+    if !user.IsValidUpdate() {
+        return nil, E.New(E.ErrDataIsInvalid)
+    }
+    if err := helper.Validate(user.ConvertToRequest()); err != nil {
+        logger.Errorf("user.patch datastore fail: %v", err)
+        return nil, err
+    }
+
+    // nothing to update on empty patch
+    if len(columns) == 0 {
+        return user, nil
+    }
+
+    // execute sql command to update the patched column
+    set, args := patchSet(columns, user.PatchFields())
+    result := st.DB.QueryRow(context.Background(), fmt.Sprintf(sqlUserPatchU, set),
+        append([]interface{}{id}, args...)...)
+
+    // prepare to scan record data
+    patched := new(d.User)
+    err = result.Scan(
+        &patched.ID,
+        &patched.Username,
+        &patched.Firstname,
+        &patched.Lastname,
+        &patched.Email,
+        &patched.StatusID,
+        &patched.RoleID,
+        &patched.CreatedAt,
+        &patched.UpdatedAt,
+    )
+
+    // check if error occur during scan
+    if err == pgx.ErrNoRows{
+        logger.Errorf("user.patch datastore fail: %v", err)
+        return nil, E.New(E.ErrDataIsEmpty)
+    } else if err != nil {
+        logger.Errorf("user.patch datastore fail: %v", err)
+        return nil, pgError(err)
+    }
+
+    return patched, nil
+}
+
+// UpdateProfile will update profile field of user based on given id. status, role and
+// passkey are left untouched
+func (st *UserStore) UpdateProfile(id uuid.UUID, input d.User) (*d.User, error) {
+    // execute sql command to update user profile
+    result := st.DB.QueryRow(context.Background(), sqlUserProfileU,
+        id,
+        input.Username,
+        input.Firstname,
+        input.Lastname,
+        input.Email,
+    )
+
+    // prepare to scan record data
+    user := new(d.User)
+    err := result.Scan(
+        &user.ID,
+        &user.Username,
+        &user.Firstname,
+        &user.Lastname,
+        &user.Email,
+        &user.StatusID,
+        &user.RoleID,
+        &user.CreatedAt,
+        &user.UpdatedAt,
+    )
+
+    // check if error occur during scan
+    if err == pgx.ErrNoRows{
+        logger.Errorf("user.update_profile datastore fail: %v", err)
+        return nil, E.New(E.ErrDataIsEmpty)
+    } else if err != nil {
+        logger.Errorf("user.update_profile datastore fail: %v", err)
+        return nil, pgError(err)
     }
 
     return user, nil
@@ -246,6 +471,76 @@ func (st *UserStore) Delete(id uuid.UUID) (*d.User, error) {
     return user, nil
 }
 
+// Restore will restore (soft) deleted user based on given id. user whose username or
+// email is already taken by other user will return E.ErrUniqueViolation
+func (st *UserStore) Restore(id uuid.UUID) (*d.User, error) {
+    // execute sql command to restore user record
+    result := st.DB.QueryRow(context.Background(), sqlUserRestoreU, id)
+
+    // prepare to scan record data
+    user := new(d.User)
+    err := result.Scan(
+        &user.ID,
+        &user.Username,
+        &user.Firstname,
+        &user.Lastname,
+        &user.Email,
+        &user.StatusID,
+        &user.RoleID,
+        &user.CreatedAt,
+        &user.UpdatedAt,
+    )
+
+    // check if error occur during scan
+    if err == pgx.ErrNoRows{
+        logger.Errorf("user.restore datastore fail: %v", err)
+        return nil, E.New(E.ErrDataIsEmpty)
+    } else if err != nil {
+        logger.Errorf("user.restore datastore fail: %v", err)
+        return nil, pgError(err)
+    }
+
+    return user, nil
+}
+
+// Purge will permanently remove (soft) deleted user based on given id. user that is not
+// deleted will return E.ErrDataIsEmpty, user still referenced will return E.ErrDataIsInUse
+func (st *UserStore) Purge(id uuid.UUID) error {
+    // execute sql command to remove user record
+    var inUse bool
+    err := st.DB.QueryRow(context.Background(), sqlUserPurgeD, id).Scan(&inUse)
+    if err == pgx.ErrNoRows {
+        // no deleted record means the user is not found or not deleted
+        return E.New(E.ErrDataIsEmpty)
+    } else if pgErrorCode(err) == pgForeignKeyViolation {
+        logger.Errorf("user.purge datastore fail: %v", err)
+        return E.New(E.ErrDataIsInUse)
+    } else if err != nil {
+        logger.Errorf("user.purge datastore fail: %v", err)
+        return E.New(E.ErrDatabase)
+    }
+
+    // user still referenced by mail app membership is not removed
+    if inUse {
+        return E.New(E.ErrDataIsInUse)
+    }
+
+    return nil
+}
+
+// PurgeDeleted will permanently remove user deleted longer than the retention days
+// and return number of the removed user
+func (st *UserStore) PurgeDeleted(retentionDays int) (int64, error) {
+    // execute sql command to remove expired user record
+    tag, err := st.DB.Exec(context.Background(), sqlUserPurgeExpiredD, retentionDays)
+    if err != nil {
+        logger.Errorf("user.purgedeleted datastore fail: %v", err)
+        return 0, E.New(E.ErrDatabase)
+    }
+
+    return tag.RowsAffected(), nil
+}
+
 // GetCredential will get user credential data
 func (st *UserStore) GetCredential(username,passkey string) (*d.UserCredential, error) {
     cred := new(d.UserCredential)
@@ -254,6 +549,7 @@ func (st *UserStore) GetCredential(username,passkey string) (*d.UserCredential,
         &cred.Username,
         &cred.PassKey,
         &cred.StatusID,
+        &cred.RoleID,
     )
 
     // check if error occur during scan
@@ -276,6 +572,7 @@ func (st *UserStore) GetByEmail(email string) (*d.UserCredential, error) {
         &cred.Username,
         &cred.PassKey,
         &cred.StatusID,
+        &cred.RoleID,
     )
 
     // check if error occur during scan
@@ -308,3 +605,22 @@ func (st *UserStore) IsUserExist(username,email string) (bool, error) {
 
     return userCount >= 1, nil
 }
+
+// UpdatePassKey will replace the passkey of the user with the same password hashed again
+// (ex: with newer hash parameter). it is not password change, so the updated_at and the
+// password history is kept. changed passkey or unknown user will return E.ErrDataIsEmpty
+func (st *UserStore) UpdatePassKey(id uuid.UUID, oldPassKey, newPassKey string) error {
+    // execute sql command to replace the passkey
+    tag, err := st.DB.Exec(context.Background(), sqlUserPassKeyU, id, oldPassKey, newPassKey)
+    if err != nil {
+        logger.Errorf("user.updatepasskey datastore fail: %v", err)
+        return E.New(E.ErrDatabase)
+    }
+
+    // no updated record means the user is not found or its passkey is changed
+    if tag.RowsAffected() == 0 {
+        return E.New(E.ErrDataIsEmpty)
+    }
+
+    return nil
+}
@@ -0,0 +1,229 @@
+/*
+   package datastore
+   user.password.go
+   - datastore layer for user password reset
+   NOTE of method:
+       * Create method
+       * Reset method
+       * GetPassKey method
+       * Change method
+       * GetByToken method
+       * GetPassKeyHistory method
+*/
+package datastore
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/reshimahendra/lbw-go/internal/database"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/logger"
+)
+
+const (
+    // query command to insert new password reset token. any unused token of the user
+    // will be removed so only the latest token can be used
+    sqlUserPasswordResetC = `WITH t AS (DELETE FROM public.user_password_reset WHERE user_id=$2 AND used_at IS NULL) INSERT INTO public.user_password_reset (token_hash,user_id,expires_at) VALUES ($1,$2,$3)`
+
+    // query command to mark the token as used, keep the old passkey on the history, update its
+    // user passkey and revoke all outstanding auth token and api key of the user in one statement
+    sqlUserPasswordResetU = `WITH t AS (UPDATE public.user_password_reset SET used_at=CURRENT_TIMESTAMP WHERE token_hash=$1 AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP RETURNING user_id), h AS (INSERT INTO public.user_password_history (user_id,passkey) SELECT users.id,users.passkey FROM public.users JOIN t ON users.id=t.user_id WHERE users.deleted_at IS NULL), u AS (UPDATE public.users SET passkey=$2,updated_at=CURRENT_TIMESTAMP FROM t WHERE users.id=t.user_id AND users.deleted_at IS NULL RETURNING users.id,users.username,users.firstname,users.lastname,users.email,users.status_id,users.role_id,users.created_at,users.updated_at), r AS (INSERT INTO public.revoked_user_token (user_id,revoked_before) SELECT id,$3 FROM u ON CONFLICT (user_id) DO UPDATE SET revoked_before=EXCLUDED.revoked_before), k AS (UPDATE public.user_api_key SET revoked_at=CURRENT_TIMESTAMP FROM u WHERE user_api_key.user_id=u.id AND user_api_key.revoked_at IS NULL) SELECT id,username,firstname,lastname,email,status_id,role_id,created_at,updated_at FROM u`
+
+    // query command to get the hashed passkey of the user
+    sqlUserPassKeyR = `SELECT passkey FROM public.users WHERE id=$1 AND deleted_at IS NULL`
+
+    // query command to keep the old passkey on the history, update user passkey and revoke all
+    // of its api key and refresh token family except the one of the current session in one statement
+    sqlUserPasswordChangeU = `WITH h AS (INSERT INTO public.user_password_history (user_id,passkey) SELECT id,passkey FROM public.users WHERE id=$1 AND deleted_at IS NULL), u AS (UPDATE public.users SET passkey=$2,updated_at=CURRENT_TIMESTAMP WHERE id=$1 AND deleted_at IS NULL RETURNING id), f AS (UPDATE public.refresh_token_family SET revoked_at=CURRENT_TIMESTAMP FROM u WHERE refresh_token_family.user_id=u.id AND refresh_token_family.id IS DISTINCT FROM NULLIF($3,'')::uuid AND refresh_token_family.revoked_at IS NULL), k AS (UPDATE public.user_api_key SET revoked_at=CURRENT_TIMESTAMP FROM u WHERE user_api_key.user_id=u.id AND user_api_key.revoked_at IS NULL) SELECT COUNT(id) FROM u`
+
+    // query command to get the user of unused and unexpired password reset token
+    sqlUserPasswordResetUserR = `SELECT users.id,users.username,users.firstname,users.lastname,users.email,users.status_id,users.role_id,users.created_at,users.updated_at FROM public.user_password_reset JOIN public.users ON users.id=user_password_reset.user_id WHERE user_password_reset.token_hash=$1 AND user_password_reset.used_at IS NULL AND user_password_reset.expires_at > CURRENT_TIMESTAMP AND users.deleted_at IS NULL`
+
+    // query command to get the current and the previous hashed passkey of the user, latest first
+    sqlUserPassKeyHistoryR = `SELECT passkey FROM (SELECT passkey,'infinity'::timestamp AS created_at FROM public.users WHERE id=$1 AND deleted_at IS NULL UNION ALL SELECT passkey,created_at FROM public.user_password_history WHERE user_id=$1) p ORDER BY created_at DESC LIMIT $2`
+)
+
+// IUserPasswordStore is user.password interface for password reset
+// operation directly to the database
+type IUserPasswordStore interface {
+    // Create will insert new password reset token and remove the unused old one
+    Create(input d.UserPasswordReset) error
+
+    // Reset will use the password reset token to update its user passkey and
+    // revoke user auth token issued before 'revokedBefore'
+    Reset(tokenHash, passKey string, revokedBefore time.Time) (*d.User, error)
+
+    // GetPassKey will get the hashed passkey of the user
+    GetPassKey(userID uuid.UUID) (string, error)
+
+    // Change will update the user passkey and revoke its other session
+    Change(userID uuid.UUID, passKey, keepFamilyID string) error
+
+    // GetByToken will get the user of the password reset token without using the token
+    GetByToken(tokenHash string) (*d.User, error)
+
+    // GetPassKeyHistory will get the last 'limit' hashed passkey of the user, the current one included
+    GetPassKeyHistory(userID uuid.UUID, limit int) ([]string, error)
+}
+
+// UserPasswordStore is instance wrapper for IDatabase interface
+type UserPasswordStore struct {
+    // DB is IDatabase interface instance
+    DB database.IDatabase
+}
+
+// NewUserPasswordStore will create instance of UserPasswordStore
+func NewUserPasswordStore(iDB database.IDatabase) *UserPasswordStore {
+    return &UserPasswordStore{DB: iDB}
+}
+
+// Create will insert new password reset token record to database
+func (st *UserPasswordStore) Create(input d.UserPasswordReset) error {
+    // execute sql command to insert new password reset token
+    _, err := st.DB.Exec(context.Background(), sqlUserPasswordResetC,
+        input.TokenHash,
+        input.UserID,
+        input.ExpiresAt,
+    )
+    if err != nil {
+        logger.Errorf("user.password.create datastore fail: %v", err)
+        return E.New(E.ErrDatabase)
+    }
+
+    return nil
+}
+
+// Reset will mark the password reset token as used, update the user passkey and
+// revoke the user auth token and api key. unknown, used or expired token will return E.ErrDataIsEmpty
+func (st *UserPasswordStore) Reset(tokenHash, passKey string, revokedBefore time.Time) (*d.User, error) {
+    // execute sql command to reset user password
+    result := st.DB.QueryRow(context.Background(), sqlUserPasswordResetU,
+        tokenHash,
+        passKey,
+        revokedBefore,
+    )
+
+    // prepare to scan record data
+    user := new(d.User)
+    err := result.Scan(
+        &user.ID,
+        &user.Username,
+        &user.Firstname,
+        &user.Lastname,
+        &user.Email,
+        &user.StatusID,
+        &user.RoleID,
+        &user.CreatedAt,
+        &user.UpdatedAt,
+    )
+
+    // check if error occur during scan
+    if err == pgx.ErrNoRows{
+        logger.Errorf("user.password.reset datastore fail: %v", err)
+        return nil, E.New(E.ErrDataIsEmpty)
+    } else if err != nil {
+        logger.Errorf("user.password.reset datastore fail: %v", err)
+        return nil, E.New(E.ErrDatabase)
+    }
+
+    return user, nil
+}
+
+// GetPassKey will get the hashed passkey of the user with the given id
+func (st *UserPasswordStore) GetPassKey(userID uuid.UUID) (string, error) {
+    // execute sql command to get the user passkey
+    var passKey string
+    err := st.DB.QueryRow(context.Background(), sqlUserPassKeyR, userID).Scan(&passKey)
+
+    // check if error occur during scan
+    if err == pgx.ErrNoRows{
+        logger.Errorf("user.password.getpasskey datastore fail: %v", err)
+        return "", E.New(E.ErrDataIsEmpty)
+    } else if err != nil {
+        logger.Errorf("user.password.getpasskey datastore fail: %v", err)
+        return "", E.New(E.ErrDatabase)
+    }
+
+    return passKey, nil
+}
+
+// Change will update the user passkey and revoke every api key and refresh token family
+// of the user except 'keepFamilyID' (the session requesting the change). unknown or deleted
+// user will return E.ErrDataIsEmpty
+func (st *UserPasswordStore) Change(userID uuid.UUID, passKey, keepFamilyID string) error {
+    // execute sql command to change user password
+    var count int64
+    err := st.DB.QueryRow(context.Background(), sqlUserPasswordChangeU,
+        userID,
+        passKey,
+        keepFamilyID,
+    ).Scan(&count)
+    if err != nil {
+        logger.Errorf("user.password.change datastore fail: %v", err)
+        return E.New(E.ErrDatabase)
+    }
+
+    // no updated record means the user is not found
+    if count == 0 {
+        return E.New(E.ErrDataIsEmpty)
+    }
+
+    return nil
+}
+
+// GetByToken will get the user of the unused and unexpired password reset token, the token
+// stay unused. unknown, used or expired token will return E.ErrDataIsEmpty
+func (st *UserPasswordStore) GetByToken(tokenHash string) (*d.User, error) {
+    // execute sql command to get the user of the token
+    result := st.DB.QueryRow(context.Background(), sqlUserPasswordResetUserR, tokenHash)
+
+    // prepare to scan record data
+    user := new(d.User)
+    err := result.Scan(
+        &user.ID,
+        &user.Username,
+        &user.Firstname,
+        &user.Lastname,
+        &user.Email,
+        &user.StatusID,
+        &user.RoleID,
+        &user.CreatedAt,
+        &user.UpdatedAt,
+    )
+
+    // check if error occur during scan
+    if err == pgx.ErrNoRows{
+        logger.Errorf("user.password.getbytoken datastore fail: %v", err)
+        return nil, E.New(E.ErrDataIsEmpty)
+    } else if err != nil {
+        logger.Errorf("user.password.getbytoken datastore fail: %v", err)
+        return nil, E.New(E.ErrDatabase)
+    }
+
+    return user, nil
+}
+
+// GetPassKeyHistory will get the current and the previous hashed passkey of the user,
+// latest first, limited to 'limit' passkey
+func (st *UserPasswordStore) GetPassKeyHistory(userID uuid.UUID, limit int) ([]string, error) {
+    // execute sql command to get the passkey history
+    results, err := st.DB.Query(context.Background(), sqlUserPassKeyHistoryR, userID, limit)
+    if err != nil {
+        logger.Errorf("user.password.getpasskeyhistory datastore fail: %v", err)
+        return nil, E.New(E.ErrDatabase)
+    }
+    defer results.Close()
+
+    // scanAllFunc is locate at datastore - user.go
+    passKeys := []string{}
+    if err = scanAllFunc(&passKeys, results); err != nil {
+        logger.Errorf("user.password.getpasskeyhistory datastore fail: %v", err)
+        return nil, E.New(E.ErrDatabase)
+    }
+
+    return passKeys, nil
+}
@@ -0,0 +1,329 @@
+/*
+   package datastore
+   user.password_test.go
+   - test unit for user.password datastore
+*/
+package datastore
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/pashagolub/pgxmock"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+    // upr is user.password reset mock data
+    upr = d.UserPasswordReset{
+        TokenHash : "3c469e9d6c5875d37a43f353d4f88e61fcf812c66eee3457465a40b0da4153e0",
+        UserID    : u[0].ID,
+        ExpiresAt : time.Now().Add(time.Hour),
+    }
+)
+
+// TestUserPasswordStoreCreate will test Create method of user.password datastore
+func TestUserPasswordStoreCreate(t *testing.T) {
+    // prepare mock and store
+    mock := PrepareMock(t)
+    store := NewUserPasswordStore(mock)
+
+    // EXPECT SUCCESS is typical test simulation with expectation that
+    // the operation will run normally
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlUserPasswordResetC)).
+            WithArgs(upr.TokenHash, upr.UserID, upr.ExpiresAt).
+            WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+        // actual method test
+        err := store.Create(upr)
+
+        // validation and verification
+        assert.NoError(t, err)
+    })
+
+    // EXPECT FAIL database error. Simulated by triggering E.ErrDatabase on mock
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlUserPasswordResetC)).
+            WithArgs(upr.TokenHash, upr.UserID, upr.ExpiresAt).
+            WillReturnError(E.New(E.ErrDatabase))
+
+        // actual method test
+        err := store.Create(upr)
+
+        // validation and verification
+        assert.Error(t, err)
+        assert.Equal(t, E.New(E.ErrDatabase), err)
+    })
+}
+
+// TestUserPasswordStoreReset will test Reset method of user.password datastore
+func TestUserPasswordStoreReset(t *testing.T) {
+    // prepare mock and store
+    mock := PrepareMock(t)
+    store := NewUserPasswordStore(mock)
+    revokedBefore := time.Now()
+
+    // EXPECT SUCCESS is typical test simulation with expectation that
+    // the operation will run normally
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        rows := pgxmock.NewRows(uHeader).
+            AddRow(u[0].ID, u[0].Username, u[0].Firstname, u[0].Lastname, u[0].Email,
+                u[0].StatusID, u[0].RoleID, u[0].CreatedAt, u[0].UpdatedAt)
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserPasswordResetU)).
+            WithArgs(upr.TokenHash, "hashed", revokedBefore).
+            WillReturnRows(rows)
+
+        // actual method test
+        got, err := store.Reset(upr.TokenHash, "hashed", revokedBefore)
+
+        // validation and verification
+        assert.NoError(t, err)
+        assert.Equal(t, u[0].ID, got.ID)
+    })
+
+    // EXPECT FAIL token invalid. Simulated by returning pgx.ErrNoRows
+    t.Run("EXPECT FAIL token invalid", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserPasswordResetU)).
+            WithArgs(upr.TokenHash, "hashed", revokedBefore).
+            WillReturnError(pgx.ErrNoRows)
+
+        // actual method test
+        got, err := store.Reset(upr.TokenHash, "hashed", revokedBefore)
+
+        // validation and verification
+        assert.Error(t, err)
+        assert.Nil(t, got)
+        assert.Equal(t, E.New(E.ErrDataIsEmpty), err)
+    })
+
+    // EXPECT FAIL database error. Simulated by triggering E.ErrDatabase on mock
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserPasswordResetU)).
+            WithArgs(upr.TokenHash, "hashed", revokedBefore).
+            WillReturnError(E.New(E.ErrDatabase))
+
+        // actual method test
+        got, err := store.Reset(upr.TokenHash, "hashed", revokedBefore)
+
+        // validation and verification
+        assert.Error(t, err)
+        assert.Nil(t, got)
+        assert.Equal(t, E.New(E.ErrDatabase), err)
+    })
+}
+
+// TestUserPasswordStoreGetPassKey will test GetPassKey method of user.password datastore
+func TestUserPasswordStoreGetPassKey(t *testing.T) {
+    // prepare mock and store
+    mock := PrepareMock(t)
+    store := NewUserPasswordStore(mock)
+
+    // EXPECT SUCCESS is typical test simulation with expectation that
+    // the operation will run normally
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        rows := pgxmock.NewRows([]string{"passkey"}).AddRow("hashed")
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserPassKeyR)).
+            WithArgs(u[0].ID).
+            WillReturnRows(rows)
+
+        // actual method test
+        got, err := store.GetPassKey(u[0].ID)
+
+        // validation and verification
+        assert.NoError(t, err)
+        assert.Equal(t, "hashed", got)
+    })
+
+    // EXPECT FAIL user not found. Simulated by returning pgx.ErrNoRows
+    t.Run("EXPECT FAIL user not found", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserPassKeyR)).
+            WithArgs(u[0].ID).
+            WillReturnError(pgx.ErrNoRows)
+
+        // actual method test
+        got, err := store.GetPassKey(u[0].ID)
+
+        // validation and verification
+        assert.Error(t, err)
+        assert.Empty(t, got)
+        assert.Equal(t, E.New(E.ErrDataIsEmpty), err)
+    })
+
+    // EXPECT FAIL database error. Simulated by triggering E.ErrDatabase on mock
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserPassKeyR)).
+            WithArgs(u[0].ID).
+            WillReturnError(E.New(E.ErrDatabase))
+
+        // actual method test
+        got, err := store.GetPassKey(u[0].ID)
+
+        // validation and verification
+        assert.Error(t, err)
+        assert.Empty(t, got)
+        assert.Equal(t, E.New(E.ErrDatabase), err)
+    })
+}
+
+// TestUserPasswordStoreChange will test Change method of user.password datastore
+func TestUserPasswordStoreChange(t *testing.T) {
+    // prepare mock and store
+    mock := PrepareMock(t)
+    store := NewUserPasswordStore(mock)
+    familyID := "9b3a8f52-0c4e-4a0f-9a4e-6f7d1b2c3d4e"
+
+    // EXPECT SUCCESS is typical test simulation with expectation that
+    // the operation will run normally
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        rows := pgxmock.NewRows([]string{"count"}).AddRow(int64(1))
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserPasswordChangeU)).
+            WithArgs(u[0].ID, "hashed", familyID).
+            WillReturnRows(rows)
+
+        // actual method test
+        err := store.Change(u[0].ID, "hashed", familyID)
+
+        // validation and verification
+        assert.NoError(t, err)
+    })
+
+    // EXPECT FAIL user not found. Simulated by returning zero updated record
+    t.Run("EXPECT FAIL user not found", func(t *testing.T){
+        rows := pgxmock.NewRows([]string{"count"}).AddRow(int64(0))
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserPasswordChangeU)).
+            WithArgs(u[0].ID, "hashed", familyID).
+            WillReturnRows(rows)
+
+        // actual method test
+        err := store.Change(u[0].ID, "hashed", familyID)
+
+        // validation and verification
+        assert.Error(t, err)
+        assert.Equal(t, E.New(E.ErrDataIsEmpty), err)
+    })
+
+    // EXPECT FAIL database error. Simulated by triggering E.ErrDatabase on mock
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserPasswordChangeU)).
+            WithArgs(u[0].ID, "hashed", familyID).
+            WillReturnError(E.New(E.ErrDatabase))
+
+        // actual method test
+        err := store.Change(u[0].ID, "hashed", familyID)
+
+        // validation and verification
+        assert.Error(t, err)
+        assert.Equal(t, E.New(E.ErrDatabase), err)
+    })
+}
+
+// TestUserPasswordStoreGetByToken will test GetByToken method of user.password datastore
+func TestUserPasswordStoreGetByToken(t *testing.T) {
+    // prepare mock and store
+    mock := PrepareMock(t)
+    store := NewUserPasswordStore(mock)
+
+    // EXPECT SUCCESS is typical test simulation with expectation that
+    // the operation will run normally
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        rows := pgxmock.NewRows(uHeader).
+            AddRow(u[0].ID, u[0].Username, u[0].Firstname, u[0].Lastname, u[0].Email,
+                u[0].StatusID, u[0].RoleID, u[0].CreatedAt, u[0].UpdatedAt)
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserPasswordResetUserR)).
+            WithArgs(upr.TokenHash).
+            WillReturnRows(rows)
+
+        // actual method test
+        got, err := store.GetByToken(upr.TokenHash)
+
+        // validation and verification
+        assert.NoError(t, err)
+        assert.Equal(t, u[0].ID, got.ID)
+        assert.Equal(t, u[0].Username, got.Username)
+    })
+
+    // EXPECT FAIL token invalid. Simulated by returning pgx.ErrNoRows
+    t.Run("EXPECT FAIL token invalid", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserPasswordResetUserR)).
+            WithArgs(upr.TokenHash).
+            WillReturnError(pgx.ErrNoRows)
+
+        // actual method test
+        got, err := store.GetByToken(upr.TokenHash)
+
+        // validation and verification
+        assert.Nil(t, got)
+        assert.Equal(t, E.New(E.ErrDataIsEmpty), err)
+    })
+
+    // EXPECT FAIL database error. Simulated by triggering E.ErrDatabase on mock
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserPasswordResetUserR)).
+            WithArgs(upr.TokenHash).
+            WillReturnError(E.New(E.ErrDatabase))
+
+        // actual method test
+        got, err := store.GetByToken(upr.TokenHash)
+
+        // validation and verification
+        assert.Nil(t, got)
+        assert.Equal(t, E.New(E.ErrDatabase), err)
+    })
+}
+
+// TestUserPasswordStoreGetPassKeyHistory will test GetPassKeyHistory method of user.password datastore
+func TestUserPasswordStoreGetPassKeyHistory(t *testing.T) {
+    // prepare mock and store
+    mock := PrepareMock(t)
+    store := NewUserPasswordStore(mock)
+
+    // EXPECT SUCCESS is typical test simulation with expectation that
+    // the operation will run normally
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        rows := pgxmock.NewRows([]string{"passkey"}).AddRow("current").AddRow("previous")
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserPassKeyHistoryR)).
+            WithArgs(u[0].ID, 5).
+            WillReturnRows(rows)
+
+        // actual method test
+        got, err := store.GetPassKeyHistory(u[0].ID, 5)
+
+        // validation and verification
+        assert.NoError(t, err)
+        assert.Equal(t, []string{"current", "previous"}, got)
+    })
+
+    // EXPECT FAIL database error. Simulated by triggering E.ErrDatabase on mock
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserPassKeyHistoryR)).
+            WithArgs(u[0].ID, 5).
+            WillReturnError(E.New(E.ErrDatabase))
+
+        // actual method test
+        got, err := store.GetPassKeyHistory(u[0].ID, 5)
+
+        // validation and verification
+        assert.Nil(t, got)
+        assert.Equal(t, E.New(E.ErrDatabase), err)
+    })
+
+    // EXPECT FAIL scan error. Simulated by returning row with mismatch column
+    t.Run("EXPECT FAIL scan error", func(t *testing.T){
+        rows := pgxmock.NewRows([]string{"passkey", "created_at"}).AddRow("current", time.Now())
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserPassKeyHistoryR)).
+            WithArgs(u[0].ID, 5).
+            WillReturnRows(rows)
+
+        // actual method test
+        got, err := store.GetPassKeyHistory(u[0].ID, 5)
+
+        // validation and verification
+        assert.Nil(t, got)
+        assert.Equal(t, E.New(E.ErrDatabase), err)
+    })
+}
@@ -7,6 +7,9 @@ package datastore
 
 import (
 	"context"
+	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/jackc/pgx/v4"
 	d "github.com/reshimahendra/lbw-go/internal/domain"
@@ -22,19 +25,48 @@ const (
     // query command for user.role to get record by its 'id'
     sqlUserRoleR1 = `SELECT id,role_name,description,created_at,updated_at FROM public.user_role WHERE id = $1 AND deleted_at IS NULL`
 
-    // query command for user.role to get all record
-    sqlUserRoleR = `SELECT id,role_name,description,created_at,updated_at FROM public.user_role WHERE deleted_at IS NULL ORDER BY id`
+    // query command for user.role to get record, the filter, sort and pagination clause is added on query
+    sqlUserRoleR = `SELECT id,role_name,description,created_at,updated_at FROM public.user_role`
+
+    // query command for user.role to count record, the filter clause is added on query
+    sqlUserRoleCount = `SELECT COUNT(id) FROM public.user_role`
 
     // query command for user.role to update records based on its 'id' and given new record
     sqlUserRoleU = `UPDATE public.user_role SET 
         role_name=$2,description=$3,updated_at=CURRENT_TIMESTAMP WHERE id = $1 AND deleted_at IS NULL
         RETURNING id, role_name, description, created_at, updated_at;`
 
+    // query command for user.role to update only the patched column, the set clause is added on query
+    sqlUserRolePatchU = `UPDATE public.user_role SET %s WHERE id = $1 AND deleted_at IS NULL
+        RETURNING id, role_name, description, created_at, updated_at;`
+
     // query to 'soft' delete user.role
-    sqlUserRoleD = `UPDATE public.user_role SET deleted_at=CURRENT_TIMESTAMP WHERE id = $1
+    sqlUserRoleD = `UPDATE public.user_role SET deleted_at=CURRENT_TIMESTAMP WHERE id = $1 AND deleted_at IS NULL
+        RETURNING id, role_name, description, created_at, updated_at;`
+
+    // query command for user.role to get (soft) deleted record, the filter clause is added on query
+    sqlUserRoleTrashR = `SELECT id,role_name,description,created_at,updated_at,deleted_at FROM public.user_role`
+
+    // query to restore (soft) deleted user.role
+    sqlUserRoleRestoreU = `UPDATE public.user_role SET deleted_at=NULL,updated_at=CURRENT_TIMESTAMP WHERE id = $1 AND deleted_at IS NOT NULL
         RETURNING id, role_name, description, created_at, updated_at;`
+
+    // query to permanently remove (soft) deleted user.role. role still held by any user
+    // (including the deleted one) is kept and reported as in use
+    sqlUserRolePurgeD = `WITH r AS (SELECT id, EXISTS (SELECT 1 FROM public.users WHERE users.role_id=user_role.id) AS in_use FROM public.user_role WHERE id = $1 AND deleted_at IS NOT NULL), p AS (DELETE FROM public.user_role USING r WHERE user_role.id=r.id AND NOT r.in_use) SELECT in_use FROM r`
+
+    // query to permanently remove user.role deleted longer than the retention days and not held by any user
+    sqlUserRolePurgeExpiredD = `DELETE FROM public.user_role WHERE deleted_at < CURRENT_TIMESTAMP - make_interval(days => $1) AND NOT EXISTS (SELECT 1 FROM public.users WHERE users.role_id=user_role.id)`
 )
 
+// userRoleSortColumns is user.role column the list can be sorted by and its cursor value
+var userRoleSortColumns = map[string]func(ur *d.UserRole) string{
+    "id"         : func(ur *d.UserRole) string { return strconv.Itoa(ur.ID) },
+    "role_name"  : func(ur *d.UserRole) string { return ur.RoleName },
+    "created_at" : func(ur *d.UserRole) string { return ur.CreatedAt.UTC().Format(time.RFC3339Nano) },
+    "deleted_at" : func(ur *d.UserRole) string { return ur.DeletedAt.UTC().Format(time.RFC3339Nano) },
+}
+
 // IUserRoleStore is user.role interface for CRUD operation directly
 // to the database
 type IUserRoleStore interface {
@@ -45,16 +77,29 @@ type IUserRoleStore interface {
     // based on the given id
     Get(id int) (*d.UserRole, error)
 
-    // Gets will execute sql query to get all user record from database
-    Gets() ([]*d.UserRole, error)
+    // Gets will execute sql query to get user record from database matching
+    // the list query along with the list metadata
+    Gets(q d.ListQuery) ([]*d.UserRole, *d.ListMeta, error)
 
     // Update will execute sql query to update user record
     // based on given input id and input data 
     Update(id int, input d.UserRole) (*d.UserRole, error)
 
+    // Patch will apply merge patch to user.role record and update only the patched field
+    Patch(id int, patch d.MergePatch) (*d.UserRole, error)
+
     // Delete will do 'soft delete' instead of deleting the user record 
     // from the database. Data should be persistant in the database
     Delete(id int) (*d.UserRole, error)
+
+    // Restore will restore (soft) deleted user.role record
+    Restore(id int) (*d.UserRole, error)
+
+    // Purge will permanently remove (soft) deleted user.role record
+    Purge(id int) error
+
+    // PurgeDeleted will permanently remove user.role record deleted longer than the retention days
+    PurgeDeleted(retentionDays int) (int64, error)
 }
 
 // UserRoleStore is instance wrapper for IDatabase interface
@@ -90,7 +135,7 @@ func (st *UserRoleStore) Create(input d.UserRole) (*d.UserRole, error) {
     if err == pgx.ErrNoRows{
         return nil, E.New(E.ErrDataIsEmpty) 
     } else if err != nil {
-        return nil, E.New(E.ErrDatabase)
+        return nil, pgError(err)
     }
 
     // return user.role from the scanned variable (ur)
@@ -123,23 +168,86 @@ func (st *UserRoleStore) Get(id int) (*d.UserRole, error) {
     return ur, nil
 }
 
-// Gets will get all user.role record from the database
-func (st *UserRoleStore) Gets() ([]*d.UserRole, error) {
-    // execute sql command to get all user.role record
-    results, err := st.DB.Query(context.Background(), sqlUserRoleR)
-    if err == pgx.ErrNoRows{
-        return nil, E.New(E.ErrDatabase)
+// Gets will get user.role record from the database matching the list query filter,
+// sorted and paginated as requested along with the list metadata
+func (st *UserRoleStore) Gets(q d.ListQuery) ([]*d.UserRole, *d.ListMeta, error) {
+    // sort field must be one of the known column as it is put into the sql command
+    cursorValue, ok := userRoleSortColumns[q.Sort]
+    if !ok {
+        return nil, nil, E.New(E.ErrDataIsInvalid)
+    }
+
+    // prepare the filter and count all user.role record matching it, deleted
+    // user.role is listed from the trash
+    sqlSelect, lc := sqlUserRoleR, newListClause("deleted_at IS NULL")
+    if q.Deleted {
+        sqlSelect, lc = sqlUserRoleTrashR, newListClause("deleted_at IS NOT NULL")
+    }
+    lc.addCreated(q)
+
+    var total int64
+    if err := st.DB.QueryRow(context.Background(), sqlUserRoleCount+lc.String(), lc.args...).Scan(&total); err != nil {
+        return nil, nil, E.New(E.ErrDatabase)
+    }
+
+    // keyset pagination continue after the last record of the previous page
+    if q.Cursor != nil {
+        value, id, err := parseUserRoleCursor(q.Cursor)
+        if err != nil {
+            return nil, nil, E.New(E.ErrDataIsInvalid)
+        }
+        lc.after(q.Sort, q.Order, value, id)
+    }
+
+    // execute sql command to get user.role record
+    results, err := st.DB.Query(context.Background(), sqlSelect+lc.page(q.Sort, q), lc.args...)
+    if err != nil {
+        return nil, nil, E.New(E.ErrDatabase)
     }
     defer results.Close()
 
     // make new variable of user.role slice as a container for scanned result query operation
     urs := make([]*d.UserRole, 0)
     if err = scanAllFunc(&urs, results); err != nil {
-        return nil, E.New(E.ErrDatabase)
+        return nil, nil, E.New(E.ErrDatabase)
+    }
+
+    // the extra record mean there is next page, it start after the last record of this page
+    meta := listMeta(q, total)
+    if len(urs) > q.Limit {
+        urs = urs[:q.Limit]
+        last := urs[len(urs)-1]
+        meta.NextCursor = (&d.ListCursor{
+            Sort  : q.Sort,
+            Order : q.Order,
+            Value : cursorValue(last),
+            ID    : strconv.Itoa(last.ID),
+        }).Encode()
     }
 
     // return user.role slice(urs) if no error found
-    return urs, nil
+    return urs, meta, nil
+}
+
+// parseUserRoleCursor will convert cursor value into the sort column and id type
+func parseUserRoleCursor(cursor *d.ListCursor) (interface{}, interface{}, error) {
+    id, err := strconv.Atoi(cursor.ID)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    switch cursor.Sort {
+    case "id":
+        return id, id, nil
+    case "created_at", "deleted_at":
+        t, err := time.Parse(time.RFC3339Nano, cursor.Value)
+        if err != nil {
+            return nil, nil, err
+        }
+        return t.UTC(), id, nil
+    }
+
+    return cursor.Value, id, nil
 }
 
 // Update will update user.role record based it 'id' with given new record value
@@ -165,14 +273,65 @@ func (st *UserRoleStore) Update(id int, input d.UserRole) (*d.UserRole, error) {
     )
 
     // check if error occur while scanning record
-    if err != nil {
-        return nil, E.New(E.ErrDatabase)
+    if err == pgx.ErrNoRows {
+        return nil, E.New(E.ErrDataIsEmpty)
+    } else if err != nil {
+        return nil, pgError(err)
     }
 
     // return scanned user.role data
     return ur, nil
 }
 
+// Patch will apply merge patch (RFC 7396) to user.role record based on its 'id'. the
+// merged record must be valid and only the patched column are updated
+func (st *UserRoleStore) Patch(id int, patch d.MergePatch) (*d.UserRole, error) {
+    // get current record to merge the patch with
+    ur, err := st.Get(id)
+    if err != nil {
+        return nil, err
+    }
+
+    // apply the patch and validate the merged record
+    columns, err := ur.ApplyPatch(patch)
+    if err != nil {
+        return nil, patchError(err)
+    }
+    if !ur.IsValid() {
+        return nil, E.New(E.ErrDataIsInvalid)
+    }
+
+    // nothing to update on empty patch
+    if len(columns) == 0 {
+        return ur, nil
+    }
+
+    // execute sql command to update the patched column
+    set, args := patchSet(columns, ur.PatchFields())
+    result := st.DB.QueryRow(context.Background(), fmt.Sprintf(sqlUserRolePatchU, set),
+        append([]interface{}{id}, args...)...)
+
+    // prepare new user.role container as a return value and scan the query result
+    var patched = new(d.UserRole)
+    err = result.Scan(
+        &patched.ID,
+        &patched.RoleName,
+        &patched.Description,
+        &patched.CreatedAt,
+        &patched.UpdatedAt,
+    )
+
+    // check if error occur while scanning record
+    if err == pgx.ErrNoRows {
+        return nil, E.New(E.ErrDataIsEmpty)
+    } else if err != nil {
+        return nil, pgError(err)
+    }
+
+    // return scanned user.role data
+    return patched, nil
+}
+
 // Delete will 'soft' delete user role record data based on its given 'id'
 func (st *UserRoleStore) Delete(id int) (*d.UserRole, error) {
     // execute sql command to 'soft' delete user.role record
@@ -189,9 +348,69 @@ func (st *UserRoleStore) Delete(id int) (*d.UserRole, error) {
     )
 
     // check if error occur while scanning record
-    if err != nil {
+    if err == pgx.ErrNoRows {
+        return nil, E.New(E.ErrDataIsEmpty)
+    } else if err != nil {
         return nil, err 
     }
     
     return ur, nil
 }
+
+// Restore will restore (soft) deleted user.role record based on its 'id'. user.role whose
+// role name is already taken by other user.role will return E.ErrUniqueViolation
+func (st *UserRoleStore) Restore(id int) (*d.UserRole, error) {
+    // execute sql command to restore user.role record
+    result := st.DB.QueryRow(context.Background(), sqlUserRoleRestoreU, id)
+
+    // prepare new user.role container as a return value and scan the query result
+    ur := new(d.UserRole)
+    err := result.Scan(
+        &ur.ID,
+        &ur.RoleName,
+        &ur.Description,
+        &ur.CreatedAt,
+        &ur.UpdatedAt,
+    )
+
+    // check if error occur while scanning record
+    if err == pgx.ErrNoRows {
+        return nil, E.New(E.ErrDataIsEmpty)
+    } else if err != nil {
+        return nil, pgError(err)
+    }
+
+    return ur, nil
+}
+
+// Purge will permanently remove (soft) deleted user.role record based on its 'id'. user.role
+// that is not deleted will return E.ErrDataIsEmpty, user.role held by any user will return
+// E.ErrDataIsInUse
+func (st *UserRoleStore) Purge(id int) error {
+    // execute sql command to remove user.role record
+    var inUse bool
+    err := st.DB.QueryRow(context.Background(), sqlUserRolePurgeD, id).Scan(&inUse)
+    if err == pgx.ErrNoRows {
+        return E.New(E.ErrDataIsEmpty)
+    } else if err != nil {
+        return E.New(E.ErrDatabase)
+    }
+
+    if inUse {
+        return E.New(E.ErrDataIsInUse)
+    }
+
+    return nil
+}
+
+// PurgeDeleted will permanently remove user.role deleted longer than the retention days
+// and return number of the removed user.role
+func (st *UserRoleStore) PurgeDeleted(retentionDays int) (int64, error) {
+    // execute sql command to remove expired user.role record
+    tag, err := st.DB.Exec(context.Background(), sqlUserRolePurgeExpiredD, retentionDays)
+    if err != nil {
+        return 0, E.New(E.ErrDatabase)
+    }
+
+    return tag.RowsAffected(), nil
+}
@@ -5,10 +5,12 @@
 package datastore 
 
 import (
+	"fmt"
 	"regexp"
 	"testing"
 	"time"
 
+	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 	"github.com/pashagolub/pgxmock"
 	d "github.com/reshimahendra/lbw-go/internal/domain"
@@ -140,10 +142,15 @@ func TestUserRoleGet(t *testing.T) {
 func TestUserRoleGets(t *testing.T) {
     // prepare mock interface
     mock := PrepareMock(t)
+    q := d.ListQuery{Page: 1, Limit: 2, Sort: "id", Order: d.SortAsc}
+    countHeader := []string{"count"}
 
     t.Run("EXPECT SUCCESS", func(t *testing.T){
         // prepare mock
-        mock.ExpectQuery(regexp.QuoteMeta(sqlUserRoleR)).
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserRoleCount + " WHERE deleted_at IS NULL")).
+            WillReturnRows(pgxmock.NewRows(countHeader).AddRow(int64(3)))
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserRoleR + " WHERE deleted_at IS NULL ORDER BY id ASC,id ASC LIMIT $1")).
+            WithArgs(3).
             WillReturnRows(pgxmock.NewRows(urHeader).
                 AddRow(ur[0].ID,ur[1].RoleName,ur[0].Description,ur[0].CreatedAt,ur[0].UpdatedAt).
                 AddRow(ur[1].ID,ur[1].RoleName,ur[1].Description,ur[1].CreatedAt,ur[1].UpdatedAt).
@@ -152,19 +159,98 @@ func TestUserRoleGets(t *testing.T) {
 
         // actual test method/function call
         store := NewUserRoleStore(mock)
-        got, err := store.Gets()
+        got, meta, err := store.Gets(q)
 
         assert.NoError(t, err)
+        assert.Len(t, got, 2)
         assert.Equal(t, ur[1].RoleName, got[1].RoleName)
+        assert.Equal(t, int64(3), meta.Total)
+
+        cursor, err := d.DecodeListCursor(meta.NextCursor)
+        assert.NoError(t, err)
+        assert.Equal(t, &d.ListCursor{Sort: "id", Order: d.SortAsc, Value: "1", ID: "1"}, cursor)
+    })
+
+    // EXPECT SUCCESS list created in date range continue after the cursor
+    t.Run("EXPECT SUCCESS filter and cursor", func(t *testing.T){
+        from := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+        to := from.AddDate(0, 1, 0)
+        cq := d.ListQuery{
+            Page        : 1,
+            Limit       : 2,
+            Sort        : "created_at",
+            Order       : d.SortDesc,
+            Cursor      : &d.ListCursor{Sort: "created_at", Order: d.SortDesc, Value: "2022-01-15T10:00:00Z", ID: "2"},
+            CreatedFrom : &from,
+            CreatedTo   : &to,
+        }
+        filter := " WHERE deleted_at IS NULL AND created_at >= $1 AND created_at < $2"
+
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserRoleCount + filter)).
+            WithArgs(from, to).
+            WillReturnRows(pgxmock.NewRows(countHeader).AddRow(int64(2)))
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserRoleR + filter +
+            " AND (created_at,id) < ($3,$4) ORDER BY created_at DESC,id DESC LIMIT $5")).
+            WithArgs(from, to, time.Date(2022, 1, 15, 10, 0, 0, 0, time.UTC), 2, 3).
+            WillReturnRows(pgxmock.NewRows(urHeader).
+                AddRow(ur[0].ID,ur[0].RoleName,ur[0].Description,ur[0].CreatedAt,ur[0].UpdatedAt),
+            )
+
+        store := NewUserRoleStore(mock)
+        got, meta, err := store.Gets(cq)
+
+        assert.NoError(t, err)
+        assert.Len(t, got, 1)
+        assert.Empty(t, meta.NextCursor)
+    })
+
+    // EXPECT FAIL sort field is not user.role column
+    t.Run("EXPECT FAIL unknown sort field", func(t *testing.T){
+        store := NewUserRoleStore(mock)
+        got, _, err := store.Gets(d.ListQuery{Page: 1, Limit: 2, Sort: "description", Order: d.SortAsc})
+
+        assert.Error(t, err)
+        assert.Equal(t, E.New(E.ErrDataIsInvalid), err)
+        assert.Nil(t, got)
+    })
+
+    // EXPECT FAIL cursor id is not number
+    t.Run("EXPECT FAIL invalid cursor", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserRoleCount)).
+            WillReturnRows(pgxmock.NewRows(countHeader).AddRow(int64(3)))
+
+        cq := q
+        cq.Cursor = &d.ListCursor{Sort: "id", Order: d.SortAsc, Value: "one", ID: "one"}
+        store := NewUserRoleStore(mock)
+        got, _, err := store.Gets(cq)
+
+        assert.Error(t, err)
+        assert.Equal(t, E.New(E.ErrDataIsInvalid), err)
+        assert.Nil(t, got)
+    })
+
+    // EXPECT FAIL count error simulated by returning error from the mock
+    t.Run("EXPECT FAIL count error", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserRoleCount)).
+            WillReturnError(pgx.ErrTxClosed)
+
+        store := NewUserRoleStore(mock)
+        got, _, err := store.Gets(q)
+
+        assert.Error(t, err)
+        assert.Equal(t, E.New(E.ErrDatabase), err)
+        assert.Nil(t, got)
     })
 
     // EXPECT FAIL no data simulate to get all user.role data with no row return 
     t.Run("EXPECT FAIL data is empty", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserRoleCount)).
+            WillReturnRows(pgxmock.NewRows(countHeader).AddRow(int64(3)))
         mock.ExpectQuery(regexp.QuoteMeta(sqlUserRoleR)).
             WillReturnError(pgx.ErrNoRows)
 
         store := NewUserRoleStore(mock)
-        got, err := store.Gets()
+        got, _, err := store.Gets(q)
 
         assert.Error(t, err)
         assert.Equal(t, E.New(E.ErrDatabase), err)
@@ -184,6 +270,8 @@ func TestUserRoleGets(t *testing.T) {
         }()
 
         // prepare mock query
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserRoleCount)).
+            WillReturnRows(pgxmock.NewRows(countHeader).AddRow(int64(3)))
         mock.ExpectQuery(regexp.QuoteMeta(sqlUserRoleR)).
             WillReturnRows(pgxmock.NewRows(urHeader).
                 AddRow(ur[0].ID,ur[1].RoleName,ur[0].Description,ur[0].CreatedAt,ur[0].UpdatedAt).
@@ -193,13 +281,12 @@ func TestUserRoleGets(t *testing.T) {
 
         // actual method test
         store := NewUserRoleStore(mock)
-        got, err := store.Gets()
+        got, _, err := store.Gets(q)
 
         // test validation and verification
         assert.Error(t, err)
         assert.Nil(t, got)
     })
-
 }
 
 // TestUserRoleUpdate will test behaviour of user.role Delete method
@@ -255,9 +342,135 @@ func TestUserRoleUpdate(t *testing.T) {
         assert.Nil(t, got)
     })
 
+    cases := []struct{
+        name    string
+        mockErr error
+        want    int
+    }{
+        {"EXPECT FAIL role is not found", pgx.ErrNoRows, E.ErrDataIsEmpty},
+        {"EXPECT FAIL role name is taken", &pgconn.PgError{Code: pgUniqueViolation}, E.ErrUniqueViolation},
+        {"EXPECT FAIL concurrent update", &pgconn.PgError{Code: pgSerializationFailure}, E.ErrSerializationFailure},
+    }
+
+    // the unused expectation of data invalid case is left on the previous mock
+    mock = PrepareMock(t)
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            mock.ExpectQuery(regexp.QuoteMeta(sqlUserRoleU)).
+                WithArgs(ur[1].ID,ur[1].RoleName,ur[1].Description).
+                WillReturnError(tt.mockErr)
+
+            store := NewUserRoleStore(mock)
+            got, err := store.Update(ur[1].ID, *ur[1])
+
+            assert.Nil(t, got)
+            assert.EqualValues(t, tt.want, E.Code(err))
+        })
+    }
 }
 
 
+// TestUserRolePatch will test user.role Patch method behaviour
+func TestUserRolePatch(t *testing.T) {
+    // prepare mock interface
+    mock := PrepareMock(t)
+    store := NewUserRoleStore(mock)
+
+    // expectGet will expect the current record is requested to merge the patch with
+    expectGet := func() {
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserRoleR1)).
+            WithArgs(ur[2].ID).
+            WillReturnRows(pgxmock.NewRows(urHeader).
+                AddRow(ur[2].ID,ur[2].RoleName,ur[2].Description,ur[2].CreatedAt,ur[2].UpdatedAt),
+            )
+    }
+    sqlPatch := fmt.Sprintf(sqlUserRolePatchU, "description=$2,updated_at=CURRENT_TIMESTAMP")
+    patch := d.MergePatch{"description": []byte(`"Administrator role"`)}
+
+    // EXPECT SUCCESS only the patched column is sent to the database
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        expectGet()
+        mock.ExpectQuery(regexp.QuoteMeta(sqlPatch)).
+            WithArgs(ur[2].ID, d.NullString("Administrator role")).
+            WillReturnRows(pgxmock.NewRows(urHeader).
+                AddRow(ur[2].ID,ur[2].RoleName,d.NullString("Administrator role"),ur[2].CreatedAt,ur[2].UpdatedAt),
+            )
+
+        got, err := store.Patch(ur[2].ID, patch)
+
+        assert.NoError(t, err)
+        assert.Equal(t, ur[2].RoleName, got.RoleName)
+        assert.Equal(t, d.NullString("Administrator role"), got.Description)
+    })
+
+    // EXPECT FAIL data empty error. Simulated by triggering pgx.ErrNoRows on getting the record
+    t.Run("EXPECT FAIL data is empty error", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserRoleR1)).
+            WithArgs(ur[2].ID).
+            WillReturnError(pgx.ErrNoRows)
+
+        got, err := store.Patch(ur[2].ID, patch)
+
+        assert.Error(t, err)
+        assert.Equal(t, E.New(E.ErrDataIsEmpty), err)
+        assert.Nil(t, got)
+    })
+
+    // EXPECT SUCCESS null description is saved as NULL
+    t.Run("EXPECT SUCCESS clear description", func(t *testing.T){
+        expectGet()
+        mock.ExpectQuery(regexp.QuoteMeta(sqlPatch)).
+            WithArgs(ur[2].ID, d.NullString("")).
+            WillReturnRows(pgxmock.NewRows(urHeader).
+                AddRow(ur[2].ID,ur[2].RoleName,nil,ur[2].CreatedAt,ur[2].UpdatedAt),
+            )
+
+        got, err := store.Patch(ur[2].ID, d.MergePatch{"description": []byte(`null`)})
+
+        assert.NoError(t, err)
+        assert.Equal(t, d.NullString(""), got.Description)
+    })
+
+    // EXPECT FAIL not null violation. Simulated by clearing the role name
+    t.Run("EXPECT FAIL not null violation", func(t *testing.T){
+        expectGet()
+
+        got, err := store.Patch(ur[2].ID, d.MergePatch{"role_name": []byte(`null`)})
+
+        assert.Nil(t, got)
+        assert.Equal(t, E.NewConstraint(E.ErrNotNullViolation, "", "role_name"), err)
+    })
+
+    // EXPECT FAIL data invalid. Simulated by emptying the role name and patching the id
+    for _, p := range []d.MergePatch{{"role_name": []byte(`""`)}, {"id": []byte(`5`)}} {
+        t.Run("EXPECT FAIL data invalid", func(t *testing.T){
+            expectGet()
+
+            got, err := store.Patch(ur[2].ID, p)
+
+            assert.Error(t, err)
+            assert.Equal(t, E.New(E.ErrDataIsInvalid), err)
+            assert.Nil(t, got)
+        })
+    }
+
+    // EXPECT FAIL database error. Simulated by triggering E.ErrDatabase on mock
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        expectGet()
+        mock.ExpectQuery(regexp.QuoteMeta(sqlPatch)).
+            WithArgs(ur[2].ID, d.NullString("Administrator role")).
+            WillReturnError(E.New(E.ErrDatabase))
+
+        got, err := store.Patch(ur[2].ID, patch)
+
+        assert.Error(t, err)
+        assert.Equal(t, E.New(E.ErrDatabase), err)
+        assert.Nil(t, got)
+    })
+
+    assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 // TestUserRoleDelete will test user.role Delete method behaviour
 func TestUserRoleDelete(t *testing.T) {
     // prepare mock interface
@@ -300,3 +513,159 @@ func TestUserRoleDelete(t *testing.T) {
     })
 
 }
+
+// TestUserRoleGetsTrash will test listing (soft) deleted user.role from the trash
+func TestUserRoleGetsTrash(t *testing.T) {
+    // prepare mock interface
+    mock := PrepareMock(t)
+    q := d.ListQuery{Page: 1, Limit: 2, Sort: "deleted_at", Order: d.SortAsc, Deleted: true}
+    deletedAt := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        // prepare mock
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserRoleCount + " WHERE deleted_at IS NOT NULL")).
+            WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(int64(1)))
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserRoleTrashR + " WHERE deleted_at IS NOT NULL ORDER BY deleted_at ASC,id ASC LIMIT $1")).
+            WithArgs(3).
+            WillReturnRows(pgxmock.NewRows(append(urHeader, "deleted_at")).
+                AddRow(ur[2].ID,ur[2].RoleName,ur[2].Description,ur[2].CreatedAt,ur[2].UpdatedAt,deletedAt),
+            )
+
+        // actual test method/function call
+        store := NewUserRoleStore(mock)
+        got, meta, err := store.Gets(q)
+
+        // test verification and validation
+        assert.NoError(t, err)
+        assert.Len(t, got, 1)
+        assert.Equal(t, ur[2].RoleName, got[0].RoleName)
+        assert.Equal(t, deletedAt, got[0].DeletedAt)
+        assert.Equal(t, deletedAt, *got[0].ConvertToResponse().DeletedAt)
+        assert.Equal(t, int64(1), meta.Total)
+    })
+}
+
+// TestUserRoleRestore will test Restore method of user.role
+func TestUserRoleRestore(t *testing.T) {
+    // prepare mock interface
+    mock := PrepareMock(t)
+    store := NewUserRoleStore(mock)
+
+    // EXPECT SUCCESS deleted user.role is restored
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserRoleRestoreU)).
+            WithArgs(ur[2].ID).
+            WillReturnRows(pgxmock.NewRows(urHeader).
+                AddRow(ur[2].ID,ur[2].RoleName,ur[2].Description,ur[2].CreatedAt,ur[2].UpdatedAt),
+            )
+
+        got, err := store.Restore(ur[2].ID)
+
+        assert.NoError(t, err)
+        assert.Equal(t, ur[2], got)
+    })
+
+    cases := []struct{
+        name    string
+        mockErr error
+        want    int
+    }{
+        {"EXPECT FAIL role is not deleted", pgx.ErrNoRows, E.ErrDataIsEmpty},
+        {"EXPECT FAIL role name is taken", &pgconn.PgError{Code: pgUniqueViolation}, E.ErrUniqueViolation},
+        {"EXPECT FAIL database error", fmt.Errorf("connection lost"), E.ErrDatabase},
+    }
+
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            mock.ExpectQuery(regexp.QuoteMeta(sqlUserRoleRestoreU)).
+                WithArgs(ur[2].ID).
+                WillReturnError(tt.mockErr)
+
+            got, err := store.Restore(ur[2].ID)
+
+            assert.Nil(t, got)
+            assert.EqualValues(t, tt.want, E.Code(err))
+        })
+    }
+}
+
+// TestUserRolePurge will test Purge method of user.role
+func TestUserRolePurge(t *testing.T) {
+    // prepare mock interface
+    mock := PrepareMock(t)
+    store := NewUserRoleStore(mock)
+    header := []string{"in_use"}
+
+    // EXPECT SUCCESS deleted user.role not held by any user is removed
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserRolePurgeD)).
+            WithArgs(ur[2].ID).
+            WillReturnRows(pgxmock.NewRows(header).AddRow(false))
+
+        err := store.Purge(ur[2].ID)
+
+        assert.NoError(t, err)
+    })
+
+    // EXPECT FAIL user.role is still held by user
+    t.Run("EXPECT FAIL role is in use", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserRolePurgeD)).
+            WithArgs(ur[2].ID).
+            WillReturnRows(pgxmock.NewRows(header).AddRow(true))
+
+        err := store.Purge(ur[2].ID)
+
+        assert.EqualValues(t, E.ErrDataIsInUse, err.(*E.Error).Code)
+    })
+
+    // EXPECT FAIL user.role is not found or not deleted
+    t.Run("EXPECT FAIL role is not deleted", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserRolePurgeD)).
+            WithArgs(ur[2].ID).
+            WillReturnError(pgx.ErrNoRows)
+
+        err := store.Purge(ur[2].ID)
+
+        assert.EqualValues(t, E.ErrDataIsEmpty, err.(*E.Error).Code)
+    })
+
+    // EXPECT FAIL database error
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserRolePurgeD)).
+            WithArgs(ur[2].ID).
+            WillReturnError(fmt.Errorf("connection lost"))
+
+        err := store.Purge(ur[2].ID)
+
+        assert.EqualValues(t, E.ErrDatabase, err.(*E.Error).Code)
+    })
+}
+
+// TestUserRolePurgeDeleted will test PurgeDeleted method of user.role
+func TestUserRolePurgeDeleted(t *testing.T) {
+    // prepare mock interface
+    mock := PrepareMock(t)
+    store := NewUserRoleStore(mock)
+
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlUserRolePurgeExpiredD)).
+            WithArgs(30).
+            WillReturnResult(pgxmock.NewResult("DELETE", 2))
+
+        got, err := store.PurgeDeleted(30)
+
+        assert.NoError(t, err)
+        assert.Equal(t, int64(2), got)
+    })
+
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlUserRolePurgeExpiredD)).
+            WithArgs(30).
+            WillReturnError(fmt.Errorf("connection lost"))
+
+        got, err := store.PurgeDeleted(30)
+
+        assert.Error(t, err)
+        assert.Equal(t, int64(0), got)
+    })
+}
@@ -0,0 +1,103 @@
+/*
+   package datastore
+   user.session.go
+   - datastore layer for user session (signed in device)
+   NOTE of method:
+       * Gets method to get active session of the user
+       * Revoke method
+       * RevokeAll method to revoke all session of the user except the given one
+*/
+package datastore
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/reshimahendra/lbw-go/internal/database"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/logger"
+)
+
+const (
+    // query to get active session of the user. session whose family is revoked, whose
+    // last refresh token is used or expired, or refreshed on or before the cutoff of the
+    // user tokens revocation is left out
+    sqlUserSessionR = `SELECT s.id,s.user_id,s.user_agent,s.ip_address,s.created_at,s.last_refreshed_at FROM public.user_session s JOIN public.refresh_token_family f ON f.id=s.id WHERE s.user_id = $1 AND f.revoked_at IS NULL AND EXISTS (SELECT 1 FROM public.refresh_token t WHERE t.family_id=s.id AND t.used_at IS NULL AND t.expires_at > CURRENT_TIMESTAMP) AND NOT EXISTS (SELECT 1 FROM public.revoked_user_token r WHERE r.user_id=s.user_id AND r.revoked_before >= date_trunc('second', s.last_refreshed_at)) ORDER BY s.last_refreshed_at DESC`
+
+    // query command to revoke session of the user by revoking its refresh token family
+    sqlUserSessionD = `UPDATE public.refresh_token_family SET revoked_at=CURRENT_TIMESTAMP WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`
+
+    // query command to revoke all session of the user except the given one
+    sqlUserSessionD1 = `UPDATE public.refresh_token_family SET revoked_at=CURRENT_TIMESTAMP WHERE user_id = $1 AND id IS DISTINCT FROM NULLIF($2,'')::uuid AND revoked_at IS NULL`
+)
+
+// IUserSessionStore is user.session interface for session operation directly to the database
+type IUserSessionStore interface {
+    // Gets will get active session of the user
+    Gets(userID uuid.UUID) ([]*d.UserSession, error)
+
+    // Revoke will revoke session of the user
+    Revoke(userID, id uuid.UUID) error
+
+    // RevokeAll will revoke all session of the user except 'keepID'
+    RevokeAll(userID uuid.UUID, keepID string) (int64, error)
+}
+
+// UserSessionStore is instance wrapper for IDatabase interface
+type UserSessionStore struct {
+    // DB is IDatabase interface instance
+    DB database.IDatabase
+}
+
+// NewUserSessionStore will create instance of UserSessionStore
+func NewUserSessionStore(iDB database.IDatabase) *UserSessionStore {
+    return &UserSessionStore{DB: iDB}
+}
+
+// Gets will get active session of the user, the last refreshed first
+func (st *UserSessionStore) Gets(userID uuid.UUID) ([]*d.UserSession, error) {
+    results, err := st.DB.Query(context.Background(), sqlUserSessionR, userID)
+    if err != nil {
+        logger.Errorf("user.session.gets datastore fail: %v", err)
+        return nil, E.New(E.ErrDatabase)
+    }
+    defer results.Close()
+
+    // scanAllFunc is locate at datastore - user.go
+    sessions := []*d.UserSession{}
+    if err = scanAllFunc(&sessions, results); err != nil {
+        logger.Errorf("user.session.gets datastore fail: %v", err)
+        return nil, E.New(E.ErrDatabase)
+    }
+
+    return sessions, nil
+}
+
+// Revoke will revoke session of the user, every token of the session is rejected after it.
+// session that is not found or already revoked will return E.ErrDataIsEmpty
+func (st *UserSessionStore) Revoke(userID, id uuid.UUID) error {
+    tag, err := st.DB.Exec(context.Background(), sqlUserSessionD, id, userID)
+    if err != nil {
+        logger.Errorf("user.session.revoke datastore fail: %v", err)
+        return E.New(E.ErrDatabase)
+    }
+
+    if tag.RowsAffected() == 0 {
+        return E.New(E.ErrDataIsEmpty)
+    }
+
+    return nil
+}
+
+// RevokeAll will revoke all session of the user except 'keepID' (empty to revoke all)
+// and get the number of revoked session
+func (st *UserSessionStore) RevokeAll(userID uuid.UUID, keepID string) (int64, error) {
+    tag, err := st.DB.Exec(context.Background(), sqlUserSessionD1, userID, keepID)
+    if err != nil {
+        logger.Errorf("user.session.revokeAll datastore fail: %v", err)
+        return 0, E.New(E.ErrDatabase)
+    }
+
+    return tag.RowsAffected(), nil
+}
@@ -0,0 +1,136 @@
+/*
+   package datastore
+   user.session_test.go
+   - test unit for user.session datastore
+*/
+package datastore
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+    // sess is user.session mock data
+    sess = d.UserSession{
+        ID              : uuid.New(),
+        UserID          : u[0].ID,
+        UserAgent       : "Mozilla/5.0 (X11; Linux x86_64) Firefox/102.0",
+        IPAddress       : "203.0.113.7",
+        CreatedAt       : time.Now().Add(-time.Hour),
+        LastRefreshedAt : time.Now(),
+    }
+    sessHeader = []string{"id","user_id","user_agent","ip_address","created_at","last_refreshed_at"}
+)
+
+// TestUserSessionStoreGets will test Gets method of user.session datastore
+func TestUserSessionStoreGets(t *testing.T) {
+    mock := PrepareMock(t)
+    store := NewUserSessionStore(mock)
+
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserSessionR)).
+            WithArgs(sess.UserID).
+            WillReturnRows(pgxmock.NewRows(sessHeader).
+                AddRow(sess.ID, sess.UserID, sess.UserAgent, sess.IPAddress, sess.CreatedAt, sess.LastRefreshedAt),
+            )
+
+        got, err := store.Gets(sess.UserID)
+
+        want := sess
+        assert.NoError(t, err)
+        assert.Equal(t, []*d.UserSession{&want}, got)
+    })
+
+    t.Run("EXPECT SUCCESS no session", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserSessionR)).
+            WithArgs(sess.UserID).
+            WillReturnRows(pgxmock.NewRows(sessHeader))
+
+        got, err := store.Gets(sess.UserID)
+
+        assert.NoError(t, err)
+        assert.Empty(t, got)
+    })
+
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserSessionR)).
+            WithArgs(sess.UserID).
+            WillReturnError(fmt.Errorf("connection lost"))
+
+        got, err := store.Gets(sess.UserID)
+
+        assert.EqualValues(t, E.ErrDatabase, err.(*E.Error).Code)
+        assert.Nil(t, got)
+    })
+}
+
+// TestUserSessionStoreRevoke will test Revoke method of user.session datastore
+func TestUserSessionStoreRevoke(t *testing.T) {
+    mock := PrepareMock(t)
+    store := NewUserSessionStore(mock)
+
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlUserSessionD)).
+            WithArgs(sess.ID, sess.UserID).
+            WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+        assert.NoError(t, store.Revoke(sess.UserID, sess.ID))
+    })
+
+    // EXPECT FAIL session of other user, unknown or already revoked
+    t.Run("EXPECT FAIL not found", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlUserSessionD)).
+            WithArgs(sess.ID, sess.UserID).
+            WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+        err := store.Revoke(sess.UserID, sess.ID)
+        assert.EqualValues(t, E.ErrDataIsEmpty, err.(*E.Error).Code)
+    })
+
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlUserSessionD)).
+            WithArgs(sess.ID, sess.UserID).
+            WillReturnError(fmt.Errorf("connection lost"))
+
+        err := store.Revoke(sess.UserID, sess.ID)
+        assert.EqualValues(t, E.ErrDatabase, err.(*E.Error).Code)
+    })
+}
+
+// TestUserSessionStoreRevokeAll will test RevokeAll method of user.session datastore
+func TestUserSessionStoreRevokeAll(t *testing.T) {
+    mock := PrepareMock(t)
+    store := NewUserSessionStore(mock)
+
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlUserSessionD1)).
+            WithArgs(sess.UserID, sess.ID.String()).
+            WillReturnResult(pgxmock.NewResult("UPDATE", 3))
+
+        got, err := store.RevokeAll(sess.UserID, sess.ID.String())
+
+        assert.NoError(t, err)
+        assert.EqualValues(t, 3, got)
+    })
+
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlUserSessionD1)).
+            WithArgs(sess.UserID, "").
+            WillReturnError(fmt.Errorf("connection lost"))
+
+        got, err := store.RevokeAll(sess.UserID, "")
+
+        assert.Zero(t, got)
+        assert.EqualValues(t, E.ErrDatabase, err.(*E.Error).Code)
+        assert.NoError(t, mock.ExpectationsWereMet())
+    })
+}
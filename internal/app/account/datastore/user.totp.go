@@ -0,0 +1,146 @@
+/*
+   package datastore
+   user.totp.go
+   - datastore layer for user two factor authentication (totp)
+   NOTE of method:
+       * Create method
+       * Get method
+       * Enable method
+       * UseStep method
+       * UseRecoveryCode method
+*/
+package datastore
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/reshimahendra/lbw-go/internal/database"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/logger"
+)
+
+const (
+    // query command to insert the totp secret. secret that not yet enabled is replaced
+    // so the user can enroll again, enabled secret is kept
+    sqlUserTOTPC = `INSERT INTO public.user_totp (user_id,secret) VALUES ($1,$2) ON CONFLICT (user_id) DO UPDATE SET secret=EXCLUDED.secret,last_used_step=NULL,created_at=CURRENT_TIMESTAMP WHERE public.user_totp.enabled_at IS NULL`
+
+    // query command to get the totp secret of the user
+    sqlUserTOTPR1 = `SELECT user_id,secret,last_used_step,enabled_at FROM public.user_totp WHERE user_id=$1`
+
+    // query command to enable the totp secret and replace the recovery codes in one statement
+    sqlUserTOTPU = `WITH t AS (UPDATE public.user_totp SET enabled_at=CURRENT_TIMESTAMP,last_used_step=$3 WHERE user_id=$1 AND enabled_at IS NULL RETURNING user_id), r AS (DELETE FROM public.user_recovery_code WHERE user_id IN (SELECT user_id FROM t)) INSERT INTO public.user_recovery_code (code_hash,user_id) SELECT c,t.user_id FROM t, unnest($2::text[]) AS c`
+
+    // query command to record the time step of the accepted code. only later step is accepted
+    // so the same code can not be used twice
+    sqlUserTOTPStepU = `UPDATE public.user_totp SET last_used_step=$2 WHERE user_id=$1 AND enabled_at IS NOT NULL AND (last_used_step IS NULL OR last_used_step < $2)`
+
+    // query command to mark the unused recovery code as used
+    sqlUserRecoveryCodeU = `UPDATE public.user_recovery_code SET used_at=CURRENT_TIMESTAMP WHERE code_hash=$1 AND user_id=$2 AND used_at IS NULL`
+)
+
+// IUserTOTPStore is user.totp interface for two factor authentication
+// operation directly to the database
+type IUserTOTPStore interface {
+    // Create will save the encrypted totp secret. it return false when the user
+    // already enabled the two factor authentication
+    Create(userID uuid.UUID, secret string) (bool, error)
+
+    // Get will get the totp secret of the user
+    Get(userID uuid.UUID) (*d.UserTOTP, error)
+
+    // Enable will enable the totp secret and replace the recovery codes.
+    // it return false when the secret is already enabled
+    Enable(userID uuid.UUID, codeHashes []string, step int64) (bool, error)
+
+    // UseStep will record the time step of the accepted code. it return false
+    // when the step (or later step) was already used
+    UseStep(userID uuid.UUID, step int64) (bool, error)
+
+    // UseRecoveryCode will mark the recovery code as used. it return false
+    // when the code is unknown or already used
+    UseRecoveryCode(userID uuid.UUID, codeHash string) (bool, error)
+}
+
+// UserTOTPStore is instance wrapper for IDatabase interface
+type UserTOTPStore struct {
+    // DB is IDatabase interface instance
+    DB database.IDatabase
+}
+
+// NewUserTOTPStore will create instance of UserTOTPStore
+func NewUserTOTPStore(iDB database.IDatabase) *UserTOTPStore {
+    return &UserTOTPStore{DB: iDB}
+}
+
+// Create will insert or replace the not yet enabled totp secret of the user
+func (st *UserTOTPStore) Create(userID uuid.UUID, secret string) (bool, error) {
+    // execute sql command to insert the secret
+    tag, err := st.DB.Exec(context.Background(), sqlUserTOTPC, userID, secret)
+    if err != nil {
+        logger.Errorf("user.totp.create datastore fail: %v", err)
+        return false, E.New(E.ErrDatabase)
+    }
+
+    return tag.RowsAffected() == 1, nil
+}
+
+// Get will get the totp secret record of the user
+func (st *UserTOTPStore) Get(userID uuid.UUID) (*d.UserTOTP, error) {
+    // prepare to scan record data
+    totp := new(d.UserTOTP)
+    err := st.DB.QueryRow(context.Background(), sqlUserTOTPR1, userID).Scan(
+        &totp.UserID,
+        &totp.Secret,
+        &totp.LastUsedStep,
+        &totp.EnabledAt,
+    )
+
+    // check if error occur during scan
+    if err == pgx.ErrNoRows {
+        return nil, E.New(E.ErrDataIsEmpty)
+    } else if err != nil {
+        logger.Errorf("user.totp.get datastore fail: %v", err)
+        return nil, E.New(E.ErrDatabase)
+    }
+
+    return totp, nil
+}
+
+// Enable will enable the totp secret of the user and save the recovery codes hash
+func (st *UserTOTPStore) Enable(userID uuid.UUID, codeHashes []string, step int64) (bool, error) {
+    // execute sql command to enable the secret
+    tag, err := st.DB.Exec(context.Background(), sqlUserTOTPU, userID, codeHashes, step)
+    if err != nil {
+        logger.Errorf("user.totp.enable datastore fail: %v", err)
+        return false, E.New(E.ErrDatabase)
+    }
+
+    return tag.RowsAffected() > 0, nil
+}
+
+// UseStep will save the time step of the accepted code
+func (st *UserTOTPStore) UseStep(userID uuid.UUID, step int64) (bool, error) {
+    // execute sql command to record the step
+    tag, err := st.DB.Exec(context.Background(), sqlUserTOTPStepU, userID, step)
+    if err != nil {
+        logger.Errorf("user.totp.useStep datastore fail: %v", err)
+        return false, E.New(E.ErrDatabase)
+    }
+
+    return tag.RowsAffected() == 1, nil
+}
+
+// UseRecoveryCode will mark the recovery code of the user as used
+func (st *UserTOTPStore) UseRecoveryCode(userID uuid.UUID, codeHash string) (bool, error) {
+    // execute sql command to use the recovery code
+    tag, err := st.DB.Exec(context.Background(), sqlUserRecoveryCodeU, codeHash, userID)
+    if err != nil {
+        logger.Errorf("user.totp.useRecoveryCode datastore fail: %v", err)
+        return false, E.New(E.ErrDatabase)
+    }
+
+    return tag.RowsAffected() == 1, nil
+}
@@ -0,0 +1,302 @@
+/*
+   package datastore
+   user.totp_test.go
+   - test unit for user.totp datastore
+*/
+package datastore
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/pashagolub/pgxmock"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+    // totpStep is time step mock data
+    totpStep int64 = 55555555
+
+    // totpEnabledAt is enabled datetime mock data
+    totpEnabledAt = time.Now()
+
+    // ut is user.totp mock data
+    ut = d.UserTOTP{
+        UserID       : u[0].ID,
+        Secret       : "ZW5jcnlwdGVkLXNlY3JldA==",
+        LastUsedStep : &totpStep,
+        EnabledAt    : &totpEnabledAt,
+    }
+
+    // utHeader is user.totp table header mock data
+    utHeader = []string{"user_id", "secret", "last_used_step", "enabled_at"}
+
+    // recoveryCodeHashes is recovery code hash mock data
+    recoveryCodeHashes = []string{
+        "3c469e9d6c5875d37a43f353d4f88e61fcf812c66eee3457465a40b0da4153e0",
+        "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae",
+    }
+)
+
+// TestUserTOTPStoreCreate will test Create method of user.totp datastore
+func TestUserTOTPStoreCreate(t *testing.T) {
+    // prepare mock and store
+    mock := PrepareMock(t)
+    store := NewUserTOTPStore(mock)
+
+    // EXPECT SUCCESS is typical test simulation with expectation that
+    // the operation will run normally
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlUserTOTPC)).
+            WithArgs(ut.UserID, ut.Secret).
+            WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+        // actual method test
+        created, err := store.Create(ut.UserID, ut.Secret)
+
+        // validation and verification
+        assert.NoError(t, err)
+        assert.True(t, created)
+    })
+
+    // EXPECT SUCCESS already enabled. no record is inserted or updated
+    t.Run("EXPECT SUCCESS already enabled", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlUserTOTPC)).
+            WithArgs(ut.UserID, ut.Secret).
+            WillReturnResult(pgxmock.NewResult("INSERT", 0))
+
+        // actual method test
+        created, err := store.Create(ut.UserID, ut.Secret)
+
+        // validation and verification
+        assert.NoError(t, err)
+        assert.False(t, created)
+    })
+
+    // EXPECT FAIL database error. Simulated by triggering E.ErrDatabase on mock
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlUserTOTPC)).
+            WithArgs(ut.UserID, ut.Secret).
+            WillReturnError(E.New(E.ErrDatabase))
+
+        // actual method test
+        created, err := store.Create(ut.UserID, ut.Secret)
+
+        // validation and verification
+        assert.Error(t, err)
+        assert.False(t, created)
+        assert.Equal(t, E.New(E.ErrDatabase), err)
+    })
+}
+
+// TestUserTOTPStoreGet will test Get method of user.totp datastore
+func TestUserTOTPStoreGet(t *testing.T) {
+    // prepare mock and store
+    mock := PrepareMock(t)
+    store := NewUserTOTPStore(mock)
+
+    // EXPECT SUCCESS is typical test simulation with expectation that
+    // the operation will run normally
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        rows := pgxmock.NewRows(utHeader).
+            AddRow(ut.UserID, ut.Secret, ut.LastUsedStep, ut.EnabledAt)
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserTOTPR1)).
+            WithArgs(ut.UserID).
+            WillReturnRows(rows)
+
+        // actual method test
+        got, err := store.Get(ut.UserID)
+
+        // validation and verification
+        assert.NoError(t, err)
+        assert.Equal(t, ut.Secret, got.Secret)
+        assert.True(t, got.IsEnabled())
+    })
+
+    // EXPECT FAIL data not found. Simulated by returning pgx.ErrNoRows
+    t.Run("EXPECT FAIL data not found", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserTOTPR1)).
+            WithArgs(ut.UserID).
+            WillReturnError(pgx.ErrNoRows)
+
+        // actual method test
+        got, err := store.Get(ut.UserID)
+
+        // validation and verification
+        assert.Error(t, err)
+        assert.Nil(t, got)
+        assert.Equal(t, E.New(E.ErrDataIsEmpty), err)
+    })
+
+    // EXPECT FAIL database error. Simulated by triggering E.ErrDatabase on mock
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserTOTPR1)).
+            WithArgs(ut.UserID).
+            WillReturnError(E.New(E.ErrDatabase))
+
+        // actual method test
+        got, err := store.Get(ut.UserID)
+
+        // validation and verification
+        assert.Error(t, err)
+        assert.Nil(t, got)
+        assert.Equal(t, E.New(E.ErrDatabase), err)
+    })
+}
+
+// TestUserTOTPStoreEnable will test Enable method of user.totp datastore
+func TestUserTOTPStoreEnable(t *testing.T) {
+    // prepare mock and store
+    mock := PrepareMock(t)
+    store := NewUserTOTPStore(mock)
+
+    // EXPECT SUCCESS is typical test simulation with expectation that
+    // the operation will run normally
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlUserTOTPU)).
+            WithArgs(ut.UserID, recoveryCodeHashes, totpStep).
+            WillReturnResult(pgxmock.NewResult("INSERT", int64(len(recoveryCodeHashes))))
+
+        // actual method test
+        enabled, err := store.Enable(ut.UserID, recoveryCodeHashes, totpStep)
+
+        // validation and verification
+        assert.NoError(t, err)
+        assert.True(t, enabled)
+    })
+
+    // EXPECT SUCCESS already enabled. no recovery code is inserted
+    t.Run("EXPECT SUCCESS already enabled", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlUserTOTPU)).
+            WithArgs(ut.UserID, recoveryCodeHashes, totpStep).
+            WillReturnResult(pgxmock.NewResult("INSERT", 0))
+
+        // actual method test
+        enabled, err := store.Enable(ut.UserID, recoveryCodeHashes, totpStep)
+
+        // validation and verification
+        assert.NoError(t, err)
+        assert.False(t, enabled)
+    })
+
+    // EXPECT FAIL database error. Simulated by triggering E.ErrDatabase on mock
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlUserTOTPU)).
+            WithArgs(ut.UserID, recoveryCodeHashes, totpStep).
+            WillReturnError(E.New(E.ErrDatabase))
+
+        // actual method test
+        enabled, err := store.Enable(ut.UserID, recoveryCodeHashes, totpStep)
+
+        // validation and verification
+        assert.Error(t, err)
+        assert.False(t, enabled)
+        assert.Equal(t, E.New(E.ErrDatabase), err)
+    })
+}
+
+// TestUserTOTPStoreUseStep will test UseStep method of user.totp datastore
+func TestUserTOTPStoreUseStep(t *testing.T) {
+    // prepare mock and store
+    mock := PrepareMock(t)
+    store := NewUserTOTPStore(mock)
+
+    // EXPECT SUCCESS is typical test simulation with expectation that
+    // the operation will run normally
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlUserTOTPStepU)).
+            WithArgs(ut.UserID, totpStep).
+            WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+        // actual method test
+        used, err := store.UseStep(ut.UserID, totpStep)
+
+        // validation and verification
+        assert.NoError(t, err)
+        assert.True(t, used)
+    })
+
+    // EXPECT SUCCESS step reused. no record is updated
+    t.Run("EXPECT SUCCESS step reused", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlUserTOTPStepU)).
+            WithArgs(ut.UserID, totpStep).
+            WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+        // actual method test
+        used, err := store.UseStep(ut.UserID, totpStep)
+
+        // validation and verification
+        assert.NoError(t, err)
+        assert.False(t, used)
+    })
+
+    // EXPECT FAIL database error. Simulated by triggering E.ErrDatabase on mock
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlUserTOTPStepU)).
+            WithArgs(ut.UserID, totpStep).
+            WillReturnError(E.New(E.ErrDatabase))
+
+        // actual method test
+        used, err := store.UseStep(ut.UserID, totpStep)
+
+        // validation and verification
+        assert.Error(t, err)
+        assert.False(t, used)
+        assert.Equal(t, E.New(E.ErrDatabase), err)
+    })
+}
+
+// TestUserTOTPStoreUseRecoveryCode will test UseRecoveryCode method of user.totp datastore
+func TestUserTOTPStoreUseRecoveryCode(t *testing.T) {
+    // prepare mock and store
+    mock := PrepareMock(t)
+    store := NewUserTOTPStore(mock)
+
+    // EXPECT SUCCESS is typical test simulation with expectation that
+    // the operation will run normally
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlUserRecoveryCodeU)).
+            WithArgs(recoveryCodeHashes[0], ut.UserID).
+            WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+        // actual method test
+        used, err := store.UseRecoveryCode(ut.UserID, recoveryCodeHashes[0])
+
+        // validation and verification
+        assert.NoError(t, err)
+        assert.True(t, used)
+    })
+
+    // EXPECT SUCCESS code unknown or used. no record is updated
+    t.Run("EXPECT SUCCESS code unknown or used", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlUserRecoveryCodeU)).
+            WithArgs(recoveryCodeHashes[0], ut.UserID).
+            WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+        // actual method test
+        used, err := store.UseRecoveryCode(ut.UserID, recoveryCodeHashes[0])
+
+        // validation and verification
+        assert.NoError(t, err)
+        assert.False(t, used)
+    })
+
+    // EXPECT FAIL database error. Simulated by triggering E.ErrDatabase on mock
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlUserRecoveryCodeU)).
+            WithArgs(recoveryCodeHashes[0], ut.UserID).
+            WillReturnError(E.New(E.ErrDatabase))
+
+        // actual method test
+        used, err := store.UseRecoveryCode(ut.UserID, recoveryCodeHashes[0])
+
+        // validation and verification
+        assert.Error(t, err)
+        assert.False(t, used)
+        assert.Equal(t, E.New(E.ErrDatabase), err)
+    })
+}
@@ -6,11 +6,14 @@
 package datastore
 
 import (
+	"fmt"
 	"regexp"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 	"github.com/pashagolub/pgxmock"
 	d "github.com/reshimahendra/lbw-go/internal/domain"
@@ -111,6 +114,57 @@ func TestUserStoreCreate(t *testing.T) {
         assert.Error(t, err)
         assert.Nil(t, got)
     })
+
+    // EXPECT FAIL username or email is taken by concurrent insert after the
+    // existing user check. Simulated by triggering unique violation
+    t.Run("EXPECT FAIL email is taken", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserC)).
+            WithArgs(u[0].ID,u[0].Username,u[0].Firstname,u[0].Lastname,u[0].Email,u[0].PassKey,
+                u[0].StatusID,u[0].RoleID).
+            WillReturnError(&pgconn.PgError{Code: pgUniqueViolation, ConstraintName: "users_email_key",
+                Detail: "Key (email)=(" + u[0].Email + ") already exists."})
+
+        // actual method test
+        got, err := store.Create(*u[0])
+
+        // validation and verification
+        assert.Nil(t, got)
+        assert.Equal(t, E.NewConstraint(E.ErrUniqueViolation, "users_email_key", "email"), err)
+    })
+
+    // EXPECT FAIL role is not found. Simulated by triggering foreign key violation
+    t.Run("EXPECT FAIL role is not found", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserC)).
+            WithArgs(u[0].ID,u[0].Username,u[0].Firstname,u[0].Lastname,u[0].Email,u[0].PassKey,
+                u[0].StatusID,u[0].RoleID).
+            WillReturnError(&pgconn.PgError{Code: pgForeignKeyViolation, ConstraintName: "users_role_id_fkey",
+                Detail: `Key (role_id)=(9) is not present in table "user_role".`})
+
+        // actual method test
+        got, err := store.Create(*u[0])
+
+        // validation and verification
+        assert.Nil(t, got)
+        assert.Equal(t, E.NewConstraint(E.ErrForeignKeyViolation, "users_role_id_fkey", "role_id"), err)
+    })
+
+    // EXPECT SUCCESS status and role are inserted to the column of users table
+    // (status_id and role_id) used by signup and social login provisioning
+    t.Run("EXPECT SUCCESS insert schema column", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO public.users (id,username,firstname,lastname,email,passkey,updated_at,status_id,role_id) VALUES`)).
+            WithArgs(u[0].ID,u[0].Username,u[0].Firstname,u[0].Lastname,u[0].Email,u[0].PassKey,
+                u[0].StatusID,u[0].RoleID).
+            WillReturnRows(pgxmock.NewRows(uHeader).
+                AddRow(u[0].ID,u[0].Username,u[0].Firstname,u[0].Lastname,u[0].Email,
+                u[0].StatusID,u[0].RoleID,u[0].CreatedAt,u[0].UpdatedAt))
+
+        // actual method test
+        got, err := store.Create(*u[0])
+
+        // validation and verification
+        assert.NoError(t, err)
+        assert.Equal(t, u[0].RoleID, got.RoleID)
+    })
 }
 
 // TestUserStoreGet will test Get method of user datastore
@@ -170,11 +224,16 @@ func TestUserStoreGets(t *testing.T) {
     // prepare mock and store
     mock := PrepareMock(t)
     store := NewUserStore(mock)
+    q := d.ListQuery{Page: 1, Limit: 1, Sort: "created_at", Order: d.SortAsc}
+    countHeader := []string{"count"}
 
     // EXPECT SUCCESS is typical test simulation with expectation that
-    // the operation will run normally
+    // the operation will run normally. the extra row mean there is next page
     t.Run("EXPECT SUCCESS", func(t *testing.T){
-        mock.ExpectQuery(regexp.QuoteMeta(sqlUserR)).
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserCount + " WHERE deleted_at IS NULL")).
+            WillReturnRows(pgxmock.NewRows(countHeader).AddRow(int64(2)))
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserR + " WHERE deleted_at IS NULL ORDER BY created_at ASC,id ASC LIMIT $1")).
+            WithArgs(2).
             WillReturnRows(pgxmock.NewRows(uHeader).
                 AddRow(u[0].ID,u[0].Username,u[0].Firstname,u[0].Lastname,u[0].Email,
                 u[0].StatusID,u[0].RoleID,u[0].CreatedAt,u[0].UpdatedAt).
@@ -183,26 +242,123 @@ func TestUserStoreGets(t *testing.T) {
             )
 
         // actual method test
-        got, err := store.Gets()
+        got, meta, err := store.Gets(q)
 
         // test verification and validation
         assert.NoError(t, err)
+        assert.Len(t, got, 1)
         assert.Equal(t, u[0].ID, got[0].ID)
         assert.Equal(t, u[0].Email, got[0].Email)
         assert.Equal(t, u[0].CreatedAt, got[0].CreatedAt)
-        assert.Equal(t, u[1].ID, got[1].ID)
-        assert.Equal(t, u[1].Email, got[1].Email)
-        assert.Equal(t, u[1].CreatedAt, got[1].CreatedAt)
+        assert.Equal(t, int64(2), meta.Total)
+        assert.Equal(t, 1, meta.Page)
+
+        cursor, err := d.DecodeListCursor(meta.NextCursor)
+        assert.NoError(t, err)
+        assert.Equal(t, u[0].ID.String(), cursor.ID)
+        assert.Equal(t, u[0].CreatedAt.UTC().Format(time.RFC3339Nano), cursor.Value)
+    })
+
+    // EXPECT SUCCESS filtered list continue after the cursor
+    t.Run("EXPECT SUCCESS filter and cursor", func(t *testing.T){
+        statusID, roleID := 1, 0
+        from := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+        fq := d.ListQuery{
+            Page        : 1,
+            Limit       : 2,
+            Sort        : "username",
+            Order       : d.SortDesc,
+            Cursor      : &d.ListCursor{Sort: "username", Order: d.SortDesc, Value: "reshi", ID: u[0].ID.String()},
+            StatusID    : &statusID,
+            RoleID      : &roleID,
+            CreatedFrom : &from,
+            Username    : "re_",
+            Email       : "reshi",
+        }
+        filter := " WHERE deleted_at IS NULL AND status_id = $1 AND role_id = $2 AND created_at >= $3" +
+            " AND username ILIKE $4 AND email ILIKE $5"
+
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserCount + filter)).
+            WithArgs(statusID, roleID, from, `re\_%`, "reshi%").
+            WillReturnRows(pgxmock.NewRows(countHeader).AddRow(int64(3)))
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserR + filter +
+            " AND (username,id) < ($6,$7) ORDER BY username DESC,id DESC LIMIT $8")).
+            WithArgs(statusID, roleID, from, `re\_%`, "reshi%", "reshi", u[0].ID, 3).
+            WillReturnRows(pgxmock.NewRows(uHeader).
+                AddRow(u[1].ID,u[1].Username,u[1].Firstname,u[1].Lastname,u[1].Email,
+                u[1].StatusID,u[1].RoleID,u[1].CreatedAt,u[1].UpdatedAt),
+            )
+
+        got, meta, err := store.Gets(fq)
+
+        assert.NoError(t, err)
+        assert.Len(t, got, 1)
+        assert.Equal(t, int64(3), meta.Total)
+        assert.Equal(t, 0, meta.Page)
+        assert.Empty(t, meta.NextCursor)
+    })
+
+    // EXPECT SUCCESS page pagination skip the previous page record
+    t.Run("EXPECT SUCCESS page offset", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserCount)).
+            WillReturnRows(pgxmock.NewRows(countHeader).AddRow(int64(0)))
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserR + " WHERE deleted_at IS NULL ORDER BY email ASC,id ASC LIMIT $1 OFFSET $2")).
+            WithArgs(11, 20).
+            WillReturnRows(pgxmock.NewRows(uHeader))
+
+        got, meta, err := store.Gets(d.ListQuery{Page: 3, Limit: 10, Sort: "email", Order: d.SortAsc})
+
+        assert.NoError(t, err)
+        assert.Empty(t, got)
+        assert.Equal(t, 3, meta.Page)
+    })
+
+    // EXPECT FAIL sort field is not user column
+    t.Run("EXPECT FAIL unknown sort field", func(t *testing.T){
+        got, meta, err := store.Gets(d.ListQuery{Page: 1, Limit: 10, Sort: "passkey", Order: d.SortAsc})
+
+        assert.Error(t, err)
+        assert.Equal(t, E.New(E.ErrDataIsInvalid), err)
+        assert.Nil(t, got)
+        assert.Nil(t, meta)
+    })
+
+    // EXPECT FAIL cursor id is not uuid
+    t.Run("EXPECT FAIL invalid cursor", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserCount)).
+            WillReturnRows(pgxmock.NewRows(countHeader).AddRow(int64(2)))
+
+        cq := q
+        cq.Cursor = &d.ListCursor{Sort: "created_at", Order: d.SortAsc, Value: "yesterday", ID: "1"}
+        got, _, err := store.Gets(cq)
+
+        assert.Error(t, err)
+        assert.Equal(t, E.New(E.ErrDataIsInvalid), err)
+        assert.Nil(t, got)
+    })
+
+    // EXPECT FAIL count error. Simulated by returning error from the mock
+    t.Run("EXPECT FAIL count error", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserCount)).
+            WillReturnError(E.New(E.ErrDatabase))
+
+        got, _, err := store.Gets(q)
+
+        assert.Error(t, err)
+        assert.Equal(t, E.New(E.ErrDatabase), err)
+        assert.Nil(t, got)
     })
 
     // EXPECT FAIL data empty error. Simulated by returning error from the mock
     t.Run("EXPECT FAIL data empty error", func(t *testing.T){
         // prepare mock query
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserCount)).
+            WillReturnRows(pgxmock.NewRows(countHeader).AddRow(int64(2)))
         mock.ExpectQuery(regexp.QuoteMeta(sqlUserR)).
             WillReturnError(E.New(E.ErrDataIsEmpty))
 
         // actual method test
-        got, err := store.Gets()
+        got, _, err := store.Gets(q)
 
         // test verification and validation
         assert.Error(t, err)
@@ -222,6 +378,8 @@ func TestUserStoreGets(t *testing.T) {
         }()
 
         // prepare mock query
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserCount)).
+            WillReturnRows(pgxmock.NewRows(countHeader).AddRow(int64(2)))
         mock.ExpectQuery(regexp.QuoteMeta(sqlUserR)).
             WillReturnRows(pgxmock.NewRows(uHeader).
                 AddRow(u[0].ID,u[0].Username,u[0].Firstname,u[0].Lastname,u[0].Email,
@@ -231,7 +389,7 @@ func TestUserStoreGets(t *testing.T) {
             )
 
         // actual method test
-        got, err := store.Gets()
+        got, _, err := store.Gets(q)
 
         // test verification and validation
         assert.Error(t, err)
@@ -250,7 +408,7 @@ func TestUserStoreUpdate(t *testing.T) {
     // and response with the data result)
     t.Run("EXPECT SUCCESS", func(t *testing.T){
         mock.ExpectQuery(regexp.QuoteMeta(sqlUserU)).
-            WithArgs(u[0].ID,u[0].Username,u[0].Firstname,u[0].Lastname,u[0].Email,u[0].PassKey,
+            WithArgs(u[0].ID,u[0].Username,u[0].Firstname,u[0].Lastname,u[0].Email,
                 u[0].StatusID,u[0].RoleID).
             WillReturnRows(pgxmock.NewRows(uHeader).
                 AddRow(u[0].ID,u[0].Username,u[0].Firstname,u[0].Lastname,u[0].Email,
@@ -272,7 +430,7 @@ func TestUserStoreUpdate(t *testing.T) {
     // EXPECT FAIL data empty error. Simulated by triggering pgx.ErrNoRows on mock
     t.Run("EXPECT FAIL data is empty error", func(t *testing.T){
         mock.ExpectQuery(regexp.QuoteMeta(sqlUserU)).
-            WithArgs(u[0].ID,u[0].Username,u[0].Firstname,u[0].Lastname,u[0].Email,u[0].PassKey,
+            WithArgs(u[0].ID,u[0].Username,u[0].Firstname,u[0].Lastname,u[0].Email,
                 u[0].StatusID,u[0].RoleID).
             WillReturnError(pgx.ErrNoRows)
 
@@ -287,7 +445,7 @@ func TestUserStoreUpdate(t *testing.T) {
     // EXPECT FAIL database error. Simulated by triggering E.ErrDatabase on mock
     t.Run("EXPECT FAIL database error", func(t *testing.T){
         mock.ExpectQuery(regexp.QuoteMeta(sqlUserU)).
-            WithArgs(u[0].ID,u[0].Username,u[0].Firstname,u[0].Lastname,u[0].Email,u[0].PassKey,
+            WithArgs(u[0].ID,u[0].Username,u[0].Firstname,u[0].Lastname,u[0].Email,
                 u[0].StatusID,u[0].RoleID).
             WillReturnError(E.New(E.ErrDatabase))
 
@@ -300,6 +458,189 @@ func TestUserStoreUpdate(t *testing.T) {
     })
 }
 
+// TestUserStorePatch will test Patch method of user datastore
+func TestUserStorePatch(t *testing.T) {
+    // prepare mock and store
+    mock := PrepareMock(t)
+    store := NewUserStore(mock)
+
+    // expectGet will expect the current record is requested to merge the patch with
+    expectGet := func() {
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserR1)).
+            WithArgs(u[0].ID).
+            WillReturnRows(pgxmock.NewRows(uHeader).
+                AddRow(u[0].ID,u[0].Username,u[0].Firstname,u[0].Lastname,u[0].Email,
+                u[0].StatusID,u[0].RoleID,u[0].CreatedAt,u[0].UpdatedAt),
+            )
+    }
+    sqlPatch := fmt.Sprintf(sqlUserPatchU, "email=$2,lastname=$3,updated_at=CURRENT_TIMESTAMP")
+    patch := d.MergePatch{"email": []byte(`"new@lotusbw.com"`), "lastname": []byte(`null`)}
+
+    // EXPECT SUCCESS only the patched column is sent to the database
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        expectGet()
+        mock.ExpectQuery(regexp.QuoteMeta(sqlPatch)).
+            WithArgs(u[0].ID, "new@lotusbw.com", d.NullString("")).
+            WillReturnRows(pgxmock.NewRows(uHeader).
+                AddRow(u[0].ID,u[0].Username,u[0].Firstname,nil,"new@lotusbw.com",
+                u[0].StatusID,u[0].RoleID,u[0].CreatedAt,u[0].UpdatedAt),
+            )
+
+        got, err := store.Patch(u[0].ID, patch)
+
+        assert.NoError(t, err)
+        assert.Equal(t, "new@lotusbw.com", got.Email)
+        assert.Equal(t, d.NullString(""), got.Lastname)
+        assert.Equal(t, u[0].Username, got.Username)
+    })
+
+    // EXPECT SUCCESS empty patch return the current record without update
+    t.Run("EXPECT SUCCESS empty patch", func(t *testing.T){
+        expectGet()
+
+        got, err := store.Patch(u[0].ID, d.MergePatch{})
+
+        assert.NoError(t, err)
+        assert.Equal(t, u[0].Email, got.Email)
+    })
+
+    // EXPECT FAIL data empty error. Simulated by triggering pgx.ErrNoRows on getting the record
+    t.Run("EXPECT FAIL data is empty error", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserR1)).
+            WithArgs(u[0].ID).
+            WillReturnError(pgx.ErrNoRows)
+
+        got, err := store.Patch(u[0].ID, patch)
+
+        assert.Error(t, err)
+        assert.Equal(t, E.New(E.ErrDataIsEmpty), err)
+        assert.Nil(t, got)
+    })
+
+    // EXPECT FAIL not null violation. Simulated by patching null on column that require a value
+    for _, column := range []string{"username", "firstname", "email", "status_id", "role_id"} {
+        t.Run("EXPECT FAIL not null violation", func(t *testing.T){
+            expectGet()
+
+            got, err := store.Patch(u[0].ID, d.MergePatch{column: []byte(`null`)})
+
+            assert.Nil(t, got)
+            assert.Equal(t, E.NewConstraint(E.ErrNotNullViolation, "", column), err)
+        })
+    }
+
+    // EXPECT FAIL data invalid. Simulated by patching unknown or read only member, member
+    // of wrong type and clearing required field
+    invalid := []d.MergePatch{
+        {"passkey": []byte(`"secret"`)},
+        {"id": []byte(`"9b3a8f52-0c4e-4a0f-9a4e-6f7d1b2c3d4e"`)},
+        {"role_id": []byte(`"admin"`)},
+        {"email": []byte(`""`)},
+    }
+    for _, p := range invalid {
+        t.Run("EXPECT FAIL data invalid", func(t *testing.T){
+            expectGet()
+
+            got, err := store.Patch(u[0].ID, p)
+
+            assert.Error(t, err)
+            assert.Equal(t, E.New(E.ErrDataIsInvalid), err)
+            assert.Nil(t, got)
+        })
+    }
+
+    // EXPECT FAIL merged record break the request rule. Simulated by patching username with
+    // invalid character, too long firstname and invalid email
+    ruleCases := []struct{
+        patch d.MergePatch
+        want  E.FieldError
+    }{
+        {d.MergePatch{"username": []byte(`"leo nard"`)},
+            E.FieldError{Field: "username", Rule: "username", Message: "must start with letter or number and only contain letter, number, dot, underscore or dash"}},
+        {d.MergePatch{"firstname": []byte(`"` + strings.Repeat("a", 31) + `"`)},
+            E.FieldError{Field: "firstname", Rule: "max", Message: "must be at most 30 characters"}},
+        {d.MergePatch{"email": []byte(`"leo.gmail.com"`)},
+            E.FieldError{Field: "email", Rule: "email", Message: "must be a valid email"}},
+    }
+    for _, tt := range ruleCases {
+        t.Run("EXPECT FAIL request rule", func(t *testing.T){
+            expectGet()
+
+            got, err := store.Patch(u[0].ID, tt.patch)
+
+            assert.Equal(t, E.NewExt(E.ErrRequestDataInvalid, E.FieldErrors{tt.want}), err)
+            assert.Nil(t, got)
+        })
+    }
+
+    // EXPECT FAIL database error. Simulated by triggering E.ErrDatabase on mock
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        expectGet()
+        mock.ExpectQuery(regexp.QuoteMeta(sqlPatch)).
+            WithArgs(u[0].ID, "new@lotusbw.com", d.NullString("")).
+            WillReturnError(E.New(E.ErrDatabase))
+
+        got, err := store.Patch(u[0].ID, patch)
+
+        assert.Error(t, err)
+        assert.Equal(t, E.New(E.ErrDatabase), err)
+        assert.Nil(t, got)
+    })
+
+    assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestUserStoreUpdateProfile is to test behaviour of UpdateProfile method for user datastore
+func TestUserStoreUpdateProfile(t *testing.T) {
+    // prepare mock and store
+    mock := PrepareMock(t)
+    store := NewUserStore(mock)
+
+    // EXPECT SUCCESS only the profile field is sent to the database
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserProfileU)).
+            WithArgs(u[0].ID,u[0].Username,u[0].Firstname,u[0].Lastname,u[0].Email).
+            WillReturnRows(pgxmock.NewRows(uHeader).
+                AddRow(u[0].ID,u[0].Username,u[0].Firstname,u[0].Lastname,u[0].Email,
+                u[0].StatusID,u[0].RoleID,u[0].CreatedAt,u[0].UpdatedAt),
+            )
+
+        got, err := store.UpdateProfile(u[0].ID, *u[0])
+
+        assert.NoError(t, err)
+        assert.Equal(t, u[0].ID, got.ID)
+        assert.Equal(t, u[0].Username, got.Username)
+        assert.Equal(t, u[0].StatusID, got.StatusID)
+        assert.Equal(t, u[0].RoleID, got.RoleID)
+    })
+
+    // EXPECT FAIL data empty error. Simulated by triggering pgx.ErrNoRows on mock
+    t.Run("EXPECT FAIL data is empty error", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserProfileU)).
+            WithArgs(u[0].ID,u[0].Username,u[0].Firstname,u[0].Lastname,u[0].Email).
+            WillReturnError(pgx.ErrNoRows)
+
+        got, err := store.UpdateProfile(u[0].ID, *u[0])
+
+        assert.Error(t, err)
+        assert.Equal(t, E.New(E.ErrDataIsEmpty), err)
+        assert.Nil(t, got)
+    })
+
+    // EXPECT FAIL database error. Simulated by triggering E.ErrDatabase on mock
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserProfileU)).
+            WithArgs(u[0].ID,u[0].Username,u[0].Firstname,u[0].Lastname,u[0].Email).
+            WillReturnError(E.New(E.ErrDatabase))
+
+        got, err := store.UpdateProfile(u[0].ID, *u[0])
+
+        assert.Error(t, err)
+        assert.Equal(t, E.New(E.ErrDatabase), err)
+        assert.Nil(t, got)
+    })
+}
+
 // TestUserStoreDelete is to test behaviour of Delete method for user datastore
 func TestUserStoreDelete(t *testing.T) {
     // prepare mock and store
@@ -358,6 +699,207 @@ func TestUserStoreDelete(t *testing.T) {
     })
 }
 
+// TestUserStoreGetsTrash will test listing (soft) deleted user from the trash
+func TestUserStoreGetsTrash(t *testing.T) {
+    // prepare mock and store
+    mock := PrepareMock(t)
+    store := NewUserStore(mock)
+    q := d.ListQuery{Page: 1, Limit: 2, Sort: "deleted_at", Order: d.SortDesc, Deleted: true}
+    deletedAt := time.Date(2022, 3, 1, 0, 0, 0, 0, time.UTC)
+
+    // EXPECT SUCCESS only deleted user is listed with its deletion time
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserCount + " WHERE deleted_at IS NOT NULL")).
+            WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(int64(1)))
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserTrashR + " WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC,id DESC LIMIT $1")).
+            WithArgs(3).
+            WillReturnRows(pgxmock.NewRows(append(uHeader, "deleted_at")).
+                AddRow(u[0].ID,u[0].Username,u[0].Firstname,u[0].Lastname,u[0].Email,
+                u[0].StatusID,u[0].RoleID,u[0].CreatedAt,u[0].UpdatedAt,deletedAt),
+            )
+
+        // actual method call
+        got, meta, err := store.Gets(q)
+
+        // test verification and validation
+        assert.NoError(t, err)
+        assert.Len(t, got, 1)
+        assert.Equal(t, u[0].ID, got[0].ID)
+        assert.Equal(t, deletedAt, got[0].DeletedAt)
+        assert.Equal(t, int64(1), meta.Total)
+        assert.Empty(t, meta.NextCursor)
+    })
+
+    // EXPECT SUCCESS cursor of deletion time continue after the deleted user
+    t.Run("EXPECT SUCCESS cursor", func(t *testing.T){
+        cq := q
+        cq.Cursor = &d.ListCursor{Sort: "deleted_at", Order: d.SortDesc,
+            Value: deletedAt.Format(time.RFC3339Nano), ID: u[0].ID.String()}
+
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserCount + " WHERE deleted_at IS NOT NULL")).
+            WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(int64(1)))
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserTrashR +
+            " WHERE deleted_at IS NOT NULL AND (deleted_at,id) < ($1,$2) ORDER BY deleted_at DESC,id DESC LIMIT $3")).
+            WithArgs(deletedAt, u[0].ID, 3).
+            WillReturnRows(pgxmock.NewRows(append(uHeader, "deleted_at")))
+
+        // actual method call
+        got, _, err := store.Gets(cq)
+
+        // test verification and validation
+        assert.NoError(t, err)
+        assert.Len(t, got, 0)
+        assert.NoError(t, mock.ExpectationsWereMet())
+    })
+}
+
+// TestUserStoreRestore will test behaviour of Restore method
+func TestUserStoreRestore(t *testing.T) {
+    // prepare mock and store
+    mock := PrepareMock(t)
+    store := NewUserStore(mock)
+
+    // EXPECT SUCCESS deleted user is restored
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserRestoreU)).
+            WithArgs(u[0].ID).
+            WillReturnRows(pgxmock.NewRows(uHeader).
+                AddRow(u[0].ID,u[0].Username,u[0].Firstname,u[0].Lastname,u[0].Email,
+                u[0].StatusID,u[0].RoleID,u[0].CreatedAt,u[0].UpdatedAt),
+            )
+
+        // actual method call
+        got, err := store.Restore(u[0].ID)
+
+        // test verification and validation
+        assert.NoError(t, err)
+        assert.Equal(t, u[0].ID, got.ID)
+        assert.Equal(t, u[0].Username, got.Username)
+        assert.True(t, got.DeletedAt.IsZero())
+    })
+
+    cases := []struct{
+        name    string
+        mockErr error
+        want    int
+    }{
+        {"EXPECT FAIL user is not deleted", pgx.ErrNoRows, E.ErrDataIsEmpty},
+        {"EXPECT FAIL username or email is taken", &pgconn.PgError{Code: pgUniqueViolation}, E.ErrUniqueViolation},
+        {"EXPECT FAIL database error", E.New(E.ErrDatabase), E.ErrDatabase},
+    }
+
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            mock.ExpectQuery(regexp.QuoteMeta(sqlUserRestoreU)).
+                WithArgs(u[0].ID).
+                WillReturnError(tt.mockErr)
+
+            // actual method call
+            got, err := store.Restore(u[0].ID)
+
+            // test verification and validation
+            assert.Nil(t, got)
+            assert.EqualValues(t, tt.want, E.Code(err))
+        })
+    }
+}
+
+// TestUserStorePurge will test behaviour of Purge method
+func TestUserStorePurge(t *testing.T) {
+    // prepare mock and store
+    mock := PrepareMock(t)
+    store := NewUserStore(mock)
+    header := []string{"in_use"}
+
+    // EXPECT SUCCESS deleted user is removed
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserPurgeD)).
+            WithArgs(u[0].ID).
+            WillReturnRows(pgxmock.NewRows(header).AddRow(false))
+
+        err := store.Purge(u[0].ID)
+
+        assert.NoError(t, err)
+    })
+
+    // EXPECT FAIL user is not found or not deleted
+    t.Run("EXPECT FAIL user is not deleted", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserPurgeD)).
+            WithArgs(u[0].ID).
+            WillReturnError(pgx.ErrNoRows)
+
+        err := store.Purge(u[0].ID)
+
+        assert.EqualValues(t, E.ErrDataIsEmpty, err.(*E.Error).Code)
+    })
+
+    // EXPECT FAIL user is still referenced by mail app membership
+    t.Run("EXPECT FAIL user is member of mail app", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserPurgeD)).
+            WithArgs(u[0].ID).
+            WillReturnRows(pgxmock.NewRows(header).AddRow(true))
+
+        err := store.Purge(u[0].ID)
+
+        assert.EqualValues(t, E.ErrDataIsInUse, err.(*E.Error).Code)
+    })
+
+    // EXPECT FAIL user is still referenced by other record
+    t.Run("EXPECT FAIL user is in use", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserPurgeD)).
+            WithArgs(u[0].ID).
+            WillReturnError(&pgconn.PgError{Code: pgForeignKeyViolation})
+
+        err := store.Purge(u[0].ID)
+
+        assert.EqualValues(t, E.ErrDataIsInUse, err.(*E.Error).Code)
+    })
+
+    // EXPECT FAIL database error
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectQuery(regexp.QuoteMeta(sqlUserPurgeD)).
+            WithArgs(u[0].ID).
+            WillReturnError(fmt.Errorf("connection lost"))
+
+        err := store.Purge(u[0].ID)
+
+        assert.EqualValues(t, E.ErrDatabase, err.(*E.Error).Code)
+    })
+
+    assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestUserStorePurgeDeleted will test behaviour of PurgeDeleted method
+func TestUserStorePurgeDeleted(t *testing.T) {
+    // prepare mock and store
+    mock := PrepareMock(t)
+    store := NewUserStore(mock)
+
+    // EXPECT SUCCESS expired user is removed and counted
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlUserPurgeExpiredD)).
+            WithArgs(30).
+            WillReturnResult(pgxmock.NewResult("DELETE", 4))
+
+        got, err := store.PurgeDeleted(30)
+
+        assert.NoError(t, err)
+        assert.Equal(t, int64(4), got)
+    })
+
+    // EXPECT FAIL database error
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlUserPurgeExpiredD)).
+            WithArgs(30).
+            WillReturnError(fmt.Errorf("connection lost"))
+
+        got, err := store.PurgeDeleted(30)
+
+        assert.Error(t, err)
+        assert.Equal(t, int64(0), got)
+    })
+}
+
 // TestGetUserCredential will test behaviour of GetCredential method
 func TestGetUserCredential(t *testing.T) {
     // prepare mock
@@ -369,8 +911,8 @@ func TestGetUserCredential(t *testing.T) {
     t.Run("EXPECT SUCCESS", func(t *testing.T){
         mock.ExpectQuery(regexp.QuoteMeta(sqlCredentialR)).
             WithArgs(u[0].Username,u[0].PassKey).
-            WillReturnRows(pgxmock.NewRows([]string{"id","username","passkey","status_id"}).
-                AddRow(u[0].ID,u[0].Username,u[0].PassKey,u[0].StatusID))
+            WillReturnRows(pgxmock.NewRows([]string{"id","username","passkey","status_id","role_id"}).
+                AddRow(u[0].ID,u[0].Username,u[0].PassKey,u[0].StatusID,u[0].RoleID))
 
         // call actual method to test
         cred, err := store.GetCredential(u[0].Username,u[0].PassKey)
@@ -420,8 +962,8 @@ func TestGetByEmail(t *testing.T) {
     t.Run("EXPECT SUCCESS", func(t *testing.T){
         mock.ExpectQuery(regexp.QuoteMeta(sqlGetUserByEmail)).
             WithArgs(u[0].Email).
-            WillReturnRows(pgxmock.NewRows([]string{"id","username","passkey","status_id"}).
-                AddRow(u[0].ID,u[0].Username,u[0].PassKey,u[0].StatusID))
+            WillReturnRows(pgxmock.NewRows([]string{"id","username","passkey","status_id","role_id"}).
+                AddRow(u[0].ID,u[0].Username,u[0].PassKey,u[0].StatusID,u[0].RoleID))
 
         // call actual method to test
         cred, err := store.GetByEmail(u[0].Email)
@@ -525,3 +1067,43 @@ func TestIsUserExist(t *testing.T) {
         assert.Equal(t, false, exist)
     }) 
 }
+
+// TestUserStoreUpdatePassKey will test behaviour of UpdatePassKey method
+func TestUserStoreUpdatePassKey(t *testing.T) {
+    // prepare mock and store
+    mock := PrepareMock(t)
+    store := NewUserStore(mock)
+
+    // EXPECT SUCCESS passkey is replaced
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlUserPassKeyU)).
+            WithArgs(u[0].ID, "old-hash", "new-hash").
+            WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+        err := store.UpdatePassKey(u[0].ID, "old-hash", "new-hash")
+
+        assert.NoError(t, err)
+    })
+
+    // EXPECT FAIL user is not found or its passkey is changed
+    t.Run("EXPECT FAIL passkey is changed", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlUserPassKeyU)).
+            WithArgs(u[0].ID, "old-hash", "new-hash").
+            WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+        err := store.UpdatePassKey(u[0].ID, "old-hash", "new-hash")
+
+        assert.EqualValues(t, E.ErrDataIsEmpty, err.(*E.Error).Code)
+    })
+
+    // EXPECT FAIL database error
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        mock.ExpectExec(regexp.QuoteMeta(sqlUserPassKeyU)).
+            WithArgs(u[0].ID, "old-hash", "new-hash").
+            WillReturnError(fmt.Errorf("connection lost"))
+
+        err := store.UpdatePassKey(u[0].ID, "old-hash", "new-hash")
+
+        assert.EqualValues(t, E.ErrDatabase, err.(*E.Error).Code)
+    })
+}
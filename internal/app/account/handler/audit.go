@@ -0,0 +1,54 @@
+/*
+   package handler
+   audit.go
+   - handler/ interaction layer for audit log (record of change made on the account app)
+   - NOTE of method:
+   - -- AuditGetsHandler : method to get audit log page filtered by the request query
+*/
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/reshimahendra/lbw-go/internal/app/account/service"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	"github.com/reshimahendra/lbw-go/internal/pkg/helper"
+)
+
+// AuditHandler is type wrapper for audit service interface
+type AuditHandler struct {
+    Service service.IAuditService
+}
+
+// NewAuditHandler is new instance of AuditHandler
+func NewAuditHandler(Service service.IAuditService) *AuditHandler{
+    return &AuditHandler{Service}
+}
+
+// AuditGetsHandler is handler layer to get audit log page. the list is filtered by the
+// actor, action, target and creation date, and paginated by the request query
+func (h *AuditHandler) AuditGetsHandler(c *gin.Context) {
+    // read pagination, sorting and filter from the request query
+    query, err := helper.GetListQuery(c, d.AuditSortFields)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    // send request to service layer to retreive audit log record
+    response, meta, err := h.Service.Gets(*query)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    // send response to client along with the list metadata
+    helper.APIListResponse(
+        c,
+        http.StatusOK,
+        "success getting audit log",
+        response,
+        meta,
+    )
+}
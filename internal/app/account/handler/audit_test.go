@@ -0,0 +1,76 @@
+/*
+   package handler
+   audit_test.go
+   - testing behaviour of audit handler
+*/
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockAuditHandler is mocked audit service interface
+type mockAuditHandler struct{}
+
+// Record is mocked Record method of IAuditService.Record
+func (m *mockAuditHandler) Record(entry d.AuditLog) error {
+    return nil
+}
+
+// Gets is mocked Gets method of IAuditService.Gets
+func (m *mockAuditHandler) Gets(q d.ListQuery) ([]*d.AuditLog, *d.ListMeta, error) {
+    if wantErr {
+        return nil, nil, E.New(E.ErrDatabase)
+    }
+
+    // cursor the datastore could not read
+    if q.Cursor != nil && q.Cursor.ID == "invalid" {
+        return nil, nil, E.New(E.ErrDataIsInvalid)
+    }
+
+    logs := []*d.AuditLog{{ID: 1, ActorID: &u[0].ID, Action: q.Action, TargetType: q.TargetType, TargetID: q.TargetID}}
+    return logs, &d.ListMeta{Total: 1, Page: q.Page, Limit: q.Limit, Sort: q.Sort, Order: q.Order}, nil
+}
+
+// TestAuditGetsHandler will test behaviour of AuditGetsHandler
+func TestAuditGetsHandler(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+    handler := NewAuditHandler(&mockAuditHandler{})
+    invalidCursor := (&d.ListCursor{Sort: "created_at", Order: d.SortAsc, ID: "invalid"}).Encode()
+
+    cases := []struct{
+        name    string
+        query   string
+        wantErr bool
+        want    int
+    }{
+        {"EXPECT SUCCESS", "?actor_id=" + u[0].ID.String() + "&action=update&target_type=role&target_id=4&order=desc", false, http.StatusOK},
+        {"EXPECT FAIL invalid actor", "?actor_id=leonard", false, http.StatusBadRequest},
+        {"EXPECT FAIL unknown sort field", "?sort=action", false, http.StatusBadRequest},
+        {"EXPECT FAIL invalid cursor", "?cursor=" + invalidCursor, false, http.StatusBadRequest},
+        {"EXPECT FAIL service error", "", true, http.StatusInternalServerError},
+    }
+
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            writer, context := NewTestWriterContext()
+            context.Request, _ = http.NewRequest("GET", "/audit/"+tt.query, nil)
+
+            wantErr = tt.wantErr
+            ServeTestContext(context, handler.AuditGetsHandler)
+            wantErr = false
+
+            assert.Equal(t, tt.want, writer.Code)
+            if tt.want == http.StatusOK {
+                assert.Contains(t, writer.Body.String(), `"target_type":"role"`)
+                assert.Contains(t, writer.Body.String(), `"total":1`)
+            }
+        })
+    }
+}
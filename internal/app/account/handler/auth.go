@@ -2,6 +2,155 @@
    handler package
    auth.go
    - interaction/ handler layer for authentification
+   - NOTE of method:
+   - -- SignoutHandler : method to signout/ revoke access and refresh token
+   - -- JWKSHandler    : method to publish public key to verify auth token
+   - -- SigningKeysHandler       : method to get state of the signing key ring
+   - -- PromoteSigningKeyHandler : method to make the signing key sign new token
+   - -- RetireSigningKeyHandler  : method to remove the signing key from the ring
 */
 package handler
 
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/reshimahendra/lbw-go/internal/app/account/service"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/helper"
+)
+
+// AuthHandler is type wrapper for auth service interface
+type AuthHandler struct {
+    Service service.IAuthService
+}
+
+// NewAuthHandler is new instance of AuthHandler
+func NewAuthHandler(Service service.IAuthService) *AuthHandler{
+    return &AuthHandler{Service}
+}
+
+// SignoutHandler is handler/ controller to signout user. It will revoke 
+// the access token on the 'Authorization' header and the refresh token on request body
+func (h *AuthHandler) SignoutHandler(c *gin.Context) {
+    // get access token from the header
+    var accessToken string
+    bearerToken := c.GetHeader("Authorization")
+    authArray := strings.Split(bearerToken, " ")
+    if len(authArray) == 2 {
+        accessToken = authArray[1]
+    }
+
+    if accessToken == "" {
+        c.Error(E.New(E.ErrTokenNotFound))
+
+        return
+    }
+
+    // get refresh token from the request body. the body is optional
+    var req d.SignoutRequest
+    if c.Request.ContentLength > 0 {
+        if err := helper.BindJSON(c, &req); err != nil {
+            c.Error(err)
+
+            return
+        }
+    }
+
+    // send request to service layer to revoke the tokens
+    if err := h.Service.Signout(accessToken, req.RefreshToken); err != nil {
+        // failing to write the denylist is server error, otherwise the token is invalid
+        c.Error(err)
+
+        return
+    }
+
+    // send response to client
+    helper.APIResponse(
+        c,
+        http.StatusOK,
+        "success signout",
+        nil,
+    )
+}
+
+// JWKSHandler is handler/ controller to publish the public key set (JWKS) so other
+// services can verify our auth token without holding the signing secret
+func (h *AuthHandler) JWKSHandler(c *gin.Context) {
+    // send request to service layer to get the public key set
+    jwks, err := h.Service.JWKS()
+    if err != nil {
+        c.Error(err)
+
+        return
+    }
+
+    // JWKS is served as is (not wrapped) so it can be consumed by any jwt library
+    c.Header("Cache-Control", "public, max-age=3600")
+    c.JSON(http.StatusOK, jwks)
+}
+
+// SigningKeysHandler is handler/ controller to get state of all keys on the signing key ring
+func (h *AuthHandler) SigningKeysHandler(c *gin.Context) {
+    // send request to service layer to get the signing keys
+    keys, err := h.Service.SigningKeys()
+    if err != nil {
+        c.Error(err)
+
+        return
+    }
+
+    // send response to client
+    helper.APIResponse(
+        c,
+        http.StatusOK,
+        "success get signing keys",
+        keys,
+    )
+}
+
+// PromoteSigningKeyHandler is handler/ controller to make the signing key with the
+// given id sign new token. the previous active key keep verifying its token
+func (h *AuthHandler) PromoteSigningKeyHandler(c *gin.Context) {
+    kid := c.Param("kid")
+
+    // send request to service layer to promote the signing key
+    if err := h.Service.PromoteSigningKey(kid); err != nil {
+        c.Error(err)
+
+        return
+    }
+    helper.SetAudit(c, d.NewAuditLog(d.AuditActionPromote, d.AuditTargetSigningKey, kid, nil, nil))
+
+    // send response to client
+    helper.APIResponse(
+        c,
+        http.StatusOK,
+        "success promote signing key",
+        kid,
+    )
+}
+
+// RetireSigningKeyHandler is handler/ controller to remove the signing key with
+// the given id from the ring after the longest token lifetime has passed
+func (h *AuthHandler) RetireSigningKeyHandler(c *gin.Context) {
+    kid := c.Param("kid")
+
+    // send request to service layer to retire the signing key
+    if err := h.Service.RetireSigningKey(kid); err != nil {
+        c.Error(err)
+
+        return
+    }
+    helper.SetAudit(c, d.NewAuditLog(d.AuditActionRetire, d.AuditTargetSigningKey, kid, nil, nil))
+
+    // send response to client
+    helper.APIResponse(
+        c,
+        http.StatusOK,
+        "success retire signing key",
+        kid,
+    )
+}
@@ -0,0 +1,134 @@
+/*
+   package handler
+   auth.oidc.go
+   - handler/ interaction layer for signin with openid connect provider (social login)
+   - NOTE of method:
+   - -- OIDCProvidersHandler : method to get name of the provider the user can signin with
+   - -- OIDCAuthorizeHandler : method to start signin and get the provider authorization url
+   - -- OIDCCallbackHandler  : method to complete signin with the code sent back by the provider
+*/
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/reshimahendra/lbw-go/internal/app/account/service"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/helper"
+	"github.com/reshimahendra/lbw-go/internal/pkg/logger"
+)
+
+// OIDCHandler is type wrapper for oidc service interface
+type OIDCHandler struct {
+    Service service.IOIDCService
+
+    // Auth is auth service used to issue token on signin
+    Auth service.IAuthService
+
+    // TwoFactor is user.totp service used to check whether signin need the two factor code
+    TwoFactor service.IUserTOTPService
+}
+
+// NewOIDCHandler is new instance of OIDCHandler
+func NewOIDCHandler(Service service.IOIDCService, Auth service.IAuthService, TwoFactor service.IUserTOTPService) *OIDCHandler{
+    return &OIDCHandler{Service, Auth, TwoFactor}
+}
+
+// OIDCProvidersHandler is handler layer to get name of the provider the user can signin with
+func (h *OIDCHandler) OIDCProvidersHandler(c *gin.Context) {
+    // send response to client
+    helper.APIResponse(
+        c,
+        http.StatusOK,
+        "success getting signin provider",
+        h.Service.ProviderNames(),
+    )
+}
+
+// OIDCAuthorizeHandler is handler layer to start signin with the provider. the client
+// send the user to the authorization url, the provider send the user back to the
+// redirect url with the authorization code and the state
+func (h *OIDCHandler) OIDCAuthorizeHandler(c *gin.Context) {
+    // send request to service layer to start the signin
+    response, err := h.Service.Authorize(c.Param("provider"))
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    // send response to client
+    helper.APIResponse(
+        c,
+        http.StatusOK,
+        "success starting signin",
+        response,
+    )
+}
+
+// OIDCCallbackHandler is handler layer to complete signin with the authorization code
+// and the state sent back by the provider. user with two factor authentication enabled
+// only get the pending token, like on password signin
+func (h *OIDCHandler) OIDCCallbackHandler(c *gin.Context) {
+    // get callback data from query string
+    var req d.OIDCCallbackRequest
+    if err := helper.BindQuery(c, &req); err != nil {
+        c.Error(err)
+        return
+    }
+
+    // the user denied the signin or the provider fail to authenticate the user
+    if req.Error != "" {
+        logger.Errorf("oidc provider %s return error: %s %s", c.Param("provider"), req.Error, req.ErrorDescription)
+        c.Error(E.New(E.ErrSignIn))
+        return
+    }
+
+    // send request to service layer to complete the signin
+    principal, err := h.Service.Callback(c.Param("provider"), req.Code, req.State)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    twoFactorEnabled, err := h.TwoFactor.IsEnabled(principal.UserID)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+    if twoFactorEnabled {
+        pending, err := h.TwoFactor.IssuePendingToken(*principal)
+        if err != nil {
+            c.Error(E.NewExt(E.ErrTokenCreate, err))
+            return
+        }
+
+        helper.APIResponse(
+            c,
+            http.StatusOK,
+            "two factor authentication required",
+            pending,
+        )
+        return
+    }
+
+    // signin start a new refresh token family
+    token, err := h.Auth.IssueToken(*principal, sessionClient(c))
+    if err != nil {
+        c.Error(E.NewExt(E.ErrTokenCreate, err))
+        return
+    }
+
+    // send token data response to the client
+    helper.APIResponse(
+        c,
+        http.StatusOK,
+        "success signin",
+        d.AuthLoginResponse{
+            AccessToken     : token.AccessToken,
+            RefreshToken    : token.RefreshToken,
+            TransmissionKey : token.TransmissionKey,
+        },
+    )
+}
@@ -0,0 +1,154 @@
+/*
+   package handler
+   auth.oidc_test.go
+   - test unit for openid connect signin handler
+*/
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/reshimahendra/lbw-go/internal/config"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockOIDCHandler is mocked oidc service. the callback result is chosen by the code
+type mockOIDCHandler struct{}
+
+// ProviderNames is mocked ProviderNames method to satisfy IOIDCService interface
+func (m *mockOIDCHandler) ProviderNames() []string {
+    return []string{"google"}
+}
+
+// Authorize is mocked Authorize method to satisfy IOIDCService interface
+func (m *mockOIDCHandler) Authorize(provider string) (*d.OIDCAuthorizeResponse, error) {
+    if provider != "google" {
+        return nil, E.New(E.ErrOIDCProviderNotFound)
+    }
+    if wantErr {
+        return nil, E.New(E.ErrDatabase)
+    }
+
+    return &d.OIDCAuthorizeResponse{
+        AuthorizationURL : "https://accounts.google.com/o/oauth2/v2/auth?state=state",
+        State            : "state",
+    }, nil
+}
+
+// Callback is mocked Callback method to satisfy IOIDCService interface
+func (m *mockOIDCHandler) Callback(provider, code, state string) (*d.Principal, error) {
+    switch code {
+    case "exchange-fail":
+        return nil, E.New(E.ErrOIDCExchange)
+    case "unverified":
+        return nil, E.New(E.ErrOIDCEmailNotVerified)
+    case "two-factor":
+        return &d.Principal{UserID: twoFactorUserID, Email: "leo@gmail.com", StatusID: 1}, nil
+    case "two-factor-error":
+        return &d.Principal{UserID: twoFactorErrUserID, Email: "leo@gmail.com", StatusID: 1}, nil
+    }
+    if state != "state" {
+        return nil, E.New(E.ErrOIDCStateInvalid)
+    }
+
+    return &d.Principal{UserID: uuid.New(), Email: "leo@gmail.com", RoleID: d.RoleGuest, StatusID: 1}, nil
+}
+
+// NewTestOIDCHandler will create oidc handler using mocked service
+func NewTestOIDCHandler(t *testing.T) *OIDCHandler {
+    return NewOIDCHandler(&mockOIDCHandler{}, NewMockAuthHandler(t), NewMockUserTOTPHandler(t))
+}
+
+// TestOIDCProvidersHandler will test behaviour of OIDCProvidersHandler
+func TestOIDCProvidersHandler(t *testing.T) {
+    handler := NewTestOIDCHandler(t)
+    writer, context := NewTestWriterContext()
+    context.Request, _ = http.NewRequest("GET", "/oidc/", nil)
+
+    ServeTestContext(context, handler.OIDCProvidersHandler)
+
+    assert.Equal(t, http.StatusOK, writer.Code)
+    assert.Contains(t, writer.Body.String(), `"google"`)
+}
+
+// TestOIDCAuthorizeHandler will test behaviour of OIDCAuthorizeHandler
+func TestOIDCAuthorizeHandler(t *testing.T) {
+    handler := NewTestOIDCHandler(t)
+
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        writer, context := NewTestWriterContext()
+        context.Params = gin.Params{{Key: "provider", Value: "google"}}
+        context.Request, _ = http.NewRequest("GET", "/oidc/google", nil)
+
+        ServeTestContext(context, handler.OIDCAuthorizeHandler)
+
+        assert.Equal(t, http.StatusOK, writer.Code)
+        assert.Contains(t, writer.Body.String(), "authorization_url")
+    })
+
+    t.Run("EXPECT FAIL provider not found", func(t *testing.T){
+        writer, context := NewTestWriterContext()
+        context.Params = gin.Params{{Key: "provider", Value: "unknown"}}
+        context.Request, _ = http.NewRequest("GET", "/oidc/unknown", nil)
+
+        ServeTestContext(context, handler.OIDCAuthorizeHandler)
+
+        assert.Equal(t, http.StatusNotFound, writer.Code)
+        assert.Contains(t, writer.Body.String(), E.ErrOIDCProviderNotFoundMsg)
+    })
+
+    // EXPECT FAIL service error. Simulated by setting wantErr=true
+    t.Run("EXPECT FAIL service error", func(t *testing.T){
+        writer, context := NewTestWriterContext()
+        context.Params = gin.Params{{Key: "provider", Value: "google"}}
+        context.Request, _ = http.NewRequest("GET", "/oidc/google", nil)
+
+        wantErr = true
+        ServeTestContext(context, handler.OIDCAuthorizeHandler)
+        wantErr = false
+
+        assert.Equal(t, http.StatusInternalServerError, writer.Code)
+    })
+}
+
+// TestOIDCCallbackHandler will test behaviour of OIDCCallbackHandler
+func TestOIDCCallbackHandler(t *testing.T) {
+    handler := NewTestOIDCHandler(t)
+
+    // prepare config, it is needed to create the token
+    err := config.Setup()
+    assert.NoError(t, err)
+
+    tests := []struct{
+        name     string
+        query    string
+        wantCode int
+        wantBody string
+    }{
+        {"EXPECT SUCCESS", "code=code&state=state", http.StatusOK, "success signin"},
+        {"EXPECT SUCCESS two factor required", "code=two-factor&state=state", http.StatusOK, "two factor authentication required"},
+        {"EXPECT FAIL provider error", "error=access_denied&state=state", http.StatusUnauthorized, E.ErrSignInMsg},
+        {"EXPECT FAIL state invalid", "code=code&state=other", http.StatusBadRequest, E.ErrOIDCStateInvalidMsg},
+        {"EXPECT FAIL exchange error", "code=exchange-fail&state=state", http.StatusBadGateway, E.ErrOIDCExchangeMsg},
+        {"EXPECT FAIL email not verified", "code=unverified&state=state", http.StatusForbidden, E.ErrOIDCEmailNotVerifiedMsg},
+        {"EXPECT FAIL two factor check error", "code=two-factor-error&state=state", http.StatusInternalServerError, E.ErrDatabaseMsg},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T){
+            writer, context := NewTestWriterContext()
+            context.Params = gin.Params{{Key: "provider", Value: "google"}}
+            context.Request, _ = http.NewRequest("GET", "/oidc/google/callback?"+tt.query, nil)
+
+            ServeTestContext(context, handler.OIDCCallbackHandler)
+
+            assert.Equal(t, tt.wantCode, writer.Code)
+            assert.Contains(t, writer.Body.String(), tt.wantBody)
+        })
+    }
+}
@@ -0,0 +1,323 @@
+/*
+   package handler
+   auth_test.go
+   - testing behaviour of auth handler
+*/
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	"github.com/reshimahendra/lbw-go/internal/pkg/auth"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockAuthHandler is mocked auth handler for our auth service interface
+type mockAuthHandler struct {
+    t *testing.T
+
+    // revokedUsers is user id whose all token were revoked
+    revokedUsers []uuid.UUID
+}
+
+// NewMockAuthHandler is new instance to our mockAuthHandler
+func NewMockAuthHandler(t *testing.T) *mockAuthHandler{
+    return &mockAuthHandler{t: t}
+}
+
+// Signout is mocked Signout method of IAuthService.Signout
+func (m *mockAuthHandler) Signout(accessToken, refreshToken string) error {
+    if accessToken == "invalid" {
+        return E.New(E.ErrTokenInvalid)
+    }
+    if wantErr {
+        return E.NewExt(E.ErrSignOut, E.New(E.ErrDatabase))
+    }
+
+    return nil
+}
+
+// IsTokenRevoked is mocked IsTokenRevoked method of IAuthService.IsTokenRevoked
+func (m *mockAuthHandler) IsTokenRevoked(token d.TokenMetadata) (bool, error) {
+    return wantErr, nil
+}
+
+// RevokeUserTokens is mocked RevokeUserTokens method of IAuthService.RevokeUserTokens
+func (m *mockAuthHandler) RevokeUserTokens(userID uuid.UUID) error {
+    if userID == revokeFailUserID {
+        return E.New(E.ErrDatabase)
+    }
+    m.revokedUsers = append(m.revokedUsers, userID)
+
+    return nil
+}
+
+// IssueToken is mocked IssueToken method of IAuthService.IssueToken
+func (m *mockAuthHandler) IssueToken(principal d.Principal, client d.SessionClient) (*d.TokenDetailsDTO, error) {
+    token, err := auth.CreateToken(principal)
+    if err != nil {
+        return nil, E.New(E.ErrTokenCreate)
+    }
+
+    return token, nil
+}
+
+// Refresh is mocked Refresh method of IAuthService.Refresh
+func (m *mockAuthHandler) Refresh(refreshToken string, client d.SessionClient) (*d.TokenDetailsDTO, error) {
+    switch refreshToken {
+    case "":
+        return nil, E.New(E.ErrTokenInvalid)
+    case "reused":
+        return nil, E.New(E.ErrRefreshTokenReused)
+    }
+    if wantErr {
+        return nil, E.NewExt(E.ErrTokenCreate, E.New(E.ErrDatabase))
+    }
+
+    return &d.TokenDetailsDTO{AccessToken: "access", RefreshToken: "refresh"}, nil
+}
+
+// JWKS is mocked JWKS method of IAuthService.JWKS
+func (m *mockAuthHandler) JWKS() (*d.JWKS, error) {
+    if wantErr {
+        return nil, E.New(E.ErrSigningKey)
+    }
+
+    return &d.JWKS{Keys: []d.JWK{{Kty: "OKP", Use: "sig", Kid: "key-1", Alg: "EdDSA", Crv: "Ed25519", X: "x"}}}, nil
+}
+
+// SigningKeys is mocked SigningKeys method of IAuthService.SigningKeys
+func (m *mockAuthHandler) SigningKeys() ([]*d.SigningKeyResponse, error) {
+    if wantErr {
+        return nil, E.New(E.ErrSigningKey)
+    }
+
+    return []*d.SigningKeyResponse{{ID: "key-1", Alg: "EdDSA", Active: true, CanSign: true}}, nil
+}
+
+// PromoteSigningKey is mocked PromoteSigningKey method of IAuthService.PromoteSigningKey
+func (m *mockAuthHandler) PromoteSigningKey(kid string) error {
+    return mockSigningKeyErr(kid)
+}
+
+// RetireSigningKey is mocked RetireSigningKey method of IAuthService.RetireSigningKey
+func (m *mockAuthHandler) RetireSigningKey(kid string) error {
+    return mockSigningKeyErr(kid)
+}
+
+// mockSigningKeyErr will get the signing key error simulated by the given key id
+func mockSigningKeyErr(kid string) error {
+    switch kid {
+    case "not-found":
+        return E.New(E.ErrDataNotFound)
+    case "in-use":
+        return E.New(E.ErrSigningKeyInUse)
+    case "verify-only":
+        return E.New(E.ErrSigningKeyVerifyOnly)
+    case "error":
+        return E.New(E.ErrServer)
+    }
+
+    return nil
+}
+
+// NewTestAuthHandler is function wrapper to get the mock handler of our handler layer
+func NewTestAuthHandler(t *testing.T) *AuthHandler{
+    t.Helper()
+
+    // set gin to test mode
+    gin.SetMode(gin.TestMode)
+
+    // prepare mock
+    mock := NewMockAuthHandler(t)
+    handler := NewAuthHandler(mock)
+
+    // return mocked handler
+    return handler
+}
+
+// TestSignoutHandler will test behaviour of SignoutHandler method of handler layer
+func TestSignoutHandler(t *testing.T) {
+    // prepare the test handler
+    handler := NewTestAuthHandler(t)
+
+    cases := []struct{
+        name, accessToken string
+        body interface{}
+        wantErr bool
+        wantCode int
+        wantMsg string
+    }{
+        {"EXPECT SUCCESS", "valid", d.SignoutRequest{RefreshToken: "refresh"}, false, http.StatusOK, "success signout"},
+        {"EXPECT SUCCESS without body", "valid", nil, false, http.StatusOK, "success signout"},
+        {"EXPECT FAIL token not found", "", nil, false, http.StatusUnauthorized, E.ErrTokenNotFoundMsg},
+        {"EXPECT FAIL bind json error", "valid", "invalid", false, http.StatusBadRequest, E.ErrRequestDataInvalidMsg},
+        {"EXPECT FAIL token invalid", "invalid", nil, false, http.StatusUnauthorized, E.ErrTokenInvalidMsg},
+        {"EXPECT FAIL revoke error", "valid", nil, true, http.StatusInternalServerError, E.ErrSignOutMsg},
+    }
+
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            // prepare request/ response / gin context
+            writer, context := NewTestWriterContext()
+
+            // inject json to request body
+            var body []byte
+            if tt.body != nil {
+                var err error
+                body, err = json.Marshal(tt.body)
+                assert.NoError(t, err)
+            }
+            var err error
+            context.Request, err = http.NewRequest("POST", "/signout", bytes.NewBuffer(body))
+            assert.NoError(t, err)
+
+            // set content type and token header
+            context.Request.Header.Add("content-type", "application/json")
+            if tt.accessToken != "" {
+                context.Request.Header.Add("Authorization", "Bearer "+tt.accessToken)
+            }
+
+            // actual method handler call
+            wantErr = tt.wantErr
+            ServeTestContext(context, handler.SignoutHandler)
+            wantErr = false
+
+            // validation and verification
+            assert.Equal(t, tt.wantCode, writer.Code)
+            assert.Contains(t, string(writer.Body.Bytes()[:]), tt.wantMsg)
+        })
+    }
+}
+
+// TestJWKSHandler will test behaviour of JWKSHandler method of handler layer
+func TestJWKSHandler(t *testing.T) {
+    // prepare the test handler
+    handler := NewTestAuthHandler(t)
+
+    // EXPECT SUCCESS public key set is served without api response wrapper
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        // prepare request/ response / gin context
+        writer, context := NewTestWriterContext()
+
+        var err error
+        context.Request, err = http.NewRequest("GET", "/.well-known/jwks.json", nil)
+        assert.NoError(t, err)
+
+        // actual method handler call
+        ServeTestContext(context, handler.JWKSHandler)
+
+        // validation and verification
+        var got d.JWKS
+        assert.Equal(t, http.StatusOK, writer.Code)
+        assert.NoError(t, json.Unmarshal(writer.Body.Bytes(), &got))
+        assert.Equal(t, "key-1", got.Keys[0].Kid)
+    })
+
+    // EXPECT FAIL signing key error. Simulated by setting wantErr=true
+    t.Run("EXPECT FAIL signing key error", func(t *testing.T){
+        // prepare request/ response / gin context
+        writer, context := NewTestWriterContext()
+
+        var err error
+        context.Request, err = http.NewRequest("GET", "/.well-known/jwks.json", nil)
+        assert.NoError(t, err)
+
+        // actual method handler call
+        wantErr = true
+        ServeTestContext(context, handler.JWKSHandler)
+        wantErr = false
+
+        // validation and verification
+        assert.Equal(t, http.StatusInternalServerError, writer.Code)
+        assert.Contains(t, string(writer.Body.Bytes()[:]), E.ErrSigningKeyMsg)
+    })
+}
+
+// TestSigningKeysHandler will test behaviour of SigningKeysHandler method of handler layer
+func TestSigningKeysHandler(t *testing.T) {
+    // prepare the test handler
+    handler := NewTestAuthHandler(t)
+
+    cases := []struct{
+        name string
+        wantErr bool
+        wantCode int
+        wantMsg string
+    }{
+        {"EXPECT SUCCESS", false, http.StatusOK, "success get signing keys"},
+        {"EXPECT FAIL signing key error", true, http.StatusInternalServerError, E.ErrSigningKeyMsg},
+    }
+
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            // prepare request/ response / gin context
+            writer, context := NewTestWriterContext()
+
+            var err error
+            context.Request, err = http.NewRequest("GET", "/keys", nil)
+            assert.NoError(t, err)
+
+            // actual method handler call
+            wantErr = tt.wantErr
+            ServeTestContext(context, handler.SigningKeysHandler)
+            wantErr = false
+
+            // validation and verification
+            assert.Equal(t, tt.wantCode, writer.Code)
+            assert.Contains(t, string(writer.Body.Bytes()[:]), tt.wantMsg)
+        })
+    }
+}
+
+// TestPromoteRetireSigningKeyHandler will test behaviour of PromoteSigningKeyHandler
+// and RetireSigningKeyHandler method of handler layer
+func TestPromoteRetireSigningKeyHandler(t *testing.T) {
+    // prepare the test handler
+    handler := NewTestAuthHandler(t)
+
+    cases := []struct{
+        name, kid string
+        wantCode int
+        wantMsg string
+    }{
+        {"EXPECT SUCCESS", "key-1", http.StatusOK, "key-1"},
+        {"EXPECT FAIL key not found", "not-found", http.StatusNotFound, E.ErrDataNotFoundMsg},
+        {"EXPECT FAIL key in use", "in-use", http.StatusConflict, E.ErrSigningKeyInUseMsg},
+        {"EXPECT FAIL verify only key", "verify-only", http.StatusUnprocessableEntity, E.ErrSigningKeyVerifyOnlyMsg},
+        {"EXPECT FAIL server error", "error", http.StatusInternalServerError, E.ErrServerMsg},
+    }
+
+    handlers := map[string]gin.HandlerFunc{
+        "promote": handler.PromoteSigningKeyHandler,
+        "retire" : handler.RetireSigningKeyHandler,
+    }
+
+    for action, handlerFunc := range handlers {
+        for _, tt := range cases {
+            t.Run(action+" "+tt.name, func(t *testing.T){
+                // prepare request/ response / gin context
+                writer, context := NewTestWriterContext()
+
+                var err error
+                context.Request, err = http.NewRequest("POST", "/keys/"+tt.kid, nil)
+                assert.NoError(t, err)
+                context.Params = gin.Params{{Key: "kid", Value: tt.kid}}
+
+                // actual method handler call
+                ServeTestContext(context, handlerFunc)
+
+                // validation and verification
+                assert.Equal(t, tt.wantCode, writer.Code)
+                assert.Contains(t, string(writer.Body.Bytes()[:]), tt.wantMsg)
+            })
+        }
+    }
+}
@@ -0,0 +1,132 @@
+/*
+   package handler
+   permission.go
+   - handler/ interaction layer for permission catalogue and permission granted to user.role
+   - NOTE of method:
+   - -- PermissionGetsHandler       : method to get the permission catalogue
+   - -- RolePermissionGetsHandler   : method to get permission granted to user.role
+   - -- RolePermissionGrantHandler  : method to grant permission to user.role
+   - -- RolePermissionRevokeHandler : method to revoke permission from user.role
+*/
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/reshimahendra/lbw-go/internal/app/account/service"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/helper"
+)
+
+// PermissionHandler is type wrapper for permission service interface
+type PermissionHandler struct {
+    // Service is interfaces to permission service
+    Service service.IPermissionService
+}
+
+// NewPermissionHandler is instance to PermissionHandler
+func NewPermissionHandler(Service service.IPermissionService) *PermissionHandler{
+    return &PermissionHandler{Service}
+}
+
+// PermissionGetsHandler is handler to get the permission catalogue
+func (h *PermissionHandler) PermissionGetsHandler(c *gin.Context) {
+    // send request to service layer to retreive the permission catalogue
+    response, err := h.Service.Gets()
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    // send response to client
+    helper.APIResponse(
+        c,
+        http.StatusOK,
+        "success get permission data",
+        response,
+    )
+}
+
+// RolePermissionGetsHandler is handler to get permission granted to user.role based on its id
+func (h *PermissionHandler) RolePermissionGetsHandler(c *gin.Context) {
+    // get 'id' param from the request context
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.Error(E.New(E.ErrParamIsInvalid))
+        return
+    }
+
+    // send request to service layer to retreive the granted permission
+    response, err := h.Service.GetsByRole(id)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    // send response to client
+    helper.APIResponse(
+        c,
+        http.StatusOK,
+        "success get user.role permission data",
+        response,
+    )
+}
+
+// RolePermissionGrantHandler is handler to grant permission with the 'code' param to user.role
+// based on its id. granting permission already granted is not an error
+func (h *PermissionHandler) RolePermissionGrantHandler(c *gin.Context) {
+    h.changeRolePermission(c, h.Service.Grant, d.AuditActionGrant, "success grant user.role permission")
+}
+
+// RolePermissionRevokeHandler is handler to revoke permission with the 'code' param from
+// user.role based on its id
+func (h *PermissionHandler) RolePermissionRevokeHandler(c *gin.Context) {
+    h.changeRolePermission(c, h.Service.Revoke, d.AuditActionRevoke, "success revoke user.role permission")
+}
+
+// changeRolePermission will grant or revoke permission of user.role on behalf of the current user.
+// the granted permission is the value after the change, the revoked one is the value before it
+func (h *PermissionHandler) changeRolePermission(c *gin.Context, change func(principal d.Principal, roleID int, code string) error, action, msg string) {
+    // get the current user
+    principal, ok := helper.GetPrincipal(c)
+    if !ok {
+        c.Error(E.New(E.ErrTokenNotFound))
+        return
+    }
+
+    // get 'id' param from the request context
+    id, err := strconv.Atoi(c.Param("id"))
+    if err != nil {
+        c.Error(E.New(E.ErrParamIsInvalid))
+        return
+    }
+
+    // send request to service layer to change the permission
+    if err := change(*principal, id, c.Param("code")); err != nil {
+        c.Error(err)
+        return
+    }
+    permission := map[string]string{"permission": c.Param("code")}
+    if action == d.AuditActionGrant {
+        helper.SetAudit(c, d.NewAuditLog(action, d.AuditTargetRole, c.Param("id"), nil, permission))
+    } else {
+        helper.SetAudit(c, d.NewAuditLog(action, d.AuditTargetRole, c.Param("id"), permission, nil))
+    }
+
+    // send response along with the current permission of user.role
+    response, err := h.Service.GetsByRole(id)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    helper.APIResponse(
+        c,
+        http.StatusOK,
+        msg,
+        response,
+    )
+}
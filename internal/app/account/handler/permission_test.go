@@ -0,0 +1,181 @@
+/*
+   package handler
+   permission_test.go
+   - testing behaviour of permission handler
+*/
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/helper"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockPermissionHandler is mocked permission service interface
+type mockPermissionHandler struct {
+    changed []string
+}
+
+// Gets is mocked Gets method of IPermissionService.Gets
+func (m *mockPermissionHandler) Gets() ([]*d.Permission, error) {
+    if wantErr {
+        return nil, E.New(E.ErrDatabase)
+    }
+
+    return []*d.Permission{{Code: d.PermRoleRead}, {Code: d.PermUserRead}}, nil
+}
+
+// GetsByRole is mocked GetsByRole method of IPermissionService.GetsByRole
+func (m *mockPermissionHandler) GetsByRole(roleID int) ([]*d.Permission, error) {
+    if wantErr {
+        return nil, E.New(E.ErrDatabase)
+    }
+
+    return []*d.Permission{{Code: d.PermUserRead}}, nil
+}
+
+// Grant is mocked Grant method of IPermissionService.Grant
+func (m *mockPermissionHandler) Grant(principal d.Principal, roleID int, code string) error {
+    return m.change("grant", principal, roleID, code)
+}
+
+// Revoke is mocked Revoke method of IPermissionService.Revoke
+func (m *mockPermissionHandler) Revoke(principal d.Principal, roleID int, code string) error {
+    return m.change("revoke", principal, roleID, code)
+}
+
+// change will simulate the grant and revoke rule of the permission service
+func (m *mockPermissionHandler) change(action string, principal d.Principal, roleID int, code string) error {
+    switch {
+    case !d.IsValidPermissionCode(code):
+        return E.New(E.ErrDataIsInvalid)
+    case roleID == principal.RoleID:
+        return E.New(E.ErrForbidden)
+    case code == "blog:write":
+        return E.New(E.ErrDataIsEmpty)
+    }
+
+    m.changed = append(m.changed, action+" "+code)
+    return nil
+}
+
+// RolePermissions is mocked RolePermissions method of IPermissionService.RolePermissions
+func (m *mockPermissionHandler) RolePermissions(roleID int) ([]string, error) {
+    return d.RolePermissions[roleID], nil
+}
+
+// TestPermissionGetsHandler will test behaviour of PermissionGetsHandler
+func TestPermissionGetsHandler(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+    handler := NewPermissionHandler(&mockPermissionHandler{})
+
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        writer, context := NewTestWriterContext()
+        context.Request, _ = http.NewRequest("GET", "/permission/", nil)
+
+        ServeTestContext(context, handler.PermissionGetsHandler)
+
+        assert.Equal(t, http.StatusOK, writer.Code)
+        assert.Contains(t, writer.Body.String(), `"code":"role:read"`)
+    })
+
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        writer, context := NewTestWriterContext()
+        context.Request, _ = http.NewRequest("GET", "/permission/", nil)
+
+        wantErr = true
+        ServeTestContext(context, handler.PermissionGetsHandler)
+        wantErr = false
+
+        assert.Equal(t, http.StatusInternalServerError, writer.Code)
+    })
+}
+
+// TestRolePermissionGetsHandler will test behaviour of RolePermissionGetsHandler
+func TestRolePermissionGetsHandler(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+    handler := NewPermissionHandler(&mockPermissionHandler{})
+
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        writer, context := NewTestWriterContext()
+        context.Params = gin.Params{{Key: "id", Value: "4"}}
+        context.Request, _ = http.NewRequest("GET", "/role/:id/permission/", nil)
+
+        ServeTestContext(context, handler.RolePermissionGetsHandler)
+
+        assert.Equal(t, http.StatusOK, writer.Code)
+        assert.Contains(t, writer.Body.String(), `"code":"user:read"`)
+    })
+
+    t.Run("EXPECT FAIL bad param id", func(t *testing.T){
+        writer, context := NewTestWriterContext()
+        context.Params = gin.Params{{Key: "id", Value: "staff"}}
+        context.Request, _ = http.NewRequest("GET", "/role/:id/permission/", nil)
+
+        ServeTestContext(context, handler.RolePermissionGetsHandler)
+
+        assert.Equal(t, http.StatusBadRequest, writer.Code)
+    })
+}
+
+// TestRolePermissionGrantHandler will test behaviour of RolePermissionGrantHandler and
+// RolePermissionRevokeHandler
+func TestRolePermissionGrantHandler(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+    mock := &mockPermissionHandler{}
+    handler := NewPermissionHandler(mock)
+    admin := &d.Principal{UserID: uuid.New(), RoleID: d.RoleAdministrator, StatusID: 1}
+
+    cases := []struct{
+        name      string
+        principal *d.Principal
+        id        string
+        code      string
+        want      int
+    }{
+        {"EXPECT SUCCESS", admin, "4", d.PermRoleRead, http.StatusOK},
+        {"EXPECT FAIL principal not found", nil, "4", d.PermRoleRead, http.StatusUnauthorized},
+        {"EXPECT FAIL bad param id", admin, "staff", d.PermRoleRead, http.StatusBadRequest},
+        {"EXPECT FAIL invalid code", admin, "4", "ROLE", http.StatusBadRequest},
+        {"EXPECT FAIL own role", admin, "2", d.PermRoleRead, http.StatusForbidden},
+        {"EXPECT FAIL permission not found", admin, "4", "blog:write", http.StatusNotFound},
+    }
+
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            for _, action := range []gin.HandlerFunc{handler.RolePermissionGrantHandler, handler.RolePermissionRevokeHandler} {
+                writer, context := NewTestWriterContext()
+                context.Params = gin.Params{{Key: "id", Value: tt.id}, {Key: "code", Value: tt.code}}
+                context.Request, _ = http.NewRequest("PUT", "/role/:id/permission/:code", nil)
+                if tt.principal != nil {
+                    helper.SetPrincipal(context, tt.principal)
+                }
+
+                ServeTestContext(context, action)
+
+                assert.Equal(t, tt.want, writer.Code)
+                _, audited := helper.GetAudit(context)
+                assert.Equal(t, tt.want == http.StatusOK, audited)
+            }
+        })
+    }
+
+    assert.Equal(t, []string{"grant role:read", "revoke role:read"}, mock.changed)
+
+    // granted permission is the value after the change
+    _, context := NewTestWriterContext()
+    context.Params = gin.Params{{Key: "id", Value: "4"}, {Key: "code", Value: d.PermRoleRead}}
+    context.Request, _ = http.NewRequest("PUT", "/role/:id/permission/:code", nil)
+    helper.SetPrincipal(context, admin)
+    ServeTestContext(context, handler.RolePermissionGrantHandler)
+
+    audit, _ := helper.GetAudit(context)
+    assert.Equal(t, d.AuditTargetRole, audit.TargetType)
+    assert.Equal(t, d.AuditChanges{"permission": {After: d.PermRoleRead}}, audit.Changes)
+}
@@ -0,0 +1,80 @@
+/*
+   package handler
+   user.activation.go
+   - handler/ interaction layer for user account activation
+   - NOTE of method:
+   - -- ActivateHandler : method to activate user account with activation token
+   - -- ResendHandler   : method to resend activation mail
+*/
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/reshimahendra/lbw-go/internal/app/account/service"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	"github.com/reshimahendra/lbw-go/internal/pkg/helper"
+)
+
+// UserActivationHandler is type wrapper for user.activation service interface
+type UserActivationHandler struct {
+    Service service.IUserActivationService
+}
+
+// NewUserActivationHandler is new instance of UserActivationHandler
+func NewUserActivationHandler(Service service.IUserActivationService) *UserActivationHandler{
+    return &UserActivationHandler{Service}
+}
+
+// ActivateHandler is handler layer to activate user account. the token is taken
+// from 'token' query param of the activation link sent to the user
+func (h *UserActivationHandler) ActivateHandler(c *gin.Context) {
+    // get 'token' query from the request context
+    token := c.Query("token")
+
+    // send request to service layer to activate the user account
+    response, err := h.Service.Activate(token)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    // the user is the actor of its own activation
+    audit := d.NewAuditLog(d.AuditActionActivate, d.AuditTargetUser, response.ID.String(), nil, nil)
+    audit.ActorID = &response.ID
+    helper.SetAudit(c, audit)
+
+    // send response to client
+    helper.APIResponse(
+        c,
+        http.StatusOK,
+        "success activating user account",
+        response,
+    )
+}
+
+// ResendHandler is handler layer to resend activation mail. the response does not
+// tell whether the email is registered or not
+func (h *UserActivationHandler) ResendHandler(c *gin.Context) {
+    // get activation request data from context
+    req := new(d.UserActivationRequest)
+    if err := helper.BindJSON(c, req); err != nil {
+        c.Error(err)
+        return
+    }
+
+    // send request to service layer to resend the activation mail
+    if err := h.Service.Resend(req.Email); err != nil {
+        c.Error(err)
+        return
+    }
+
+    // send response to client
+    helper.APIResponse(
+        c,
+        http.StatusOK,
+        "success resending activation mail",
+        nil,
+    )
+}
@@ -0,0 +1,161 @@
+/*
+   package handler
+   user.activation_test.go
+   - testing behaviour of user.activation handler
+*/
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockUserActivationHandler is mocked user.activation handler for our user.activation service interface
+type mockUserActivationHandler struct {
+    t *testing.T
+    issued []uuid.UUID
+}
+
+// NewMockUserActivationHandler is new instance to our mockUserActivationHandler
+func NewMockUserActivationHandler(t *testing.T) *mockUserActivationHandler{
+    return &mockUserActivationHandler{t: t}
+}
+
+// Issue is mocked Issue method of IUserActivationService.Issue
+func (m *mockUserActivationHandler) Issue(userID uuid.UUID, email string) error {
+    if wantErr {
+        return E.NewExt(E.ErrActivationMail, E.New(E.ErrDatabase))
+    }
+    m.issued = append(m.issued, userID)
+
+    return nil
+}
+
+// Activate is mocked Activate method of IUserActivationService.Activate
+func (m *mockUserActivationHandler) Activate(token string) (*d.UserResponse, error) {
+    if token != "valid" {
+        return nil, E.New(E.ErrActivationTokenInvalid)
+    }
+    if wantErr {
+        return nil, E.New(E.ErrDatabase)
+    }
+
+    return u[0], nil
+}
+
+// Resend is mocked Resend method of IUserActivationService.Resend
+func (m *mockUserActivationHandler) Resend(email string) error {
+    if email == "invalid" {
+        return E.New(E.ErrEmailIsInvalid)
+    }
+    if wantErr {
+        return E.New(E.ErrDatabase)
+    }
+
+    return nil
+}
+
+// NewTestUserActivationHandler is function wrapper to get the mock handler of our handler layer
+func NewTestUserActivationHandler(t *testing.T) *UserActivationHandler{
+    t.Helper()
+
+    // set gin to test mode
+    gin.SetMode(gin.TestMode)
+
+    // prepare mock
+    mock := NewMockUserActivationHandler(t)
+    handler := NewUserActivationHandler(mock)
+
+    // return mocked handler
+    return handler
+}
+
+// TestActivateHandler will test behaviour of ActivateHandler method of handler layer
+func TestActivateHandler(t *testing.T) {
+    // prepare the test handler
+    handler := NewTestUserActivationHandler(t)
+
+    cases := []struct{
+        name, token string
+        wantErr bool
+        wantCode int
+        wantMsg string
+    }{
+        {"EXPECT SUCCESS", "valid", false, http.StatusOK, "success activating user account"},
+        {"EXPECT FAIL token empty", "", false, http.StatusBadRequest, E.ErrActivationTokenInvalidMsg},
+        {"EXPECT FAIL token invalid", "invalid", false, http.StatusBadRequest, E.ErrActivationTokenInvalidMsg},
+        {"EXPECT FAIL database error", "valid", true, http.StatusInternalServerError, E.ErrDatabaseMsg},
+    }
+
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            // prepare request/ response / gin context
+            writer, context := NewTestWriterContext()
+
+            var err error
+            context.Request, err = http.NewRequest("GET", "/activate?token="+tt.token, nil)
+            assert.NoError(t, err)
+
+            // actual method handler call
+            wantErr = tt.wantErr
+            ServeTestContext(context, handler.ActivateHandler)
+            wantErr = false
+
+            // validation and verification
+            assert.Equal(t, tt.wantCode, writer.Code)
+            assert.Contains(t, string(writer.Body.Bytes()[:]), tt.wantMsg)
+        })
+    }
+}
+
+// TestResendHandler will test behaviour of ResendHandler method of handler layer
+func TestResendHandler(t *testing.T) {
+    // prepare the test handler
+    handler := NewTestUserActivationHandler(t)
+
+    cases := []struct{
+        name string
+        body interface{}
+        wantErr bool
+        wantCode int
+        wantMsg string
+    }{
+        {"EXPECT SUCCESS", d.UserActivationRequest{Email: u[1].Email}, false, http.StatusOK, "success resending activation mail"},
+        {"EXPECT FAIL bind json error", "invalid", false, http.StatusBadRequest, E.ErrRequestDataInvalidMsg},
+        {"EXPECT FAIL email invalid", d.UserActivationRequest{Email: "invalid"}, false, http.StatusBadRequest, `{"field":"email","rule":"email"`},
+        {"EXPECT FAIL database error", d.UserActivationRequest{Email: u[1].Email}, true, http.StatusInternalServerError, E.ErrDatabaseMsg},
+    }
+
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            // prepare request/ response / gin context
+            writer, context := NewTestWriterContext()
+
+            // inject json to request body
+            body, err := json.Marshal(tt.body)
+            assert.NoError(t, err)
+            context.Request, err = http.NewRequest("POST", "/activation/resend", bytes.NewBuffer(body))
+            assert.NoError(t, err)
+
+            // set content type to json
+            context.Request.Header.Add("content-type", "application/json")
+
+            // actual method handler call
+            wantErr = tt.wantErr
+            ServeTestContext(context, handler.ResendHandler)
+            wantErr = false
+
+            // validation and verification
+            assert.Equal(t, tt.wantCode, writer.Code)
+            assert.Contains(t, string(writer.Body.Bytes()[:]), tt.wantMsg)
+        })
+    }
+}
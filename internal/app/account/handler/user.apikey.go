@@ -0,0 +1,126 @@
+/*
+   package handler
+   user.apikey.go
+   - handler/ interaction layer for user api key (personal access token)
+   - NOTE of method:
+   - -- APIKeyCreateHandler : method to create new api key for the current user
+   - -- APIKeyGetsHandler   : method to get api key of the current user
+   - -- APIKeyRevokeHandler : method to revoke api key of the current user
+*/
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/reshimahendra/lbw-go/internal/app/account/service"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/helper"
+)
+
+// UserAPIKeyHandler is type wrapper for user.apikey service interface
+type UserAPIKeyHandler struct {
+    Service service.IUserAPIKeyService
+}
+
+// NewUserAPIKeyHandler is new instance of UserAPIKeyHandler
+func NewUserAPIKeyHandler(Service service.IUserAPIKeyService) *UserAPIKeyHandler{
+    return &UserAPIKeyHandler{Service}
+}
+
+// APIKeyCreateHandler is handler layer to create new api key for the current user. the
+// permission of the current user must be loaded first (see middleware.LoadPermissions).
+// the key is only shown once
+func (h *UserAPIKeyHandler) APIKeyCreateHandler(c *gin.Context) {
+    // get the current user and its permission
+    principal, ok := helper.GetPrincipal(c)
+    if !ok {
+        c.Error(E.New(E.ErrTokenNotFound))
+        return
+    }
+    held, _ := helper.GetPermissions(c)
+
+    // get api key request data from context
+    var input d.APIKeyRequest
+    if err := helper.BindJSON(c, &input); err != nil {
+        c.Error(err)
+        return
+    }
+
+    // send request to service layer to create the api key
+    response, err := h.Service.Create(*principal, held, input)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    // the key itself is never kept on the audit log
+    helper.SetAudit(c, d.NewAuditLog(d.AuditActionCreate, d.AuditTargetAPIKey, response.ID.String(), nil, response.APIKey))
+
+    // send response to client
+    helper.APIResponse(
+        c,
+        http.StatusOK,
+        "success creating api key",
+        response,
+    )
+}
+
+// APIKeyGetsHandler is handler layer to get api key of the current user
+func (h *UserAPIKeyHandler) APIKeyGetsHandler(c *gin.Context) {
+    // get the current user
+    principal, ok := helper.GetPrincipal(c)
+    if !ok {
+        c.Error(E.New(E.ErrTokenNotFound))
+        return
+    }
+
+    // send request to service layer to retreive the api key
+    response, err := h.Service.Gets(principal.UserID)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    // send response to client
+    helper.APIResponse(
+        c,
+        http.StatusOK,
+        "success get api key data",
+        response,
+    )
+}
+
+// APIKeyRevokeHandler is handler layer to revoke api key of the current user based on its id
+func (h *UserAPIKeyHandler) APIKeyRevokeHandler(c *gin.Context) {
+    // get the current user
+    principal, ok := helper.GetPrincipal(c)
+    if !ok {
+        c.Error(E.New(E.ErrTokenNotFound))
+        return
+    }
+
+    // get 'id' param from the request context
+    id, err := uuid.Parse(c.Param("id"))
+    if err != nil {
+        c.Error(E.New(E.ErrParamIsInvalid))
+        return
+    }
+
+    // send request to service layer to revoke the api key
+    if err := h.Service.Revoke(principal.UserID, id); err != nil {
+        c.Error(err)
+        return
+    }
+    helper.SetAudit(c, d.NewAuditLog(d.AuditActionRevoke, d.AuditTargetAPIKey, id.String(), nil, nil))
+
+    // send response to client
+    helper.APIResponse(
+        c,
+        http.StatusOK,
+        "success revoking api key",
+        nil,
+    )
+}
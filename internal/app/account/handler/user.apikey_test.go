@@ -0,0 +1,200 @@
+/*
+   package handler
+   user.apikey_test.go
+   - testing behaviour of user.apikey handler
+*/
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/helper"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockUserAPIKeyHandler is mocked user.apikey service interface
+type mockUserAPIKeyHandler struct{}
+
+// testAPIKeyID is id of the only api key known by mockUserAPIKeyHandler
+var testAPIKeyID = uuid.New()
+
+// Create is mocked Create method of IUserAPIKeyService.Create
+func (m *mockUserAPIKeyHandler) Create(principal d.Principal, held []string, input d.APIKeyRequest) (*d.APIKeyCreateResponse, error) {
+    if !input.IsValid() {
+        return nil, E.New(E.ErrDataIsInvalid)
+    }
+    for _, scope := range input.Scopes {
+        if !containsTestString(held, scope) {
+            return nil, E.New(E.ErrForbidden)
+        }
+    }
+    if wantErr {
+        return nil, E.New(E.ErrDatabase)
+    }
+
+    return &d.APIKeyCreateResponse{
+        APIKey : d.APIKey{ID: testAPIKeyID, UserID: principal.UserID, Name: input.Name, Scopes: input.Scopes},
+        Key    : d.APIKeyPrefix + "secret",
+    }, nil
+}
+
+// Gets is mocked Gets method of IUserAPIKeyService.Gets
+func (m *mockUserAPIKeyHandler) Gets(userID uuid.UUID) ([]*d.APIKey, error) {
+    if wantErr {
+        return nil, E.New(E.ErrDatabase)
+    }
+
+    return []*d.APIKey{{ID: testAPIKeyID, UserID: userID, Name: "deploy bot"}}, nil
+}
+
+// Revoke is mocked Revoke method of IUserAPIKeyService.Revoke
+func (m *mockUserAPIKeyHandler) Revoke(userID, id uuid.UUID) error {
+    if id != testAPIKeyID {
+        return E.New(E.ErrDataIsEmpty)
+    }
+
+    return nil
+}
+
+// AuthenticateAPIKey is mocked AuthenticateAPIKey method of IUserAPIKeyService.AuthenticateAPIKey
+func (m *mockUserAPIKeyHandler) AuthenticateAPIKey(key string) (*d.Principal, error) {
+    return nil, E.New(E.ErrTokenInvalid)
+}
+
+// containsTestString will check whether the value is on the list
+func containsTestString(list []string, value string) bool {
+    for _, v := range list {
+        if v == value {
+            return true
+        }
+    }
+
+    return false
+}
+
+// TestAPIKeyCreateHandler will test behaviour of APIKeyCreateHandler
+func TestAPIKeyCreateHandler(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+    handler := NewUserAPIKeyHandler(&mockUserAPIKeyHandler{})
+    principal := &d.Principal{UserID: u[0].ID, RoleID: d.RoleStaff, StatusID: 1}
+
+    cases := []struct{
+        name      string
+        principal *d.Principal
+        body      string
+        wantErr   bool
+        want      int
+    }{
+        {"EXPECT SUCCESS", principal, `{"name":"deploy bot","scopes":["user:read"]}`, false, http.StatusOK},
+        {"EXPECT FAIL principal not found", nil, `{"name":"deploy bot"}`, false, http.StatusUnauthorized},
+        {"EXPECT FAIL bad request body", principal, `{"name":`, false, http.StatusBadRequest},
+        {"EXPECT FAIL invalid request", principal, `{"name":""}`, false, http.StatusBadRequest},
+        {"EXPECT FAIL scope not held", principal, `{"name":"deploy bot","scopes":["user:write"]}`, false, http.StatusForbidden},
+        {"EXPECT FAIL database error", principal, `{"name":"deploy bot"}`, true, http.StatusInternalServerError},
+    }
+
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            writer, context := NewTestWriterContext()
+            context.Request, _ = http.NewRequest("POST", "/me/api-keys", bytes.NewBufferString(tt.body))
+            if tt.principal != nil {
+                helper.SetPrincipal(context, tt.principal)
+                helper.SetPermissions(context, []string{d.PermUserRead})
+            }
+
+            wantErr = tt.wantErr
+            ServeTestContext(context, handler.APIKeyCreateHandler)
+            wantErr = false
+
+            assert.Equal(t, tt.want, writer.Code)
+            if tt.want == http.StatusOK {
+                assert.Contains(t, writer.Body.String(), `"key":"lbw_secret"`)
+
+                // the key is never kept on the audit log
+                audit, ok := helper.GetAudit(context)
+                assert.True(t, ok)
+                assert.NotContains(t, audit.Changes, "key")
+                assert.Contains(t, audit.Changes, "name")
+            }
+        })
+    }
+}
+
+// TestAPIKeyGetsHandler will test behaviour of APIKeyGetsHandler
+func TestAPIKeyGetsHandler(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+    handler := NewUserAPIKeyHandler(&mockUserAPIKeyHandler{})
+
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        writer, context := NewTestWriterContext()
+        context.Request, _ = http.NewRequest("GET", "/me/api-keys", nil)
+        helper.SetPrincipal(context, &d.Principal{UserID: u[0].ID})
+
+        ServeTestContext(context, handler.APIKeyGetsHandler)
+
+        assert.Equal(t, http.StatusOK, writer.Code)
+        assert.Contains(t, writer.Body.String(), `"name":"deploy bot"`)
+        assert.NotContains(t, writer.Body.String(), `"key"`)
+    })
+
+    t.Run("EXPECT FAIL principal not found", func(t *testing.T){
+        writer, context := NewTestWriterContext()
+        context.Request, _ = http.NewRequest("GET", "/me/api-keys", nil)
+
+        ServeTestContext(context, handler.APIKeyGetsHandler)
+
+        assert.Equal(t, http.StatusUnauthorized, writer.Code)
+    })
+
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        writer, context := NewTestWriterContext()
+        context.Request, _ = http.NewRequest("GET", "/me/api-keys", nil)
+        helper.SetPrincipal(context, &d.Principal{UserID: u[0].ID})
+
+        wantErr = true
+        ServeTestContext(context, handler.APIKeyGetsHandler)
+        wantErr = false
+
+        assert.Equal(t, http.StatusInternalServerError, writer.Code)
+    })
+}
+
+// TestAPIKeyRevokeHandler will test behaviour of APIKeyRevokeHandler
+func TestAPIKeyRevokeHandler(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+    handler := NewUserAPIKeyHandler(&mockUserAPIKeyHandler{})
+    principal := &d.Principal{UserID: u[0].ID}
+
+    cases := []struct{
+        name      string
+        principal *d.Principal
+        id        string
+        want      int
+    }{
+        {"EXPECT SUCCESS", principal, testAPIKeyID.String(), http.StatusOK},
+        {"EXPECT FAIL principal not found", nil, testAPIKeyID.String(), http.StatusUnauthorized},
+        {"EXPECT FAIL bad param id", principal, "deploy-bot", http.StatusBadRequest},
+        {"EXPECT FAIL api key not found", principal, uuid.NewString(), http.StatusNotFound},
+    }
+
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            writer, context := NewTestWriterContext()
+            context.Params = gin.Params{{Key: "id", Value: tt.id}}
+            context.Request, _ = http.NewRequest("DELETE", "/me/api-keys/:id", nil)
+            if tt.principal != nil {
+                helper.SetPrincipal(context, tt.principal)
+            }
+
+            ServeTestContext(context, handler.APIKeyRevokeHandler)
+
+            assert.Equal(t, tt.want, writer.Code)
+        })
+    }
+}
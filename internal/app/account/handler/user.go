@@ -7,19 +7,28 @@
    - -- UserGetHandler    : method to get user record by id
    - -- UserGetsHandler   : method to get all user record
    - -- UserUpdateHandler : method to update user record
+   - -- UserPatchHandler  : method to partially update user record with merge patch
    - -- UserDeletesHandler: method to soft delete.role record
+   - -- UserTrashGetsHandler : method to get all (soft) deleted user record
+   - -- UserRestoreHandler   : method to restore (soft) deleted user record
+   - -- UserPurgeHandler     : method to permanently remove (soft) deleted user record
+   - -- MeGetHandler      : method to get profile of the current user
+   - -- MeUpdateHandler   : method to update profile of the current user
+   - -- MeDeleteHandler   : method to soft delete account of the current user
    - -- UserSignupHandler : method to signup (create new user)
-   - -- UserSigninHandler : method to signin/ login
+   - -- UserSigninHandler : method to signin/ login (first step when two factor authentication is enabled)
 */
 package handler
 
 import (
 	"encoding/json"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
 	"github.com/reshimahendra/lbw-go/internal/app/account/service"
 	d "github.com/reshimahendra/lbw-go/internal/domain"
 	"github.com/reshimahendra/lbw-go/internal/pkg/auth"
@@ -29,41 +38,56 @@ import (
 )
 
 var (
-    // checkPasswordHashFunc is instance func wrapper for helper.CheckPasswordHash
-    checkPasswordHashFunc = helper.CheckPasswordHash
+    // checkPasswordHashFunc is instance func wrapper for auth.VerifyPassword
+    checkPasswordHashFunc = auth.VerifyPassword
 
-    // createToken is instance func wrapper for auth.CreateToken
-    createTokenFunc = auth.CreateToken
+    // dummyPassKeyFunc is instance func wrapper for auth.DummyPasswordHash
+    dummyPassKeyFunc = auth.DummyPasswordHash
 )
 
 // UserHandler is type wrapper for user service interface
 type UserHandler struct {
     Service service.IUserService
+
+    // Activation is user.activation service used to issue activation token on signup
+    Activation service.IUserActivationService
+
+    // Auth is auth service used to issue and rotate token on signin and refresh
+    Auth service.IAuthService
+
+    // TwoFactor is user.totp service used to check whether signin need the two factor code
+    TwoFactor service.IUserTOTPService
+
+    // Attempt is signin attempt service used to throttle and lock brute-force signin
+    Attempt service.ISigninAttemptService
 }
 
 // NewUserHandler is new instance of UserHandler
-func NewUserHandler(Service service.IUserService) *UserHandler{
-    return &UserHandler{Service}
+func NewUserHandler(Service service.IUserService, Activation service.IUserActivationService, Auth service.IAuthService, TwoFactor service.IUserTOTPService, Attempt service.ISigninAttemptService) *UserHandler{
+    return &UserHandler{Service, Activation, Auth, TwoFactor, Attempt}
 }
 
 // UserCreateHandler is handler layer for Create user 
 func (h *UserHandler) UserCreateHandler(c *gin.Context) {
     // get user request data from context
     req := new(d.UserRequest)
-    if err := c.ShouldBindJSON(&req); err != nil {
-        e := E.New(E.ErrRequestDataInvalid)
-        logger.Errorf("fail binding user data: %v", err)
-        helper.APIErrorResponse(c, http.StatusBadRequest, e)
+    if err := helper.BindJSON(c, req); err != nil {
+        c.Error(err)
         return
     }
 
-    // send request to service layer to process insert new user record
-    response, err := h.Service.Create(*req)
+    // send request to service layer to process insert new user record. the principal can
+    // only assign role holding the permission it holds itself
+    var principal d.Principal
+    if p, ok := helper.GetPrincipal(c); ok {
+        principal = *p
+    }
+    response, err := h.Service.Create(principal, *req)
     if err != nil {
-        logger.Errorf("fail inserting user data: %v", err)
-        helper.APIErrorResponse(c, http.StatusInternalServerError, err)
+        c.Error(err)
         return
     }
+    helper.SetAudit(c, d.NewAuditLog(d.AuditActionCreate, d.AuditTargetUser, response.ID.String(), nil, response))
 
     // send response data to user/ client
     helper.APIResponse(
@@ -82,7 +106,7 @@ func (h *UserHandler) UserGetHandler(c *gin.Context) {
     // send request to service layer to retreive user.role record
     response, err := h.Service.Get(id)
     if err != nil {
-        helper.APIErrorResponse(c, http.StatusInternalServerError, err)
+        c.Error(err)
         return
     }
 
@@ -95,21 +119,30 @@ func (h *UserHandler) UserGetHandler(c *gin.Context) {
     )
 }
 
-// UserGetsHandler is handler layer to get all user record 
+// UserGetsHandler is handler layer to get user record page. the list is filtered, sorted
+// and paginated by the request query
 func (h *UserHandler) UserGetsHandler(c *gin.Context) {
-    // send request to service layer to retreive user.role record
-    response, err := h.Service.Gets()
+    // read pagination, sorting and filter from the request query
+    query, err := helper.GetListQuery(c, d.UserSortFields)
     if err != nil {
-        helper.APIErrorResponse(c, http.StatusInternalServerError, err)
+        c.Error(err)
         return
     }
 
-    // send response to client
-    helper.APIResponse(
+    // send request to service layer to retreive user record
+    response, meta, err := h.Service.Gets(*query)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    // send response to client along with the list metadata
+    helper.APIListResponse(
         c,
         http.StatusOK,
         "success getting user data",
         response,
+        meta,
     )
 }
 
@@ -120,18 +153,30 @@ func (h *UserHandler) UserUpdateHandler(c *gin.Context) {
 
     // get user request data from context
     req := new(d.UserRequest)
-    if err := c.ShouldBindJSON(&req); err != nil {
-        e := E.New(E.ErrRequestDataInvalid)
-        logger.Errorf("fail binding user data: %v", err)
-        helper.APIErrorResponse(c, http.StatusBadRequest, e)
+    if err := helper.BindJSON(c, req); err != nil {
+        c.Error(err)
         return
     }
 
-    // send request to service layer to process insert new user record
-    response, err := h.Service.Update(id, *req)
+    // the record before the change is kept on the audit log
+    current, _ := h.Service.Get(id)
+
+    // send request to service layer to update user record. the principal can not change
+    // its own status and role, request without principal never target its own record
+    var principal d.Principal
+    if p, ok := helper.GetPrincipal(c); ok {
+        principal = *p
+    }
+    response, err := h.Service.Update(principal, id, *req)
     if err != nil {
-        logger.Errorf("fail updating user data: %v", err)
-        helper.APIErrorResponse(c, http.StatusInternalServerError, err)
+        c.Error(err)
+        return
+    }
+    helper.SetAudit(c, d.NewAuditLog(d.AuditActionUpdate, d.AuditTargetUser, id, current, response))
+
+    // sign the user out of all session when its status or role is changed
+    if err := h.revokeStatusRoleChange(current, response); err != nil {
+        c.Error(err)
         return
     }
 
@@ -144,6 +189,61 @@ func (h *UserHandler) UserUpdateHandler(c *gin.Context) {
     )
 }
 
+// UserPatchHandler is handler layer to partially update user record with JSON merge
+// patch (RFC 7396). only the field present on the patch is updated
+func (h *UserHandler) UserPatchHandler(c *gin.Context) {
+    // get 'id' param from the request context
+    id := c.Param("id")
+
+    // get merge patch document from context
+    patch, err := helper.GetMergePatch(c)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    // the record before the change is kept on the audit log
+    current, _ := h.Service.Get(id)
+
+    // send request to service layer to patch the user record. the principal can not change
+    // its own status and role, request without principal never target its own record
+    var principal d.Principal
+    if p, ok := helper.GetPrincipal(c); ok {
+        principal = *p
+    }
+    response, err := h.Service.Patch(principal, id, patch)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+    helper.SetAudit(c, d.NewAuditLog(d.AuditActionUpdate, d.AuditTargetUser, id, current, response))
+
+    // sign the user out of all session when its status or role is changed
+    if err := h.revokeStatusRoleChange(current, response); err != nil {
+        c.Error(err)
+        return
+    }
+
+    // send response data to user/ client
+    helper.APIResponse(
+        c,
+        http.StatusOK,
+        "success updating user data",
+        response,
+    )
+}
+
+// revokeStatusRoleChange will revoke all token of the user when its status or role is changed,
+// so token carrying the old status and role can not be used anymore. unknown record before
+// the change is treated as changed
+func (h *UserHandler) revokeStatusRoleChange(before, after *d.UserResponse) error {
+    if before != nil && before.StatusID == after.StatusID && before.RoleID == after.RoleID {
+        return nil
+    }
+
+    return h.Auth.RevokeUserTokens(after.ID)
+}
+
 // UserDeleteHandler is handler layer to delete user record 
 func (h *UserHandler) UserDeleteHandler(c *gin.Context) {
     // get 'id' param from the request context
@@ -152,7 +252,180 @@ func (h *UserHandler) UserDeleteHandler(c *gin.Context) {
     // send request to service layer to delete user record
     response, err := h.Service.Delete(id)
     if err != nil {
-        helper.APIErrorResponse(c, http.StatusInternalServerError, err)
+        c.Error(err)
+        return
+    }
+    helper.SetAudit(c, d.NewAuditLog(d.AuditActionDelete, d.AuditTargetUser, id, response, nil))
+
+    // sign the deleted user out of all session
+    if err := h.Auth.RevokeUserTokens(response.ID); err != nil {
+        c.Error(err)
+        return
+    }
+
+    // send response to client
+    helper.APIResponse(
+        c,
+        http.StatusOK,
+        "success deleting user data",
+        response,
+    )
+}
+
+// UserTrashGetsHandler is handler layer to get all (soft) deleted user
+func (h *UserHandler) UserTrashGetsHandler(c *gin.Context) {
+    // read pagination, sorting and filter from the request query
+    query, err := helper.GetListQuery(c, d.UserTrashSortFields)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+    query.Deleted = true
+
+    // send request to service layer to retreive deleted user record
+    response, meta, err := h.Service.Gets(*query)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    // send response to client along with the list metadata
+    helper.APIListResponse(
+        c,
+        http.StatusOK,
+        "success getting deleted user data",
+        response,
+        meta,
+    )
+}
+
+// UserRestoreHandler is handler layer to restore (soft) deleted user
+func (h *UserHandler) UserRestoreHandler(c *gin.Context) {
+    // get 'id' param from the request context
+    id := c.Param("id")
+
+    // send request to service layer to restore user record
+    response, err := h.Service.Restore(id)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+    helper.SetAudit(c, d.NewAuditLog(d.AuditActionRestore, d.AuditTargetUser, id, nil, response))
+
+    // send response to client
+    helper.APIResponse(
+        c,
+        http.StatusOK,
+        "success restoring user data",
+        response,
+    )
+}
+
+// UserPurgeHandler is handler layer to permanently remove (soft) deleted user
+func (h *UserHandler) UserPurgeHandler(c *gin.Context) {
+    // get 'id' param from the request context
+    id := c.Param("id")
+
+    // send request to service layer to remove user record
+    if err := h.Service.Purge(id); err != nil {
+        c.Error(err)
+        return
+    }
+    helper.SetAudit(c, d.NewAuditLog(d.AuditActionPurge, d.AuditTargetUser, id, nil, nil))
+
+    // send response to client
+    helper.APIResponse(
+        c,
+        http.StatusOK,
+        "success purging user data",
+        nil,
+    )
+}
+
+// MeGetHandler is handler layer to get profile of the current user
+func (h *UserHandler) MeGetHandler(c *gin.Context) {
+    // get the current user
+    principal, ok := helper.GetPrincipal(c)
+    if !ok {
+        c.Error(E.New(E.ErrTokenNotFound))
+        return
+    }
+
+    // send request to service layer to retreive user record
+    response, err := h.Service.Get(principal.UserID.String())
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    // send response to client
+    helper.APIResponse(
+        c,
+        http.StatusOK,
+        "success getting user data",
+        response,
+    )
+}
+
+// MeUpdateHandler is handler layer to update profile of the current user. status
+// and role are not part of the profile, so the user can not change its own
+func (h *UserHandler) MeUpdateHandler(c *gin.Context) {
+    // get the current user
+    principal, ok := helper.GetPrincipal(c)
+    if !ok {
+        c.Error(E.New(E.ErrTokenNotFound))
+        return
+    }
+
+    // get user profile request data from context
+    req := new(d.UserProfileRequest)
+    if err := helper.BindJSON(c, req); err != nil {
+        c.Error(err)
+        return
+    }
+
+    // the profile before the change is kept on the audit log
+    current, _ := h.Service.Get(principal.UserID.String())
+
+    // send request to service layer to update the profile
+    response, err := h.Service.UpdateProfile(principal.UserID.String(), *req)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+    helper.SetAudit(c, d.NewAuditLog(d.AuditActionUpdate, d.AuditTargetUser, principal.UserID.String(), current, response))
+
+    // send response data to user/ client
+    helper.APIResponse(
+        c,
+        http.StatusOK,
+        "success updating user data",
+        response,
+    )
+}
+
+// MeDeleteHandler is handler layer to delete account of the current user. all token
+// of the user are revoked so the user is signed out of all session
+func (h *UserHandler) MeDeleteHandler(c *gin.Context) {
+    // get the current user
+    principal, ok := helper.GetPrincipal(c)
+    if !ok {
+        c.Error(E.New(E.ErrTokenNotFound))
+        return
+    }
+
+    // send request to service layer to delete user record
+    response, err := h.Service.Delete(principal.UserID.String())
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    helper.SetAudit(c, d.NewAuditLog(d.AuditActionDelete, d.AuditTargetUser, principal.UserID.String(), response, nil))
+
+    // sign the deleted user out of all session
+    if err := h.Auth.RevokeUserTokens(principal.UserID); err != nil {
+        c.Error(err)
         return
     }
 
@@ -167,13 +440,11 @@ func (h *UserHandler) UserDeleteHandler(c *gin.Context) {
 
 // SignupHandler is handler/ controller to sign up new user
 func (h *UserHandler) SignupHandler(c *gin.Context) {    
-    var userRequest d.UserRequest
+    var userRequest d.UserSignupRequest
 
-    err := c.ShouldBindJSON(&userRequest)
+    err := helper.BindJSON(c, &userRequest)
     if err != nil {
-        e := E.New(E.ErrRequestDataInvalid)
-        logger.Errorf("%s. %v", E.ErrSignUpMsg, err)
-        helper.APIErrorResponse(c, http.StatusBadRequest, e)
+        c.Error(err)
 
         return
     }
@@ -181,22 +452,30 @@ func (h *UserHandler) SignupHandler(c *gin.Context) {
     // check if user already exist
     isUserExist := h.Service.IsUserExist(userRequest.Username, userRequest.Email)
     if isUserExist {
-        err := E.New(E.ErrUserAlreadyRegistered)
-        logger.Errorf("%s. %v", E.ErrSignUpMsg, err)
-        helper.APIErrorResponse(c, http.StatusBadRequest, err)
+        c.Error(E.New(E.ErrUserAlreadyRegistered))
 
         return
     }
 
-    // create user account. exit if error
-    userResponse, err := h.Service.Create(userRequest)
+    // create inactive guest user account. exit if error
+    userResponse, err := h.Service.Signup(userRequest)
     if err != nil {
-        logger.Errorf("%s. %v", E.ErrSignUpMsg, err)
-        helper.APIErrorResponse(c, http.StatusInternalServerError, err)
+        c.Error(err)
 
         return
     }
 
+    // the new user is the actor of its own signup
+    audit := d.NewAuditLog(d.AuditActionCreate, d.AuditTargetUser, userResponse.ID.String(), nil, userResponse)
+    audit.ActorID = &userResponse.ID
+    helper.SetAudit(c, audit)
+
+    // send activation mail. signup still succeed when it fail since
+    // the user can request to resend the activation mail
+    if err := h.Activation.Issue(userResponse.ID, userResponse.Email); err != nil {
+        logger.Errorf("fail issuing activation token: %v", err)
+    }
+
     // send response to client
     helper.APIResponse(
         c,
@@ -210,48 +489,106 @@ func (h *UserHandler) SignupHandler(c *gin.Context) {
 func (h *UserHandler) SigninHandler(c *gin.Context) {
     // get login data from context
     var login d.AuthLoginDTO
-    err := c.ShouldBindJSON(&login)
+    err := helper.BindJSON(c, &login)
     if err != nil {
-        e := E.New(E.ErrRequestDataInvalid)
-        logger.Errorf("%s: %v", E.ErrRequestDataInvalidMsg, err)
-        helper.APIErrorResponse(c, http.StatusUnauthorized, e)
+        c.Error(err)
 
         return
     }
 
+    // reject signin from client ip with too many failed attempt
+    ip := c.ClientIP()
+    if !h.signinAllowed(c, d.SigninScopeIP, ip) {
+        return
+    }
+
     // get credential by its username
     cred, err := h.Service.GetByEmail(login.Email)
     if err != nil {
-        logger.Errorf("login fail: %v", err)
-        helper.APIErrorResponse(c, http.StatusUnauthorized, err)
+        if e, ok := err.(*E.Error); ok && e.Code == E.ErrDatabase {
+            c.Error(err)
+            return
+        }
+
+        // guessing unknown email is counted on the client ip, and it is rejected the same
+        // way as wrong password so the registered email is not disclosed. the password is
+        // verified against dummy passkey so the response take as long as wrong password
+        checkPasswordHashFunc(login.Passkey, dummyPassKeyFunc())
+        h.signinFailed(uuid.Nil, ip)
+        c.Error(E.NewExt(E.ErrSignIn, err))
         return
     }
 
-    // User not active 
-    if !cred.IsActive() {
-        err := E.New(E.ErrUserNotActive)
-        logger.Errorf("login fail: %v", err)
-        helper.APIErrorResponse(c, http.StatusUnauthorized, err)
-
+    // reject signin on locked user account or before its delay is over
+    if !h.signinAllowed(c, d.SigninScopeUser, cred.ID.String()) {
         return
     }
 
     // Check whether user & password match
     isPasswordMatch := checkPasswordHashFunc(login.Passkey, cred.PassKey)
     if !isPasswordMatch {
-        err := E.New(E.ErrSignIn)
-        logger.Errorf("login fail: %v", err)
-        helper.APIErrorResponse(c, http.StatusUnauthorized, err)
+        h.signinFailed(cred.ID, ip)
+
+        c.Error(E.New(E.ErrSignIn))
+
+        return
+    }
+
+    // User not active. it is only told once the password match, so the status of the
+    // account is not disclosed to whom does not know its password
+    if !cred.IsActive() {
+        c.Error(E.New(E.ErrUserNotActive))
 
         return
     }
 
+    // passkey made with outdated hash algorithm or parameter is upgraded while the
+    // password is known, failing to do so does not fail the signin
+    if err := h.Service.RehashPassKey(cred.ID, login.Passkey, cred.PassKey); err != nil {
+        logger.Errorf("rehash passkey of user %s fail: %v", cred.ID, err)
+    }
+
     if cred.IsActive() && isPasswordMatch {
-        token, err := auth.CreateToken(login.Email)
+        principal := d.Principal{
+            UserID   : cred.ID,
+            Email    : login.Email,
+            RoleID   : cred.RoleID,
+            StatusID : cred.StatusID,
+        }
+
+        // user with two factor authentication enabled only get the pending token,
+        // the signin is completed on /signin/2fa with the two factor code. the failed
+        // attempt is kept until the code is verified, so wrong code is counted on top of it
+        twoFactorEnabled, err := h.TwoFactor.IsEnabled(cred.ID)
+        if err != nil {
+            c.Error(err)
+
+            return
+        }
+        if twoFactorEnabled {
+            pending, err := h.TwoFactor.IssuePendingToken(principal)
+            if err != nil {
+                c.Error(E.NewExt(E.ErrTokenCreate, err))
+
+                return
+            }
+
+            helper.APIResponse(
+                c,
+                http.StatusOK,
+                "two factor authentication required",
+                pending,
+            )
+            return
+        }
+
+        // password match without second factor, the failed attempt of the user is cleared
+        h.signinSucceeded(cred.ID)
+
+        // signin start a new refresh token family
+        token, err := h.Auth.IssueToken(principal, sessionClient(c))
         if err != nil {
-            e := E.New(E.ErrTokenCreate)
-            logger.Errorf("%s: %v", E.ErrTokenCreateMsg, e)
-            helper.APIErrorResponse(c, http.StatusInternalServerError, e)
+            c.Error(E.NewExt(E.ErrTokenCreate, err))
 
             return
         }
@@ -272,37 +609,62 @@ func (h *UserHandler) SigninHandler(c *gin.Context) {
     }
 }
 
+// signinAllowed will check whether the subject may attempt to signin. when it may not,
+// the error is put on the request context and 'Retry-After' header tell how long to wait
+func (h *UserHandler) signinAllowed(c *gin.Context, scope, subject string) bool {
+    wait, err := h.Attempt.Check(scope, subject)
+    if err == nil {
+        return true
+    }
+    logger.Errorf("login fail for %s %s: %v", scope, subject, err)
+
+    if wait > 0 {
+        c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+    }
+    c.Error(err)
+
+    return false
+}
+
+// signinFailed will count the failed signin on the user account (when it is known)
+// and the client ip. failing to count is only logged since the signin is rejected anyway
+func (h *UserHandler) signinFailed(userID uuid.UUID, ip string) {
+    if userID != uuid.Nil {
+        if err := h.Attempt.Fail(d.SigninScopeUser, userID.String()); err != nil {
+            logger.Errorf("count failed signin of user %s fail: %v", userID, err)
+        }
+    }
+    if err := h.Attempt.Fail(d.SigninScopeIP, ip); err != nil {
+        logger.Errorf("count failed signin of ip %s fail: %v", ip, err)
+    }
+}
+
+// signinSucceeded will clear the failed signin on the user account. the failed signin of the
+// client ip is kept until its window is over, so one known account can not be used to clear
+// the counter of the ip guessing other account
+func (h *UserHandler) signinSucceeded(userID uuid.UUID) {
+    if err := h.Attempt.Reset(d.SigninScopeUser, userID.String()); err != nil {
+        logger.Errorf("reset failed signin of user %s fail: %v", userID, err)
+    }
+}
+
 // RefreshTokenHandler is handler to refresh user account access token
 func (h *UserHandler) RefreshTokenHandler(c *gin.Context) {
     mapToken := map[string]string{}
 
     decoder := json.NewDecoder(c.Request.Body)
     if err := decoder.Decode(&mapToken); err != nil {
-        logger.Errorf("decode json fail on refresh token: %v", err)
-        e := E.New(E.ErrTokenRefresh)
-        helper.APIErrorResponse(c, http.StatusUnprocessableEntity, e)
+        c.Error(E.NewExt(E.ErrRequestDataInvalid, err))
         return
     }
 
     defer c.Request.Body.Close()
 
-    token, err := auth.TokenValid(mapToken["refresh_token"])
-    if err != nil {
-        logger.Errorf("token validity check fail on refresh token: %v", err)
-        e := E.New(E.ErrTokenInvalid)
-        helper.APIErrorResponse(c, http.StatusUnauthorized, e)
-
-        return
-    }
-
-    email := token.Claims.(jwt.MapClaims)["email"].(string)
-
-    // Create new token 
-    newToken, err := createTokenFunc(email)
+    // send request to service layer to rotate the refresh token
+    newToken, err := h.Auth.Refresh(mapToken["refresh_token"], sessionClient(c))
     if err != nil {
-        e := E.New(E.ErrTokenCreate)
-        logger.Errorf("token creation fail on refresh token: %v", err)
-        helper.APIErrorResponse(c, http.StatusInternalServerError, e)
+        // failing to rotate or create token is server error, otherwise the token is rejected
+        c.Error(err)
 
         return
     }
@@ -332,29 +694,32 @@ func (h *UserHandler) CheckTokenHandler(c *gin.Context) {
     }
 
     if decToken == "" {
-        err := E.New(E.ErrTokenNotFound)
-        logger.Errorf("check token fail: %v", err)
-        helper.APIErrorResponse(c, http.StatusUnauthorized, err)
+        c.Error(E.New(E.ErrTokenNotFound))
 
         return
     }
 
     token, err := auth.TokenValid(decToken)
     if err != nil {
-        e := E.New(E.ErrTokenInvalid)
-        logger.Errorf("token validity check fail on CheckToken: %v", err)
-        helper.APIErrorResponse(c, http.StatusUnauthorized, e)
+        c.Error(err)
+
+        return
+    }
+
+    // get the token owner
+    principal, err := auth.ExtractPrincipal(token)
+    if err != nil {
+        c.Error(err)
 
         return
     }
 
     // send response of the 'checkToken' result to client
-    email := token.Claims.(jwt.MapClaims)["email"].(string)
     helper.APIResponse(
         c,
         http.StatusOK,
         "success checking token",
-        email,
+        principal.Email,
     )
 }
 
@@ -0,0 +1,114 @@
+/*
+   package handler
+   user.password.go
+   - handler/ interaction layer for user password reset
+   - NOTE of method:
+   - -- ForgotHandler : method to request password reset mail
+   - -- ResetHandler  : method to reset password with password reset token
+   - -- ChangeHandler : method to change password of the current user
+*/
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/reshimahendra/lbw-go/internal/app/account/service"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/helper"
+)
+
+// UserPasswordHandler is type wrapper for user.password service interface
+type UserPasswordHandler struct {
+    Service service.IUserPasswordService
+}
+
+// NewUserPasswordHandler is new instance of UserPasswordHandler
+func NewUserPasswordHandler(Service service.IUserPasswordService) *UserPasswordHandler{
+    return &UserPasswordHandler{Service}
+}
+
+// ForgotHandler is handler layer to request password reset mail. the response does not
+// tell whether the email is registered or not
+func (h *UserPasswordHandler) ForgotHandler(c *gin.Context) {
+    // get password forgot request data from context
+    req := new(d.PasswordForgotRequest)
+    if err := helper.BindJSON(c, req); err != nil {
+        c.Error(err)
+        return
+    }
+
+    // send request to service layer to send the password reset mail
+    if err := h.Service.Forgot(req.Email); err != nil {
+        c.Error(err)
+        return
+    }
+
+    // send response to client
+    helper.APIResponse(
+        c,
+        http.StatusOK,
+        "success requesting password reset",
+        nil,
+    )
+}
+
+// ResetHandler is handler layer to reset user password with password reset token
+func (h *UserPasswordHandler) ResetHandler(c *gin.Context) {
+    // get password reset request data from context
+    req := new(d.PasswordResetRequest)
+    if err := helper.BindJSON(c, req); err != nil {
+        c.Error(err)
+        return
+    }
+
+    // send request to service layer to reset the password
+    userID, err := h.Service.Reset(*req)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+    helper.SetAudit(c, d.NewAuditLog(d.AuditActionPasswordReset, d.AuditTargetUser, userID.String(), nil, nil))
+
+    // send response to client
+    helper.APIResponse(
+        c,
+        http.StatusOK,
+        "success resetting password",
+        nil,
+    )
+}
+
+// ChangeHandler is handler layer to change password of the current user. the current
+// password must be given and every other session of the user will be revoked
+func (h *UserPasswordHandler) ChangeHandler(c *gin.Context) {
+    // get the current user
+    principal, ok := helper.GetPrincipal(c)
+    if !ok {
+        c.Error(E.New(E.ErrTokenNotFound))
+        return
+    }
+
+    // get password change request data from context
+    req := new(d.PasswordChangeRequest)
+    if err := helper.BindJSON(c, req); err != nil {
+        c.Error(err)
+        return
+    }
+
+    // send request to service layer to change the password
+    if err := h.Service.Change(*principal, *req); err != nil {
+        c.Error(err)
+        return
+    }
+    helper.SetAudit(c, d.NewAuditLog(d.AuditActionPasswordChange, d.AuditTargetUser, principal.UserID.String(), nil, nil))
+
+    // send response to client
+    helper.APIResponse(
+        c,
+        http.StatusOK,
+        "success changing password",
+        nil,
+    )
+}
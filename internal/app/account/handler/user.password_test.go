@@ -0,0 +1,225 @@
+/*
+   package handler
+   user.password_test.go
+   - testing behaviour of user.password handler
+*/
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/helper"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockUserPasswordHandler is mocked user.password handler for our user.password service interface
+type mockUserPasswordHandler struct {
+    t *testing.T
+}
+
+// NewMockUserPasswordHandler is new instance to our mockUserPasswordHandler
+func NewMockUserPasswordHandler(t *testing.T) *mockUserPasswordHandler{
+    return &mockUserPasswordHandler{t}
+}
+
+// Forgot is mocked Forgot method of IUserPasswordService.Forgot
+func (m *mockUserPasswordHandler) Forgot(email string) error {
+    if email == "invalid" {
+        return E.New(E.ErrEmailIsInvalid)
+    }
+
+    return nil
+}
+
+// Reset is mocked Reset method of IUserPasswordService.Reset
+func (m *mockUserPasswordHandler) Reset(input d.PasswordResetRequest) (uuid.UUID, error) {
+    if input.Token != "valid" {
+        return uuid.Nil, E.New(E.ErrPasswordResetTokenInvalid)
+    }
+    if len(input.PassKey) < 8 {
+        return uuid.Nil, E.NewExt(E.ErrPasswordPolicy, E.FieldErrors{{Field: "passkey", Rule: "min", Message: "must be at least 8 characters"}})
+    }
+    if wantErr {
+        return uuid.Nil, E.New(E.ErrDatabase)
+    }
+
+    return u[0].ID, nil
+}
+
+// Change is mocked Change method of IUserPasswordService.Change
+func (m *mockUserPasswordHandler) Change(principal d.Principal, input d.PasswordChangeRequest) error {
+    if !input.IsValid() {
+        return E.New(E.ErrDataIsInvalid)
+    }
+    if len(input.NewPassword) < 8 {
+        return E.NewExt(E.ErrPasswordPolicy, E.FieldErrors{{Field: "new_password", Rule: "min", Message: "must be at least 8 characters"}})
+    }
+    if principal.UserID != u[0].ID {
+        return E.New(E.ErrDataIsEmpty)
+    }
+    if input.CurrentPassword != "old-secret" {
+        return E.New(E.ErrPasswordNotMatch)
+    }
+    if wantErr {
+        return E.New(E.ErrDatabase)
+    }
+
+    return nil
+}
+
+// NewTestUserPasswordHandler is function wrapper to get the mock handler of our handler layer
+func NewTestUserPasswordHandler(t *testing.T) *UserPasswordHandler{
+    t.Helper()
+
+    // set gin to test mode
+    gin.SetMode(gin.TestMode)
+
+    // prepare mock
+    mock := NewMockUserPasswordHandler(t)
+    handler := NewUserPasswordHandler(mock)
+
+    // return mocked handler
+    return handler
+}
+
+// TestForgotHandler will test behaviour of ForgotHandler method of handler layer
+func TestForgotHandler(t *testing.T) {
+    // prepare the test handler
+    handler := NewTestUserPasswordHandler(t)
+
+    cases := []struct{
+        name string
+        body interface{}
+        wantCode int
+        wantMsg string
+    }{
+        {"EXPECT SUCCESS", d.PasswordForgotRequest{Email: u[0].Email}, http.StatusOK, "success requesting password reset"},
+        {"EXPECT FAIL bind json error", "invalid", http.StatusBadRequest, E.ErrRequestDataInvalidMsg},
+        {"EXPECT FAIL email invalid", d.PasswordForgotRequest{Email: "invalid"}, http.StatusBadRequest, `{"field":"email","rule":"email"`},
+    }
+
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            // prepare request/ response / gin context
+            writer, context := NewTestWriterContext()
+
+            // inject json to request body
+            body, err := json.Marshal(tt.body)
+            assert.NoError(t, err)
+            context.Request, err = http.NewRequest("POST", "/password/forgot", bytes.NewBuffer(body))
+            assert.NoError(t, err)
+
+            // set content type to json
+            context.Request.Header.Add("content-type", "application/json")
+
+            // actual method handler call
+            ServeTestContext(context, handler.ForgotHandler)
+
+            // validation and verification
+            assert.Equal(t, tt.wantCode, writer.Code)
+            assert.Contains(t, string(writer.Body.Bytes()[:]), tt.wantMsg)
+        })
+    }
+}
+
+// TestResetHandler will test behaviour of ResetHandler method of handler layer
+func TestResetHandler(t *testing.T) {
+    // prepare the test handler
+    handler := NewTestUserPasswordHandler(t)
+
+    cases := []struct{
+        name string
+        body interface{}
+        wantErr bool
+        wantCode int
+        wantMsg string
+    }{
+        {"EXPECT SUCCESS", d.PasswordResetRequest{Token: "valid", PassKey: "new-secret"}, false, http.StatusOK, "success resetting password"},
+        {"EXPECT FAIL bind json error", "invalid", false, http.StatusBadRequest, E.ErrRequestDataInvalidMsg},
+        {"EXPECT FAIL token invalid", d.PasswordResetRequest{Token: "invalid", PassKey: "new-secret"}, false, http.StatusBadRequest, E.ErrPasswordResetTokenInvalidMsg},
+        {"EXPECT FAIL password too short", d.PasswordResetRequest{Token: "valid", PassKey: "short"}, false, http.StatusBadRequest, `{"field":"passkey","rule":"min"`},
+        {"EXPECT FAIL database error", d.PasswordResetRequest{Token: "valid", PassKey: "new-secret"}, true, http.StatusInternalServerError, E.ErrDatabaseMsg},
+    }
+
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            // prepare request/ response / gin context
+            writer, context := NewTestWriterContext()
+
+            // inject json to request body
+            body, err := json.Marshal(tt.body)
+            assert.NoError(t, err)
+            context.Request, err = http.NewRequest("POST", "/password/reset", bytes.NewBuffer(body))
+            assert.NoError(t, err)
+
+            // set content type to json
+            context.Request.Header.Add("content-type", "application/json")
+
+            // actual method handler call
+            wantErr = tt.wantErr
+            ServeTestContext(context, handler.ResetHandler)
+            wantErr = false
+
+            // validation and verification
+            assert.Equal(t, tt.wantCode, writer.Code)
+            assert.Contains(t, string(writer.Body.Bytes()[:]), tt.wantMsg)
+            if tt.wantCode == http.StatusOK {
+                audit, ok := helper.GetAudit(context)
+                assert.True(t, ok)
+                assert.Equal(t, u[0].ID.String(), audit.TargetID)
+            }
+        })
+    }
+}
+
+// TestChangeHandler will test behaviour of ChangeHandler method of handler layer
+func TestChangeHandler(t *testing.T) {
+    // prepare the test handler
+    handler := NewTestUserPasswordHandler(t)
+    principal := &d.Principal{UserID: u[0].ID}
+    req := d.PasswordChangeRequest{CurrentPassword: "old-secret", NewPassword: "new-secret"}
+
+    cases := []struct{
+        name string
+        body interface{}
+        principal *d.Principal
+        wantErr bool
+        wantCode int
+        wantMsg string
+    }{
+        {"EXPECT SUCCESS", req, principal, false, http.StatusOK, "success changing password"},
+        {"EXPECT FAIL principal not found", req, nil, false, http.StatusUnauthorized, E.ErrTokenNotFoundMsg},
+        {"EXPECT FAIL bind json error", "invalid", principal, false, http.StatusBadRequest, E.ErrRequestDataInvalidMsg},
+        {"EXPECT FAIL data invalid", d.PasswordChangeRequest{NewPassword: "new-secret"}, principal, false, http.StatusBadRequest, `{"field":"current_password","rule":"required"`},
+        {"EXPECT FAIL password too short", d.PasswordChangeRequest{CurrentPassword: "old-secret", NewPassword: "short"}, principal, false, http.StatusBadRequest, `{"field":"new_password","rule":"min"`},
+        {"EXPECT FAIL current password not match", d.PasswordChangeRequest{CurrentPassword: "wrong-secret", NewPassword: "new-secret"}, principal, false, http.StatusForbidden, E.ErrPasswordNotMatchMsg},
+        {"EXPECT FAIL user not found", req, &d.Principal{UserID: u[1].ID}, false, http.StatusNotFound, E.ErrDataIsEmptyMsg},
+        {"EXPECT FAIL database error", req, principal, true, http.StatusInternalServerError, E.ErrDatabaseMsg},
+    }
+
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            writer, context := NewTestWriterContext()
+            context.Request = testTwoFactorRequest(t, tt.body)
+            if tt.principal != nil {
+                helper.SetPrincipal(context, tt.principal)
+            }
+
+            // actual method handler call
+            wantErr = tt.wantErr
+            ServeTestContext(context, handler.ChangeHandler)
+            wantErr = false
+
+            // validation and verification
+            assert.Equal(t, tt.wantCode, writer.Code)
+            assert.Contains(t, writer.Body.String(), tt.wantMsg)
+        })
+    }
+}
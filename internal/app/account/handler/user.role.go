@@ -7,7 +7,11 @@
     - -- UserRoleGetHandler    : method to get user.role record by id
     - -- UserRoleGetsHandler   : method to get all user.role record
     - -- UserRoleUpdateHandler : method to update user.role record
+    - -- UserRolePatchHandler  : method to partially update user.role record with merge patch
     - -- UserRoleDeletesHandler: method to soft delete user.role record
+    - -- UserRoleTrashGetsHandler : method to get all (soft) deleted user.role record
+    - -- UserRoleRestoreHandler   : method to restore (soft) deleted user.role record
+    - -- UserRolePurgeHandler     : method to permanently remove (soft) deleted user.role record
 */
 package handler
 
@@ -37,18 +41,18 @@ func NewUserRoleHandler(Service service.IUserRoleService) *UserRoleHandler{
 func (h *UserRoleHandler) UserRoleCreateHandler(c *gin.Context) {
     // prepare instance to get user.role request dto from the request context
     var uReq = new(domain.UserRoleRequest)
-    if err := c.ShouldBindJSON(uReq); err != nil {
-        e := E.New(E.ErrDataIsInvalid)
-        helper.APIErrorResponse(c, http.StatusBadRequest, e)
+    if err := helper.BindJSON(c, uReq); err != nil {
+        c.Error(err)
         return
     }
 
     // send request to service layer to process the inserting new user.role record
     response, err := h.Service.Create(*uReq)
     if err != nil {
-        helper.APIErrorResponse(c, http.StatusInternalServerError, err)
+        c.Error(err)
         return
     }
+    helper.SetAudit(c, domain.NewAuditLog(domain.AuditActionCreate, domain.AuditTargetRole, strconv.Itoa(response.ID), nil, response))
 
     // send response to client
     helper.APIResponse(
@@ -65,15 +69,14 @@ func (h *UserRoleHandler) UserRoleGetHandler(c *gin.Context) {
     paramId := c.Param("id")
     id, err := strconv.Atoi(paramId)
     if err != nil {
-        e := E.New(E.ErrParamIsInvalid)
-        helper.APIErrorResponse(c, http.StatusBadRequest, e)
+        c.Error(E.New(E.ErrParamIsInvalid))
         return
     }
 
     // send request to service layer to retreive user.role record
     response, err := h.Service.Get(id)
     if err != nil {
-        helper.APIErrorResponse(c, http.StatusInternalServerError, err)
+        c.Error(err)
         return
     }
 
@@ -86,21 +89,30 @@ func (h *UserRoleHandler) UserRoleGetHandler(c *gin.Context) {
     )
 }
 
-// UserRoleGetsHandler is handler to get all user.role record
+// UserRoleGetsHandler is handler to get user.role record page. the list is filtered,
+// sorted and paginated by the request query
 func (h *UserRoleHandler) UserRoleGetsHandler(c *gin.Context) {
+    // read pagination, sorting and filter from the request query
+    query, err := helper.GetListQuery(c, domain.UserRoleSortFields)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
     // send request to service layer to retreive user.role record
-    response, err := h.Service.Gets()
+    response, meta, err := h.Service.Gets(*query)
     if err != nil {
-        helper.APIErrorResponse(c, http.StatusInternalServerError, err)
+        c.Error(err)
         return
     }
 
-    // send response to client
-    helper.APIResponse(
+    // send response to client along with the list metadata
+    helper.APIListResponse(
         c,
         http.StatusOK,
         "success get user.role data",
         response,
+        meta,
     )
 }
 
@@ -110,26 +122,66 @@ func (h *UserRoleHandler) UserRoleUpdateHandler(c *gin.Context) {
     paramId := c.Param("id")
     id, err := strconv.Atoi(paramId)
     if err != nil {
-        e := E.New(E.ErrParamIsInvalid)
-        helper.APIErrorResponse(c, http.StatusBadRequest, e)
+        c.Error(E.New(E.ErrParamIsInvalid))
         return
     }
 
     // get new user.role data from request context
     var uReq = new(domain.UserRoleRequest)
-    if err := c.ShouldBindJSON(&uReq); err != nil {
-        e := E.New(E.ErrRequestDataInvalid)
-        helper.APIErrorResponse(c, http.StatusBadRequest, e)
+    if err := helper.BindJSON(c, uReq); err != nil {
+        c.Error(err)
         return
     }
     
 
+    // the record before the change is kept on the audit log
+    current, _ := h.Service.Get(id)
+
     // send request to service layer to update user.role record
     response, err := h.Service.Update(id, *uReq)
     if err != nil {
-        helper.APIErrorResponse(c, http.StatusInternalServerError, err)
+        c.Error(err)
         return
     }
+    helper.SetAudit(c, domain.NewAuditLog(domain.AuditActionUpdate, domain.AuditTargetRole, paramId, current, response))
+
+    // send response to client
+    helper.APIResponse(
+        c,
+        http.StatusOK,
+        "success update user.role data",
+        response,
+    )
+}
+
+// UserRolePatchHandler is handler to partially update user.role record with JSON merge
+// patch (RFC 7396). only the field present on the patch is updated
+func (h *UserRoleHandler) UserRolePatchHandler(c *gin.Context) {
+    // get 'id' param from the request context
+    paramId := c.Param("id")
+    id, err := strconv.Atoi(paramId)
+    if err != nil {
+        c.Error(E.New(E.ErrParamIsInvalid))
+        return
+    }
+
+    // get merge patch document from request context
+    patch, err := helper.GetMergePatch(c)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    // the record before the change is kept on the audit log
+    current, _ := h.Service.Get(id)
+
+    // send request to service layer to patch user.role record
+    response, err := h.Service.Patch(id, patch)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+    helper.SetAudit(c, domain.NewAuditLog(domain.AuditActionUpdate, domain.AuditTargetRole, paramId, current, response))
 
     // send response to client
     helper.APIResponse(
@@ -146,17 +198,17 @@ func (h *UserRoleHandler) UserRoleDeletesHandler(c *gin.Context) {
     paramId := c.Param("id")
     id, err := strconv.Atoi(paramId)
     if err != nil {
-        e := E.New(E.ErrParamIsInvalid)
-        helper.APIErrorResponse(c, http.StatusBadRequest, e)
+        c.Error(E.New(E.ErrParamIsInvalid))
         return
     }
 
     // send request to service layer to delete user.role record
     response, err := h.Service.Delete(id)
     if err != nil {
-        helper.APIErrorResponse(c, http.StatusInternalServerError, err)
+        c.Error(err)
         return
     }
+    helper.SetAudit(c, domain.NewAuditLog(domain.AuditActionDelete, domain.AuditTargetRole, paramId, response, nil))
 
     // send response to client
     helper.APIResponse(
@@ -166,3 +218,83 @@ func (h *UserRoleHandler) UserRoleDeletesHandler(c *gin.Context) {
         response,
     )
 }
+
+// UserRoleTrashGetsHandler is handler to get (soft) deleted user.role record page
+func (h *UserRoleHandler) UserRoleTrashGetsHandler(c *gin.Context) {
+    // read pagination, sorting and filter from the request query
+    query, err := helper.GetListQuery(c, domain.UserRoleTrashSortFields)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+    query.Deleted = true
+
+    // send request to service layer to retreive deleted user.role record
+    response, meta, err := h.Service.Gets(*query)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    // send response to client along with the list metadata
+    helper.APIListResponse(
+        c,
+        http.StatusOK,
+        "success get deleted user.role data",
+        response,
+        meta,
+    )
+}
+
+// UserRoleRestoreHandler is handler to restore (soft) deleted user.role record based on its id
+func (h *UserRoleHandler) UserRoleRestoreHandler(c *gin.Context) {
+    // get 'id' param from the request context
+    paramId := c.Param("id")
+    id, err := strconv.Atoi(paramId)
+    if err != nil {
+        c.Error(E.New(E.ErrParamIsInvalid))
+        return
+    }
+
+    // send request to service layer to restore user.role record
+    response, err := h.Service.Restore(id)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+    helper.SetAudit(c, domain.NewAuditLog(domain.AuditActionRestore, domain.AuditTargetRole, paramId, nil, response))
+
+    // send response to client
+    helper.APIResponse(
+        c,
+        http.StatusOK,
+        "success restore user.role data",
+        response,
+    )
+}
+
+// UserRolePurgeHandler is handler to permanently remove (soft) deleted user.role record based on its id
+func (h *UserRoleHandler) UserRolePurgeHandler(c *gin.Context) {
+    // get 'id' param from the request context
+    paramId := c.Param("id")
+    id, err := strconv.Atoi(paramId)
+    if err != nil {
+        c.Error(E.New(E.ErrParamIsInvalid))
+        return
+    }
+
+    // send request to service layer to remove user.role record
+    if err := h.Service.Purge(id); err != nil {
+        c.Error(err)
+        return
+    }
+    helper.SetAudit(c, domain.NewAuditLog(domain.AuditActionPurge, domain.AuditTargetRole, paramId, nil, nil))
+
+    // send response to client
+    helper.APIResponse(
+        c,
+        http.StatusOK,
+        "success purge user.role data",
+        nil,
+    )
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/gin-gonic/gin"
 	d "github.com/reshimahendra/lbw-go/internal/domain"
 	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/helper"
 	"github.com/stretchr/testify/assert"
 )
 var (
@@ -73,10 +74,10 @@ func (m *mockUserRoleHandler) Get(id int) (*d.UserRoleResponse, error) {
 }
 
 // Gets is mocked Gets method of IUserRoleService.Gets
-func (m *mockUserRoleHandler) Gets() ([]*d.UserRoleResponse, error) {
+func (m *mockUserRoleHandler) Gets(q d.ListQuery) ([]*d.UserRoleResponse, *d.ListMeta, error) {
     // return nil if force error set to true
     if wantErr {
-        return nil, E.New(E.ErrDatabase)
+        return nil, nil, E.New(E.ErrDatabase)
     }
 
     // convert user.role slice into user.role response dto slice
@@ -85,7 +86,7 @@ func (m *mockUserRoleHandler) Gets() ([]*d.UserRoleResponse, error) {
         urRes = append(urRes, ures.ConvertToResponse())
     }
 
-    return urRes, nil
+    return urRes, &d.ListMeta{Total: int64(len(urRes)), Page: q.Page, Limit: q.Limit, Sort: q.Sort, Order: q.Order}, nil
 }
 
 // Update is mocked Update method of IUserRoleService.Update
@@ -108,6 +109,25 @@ func (m *mockUserRoleHandler) Update(id int, input d.UserRoleRequest) (*d.UserRo
     return res, nil
 }
 
+// Patch is mocked Patch method of IUserRoleService.Patch
+func (m *mockUserRoleHandler) Patch(id int, patch d.MergePatch) (*d.UserRoleResponse, error) {
+    if len(ur) <= id {
+        return nil, E.New(E.ErrDataIsEmpty)
+    }
+
+    if wantErr {
+        return nil, E.New(E.ErrDatabase)
+    }
+
+    // apply the patch to the mocked record
+    role := &d.UserRole{ID: ur[id].ID, RoleName: ur[id].RoleName, Description: ur[id].Description}
+    if _, err := role.ApplyPatch(patch); err != nil || !role.IsValid() {
+        return nil, E.New(E.ErrDataIsInvalid)
+    }
+
+    return role.ConvertToResponse(), nil
+}
+
 // Delete is mocked Delete method of IUserRoleService.Delete
 func (m *mockUserRoleHandler) Delete(id int) (*d.UserRoleResponse, error) {
     if len(ur) < id {
@@ -124,6 +144,32 @@ func (m *mockUserRoleHandler) Delete(id int) (*d.UserRoleResponse, error) {
     return res, nil
 }
 
+// Restore is mocked Restore method of IUserRoleService.Restore
+func (m *mockUserRoleHandler) Restore(id int) (*d.UserRoleResponse, error) {
+    if len(ur) <= id {
+        return nil, E.New(E.ErrDataIsEmpty)
+    }
+
+    if wantErr {
+        return nil, E.NewConstraint(E.ErrUniqueViolation, "user_role_role_name_key", "role_name")
+    }
+
+    return ur[id].ConvertToResponse(), nil
+}
+
+// Purge is mocked Purge method of IUserRoleService.Purge
+func (m *mockUserRoleHandler) Purge(id int) error {
+    if len(ur) <= id {
+        return E.New(E.ErrDataIsEmpty)
+    }
+
+    if wantErr {
+        return E.New(E.ErrDataIsInUse)
+    }
+
+    return nil
+}
+
 // NewTestUserRoleHandler is function wrapper to get the mock handler of our handler layer
 func NewTestUserRoleHandler(t *testing.T) *UserRoleHandler{
     t.Helper()
@@ -147,6 +193,13 @@ func NewTestWriterContext() (*httptest.ResponseRecorder, *gin.Context) {
     return writer, context
 }
 
+// ServeTestContext will run the handler with the test context and send the error it put
+// on the context, as it is done by the error middleware
+func ServeTestContext(context *gin.Context, h gin.HandlerFunc) {
+    h(context)
+    helper.APIContextErrorResponse(context)
+}
+
 // TestUserRoleServiceCreate will test behaviour of UserRoleCreateHandler 
 func TestUserRoleCreateHandler(t *testing.T) {
     // prepare the test
@@ -161,7 +214,7 @@ func TestUserRoleCreateHandler(t *testing.T) {
         // prepare mock with ur[0] values
         req := new(d.UserRoleRequest)
         req.RoleName = ur[0].RoleName
-        req.Description = ur[0].Description
+        req.Description = string(ur[0].Description)
 
         uRoleJSON, err := json.Marshal(req)
         assert.NoError(t, err)
@@ -174,7 +227,7 @@ func TestUserRoleCreateHandler(t *testing.T) {
         context.Request.Header.Add("content-type", "application/json")
 
         // actual method handler call
-        handler.UserRoleCreateHandler(context)
+        ServeTestContext(context, handler.UserRoleCreateHandler)
 
         // prepare expected data for test comparison
         want, err := json.Marshal(ur[0].ConvertToResponse())
@@ -200,22 +253,22 @@ func TestUserRoleCreateHandler(t *testing.T) {
         context.Request.Header.Add("content-type", "application/json")
 
         // actual method handler call
-        handler.UserRoleCreateHandler(context)
+        ServeTestContext(context, handler.UserRoleCreateHandler)
 
         // validation and verification
         assert.Equal(t, http.StatusBadRequest, writer.Code)
-        assert.Contains(t, string(writer.Body.Bytes()[:]), E.ErrDataIsInvalidMsg)
+        assert.Contains(t, string(writer.Body.Bytes()[:]), E.ErrRequestDataInvalidMsg)
     })
 
-    // EXPECT FAIL insert error. Simulation done by removing role.name field to make 
-    // request data invalid
-    t.Run("EXPECT FAIL insert record error", func(t *testing.T){
+    // EXPECT FAIL required field missing. Simulation done by removing role.name field, the
+    // field is named on the response
+    t.Run("EXPECT FAIL required field missing", func(t *testing.T){
         // prepare request/ response / gin context
         writer, context := NewTestWriterContext()
 
         // prepare mock with ur[0] values
         req := new(d.UserRoleRequest)
-        req.Description = ur[0].Description
+        req.Description = string(ur[0].Description)
 
         uRoleJSON, err := json.Marshal(req)
         assert.NoError(t, err)
@@ -228,11 +281,12 @@ func TestUserRoleCreateHandler(t *testing.T) {
         context.Request.Header.Add("content-type", "application/json")
 
         // actual method handler call
-        handler.UserRoleCreateHandler(context)
+        ServeTestContext(context, handler.UserRoleCreateHandler)
 
         // validation and verification
-        assert.Equal(t, http.StatusInternalServerError, writer.Code)
-        assert.Contains(t, string(writer.Body.Bytes()[:]), E.ErrDataIsInvalidMsg)
+        assert.Equal(t, http.StatusBadRequest, writer.Code)
+        assert.Contains(t, string(writer.Body.Bytes()[:]), E.ErrRequestDataInvalidMsg)
+        assert.Contains(t, writer.Body.String(), `{"field":"role_name","rule":"required"`)
 
     })
 }
@@ -259,7 +313,7 @@ func TestUserRoleGetHandler(t *testing.T) {
         assert.NoError(t, err)
 
         // actual method call
-        handler.UserRoleGetHandler(context)
+        ServeTestContext(context, handler.UserRoleGetHandler)
 
         // prepare expected data for test comparison
         want, err := json.Marshal(ur[1].ConvertToResponse())
@@ -282,7 +336,7 @@ func TestUserRoleGetHandler(t *testing.T) {
         assert.NoError(t, err)
 
         // actual method call
-        handler.UserRoleGetHandler(context)
+        ServeTestContext(context, handler.UserRoleGetHandler)
 
         assert.Equal(t, http.StatusBadRequest, writer.Code)
         assert.Contains(t, string(writer.Body.Bytes()[:]), E.ErrParamIsInvalidMsg)
@@ -307,7 +361,7 @@ func TestUserRoleGetHandler(t *testing.T) {
         wantErr = true
 
         // actual method call
-        handler.UserRoleGetHandler(context)
+        ServeTestContext(context, handler.UserRoleGetHandler)
 
         // set back wantErr to its default falue
         wantErr = false
@@ -333,11 +387,25 @@ func TestUserRoleGetsHandler(t *testing.T) {
         assert.NoError(t, err)
 
         // actual method call
-        handler.UserRoleGetsHandler(context)
+        ServeTestContext(context, handler.UserRoleGetsHandler)
 
         // test validation and verification
         assert.Equal(t, http.StatusOK, writer.Code)
         assert.Contains(t, string(writer.Body.Bytes()[:]), "success get user.role data")
+        assert.Contains(t, string(writer.Body.Bytes()[:]), `"sort":"id","order":"asc"`)
+    })
+
+    // EXPECT FAIL invalid list query. Simulated by requesting limit above the maximum
+    t.Run("EXPECT FAIL invalid list query", func(t *testing.T){
+        writer, context := NewTestWriterContext()
+
+        var err error = nil
+        context.Request, err = http.NewRequest("GET", "/?limit=1000", nil)
+        assert.NoError(t, err)
+
+        ServeTestContext(context, handler.UserRoleGetsHandler)
+
+        assert.Equal(t, http.StatusBadRequest, writer.Code)
     })
 
     // EXPECT FAIL get data error. Simulated by inserting non existing id
@@ -354,7 +422,7 @@ func TestUserRoleGetsHandler(t *testing.T) {
         wantErr = true
 
         // actual method call
-        handler.UserRoleGetsHandler(context)
+        ServeTestContext(context, handler.UserRoleGetsHandler)
 
         // set back wantErr to its default falue
         wantErr = false
@@ -382,7 +450,7 @@ func TestUserRoleUpdateHandler(t *testing.T) {
         // prepare mock with ur[0] values
         req := new(d.UserRoleRequest)
         req.RoleName = ur[0].RoleName
-        req.Description = ur[0].Description
+        req.Description = string(ur[0].Description)
 
         uRoleJSON, err := json.Marshal(req)
         assert.NoError(t, err)
@@ -395,7 +463,7 @@ func TestUserRoleUpdateHandler(t *testing.T) {
         context.Request.Header.Add("content-type", "application/json")
 
         // actual method call
-        handler.UserRoleUpdateHandler(context)
+        ServeTestContext(context, handler.UserRoleUpdateHandler)
 
         // prepare expected data for test comparison
         want, err := json.Marshal(ur[1].ConvertToResponse())
@@ -421,7 +489,7 @@ func TestUserRoleUpdateHandler(t *testing.T) {
         context.Request.Header.Add("content-type", "application/json")
 
         // actual method call
-        handler.UserRoleUpdateHandler(context)
+        ServeTestContext(context, handler.UserRoleUpdateHandler)
 
         assert.Equal(t, http.StatusBadRequest, writer.Code)
         assert.Contains(t, string(writer.Body.Bytes()[:]), E.ErrParamIsInvalidMsg)
@@ -446,7 +514,7 @@ func TestUserRoleUpdateHandler(t *testing.T) {
         context.Request.Header.Add("content-type", "application/json")
 
         // set wantErr to force error return
-        handler.UserRoleUpdateHandler(context)
+        ServeTestContext(context, handler.UserRoleUpdateHandler)
 
         assert.Equal(t, http.StatusBadRequest, writer.Code)
         assert.Contains(t, string(writer.Body.Bytes()[:]), E.ErrRequestDataInvalidMsg)
@@ -464,7 +532,7 @@ func TestUserRoleUpdateHandler(t *testing.T) {
 
         // prepare mock with ur[0] values
         req := new(d.UserRoleRequest)
-        req.Description = ur[0].Description
+        req.Description = string(ur[0].Description)
 
         uRoleJSON, err := json.Marshal(req)
         assert.NoError(t, err)
@@ -479,13 +547,60 @@ func TestUserRoleUpdateHandler(t *testing.T) {
         // set wantErr to force error return
         // actual method call
         wantErr = true
-        handler.UserRoleUpdateHandler(context)
+        ServeTestContext(context, handler.UserRoleUpdateHandler)
         wantErr = false
 
-        assert.Equal(t, http.StatusInternalServerError, writer.Code)
+        assert.Equal(t, http.StatusBadRequest, writer.Code)
     })
 }
 
+// TestUserRolePatchHandler will test behaviour of UserRolePatchHandler
+func TestUserRolePatchHandler(t *testing.T) {
+    handler := NewTestUserRoleHandler(t)
+
+    cases := []struct{
+        name string
+        id string
+        contentType string
+        body string
+        wantErr bool
+        wantCode int
+        wantMsg string
+    }{
+        {"EXPECT SUCCESS", "1", d.MergePatchContentType, `{"description":"Root role"}`,
+            false, http.StatusOK, `"description":"Root role"`},
+        {"EXPECT SUCCESS description cleared", "1", d.MergePatchContentType, `{"description":null}`,
+            false, http.StatusOK, "success update user.role data"},
+        {"EXPECT FAIL bad param id", "one", d.MergePatchContentType, `{"description":"Root role"}`,
+            false, http.StatusBadRequest, E.ErrParamIsInvalidMsg},
+        {"EXPECT FAIL malformed document", "1", d.MergePatchContentType, `{"description":`,
+            false, http.StatusBadRequest, E.ErrRequestDataInvalidMsg},
+        {"EXPECT FAIL data invalid", "1", d.MergePatchContentType, `{"role_name":null}`,
+            false, http.StatusBadRequest, E.ErrDataIsInvalidMsg},
+        {"EXPECT FAIL data is empty", "9", d.MergePatchContentType, `{"description":"Root role"}`,
+            false, http.StatusNotFound, E.ErrDataIsEmptyMsg},
+        {"EXPECT FAIL database error", "1", d.MergePatchContentType, `{"description":"Root role"}`,
+            true, http.StatusInternalServerError, E.ErrDatabaseMsg},
+    }
+
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            writer, context := NewTestWriterContext()
+            context.Params = gin.Params{
+                {Key:"id", Value:tt.id},
+            }
+            context.Request = testPatchRequest(t, tt.contentType, tt.body)
+
+            wantErr = tt.wantErr
+            ServeTestContext(context, handler.UserRolePatchHandler)
+            wantErr = false
+
+            assert.Equal(t, tt.wantCode, writer.Code)
+            assert.Contains(t, writer.Body.String(), tt.wantMsg)
+        })
+    }
+}
+
 // TestUserRoleDeleteHandler will test behaviour of UserRoleDeleteHandler
 func TestUserRoleDeleteHandler(t *testing.T) {
     // prepare the test
@@ -508,7 +623,7 @@ func TestUserRoleDeleteHandler(t *testing.T) {
         assert.NoError(t, err)
 
         // actual method call
-        handler.UserRoleDeletesHandler(context)
+        ServeTestContext(context, handler.UserRoleDeletesHandler)
 
         // prepare expected data for test comparison
         want, err := json.Marshal(ur[1].ConvertToResponse())
@@ -531,7 +646,7 @@ func TestUserRoleDeleteHandler(t *testing.T) {
         assert.NoError(t, err)
 
         // actual method call
-        handler.UserRoleDeletesHandler(context)
+        ServeTestContext(context, handler.UserRoleDeletesHandler)
 
         assert.Equal(t, http.StatusBadRequest, writer.Code)
         assert.Contains(t, string(writer.Body.Bytes()[:]), E.ErrParamIsInvalidMsg)
@@ -555,9 +670,113 @@ func TestUserRoleDeleteHandler(t *testing.T) {
         // set wantErr to force error return
         // actual method call
         wantErr = true
-        handler.UserRoleDeletesHandler(context)
+        ServeTestContext(context, handler.UserRoleDeletesHandler)
+        wantErr = false
+
+        assert.Equal(t, http.StatusInternalServerError, writer.Code)
+    })
+}
+
+// TestUserRoleTrashGetsHandler will test behaviour of UserRoleTrashGetsHandler
+func TestUserRoleTrashGetsHandler(t *testing.T) {
+    // prepare the test
+    handler := NewTestUserRoleHandler(t)
+
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        writer, context := NewTestWriterContext()
+
+        var err error = nil
+        context.Request, err = http.NewRequest("GET", "/trash/?order=desc", nil)
+        assert.NoError(t, err)
+
+        ServeTestContext(context, handler.UserRoleTrashGetsHandler)
+
+        assert.Equal(t, http.StatusOK, writer.Code)
+        assert.Contains(t, writer.Body.String(), "success get deleted user.role data")
+        assert.Contains(t, writer.Body.String(), `"sort":"deleted_at","order":"desc"`)
+    })
+
+    t.Run("EXPECT FAIL get data error", func(t *testing.T){
+        writer, context := NewTestWriterContext()
+
+        var err error = nil
+        context.Request, err = http.NewRequest("GET", "/trash/", nil)
+        assert.NoError(t, err)
+
+        wantErr = true
+        ServeTestContext(context, handler.UserRoleTrashGetsHandler)
         wantErr = false
 
         assert.Equal(t, http.StatusInternalServerError, writer.Code)
     })
 }
+
+// TestUserRoleRestoreHandler will test behaviour of UserRoleRestoreHandler
+func TestUserRoleRestoreHandler(t *testing.T) {
+    // prepare the test
+    handler := NewTestUserRoleHandler(t)
+
+    cases := []struct{
+        name    string
+        id      string
+        wantErr bool
+        want    int
+    }{
+        {"EXPECT SUCCESS", "2", false, http.StatusOK},
+        {"EXPECT FAIL bad param id", "two", false, http.StatusBadRequest},
+        {"EXPECT FAIL role is not deleted", "9", false, http.StatusNotFound},
+        {"EXPECT FAIL role name is taken", "2", true, http.StatusConflict},
+    }
+
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            writer, context := NewTestWriterContext()
+            context.Params = gin.Params{{Key:"id", Value: tt.id}}
+
+            var err error = nil
+            context.Request, err = http.NewRequest("POST", "/trash/:id/restore", nil)
+            assert.NoError(t, err)
+
+            wantErr = tt.wantErr
+            ServeTestContext(context, handler.UserRoleRestoreHandler)
+            wantErr = false
+
+            assert.Equal(t, tt.want, writer.Code)
+        })
+    }
+}
+
+// TestUserRolePurgeHandler will test behaviour of UserRolePurgeHandler
+func TestUserRolePurgeHandler(t *testing.T) {
+    // prepare the test
+    handler := NewTestUserRoleHandler(t)
+
+    cases := []struct{
+        name    string
+        id      string
+        wantErr bool
+        want    int
+    }{
+        {"EXPECT SUCCESS", "2", false, http.StatusOK},
+        {"EXPECT FAIL bad param id", "two", false, http.StatusBadRequest},
+        {"EXPECT FAIL role is not deleted", "9", false, http.StatusNotFound},
+        {"EXPECT FAIL role is in use", "2", true, http.StatusConflict},
+    }
+
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            writer, context := NewTestWriterContext()
+            context.Params = gin.Params{{Key:"id", Value: tt.id}}
+
+            var err error = nil
+            context.Request, err = http.NewRequest("DELETE", "/trash/:id", nil)
+            assert.NoError(t, err)
+
+            wantErr = tt.wantErr
+            ServeTestContext(context, handler.UserRolePurgeHandler)
+            wantErr = false
+
+            assert.Equal(t, tt.want, writer.Code)
+        })
+    }
+}
@@ -0,0 +1,176 @@
+/*
+   package handler
+   user.session.go
+   - handler/ interaction layer for user session (signed in device)
+   - NOTE of method:
+   - -- SessionGetsHandler          : method to get active session of the current user
+   - -- SessionRevokeHandler        : method to revoke session of the current user
+   - -- SessionRevokeAllHandler     : method to revoke all other session of the current user
+   - -- UserSessionGetsHandler      : method to get active session of any user
+   - -- UserSessionRevokeHandler    : method to revoke session of any user
+   - -- UserSessionRevokeAllHandler : method to revoke all session of any user
+*/
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/reshimahendra/lbw-go/internal/app/account/service"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/helper"
+)
+
+// UserSessionHandler is type wrapper for user.session service interface
+type UserSessionHandler struct {
+    Service service.IUserSessionService
+}
+
+// NewUserSessionHandler is new instance of UserSessionHandler
+func NewUserSessionHandler(Service service.IUserSessionService) *UserSessionHandler{
+    return &UserSessionHandler{Service}
+}
+
+// SessionGetsHandler is handler layer to get active session of the current user. the
+// session making the request is marked as current
+func (h *UserSessionHandler) SessionGetsHandler(c *gin.Context) {
+    // get the current user
+    principal, ok := helper.GetPrincipal(c)
+    if !ok {
+        c.Error(E.New(E.ErrTokenNotFound))
+        return
+    }
+
+    h.sessionGets(c, principal.UserID, principal.FamilyID)
+}
+
+// SessionRevokeHandler is handler layer to revoke session of the current user based on its id
+func (h *UserSessionHandler) SessionRevokeHandler(c *gin.Context) {
+    // get the current user
+    principal, ok := helper.GetPrincipal(c)
+    if !ok {
+        c.Error(E.New(E.ErrTokenNotFound))
+        return
+    }
+
+    h.sessionRevoke(c, principal.UserID, c.Param("id"))
+}
+
+// SessionRevokeAllHandler is handler layer to revoke all session of the current user
+// except the session making the request
+func (h *UserSessionHandler) SessionRevokeAllHandler(c *gin.Context) {
+    // get the current user
+    principal, ok := helper.GetPrincipal(c)
+    if !ok {
+        c.Error(E.New(E.ErrTokenNotFound))
+        return
+    }
+
+    h.sessionRevokeAll(c, principal.UserID, principal.FamilyID)
+}
+
+// UserSessionGetsHandler is handler layer to get active session of the user based on its id
+func (h *UserSessionHandler) UserSessionGetsHandler(c *gin.Context) {
+    // get 'id' param from the request context
+    userID, err := uuid.Parse(c.Param("id"))
+    if err != nil {
+        c.Error(E.New(E.ErrParamIsInvalid))
+        return
+    }
+
+    h.sessionGets(c, userID, "")
+}
+
+// UserSessionRevokeHandler is handler layer to revoke session of the user based on the
+// user id and the session id
+func (h *UserSessionHandler) UserSessionRevokeHandler(c *gin.Context) {
+    // get 'id' param from the request context
+    userID, err := uuid.Parse(c.Param("id"))
+    if err != nil {
+        c.Error(E.New(E.ErrParamIsInvalid))
+        return
+    }
+
+    h.sessionRevoke(c, userID, c.Param("sid"))
+}
+
+// UserSessionRevokeAllHandler is handler layer to revoke all session of the user based on its id
+func (h *UserSessionHandler) UserSessionRevokeAllHandler(c *gin.Context) {
+    // get 'id' param from the request context
+    userID, err := uuid.Parse(c.Param("id"))
+    if err != nil {
+        c.Error(E.New(E.ErrParamIsInvalid))
+        return
+    }
+
+    h.sessionRevokeAll(c, userID, "")
+}
+
+// sessionGets will get active session of the user and send it to the client
+func (h *UserSessionHandler) sessionGets(c *gin.Context, userID uuid.UUID, currentID string) {
+    // send request to service layer to retreive the session
+    response, err := h.Service.Gets(userID, currentID)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    // send response to client
+    helper.APIResponse(
+        c,
+        http.StatusOK,
+        "success get session data",
+        response,
+    )
+}
+
+// sessionRevoke will revoke session of the user with the given session id
+func (h *UserSessionHandler) sessionRevoke(c *gin.Context, userID uuid.UUID, sessionID string) {
+    id, err := uuid.Parse(sessionID)
+    if err != nil {
+        c.Error(E.New(E.ErrParamIsInvalid))
+        return
+    }
+
+    // send request to service layer to revoke the session
+    if err := h.Service.Revoke(userID, id); err != nil {
+        c.Error(err)
+        return
+    }
+    helper.SetAudit(c, d.NewAuditLog(d.AuditActionRevoke, d.AuditTargetSession, id.String(), nil, nil))
+
+    // send response to client
+    helper.APIResponse(
+        c,
+        http.StatusOK,
+        "success revoking session",
+        nil,
+    )
+}
+
+// sessionRevokeAll will revoke all session of the user except 'keepID'
+func (h *UserSessionHandler) sessionRevokeAll(c *gin.Context, userID uuid.UUID, keepID string) {
+    // send request to service layer to revoke the session
+    count, err := h.Service.RevokeAll(userID, keepID)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+    helper.SetAudit(c, d.NewAuditLog(d.AuditActionRevokeSessions, d.AuditTargetUser, userID.String(), nil, map[string]int64{"revoked": count}))
+
+    // send response to client
+    helper.APIResponse(
+        c,
+        http.StatusOK,
+        "success revoking session",
+        nil,
+    )
+}
+
+// sessionClient will get the client (device) making the request, it is recorded on
+// the session when token is issued
+func sessionClient(c *gin.Context) d.SessionClient {
+    return d.NewSessionClient(c.Request.UserAgent(), c.ClientIP())
+}
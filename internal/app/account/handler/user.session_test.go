@@ -0,0 +1,255 @@
+/*
+   package handler
+   user.session_test.go
+   - testing behaviour of user.session handler
+*/
+package handler
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/helper"
+	"github.com/stretchr/testify/assert"
+)
+
+// testSessionID is id of the only session known by mockUserSessionHandler
+var testSessionID = uuid.New()
+
+// mockUserSessionHandler is mocked user.session service interface
+type mockUserSessionHandler struct {
+    // keepID is 'keepID' given on the last RevokeAll call
+    keepID string
+}
+
+// Gets is mocked Gets method of IUserSessionService.Gets
+func (m *mockUserSessionHandler) Gets(userID uuid.UUID, currentID string) ([]*d.UserSession, error) {
+    if wantErr {
+        return nil, E.New(E.ErrDatabase)
+    }
+
+    return []*d.UserSession{{
+        ID        : testSessionID,
+        UserID    : userID,
+        UserAgent : "Mozilla/5.0",
+        Current   : testSessionID.String() == currentID,
+    }}, nil
+}
+
+// Revoke is mocked Revoke method of IUserSessionService.Revoke
+func (m *mockUserSessionHandler) Revoke(userID, id uuid.UUID) error {
+    if id != testSessionID {
+        return E.New(E.ErrDataIsEmpty)
+    }
+
+    return nil
+}
+
+// RevokeAll is mocked RevokeAll method of IUserSessionService.RevokeAll
+func (m *mockUserSessionHandler) RevokeAll(userID uuid.UUID, keepID string) (int64, error) {
+    if wantErr {
+        return 0, E.New(E.ErrDatabase)
+    }
+    m.keepID = keepID
+
+    return 1, nil
+}
+
+// TestSessionGetsHandler will test behaviour of SessionGetsHandler and UserSessionGetsHandler
+func TestSessionGetsHandler(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+    handler := NewUserSessionHandler(&mockUserSessionHandler{})
+    principal := &d.Principal{UserID: u[0].ID, FamilyID: testSessionID.String()}
+
+    t.Run("EXPECT SUCCESS current session is marked", func(t *testing.T){
+        writer, context := NewTestWriterContext()
+        context.Request, _ = http.NewRequest("GET", "/me/sessions", nil)
+        helper.SetPrincipal(context, principal)
+
+        ServeTestContext(context, handler.SessionGetsHandler)
+
+        assert.Equal(t, http.StatusOK, writer.Code)
+        assert.Contains(t, writer.Body.String(), `"current":true`)
+    })
+
+    t.Run("EXPECT FAIL principal not found", func(t *testing.T){
+        writer, context := NewTestWriterContext()
+        context.Request, _ = http.NewRequest("GET", "/me/sessions", nil)
+
+        ServeTestContext(context, handler.SessionGetsHandler)
+
+        assert.Equal(t, http.StatusUnauthorized, writer.Code)
+    })
+
+    t.Run("EXPECT SUCCESS session of other user", func(t *testing.T){
+        writer, context := NewTestWriterContext()
+        context.Params = gin.Params{{Key: "id", Value: u[1].ID.String()}}
+        context.Request, _ = http.NewRequest("GET", "/:id/sessions", nil)
+
+        ServeTestContext(context, handler.UserSessionGetsHandler)
+
+        assert.Equal(t, http.StatusOK, writer.Code)
+        assert.Contains(t, writer.Body.String(), u[1].ID.String())
+        assert.Contains(t, writer.Body.String(), `"current":false`)
+    })
+
+    t.Run("EXPECT FAIL bad param id", func(t *testing.T){
+        writer, context := NewTestWriterContext()
+        context.Params = gin.Params{{Key: "id", Value: "leonard"}}
+        context.Request, _ = http.NewRequest("GET", "/:id/sessions", nil)
+
+        ServeTestContext(context, handler.UserSessionGetsHandler)
+
+        assert.Equal(t, http.StatusBadRequest, writer.Code)
+    })
+
+    // EXPECT FAIL service error. Simulated by setting wantErr=true
+    t.Run("EXPECT FAIL service error", func(t *testing.T){
+        writer, context := NewTestWriterContext()
+        context.Request, _ = http.NewRequest("GET", "/me/sessions", nil)
+        helper.SetPrincipal(context, principal)
+
+        wantErr = true
+        ServeTestContext(context, handler.SessionGetsHandler)
+        wantErr = false
+
+        assert.Equal(t, http.StatusInternalServerError, writer.Code)
+    })
+}
+
+// TestSessionRevokeHandler will test behaviour of SessionRevokeHandler and UserSessionRevokeHandler
+func TestSessionRevokeHandler(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+    handler := NewUserSessionHandler(&mockUserSessionHandler{})
+    principal := &d.Principal{UserID: u[0].ID}
+
+    cases := []struct{
+        name      string
+        principal *d.Principal
+        id        string
+        want      int
+    }{
+        {"EXPECT SUCCESS", principal, testSessionID.String(), http.StatusOK},
+        {"EXPECT FAIL principal not found", nil, testSessionID.String(), http.StatusUnauthorized},
+        {"EXPECT FAIL bad param id", principal, "laptop", http.StatusBadRequest},
+        {"EXPECT FAIL session not found", principal, uuid.NewString(), http.StatusNotFound},
+    }
+
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            writer, context := NewTestWriterContext()
+            context.Params = gin.Params{{Key: "id", Value: tt.id}}
+            context.Request, _ = http.NewRequest("DELETE", "/me/sessions/:id", nil)
+            if tt.principal != nil {
+                helper.SetPrincipal(context, tt.principal)
+            }
+
+            ServeTestContext(context, handler.SessionRevokeHandler)
+
+            assert.Equal(t, tt.want, writer.Code)
+        })
+    }
+
+    admin := []struct{
+        name      string
+        id, sid   string
+        want      int
+    }{
+        {"EXPECT SUCCESS session of other user", u[1].ID.String(), testSessionID.String(), http.StatusOK},
+        {"EXPECT FAIL bad param id", "jennydoe", testSessionID.String(), http.StatusBadRequest},
+        {"EXPECT FAIL bad param sid", u[1].ID.String(), "laptop", http.StatusBadRequest},
+        {"EXPECT FAIL session of other user not found", u[1].ID.String(), uuid.NewString(), http.StatusNotFound},
+    }
+
+    for _, tt := range admin {
+        t.Run(tt.name, func(t *testing.T){
+            writer, context := NewTestWriterContext()
+            context.Params = gin.Params{{Key: "id", Value: tt.id}, {Key: "sid", Value: tt.sid}}
+            context.Request, _ = http.NewRequest("DELETE", "/:id/sessions/:sid", nil)
+
+            ServeTestContext(context, handler.UserSessionRevokeHandler)
+
+            assert.Equal(t, tt.want, writer.Code)
+        })
+    }
+}
+
+// TestSessionRevokeAllHandler will test behaviour of SessionRevokeAllHandler and UserSessionRevokeAllHandler
+func TestSessionRevokeAllHandler(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+    mock := &mockUserSessionHandler{}
+    handler := NewUserSessionHandler(mock)
+
+    // EXPECT SUCCESS the session making the request is kept
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        writer, context := NewTestWriterContext()
+        context.Request, _ = http.NewRequest("DELETE", "/me/sessions", nil)
+        helper.SetPrincipal(context, &d.Principal{UserID: u[0].ID, FamilyID: testSessionID.String()})
+
+        ServeTestContext(context, handler.SessionRevokeAllHandler)
+
+        assert.Equal(t, http.StatusOK, writer.Code)
+        assert.Equal(t, testSessionID.String(), mock.keepID)
+    })
+
+    t.Run("EXPECT FAIL principal not found", func(t *testing.T){
+        writer, context := NewTestWriterContext()
+        context.Request, _ = http.NewRequest("DELETE", "/me/sessions", nil)
+
+        ServeTestContext(context, handler.SessionRevokeAllHandler)
+
+        assert.Equal(t, http.StatusUnauthorized, writer.Code)
+    })
+
+    // EXPECT SUCCESS every session of other user is revoked
+    t.Run("EXPECT SUCCESS session of other user", func(t *testing.T){
+        writer, context := NewTestWriterContext()
+        context.Params = gin.Params{{Key: "id", Value: u[1].ID.String()}}
+        context.Request, _ = http.NewRequest("DELETE", "/:id/sessions", nil)
+
+        ServeTestContext(context, handler.UserSessionRevokeAllHandler)
+
+        assert.Equal(t, http.StatusOK, writer.Code)
+        assert.Empty(t, mock.keepID)
+    })
+
+    t.Run("EXPECT FAIL bad param id", func(t *testing.T){
+        writer, context := NewTestWriterContext()
+        context.Params = gin.Params{{Key: "id", Value: "jennydoe"}}
+        context.Request, _ = http.NewRequest("DELETE", "/:id/sessions", nil)
+
+        ServeTestContext(context, handler.UserSessionRevokeAllHandler)
+
+        assert.Equal(t, http.StatusBadRequest, writer.Code)
+    })
+
+    // EXPECT FAIL service error. Simulated by setting wantErr=true
+    t.Run("EXPECT FAIL service error", func(t *testing.T){
+        writer, context := NewTestWriterContext()
+        context.Params = gin.Params{{Key: "id", Value: u[1].ID.String()}}
+        context.Request, _ = http.NewRequest("DELETE", "/:id/sessions", nil)
+
+        wantErr = true
+        ServeTestContext(context, handler.UserSessionRevokeAllHandler)
+        wantErr = false
+
+        assert.Equal(t, http.StatusInternalServerError, writer.Code)
+    })
+}
+
+// TestSessionClient will test getting the client recorded on the session
+func TestSessionClient(t *testing.T) {
+    _, context := NewTestWriterContext()
+    context.Request, _ = http.NewRequest("POST", "/signin", nil)
+    context.Request.Header.Set("User-Agent", "Mozilla/5.0 (X11; Linux x86_64) Firefox/102.0")
+    context.Request.RemoteAddr = "203.0.113.7:52114"
+
+    got := sessionClient(context)
+
+    assert.Equal(t, "Mozilla/5.0 (X11; Linux x86_64) Firefox/102.0", got.UserAgent)
+    assert.Equal(t, "203.0.113.7", got.IPAddress)
+}
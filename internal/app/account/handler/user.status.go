@@ -35,15 +35,14 @@ func (h *UserStatusHandler) UserStatusGetHandler(c *gin.Context) {
     uid := c.Param("id")
     id, err := strconv.Atoi(uid)
     if err != nil {
-        e := E.New(E.ErrParamIsInvalid)
-        helper.APIErrorResponse(c, http.StatusBadRequest, e)
+        c.Error(E.New(E.ErrParamIsInvalid))
         return
     }
 
     // send request to service layer to retreive user.status record
     response, err := h.Service.Get(id)
     if err != nil {
-        helper.APIErrorResponse(c, http.StatusInternalServerError, err)
+        c.Error(err)
         return
     }
 
@@ -61,7 +60,7 @@ func (h *UserStatusHandler) UserStatusGetsHandler(c *gin.Context) {
     // send request to service layer to retreive all user.status record
     response, err := h.Service.Gets()
     if err != nil {
-        helper.APIErrorResponse(c, http.StatusInternalServerError, err)
+        c.Error(err)
         return
     }
 
@@ -94,7 +94,7 @@ func TestUserStatusHandler(t *testing.T) {
         assert.NoError(t, err)
 
         // actual method call
-        handler.UserStatusGetHandler(context)
+        ServeTestContext(context, handler.UserStatusGetHandler)
 
         // prepare expected data for test comparison
         want, err := json.Marshal(us[1])
@@ -117,7 +117,7 @@ func TestUserStatusHandler(t *testing.T) {
         assert.NoError(t, err)
 
         // actual method call
-        handler.UserStatusGetHandler(context)
+        ServeTestContext(context, handler.UserStatusGetHandler)
 
         assert.Equal(t, http.StatusBadRequest, writer.Code)
         assert.Contains(t, string(writer.Body.Bytes()[:]), E.ErrParamIsInvalidMsg)
@@ -141,7 +141,7 @@ func TestUserStatusHandler(t *testing.T) {
         // actual method call
         // set wantErr to force error return
         wantErr = true
-        handler.UserStatusGetHandler(context)
+        ServeTestContext(context, handler.UserStatusGetHandler)
         wantErr = false
 
         assert.Equal(t, http.StatusInternalServerError, writer.Code)
@@ -159,7 +159,7 @@ func TestUserStatusHandler(t *testing.T) {
         assert.NoError(t, err)
 
         // actual method call
-        handler.UserStatusGetsHandler(context)
+        ServeTestContext(context, handler.UserStatusGetsHandler)
 
         // prepare expected data for test comparison
         want, err := json.Marshal(us)
@@ -184,7 +184,7 @@ func TestUserStatusHandler(t *testing.T) {
         // actual method call
         // set wantErr to force error return
         wantErr = true
-        handler.UserStatusGetsHandler(context)
+        ServeTestContext(context, handler.UserStatusGetsHandler)
         wantErr = false
 
         assert.Equal(t, http.StatusInternalServerError, writer.Code)
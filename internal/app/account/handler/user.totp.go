@@ -0,0 +1,132 @@
+/*
+   package handler
+   user.totp.go
+   - handler/ interaction layer for user two factor authentication (totp)
+   - NOTE of method:
+   - -- EnrollHandler    : method to create new totp secret for the current user
+   - -- ConfirmHandler   : method to enable the totp secret and get the recovery codes
+   - -- Signin2FAHandler : method to complete the signin with the two factor code
+*/
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/reshimahendra/lbw-go/internal/app/account/service"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/helper"
+)
+
+// UserTOTPHandler is type wrapper for user.totp service interface
+type UserTOTPHandler struct {
+    Service service.IUserTOTPService
+
+    // Auth is auth service used to issue the token once the signin is completed
+    Auth service.IAuthService
+}
+
+// NewUserTOTPHandler is new instance of UserTOTPHandler
+func NewUserTOTPHandler(Service service.IUserTOTPService, Auth service.IAuthService) *UserTOTPHandler{
+    return &UserTOTPHandler{Service, Auth}
+}
+
+// EnrollHandler is handler layer to create new totp secret for the current user.
+// the secret is not used on signin until it is confirmed
+func (h *UserTOTPHandler) EnrollHandler(c *gin.Context) {
+    // get the current user
+    principal, ok := helper.GetPrincipal(c)
+    if !ok {
+        c.Error(E.New(E.ErrTokenNotFound))
+        return
+    }
+
+    // send request to service layer to create the secret
+    response, err := h.Service.Enroll(*principal)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+
+    // send response to client
+    helper.APIResponse(
+        c,
+        http.StatusOK,
+        "success enrolling two factor authentication",
+        response,
+    )
+}
+
+// ConfirmHandler is handler layer to confirm the enrolled secret with the code
+// shown on the authenticator app. the recovery codes are only shown once
+func (h *UserTOTPHandler) ConfirmHandler(c *gin.Context) {
+    // get the current user
+    principal, ok := helper.GetPrincipal(c)
+    if !ok {
+        c.Error(E.New(E.ErrTokenNotFound))
+        return
+    }
+
+    // get confirm request data from context
+    req := new(d.UserTOTPConfirmRequest)
+    if err := helper.BindJSON(c, req); err != nil {
+        c.Error(err)
+        return
+    }
+
+    // send request to service layer to enable the two factor authentication
+    response, err := h.Service.Confirm(principal.UserID, req.Code)
+    if err != nil {
+        c.Error(err)
+        return
+    }
+    helper.SetAudit(c, d.NewAuditLog(d.AuditActionEnable2FA, d.AuditTargetUser, principal.UserID.String(), nil, nil))
+
+    // send response to client
+    helper.APIResponse(
+        c,
+        http.StatusOK,
+        "success enabling two factor authentication",
+        response,
+    )
+}
+
+// Signin2FAHandler is handler layer to exchange the pending token given on signin
+// and the two factor code (or recovery code) with the access and refresh token
+func (h *UserTOTPHandler) Signin2FAHandler(c *gin.Context) {
+    // get two factor signin data from context
+    var input d.AuthTwoFactorDTO
+    if err := helper.BindJSON(c, &input); err != nil {
+        c.Error(err)
+        return
+    }
+
+    // send request to service layer to verify the pending token and the code
+    principal, err := h.Service.VerifySignin(input, c.ClientIP())
+    if err != nil {
+        // failing to verify is server error, otherwise the signin is rejected
+        c.Error(err)
+        return
+    }
+
+    // signin start a new refresh token family
+    principal.FamilyID = ""
+    token, err := h.Auth.IssueToken(*principal, sessionClient(c))
+    if err != nil {
+        c.Error(E.NewExt(E.ErrTokenCreate, err))
+        return
+    }
+
+    // send token data response to the client
+    helper.APIResponse(
+        c,
+        http.StatusOK,
+        "success signin",
+        d.AuthLoginResponse{
+            AccessToken     : token.AccessToken,
+            RefreshToken    : token.RefreshToken,
+            TransmissionKey : token.TransmissionKey,
+        },
+    )
+}
@@ -0,0 +1,261 @@
+/*
+   package handler
+   user.totp_test.go
+   - testing behaviour of user.totp handler
+*/
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/reshimahendra/lbw-go/internal/config"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/helper"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+    // twoFactorUserID is id of mocked user with two factor authentication enabled
+    twoFactorUserID = uuid.New()
+
+    // twoFactorErrUserID is id of mocked user failing the two factor authentication check
+    twoFactorErrUserID = uuid.New()
+)
+
+// mockUserTOTPHandler is mocked user.totp service
+type mockUserTOTPHandler struct {
+    t *testing.T
+}
+
+// NewMockUserTOTPHandler is new instance of mockUserTOTPHandler
+func NewMockUserTOTPHandler(t *testing.T) *mockUserTOTPHandler {
+    return &mockUserTOTPHandler{t}
+}
+
+// Enroll is mocked Enroll method to satisfy IUserTOTPService interface
+func (m *mockUserTOTPHandler) Enroll(principal d.Principal) (*d.UserTOTPEnrollResponse, error) {
+    if wantErr {
+        return nil, E.New(E.ErrDatabase)
+    }
+    if principal.UserID == twoFactorUserID {
+        return nil, E.New(E.ErrTwoFactorAlreadyEnabled)
+    }
+
+    return &d.UserTOTPEnrollResponse{
+        Secret          : "JBSWY3DPEHPK3PXP",
+        ProvisioningURI : "otpauth://totp/Lotus:" + principal.Email + "?secret=JBSWY3DPEHPK3PXP",
+    }, nil
+}
+
+// Confirm is mocked Confirm method to satisfy IUserTOTPService interface
+func (m *mockUserTOTPHandler) Confirm(userID uuid.UUID, code string) (*d.UserTOTPConfirmResponse, error) {
+    if wantErr {
+        return nil, E.New(E.ErrDatabase)
+    }
+    if userID == twoFactorUserID {
+        return nil, E.New(E.ErrTwoFactorAlreadyEnabled)
+    }
+    if code == "000000" {
+        return nil, E.New(E.ErrTwoFactorCodeInvalid)
+    }
+
+    return &d.UserTOTPConfirmResponse{RecoveryCodes: []string{"a1b2c-3d4e5"}}, nil
+}
+
+// IsEnabled is mocked IsEnabled method to satisfy IUserTOTPService interface
+func (m *mockUserTOTPHandler) IsEnabled(userID uuid.UUID) (bool, error) {
+    if wantErr || userID == twoFactorErrUserID {
+        return false, E.New(E.ErrDatabase)
+    }
+
+    return userID == twoFactorUserID, nil
+}
+
+// IssuePendingToken is mocked IssuePendingToken method to satisfy IUserTOTPService interface
+func (m *mockUserTOTPHandler) IssuePendingToken(principal d.Principal) (*d.AuthTwoFactorResponse, error) {
+    if principal.Email == "pendingfail@lotusbw.com" {
+        return nil, E.New(E.ErrTokenCreate)
+    }
+
+    return &d.AuthTwoFactorResponse{
+        TwoFactorRequired : true,
+        PendingToken      : "pending",
+        ExpiresAt         : time.Now().Add(5 * time.Minute),
+    }, nil
+}
+
+// VerifySignin is mocked VerifySignin method to satisfy IUserTOTPService interface
+func (m *mockUserTOTPHandler) VerifySignin(input d.AuthTwoFactorDTO, ip string) (*d.Principal, error) {
+    if wantErr {
+        return nil, E.NewExt(E.ErrDatabase, E.New(E.ErrDatabase))
+    }
+    if input.PendingToken != "pending" {
+        return nil, E.New(E.ErrTokenInvalid)
+    }
+
+    switch input.Code {
+    case "000000":
+        return nil, E.New(E.ErrTwoFactorCodeInvalid)
+    case "badmail":
+        return &d.Principal{UserID: twoFactorUserID, Email: "dddd.com"}, nil
+    }
+
+    return &d.Principal{UserID: twoFactorUserID, Email: "2fa@lotusbw.com", RoleID: 4, StatusID: 1}, nil
+}
+
+// NewTestUserTOTPHandler is function wrapper to get the mock handler of our handler layer
+func NewTestUserTOTPHandler(t *testing.T) *UserTOTPHandler {
+    t.Helper()
+
+    // set gin to test mode
+    gin.SetMode(gin.TestMode)
+
+    return NewUserTOTPHandler(NewMockUserTOTPHandler(t), NewMockAuthHandler(t))
+}
+
+// testTwoFactorRequest will prepare json request with given body
+func testTwoFactorRequest(t *testing.T, body interface{}) *http.Request {
+    var data []byte
+    if body != nil {
+        var err error
+        data, err = json.Marshal(body)
+        assert.NoError(t, err)
+    }
+
+    req, err := http.NewRequest("POST", "/", bytes.NewBuffer(data))
+    assert.NoError(t, err)
+    req.Header.Add("content-type", "application/json")
+
+    return req
+}
+
+// TestEnrollHandler will test behaviour of EnrollHandler method of handler layer
+func TestEnrollHandler(t *testing.T) {
+    handler := NewTestUserTOTPHandler(t)
+
+    cases := []struct{
+        name string
+        principal *d.Principal
+        wantErr bool
+        wantCode int
+        wantMsg string
+    }{
+        {"EXPECT SUCCESS", &d.Principal{UserID: u[0].ID, Email: u[0].Email}, false, http.StatusOK, "success enrolling two factor authentication"},
+        {"EXPECT FAIL principal not found", nil, false, http.StatusUnauthorized, E.ErrTokenNotFoundMsg},
+        {"EXPECT FAIL already enabled", &d.Principal{UserID: twoFactorUserID}, false, http.StatusConflict, E.ErrTwoFactorAlreadyEnabledMsg},
+        {"EXPECT FAIL database error", &d.Principal{UserID: u[0].ID}, true, http.StatusInternalServerError, E.ErrDatabaseMsg},
+    }
+
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            // prepare request/ response / gin context
+            writer, context := NewTestWriterContext()
+            context.Request = testTwoFactorRequest(t, nil)
+            if tt.principal != nil {
+                helper.SetPrincipal(context, tt.principal)
+            }
+
+            // actual method handler call
+            wantErr = tt.wantErr
+            ServeTestContext(context, handler.EnrollHandler)
+            wantErr = false
+
+            // validation and verification
+            assert.Equal(t, tt.wantCode, writer.Code)
+            assert.Contains(t, writer.Body.String(), tt.wantMsg)
+        })
+    }
+}
+
+// TestConfirmHandler will test behaviour of ConfirmHandler method of handler layer
+func TestConfirmHandler(t *testing.T) {
+    handler := NewTestUserTOTPHandler(t)
+    principal := &d.Principal{UserID: u[0].ID, Email: u[0].Email}
+
+    cases := []struct{
+        name string
+        body interface{}
+        principal *d.Principal
+        wantErr bool
+        wantCode int
+        wantMsg string
+    }{
+        {"EXPECT SUCCESS", d.UserTOTPConfirmRequest{Code: "123456"}, principal, false, http.StatusOK, "a1b2c-3d4e5"},
+        {"EXPECT FAIL principal not found", d.UserTOTPConfirmRequest{Code: "123456"}, nil, false, http.StatusUnauthorized, E.ErrTokenNotFoundMsg},
+        {"EXPECT FAIL bind json error", "invalid", principal, false, http.StatusBadRequest, E.ErrRequestDataInvalidMsg},
+        {"EXPECT FAIL code invalid", d.UserTOTPConfirmRequest{Code: "000000"}, principal, false, http.StatusUnprocessableEntity, E.ErrTwoFactorCodeInvalidMsg},
+        {"EXPECT FAIL already enabled", d.UserTOTPConfirmRequest{Code: "123456"}, &d.Principal{UserID: twoFactorUserID}, false, http.StatusConflict, E.ErrTwoFactorAlreadyEnabledMsg},
+        {"EXPECT FAIL database error", d.UserTOTPConfirmRequest{Code: "123456"}, principal, true, http.StatusInternalServerError, E.ErrDatabaseMsg},
+    }
+
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            // prepare request/ response / gin context
+            writer, context := NewTestWriterContext()
+            context.Request = testTwoFactorRequest(t, tt.body)
+            if tt.principal != nil {
+                helper.SetPrincipal(context, tt.principal)
+            }
+
+            // actual method handler call
+            wantErr = tt.wantErr
+            ServeTestContext(context, handler.ConfirmHandler)
+            wantErr = false
+
+            // validation and verification
+            assert.Equal(t, tt.wantCode, writer.Code)
+            assert.Contains(t, writer.Body.String(), tt.wantMsg)
+        })
+    }
+}
+
+// TestSignin2FAHandler will test behaviour of Signin2FAHandler method of handler layer
+func TestSignin2FAHandler(t *testing.T) {
+    handler := NewTestUserTOTPHandler(t)
+
+    // prepare config for token creation
+    err := config.Setup()
+    assert.NoError(t, err)
+
+    cases := []struct{
+        name string
+        body interface{}
+        wantErr bool
+        wantCode int
+        wantMsg string
+    }{
+        {"EXPECT SUCCESS", d.AuthTwoFactorDTO{PendingToken: "pending", Code: "123456"}, false, http.StatusOK, "success signin"},
+        {"EXPECT FAIL bind json error", "invalid", false, http.StatusBadRequest, E.ErrRequestDataInvalidMsg},
+        {"EXPECT FAIL pending token invalid", d.AuthTwoFactorDTO{PendingToken: "invalid", Code: "123456"}, false, http.StatusUnauthorized, E.ErrTokenInvalidMsg},
+        {"EXPECT FAIL code invalid", d.AuthTwoFactorDTO{PendingToken: "pending", Code: "000000"}, false, http.StatusUnprocessableEntity, E.ErrTwoFactorCodeInvalidMsg},
+        {"EXPECT FAIL verify error", d.AuthTwoFactorDTO{PendingToken: "pending", Code: "123456"}, true, http.StatusInternalServerError, E.ErrDatabaseMsg},
+        {"EXPECT FAIL token create error", d.AuthTwoFactorDTO{PendingToken: "pending", Code: "badmail"}, false, http.StatusInternalServerError, E.ErrTokenCreateMsg},
+    }
+
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            // prepare request/ response / gin context
+            writer, context := NewTestWriterContext()
+            context.Request = testTwoFactorRequest(t, tt.body)
+
+            // actual method handler call
+            wantErr = tt.wantErr
+            ServeTestContext(context, handler.Signin2FAHandler)
+            wantErr = false
+
+            // validation and verification
+            assert.Equal(t, tt.wantCode, writer.Code)
+            assert.Contains(t, writer.Body.String(), tt.wantMsg)
+            if tt.wantCode == http.StatusOK {
+                assert.Contains(t, writer.Body.String(), "access_token")
+            }
+        })
+    }
+}
@@ -5,12 +5,21 @@ import (
 	ds "github.com/reshimahendra/lbw-go/internal/app/account/datastore"
 	h "github.com/reshimahendra/lbw-go/internal/app/account/handler"
 	s "github.com/reshimahendra/lbw-go/internal/app/account/service"
+	"github.com/reshimahendra/lbw-go/internal/config"
 	db "github.com/reshimahendra/lbw-go/internal/database"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
 	"github.com/reshimahendra/lbw-go/internal/middleware"
+	"github.com/reshimahendra/lbw-go/internal/pkg/auth"
+	"github.com/reshimahendra/lbw-go/internal/pkg/mailer"
 )
 
 
 func Router(dbPool db.IDatabase, router *gin.Engine) {
+    // audit log layer setup
+    auditDatastore      := ds.NewAuditStore(dbPool)
+    auditService        := s.NewAuditService(auditDatastore)
+    auditHandler        := h.NewAuditHandler(auditService)
+
     // user.status layer setup
     userStatusDatastore := ds.NewUserStatusStore(dbPool)
     userStatusService := s.NewUserStatusService(userStatusDatastore)
@@ -21,33 +30,163 @@ func Router(dbPool db.IDatabase, router *gin.Engine) {
     userRoleService     := s.NewUserRoleService(userRoleDatastore)
     userRoleHandler     := h.NewUserRoleHandler(userRoleService)
 
+    // permission layer setup
+    permissionDatastore := ds.NewPermissionStore(dbPool)
+    permissionService   := s.NewPermissionService(permissionDatastore)
+    permissionHandler   := h.NewPermissionHandler(permissionService)
+
     // user layer setup
     userDatastore       := ds.NewUserStore(dbPool)
-    userService         := s.NewUserService(userDatastore)
-    userHandler         := h.NewUserHandler(userService)
+    userService         := s.NewUserService(userDatastore, permissionService)
+
+    // user.activation layer setup
+    mail                     := mailer.New(config.Get().Mail)
+    userActivationDatastore  := ds.NewUserActivationStore(dbPool)
+    userActivationService    := s.NewUserActivationService(userActivationDatastore, userDatastore, mail)
+    userActivationHandler    := h.NewUserActivationHandler(userActivationService)
+
+    // user.password layer setup
+    userPasswordDatastore    := ds.NewUserPasswordStore(dbPool)
+    userPasswordService      := s.NewUserPasswordService(userPasswordDatastore, userDatastore, mail)
+    userPasswordHandler      := h.NewUserPasswordHandler(userPasswordService)
+
+    // auth layer setup
+    authDatastore       := ds.NewAuthStore(dbPool)
+    authService         := s.NewAuthService(authDatastore, userDatastore)
+    authHandler         := h.NewAuthHandler(authService)
+
+    // signin attempt (brute-force protection) layer setup
+    signinAttemptDatastore := ds.NewSigninAttemptStore(dbPool)
+    signinAttemptService   := s.NewSigninAttemptService(signinAttemptDatastore)
+
+    // user.totp (two factor authentication) layer setup
+    userTOTPDatastore   := ds.NewUserTOTPStore(dbPool)
+    userTOTPService     := s.NewUserTOTPService(userTOTPDatastore, authDatastore, signinAttemptService)
+    userTOTPHandler     := h.NewUserTOTPHandler(userTOTPService, authService)
+
+    // user.apikey (personal access token) layer setup
+    userAPIKeyDatastore := ds.NewUserAPIKeyStore(dbPool)
+    userAPIKeyService   := s.NewUserAPIKeyService(userAPIKeyDatastore)
+    userAPIKeyHandler   := h.NewUserAPIKeyHandler(userAPIKeyService)
+
+    // user.session (signed in device) layer setup
+    userSessionDatastore := ds.NewUserSessionStore(dbPool)
+    userSessionService   := s.NewUserSessionService(userSessionDatastore)
+    userSessionHandler   := h.NewUserSessionHandler(userSessionService)
+
+    userHandler         := h.NewUserHandler(userService, userActivationService, authService, userTOTPService, signinAttemptService)
+
+    // oidc (signin with openid connect provider) layer setup
+    oidcDatastore       := ds.NewOIDCStore(dbPool)
+    oidcService         := s.NewOIDCService(oidcDatastore, userDatastore, s.NewOIDCProviders(config.Get().OIDC))
+    oidcHandler         := h.NewOIDCHandler(oidcService, authService, userTOTPService)
+
+    // purge user and role deleted longer than the retention days. user is purged first
+    // so the role it held can be purged on the same run
+    if days := config.Get().Account.DeletedRetentionDays; days > 0 && dbPool != nil {
+        s.NewPurgeService(days, userDatastore, userRoleDatastore).Start(s.PurgeInterval)
+    }
+
+    // check permission granted to the role on the database
+    middleware.SetPermissionProvider(permissionService)
+
+    // reject revoked token on every token validation
+    auth.SetRevocationChecker(authService)
+
+    // persist signing key promotion and retirement, so it survive restart and is shared by all instance
+    if dbPool != nil {
+        auth.SetKeyRingStore(ds.NewSigningKeyStore(dbPool))
+    }
+
+    // accept api key of machine client next to the auth token
+    middleware.SetAPIKeyAuthenticator(userAPIKeyService)
+
+    // keep audit log of the change made on the account app
+    middleware.SetAuditRecorder(auditService)
+
+    // publish public key to verify auth token
+    router.GET("/.well-known/jwks.json", authHandler.JWKSHandler)
 
     // app router group
     user := router.Group("/account")
     user.Use(middleware.CORS())
     user.Use(middleware.Security())
+    user.Use(middleware.Audit())
 
     user.POST("/signup", userHandler.SignupHandler)
     user.POST("/signin", userHandler.SigninHandler)
+    user.POST("/signin/2fa", userTOTPHandler.Signin2FAHandler)
+    user.GET("/activate", userActivationHandler.ActivateHandler)
+    user.POST("/activation/resend", userActivationHandler.ResendHandler)
+    user.POST("/password/forgot", userPasswordHandler.ForgotHandler)
+    user.POST("/password/reset", userPasswordHandler.ResetHandler)
+
+    // router for signin with openid connect provider
+    user.GET("/oidc/", oidcHandler.OIDCProvidersHandler)
+    user.GET("/oidc/:provider", oidcHandler.OIDCAuthorizeHandler)
+    user.GET("/oidc/:provider/callback", oidcHandler.OIDCCallbackHandler)
 
     // need authorization
     userAuth := router.Group("/account")
     userAuth.Use(middleware.CORS())
     userAuth.Use(middleware.Security())
     userAuth.Use(middleware.Authorize())
+    userAuth.Use(middleware.Audit())
+
+    // router for profile of the current user
+    userAuth.GET("/me", userHandler.MeGetHandler)
+    userAuth.PUT("/me", middleware.DenyAPIKey(), userHandler.MeUpdateHandler)
+    userAuth.DELETE("/me", middleware.DenyAPIKey(), userHandler.MeDeleteHandler)
+    userAuth.PUT("/me/password", middleware.DenyAPIKey(), userPasswordHandler.ChangeHandler)
+
+    // router for api key of the current user, api key can not manage api key
+    userAuth.POST("/me/api-keys", middleware.DenyAPIKey(), middleware.LoadPermissions(), userAPIKeyHandler.APIKeyCreateHandler)
+    userAuth.GET("/me/api-keys", middleware.DenyAPIKey(), userAPIKeyHandler.APIKeyGetsHandler)
+    userAuth.DELETE("/me/api-keys/:id", middleware.DenyAPIKey(), userAPIKeyHandler.APIKeyRevokeHandler)
+
+    // router for session of the current user, api key is not a session
+    userAuth.GET("/me/sessions", middleware.DenyAPIKey(), userSessionHandler.SessionGetsHandler)
+    userAuth.DELETE("/me/sessions", middleware.DenyAPIKey(), userSessionHandler.SessionRevokeAllHandler)
+    userAuth.DELETE("/me/sessions/:id", middleware.DenyAPIKey(), userSessionHandler.SessionRevokeHandler)
 
     // Router for User
-    userAuth.POST("/", userHandler.UserCreateHandler)
-    user.PUT("/:id", userHandler.UserUpdateHandler)
-    userAuth.DELETE("/:id", userHandler.UserDeleteHandler)
-    userAuth.GET("/:id", userHandler.UserGetHandler)
-    userAuth.GET("/", userHandler.UserGetsHandler)
+    userAuth.POST("/", middleware.RequirePermission(d.PermUserWrite), userHandler.UserCreateHandler)
+    userAuth.PUT("/:id", middleware.RequirePermission(d.PermUserWrite), userHandler.UserUpdateHandler)
+    userAuth.PATCH("/:id", middleware.RequirePermission(d.PermUserWrite), userHandler.UserPatchHandler)
+    userAuth.DELETE("/:id", middleware.RequirePermission(d.PermUserWrite), userHandler.UserDeleteHandler)
+    userAuth.GET("/:id", middleware.RequirePermission(d.PermUserRead), userHandler.UserGetHandler)
+    userAuth.GET("/", middleware.RequirePermission(d.PermUserRead), userHandler.UserGetsHandler)
+
+    // router for session of the user
+    userAuth.GET("/:id/sessions", middleware.RequirePermission(d.PermUserRead), userSessionHandler.UserSessionGetsHandler)
+    userAuth.DELETE("/:id/sessions", middleware.RequirePermission(d.PermUserWrite), userSessionHandler.UserSessionRevokeAllHandler)
+    userAuth.DELETE("/:id/sessions/:sid", middleware.RequirePermission(d.PermUserWrite), userSessionHandler.UserSessionRevokeHandler)
+
+    // router for (soft) deleted user
+    userAuth.GET("/trash/", middleware.RequirePermission(d.PermUserRead), userHandler.UserTrashGetsHandler)
+    userAuth.POST("/trash/:id/restore", middleware.RequirePermission(d.PermUserWrite), userHandler.UserRestoreHandler)
+    userAuth.DELETE("/trash/:id", middleware.RequirePermission(d.PermUserWrite), userHandler.UserPurgeHandler)
+
     userAuth.POST("/refresh-token", userHandler.RefreshTokenHandler)
     userAuth.POST("/check-token", userHandler.CheckTokenHandler)
+    userAuth.POST("/signout", authHandler.SignoutHandler)
+
+    // router for audit log of the change made on the account app
+    userAuth.GET("/audit/", middleware.RequirePermission(d.PermAuditRead), auditHandler.AuditGetsHandler)
+
+    // router for the permission catalogue
+    userAuth.GET("/permission/", middleware.RequirePermission(d.PermRoleRead), permissionHandler.PermissionGetsHandler)
+
+    // router for two factor authentication of the current user
+    userAuth.POST("/2fa/enroll", middleware.DenyAPIKey(), userTOTPHandler.EnrollHandler)
+    userAuth.POST("/2fa/confirm", middleware.DenyAPIKey(), userTOTPHandler.ConfirmHandler)
+
+    // router for auth token signing key ring
+    signingKeyAuth := userAuth.Group("/keys")
+    signingKeyAuth.Use(middleware.RequirePermission(d.PermKeyManage))
+    signingKeyAuth.GET("/", authHandler.SigningKeysHandler)
+    signingKeyAuth.POST("/:kid/promote", authHandler.PromoteSigningKeyHandler)
+    signingKeyAuth.DELETE("/:kid", authHandler.RetireSigningKeyHandler)
 
     // router for user.status
     userStatus := user.Group("/status")
@@ -57,9 +196,20 @@ func Router(dbPool db.IDatabase, router *gin.Engine) {
     // router for user.role
     // userRole := user.Group("/role")
     userRoleAuth := userAuth.Group("/role")
-    userRoleAuth.POST("/", userRoleHandler.UserRoleCreateHandler)
-    userRoleAuth.PUT("/:id", userRoleHandler.UserRoleUpdateHandler)
-    userRoleAuth.DELETE("/:id", userRoleHandler.UserRoleDeletesHandler)
-    userRoleAuth.GET("/:id", userRoleHandler.UserRoleGetHandler)
-    userRoleAuth.GET("/", userRoleHandler.UserRoleGetsHandler)
+    userRoleAuth.POST("/", middleware.RequirePermission(d.PermRoleWrite), userRoleHandler.UserRoleCreateHandler)
+    userRoleAuth.PUT("/:id", middleware.RequirePermission(d.PermRoleWrite), userRoleHandler.UserRoleUpdateHandler)
+    userRoleAuth.PATCH("/:id", middleware.RequirePermission(d.PermRoleWrite), userRoleHandler.UserRolePatchHandler)
+    userRoleAuth.DELETE("/:id", middleware.RequirePermission(d.PermRoleWrite), userRoleHandler.UserRoleDeletesHandler)
+    userRoleAuth.GET("/:id", middleware.RequirePermission(d.PermRoleRead), userRoleHandler.UserRoleGetHandler)
+    userRoleAuth.GET("/", middleware.RequirePermission(d.PermRoleRead), userRoleHandler.UserRoleGetsHandler)
+
+    // router for permission granted to user.role
+    userRoleAuth.GET("/:id/permission/", middleware.RequirePermission(d.PermRoleRead), permissionHandler.RolePermissionGetsHandler)
+    userRoleAuth.PUT("/:id/permission/:code", middleware.RequirePermission(d.PermRoleWrite), permissionHandler.RolePermissionGrantHandler)
+    userRoleAuth.DELETE("/:id/permission/:code", middleware.RequirePermission(d.PermRoleWrite), permissionHandler.RolePermissionRevokeHandler)
+
+    // router for (soft) deleted user.role
+    userRoleAuth.GET("/trash/", middleware.RequirePermission(d.PermRoleRead), userRoleHandler.UserRoleTrashGetsHandler)
+    userRoleAuth.POST("/trash/:id/restore", middleware.RequirePermission(d.PermRoleWrite), userRoleHandler.UserRoleRestoreHandler)
+    userRoleAuth.DELETE("/trash/:id", middleware.RequirePermission(d.PermRoleWrite), userRoleHandler.UserRolePurgeHandler)
 }
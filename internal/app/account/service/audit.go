@@ -0,0 +1,42 @@
+/*
+   service package
+   audit.go
+   - service/ business layer for audit log (record of change made on the account app)
+*/
+package service
+
+import (
+	ds "github.com/reshimahendra/lbw-go/internal/app/account/datastore"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+)
+
+// IAuditService is service layer for audit log so the handler layer can
+// communicate with the datastore layer
+type IAuditService interface {
+    // Record will keep audit log of the change, it satisfy middleware.IAuditRecorder
+    Record(entry d.AuditLog) error
+
+    // Gets will get audit log matching the list query along with the list metadata
+    Gets(q d.ListQuery) ([]*d.AuditLog, *d.ListMeta, error)
+}
+
+// AuditService is instance wrapper for IAuditStore interface
+type AuditService struct {
+    Store ds.IAuditStore
+}
+
+// NewAuditService is new instance of AuditService
+func NewAuditService(store ds.IAuditStore) *AuditService {
+    return &AuditService{Store: store}
+}
+
+// Record will send request to datastore to append the audit log
+func (s *AuditService) Record(entry d.AuditLog) error {
+    _, err := s.Store.Create(entry)
+    return err
+}
+
+// Gets will send request to datastore to get audit log matching the list query
+func (s *AuditService) Gets(q d.ListQuery) ([]*d.AuditLog, *d.ListMeta, error) {
+    return s.Store.Gets(q)
+}
@@ -0,0 +1,113 @@
+/*
+    package service
+    audit_test.go
+    - test unit for audit service
+*/
+package service
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockAuditService is mocked audit datastore
+type mockAuditService struct {
+    logs []d.AuditLog
+}
+
+// Create is mocked Create method to satisfy IAuditStore interface
+func (m *mockAuditService) Create(input d.AuditLog) (*d.AuditLog, error) {
+    if wantErr {
+        return nil, E.New(E.ErrDatabase)
+    }
+    if !input.IsValid() {
+        return nil, E.New(E.ErrDataIsInvalid)
+    }
+
+    input.ID = int64(len(m.logs) + 1)
+    m.logs = append(m.logs, input)
+
+    return &input, nil
+}
+
+// Gets is mocked Gets method to satisfy IAuditStore interface
+func (m *mockAuditService) Gets(q d.ListQuery) ([]*d.AuditLog, *d.ListMeta, error) {
+    if wantErr {
+        return nil, nil, E.New(E.ErrDatabase)
+    }
+
+    logs := []*d.AuditLog{}
+    for i := range m.logs {
+        if q.TargetType == "" || m.logs[i].TargetType == q.TargetType {
+            logs = append(logs, &m.logs[i])
+        }
+    }
+
+    return logs, &d.ListMeta{Total: int64(len(logs)), Page: q.Page, Limit: q.Limit, Sort: q.Sort, Order: q.Order}, nil
+}
+
+// TestAuditServiceRecord will test Record method of audit service
+func TestAuditServiceRecord(t *testing.T) {
+    store := &mockAuditService{}
+    service := NewAuditService(store)
+
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        entry := d.NewAuditLog(d.AuditActionUpdate, d.AuditTargetRole, "4",
+            d.UserRoleResponse{ID: 4, RoleName: "Editor"},
+            d.UserRoleResponse{ID: 4, RoleName: "Writer"},
+        )
+
+        err := service.Record(*entry)
+
+        assert.NoError(t, err)
+        assert.Len(t, store.logs, 1)
+        assert.Equal(t, d.AuditChanges{"role_name": {Before: "Editor", After: "Writer"}}, store.logs[0].Changes)
+    })
+
+    t.Run("EXPECT FAIL audit log is invalid", func(t *testing.T){
+        err := service.Record(d.AuditLog{Action: d.AuditActionDelete, TargetType: d.AuditTargetUser})
+
+        assert.EqualValues(t, E.ErrDataIsInvalid, err.(*E.Error).Code)
+    })
+
+    // EXPECT FAIL datastore error. Simulated by setting wantErr=true
+    t.Run("EXPECT FAIL datastore error", func(t *testing.T){
+        wantErr = true
+        err := service.Record(*d.NewAuditLog(d.AuditActionDelete, d.AuditTargetUser, uuid.NewString(), nil, nil))
+        wantErr = false
+
+        assert.EqualValues(t, E.ErrDatabase, err.(*E.Error).Code)
+    })
+}
+
+// TestAuditServiceGets will test Gets method of audit service
+func TestAuditServiceGets(t *testing.T) {
+    store := &mockAuditService{}
+    service := NewAuditService(store)
+    _ = service.Record(*d.NewAuditLog(d.AuditActionCreate, d.AuditTargetRole, "4", nil, d.UserRoleResponse{ID: 4}))
+    _ = service.Record(*d.NewAuditLog(d.AuditActionDelete, d.AuditTargetUser, uuid.NewString(), nil, nil))
+    q := d.ListQuery{Page: 1, Limit: 20, Sort: "created_at", Order: d.SortDesc, TargetType: d.AuditTargetRole}
+
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        got, meta, err := service.Gets(q)
+
+        assert.NoError(t, err)
+        assert.Len(t, got, 1)
+        assert.Equal(t, d.AuditActionCreate, got[0].Action)
+        assert.Equal(t, int64(1), meta.Total)
+    })
+
+    // EXPECT FAIL datastore error. Simulated by setting wantErr=true
+    t.Run("EXPECT FAIL datastore error", func(t *testing.T){
+        wantErr = true
+        got, _, err := service.Gets(q)
+        wantErr = false
+
+        assert.Nil(t, got)
+        assert.EqualValues(t, E.ErrDatabase, err.(*E.Error).Code)
+    })
+}
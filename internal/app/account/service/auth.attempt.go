@@ -0,0 +1,182 @@
+/*
+   service package
+   auth.attempt.go
+   - service/ business layer for failed signin attempt (brute-force protection)
+*/
+package service
+
+import (
+	"time"
+
+	ds "github.com/reshimahendra/lbw-go/internal/app/account/datastore"
+	"github.com/reshimahendra/lbw-go/internal/config"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/logger"
+)
+
+const (
+    // defaultSigninLockoutThreshold is fallback number of failed signin on a user account before it is locked
+    defaultSigninLockoutThreshold = 5
+
+    // defaultSigninIPLockoutThreshold is fallback number of failed signin from a client ip before it is locked
+    defaultSigninIPLockoutThreshold = 20
+
+    // defaultSigninLockoutDuration is fallback lock duration (in minute)
+    defaultSigninLockoutDuration = 15
+
+    // defaultSigninDelayBase is fallback delay (in second) after the first failed signin
+    defaultSigninDelayBase = 1
+
+    // defaultSigninDelayMax is fallback longest delay (in second) between failed signin
+    defaultSigninDelayMax = 30
+)
+
+var (
+    // timeNowFunc is func instance of time.Now
+    // it will be used to mock the inner func on test
+    timeNowFunc = time.Now
+)
+
+// ISigninAttemptService is service layer for failed signin attempt so the handler
+// layer can throttle and lock brute-force signin
+type ISigninAttemptService interface {
+    // Check will check whether the subject may attempt to signin. when it may not,
+    // it return how long the subject should wait before the next attempt
+    Check(scope, subject string) (time.Duration, error)
+
+    // Fail will count the failed signin of the subject and lock it once the threshold is reached
+    Fail(scope, subject string) error
+
+    // Reset will clear the failed signin of the subject after successful signin
+    Reset(scope, subject string) error
+}
+
+// SigninAttemptService is instance wrapper for ISigninAttemptStore interface
+type SigninAttemptService struct {
+    Store ds.ISigninAttemptStore
+}
+
+// NewSigninAttemptService is new instance of SigninAttemptService
+func NewSigninAttemptService(store ds.ISigninAttemptStore) *SigninAttemptService {
+    return &SigninAttemptService{Store: store}
+}
+
+// signinPolicy is the lockout and delay setting of the failed signin
+type signinPolicy struct {
+    threshold   int
+    ipThreshold int
+    lockout     time.Duration
+    delayBase   time.Duration
+    delayMax    time.Duration
+}
+
+// Check will send request to datastore to get the failed attempt of the subject. locked user
+// account get E.ErrAccountLocked, while locked ip and user account still on its delay get E.ErrSigninThrottled
+func (s *SigninAttemptService) Check(scope, subject string) (time.Duration, error) {
+    attempt, err := s.Store.Get(scope, subject)
+    if err != nil {
+        // no failed attempt recorded
+        if e, ok := err.(*E.Error); ok && e.Code == E.ErrDataIsEmpty {
+            return 0, nil
+        }
+        return 0, err
+    }
+
+    now := timeNowFunc().UTC()
+    if attempt.LockedUntil != nil && now.Before(*attempt.LockedUntil) {
+        if scope == d.SigninScopeUser {
+            return attempt.LockedUntil.Sub(now), E.New(E.ErrAccountLocked)
+        }
+        return attempt.LockedUntil.Sub(now), E.New(E.ErrSigninThrottled)
+    }
+
+    // the delay only apply to user account, many users may share the same ip
+    if scope == d.SigninScopeUser && attempt.FailedCount > 0 {
+        next := attempt.LastFailedAt.Add(loadSigninPolicy().delay(attempt.FailedCount))
+        if now.Before(next) {
+            return next.Sub(now), E.New(E.ErrSigninThrottled)
+        }
+    }
+
+    return 0, nil
+}
+
+// Fail will send request to datastore to count the failed attempt and lock the subject
+// when its failed attempt reach the threshold of its scope
+func (s *SigninAttemptService) Fail(scope, subject string) error {
+    policy := loadSigninPolicy()
+    now := timeNowFunc().UTC()
+
+    // failed attempt older than the lockout duration is forgotten
+    attempt, err := s.Store.Fail(scope, subject, now, now.Add(-policy.lockout))
+    if err != nil {
+        return err
+    }
+
+    threshold := policy.threshold
+    if scope == d.SigninScopeIP {
+        threshold = policy.ipThreshold
+    }
+    if attempt.FailedCount < threshold {
+        return nil
+    }
+
+    if err := s.Store.Lock(scope, subject, now.Add(policy.lockout)); err != nil {
+        return err
+    }
+    logger.Infof("signin %s %s locked for %v after %d failed attempt", scope, subject, policy.lockout, attempt.FailedCount)
+
+    return nil
+}
+
+// Reset will send request to datastore to remove the failed attempt of the subject
+func (s *SigninAttemptService) Reset(scope, subject string) error {
+    return s.Store.Reset(scope, subject)
+}
+
+// delay will get the delay after the given number of failed attempt.
+// the delay start from delayBase and is doubled on each failed attempt up to delayMax
+func (p signinPolicy) delay(failedCount int) time.Duration {
+    delay := p.delayBase
+    for i := 1; i < failedCount && delay < p.delayMax; i++ {
+        delay *= 2
+    }
+    if delay > p.delayMax {
+        delay = p.delayMax
+    }
+
+    return delay
+}
+
+// loadSigninPolicy will get the signin lockout and delay setting from account
+// configuration, falling back to default value when it is not set
+func loadSigninPolicy() signinPolicy {
+    policy := signinPolicy{
+        threshold   : defaultSigninLockoutThreshold,
+        ipThreshold : defaultSigninIPLockoutThreshold,
+        lockout     : defaultSigninLockoutDuration * time.Minute,
+        delayBase   : defaultSigninDelayBase * time.Second,
+        delayMax    : defaultSigninDelayMax * time.Second,
+    }
+
+    if cfg := config.Get(); cfg != nil {
+        if cfg.Account.SigninLockoutThreshold > 0 {
+            policy.threshold = cfg.Account.SigninLockoutThreshold
+        }
+        if cfg.Account.SigninIPLockoutThreshold > 0 {
+            policy.ipThreshold = cfg.Account.SigninIPLockoutThreshold
+        }
+        if cfg.Account.SigninLockoutDuration > 0 {
+            policy.lockout = time.Duration(cfg.Account.SigninLockoutDuration) * time.Minute
+        }
+        if cfg.Account.SigninDelayBase > 0 {
+            policy.delayBase = time.Duration(cfg.Account.SigninDelayBase) * time.Second
+        }
+        if cfg.Account.SigninDelayMax > 0 {
+            policy.delayMax = time.Duration(cfg.Account.SigninDelayMax) * time.Second
+        }
+    }
+
+    return policy
+}
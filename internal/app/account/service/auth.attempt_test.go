@@ -0,0 +1,216 @@
+/*
+    package service
+    auth.attempt_test.go
+    - test unit for failed signin attempt service
+*/
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/reshimahendra/lbw-go/internal/config"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockSigninAttemptService is mocked signin attempt datastore
+type mockSigninAttemptService struct {
+    t *testing.T
+    attempts map[string]*d.SigninAttempt
+}
+
+// NewMockSigninAttemptService is new instance of mockSigninAttemptService
+func NewMockSigninAttemptService(t *testing.T) *mockSigninAttemptService {
+    return &mockSigninAttemptService{t: t, attempts: map[string]*d.SigninAttempt{}}
+}
+
+// Get is mocked Get method to satisfy ISigninAttemptStore interface
+func (m *mockSigninAttemptService) Get(scope, subject string) (*d.SigninAttempt, error) {
+    if wantErr {
+        return nil, E.New(E.ErrDatabase)
+    }
+    attempt, ok := m.attempts[scope+subject]
+    if !ok {
+        return nil, E.New(E.ErrDataIsEmpty)
+    }
+
+    return attempt, nil
+}
+
+// Fail is mocked Fail method to satisfy ISigninAttemptStore interface
+func (m *mockSigninAttemptService) Fail(scope, subject string, failedAt, windowStart time.Time) (*d.SigninAttempt, error) {
+    if wantErr {
+        return nil, E.New(E.ErrDatabase)
+    }
+    attempt, ok := m.attempts[scope+subject]
+    if !ok {
+        attempt = &d.SigninAttempt{Scope: scope, Subject: subject}
+        m.attempts[scope+subject] = attempt
+    }
+    if attempt.LastFailedAt.Before(windowStart) {
+        attempt.FailedCount = 0
+    }
+    attempt.FailedCount++
+    attempt.LastFailedAt = failedAt
+
+    return attempt, nil
+}
+
+// Lock is mocked Lock method to satisfy ISigninAttemptStore interface
+func (m *mockSigninAttemptService) Lock(scope, subject string, until time.Time) error {
+    attempt := m.attempts[scope+subject]
+    attempt.FailedCount = 0
+    attempt.LockedUntil = &until
+
+    return nil
+}
+
+// Reset is mocked Reset method to satisfy ISigninAttemptStore interface
+func (m *mockSigninAttemptService) Reset(scope, subject string) error {
+    if wantErr {
+        return E.New(E.ErrDatabase)
+    }
+    delete(m.attempts, scope+subject)
+
+    return nil
+}
+
+// mockTimeNow will mock time.Now with the returned clock, the clock can be moved forward
+func mockTimeNow(t *testing.T) *time.Time {
+    now := time.Now()
+    timeNow := timeNowFunc
+    timeNowFunc = func() time.Time { return now }
+    t.Cleanup(func() { timeNowFunc = timeNow })
+
+    return &now
+}
+
+// TestSigninAttemptServiceUser will test lockout and delay of signin attempt on user account
+func TestSigninAttemptServiceUser(t *testing.T) {
+    if err := config.Setup(); err != nil {
+        t.Fatalf("unexpected error occur: %v", err)
+    }
+    now := mockTimeNow(t)
+    mock := NewMockSigninAttemptService(t)
+    service := NewSigninAttemptService(mock)
+    subject := u[0].ID.String()
+
+    // EXPECT SUCCESS no failed attempt
+    t.Run("EXPECT SUCCESS no failed attempt", func(t *testing.T){
+        wait, err := service.Check(d.SigninScopeUser, subject)
+        assert.NoError(t, err)
+        assert.Equal(t, time.Duration(0), wait)
+    })
+
+    // EXPECT FAIL throttled with progressive delay (1s, 2s, 4s, 8s)
+    t.Run("EXPECT FAIL throttled with progressive delay", func(t *testing.T){
+        for i, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second} {
+            assert.NoError(t, service.Fail(d.SigninScopeUser, subject))
+
+            wait, err := service.Check(d.SigninScopeUser, subject)
+            assert.Equal(t, E.New(E.ErrSigninThrottled), err, "attempt %d", i+1)
+            assert.Equal(t, want, wait, "attempt %d", i+1)
+
+            // wait for the delay to pass
+            *now = now.Add(want)
+            wait, err = service.Check(d.SigninScopeUser, subject)
+            assert.NoError(t, err)
+            assert.Equal(t, time.Duration(0), wait)
+        }
+    })
+
+    // EXPECT FAIL account locked once the threshold is reached
+    t.Run("EXPECT FAIL account locked", func(t *testing.T){
+        assert.NoError(t, service.Fail(d.SigninScopeUser, subject))
+
+        wait, err := service.Check(d.SigninScopeUser, subject)
+        assert.Equal(t, E.New(E.ErrAccountLocked), err)
+        assert.Equal(t, 15 * time.Minute, wait)
+    })
+
+    // EXPECT SUCCESS lock is over
+    t.Run("EXPECT SUCCESS lock is over", func(t *testing.T){
+        *now = now.Add(15 * time.Minute)
+
+        wait, err := service.Check(d.SigninScopeUser, subject)
+        assert.NoError(t, err)
+        assert.Equal(t, time.Duration(0), wait)
+    })
+
+    // EXPECT SUCCESS counter reset on successful signin
+    t.Run("EXPECT SUCCESS reset", func(t *testing.T){
+        assert.NoError(t, service.Fail(d.SigninScopeUser, subject))
+        assert.NoError(t, service.Reset(d.SigninScopeUser, subject))
+
+        wait, err := service.Check(d.SigninScopeUser, subject)
+        assert.NoError(t, err)
+        assert.Equal(t, time.Duration(0), wait)
+        assert.NotContains(t, mock.attempts, d.SigninScopeUser+subject)
+    })
+
+    // EXPECT FAIL database error. Simulated by forcing to return error (set wantErr=true)
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        wantErr = true
+        _, checkErr := service.Check(d.SigninScopeUser, subject)
+        failErr := service.Fail(d.SigninScopeUser, subject)
+        wantErr = false
+
+        assert.Equal(t, E.New(E.ErrDatabase), checkErr)
+        assert.Equal(t, E.New(E.ErrDatabase), failErr)
+    })
+}
+
+// TestSigninAttemptServiceIP will test lockout of signin attempt from client ip
+func TestSigninAttemptServiceIP(t *testing.T) {
+    if err := config.Setup(); err != nil {
+        t.Fatalf("unexpected error occur: %v", err)
+    }
+    now := mockTimeNow(t)
+    service := NewSigninAttemptService(NewMockSigninAttemptService(t))
+    subject := "10.0.0.1"
+
+    // EXPECT SUCCESS no delay below the threshold
+    t.Run("EXPECT SUCCESS below threshold", func(t *testing.T){
+        for i := 1; i < config.Get().Account.SigninIPLockoutThreshold; i++ {
+            assert.NoError(t, service.Fail(d.SigninScopeIP, subject))
+        }
+
+        wait, err := service.Check(d.SigninScopeIP, subject)
+        assert.NoError(t, err)
+        assert.Equal(t, time.Duration(0), wait)
+    })
+
+    // EXPECT FAIL ip locked once the threshold is reached
+    t.Run("EXPECT FAIL ip locked", func(t *testing.T){
+        assert.NoError(t, service.Fail(d.SigninScopeIP, subject))
+
+        wait, err := service.Check(d.SigninScopeIP, subject)
+        assert.Equal(t, E.New(E.ErrSigninThrottled), err)
+        assert.Equal(t, 15 * time.Minute, wait)
+    })
+
+    // EXPECT SUCCESS old failed attempt is forgotten
+    t.Run("EXPECT SUCCESS old failed attempt forgotten", func(t *testing.T){
+        *now = now.Add(15 * time.Minute)
+        assert.NoError(t, service.Fail(d.SigninScopeIP, subject))
+
+        *now = now.Add(16 * time.Minute)
+        assert.NoError(t, service.Fail(d.SigninScopeIP, subject))
+
+        attempt, err := service.Store.Get(d.SigninScopeIP, subject)
+        assert.NoError(t, err)
+        assert.Equal(t, 1, attempt.FailedCount)
+    })
+}
+
+// TestSigninPolicyDelay will test the progressive delay is capped by delayMax
+func TestSigninPolicyDelay(t *testing.T) {
+    policy := signinPolicy{delayBase: time.Second, delayMax: 30 * time.Second}
+
+    assert.Equal(t, time.Second, policy.delay(1))
+    assert.Equal(t, 16 * time.Second, policy.delay(5))
+    assert.Equal(t, 30 * time.Second, policy.delay(6))
+    assert.Equal(t, 30 * time.Second, policy.delay(100))
+}
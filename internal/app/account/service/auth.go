@@ -5,3 +5,310 @@
 */
 package service
 
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+	ds "github.com/reshimahendra/lbw-go/internal/app/account/datastore"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	"github.com/reshimahendra/lbw-go/internal/pkg/auth"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/logger"
+)
+
+var (
+    // tokenValidFunc is func instance of auth.TokenValid
+    // it will be used to mock the inner func on test
+    tokenValidFunc = auth.TokenValid
+
+    // refreshTokenValidFunc is func instance of auth.RefreshTokenValid
+    // it will be used to mock the inner func on test
+    refreshTokenValidFunc = auth.RefreshTokenValid
+
+    // createTokenFunc is func instance of auth.CreateToken
+    // it will be used to mock the inner func on test
+    createTokenFunc = auth.CreateToken
+
+    // publicJWKSFunc is func instance of auth.PublicJWKS
+    // it will be used to mock the inner func on test
+    publicJWKSFunc = auth.PublicJWKS
+
+    // signingKeysFunc is func instance of auth.SigningKeys
+    // it will be used to mock the inner func on test
+    signingKeysFunc = auth.SigningKeys
+
+    // promoteSigningKeyFunc is func instance of auth.PromoteSigningKey
+    // it will be used to mock the inner func on test
+    promoteSigningKeyFunc = auth.PromoteSigningKey
+
+    // retireSigningKeyFunc is func instance of auth.RetireSigningKey
+    // it will be used to mock the inner func on test
+    retireSigningKeyFunc = auth.RetireSigningKey
+)
+
+// IAuthService is service layer for authentification so the handler layer can
+// communicate with the datastore layer
+type IAuthService interface {
+    // Signout will revoke the given access token and refresh token so both
+    // token can not be used anymore even before it is expired
+    Signout(accessToken, refreshToken string) error
+
+    // IsTokenRevoked will check whether token with given metadata is already revoked
+    IsTokenRevoked(token d.TokenMetadata) (bool, error)
+
+    // RevokeUserTokens will revoke all token issued to the user so far, it sign
+    // the user out of all session
+    RevokeUserTokens(userID uuid.UUID) error
+
+    // IssueToken will create access and refresh token for the principal and
+    // record the refresh token on its family and the client on its session
+    IssueToken(principal d.Principal, client d.SessionClient) (*d.TokenDetailsDTO, error)
+
+    // Refresh will rotate the given refresh token into new access and refresh token.
+    // reusing refresh token that already rotated will revoke its whole family
+    Refresh(refreshToken string, client d.SessionClient) (*d.TokenDetailsDTO, error)
+
+    // JWKS will get the public key set to verify our auth token
+    JWKS() (*d.JWKS, error)
+
+    // SigningKeys will get the state of all keys on the signing key ring
+    SigningKeys() ([]*d.SigningKeyResponse, error)
+
+    // PromoteSigningKey will make the key with the given key id sign new token
+    PromoteSigningKey(kid string) error
+
+    // RetireSigningKey will remove the key with the given key id from the signing key ring
+    RetireSigningKey(kid string) error
+}
+
+// AuthService is instance wrapper for IAuthStore interface
+type AuthService struct {
+    Store ds.IAuthStore
+
+    // UserStore is user datastore used to re-check the user status on refresh
+    UserStore ds.IUserStore
+}
+
+// NewAuthService is new instance of AuthService
+func NewAuthService(store ds.IAuthStore, userStore ds.IUserStore) *AuthService {
+    return &AuthService{Store: store, UserStore: userStore}
+}
+
+// Signout will put access token and refresh token on the denylist and revoke the refresh token
+// family (session) of the access token, so the session is closed even without the refresh token
+func (s *AuthService) Signout(accessToken, refreshToken string) error {
+    // access token is mandatory
+    metadata, err := s.revoke(accessToken, tokenValidFunc)
+    if err != nil {
+        return err
+    }
+
+    // revoke the family of the access token, its refresh token can not be used anymore
+    if metadata.FamilyID != "" {
+        if err := s.Store.RevokeTokenFamily(metadata.FamilyID); err != nil {
+            logger.Errorf("signout fail: %v", err)
+            return E.NewExt(E.ErrSignOut, err)
+        }
+    }
+
+    // refresh token is optional, but it must be valid when it is given
+    if refreshToken != "" {
+        if _, err := s.revoke(refreshToken, refreshTokenValidFunc); err != nil {
+            return err
+        }
+    }
+
+    return nil
+}
+
+// IsTokenRevoked will send request to datastore to check whether token is on the denylist
+func (s *AuthService) IsTokenRevoked(token d.TokenMetadata) (bool, error) {
+    return s.Store.IsTokenRevoked(token)
+}
+
+// RevokeUserTokens will send request to datastore to revoke all token issued to the user until now
+func (s *AuthService) RevokeUserTokens(userID uuid.UUID) error {
+    if err := s.Store.RevokeUserTokens(userID, revocationCutoff()); err != nil {
+        logger.Errorf("revoke user tokens fail: %v", err)
+        return err
+    }
+
+    return nil
+}
+
+// IssueToken will create token for the principal and send request to datastore
+// to record the refresh token. principal without family start a new session
+func (s *AuthService) IssueToken(principal d.Principal, client d.SessionClient) (*d.TokenDetailsDTO, error) {
+    token, err := createTokenFunc(principal)
+    if err != nil {
+        logger.Errorf("issue token fail: %v", err)
+        return nil, E.New(E.ErrTokenCreate)
+    }
+
+    // record the refresh token so it can only be used once
+    err = s.Store.CreateRefreshToken(d.TokenMetadata{
+        ID        : token.RefreshTokenID,
+        UserID    : principal.UserID,
+        FamilyID  : token.FamilyID,
+        ExpiresAt : token.RtExpiresTime,
+    }, client)
+    if err != nil {
+        logger.Errorf("issue token fail: %v", err)
+        return nil, E.NewExt(E.ErrTokenCreate, err)
+    }
+
+    return token, nil
+}
+
+// Refresh will validate and rotate the refresh token, re-check its user status
+// and issue new token on the same family (session)
+func (s *AuthService) Refresh(refreshToken string, client d.SessionClient) (*d.TokenDetailsDTO, error) {
+    // validate token. access token or token that already revoked (including its family)
+    // or expired is rejected
+    token, err := refreshTokenValidFunc(refreshToken)
+    if err != nil {
+        logger.Errorf("refresh token fail: %v", err)
+        return nil, err
+    }
+
+    // get token id and its family
+    metadata, err := auth.ExtractTokenMetadata(token)
+    if err != nil {
+        logger.Errorf("refresh token fail: %v", err)
+        return nil, err
+    }
+    if metadata.FamilyID == "" {
+        return nil, E.New(E.ErrTokenInvalid)
+    }
+
+    // send request to datastore to mark the token as used
+    rotated, err := s.Store.RotateRefreshToken(*metadata)
+    if err != nil {
+        logger.Errorf("refresh token fail: %v", err)
+
+        // token never recorded as refresh token (eg. access token)
+        if e, ok := err.(*E.Error); ok && e.Code == E.ErrDataIsEmpty {
+            return nil, E.New(E.ErrTokenInvalid)
+        }
+        return nil, E.NewExt(E.ErrTokenRefresh, err)
+    }
+
+    // token already rotated before, it might be stolen. revoke the whole family
+    if !rotated {
+        s.revokeFamily(metadata.FamilyID)
+        return nil, E.New(E.ErrRefreshTokenReused)
+    }
+
+    // re-check the user, deleted or inactive user can not keep the session alive
+    user, err := s.UserStore.Get(metadata.UserID)
+    if err != nil {
+        logger.Errorf("refresh token fail: %v", err)
+        if e, ok := err.(*E.Error); ok && e.Code == E.ErrDataIsEmpty {
+            s.revokeFamily(metadata.FamilyID)
+            return nil, E.New(E.ErrUserNotActive)
+        }
+        return nil, E.NewExt(E.ErrTokenRefresh, err)
+    }
+
+    if !user.ConvertToCredential().IsActive() {
+        s.revokeFamily(metadata.FamilyID)
+        return nil, E.New(E.ErrUserNotActive)
+    }
+
+    // issue new token with the current user data on the same family
+    return s.IssueToken(d.Principal{
+        UserID   : user.ID,
+        Email    : user.Email,
+        RoleID   : user.RoleID,
+        StatusID : user.StatusID,
+        FamilyID : metadata.FamilyID,
+    }, client)
+}
+
+// JWKS will get the public key of our signing key
+func (s *AuthService) JWKS() (*d.JWKS, error) {
+    jwks, err := publicJWKSFunc()
+    if err != nil {
+        logger.Errorf("get jwks fail: %v", err)
+        return nil, err
+    }
+
+    return jwks, nil
+}
+
+// SigningKeys will get the state of all keys on the signing key ring
+func (s *AuthService) SigningKeys() ([]*d.SigningKeyResponse, error) {
+    keys, err := signingKeysFunc()
+    if err != nil {
+        logger.Errorf("get signing keys fail: %v", err)
+        return nil, err
+    }
+
+    return keys, nil
+}
+
+// PromoteSigningKey will promote the signing key, the previous active key
+// keep verifying token signed by it
+func (s *AuthService) PromoteSigningKey(kid string) error {
+    if err := promoteSigningKeyFunc(kid); err != nil {
+        logger.Errorf("promote signing key %q fail: %v", kid, err)
+        return err
+    }
+    logger.Infof("signing key %q promoted", kid)
+
+    return nil
+}
+
+// RetireSigningKey will retire the signing key once no valid token signed by it is left
+func (s *AuthService) RetireSigningKey(kid string) error {
+    if err := retireSigningKeyFunc(kid); err != nil {
+        logger.Errorf("retire signing key %q fail: %v", kid, err)
+        return err
+    }
+    logger.Infof("signing key %q retired", kid)
+
+    return nil
+}
+
+// revocationCutoff will get the cutoff to revoke the user tokens issued until now. it is
+// truncated to second since the token 'iat' claim only has second precision, token issued
+// on the cutoff second is revoked (see IAuthStore.IsTokenRevoked), so token issued on the
+// same second before the revocation does not survive it
+func revocationCutoff() time.Time {
+    return timeNowFunc().UTC().Truncate(time.Second)
+}
+
+// revokeFamily will send request to datastore to revoke the refresh token family
+// failing to revoke is only logged since the request is rejected anyway
+func (s *AuthService) revokeFamily(familyID string) {
+    if err := s.Store.RevokeTokenFamily(familyID); err != nil {
+        logger.Errorf("revoke token family %s fail: %v", familyID, err)
+    }
+}
+
+// revoke will validate the token with the given validator, put it on the denylist and
+// return its metadata
+func (s *AuthService) revoke(tokenStr string, valid func(string) (*jwt.Token, error)) (*d.TokenMetadata, error) {
+    // validate token. token of other type or that already revoked or expired is rejected
+    token, err := valid(tokenStr)
+    if err != nil {
+        logger.Errorf("signout fail: %v", err)
+        return nil, err
+    }
+
+    // get token id and its expiration time
+    metadata, err := auth.ExtractTokenMetadata(token)
+    if err != nil {
+        logger.Errorf("signout fail: %v", err)
+        return nil, err
+    }
+
+    // send request to datastore to put the token on the denylist
+    if err := s.Store.RevokeToken(*metadata); err != nil {
+        logger.Errorf("signout fail: %v", err)
+        return nil, E.NewExt(E.ErrSignOut, err)
+    }
+
+    return metadata, nil
+}
@@ -0,0 +1,295 @@
+/*
+   service package
+   auth.oidc.go
+   - service/ business layer for signin with openid connect provider (social login)
+*/
+package service
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	ds "github.com/reshimahendra/lbw-go/internal/app/account/datastore"
+	"github.com/reshimahendra/lbw-go/internal/config"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/helper"
+	"github.com/reshimahendra/lbw-go/internal/pkg/logger"
+	"github.com/reshimahendra/lbw-go/internal/pkg/oidc"
+)
+
+const (
+    // oidcTokenLength is byte length of the generated state, nonce and pkce code verifier
+    oidcTokenLength = 32
+
+    // defaultOIDCStateExpireDuration is fallback valid duration (in minute) of the signin state
+    defaultOIDCStateExpireDuration = 10
+
+    // oidcUnusablePassword is password of user created on signin with the provider. it is
+    // not a password hash so no password match it, the user can set one with password reset
+    oidcUnusablePassword = "!"
+
+    // oidcUsernameLength and oidcNameLength is max length of username and name of the user
+    oidcUsernameLength = 30
+    oidcNameLength     = 30
+)
+
+// IOIDCService is service layer for signin with openid connect provider
+type IOIDCService interface {
+    // ProviderNames will get name of the provider the user can signin with
+    ProviderNames() []string
+
+    // Authorize will start signin with the provider and get the url to send the user to
+    Authorize(provider string) (*d.OIDCAuthorizeResponse, error)
+
+    // Callback will complete signin with the authorization code and the state sent back
+    // by the provider, and get the principal of the signed in user
+    Callback(provider, code, state string) (*d.Principal, error)
+}
+
+// OIDCService is instance wrapper for IOIDCStore interface
+type OIDCService struct {
+    // Store is oidc datastore
+    Store     ds.IOIDCStore
+
+    // UserStore is user datastore, used to look up user by email
+    UserStore ds.IUserStore
+
+    // Providers is the provider the user can signin with, keyed by its name
+    Providers map[string]oidc.IProvider
+}
+
+// NewOIDCService is new instance of OIDCService
+func NewOIDCService(st ds.IOIDCStore, us ds.IUserStore, providers map[string]oidc.IProvider) *OIDCService {
+    return &OIDCService{Store: st, UserStore: us, Providers: providers}
+}
+
+// NewOIDCProviders will create the provider on the configuration. provider with
+// incomplete configuration is skipped
+func NewOIDCProviders(cfg config.OIDC) map[string]oidc.IProvider {
+    providers := map[string]oidc.IProvider{}
+    for _, p := range cfg.Providers {
+        if !p.IsValid() {
+            logger.Errorf("oidc provider %q configuration is incomplete, it is skipped", p.Name)
+            continue
+        }
+        providers[p.Name] = oidc.NewProvider(p)
+    }
+
+    return providers
+}
+
+// ProviderNames will get name of the provider the user can signin with
+func (s *OIDCService) ProviderNames() []string {
+    names := []string{}
+    for name := range s.Providers {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+
+    return names
+}
+
+// Authorize will generate the state, nonce and pkce code verifier of new signin and send
+// request to datastore to save it. only the state hash is saved, the state itself is sent
+// to the provider along with the nonce and the code challenge
+func (s *OIDCService) Authorize(name string) (*d.OIDCAuthorizeResponse, error) {
+    provider, ok := s.Providers[name]
+    if !ok {
+        return nil, E.New(E.ErrOIDCProviderNotFound)
+    }
+
+    var secrets [3]string
+    for i := range secrets {
+        token, err := generateTokenFunc(oidcTokenLength)
+        if err != nil {
+            logger.Errorf("generate oidc signin state fail: %v", err)
+            return nil, E.NewExt(E.ErrTokenCreate, err)
+        }
+        secrets[i] = token
+    }
+    state, nonce, verifier := secrets[0], secrets[1], secrets[2]
+
+    authURL, err := provider.AuthCodeURL(state, nonce, verifier)
+    if err != nil {
+        return nil, err
+    }
+
+    // send request to datastore to save the signin state
+    err = s.Store.CreateState(d.OIDCState{
+        StateHash    : helper.HashToken(state),
+        Provider     : name,
+        CodeVerifier : verifier,
+        Nonce        : nonce,
+        ExpiresAt    : timeNowFunc().Add(time.Duration(oidcStateExpireDuration()) * time.Minute),
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return &d.OIDCAuthorizeResponse{AuthorizationURL: authURL, State: state}, nil
+}
+
+// Callback will use the signin state, exchange the authorization code with the id token
+// and verify it. the user is found by the provider identity, or by the email verified by
+// the provider (the identity is linked to it), otherwise new user is created
+func (s *OIDCService) Callback(name, code, state string) (*d.Principal, error) {
+    provider, ok := s.Providers[name]
+    if !ok {
+        return nil, E.New(E.ErrOIDCProviderNotFound)
+    }
+    if code == "" || state == "" {
+        return nil, E.New(E.ErrOIDCStateInvalid)
+    }
+
+    // the state can only be used once, on the provider it was started with
+    pending, err := s.Store.ConsumeState(helper.HashToken(state), name)
+    if err != nil {
+        if e, ok := err.(*E.Error); ok && e.Code == E.ErrDataIsEmpty {
+            return nil, E.New(E.ErrOIDCStateInvalid)
+        }
+        return nil, err
+    }
+
+    rawIDToken, err := provider.Exchange(code, pending.CodeVerifier)
+    if err != nil {
+        return nil, err
+    }
+    idToken, err := provider.VerifyIDToken(rawIDToken, pending.Nonce)
+    if err != nil {
+        return nil, err
+    }
+
+    principal, err := s.signinUser(name, idToken)
+    if err != nil {
+        return nil, err
+    }
+
+    if !(&d.UserCredential{StatusID: principal.StatusID}).IsActive() {
+        return nil, E.New(E.ErrUserNotActive)
+    }
+
+    return principal, nil
+}
+
+// signinUser will get the principal of the user owning the provider identity. identity
+// that is not linked yet is linked to the user with the same verified email, or to new user
+func (s *OIDCService) signinUser(name string, idToken *oidc.IDToken) (*d.Principal, error) {
+    user, err := s.Store.GetIdentityUser(name, idToken.Subject)
+    if err == nil {
+        return &d.Principal{UserID: user.ID, Email: user.Email, RoleID: user.RoleID, StatusID: user.StatusID}, nil
+    }
+    if e, ok := err.(*E.Error); !ok || e.Code != E.ErrDataIsEmpty {
+        return nil, err
+    }
+
+    // email not verified by the provider can not be trusted to link or create user
+    if !idToken.IsEmailVerified() {
+        logger.Errorf("oidc %s subject %s signin with unverified email", name, idToken.Subject)
+        return nil, E.New(E.ErrOIDCEmailNotVerified)
+    }
+    email := strings.ToLower(idToken.Email)
+    identity := d.UserIdentity{Provider: name, Subject: idToken.Subject, Email: email}
+
+    // link the identity to the user with the same email
+    cred, err := s.UserStore.GetByEmail(email)
+    if err == nil {
+        identity.UserID = cred.ID
+        if err := s.Store.LinkIdentity(identity); err != nil {
+            return nil, err
+        }
+        return &d.Principal{UserID: cred.ID, Email: email, RoleID: cred.RoleID, StatusID: cred.StatusID}, nil
+    }
+    if e, ok := err.(*E.Error); !ok || e.Code != E.ErrDataIsEmpty {
+        return nil, err
+    }
+
+    // create new user, its email is already verified by the provider
+    input, err := s.newIdentityUser(idToken, email)
+    if err != nil {
+        return nil, err
+    }
+    user, err = s.Store.CreateUser(*input, identity)
+    if err != nil {
+        return nil, err
+    }
+
+    return &d.Principal{UserID: user.ID, Email: user.Email, RoleID: user.RoleID, StatusID: user.StatusID}, nil
+}
+
+// newIdentityUser will create active user from the id token claims. the username is taken
+// from the preferred username or the email, a random suffix is added when it is taken
+func (s *OIDCService) newIdentityUser(idToken *oidc.IDToken, email string) (*d.User, error) {
+    username := oidcUsername(idToken.PreferredUsername)
+    if username == "" {
+        username = oidcUsername(strings.SplitN(email, "@", 2)[0])
+    }
+    if username == "" {
+        username = "user"
+    }
+
+    taken, err := s.UserStore.IsUserExist(username, email)
+    if err != nil {
+        return nil, err
+    }
+    if taken {
+        suffix, err := generateTokenFunc(3)
+        if err != nil {
+            return nil, E.NewExt(E.ErrTokenCreate, err)
+        }
+        username = truncate(username, oidcUsernameLength-len(suffix)-1) + "_" + strings.ToLower(suffix)
+    }
+
+    firstname := idToken.GivenName
+    if firstname == "" {
+        firstname = idToken.Name
+    }
+    if firstname == "" {
+        firstname = username
+    }
+
+    return &d.User{
+        ID        : uuid.New(),
+        Username  : username,
+        Firstname : truncate(firstname, oidcNameLength),
+        Lastname  : d.NullString(truncate(idToken.FamilyName, oidcNameLength)),
+        Email     : email,
+        PassKey   : oidcUnusablePassword,
+        StatusID  : 1,
+        RoleID    : d.RoleGuest,
+    }, nil
+}
+
+// oidcUsername will keep only lowercase letter, digit, '.', '_' and '-' of the name
+func oidcUsername(name string) string {
+    var b strings.Builder
+    for _, r := range strings.ToLower(name) {
+        if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("._-", r)) {
+            b.WriteRune(r)
+        }
+    }
+
+    return truncate(b.String(), oidcUsernameLength)
+}
+
+// truncate will cut the string to the given length of character
+func truncate(s string, length int) string {
+    runes := []rune(s)
+    if len(runes) > length {
+        return string(runes[:length])
+    }
+
+    return s
+}
+
+// oidcStateExpireDuration will get valid duration (in minute) of the signin state
+func oidcStateExpireDuration() int64 {
+    if cfg := config.Get(); cfg != nil && cfg.OIDC.StateExpireDuration > 0 {
+        return cfg.OIDC.StateExpireDuration
+    }
+
+    return defaultOIDCStateExpireDuration
+}
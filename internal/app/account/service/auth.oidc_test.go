@@ -0,0 +1,354 @@
+/*
+    package service
+    auth.oidc_test.go
+    - test unit for openid connect signin service against local stand-in provider
+*/
+package service
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+	"github.com/reshimahendra/lbw-go/internal/config"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/oidc/oidctest"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockOIDCService is mocked oidc datastore keeping the state and identity in memory
+type mockOIDCService struct {
+    states     map[string]d.OIDCState
+    identities map[string]d.UserIdentity
+    users      map[uuid.UUID]*d.User
+}
+
+// newMockOIDCService will create empty mocked oidc datastore
+func newMockOIDCService() *mockOIDCService {
+    return &mockOIDCService{
+        states     : map[string]d.OIDCState{},
+        identities : map[string]d.UserIdentity{},
+        users      : map[uuid.UUID]*d.User{},
+    }
+}
+
+// CreateState is mocked CreateState method to satisfy IOIDCStore interface
+func (m *mockOIDCService) CreateState(input d.OIDCState) error {
+    if wantErr {
+        return E.New(E.ErrDatabase)
+    }
+
+    m.states[input.StateHash] = input
+    return nil
+}
+
+// ConsumeState is mocked ConsumeState method to satisfy IOIDCStore interface
+func (m *mockOIDCService) ConsumeState(stateHash, provider string) (*d.OIDCState, error) {
+    state, ok := m.states[stateHash]
+    if !ok || state.Provider != provider {
+        return nil, E.New(E.ErrDataIsEmpty)
+    }
+
+    delete(m.states, stateHash)
+    return &state, nil
+}
+
+// GetIdentityUser is mocked GetIdentityUser method to satisfy IOIDCStore interface
+func (m *mockOIDCService) GetIdentityUser(provider, subject string) (*d.User, error) {
+    identity, ok := m.identities[provider+"/"+subject]
+    if !ok {
+        return nil, E.New(E.ErrDataIsEmpty)
+    }
+
+    if user, ok := m.users[identity.UserID]; ok {
+        return user, nil
+    }
+    return u[0], nil
+}
+
+// LinkIdentity is mocked LinkIdentity method to satisfy IOIDCStore interface
+func (m *mockOIDCService) LinkIdentity(input d.UserIdentity) error {
+    m.identities[input.Provider+"/"+input.Subject] = input
+    return nil
+}
+
+// CreateUser is mocked CreateUser method to satisfy IOIDCStore interface
+func (m *mockOIDCService) CreateUser(user d.User, identity d.UserIdentity) (*d.User, error) {
+    identity.UserID = user.ID
+    m.users[user.ID] = &user
+    m.identities[identity.Provider+"/"+identity.Subject] = identity
+
+    return &user, nil
+}
+
+// mockOIDCUserStore is mocked user datastore returning the given result on GetByEmail
+// and IsUserExist
+type mockOIDCUserStore struct {
+    *mockUserService
+    cred  *d.UserCredential
+    err   error
+    taken bool
+}
+
+// GetByEmail is mocked GetByEmail method to satisfy IUserStore interface
+func (m *mockOIDCUserStore) GetByEmail(email string) (*d.UserCredential, error) {
+    return m.cred, m.err
+}
+
+// IsUserExist is mocked IsUserExist method to satisfy IUserStore interface
+func (m *mockOIDCUserStore) IsUserExist(username, email string) (bool, error) {
+    return m.taken, nil
+}
+
+// newTestOIDCService will start stand-in provider and create oidc service using it
+func newTestOIDCService(t *testing.T, us *mockOIDCUserStore) (*oidctest.Server, *mockOIDCService, *OIDCService) {
+    t.Helper()
+
+    idp := oidctest.NewServer("lbw-go", "lbw-go-secret")
+    t.Cleanup(idp.Close)
+
+    store := newMockOIDCService()
+    providers := NewOIDCProviders(config.OIDC{Providers: []config.OIDCProvider{
+        {
+            Name         : "test",
+            Issuer       : idp.Issuer(),
+            ClientID     : "lbw-go",
+            ClientSecret : "lbw-go-secret",
+            RedirectURL  : "https://lotusbw.com/account/oidc/test/callback",
+        },
+        // incomplete provider configuration is skipped
+        {Name: "incomplete", Issuer: idp.Issuer()},
+    }})
+
+    return idp, store, NewOIDCService(store, us, providers)
+}
+
+// signin will start signin on the service and approve it on the stand-in provider
+func signin(t *testing.T, idp *oidctest.Server, service *OIDCService, claims jwt.MapClaims) (code, state string) {
+    t.Helper()
+
+    res, err := service.Authorize("test")
+    assert.NoError(t, err)
+
+    code, state, err = idp.Authorize(res.AuthorizationURL, claims)
+    assert.NoError(t, err)
+    assert.Equal(t, res.State, state)
+
+    return code, state
+}
+
+// TestOIDCServiceProviderNames will test ProviderNames method of oidc service
+func TestOIDCServiceProviderNames(t *testing.T) {
+    _, _, service := newTestOIDCService(t, &mockOIDCUserStore{})
+
+    assert.Equal(t, []string{"test"}, service.ProviderNames())
+}
+
+// TestOIDCServiceAuthorize will test Authorize method of oidc service
+func TestOIDCServiceAuthorize(t *testing.T) {
+    _, store, service := newTestOIDCService(t, &mockOIDCUserStore{})
+
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        got, err := service.Authorize("test")
+
+        assert.NoError(t, err)
+        assert.NotEmpty(t, got.State)
+        assert.Contains(t, got.AuthorizationURL, "code_challenge_method=S256")
+        assert.Len(t, store.states, 1)
+
+        // only the state hash is saved, the code verifier never leave the server
+        for hash, state := range store.states {
+            assert.NotEqual(t, got.State, hash)
+            assert.NotContains(t, got.AuthorizationURL, state.CodeVerifier)
+        }
+    })
+
+    t.Run("EXPECT FAIL provider not found", func(t *testing.T){
+        got, err := service.Authorize("incomplete")
+
+        assert.Nil(t, got)
+        assert.EqualValues(t, E.ErrOIDCProviderNotFound, err.(*E.Error).Code)
+    })
+
+    // EXPECT FAIL datastore error. Simulated by setting wantErr=true
+    t.Run("EXPECT FAIL datastore error", func(t *testing.T){
+        wantErr = true
+        got, err := service.Authorize("test")
+        wantErr = false
+
+        assert.Nil(t, got)
+        assert.EqualValues(t, E.ErrDatabase, err.(*E.Error).Code)
+    })
+
+    t.Run("EXPECT FAIL generate token error", func(t *testing.T){
+        generateToken := generateTokenFunc
+        generateTokenFunc = func(length int) (string, error) {
+            return "", fmt.Errorf("no entropy")
+        }
+        defer func() { generateTokenFunc = generateToken }()
+
+        got, err := service.Authorize("test")
+
+        assert.Nil(t, got)
+        assert.EqualValues(t, E.ErrTokenCreate, err.(*E.ErrorExt).Code)
+    })
+}
+
+// TestOIDCServiceCallback will test Callback method of oidc service
+func TestOIDCServiceCallback(t *testing.T) {
+    t.Run("EXPECT SUCCESS linked identity", func(t *testing.T){
+        idp, store, service := newTestOIDCService(t, &mockOIDCUserStore{})
+        store.identities["test/248289761001"] = d.UserIdentity{Provider: "test", Subject: "248289761001", UserID: u[0].ID}
+
+        // the linked identity is used even when the email is not verified (anymore)
+        code, state := signin(t, idp, service, jwt.MapClaims{"sub": "248289761001", "email_verified": false})
+        got, err := service.Callback("test", code, state)
+
+        assert.NoError(t, err)
+        assert.Equal(t, u[0].ID, got.UserID)
+    })
+
+    t.Run("EXPECT SUCCESS link to user with the same email", func(t *testing.T){
+        cred := &d.UserCredential{ID: u[1].ID, StatusID: 1, RoleID: d.RoleStaff}
+        idp, store, service := newTestOIDCService(t, &mockOIDCUserStore{cred: cred})
+
+        code, state := signin(t, idp, service, jwt.MapClaims{"sub": "248289761001", "email": "Jenny@Gmail.com"})
+        got, err := service.Callback("test", code, state)
+
+        assert.NoError(t, err)
+        assert.Equal(t, u[1].ID, got.UserID)
+        assert.Equal(t, d.RoleStaff, got.RoleID)
+        assert.Equal(t, "jenny@gmail.com", store.identities["test/248289761001"].Email)
+        assert.Equal(t, u[1].ID, store.identities["test/248289761001"].UserID)
+    })
+
+    t.Run("EXPECT SUCCESS create new user", func(t *testing.T){
+        us := &mockOIDCUserStore{err: E.New(E.ErrDataIsEmpty)}
+        idp, store, service := newTestOIDCService(t, us)
+
+        code, state := signin(t, idp, service, jwt.MapClaims{
+            "sub": "248289761001", "email": "leo.singa@oidctest.com", "given_name": "Leo", "family_name": "Singa",
+        })
+        got, err := service.Callback("test", code, state)
+
+        assert.NoError(t, err)
+        assert.Equal(t, d.RoleGuest, got.RoleID)
+
+        user := store.users[got.UserID]
+        assert.Equal(t, "leo.singa", user.Username)
+        assert.Equal(t, "Leo", user.Firstname)
+        assert.Equal(t, d.NullString("Singa"), user.Lastname)
+        assert.Equal(t, oidcUnusablePassword, user.PassKey)
+        assert.Equal(t, 1, user.StatusID)
+    })
+
+    t.Run("EXPECT SUCCESS create new user with taken username", func(t *testing.T){
+        us := &mockOIDCUserStore{err: E.New(E.ErrDataIsEmpty), taken: true}
+        idp, store, service := newTestOIDCService(t, us)
+
+        code, state := signin(t, idp, service, jwt.MapClaims{"preferred_username": "Leonard!", "name": "Leo Singa"})
+        got, err := service.Callback("test", code, state)
+
+        assert.NoError(t, err)
+
+        user := store.users[got.UserID]
+        assert.True(t, strings.HasPrefix(user.Username, "leonard_"))
+        assert.Equal(t, "Leo Singa", user.Firstname)
+    })
+
+    t.Run("EXPECT FAIL state used twice", func(t *testing.T){
+        idp, store, service := newTestOIDCService(t, &mockOIDCUserStore{})
+        store.identities["test/oidctest-subject"] = d.UserIdentity{UserID: u[0].ID}
+
+        code, state := signin(t, idp, service, nil)
+        _, err := service.Callback("test", code, state)
+        assert.NoError(t, err)
+
+        got, err := service.Callback("test", code, state)
+        assert.Nil(t, got)
+        assert.EqualValues(t, E.ErrOIDCStateInvalid, err.(*E.Error).Code)
+    })
+
+    t.Run("EXPECT FAIL state of other provider", func(t *testing.T){
+        idp, store, service := newTestOIDCService(t, &mockOIDCUserStore{})
+        service.Providers["other"] = service.Providers["test"]
+
+        code, state := signin(t, idp, service, nil)
+        got, err := service.Callback("other", code, state)
+
+        assert.Nil(t, got)
+        assert.EqualValues(t, E.ErrOIDCStateInvalid, err.(*E.Error).Code)
+        assert.Len(t, store.states, 1)
+    })
+
+    t.Run("EXPECT FAIL missing code", func(t *testing.T){
+        _, _, service := newTestOIDCService(t, &mockOIDCUserStore{})
+
+        got, err := service.Callback("test", "", "state")
+
+        assert.Nil(t, got)
+        assert.EqualValues(t, E.ErrOIDCStateInvalid, err.(*E.Error).Code)
+    })
+
+    t.Run("EXPECT FAIL provider not found", func(t *testing.T){
+        _, _, service := newTestOIDCService(t, &mockOIDCUserStore{})
+
+        got, err := service.Callback("unknown", "code", "state")
+
+        assert.Nil(t, got)
+        assert.EqualValues(t, E.ErrOIDCProviderNotFound, err.(*E.Error).Code)
+    })
+
+    t.Run("EXPECT FAIL code not issued by the provider", func(t *testing.T){
+        idp, _, service := newTestOIDCService(t, &mockOIDCUserStore{})
+
+        _, state := signin(t, idp, service, nil)
+        got, err := service.Callback("test", "forged-code", state)
+
+        assert.Nil(t, got)
+        assert.EqualValues(t, E.ErrOIDCExchange, err.(*E.Error).Code)
+    })
+
+    t.Run("EXPECT FAIL email not verified", func(t *testing.T){
+        idp, store, service := newTestOIDCService(t, &mockOIDCUserStore{cred: &d.UserCredential{ID: u[0].ID}})
+
+        code, state := signin(t, idp, service, jwt.MapClaims{"email_verified": false})
+        got, err := service.Callback("test", code, state)
+
+        assert.Nil(t, got)
+        assert.EqualValues(t, E.ErrOIDCEmailNotVerified, err.(*E.Error).Code)
+        assert.Empty(t, store.identities)
+    })
+
+    t.Run("EXPECT FAIL user not active", func(t *testing.T){
+        cred := &d.UserCredential{ID: u[0].ID, StatusID: 0}
+        idp, _, service := newTestOIDCService(t, &mockOIDCUserStore{cred: cred})
+
+        code, state := signin(t, idp, service, nil)
+        got, err := service.Callback("test", code, state)
+
+        assert.Nil(t, got)
+        assert.EqualValues(t, E.ErrUserNotActive, err.(*E.Error).Code)
+    })
+
+    t.Run("EXPECT FAIL user datastore error", func(t *testing.T){
+        idp, _, service := newTestOIDCService(t, &mockOIDCUserStore{err: E.New(E.ErrDatabase)})
+
+        code, state := signin(t, idp, service, nil)
+        got, err := service.Callback("test", code, state)
+
+        assert.Nil(t, got)
+        assert.EqualValues(t, E.ErrDatabase, err.(*E.Error).Code)
+    })
+}
+
+// TestOIDCUsername will test username created from the provider claims
+func TestOIDCUsername(t *testing.T) {
+    assert.Equal(t, "leo.singa", oidcUsername("Leo.Singa"))
+    assert.Equal(t, "jos", oidcUsername("José"))
+    assert.Equal(t, "", oidcUsername("李小龍"))
+    assert.Len(t, oidcUsername(strings.Repeat("a", 40)), oidcUsernameLength)
+}
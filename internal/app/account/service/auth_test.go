@@ -0,0 +1,552 @@
+/*
+    package service
+    auth_test.go
+    - test unit for auth service
+*/
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+	"github.com/reshimahendra/lbw-go/internal/config"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	"github.com/reshimahendra/lbw-go/internal/pkg/auth"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// sc is session client mock data
+var sc = d.NewSessionClient("Mozilla/5.0 (X11; Linux x86_64) Firefox/102.0", "203.0.113.7")
+
+// mockAuthService is mocked auth datastore
+type mockAuthService struct {
+    t *testing.T
+    revoked []string
+
+    // refreshTokens is recorded refresh token id and whether it is already used
+    refreshTokens map[string]bool
+
+    // revokedFamilies is revoked refresh token family id
+    revokedFamilies []string
+
+    // revokedUsers is user id whose all token were revoked
+    revokedUsers []uuid.UUID
+
+    // revokedBefore is the cutoff of the last user tokens revocation
+    revokedBefore time.Time
+
+    // sessions is the client recorded on the session of the refresh token family
+    sessions map[string]d.SessionClient
+
+    // rotateErr is error returned by RotateRefreshToken
+    rotateErr error
+}
+
+// NewMockAuthService is new instance of mockAuthService
+func NewMockAuthService(t *testing.T) *mockAuthService{
+    return &mockAuthService{t: t, refreshTokens: map[string]bool{}, sessions: map[string]d.SessionClient{}}
+}
+
+// RevokeToken is mocked RevokeToken method to satisfy IAuthStore interface
+func (m *mockAuthService) RevokeToken(token d.TokenMetadata) error {
+    if wantErr {
+        return E.New(E.ErrDatabase)
+    }
+    m.revoked = append(m.revoked, token.ID)
+
+    return nil
+}
+
+// IsTokenRevoked is mocked IsTokenRevoked method to satisfy IAuthStore interface
+func (m *mockAuthService) IsTokenRevoked(token d.TokenMetadata) (bool, error) {
+    if wantErr {
+        return false, E.New(E.ErrDatabase)
+    }
+    for _, id := range m.revoked {
+        if id == token.ID {
+            return true, nil
+        }
+    }
+
+    // token issued on or before the cutoff of its user tokens revocation
+    for _, id := range m.revokedUsers {
+        if id == token.UserID && !m.revokedBefore.Before(token.IssuedAt) {
+            return true, nil
+        }
+    }
+
+    return false, nil
+}
+
+// CreateRefreshToken is mocked CreateRefreshToken method to satisfy IAuthStore interface
+func (m *mockAuthService) CreateRefreshToken(token d.TokenMetadata, client d.SessionClient) error {
+    if wantErr {
+        return E.New(E.ErrDatabase)
+    }
+    m.refreshTokens[token.ID] = false
+    m.sessions[token.FamilyID] = client
+
+    return nil
+}
+
+// RotateRefreshToken is mocked RotateRefreshToken method to satisfy IAuthStore interface
+func (m *mockAuthService) RotateRefreshToken(token d.TokenMetadata) (bool, error) {
+    if m.rotateErr != nil {
+        return false, m.rotateErr
+    }
+
+    used, ok := m.refreshTokens[token.ID]
+    if !ok {
+        return false, E.New(E.ErrDataIsEmpty)
+    }
+    if used {
+        return false, nil
+    }
+    m.refreshTokens[token.ID] = true
+
+    return true, nil
+}
+
+// RevokeTokenFamily is mocked RevokeTokenFamily method to satisfy IAuthStore interface
+func (m *mockAuthService) RevokeTokenFamily(familyID string) error {
+    m.revokedFamilies = append(m.revokedFamilies, familyID)
+
+    return nil
+}
+
+// RevokeUserTokens is mocked RevokeUserTokens method to satisfy IAuthStore interface
+func (m *mockAuthService) RevokeUserTokens(userID uuid.UUID, revokedBefore time.Time) error {
+    if wantErr {
+        return E.New(E.ErrDatabase)
+    }
+    m.revokedUsers = append(m.revokedUsers, userID)
+    m.revokedBefore = revokedBefore
+
+    return nil
+}
+
+// mockStatusUserStore is mocked user datastore returning the given user on Get
+type mockStatusUserStore struct {
+    *mockUserService
+    user *d.User
+    err error
+}
+
+// Get is mocked Get method to satisfy IUserStore interface
+func (m *mockStatusUserStore) Get(id uuid.UUID) (*d.User, error) {
+    return m.user, m.err
+}
+
+// TestAuthServiceSignout will test Signout method behaviour of auth service
+func TestAuthServiceSignout(t *testing.T) {
+    // prepare config for token creation
+    if err := config.Setup(); err != nil {
+        t.Fatalf("unexpected error occur: %v", err)
+    }
+
+    // prepare mock and service
+    mock := NewMockAuthService(t)
+    service := NewAuthService(mock, NewMockUserService(t))
+
+    // EXPECT SUCCESS both access and refresh token revoked
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        token, err := auth.CreateToken(d.Principal{UserID: u[0].ID, Email: u[0].Email})
+        assert.NoError(t, err)
+
+        // actual method call
+        err = service.Signout(token.AccessToken, token.RefreshToken)
+
+        // test verification and validation
+        assert.NoError(t, err)
+        assert.Contains(t, mock.revoked, token.AccessTokenID)
+        assert.Contains(t, mock.revoked, token.RefreshTokenID)
+
+        revoked, err := service.IsTokenRevoked(d.TokenMetadata{ID: token.AccessTokenID})
+        assert.NoError(t, err)
+        assert.Equal(t, true, revoked)
+    })
+
+    // EXPECT SUCCESS without refresh token revoke the access token and its family, so the
+    // refresh token of the session can not be used anymore
+    t.Run("EXPECT SUCCESS without refresh token", func(t *testing.T){
+        token, err := auth.CreateToken(d.Principal{UserID: u[0].ID, Email: u[0].Email})
+        assert.NoError(t, err)
+
+        // actual method call
+        err = service.Signout(token.AccessToken, "")
+
+        // test verification and validation
+        assert.NoError(t, err)
+        assert.Contains(t, mock.revoked, token.AccessTokenID)
+        assert.NotContains(t, mock.revoked, token.RefreshTokenID)
+        assert.Contains(t, mock.revokedFamilies, token.FamilyID)
+    })
+
+    // EXPECT FAIL invalid token. Simulated by giving invalid token value
+    t.Run("EXPECT FAIL invalid token", func(t *testing.T){
+        // actual method call
+        err := service.Signout("invalid-token", "")
+
+        // test verification and validation
+        assert.Error(t, err)
+    })
+
+    // EXPECT FAIL token swapped. refresh token is rejected as access token and vice versa
+    t.Run("EXPECT FAIL token swapped", func(t *testing.T){
+        token, err := auth.CreateToken(d.Principal{UserID: u[0].ID, Email: u[0].Email})
+        assert.NoError(t, err)
+
+        // actual method call
+        err = service.Signout(token.RefreshToken, token.AccessToken)
+
+        // test verification and validation
+        assert.Equal(t, E.New(E.ErrTokenInvalid), err)
+        assert.NotContains(t, mock.revoked, token.RefreshTokenID)
+    })
+
+    // EXPECT FAIL token without id. Simulated by mocking auth.TokenValid
+    t.Run("EXPECT FAIL token without id", func(t *testing.T){
+        tokenValid := tokenValidFunc
+        tokenValidFunc = func(bearerToken string) (*jwt.Token, error) {
+            return jwt.New(jwt.SigningMethodHS256), nil
+        }
+        defer func() { tokenValidFunc = tokenValid }()
+
+        // actual method call
+        err := service.Signout("token", "")
+
+        // test verification and validation
+        assert.Error(t, err)
+        assert.Equal(t, E.New(E.ErrTokenInvalid), err)
+    })
+
+    // EXPECT FAIL revoke error. Simulated by forcing to return error
+    // by setting wantErr=true
+    t.Run("EXPECT FAIL revoke error", func(t *testing.T){
+        token, err := auth.CreateToken(d.Principal{UserID: u[0].ID, Email: u[0].Email})
+        assert.NoError(t, err)
+
+        // actual method call
+        wantErr = true
+        err = service.Signout(token.AccessToken, token.RefreshToken)
+        wantErr = false
+
+        // test verification and validation
+        assert.Error(t, err)
+        assert.Equal(t, uint(E.ErrSignOut), err.(*E.ErrorExt).Code)
+    })
+}
+
+// TestAuthServiceRevokeUserTokens will test RevokeUserTokens method of auth service
+func TestAuthServiceRevokeUserTokens(t *testing.T) {
+    // prepare mock and service
+    mock := NewMockAuthService(t)
+    service := NewAuthService(mock, NewMockUserService(t))
+    userID := uuid.New()
+
+    // cutoff is in UTC and truncated to second like the token 'iat' claim
+    now := time.Date(2022, 2, 3, 11, 30, 15, 700000000, time.FixedZone("WITA", 8*3600))
+    timeNow := timeNowFunc
+    timeNowFunc = func() time.Time { return now }
+    defer func() { timeNowFunc = timeNow }()
+
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        err := service.RevokeUserTokens(userID)
+
+        assert.NoError(t, err)
+        assert.Equal(t, []uuid.UUID{userID}, mock.revokedUsers)
+        assert.Equal(t, time.Date(2022, 2, 3, 3, 30, 15, 0, time.UTC), mock.revokedBefore)
+    })
+
+    // EXPECT SUCCESS token issued on the revocation second is revoked, token issued on
+    // the next second is not
+    t.Run("EXPECT SUCCESS token issued on the same second", func(t *testing.T){
+        issuedAt := time.Date(2022, 2, 3, 3, 30, 15, 0, time.UTC)
+        sameSecond := d.TokenMetadata{ID: uuid.NewString(), UserID: userID, IssuedAt: issuedAt}
+        nextSecond := d.TokenMetadata{ID: uuid.NewString(), UserID: userID, IssuedAt: issuedAt.Add(time.Second)}
+
+        revoked, err := service.IsTokenRevoked(sameSecond)
+        assert.NoError(t, err)
+        assert.True(t, revoked)
+
+        revoked, err = service.IsTokenRevoked(nextSecond)
+        assert.NoError(t, err)
+        assert.False(t, revoked)
+    })
+    // EXPECT FAIL datastore error. Simulated by triggering 'wantErr'
+    t.Run("EXPECT FAIL datastore error", func(t *testing.T){
+        wantErr = true
+        err := service.RevokeUserTokens(userID)
+        wantErr = false
+
+        assert.Error(t, err)
+        assert.Equal(t, E.New(E.ErrDatabase), err)
+    })}
+
+// TestAuthServiceIssueToken will test IssueToken method behaviour of auth service
+func TestAuthServiceIssueToken(t *testing.T) {
+    // prepare config for token creation
+    if err := config.Setup(); err != nil {
+        t.Fatalf("unexpected error occur: %v", err)
+    }
+
+    // prepare mock and service
+    mock := NewMockAuthService(t)
+    service := NewAuthService(mock, NewMockUserService(t))
+
+    // EXPECT SUCCESS refresh token is recorded on new family
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        // actual method call
+        got, err := service.IssueToken(d.Principal{UserID: u[0].ID, Email: u[0].Email}, sc)
+
+        // test verification and validation
+        assert.NoError(t, err)
+        assert.NotEmpty(t, got.FamilyID)
+        assert.Contains(t, mock.refreshTokens, got.RefreshTokenID)
+        assert.Equal(t, sc, mock.sessions[got.FamilyID])
+    })
+
+    // EXPECT FAIL create token error. Simulated by giving invalid email
+    t.Run("EXPECT FAIL create token error", func(t *testing.T){
+        // actual method call
+        got, err := service.IssueToken(d.Principal{UserID: u[0].ID, Email: "invalid"}, sc)
+
+        // test verification and validation
+        assert.Error(t, err)
+        assert.Equal(t, E.New(E.ErrTokenCreate), err)
+        assert.Nil(t, got)
+    })
+
+    // EXPECT FAIL record token error. Simulated by forcing to return error
+    // by setting wantErr=true
+    t.Run("EXPECT FAIL record token error", func(t *testing.T){
+        // actual method call
+        wantErr = true
+        got, err := service.IssueToken(d.Principal{UserID: u[0].ID, Email: u[0].Email}, sc)
+        wantErr = false
+
+        // test verification and validation
+        assert.Error(t, err)
+        assert.Equal(t, uint(E.ErrTokenCreate), err.(*E.ErrorExt).Code)
+        assert.Nil(t, got)
+    })
+}
+
+// TestAuthServiceRefresh will test Refresh method behaviour of auth service
+func TestAuthServiceRefresh(t *testing.T) {
+    // prepare config for token creation
+    if err := config.Setup(); err != nil {
+        t.Fatalf("unexpected error occur: %v", err)
+    }
+
+    // inactive is inactive (suspended) user
+    inactive := *u[0]
+    inactive.StatusID = 2
+
+    cases := []struct{
+        name string
+        user *d.User
+        userErr, rotateErr error
+        wantErr error
+        wantExtCode uint
+        wantRevoked bool
+    }{
+        {"EXPECT SUCCESS", u[0], nil, nil, nil, 0, false},
+        {"EXPECT FAIL user not active", &inactive, nil, nil, E.New(E.ErrUserNotActive), 0, true},
+        {"EXPECT FAIL user not found", nil, E.New(E.ErrDataIsEmpty), nil, E.New(E.ErrUserNotActive), 0, true},
+        {"EXPECT FAIL get user error", nil, E.New(E.ErrDatabase), nil, nil, E.ErrTokenRefresh, false},
+        {"EXPECT FAIL rotate token error", u[0], nil, E.New(E.ErrDatabase), nil, E.ErrTokenRefresh, false},
+    }
+
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            // prepare mock and service
+            mock := NewMockAuthService(t)
+            mock.rotateErr = tt.rotateErr
+            userStore := &mockStatusUserStore{NewMockUserService(t), tt.user, tt.userErr}
+            service := NewAuthService(mock, userStore)
+
+            token, err := service.IssueToken(d.Principal{UserID: u[0].ID, Email: u[0].Email}, sc)
+            assert.NoError(t, err)
+
+            // actual method call, the session is refreshed from other network
+            client := d.NewSessionClient(sc.UserAgent, "198.51.100.20")
+            got, err := service.Refresh(token.RefreshToken, client)
+
+            // test verification and validation
+            switch {
+            case tt.wantErr != nil:
+                assert.Equal(t, tt.wantErr, err)
+                assert.Nil(t, got)
+            case tt.wantExtCode != 0:
+                assert.Error(t, err)
+                assert.Equal(t, tt.wantExtCode, err.(*E.ErrorExt).Code)
+                assert.Nil(t, got)
+            default:
+                assert.NoError(t, err)
+                assert.Equal(t, token.FamilyID, got.FamilyID)
+                assert.NotEqual(t, token.RefreshTokenID, got.RefreshTokenID)
+                assert.Equal(t, client, mock.sessions[got.FamilyID])
+                assert.Contains(t, mock.refreshTokens, got.RefreshTokenID)
+            }
+
+            if tt.wantRevoked {
+                assert.Contains(t, mock.revokedFamilies, token.FamilyID)
+            } else {
+                assert.Empty(t, mock.revokedFamilies)
+            }
+        })
+    }
+
+    // EXPECT FAIL token reused. Simulated by refreshing the same token twice
+    t.Run("EXPECT FAIL token reused", func(t *testing.T){
+        mock := NewMockAuthService(t)
+        service := NewAuthService(mock, NewMockUserService(t))
+
+        token, err := service.IssueToken(d.Principal{UserID: u[0].ID, Email: u[0].Email}, sc)
+        assert.NoError(t, err)
+
+        _, err = service.Refresh(token.RefreshToken, sc)
+        assert.NoError(t, err)
+
+        // actual method call
+        got, err := service.Refresh(token.RefreshToken, sc)
+
+        // test verification and validation
+        assert.Equal(t, E.New(E.ErrRefreshTokenReused), err)
+        assert.Nil(t, got)
+        assert.Contains(t, mock.revokedFamilies, token.FamilyID)
+    })
+
+    // EXPECT FAIL access token. access token is rejected before it reach the datastore
+    t.Run("EXPECT FAIL access token", func(t *testing.T){
+        mock := NewMockAuthService(t)
+        service := NewAuthService(mock, NewMockUserService(t))
+
+        token, err := service.IssueToken(d.Principal{UserID: u[0].ID, Email: u[0].Email}, sc)
+        assert.NoError(t, err)
+
+        // actual method call
+        got, err := service.Refresh(token.AccessToken, sc)
+
+        // test verification and validation
+        assert.Equal(t, E.New(E.ErrTokenInvalid), err)
+        assert.Nil(t, got)
+        assert.Empty(t, mock.revokedFamilies)
+    })
+
+    // EXPECT FAIL invalid token. Simulated by giving invalid token value
+    t.Run("EXPECT FAIL invalid token", func(t *testing.T){
+        service := NewAuthService(NewMockAuthService(t), NewMockUserService(t))
+
+        // actual method call
+        got, err := service.Refresh("invalid-token", sc)
+
+        // test verification and validation
+        assert.Error(t, err)
+        assert.Nil(t, got)
+    })
+
+    // EXPECT FAIL token without family. Simulated by mocking auth.RefreshTokenValid
+    t.Run("EXPECT FAIL token without family", func(t *testing.T){
+        tokenValid := refreshTokenValidFunc
+        refreshTokenValidFunc = func(bearerToken string) (*jwt.Token, error) {
+            return jwt.NewWithClaims(jwt.SigningMethodHS256, &auth.Claims{
+                StandardClaims: jwt.StandardClaims{Id: uuid.NewString(), Subject: u[0].ID.String()},
+            }), nil
+        }
+        defer func() { refreshTokenValidFunc = tokenValid }()
+
+        service := NewAuthService(NewMockAuthService(t), NewMockUserService(t))
+
+        // actual method call
+        got, err := service.Refresh("token", sc)
+
+        // test verification and validation
+        assert.Equal(t, E.New(E.ErrTokenInvalid), err)
+        assert.Nil(t, got)
+    })
+}
+
+// TestAuthServiceJWKS will test JWKS method behaviour of auth service
+func TestAuthServiceJWKS(t *testing.T) {
+    service := NewAuthService(NewMockAuthService(t), NewMockUserService(t))
+
+    // EXPECT SUCCESS public key set is returned
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        publicJWKS := publicJWKSFunc
+        publicJWKSFunc = func() (*d.JWKS, error) {
+            return &d.JWKS{Keys: []d.JWK{{Kty: "RSA", Kid: "key-1"}}}, nil
+        }
+        defer func() { publicJWKSFunc = publicJWKS }()
+
+        // actual method call
+        got, err := service.JWKS()
+
+        // test verification and validation
+        assert.NoError(t, err)
+        assert.Equal(t, "key-1", got.Keys[0].Kid)
+    })
+
+    // EXPECT FAIL signing key error. Simulated by mocking auth.PublicJWKS
+    t.Run("EXPECT FAIL signing key error", func(t *testing.T){
+        publicJWKS := publicJWKSFunc
+        publicJWKSFunc = func() (*d.JWKS, error) {
+            return nil, E.New(E.ErrSigningKey)
+        }
+        defer func() { publicJWKSFunc = publicJWKS }()
+
+        // actual method call
+        got, err := service.JWKS()
+
+        // test verification and validation
+        assert.Equal(t, E.New(E.ErrSigningKey), err)
+        assert.Nil(t, got)
+    })
+}
+
+// TestAuthServiceSigningKeys will test signing key ring methods behaviour of auth service
+func TestAuthServiceSigningKeys(t *testing.T) {
+    service := NewAuthService(NewMockAuthService(t), NewMockUserService(t))
+
+    // mock the key ring functions of auth package
+    signingKeys, promote, retire := signingKeysFunc, promoteSigningKeyFunc, retireSigningKeyFunc
+    defer func() {
+        signingKeysFunc, promoteSigningKeyFunc, retireSigningKeyFunc = signingKeys, promote, retire
+    }()
+
+    var ringErr error
+    signingKeysFunc = func() ([]*d.SigningKeyResponse, error) {
+        if ringErr != nil {
+            return nil, ringErr
+        }
+        return []*d.SigningKeyResponse{{ID: "key-1", Active: true}}, nil
+    }
+    promoteSigningKeyFunc = func(kid string) error { return ringErr }
+    retireSigningKeyFunc = func(kid string) error { return ringErr }
+
+    // EXPECT SUCCESS key ring request is passed to auth package
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        ringErr = nil
+
+        got, err := service.SigningKeys()
+        assert.NoError(t, err)
+        assert.Equal(t, "key-1", got[0].ID)
+        assert.NoError(t, service.PromoteSigningKey("key-1"))
+        assert.NoError(t, service.RetireSigningKey("key-1"))
+    })
+
+    // EXPECT FAIL key ring error is returned as is
+    t.Run("EXPECT FAIL key ring error", func(t *testing.T){
+        ringErr = E.New(E.ErrSigningKeyInUse)
+
+        got, err := service.SigningKeys()
+        assert.Equal(t, ringErr, err)
+        assert.Nil(t, got)
+        assert.Equal(t, ringErr, service.PromoteSigningKey("key-1"))
+        assert.Equal(t, ringErr, service.RetireSigningKey("key-1"))
+    })
+}
@@ -0,0 +1,131 @@
+/*
+   service package
+   permission.go
+   - service/ business layer for permission catalogue and permission granted to user.role
+*/
+package service
+
+import (
+	ds "github.com/reshimahendra/lbw-go/internal/app/account/datastore"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/logger"
+)
+
+// IPermissionService is service layer for permission so the handler layer can
+// communicate with the datastore layer
+type IPermissionService interface {
+    // Gets will make request to datastore to get the permission catalogue
+    Gets() ([]*d.Permission, error)
+
+    // GetsByRole will make request to datastore to get permission granted to user.role
+    GetsByRole(roleID int) ([]*d.Permission, error)
+
+    // Grant will grant the permission to user.role on behalf of the principal
+    Grant(principal d.Principal, roleID int, code string) error
+
+    // Revoke will revoke the permission from user.role on behalf of the principal
+    Revoke(principal d.Principal, roleID int, code string) error
+
+    // RolePermissions will get code of the effective permission of user.role, it
+    // satisfy the permission provider of the RequirePermission middleware
+    RolePermissions(roleID int) ([]string, error)
+}
+
+// PermissionService is instance wrapper for IPermissionStore interface
+type PermissionService struct {
+    Store ds.IPermissionStore
+}
+
+// NewPermissionService is new instance of PermissionService
+func NewPermissionService(store ds.IPermissionStore) *PermissionService {
+    return &PermissionService{Store: store}
+}
+
+// Gets will send request to datastore to get the permission catalogue
+func (s *PermissionService) Gets() ([]*d.Permission, error) {
+    return s.Store.Gets()
+}
+
+// GetsByRole will send request to datastore to get permission granted to user.role
+func (s *PermissionService) GetsByRole(roleID int) ([]*d.Permission, error) {
+    return s.Store.GetsByRole(roleID)
+}
+
+// RolePermissions will get code of the effective permission of user.role
+func (s *PermissionService) RolePermissions(roleID int) ([]string, error) {
+    perms, err := s.Store.GetsByRole(roleID)
+    if err != nil {
+        return nil, err
+    }
+
+    codes := make([]string, len(perms))
+    for i, perm := range perms {
+        codes[i] = perm.Code
+    }
+
+    return codes, nil
+}
+
+// Grant will grant the permission to user.role. the principal can only grant permission
+// it holds itself to user.role other than its own
+func (s *PermissionService) Grant(principal d.Principal, roleID int, code string) error {
+    if err := s.checkManage(principal, roleID, code); err != nil {
+        return err
+    }
+
+    return s.Store.Grant(roleID, code)
+}
+
+// Revoke will revoke the permission from user.role. the principal can only revoke permission
+// it holds itself from user.role other than its own
+func (s *PermissionService) Revoke(principal d.Principal, roleID int, code string) error {
+    if err := s.checkManage(principal, roleID, code); err != nil {
+        return err
+    }
+
+    return s.Store.Revoke(roleID, code)
+}
+
+// checkManage will check whether the principal may manage the permission of user.role. the
+// principal can not change its own role, the permission it does not hold, nor role holding
+// permission it does not hold (to prevent privilege escalation)
+func (s *PermissionService) checkManage(principal d.Principal, roleID int, code string) error {
+    if !d.IsValidPermissionCode(code) {
+        return E.New(E.ErrDataIsInvalid)
+    }
+
+    if roleID == principal.RoleID {
+        logger.Errorf("user %s can not change permission of its own role %d", principal.UserID, roleID)
+        return E.New(E.ErrForbidden)
+    }
+
+    held, err := s.RolePermissions(principal.RoleID)
+    if err != nil {
+        return err
+    }
+    target, err := s.RolePermissions(roleID)
+    if err != nil {
+        return err
+    }
+
+    for _, perm := range append(target, code) {
+        if !containsString(held, perm) {
+            logger.Errorf("user %s without %s permission can not change permission of role %d", principal.UserID, perm, roleID)
+            return E.New(E.ErrForbidden)
+        }
+    }
+
+    return nil
+}
+
+// containsString will check whether the value is on the list
+func containsString(list []string, value string) bool {
+    for _, v := range list {
+        if v == value {
+            return true
+        }
+    }
+
+    return false
+}
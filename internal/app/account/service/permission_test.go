@@ -0,0 +1,155 @@
+/*
+    package service
+    permission_test.go
+    - test unit for permission service
+*/
+package service
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/google/uuid"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockPermissionService is mocked permission datastore holding the grant of each role
+type mockPermissionService struct {
+    grants map[int][]string
+}
+
+// NewMockPermissionService is new instance of mockPermissionService with the default grant
+func NewMockPermissionService() *mockPermissionService {
+    grants := map[int][]string{d.RoleStaff: {d.PermUserRead}}
+    for roleID, perms := range d.RolePermissions {
+        grants[roleID] = append([]string{}, perms...)
+    }
+
+    return &mockPermissionService{grants: grants}
+}
+
+// Gets is mocked Gets method to satisfy IPermissionStore interface
+func (m *mockPermissionService) Gets() ([]*d.Permission, error) {
+    if wantErr {
+        return nil, E.New(E.ErrDatabase)
+    }
+
+    return m.GetsByRole(d.RoleSuperuser)
+}
+
+// GetsByRole is mocked GetsByRole method to satisfy IPermissionStore interface
+func (m *mockPermissionService) GetsByRole(roleID int) ([]*d.Permission, error) {
+    if wantErr {
+        return nil, E.New(E.ErrDatabase)
+    }
+
+    perms := []*d.Permission{}
+    for _, code := range m.grants[roleID] {
+        perms = append(perms, &d.Permission{Code: code})
+    }
+
+    return perms, nil
+}
+
+// Grant is mocked Grant method to satisfy IPermissionStore interface
+func (m *mockPermissionService) Grant(roleID int, code string) error {
+    if !containsString(m.grants[roleID], code) {
+        m.grants[roleID] = append(m.grants[roleID], code)
+        sort.Strings(m.grants[roleID])
+    }
+
+    return nil
+}
+
+// Revoke is mocked Revoke method to satisfy IPermissionStore interface
+func (m *mockPermissionService) Revoke(roleID int, code string) error {
+    for i, granted := range m.grants[roleID] {
+        if granted == code {
+            m.grants[roleID] = append(m.grants[roleID][:i], m.grants[roleID][i+1:]...)
+            return nil
+        }
+    }
+
+    return E.New(E.ErrDataIsEmpty)
+}
+
+// TestPermissionServiceRolePermissions will test getting effective permission code of the role
+func TestPermissionServiceRolePermissions(t *testing.T) {
+    service := NewPermissionService(NewMockPermissionService())
+
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        got, err := service.RolePermissions(d.RoleAdministrator)
+
+        assert.NoError(t, err)
+        assert.Equal(t, d.RolePermissions[d.RoleAdministrator], got)
+    })
+
+    t.Run("EXPECT SUCCESS role without permission", func(t *testing.T){
+        got, err := service.RolePermissions(d.RoleGuest)
+
+        assert.NoError(t, err)
+        assert.Empty(t, got)
+    })
+
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        wantErr = true
+        got, err := service.RolePermissions(d.RoleGuest)
+        wantErr = false
+
+        assert.Error(t, err)
+        assert.Nil(t, got)
+    })
+}
+
+// TestPermissionServiceGrant will test granting and revoking permission on behalf of the principal
+func TestPermissionServiceGrant(t *testing.T) {
+    admin := d.Principal{UserID: uuid.New(), RoleID: d.RoleAdministrator}
+    superuser := d.Principal{UserID: uuid.New(), RoleID: d.RoleSuperuser}
+
+    t.Run("EXPECT SUCCESS grant and revoke", func(t *testing.T){
+        store := NewMockPermissionService()
+        service := NewPermissionService(store)
+
+        assert.NoError(t, service.Grant(admin, d.RoleStaff, d.PermRoleRead))
+        assert.Equal(t, []string{d.PermRoleRead, d.PermUserRead}, store.grants[d.RoleStaff])
+
+        assert.NoError(t, service.Revoke(admin, d.RoleStaff, d.PermUserRead))
+        assert.Equal(t, []string{d.PermRoleRead}, store.grants[d.RoleStaff])
+    })
+
+    cases := []struct{
+        name      string
+        principal d.Principal
+        roleID    int
+        code      string
+        want      int
+    }{
+        {"EXPECT FAIL invalid code", admin, d.RoleStaff, "USER READ", E.ErrDataIsInvalid},
+        {"EXPECT FAIL own role", admin, d.RoleAdministrator, d.PermUserRead, E.ErrForbidden},
+        {"EXPECT FAIL permission not held", admin, d.RoleStaff, d.PermKeyManage, E.ErrForbidden},
+        {"EXPECT FAIL role with higher permission", admin, d.RoleSuperuser, d.PermUserRead, E.ErrForbidden},
+        {"EXPECT FAIL permission not on catalogue", superuser, d.RoleStaff, "blog:write", E.ErrForbidden},
+    }
+
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            service := NewPermissionService(NewMockPermissionService())
+
+            err := service.Grant(tt.principal, tt.roleID, tt.code)
+            assert.EqualValues(t, tt.want, err.(*E.Error).Code)
+
+            err = service.Revoke(tt.principal, tt.roleID, tt.code)
+            assert.EqualValues(t, tt.want, err.(*E.Error).Code)
+        })
+    }
+
+    t.Run("EXPECT FAIL permission is not granted", func(t *testing.T){
+        service := NewPermissionService(NewMockPermissionService())
+
+        err := service.Revoke(superuser, d.RoleStaff, d.PermRoleWrite)
+
+        assert.EqualValues(t, E.ErrDataIsEmpty, err.(*E.Error).Code)
+    })
+}
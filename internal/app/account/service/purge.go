@@ -0,0 +1,77 @@
+/*
+   service package
+   purge.go
+   - service/ business layer to permanently remove (soft) deleted record after the retention days
+*/
+package service
+
+import (
+	"time"
+
+	"github.com/reshimahendra/lbw-go/internal/pkg/logger"
+)
+
+// PurgeInterval is interval of the scheduled purge
+const PurgeInterval = 24 * time.Hour
+
+// IPurgeStore is datastore holding (soft) deleted record that can be purged
+type IPurgeStore interface {
+    // PurgeDeleted will permanently remove record deleted longer than the retention days
+    PurgeDeleted(retentionDays int) (int64, error)
+}
+
+// PurgeService is instance wrapper for the purged datastore
+type PurgeService struct {
+    // RetentionDays is number of days deleted record is kept before it is purged
+    RetentionDays int
+
+    // Stores is datastore to purge, it is purged in order so the record referencing
+    // other record should come first (user before user.role)
+    Stores []IPurgeStore
+}
+
+// NewPurgeService is new instance of PurgeService
+func NewPurgeService(retentionDays int, stores ...IPurgeStore) *PurgeService {
+    return &PurgeService{RetentionDays: retentionDays, Stores: stores}
+}
+
+// Purge will permanently remove record deleted longer than the retention days from
+// all the datastore and return number of the removed record
+func (s *PurgeService) Purge() (int64, error) {
+    var total int64
+    for _, store := range s.Stores {
+        n, err := store.PurgeDeleted(s.RetentionDays)
+        if err != nil {
+            return total, err
+        }
+        total += n
+    }
+
+    return total, nil
+}
+
+// Start will run the purge right away and then on every interval in background.
+// it return func to stop the scheduled purge
+func (s *PurgeService) Start(interval time.Duration) (stop func()) {
+    done := make(chan struct{})
+    ticker := time.NewTicker(interval)
+
+    go func() {
+        defer ticker.Stop()
+        for {
+            if n, err := s.Purge(); err != nil {
+                logger.Errorf("purge deleted record fail: %v", err)
+            } else if n > 0 {
+                logger.Infof("purged %d deleted record", n)
+            }
+
+            select {
+            case <-ticker.C:
+            case <-done:
+                return
+            }
+        }
+    }()
+
+    return func() { close(done) }
+}
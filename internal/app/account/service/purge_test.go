@@ -0,0 +1,83 @@
+/*
+   service package (test)
+   purge_test.go
+   - test unit for scheduled purge of (soft) deleted record
+*/
+package service
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockPurgeStore is mocked IPurgeStore recording the purge call
+type mockPurgeStore struct {
+    mu     sync.Mutex
+    name   string
+    order  *[]string
+    purged int64
+    err    error
+    days   int
+}
+
+// PurgeDeleted is mocked PurgeDeleted method to satisfy IPurgeStore interface
+func (m *mockPurgeStore) PurgeDeleted(retentionDays int) (int64, error) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    m.days = retentionDays
+    *m.order = append(*m.order, m.name)
+    return m.purged, m.err
+}
+
+// TestPurgeServicePurge will test Purge method of purge service
+func TestPurgeServicePurge(t *testing.T) {
+    // EXPECT SUCCESS all store is purged in order with the retention days
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        var order []string
+        users := &mockPurgeStore{name: "users", order: &order, purged: 3}
+        roles := &mockPurgeStore{name: "roles", order: &order, purged: 1}
+        service := NewPurgeService(30, users, roles)
+
+        got, err := service.Purge()
+
+        assert.NoError(t, err)
+        assert.Equal(t, int64(4), got)
+        assert.Equal(t, []string{"users", "roles"}, order)
+        assert.Equal(t, 30, users.days)
+        assert.Equal(t, 30, roles.days)
+    })
+
+    // EXPECT FAIL purge stop on the first failed store
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        var order []string
+        users := &mockPurgeStore{name: "users", order: &order, err: E.New(E.ErrDatabase)}
+        roles := &mockPurgeStore{name: "roles", order: &order, purged: 1}
+        service := NewPurgeService(30, users, roles)
+
+        got, err := service.Purge()
+
+        assert.Error(t, err)
+        assert.Equal(t, int64(0), got)
+        assert.Equal(t, []string{"users"}, order)
+    })
+}
+
+// TestPurgeServiceStart will test the scheduled purge run right away and on every interval
+func TestPurgeServiceStart(t *testing.T) {
+    var order []string
+    store := &mockPurgeStore{name: "users", order: &order, purged: 1}
+    service := NewPurgeService(30, store)
+
+    stop := service.Start(10 * time.Millisecond)
+    assert.Eventually(t, func() bool {
+        store.mu.Lock()
+        defer store.mu.Unlock()
+        return len(order) >= 2
+    }, time.Second, 5*time.Millisecond)
+    stop()
+}
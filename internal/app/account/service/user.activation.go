@@ -0,0 +1,169 @@
+/*
+   service package
+   user.activation.go
+   - service/ business layer for user account activation
+*/
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	ds "github.com/reshimahendra/lbw-go/internal/app/account/datastore"
+	"github.com/reshimahendra/lbw-go/internal/config"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/helper"
+	"github.com/reshimahendra/lbw-go/internal/pkg/logger"
+	"github.com/reshimahendra/lbw-go/internal/pkg/mailer"
+)
+
+const (
+    // activationTokenLength is byte length of the generated activation token
+    activationTokenLength = 32
+
+    // defaultActivationExpireDuration is fallback valid duration (in hour) of activation token
+    defaultActivationExpireDuration = 24
+
+    // activationMailSubject is subject of the activation mail
+    activationMailSubject = "Activate your account"
+
+    // activationMailBody is body template of the activation mail
+    activationMailBody = "Hi,\n\nPlease activate your account by opening the link below:\n%s\n\nThe link will expire in %d hour(s).\n"
+)
+
+var (
+    // generateTokenFunc is func instance of helper.GenerateToken
+    // it will be used to mock the inner func on test
+    generateTokenFunc = helper.GenerateToken
+)
+
+// IUserActivationService is service layer for user account activation
+type IUserActivationService interface {
+    // Issue will create new activation token for the user and send it to the user email
+    Issue(userID uuid.UUID, email string) error
+
+    // Activate will use the activation token to activate its user account
+    Activate(token string) (*d.UserResponse, error)
+
+    // Resend will issue new activation token for inactive user with given email
+    Resend(email string) error
+}
+
+// UserActivationService is instance wrapper for IUserActivationStore interface
+type UserActivationService struct {
+    // Store is user.activation datastore
+    Store     ds.IUserActivationStore
+
+    // UserStore is user datastore, used to look up user by email
+    UserStore ds.IUserStore
+
+    // Mailer is the mail sender for the activation mail
+    Mailer    mailer.IMailer
+}
+
+// NewUserActivationService is new instance of UserActivationService
+func NewUserActivationService(st ds.IUserActivationStore, us ds.IUserStore, m mailer.IMailer) *UserActivationService {
+    return &UserActivationService{Store: st, UserStore: us, Mailer: m}
+}
+
+// Issue will send request to datastore to save new activation token and mail the token to the user
+func (s *UserActivationService) Issue(userID uuid.UUID, email string) error {
+    // generate the token, only its hash is saved on the database
+    token, err := generateTokenFunc(activationTokenLength)
+    if err != nil {
+        logger.Errorf("generate activation token fail: %v", err)
+        return E.NewExt(E.ErrActivationMail, err)
+    }
+
+    // load account configuration
+    expireDuration, activationURL := activationConfig()
+
+    // send request to datastore to save the activation token
+    err = s.Store.Create(d.UserActivation{
+        TokenHash : helper.HashToken(token),
+        UserID    : userID,
+        ExpiresAt : time.Now().Add(time.Duration(expireDuration) * time.Hour),
+    })
+    if err != nil {
+        return err
+    }
+
+    // send the activation link to the user
+    link := fmt.Sprintf("%s?token=%s", activationURL, token)
+    body := fmt.Sprintf(activationMailBody, link, expireDuration)
+    if err = s.Mailer.Send(email, activationMailSubject, body); err != nil {
+        return E.NewExt(E.ErrActivationMail, err)
+    }
+
+    return nil
+}
+
+// Activate will send request to datastore to activate user account with given token
+func (s *UserActivationService) Activate(token string) (*d.UserResponse, error) {
+    if token == "" {
+        return nil, E.New(E.ErrActivationTokenInvalid)
+    }
+
+    // send request to datastore to activate the user
+    user, err := s.Store.Activate(helper.HashToken(token))
+    if err != nil {
+        // no record means the token is unknown, used or expired
+        if e, ok := err.(*E.Error); ok && e.Code == E.ErrDataIsEmpty {
+            return nil, E.New(E.ErrActivationTokenInvalid)
+        }
+        return nil, err
+    }
+
+    // return user.response to handler layer
+    return user.ConvertToResponse(), nil
+}
+
+// Resend will issue new activation token for the user with given email.
+// unregistered or already active user is silently ignored
+func (s *UserActivationService) Resend(email string) error {
+    // check if email is valid
+    if !helper.EmailIsValid(email) {
+        return E.New(E.ErrEmailIsInvalid)
+    }
+
+    // get user credential by its email
+    cred, err := s.UserStore.GetByEmail(email)
+    if err != nil {
+        if e, ok := err.(*E.Error); ok && e.Code == E.ErrDataIsEmpty {
+            logger.Infof("resend activation for unregistered email: %s", email)
+            return nil
+        }
+        return err
+    }
+
+    // only inactive user need activation
+    if !cred.NeedActivation() {
+        logger.Infof("resend activation for user that does not need activation: %s", email)
+        return nil
+    }
+
+    return s.Issue(cred.ID, email)
+}
+
+// activationConfig will get activation token expire duration and activation url
+// from account configuration, falling back to default value when it is not set
+func activationConfig() (int64, string) {
+    var (
+        expireDuration int64 = defaultActivationExpireDuration
+        activationURL string
+    )
+
+    if cfg := config.Get(); cfg != nil {
+        if cfg.Account.ActivationTokenExpireDuration > 0 {
+            expireDuration = cfg.Account.ActivationTokenExpireDuration
+        }
+        activationURL = cfg.Account.ActivationURL
+        if activationURL == "" {
+            activationURL = fmt.Sprintf("https://%s/account/activate", cfg.Server.DomainName)
+        }
+    }
+
+    return expireDuration, activationURL
+}
@@ -0,0 +1,262 @@
+/*
+    package service
+    user.activation_test.go
+    - test unit for user.activation service
+*/
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/reshimahendra/lbw-go/internal/config"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/helper"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockUserActivationService is mocked user.activation datastore
+type mockUserActivationService struct {
+    t *testing.T
+    created []d.UserActivation
+}
+
+// NewMockUserActivationService is new instance of mockUserActivationService
+func NewMockUserActivationService(t *testing.T) *mockUserActivationService{
+    return &mockUserActivationService{t: t}
+}
+
+// Create is mocked Create method to satisfy IUserActivationStore interface
+func (m *mockUserActivationService) Create(input d.UserActivation) error {
+    if wantErr {
+        return E.New(E.ErrDatabase)
+    }
+    m.created = append(m.created, input)
+
+    return nil
+}
+
+// Activate is mocked Activate method to satisfy IUserActivationStore interface
+func (m *mockUserActivationService) Activate(tokenHash string) (*d.User, error) {
+    if wantErr {
+        return nil, E.New(E.ErrDatabase)
+    }
+    for _, a := range m.created {
+        if a.TokenHash == tokenHash {
+            return u[0], nil
+        }
+    }
+
+    return nil, E.New(E.ErrDataIsEmpty)
+}
+
+// mockEmailUserStore is mocked user datastore returning the given credential on GetByEmail
+type mockEmailUserStore struct {
+    *mockUserService
+    cred *d.UserCredential
+    err error
+}
+
+// GetByEmail is mocked GetByEmail method to satisfy IUserStore interface
+func (m *mockEmailUserStore) GetByEmail(email string) (*d.UserCredential, error) {
+    return m.cred, m.err
+}
+
+// mockMailer is mocked mailer to record the sent mail
+type mockMailer struct {
+    to, subject, body string
+    err error
+}
+
+// Send is mocked Send method to satisfy IMailer interface
+func (m *mockMailer) Send(to, subject, body string) error {
+    if m.err != nil {
+        return m.err
+    }
+    m.to, m.subject, m.body = to, subject, body
+
+    return nil
+}
+
+// sentToken will get the activation token from the sent mail link
+func sentToken(body string) string {
+    idx := strings.Index(body, "?token=")
+    if idx < 0 {
+        return ""
+    }
+    token := body[idx+len("?token="):]
+
+    return strings.Fields(token)[0]
+}
+
+// TestUserActivationServiceIssue will test Issue method of user.activation service
+func TestUserActivationServiceIssue(t *testing.T) {
+    // prepare config for activation setting
+    if err := config.Setup(); err != nil {
+        t.Fatalf("unexpected error occur: %v", err)
+    }
+
+    // EXPECT SUCCESS token saved as hash and sent to the user
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        store, mail := NewMockUserActivationService(t), new(mockMailer)
+        service := NewUserActivationService(store, NewMockUserService(t), mail)
+
+        // actual method call
+        err := service.Issue(u[0].ID, u[0].Email)
+
+        // test verification and validation
+        assert.NoError(t, err)
+        assert.Equal(t, u[0].Email, mail.to)
+        assert.Contains(t, mail.body, config.Get().Account.ActivationURL+"?token=")
+        assert.Len(t, store.created, 1)
+        assert.Equal(t, u[0].ID, store.created[0].UserID)
+        assert.Equal(t, helper.HashToken(sentToken(mail.body)), store.created[0].TokenHash)
+    })
+
+    // EXPECT FAIL generate token error. Simulated by mocking helper.GenerateToken
+    t.Run("EXPECT FAIL generate token error", func(t *testing.T){
+        generateToken := generateTokenFunc
+        generateTokenFunc = func(length int) (string, error) {
+            return "", E.New(E.ErrDataIsInvalid)
+        }
+        defer func() { generateTokenFunc = generateToken }()
+
+        service := NewUserActivationService(NewMockUserActivationService(t), NewMockUserService(t), new(mockMailer))
+
+        // actual method call
+        err := service.Issue(u[0].ID, u[0].Email)
+
+        // test verification and validation
+        assert.Error(t, err)
+        assert.Equal(t, uint(E.ErrActivationMail), err.(*E.ErrorExt).Code)
+    })
+
+    // EXPECT FAIL datastore error. Simulated by setting wantErr=true
+    t.Run("EXPECT FAIL datastore error", func(t *testing.T){
+        service := NewUserActivationService(NewMockUserActivationService(t), NewMockUserService(t), new(mockMailer))
+
+        // actual method call
+        wantErr = true
+        err := service.Issue(u[0].ID, u[0].Email)
+        wantErr = false
+
+        // test verification and validation
+        assert.Error(t, err)
+        assert.Equal(t, E.New(E.ErrDatabase), err)
+    })
+
+    // EXPECT FAIL mailer error. Simulated by returning error from mocked mailer
+    t.Run("EXPECT FAIL mailer error", func(t *testing.T){
+        mail := &mockMailer{err: E.New(E.ErrActivationMail)}
+        service := NewUserActivationService(NewMockUserActivationService(t), NewMockUserService(t), mail)
+
+        // actual method call
+        err := service.Issue(u[0].ID, u[0].Email)
+
+        // test verification and validation
+        assert.Error(t, err)
+        assert.Equal(t, uint(E.ErrActivationMail), err.(*E.ErrorExt).Code)
+    })
+}
+
+// TestUserActivationServiceActivate will test Activate method of user.activation service
+func TestUserActivationServiceActivate(t *testing.T) {
+    // prepare config for activation setting
+    if err := config.Setup(); err != nil {
+        t.Fatalf("unexpected error occur: %v", err)
+    }
+
+    store, mail := NewMockUserActivationService(t), new(mockMailer)
+    service := NewUserActivationService(store, NewMockUserService(t), mail)
+    assert.NoError(t, service.Issue(u[0].ID, u[0].Email))
+
+    // EXPECT SUCCESS using the token sent to the user
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        // actual method call
+        got, err := service.Activate(sentToken(mail.body))
+
+        // test verification and validation
+        assert.NoError(t, err)
+        assert.Equal(t, u[0].ID, got.ID)
+    })
+
+    // EXPECT FAIL token empty
+    t.Run("EXPECT FAIL token empty", func(t *testing.T){
+        // actual method call
+        got, err := service.Activate("")
+
+        // test verification and validation
+        assert.Error(t, err)
+        assert.Nil(t, got)
+        assert.Equal(t, E.New(E.ErrActivationTokenInvalid), err)
+    })
+
+    // EXPECT FAIL token invalid. Simulated by giving unknown token
+    t.Run("EXPECT FAIL token invalid", func(t *testing.T){
+        // actual method call
+        got, err := service.Activate("unknown-token")
+
+        // test verification and validation
+        assert.Error(t, err)
+        assert.Nil(t, got)
+        assert.Equal(t, E.New(E.ErrActivationTokenInvalid), err)
+    })
+
+    // EXPECT FAIL datastore error. Simulated by setting wantErr=true
+    t.Run("EXPECT FAIL datastore error", func(t *testing.T){
+        // actual method call
+        wantErr = true
+        got, err := service.Activate(sentToken(mail.body))
+        wantErr = false
+
+        // test verification and validation
+        assert.Error(t, err)
+        assert.Nil(t, got)
+        assert.Equal(t, E.New(E.ErrDatabase), err)
+    })
+}
+
+// TestUserActivationServiceResend will test Resend method of user.activation service
+func TestUserActivationServiceResend(t *testing.T) {
+    // prepare config for activation setting
+    if err := config.Setup(); err != nil {
+        t.Fatalf("unexpected error occur: %v", err)
+    }
+
+    inactive := &d.UserCredential{ID: u[1].ID, Username: u[1].Username, StatusID: 0}
+    active := &d.UserCredential{ID: u[0].ID, Username: u[0].Username, StatusID: 1}
+
+    cases := []struct{
+        name, email string
+        cred *d.UserCredential
+        storeErr error
+        wantErr error
+        wantMail bool
+    }{
+        {"EXPECT SUCCESS", u[1].Email, inactive, nil, nil, true},
+        {"EXPECT SUCCESS user already active", u[0].Email, active, nil, nil, false},
+        {"EXPECT SUCCESS user not registered", "nobody@gmail.com", nil, E.New(E.ErrDataIsEmpty), nil, false},
+        {"EXPECT FAIL email invalid", "invalid-email", nil, nil, E.New(E.ErrEmailIsInvalid), false},
+        {"EXPECT FAIL datastore error", u[1].Email, nil, E.New(E.ErrDatabase), E.New(E.ErrDatabase), false},
+    }
+
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            mail := new(mockMailer)
+            userStore := &mockEmailUserStore{NewMockUserService(t), tt.cred, tt.storeErr}
+            service := NewUserActivationService(NewMockUserActivationService(t), userStore, mail)
+
+            // actual method call
+            err := service.Resend(tt.email)
+
+            // test verification and validation
+            assert.Equal(t, tt.wantErr, err)
+            if tt.wantMail {
+                assert.Equal(t, tt.email, mail.to)
+            } else {
+                assert.Equal(t, "", mail.to)
+            }
+        })
+    }
+}
@@ -0,0 +1,129 @@
+/*
+   service package
+   user.apikey.go
+   - service/ business layer for user api key (personal access token)
+*/
+package service
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+	ds "github.com/reshimahendra/lbw-go/internal/app/account/datastore"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/helper"
+	"github.com/reshimahendra/lbw-go/internal/pkg/logger"
+)
+
+// apiKeyLength is byte length of the random part of the api key
+const apiKeyLength = 32
+
+// IUserAPIKeyService is service layer for user api key so the handler and the
+// authorization middleware can communicate with the datastore layer
+type IUserAPIKeyService interface {
+    // Create will create new api key for the principal. held is the permission of the principal
+    Create(principal d.Principal, held []string, input d.APIKeyRequest) (*d.APIKeyCreateResponse, error)
+
+    // Gets will get api key of the user that is not revoked yet
+    Gets(userID uuid.UUID) ([]*d.APIKey, error)
+
+    // Revoke will revoke api key of the user
+    Revoke(userID, id uuid.UUID) error
+
+    // AuthenticateAPIKey will get the principal (key owner) of the given api key
+    AuthenticateAPIKey(key string) (*d.Principal, error)
+}
+
+// UserAPIKeyService is instance wrapper for IUserAPIKeyStore interface
+type UserAPIKeyService struct {
+    Store ds.IUserAPIKeyStore
+}
+
+// NewUserAPIKeyService is new instance of UserAPIKeyService
+func NewUserAPIKeyService(store ds.IUserAPIKeyStore) *UserAPIKeyService {
+    return &UserAPIKeyService{Store: store}
+}
+
+// Create will generate new api key and send request to datastore to save its hash. the
+// principal can only give permission it holds itself to the key, and api key can not be
+// used to create another api key
+func (s *UserAPIKeyService) Create(principal d.Principal, held []string, input d.APIKeyRequest) (*d.APIKeyCreateResponse, error) {
+    if principal.IsAPIKey() {
+        logger.Errorf("api key %s can not create another api key", principal.APIKeyID)
+        return nil, E.New(E.ErrForbidden)
+    }
+
+    // check the request and its expiration datetime
+    if !input.IsValid() || (input.ExpiresAt != nil && !input.ExpiresAt.After(timeNowFunc())) {
+        return nil, E.New(E.ErrDataIsInvalid)
+    }
+
+    scopes := []string{}
+    for _, scope := range input.Scopes {
+        if !containsString(held, scope) {
+            logger.Errorf("user %s without %s permission can not give it to api key", principal.UserID, scope)
+            return nil, E.New(E.ErrForbidden)
+        }
+        if !containsString(scopes, scope) {
+            scopes = append(scopes, scope)
+        }
+    }
+
+    // generate the key, only its hash is saved on the database
+    token, err := generateTokenFunc(apiKeyLength)
+    if err != nil {
+        logger.Errorf("generate api key fail: %v", err)
+        return nil, E.NewExt(E.ErrTokenCreate, err)
+    }
+    key := d.APIKeyPrefix + token
+
+    // send request to datastore to save the api key
+    apiKey, err := s.Store.Create(d.APIKey{
+        ID        : uuid.New(),
+        UserID    : principal.UserID,
+        Name      : strings.TrimSpace(input.Name),
+        Prefix    : key[:d.APIKeyDisplayLength],
+        KeyHash   : helper.HashToken(key),
+        Scopes    : scopes,
+        ExpiresAt : input.ExpiresAt,
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    return &d.APIKeyCreateResponse{APIKey: *apiKey, Key: key}, nil
+}
+
+// Gets will send request to datastore to get api key of the user
+func (s *UserAPIKeyService) Gets(userID uuid.UUID) ([]*d.APIKey, error) {
+    return s.Store.Gets(userID)
+}
+
+// Revoke will send request to datastore to revoke api key of the user
+func (s *UserAPIKeyService) Revoke(userID, id uuid.UUID) error {
+    return s.Store.Revoke(userID, id)
+}
+
+// AuthenticateAPIKey will get the principal (key owner) of the given api key. unknown,
+// revoked or expired key is invalid, and key of inactive user is rejected
+func (s *UserAPIKeyService) AuthenticateAPIKey(key string) (*d.Principal, error) {
+    if !strings.HasPrefix(key, d.APIKeyPrefix) {
+        return nil, E.New(E.ErrTokenInvalid)
+    }
+
+    // send request to datastore to get the key owner
+    principal, err := s.Store.Authenticate(helper.HashToken(key))
+    if err != nil {
+        if e, ok := err.(*E.Error); ok && e.Code == E.ErrDataIsEmpty {
+            return nil, E.New(E.ErrTokenInvalid)
+        }
+        return nil, err
+    }
+
+    if !(&d.UserCredential{StatusID: principal.StatusID}).IsActive() {
+        return nil, E.New(E.ErrUserNotActive)
+    }
+
+    return principal, nil
+}
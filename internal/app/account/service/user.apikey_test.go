@@ -0,0 +1,221 @@
+/*
+    package service
+    user.apikey_test.go
+    - test unit for user.apikey service
+*/
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/helper"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockUserAPIKeyService is mocked user.apikey datastore
+type mockUserAPIKeyService struct {
+    keys   []d.APIKey
+    status int
+}
+
+// Create is mocked Create method to satisfy IUserAPIKeyStore interface
+func (m *mockUserAPIKeyService) Create(input d.APIKey) (*d.APIKey, error) {
+    if wantErr {
+        return nil, E.New(E.ErrDatabase)
+    }
+
+    input.CreatedAt = time.Now()
+    m.keys = append(m.keys, input)
+    return &input, nil
+}
+
+// Gets is mocked Gets method to satisfy IUserAPIKeyStore interface
+func (m *mockUserAPIKeyService) Gets(userID uuid.UUID) ([]*d.APIKey, error) {
+    if wantErr {
+        return nil, E.New(E.ErrDatabase)
+    }
+
+    keys := []*d.APIKey{}
+    for i := range m.keys {
+        if m.keys[i].UserID == userID {
+            keys = append(keys, &m.keys[i])
+        }
+    }
+
+    return keys, nil
+}
+
+// Revoke is mocked Revoke method to satisfy IUserAPIKeyStore interface
+func (m *mockUserAPIKeyService) Revoke(userID, id uuid.UUID) error {
+    for i, key := range m.keys {
+        if key.ID == id && key.UserID == userID {
+            m.keys = append(m.keys[:i], m.keys[i+1:]...)
+            return nil
+        }
+    }
+
+    return E.New(E.ErrDataIsEmpty)
+}
+
+// Authenticate is mocked Authenticate method to satisfy IUserAPIKeyStore interface
+func (m *mockUserAPIKeyService) Authenticate(keyHash string) (*d.Principal, error) {
+    if wantErr {
+        return nil, E.New(E.ErrDatabase)
+    }
+
+    for _, key := range m.keys {
+        if key.KeyHash == keyHash {
+            return &d.Principal{
+                UserID   : key.UserID,
+                RoleID   : d.RoleStaff,
+                StatusID : m.status,
+                APIKeyID : key.ID.String(),
+                Scopes   : key.Scopes,
+            }, nil
+        }
+    }
+
+    return nil, E.New(E.ErrDataIsEmpty)
+}
+
+// TestUserAPIKeyServiceCreate will test creating api key on behalf of the principal
+func TestUserAPIKeyServiceCreate(t *testing.T) {
+    principal := d.Principal{UserID: uuid.New(), RoleID: d.RoleStaff}
+    held := []string{d.PermUserRead, d.PermRoleRead}
+    past := time.Now().Add(-time.Hour)
+
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        store := &mockUserAPIKeyService{status: 1}
+        service := NewUserAPIKeyService(store)
+
+        got, err := service.Create(principal, held, d.APIKeyRequest{
+            Name   : " deploy bot ",
+            Scopes : []string{d.PermUserRead, d.PermUserRead},
+        })
+
+        assert.NoError(t, err)
+        assert.True(t, strings.HasPrefix(got.Key, d.APIKeyPrefix))
+        assert.Equal(t, got.Key[:d.APIKeyDisplayLength], got.Prefix)
+        assert.Equal(t, "deploy bot", got.Name)
+        assert.Equal(t, []string{d.PermUserRead}, got.Scopes)
+        assert.Len(t, store.keys, 1)
+        assert.Equal(t, helper.HashToken(got.Key), store.keys[0].KeyHash)
+    })
+
+    cases := []struct{
+        name      string
+        principal d.Principal
+        input     d.APIKeyRequest
+        want      int
+    }{
+        {"EXPECT FAIL empty name", principal, d.APIKeyRequest{Name: " "}, E.ErrDataIsInvalid},
+        {"EXPECT FAIL invalid scope", principal, d.APIKeyRequest{Name: "bot", Scopes: []string{"USER"}}, E.ErrDataIsInvalid},
+        {"EXPECT FAIL already expired", principal, d.APIKeyRequest{Name: "bot", ExpiresAt: &past}, E.ErrDataIsInvalid},
+        {"EXPECT FAIL scope not held", principal, d.APIKeyRequest{Name: "bot", Scopes: []string{d.PermUserWrite}}, E.ErrForbidden},
+        {"EXPECT FAIL created with api key", d.Principal{UserID: principal.UserID, APIKeyID: uuid.NewString()}, d.APIKeyRequest{Name: "bot"}, E.ErrForbidden},
+    }
+
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            service := NewUserAPIKeyService(&mockUserAPIKeyService{})
+
+            got, err := service.Create(tt.principal, held, tt.input)
+
+            assert.Nil(t, got)
+            assert.EqualValues(t, tt.want, err.(*E.Error).Code)
+        })
+    }
+
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        service := NewUserAPIKeyService(&mockUserAPIKeyService{})
+
+        wantErr = true
+        got, err := service.Create(principal, held, d.APIKeyRequest{Name: "bot"})
+        wantErr = false
+
+        assert.Nil(t, got)
+        assert.Error(t, err)
+    })
+}
+
+// TestUserAPIKeyServiceRevoke will test listing and revoking api key of the user
+func TestUserAPIKeyServiceRevoke(t *testing.T) {
+    principal := d.Principal{UserID: uuid.New(), RoleID: d.RoleStaff}
+    store := &mockUserAPIKeyService{status: 1}
+    service := NewUserAPIKeyService(store)
+
+    created, err := service.Create(principal, nil, d.APIKeyRequest{Name: "bot"})
+    assert.NoError(t, err)
+
+    keys, err := service.Gets(principal.UserID)
+    assert.NoError(t, err)
+    assert.Len(t, keys, 1)
+
+    // other user can not revoke the key
+    err = service.Revoke(uuid.New(), created.ID)
+    assert.EqualValues(t, E.ErrDataIsEmpty, err.(*E.Error).Code)
+
+    assert.NoError(t, service.Revoke(principal.UserID, created.ID))
+    keys, _ = service.Gets(principal.UserID)
+    assert.Len(t, keys, 0)
+}
+
+// TestUserAPIKeyServiceAuthenticate will test getting the principal of the api key
+func TestUserAPIKeyServiceAuthenticate(t *testing.T) {
+    principal := d.Principal{UserID: uuid.New(), RoleID: d.RoleStaff}
+    store := &mockUserAPIKeyService{status: 1}
+    service := NewUserAPIKeyService(store)
+
+    created, err := service.Create(principal, []string{d.PermUserRead}, d.APIKeyRequest{
+        Name   : "bot",
+        Scopes : []string{d.PermUserRead},
+    })
+    assert.NoError(t, err)
+
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        got, err := service.AuthenticateAPIKey(created.Key)
+
+        assert.NoError(t, err)
+        assert.Equal(t, principal.UserID, got.UserID)
+        assert.Equal(t, created.ID.String(), got.APIKeyID)
+        assert.Equal(t, []string{d.PermUserRead}, got.Scopes)
+    })
+
+    t.Run("EXPECT FAIL not an api key", func(t *testing.T){
+        got, err := service.AuthenticateAPIKey("eyJhbGciOiJFUzI1NiJ9")
+
+        assert.Nil(t, got)
+        assert.EqualValues(t, E.ErrTokenInvalid, err.(*E.Error).Code)
+    })
+
+    // EXPECT FAIL key is unknown, revoked or expired
+    t.Run("EXPECT FAIL unknown key", func(t *testing.T){
+        got, err := service.AuthenticateAPIKey(d.APIKeyPrefix + "unknown")
+
+        assert.Nil(t, got)
+        assert.EqualValues(t, E.ErrTokenInvalid, err.(*E.Error).Code)
+    })
+
+    t.Run("EXPECT FAIL user not active", func(t *testing.T){
+        store.status = 2
+        got, err := service.AuthenticateAPIKey(created.Key)
+        store.status = 1
+
+        assert.Nil(t, got)
+        assert.EqualValues(t, E.ErrUserNotActive, err.(*E.Error).Code)
+    })
+
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        wantErr = true
+        got, err := service.AuthenticateAPIKey(created.Key)
+        wantErr = false
+
+        assert.Nil(t, got)
+        assert.EqualValues(t, E.ErrDatabase, err.(*E.Error).Code)
+    })
+}
@@ -6,21 +6,31 @@
 package service
 
 import (
+	"encoding/json"
+
 	"github.com/google/uuid"
 	ds "github.com/reshimahendra/lbw-go/internal/app/account/datastore"
 	d "github.com/reshimahendra/lbw-go/internal/domain"
+	"github.com/reshimahendra/lbw-go/internal/pkg/auth"
 	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
 	"github.com/reshimahendra/lbw-go/internal/pkg/helper"
 	"github.com/reshimahendra/lbw-go/internal/pkg/logger"
 )
 
 var (
-    // generateHashPassFunc is func instance of helper.HashPassword
+    // generateHashPassFunc is func instance of auth.HashPassword
     // it will be used to mock the inner func on test
-    generateHashPassFunc = helper.HashPassword
+    generateHashPassFunc = auth.HashPassword
+
+    // checkPassHashFunc is func instance of auth.VerifyPassword
+    checkPassHashFunc = auth.VerifyPassword
 
-    // checkPassHashFunc is func instance of helper.CheckPasswordHash
-    checkPassHashFunc = helper.CheckPasswordHash
+    // passNeedRehashFunc is func instance of auth.PasswordNeedRehash
+    passNeedRehashFunc = auth.PasswordNeedRehash
+
+    // passMaxBytesFunc is func instance of auth.PasswordMaximumBytes
+    // it will be used to mock the password byte limit of the hasher on test
+    passMaxBytesFunc = auth.PasswordMaximumBytes
 )
 
 // IUserService is service layer for user so the handle layer can
@@ -30,7 +40,11 @@ type IUserService interface {
     // Create will send user request data to datastore 
     // and request it to process with user insertion operation and 
     // expect to get UserResponse dto from it
-    Create(input d.UserRequest) (*d.UserResponse, error)
+    Create(principal d.Principal, input d.UserRequest) (*d.UserResponse, error)
+
+    // Signup will send user signup request data to datastore to create inactive
+    // user with guest role, status and role can not be chosen on signup
+    Signup(input d.UserSignupRequest) (*d.UserResponse, error)
 
     // Get will make request to datastore to retreive user record based on
     // given id and expect to get UserResponse dto from the operation
@@ -39,36 +53,67 @@ type IUserService interface {
     // GetByEmail will make request to datastore to get user credential data by its username
     GetByEmail(email string) (*d.UserCredential, error) 
 
-    // Gets will make request to datastore to retreive all user data in dto format
-    Gets() ([]*d.UserResponse, error)
+    // Gets will make request to datastore to retreive user data matching the list query
+    // in dto format along with the list metadata
+    Gets(q d.ListQuery) ([]*d.UserResponse, *d.ListMeta, error)
 
     // Update will make request to datastore to update certain record based on its ID
     // with the given new user value
-    Update(id string, input d.UserRequest) (*d.UserResponse, error)
+    Update(principal d.Principal, id string, input d.UserRequest) (*d.UserResponse, error)
+
+    // UpdateProfile will make request to datastore to update profile of the user with
+    // the given id. status, role and password are not part of the profile
+    UpdateProfile(id string, input d.UserProfileRequest) (*d.UserResponse, error)
+
+    // Patch will make request to datastore to apply merge patch to certain record based on its ID.
+    // only the patched field is updated
+    Patch(principal d.Principal, id string, patch d.MergePatch) (*d.UserResponse, error)
 
     // Delete will make request to datastore to do (soft) delete to give user id record
     Delete(id string) (*d.UserResponse, error)
 
+    // Restore will make request to datastore to restore (soft) deleted user record
+    Restore(id string) (*d.UserResponse, error)
+
+    // Purge will make request to datastore to permanently remove (soft) deleted user record
+    Purge(id string) error
+
     // GetCredential will make request to datastore to get user credential data
     GetCredential(username,passkey string) (*d.UserCredential, error) 
 
     // IsUserExist will make request to datastore to check whether username/ email
     // is already exist
     IsUserExist(username,email string) bool
+
+    // RehashPassKey will make request to datastore to replace the passkey made with outdated
+    // hash algorithm or parameter with the verified password hashed again
+    RehashPassKey(id uuid.UUID, password, passKey string) error
 }
 
 // UserService is instance wrapper for IUserStore interface
 type UserService struct {
     Store ds.IUserStore
+    Permission IPermissionService
 }
 
 // NewUserService is new instance of UserService
-func NewUserService(rs ds.IUserStore) *UserService{
-    return &UserService{Store: rs}
+func NewUserService(rs ds.IUserStore, ps IPermissionService) *UserService{
+    return &UserService{Store: rs, Permission: ps}
 }
 
-// Create will send request to datastore to insert new user record
-func (s *UserService) Create(input d.UserRequest) (*d.UserResponse, error) {
+// Create will send request to datastore to insert new user record on behalf of the principal.
+// the principal can only assign role holding the permission it holds itself
+func (s *UserService) Create(principal d.Principal, input d.UserRequest) (*d.UserResponse, error) {
+    // check if the principal may assign the role
+    if err := s.checkAssignRole(principal, input.RoleID); err != nil {
+        return nil, err
+    }
+
+    return s.create(input)
+}
+
+// create will send request to datastore to insert new user record
+func (s *UserService) create(input d.UserRequest) (*d.UserResponse, error) {
     // create new uuid
     input.ID = uuid.New()
 
@@ -86,6 +131,11 @@ func (s *UserService) Create(input d.UserRequest) (*d.UserResponse, error) {
         return nil, err
     }
 
+    // check the password against the password policy
+    if err := checkPasswordPolicy(passwordPolicyFunc(), "passkey", input.PassKey, nil, input.Username, input.Email); err != nil {
+        return nil, err
+    }
+
     // generate hashed passkey
     passKey, err := generateHashPassFunc(input.PassKey)
     if err != nil {
@@ -104,6 +154,12 @@ func (s *UserService) Create(input d.UserRequest) (*d.UserResponse, error) {
     return user.ConvertToResponse(), nil
 }
 
+// Signup will send request to datastore to insert new inactive user with guest role.
+// the account is active once it is activated and its role can only be changed by administrator
+func (s *UserService) Signup(input d.UserSignupRequest) (*d.UserResponse, error) {
+    return s.create(*input.ToUserRequest())
+}
+
 // Get will send request to user datastore to retreive user record with given id
 func (s *UserService) Get(id string) (*d.UserResponse, error) {
     // send request to datastore to get record
@@ -116,12 +172,12 @@ func (s *UserService) Get(id string) (*d.UserResponse, error) {
     return user.ConvertToResponse(), nil 
 }
 
-// Gets will send request to user datastore to retreive all user record
-func (s *UserService) Gets() ([]*d.UserResponse, error) {
+// Gets will send request to user datastore to retreive user record matching the list query
+func (s *UserService) Gets(q d.ListQuery) ([]*d.UserResponse, *d.ListMeta, error) {
     // send request to datastore to get record
-    users, err := s.Store.Gets()
+    users, meta, err := s.Store.Gets(q)
     if err != nil {
-        return nil, err
+        return nil, nil, err
     }
 
     // make new instance of user response as container of the 
@@ -132,13 +188,16 @@ func (s *UserService) Gets() ([]*d.UserResponse, error) {
     }
 
     // return user.response slice to handler layer
-    return uRes, nil 
+    return uRes, meta, nil 
 }
 
-// Update will send request to user datastore to update user record by given user id
-func (s *UserService) Update(id string, input d.UserRequest) (*d.UserResponse, error) {
-    // check if input data is invalid
-    if !input.IsValid() {
+// Update will send request to user datastore to update user record by given user id.
+// the principal can not change its own status and role, and can only assign role holding
+// the permission it holds itself
+func (s *UserService) Update(principal d.Principal, id string, input d.UserRequest) (*d.UserResponse, error) {
+    // check if input data is invalid. passkey is not part of the update, it is
+    // changed through user.password service
+    if !input.IsValidUpdate() {
         err := E.New(E.ErrDataIsInvalid)
         logger.Errorf("%v", err)
         return nil, err
@@ -153,37 +212,144 @@ func (s *UserService) Update(id string, input d.UserRequest) (*d.UserResponse, e
 
     // parse id string to UUID
     userUUID := ParseUUID(id)
+    if userUUID == nil {
+        return nil, E.New(E.ErrDataIsInvalid)
+    }
+
+    // check if the principal may change the status and role
+    if err := s.checkStatusRole(principal, *userUUID, func(current *d.User) (int, int) {
+        return input.StatusID, input.RoleID
+    }); err != nil {
+        return nil, err
+    }
 
-    // get user record as comparison data
-    user, err := s.Store.Get(*userUUID)
+    // update user data
+    updatedUser, err := s.Store.Update(*userUUID, *input.RequestToUser())
     if err != nil {
-        return nil, E.New(E.ErrGettingData)
+        return nil, err
     }
-    
-    // check if password change
-    if !checkPassHashFunc(input.PassKey, user.PassKey) {
-        // it mean password has changed
-        newPass, err := generateHashPassFunc(input.PassKey)
-        if err != nil {
-            logger.Errorf("generate passkey fail: %v", err)
+
+    // return response to handler layer
+    return updatedUser.ConvertToResponse(), nil
+}
+
+// Patch will send request to user datastore to apply merge patch to user record by given user id.
+// the principal can not change its own status and role, and can only assign role holding
+// the permission it holds itself
+func (s *UserService) Patch(principal d.Principal, id string, patch d.MergePatch) (*d.UserResponse, error) {
+    // check if patched email is valid, null email is rejected by the datastore as the
+    // column require a value
+    if patch.Has("email") && !patch.IsNull("email") {
+        var email string
+        if err := json.Unmarshal(patch["email"], &email); err != nil || !helper.EmailIsValid(email) {
+            err := E.New(E.ErrEmailIsInvalid)
+            logger.Errorf("%v", err)
             return nil, err
         }
+    }
 
-        // update input passskey with hashed key
-        input.PassKey = newPass
-    } else {
-        // do not update passkey and use the old hashed key
-        input.PassKey = user.PassKey
+    // parse id string to UUID
+    userUUID := ParseUUID(id)
+    if userUUID == nil {
+        return nil, E.New(E.ErrDataIsInvalid)
     }
 
-    // update user data
-    updatedUser, err := s.Store.Update(*userUUID, *input.RequestToUser())
+    // check if the principal may change the status and role. invalid patch is left to
+    // be rejected by the datastore
+    if err := s.checkStatusRole(principal, *userUUID, func(current *d.User) (int, int) {
+        patched := &d.User{StatusID: current.StatusID, RoleID: current.RoleID}
+        if _, err := patched.ApplyPatch(patch); err != nil {
+            return current.StatusID, current.RoleID
+        }
+        return patched.StatusID, patched.RoleID
+    }); err != nil {
+        return nil, err
+    }
+
+    // patch user data
+    user, err := s.Store.Patch(*userUUID, patch)
     if err != nil {
         return nil, err
     }
 
     // return response to handler layer
-    return updatedUser.ConvertToResponse(), nil
+    return user.ConvertToResponse(), nil
+}
+
+// checkStatusRole will check the status and role 'changed' return for the user with the given id.
+// the principal can not change its own status and role, and the new role must not hold
+// permission the principal does not hold
+func (s *UserService) checkStatusRole(principal d.Principal, id uuid.UUID, changed func(current *d.User) (statusID, roleID int)) error {
+    current, err := s.Store.Get(id)
+    if err != nil {
+        return err
+    }
+
+    statusID, roleID := changed(current)
+    if id == principal.UserID && (statusID != current.StatusID || roleID != current.RoleID) {
+        err := E.New(E.ErrForbidden)
+        logger.Errorf("user %s changing its own status or role: %v", id, err)
+        return err
+    }
+    if roleID != current.RoleID {
+        return s.checkAssignRole(principal, roleID)
+    }
+
+    return nil
+}
+
+// checkAssignRole will check whether the principal may assign the role to user. the principal
+// can not assign role holding permission it does not hold (to prevent privilege escalation)
+func (s *UserService) checkAssignRole(principal d.Principal, roleID int) error {
+    held, err := s.Permission.RolePermissions(principal.RoleID)
+    if err != nil {
+        return err
+    }
+    target, err := s.Permission.RolePermissions(roleID)
+    if err != nil {
+        return err
+    }
+
+    for _, perm := range target {
+        if !containsString(held, perm) {
+            logger.Errorf("user %s without %s permission can not assign role %d", principal.UserID, perm, roleID)
+            return E.New(E.ErrForbidden)
+        }
+    }
+
+    return nil
+}
+
+// UpdateProfile will send request to user datastore to update profile of the user with the given id
+func (s *UserService) UpdateProfile(id string, input d.UserProfileRequest) (*d.UserResponse, error) {
+    // check if input data is invalid
+    if !input.IsValid() {
+        err := E.New(E.ErrDataIsInvalid)
+        logger.Errorf("%v", err)
+        return nil, err
+    }
+
+    // check if email if its valid
+    if !helper.EmailIsValid(input.Email) {
+        err := E.New(E.ErrEmailIsInvalid)
+        logger.Errorf("%v", err)
+        return nil, err
+    }
+
+    // parse id string to UUID
+    userUUID := ParseUUID(id)
+    if userUUID == nil {
+        return nil, E.New(E.ErrDataIsInvalid)
+    }
+
+    // update user profile
+    user, err := s.Store.UpdateProfile(*userUUID, *input.RequestToUser())
+    if err != nil {
+        return nil, err
+    }
+
+    // return response to handler layer
+    return user.ConvertToResponse(), nil
 }
 
 // Delete will  send request to user datastore to 'soft' delete user record by given user id
@@ -198,6 +364,36 @@ func (s *UserService) Delete(id string) (*d.UserResponse, error) {
     return user.ConvertToResponse(), nil
 }
 
+// Restore will send request to user datastore to restore (soft) deleted user record by given user id
+func (s *UserService) Restore(id string) (*d.UserResponse, error) {
+    // parse id string to UUID
+    userUUID := ParseUUID(id)
+    if userUUID == nil {
+        return nil, E.New(E.ErrDataIsInvalid)
+    }
+
+    // restore user data
+    user, err := s.Store.Restore(*userUUID)
+    if err != nil {
+        return nil, err
+    }
+
+    // return response to handler layer
+    return user.ConvertToResponse(), nil
+}
+
+// Purge will send request to user datastore to permanently remove (soft) deleted user record
+// by given user id
+func (s *UserService) Purge(id string) error {
+    // parse id string to UUID
+    userUUID := ParseUUID(id)
+    if userUUID == nil {
+        return E.New(E.ErrDataIsInvalid)
+    }
+
+    return s.Store.Purge(*userUUID)
+}
+
 // GetByEmail will send request to user datastore to get user credential data
 // based on its email
 func (s *UserService) GetByEmail(email string) (*d.UserCredential, error) {
@@ -247,3 +443,21 @@ func ParseUUID(s string) *uuid.UUID{
 
     return &aUUID
 }
+
+// RehashPassKey will hash the verified password again when its passkey is not made with the
+// configured hash algorithm and parameter, and send request to datastore to replace it.
+// the passkey is only replaced when it is not changed meanwhile
+func (s *UserService) RehashPassKey(id uuid.UUID, password, passKey string) error {
+    if !passNeedRehashFunc(passKey) {
+        return nil
+    }
+
+    // generate hashed passkey with the current algorithm and parameter
+    newPassKey, err := generateHashPassFunc(password)
+    if err != nil {
+        logger.Errorf("generate passkey fail: %v", err)
+        return err
+    }
+
+    return s.Store.UpdatePassKey(id, passKey, newPassKey)
+}
@@ -0,0 +1,239 @@
+/*
+   service package
+   user.password.go
+   - service/ business layer for user password reset
+*/
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	ds "github.com/reshimahendra/lbw-go/internal/app/account/datastore"
+	"github.com/reshimahendra/lbw-go/internal/config"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/helper"
+	"github.com/reshimahendra/lbw-go/internal/pkg/logger"
+	"github.com/reshimahendra/lbw-go/internal/pkg/mailer"
+)
+
+const (
+    // passwordResetTokenLength is byte length of the generated password reset token
+    passwordResetTokenLength = 32
+
+    // defaultPasswordResetExpireDuration is fallback valid duration (in hour) of password reset token
+    defaultPasswordResetExpireDuration = 1
+
+    // passwordResetMailSubject is subject of the password reset mail
+    passwordResetMailSubject = "Reset your password"
+
+    // passwordResetMailBody is body template of the password reset mail
+    passwordResetMailBody = "Hi,\n\nWe received a request to reset your password. Open the link below to set a new password:\n%s\n\nThe link will expire in %d hour(s). If you did not request it, you can ignore this mail.\n"
+)
+
+// IUserPasswordService is service layer for user password reset
+type IUserPasswordService interface {
+    // Forgot will send password reset mail to the user with given email
+    Forgot(email string) error
+
+    // Reset will use the password reset token to set new password of its user
+    Reset(input d.PasswordResetRequest) (uuid.UUID, error)
+
+    // Change will verify the current password of the principal and set the new one
+    Change(principal d.Principal, input d.PasswordChangeRequest) error
+}
+
+// UserPasswordService is instance wrapper for IUserPasswordStore interface
+type UserPasswordService struct {
+    // Store is user.password datastore
+    Store     ds.IUserPasswordStore
+
+    // UserStore is user datastore, used to look up user by email
+    UserStore ds.IUserStore
+
+    // Mailer is the mail sender for the password reset mail
+    Mailer    mailer.IMailer
+}
+
+// NewUserPasswordService is new instance of UserPasswordService
+func NewUserPasswordService(st ds.IUserPasswordStore, us ds.IUserStore, m mailer.IMailer) *UserPasswordService {
+    return &UserPasswordService{Store: st, UserStore: us, Mailer: m}
+}
+
+// Forgot will issue password reset token and mail it to the user. to not reveal whether
+// the email is registered, it return no error once the email is valid
+func (s *UserPasswordService) Forgot(email string) error {
+    // check if email is valid
+    if !helper.EmailIsValid(email) {
+        return E.New(E.ErrEmailIsInvalid)
+    }
+
+    // get user credential by its email
+    cred, err := s.UserStore.GetByEmail(email)
+    if err != nil {
+        logger.Errorf("password forgot fail to get user %s: %v", email, err)
+        return nil
+    }
+
+    // suspended or banned user can not reset the password
+    if !cred.IsActive() && !cred.NeedActivation() {
+        logger.Infof("password forgot for user with status %d: %s", cred.StatusID, email)
+        return nil
+    }
+
+    // generate the token, only its hash is saved on the database
+    token, err := generateTokenFunc(passwordResetTokenLength)
+    if err != nil {
+        logger.Errorf("generate password reset token fail: %v", err)
+        return nil
+    }
+
+    // load account configuration
+    expireDuration, resetURL := passwordResetConfig()
+
+    // send request to datastore to save the password reset token
+    err = s.Store.Create(d.UserPasswordReset{
+        TokenHash : helper.HashToken(token),
+        UserID    : cred.ID,
+        ExpiresAt : time.Now().Add(time.Duration(expireDuration) * time.Hour),
+    })
+    if err != nil {
+        logger.Errorf("password forgot fail to save token: %v", err)
+        return nil
+    }
+
+    // send the password reset link to the user
+    link := fmt.Sprintf("%s?token=%s", resetURL, token)
+    body := fmt.Sprintf(passwordResetMailBody, link, expireDuration)
+    if err = s.Mailer.Send(email, passwordResetMailSubject, body); err != nil {
+        logger.Errorf("password forgot fail to send mail: %v", err)
+    }
+
+    return nil
+}
+
+// Reset will send request to datastore to set the new password and revoke all
+// auth token of the user. it return id of the user whose password is reset
+func (s *UserPasswordService) Reset(input d.PasswordResetRequest) (uuid.UUID, error) {
+    if input.Token == "" {
+        return uuid.Nil, E.New(E.ErrPasswordResetTokenInvalid)
+    }
+
+    // get the user of the token, the token is only used once the password is reset
+    user, err := s.Store.GetByToken(helper.HashToken(input.Token))
+    if err != nil {
+        if e, ok := err.(*E.Error); ok && e.Code == E.ErrDataIsEmpty {
+            return uuid.Nil, E.New(E.ErrPasswordResetTokenInvalid)
+        }
+        return uuid.Nil, err
+    }
+
+    // check the new password against the password policy
+    policy := passwordPolicyFunc()
+    history, err := s.passKeyHistory(policy, user.ID)
+    if err != nil {
+        return uuid.Nil, err
+    }
+    if err = checkPasswordPolicy(policy, "passkey", input.PassKey, history, user.Username, user.Email); err != nil {
+        return uuid.Nil, err
+    }
+
+    // generate hashed passkey
+    passKey, err := generateHashPassFunc(input.PassKey)
+    if err != nil {
+        logger.Errorf("generate passkey fail: %v", err)
+        return uuid.Nil, err
+    }
+
+    // send request to datastore to reset the password. token issued
+    // before now will be revoked
+    user, err = s.Store.Reset(helper.HashToken(input.Token), passKey, revocationCutoff())
+    if err != nil {
+        // no record means the token is unknown, used or expired
+        if e, ok := err.(*E.Error); ok && e.Code == E.ErrDataIsEmpty {
+            return uuid.Nil, E.New(E.ErrPasswordResetTokenInvalid)
+        }
+        return uuid.Nil, err
+    }
+
+    return user.ID, nil
+}
+
+// Change will verify the current password of the principal, hash the new password and
+// revoke every other session of the principal. the session used to request the change stays valid
+func (s *UserPasswordService) Change(principal d.Principal, input d.PasswordChangeRequest) error {
+    if !input.IsValid() {
+        return E.New(E.ErrDataIsInvalid)
+    }
+
+    // get current hashed passkey to verify the current password
+    current, err := s.Store.GetPassKey(principal.UserID)
+    if err != nil {
+        return err
+    }
+    if !checkPassHashFunc(input.CurrentPassword, current) {
+        return E.New(E.ErrPasswordNotMatch)
+    }
+
+    // check the new password against the password policy, the username is only
+    // needed when the password must not contain it
+    policy := passwordPolicyFunc()
+    userInfo := []string{principal.Email}
+    if policy.RejectUserInfo {
+        user, err := s.UserStore.Get(principal.UserID)
+        if err != nil {
+            return err
+        }
+        userInfo = []string{user.Username, user.Email}
+    }
+    history, err := s.passKeyHistory(policy, principal.UserID)
+    if err != nil {
+        return err
+    }
+    if err = checkPasswordPolicy(policy, "new_password", input.NewPassword, history, userInfo...); err != nil {
+        return err
+    }
+
+    // generate hashed passkey
+    passKey, err := generateHashPassFunc(input.NewPassword)
+    if err != nil {
+        logger.Errorf("generate passkey fail: %v", err)
+        return err
+    }
+
+    // send request to datastore to change the password and revoke the other session
+    return s.Store.Change(principal.UserID, passKey, principal.FamilyID)
+}
+
+// passKeyHistory will get the hashed passkey of the user the new password must not match,
+// it is empty when the password policy has no history
+func (s *UserPasswordService) passKeyHistory(policy config.PasswordPolicy, userID uuid.UUID) ([]string, error) {
+    if policy.HistoryCount <= 0 {
+        return nil, nil
+    }
+
+    return s.Store.GetPassKeyHistory(userID, policy.HistoryCount)
+}
+
+// passwordResetConfig will get password reset token expire duration and password reset url
+// from account configuration, falling back to default value when it is not set
+func passwordResetConfig() (int64, string) {
+    var (
+        expireDuration int64 = defaultPasswordResetExpireDuration
+        resetURL string
+    )
+
+    if cfg := config.Get(); cfg != nil {
+        if cfg.Account.PasswordResetTokenExpireDuration > 0 {
+            expireDuration = cfg.Account.PasswordResetTokenExpireDuration
+        }
+        resetURL = cfg.Account.PasswordResetURL
+        if resetURL == "" {
+            resetURL = fmt.Sprintf("https://%s/account/password/reset", cfg.Server.DomainName)
+        }
+    }
+
+    return expireDuration, resetURL
+}
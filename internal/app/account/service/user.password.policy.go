@@ -0,0 +1,234 @@
+/*
+   service package
+   user.password.policy.go
+   - password policy checked on signup, password change and reset
+*/
+package service
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/reshimahendra/lbw-go/internal/config"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/logger"
+)
+
+const (
+    // defaultPasswordMinimumLength is fallback minimum number of character of the password
+    defaultPasswordMinimumLength = 8
+
+    // defaultPasswordMaximumLength is fallback maximum number of character of the password.
+    // byte length is limited separately by the hasher (see auth.PasswordMaximumBytes)
+    defaultPasswordMaximumLength = 72
+
+    // minimumUserInfoLength is minimum length of the username or email part checked on the
+    // password, shorter part is too likely to be found by chance
+    minimumUserInfoLength = 3
+)
+
+var (
+    // passwordPolicyFunc is func instance of passwordPolicy
+    // it will be used to mock the password policy on test
+    passwordPolicyFunc = passwordPolicy
+
+    // breachedLists is the loaded breached password list, keyed by its file path
+    breachedLists = map[string]*breachedList{}
+
+    // breachedListsMu is guard of breachedLists
+    breachedListsMu sync.RWMutex
+
+    // breachedListRetryInterval is interval loading the breached password list
+    // is retried after it fail
+    breachedListRetryInterval = time.Minute
+)
+
+// breachedList is loaded breached password list. list that fail to load is kept with the
+// error, so loading it is retried once breachedListRetryInterval has passed and not on every check
+type breachedList struct {
+    passwords map[string]struct{}
+    err       error
+    loadedAt  time.Time
+}
+
+// usable will check whether the loaded list can be used without loading it again
+func (l *breachedList) usable() bool {
+    return l.err == nil || timeNowFunc().Sub(l.loadedAt) < breachedListRetryInterval
+}
+
+// passwordPolicy will get the password policy from account configuration, falling back
+// to default length when it is not set
+func passwordPolicy() config.PasswordPolicy {
+    var policy config.PasswordPolicy
+    if cfg := config.Get(); cfg != nil {
+        policy = cfg.Account.PasswordPolicy
+    }
+
+    if policy.MinimumLength <= 0 {
+        policy.MinimumLength = defaultPasswordMinimumLength
+    }
+    if policy.MaximumLength <= 0 {
+        policy.MaximumLength = defaultPasswordMaximumLength
+    }
+
+    return policy
+}
+
+// checkPasswordPolicy will check the password against the password policy. every rule the
+// password fail is listed on the returned E.ErrPasswordPolicy error under the request 'field'.
+// 'history' is the previous hashed passkey the password must not match and 'userInfo' is
+// the username and email the password must not contain
+func checkPasswordPolicy(policy config.PasswordPolicy, field, password string, history []string, userInfo ...string) error {
+    var fails E.FieldErrors
+    fail := func(rule, message string) {
+        fails = append(fails, E.FieldError{Field: field, Rule: rule, Message: message})
+    }
+
+    // check the password length
+    length := utf8.RuneCountInString(password)
+    if length < policy.MinimumLength {
+        fail("min", fmt.Sprintf("must be at least %d characters", policy.MinimumLength))
+    }
+    if length > policy.MaximumLength {
+        fail("max", fmt.Sprintf("must be at most %d characters", policy.MaximumLength))
+    } else if maxBytes := passMaxBytesFunc(); maxBytes > 0 && len(password) > maxBytes {
+        // bcrypt ignore the byte after its limit, multi byte character reach it before the maximum length
+        fail("max_bytes", fmt.Sprintf("must be at most %d bytes", maxBytes))
+    }
+
+    // check the character class
+    var upper, lower, digit, symbol bool
+    for _, r := range password {
+        switch {
+        case unicode.IsUpper(r) : upper = true
+        case unicode.IsLower(r) : lower = true
+        case unicode.IsDigit(r) : digit = true
+        case !unicode.IsLetter(r) : symbol = true
+        }
+    }
+    if policy.RequireUppercase && !upper {
+        fail("uppercase", "must contain uppercase letter")
+    }
+    if policy.RequireLowercase && !lower {
+        fail("lowercase", "must contain lowercase letter")
+    }
+    if policy.RequireDigit && !digit {
+        fail("digit", "must contain digit")
+    }
+    if policy.RequireSymbol && !symbol {
+        fail("symbol", "must contain symbol")
+    }
+
+    // check the username and email
+    if policy.RejectUserInfo && containUserInfo(password, userInfo...) {
+        fail("user_info", "must not contain the username or email")
+    }
+
+    // check the breached password list
+    if policy.BreachedListFile != "" && isBreachedPassword(policy.BreachedListFile, password) {
+        fail("breached", "is too common or known to be breached")
+    }
+
+    // check the previous password
+    for _, passKey := range history {
+        if checkPassHashFunc(password, passKey) {
+            fail("history", fmt.Sprintf("must not be one of the last %d password", policy.HistoryCount))
+            break
+        }
+    }
+
+    if len(fails) > 0 {
+        return E.NewExt(E.ErrPasswordPolicy, fails)
+    }
+
+    return nil
+}
+
+// containUserInfo will check whether the password contain the username or the email,
+// the email is checked as whole and by its local part. the check is case insensitive
+func containUserInfo(password string, userInfo ...string) bool {
+    password = strings.ToLower(password)
+    for _, info := range userInfo {
+        info = strings.ToLower(info)
+        parts := []string{info}
+        if at := strings.LastIndex(info, "@"); at > 0 {
+            parts = append(parts, info[:at])
+        }
+
+        for _, part := range parts {
+            if utf8.RuneCountInString(part) >= minimumUserInfoLength && strings.Contains(password, part) {
+                return true
+            }
+        }
+    }
+
+    return false
+}
+
+// isBreachedPassword will check whether the password is listed on the breached password list
+// file, the check is case insensitive. while the list can not be loaded the rule is skipped
+func isBreachedPassword(path, password string) bool {
+    list := getBreachedList(path)
+    if list.err != nil {
+        return false
+    }
+
+    _, found := list.passwords[strings.ToLower(password)]
+    return found
+}
+
+// getBreachedList will get the loaded breached password list, the list is loaded on its first
+// use. list that fail to load is logged and loaded again after breachedListRetryInterval
+func getBreachedList(path string) *breachedList {
+    breachedListsMu.RLock()
+    list, ok := breachedLists[path]
+    breachedListsMu.RUnlock()
+    if ok && list.usable() {
+        return list
+    }
+
+    breachedListsMu.Lock()
+    defer breachedListsMu.Unlock()
+
+    // the list may be loaded by other check while waiting for the lock
+    if list, ok := breachedLists[path]; ok && list.usable() {
+        return list
+    }
+
+    passwords, err := loadBreachedList(path)
+    if err != nil {
+        logger.Errorf("load breached password list %s fail: %v", path, err)
+    }
+    list = &breachedList{passwords: passwords, err: err, loadedAt: timeNowFunc()}
+    breachedLists[path] = list
+
+    return list
+}
+
+// loadBreachedList will read the breached password list file, one password per line.
+// empty line and line starting with '#' is ignored
+func loadBreachedList(path string) (map[string]struct{}, error) {
+    file, err := os.Open(path)
+    if err != nil {
+        return nil, err
+    }
+    defer file.Close()
+
+    list := map[string]struct{}{}
+    scanner := bufio.NewScanner(file)
+    for scanner.Scan() {
+        line := strings.TrimSpace(scanner.Text())
+        if line == "" || strings.HasPrefix(line, "#") {
+            continue
+        }
+        list[strings.ToLower(line)] = struct{}{}
+    }
+
+    return list, scanner.Err()
+}
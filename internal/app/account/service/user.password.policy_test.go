@@ -0,0 +1,184 @@
+/*
+    package service
+    user.password.policy_test.go
+    - test unit for password policy
+*/
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/reshimahendra/lbw-go/internal/config"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockPasswordPolicy will mock the password policy and return func to restore it
+func mockPasswordPolicy(policy config.PasswordPolicy) func() {
+    passwordPolicyF := passwordPolicyFunc
+    passwordPolicyFunc = func() config.PasswordPolicy {
+        return policy
+    }
+
+    return func() { passwordPolicyFunc = passwordPolicyF }
+}
+
+// TestPasswordPolicy will test the password policy fallback length
+func TestPasswordPolicy(t *testing.T) {
+    cfg := config.Get()
+    if cfg == nil {
+        if err := config.Setup(); err != nil {
+            t.Fatalf("unexpected error occur: %v", err)
+        }
+        cfg = config.Get()
+    }
+    policy := cfg.Account.PasswordPolicy
+    defer func() { cfg.Account.PasswordPolicy = policy }()
+
+    cfg.Account.PasswordPolicy = config.PasswordPolicy{RequireDigit: true}
+    assert.Equal(t, config.PasswordPolicy{MinimumLength: 8, MaximumLength: 72, RequireDigit: true}, passwordPolicy())
+
+    cfg.Account.PasswordPolicy = config.PasswordPolicy{MinimumLength: 12, MaximumLength: 64}
+    assert.Equal(t, config.PasswordPolicy{MinimumLength: 12, MaximumLength: 64}, passwordPolicy())
+}
+
+// TestCheckPasswordPolicy will test checking password against the password policy
+func TestCheckPasswordPolicy(t *testing.T) {
+    // mock hash check to speed up the test
+    checkPassHash := checkPassHashFunc
+    checkPassHashFunc = func(password, hash string) bool {
+        return hash == "hashed:"+password
+    }
+    defer func() { checkPassHashFunc = checkPassHash }()
+
+    // the byte limit of the hasher is checked on its own test
+    passMaxBytes := passMaxBytesFunc
+    passMaxBytesFunc = func() int { return 0 }
+    defer func() { passMaxBytesFunc = passMaxBytes }()
+
+    // breached password list file
+    breached := filepath.Join(t.TempDir(), "breached.txt")
+    if err := os.WriteFile(breached, []byte("# common password\n\nPassword1!\nqwerty123\n"), 0600); err != nil {
+        t.Fatalf("unexpected error occur: %v", err)
+    }
+
+    strict := config.PasswordPolicy{
+        MinimumLength    : 8,
+        MaximumLength    : 16,
+        RequireUppercase : true,
+        RequireLowercase : true,
+        RequireDigit     : true,
+        RequireSymbol    : true,
+        RejectUserInfo   : true,
+        BreachedListFile : breached,
+        HistoryCount     : 3,
+    }
+    fail := func(rule, message string) E.FieldError {
+        return E.FieldError{Field: "passkey", Rule: rule, Message: message}
+    }
+
+    cases := []struct{
+        name     string
+        policy   config.PasswordPolicy
+        password string
+        want     E.FieldErrors
+    }{
+        {"EXPECT SUCCESS", strict, "Lotus-blue-42", nil},
+        {"EXPECT SUCCESS rule disabled", config.PasswordPolicy{MinimumLength: 8, MaximumLength: 72}, "password", nil},
+        {"EXPECT SUCCESS breached list can not be loaded", config.PasswordPolicy{MinimumLength: 8, MaximumLength: 72,
+            BreachedListFile: filepath.Join(t.TempDir(), "unknown.txt")}, "qwerty123", nil},
+        {"EXPECT FAIL too short", strict, "Ab1-", E.FieldErrors{fail("min", "must be at least 8 characters")}},
+        {"EXPECT FAIL too long", strict, "Lotus-blue-42-lotus", E.FieldErrors{fail("max", "must be at most 16 characters")}},
+        {"EXPECT FAIL character class", strict, "lotusblue", E.FieldErrors{
+            fail("uppercase", "must contain uppercase letter"),
+            fail("digit", "must contain digit"),
+            fail("symbol", "must contain symbol"),
+        }},
+        {"EXPECT FAIL lowercase", strict, "LOTUS-BLUE-42", E.FieldErrors{fail("lowercase", "must contain lowercase letter")}},
+        {"EXPECT FAIL username", strict, "Leonard-42", E.FieldErrors{fail("user_info", "must not contain the username or email")}},
+        {"EXPECT FAIL email", strict, "Leo@gmail.com-1", E.FieldErrors{fail("user_info", "must not contain the username or email")}},
+        {"EXPECT FAIL breached", strict, "PASSword1!", E.FieldErrors{fail("breached", "is too common or known to be breached")}},
+        {"EXPECT FAIL history", strict, "Previous-1", E.FieldErrors{fail("history", "must not be one of the last 3 password")}},
+    }
+
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            err := checkPasswordPolicy(tt.policy, "passkey", tt.password, []string{"hashed:Current-1", "hashed:Previous-1"}, "leonard", "leo@gmail.com")
+
+            if tt.want == nil {
+                assert.NoError(t, err)
+                return
+            }
+            assert.Equal(t, E.NewExt(E.ErrPasswordPolicy, tt.want), err)
+        })
+    }
+
+    // EXPECT FAIL multi byte password longer than the byte limit of bcrypt
+    t.Run("EXPECT FAIL too long in byte", func(t *testing.T){
+        passMaxBytesFunc = func() int { return 72 }
+        defer func() { passMaxBytesFunc = func() int { return 0 } }()
+        policy := config.PasswordPolicy{MinimumLength: 8, MaximumLength: 72}
+
+        // 40 characters of 2 byte
+        err := checkPasswordPolicy(policy, "passkey", strings.Repeat("é", 40), nil)
+        assert.Equal(t, E.NewExt(E.ErrPasswordPolicy, E.FieldErrors{fail("max_bytes", "must be at most 72 bytes")}), err)
+
+        // too many character is only reported once
+        err = checkPasswordPolicy(policy, "passkey", strings.Repeat("a", 73), nil)
+        assert.Equal(t, E.NewExt(E.ErrPasswordPolicy, E.FieldErrors{fail("max", "must be at most 72 characters")}), err)
+
+        assert.NoError(t, checkPasswordPolicy(policy, "passkey", strings.Repeat("é", 36), nil))
+    })
+}
+
+// TestIsBreachedPassword will test loading the breached password list once and
+// retrying the list that fail to load
+func TestIsBreachedPassword(t *testing.T) {
+    now := mockTimeNow(t)
+    path := filepath.Join(t.TempDir(), "breached.txt")
+
+    // EXPECT SUCCESS list that can not be loaded skip the rule and is not loaded on every check
+    assert.False(t, isBreachedPassword(path, "qwerty123"))
+    if err := os.WriteFile(path, []byte("qwerty123\n"), 0600); err != nil {
+        t.Fatalf("unexpected error occur: %v", err)
+    }
+    assert.False(t, isBreachedPassword(path, "qwerty123"))
+    assert.Error(t, breachedLists[path].err)
+
+    // EXPECT SUCCESS loading the list is retried after the retry interval
+    *now = now.Add(breachedListRetryInterval)
+    assert.True(t, isBreachedPassword(path, "QWERTY123"))
+
+    // EXPECT SUCCESS loaded list is kept
+    if err := os.Remove(path); err != nil {
+        t.Fatalf("unexpected error occur: %v", err)
+    }
+    *now = now.Add(time.Hour)
+    assert.True(t, isBreachedPassword(path, "qwerty123"))
+    assert.False(t, isBreachedPassword(path, "lotus-blue-42"))
+}
+
+// TestContainUserInfo will test checking username and email on the password
+func TestContainUserInfo(t *testing.T) {
+    cases := []struct{
+        name     string
+        password string
+        want     bool
+    }{
+        {"EXPECT SUCCESS username", "my-LEONARD-pass", true},
+        {"EXPECT SUCCESS email", "xleonard.s@gmail.comx", true},
+        {"EXPECT SUCCESS email local part", "leonard.s-2022", true},
+        {"EXPECT SUCCESS short part is ignored", "jo-secret-jo", false},
+        {"EXPECT SUCCESS not contained", "lotus-blue-42", false},
+    }
+
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            assert.Equal(t, tt.want, containUserInfo(tt.password, "leonard", "leonard.s@gmail.com", "jo"))
+        })
+    }
+}
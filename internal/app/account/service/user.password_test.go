@@ -0,0 +1,376 @@
+/*
+    package service
+    user.password_test.go
+    - test unit for user.password service
+*/
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/reshimahendra/lbw-go/internal/config"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	"github.com/reshimahendra/lbw-go/internal/pkg/auth"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/helper"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockUserPasswordService is mocked user.password datastore
+type mockUserPasswordService struct {
+    t *testing.T
+    created []d.UserPasswordReset
+    passKey string
+    revokedBefore time.Time
+    currentPassKey string
+    keepFamilyID string
+    changeErr bool
+    history []string
+}
+
+// NewMockUserPasswordService is new instance of mockUserPasswordService
+func NewMockUserPasswordService(t *testing.T) *mockUserPasswordService{
+    return &mockUserPasswordService{t: t}
+}
+
+// Create is mocked Create method to satisfy IUserPasswordStore interface
+func (m *mockUserPasswordService) Create(input d.UserPasswordReset) error {
+    if wantErr {
+        return E.New(E.ErrDatabase)
+    }
+    m.created = append(m.created, input)
+
+    return nil
+}
+
+// Reset is mocked Reset method to satisfy IUserPasswordStore interface
+func (m *mockUserPasswordService) Reset(tokenHash, passKey string, revokedBefore time.Time) (*d.User, error) {
+    if wantErr {
+        return nil, E.New(E.ErrDatabase)
+    }
+    for _, r := range m.created {
+        if r.TokenHash == tokenHash {
+            m.passKey, m.revokedBefore = passKey, revokedBefore
+            return u[0], nil
+        }
+    }
+
+    return nil, E.New(E.ErrDataIsEmpty)
+}
+
+// GetPassKey is mocked GetPassKey method to satisfy IUserPasswordStore interface
+func (m *mockUserPasswordService) GetPassKey(userID uuid.UUID) (string, error) {
+    if wantErr {
+        return "", E.New(E.ErrDatabase)
+    }
+    if userID != u[0].ID {
+        return "", E.New(E.ErrDataIsEmpty)
+    }
+
+    return m.currentPassKey, nil
+}
+
+// Change is mocked Change method to satisfy IUserPasswordStore interface
+func (m *mockUserPasswordService) Change(userID uuid.UUID, passKey, keepFamilyID string) error {
+    if m.changeErr {
+        return E.New(E.ErrDatabase)
+    }
+    m.passKey, m.keepFamilyID = passKey, keepFamilyID
+
+    return nil
+}
+
+// GetByToken is mocked GetByToken method to satisfy IUserPasswordStore interface
+func (m *mockUserPasswordService) GetByToken(tokenHash string) (*d.User, error) {
+    if wantErr {
+        return nil, E.New(E.ErrDatabase)
+    }
+    for _, r := range m.created {
+        if r.TokenHash == tokenHash {
+            return u[0], nil
+        }
+    }
+
+    return nil, E.New(E.ErrDataIsEmpty)
+}
+
+// GetPassKeyHistory is mocked GetPassKeyHistory method to satisfy IUserPasswordStore interface
+func (m *mockUserPasswordService) GetPassKeyHistory(userID uuid.UUID, limit int) ([]string, error) {
+    if m.changeErr {
+        return nil, E.New(E.ErrDatabase)
+    }
+    if len(m.history) > limit {
+        return m.history[:limit], nil
+    }
+
+    return m.history, nil
+}
+
+// TestUserPasswordServiceForgot will test Forgot method of user.password service
+func TestUserPasswordServiceForgot(t *testing.T) {
+    // prepare config for password reset setting
+    if err := config.Setup(); err != nil {
+        t.Fatalf("unexpected error occur: %v", err)
+    }
+
+    active := &d.UserCredential{ID: u[0].ID, Username: u[0].Username, StatusID: 1}
+    banned := &d.UserCredential{ID: u[1].ID, Username: u[1].Username, StatusID: 3}
+
+    cases := []struct{
+        name, email string
+        cred *d.UserCredential
+        storeErr error
+        createErr bool
+        mailErr error
+        wantErr error
+        wantMail bool
+    }{
+        {"EXPECT SUCCESS", u[0].Email, active, nil, false, nil, nil, true},
+        {"EXPECT SUCCESS user not registered", "nobody@gmail.com", nil, E.New(E.ErrDataIsEmpty), false, nil, nil, false},
+        {"EXPECT SUCCESS user banned", u[1].Email, banned, nil, false, nil, nil, false},
+        {"EXPECT SUCCESS datastore error is hidden", u[0].Email, active, nil, true, nil, nil, false},
+        {"EXPECT SUCCESS mailer error is hidden", u[0].Email, active, nil, false, E.New(E.ErrDatabase), nil, false},
+        {"EXPECT FAIL email invalid", "invalid-email", nil, nil, false, nil, E.New(E.ErrEmailIsInvalid), false},
+    }
+
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            store, mail := NewMockUserPasswordService(t), &mockMailer{err: tt.mailErr}
+            userStore := &mockEmailUserStore{NewMockUserService(t), tt.cred, tt.storeErr}
+            service := NewUserPasswordService(store, userStore, mail)
+
+            // actual method call
+            wantErr = tt.createErr
+            err := service.Forgot(tt.email)
+            wantErr = false
+
+            // test verification and validation
+            assert.Equal(t, tt.wantErr, err)
+            if tt.wantMail {
+                assert.Equal(t, tt.email, mail.to)
+                assert.Contains(t, mail.body, config.Get().Account.PasswordResetURL+"?token=")
+                assert.Equal(t, helper.HashToken(sentToken(mail.body)), store.created[0].TokenHash)
+                assert.Equal(t, tt.cred.ID, store.created[0].UserID)
+            } else {
+                assert.Equal(t, "", mail.to)
+            }
+        })
+    }
+}
+
+// TestUserPasswordServiceReset will test Reset method of user.password service
+func TestUserPasswordServiceReset(t *testing.T) {
+    // prepare config for password reset setting
+    if err := config.Setup(); err != nil {
+        t.Fatalf("unexpected error occur: %v", err)
+    }
+
+    // issue password reset token
+    store, mail := NewMockUserPasswordService(t), new(mockMailer)
+    userStore := &mockEmailUserStore{NewMockUserService(t), &d.UserCredential{ID: u[0].ID, StatusID: 1}, nil}
+    service := NewUserPasswordService(store, userStore, mail)
+    assert.NoError(t, service.Forgot(u[0].Email))
+    token := sentToken(mail.body)
+    defer mockPasswordPolicy(config.PasswordPolicy{MinimumLength: 8, MaximumLength: 72})()
+
+    // EXPECT SUCCESS new password is hashed and outstanding token revoked
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        now := time.Date(2022, 2, 3, 11, 30, 15, 700000000, time.FixedZone("WITA", 8*3600))
+        timeNow := timeNowFunc
+        timeNowFunc = func() time.Time { return now }
+        defer func() { timeNowFunc = timeNow }()
+
+        // actual method call
+        got, err := service.Reset(d.PasswordResetRequest{Token: token, PassKey: "new-secret"})
+
+        // test verification and validation
+        assert.NoError(t, err)
+        assert.Equal(t, u[0].ID, got)
+        assert.True(t, auth.VerifyPassword("new-secret", store.passKey))
+        assert.Equal(t, time.Date(2022, 2, 3, 3, 30, 15, 0, time.UTC), store.revokedBefore)
+    })
+
+    // EXPECT FAIL token empty
+    t.Run("EXPECT FAIL token empty", func(t *testing.T){
+        _, err := service.Reset(d.PasswordResetRequest{PassKey: "new-secret"})
+
+        assert.Error(t, err)
+        assert.Equal(t, E.New(E.ErrPasswordResetTokenInvalid), err)
+    })
+
+    // EXPECT FAIL password too short
+    t.Run("EXPECT FAIL password too short", func(t *testing.T){
+        _, err := service.Reset(d.PasswordResetRequest{Token: token, PassKey: "short"})
+
+        assert.Error(t, err)
+        assert.Equal(t, E.NewExt(E.ErrPasswordPolicy, E.FieldErrors{
+            {Field: "passkey", Rule: "min", Message: "must be at least 8 characters"},
+        }), err)
+    })
+
+    // EXPECT FAIL password policy. Simulated by reusing previous password containing the username
+    t.Run("EXPECT FAIL password policy", func(t *testing.T){
+        checkPassHash := checkPassHashFunc
+        checkPassHashFunc = func(password, hash string) bool {
+            return hash == "hashed:"+password
+        }
+        defer func() { checkPassHashFunc = checkPassHash }()
+        store.history = []string{"hashed:current", "hashed:leonard-secret"}
+        defer func() { store.history = nil }()
+        defer mockPasswordPolicy(config.PasswordPolicy{MinimumLength: 8, MaximumLength: 72, RejectUserInfo: true, HistoryCount: 2})()
+
+        _, err := service.Reset(d.PasswordResetRequest{Token: token, PassKey: "leonard-secret"})
+
+        assert.Equal(t, E.NewExt(E.ErrPasswordPolicy, E.FieldErrors{
+            {Field: "passkey", Rule: "user_info", Message: "must not contain the username or email"},
+            {Field: "passkey", Rule: "history", Message: "must not be one of the last 2 password"},
+        }), err)
+    })
+
+    // EXPECT FAIL get passkey history error. Simulated by setting changeErr=true
+    t.Run("EXPECT FAIL get passkey history error", func(t *testing.T){
+        store.changeErr = true
+        defer func() { store.changeErr = false }()
+        defer mockPasswordPolicy(config.PasswordPolicy{MinimumLength: 8, MaximumLength: 72, HistoryCount: 2})()
+
+        _, err := service.Reset(d.PasswordResetRequest{Token: token, PassKey: "new-secret"})
+
+        assert.Equal(t, E.New(E.ErrDatabase), err)
+    })
+
+    // EXPECT FAIL hash password error. Simulated by mocking auth.HashPassword
+    t.Run("EXPECT FAIL hash password error", func(t *testing.T){
+        generateHashPass := generateHashPassFunc
+        generateHashPassFunc = func(password string) (string, error) {
+            return "", E.New(E.ErrDataIsInvalid)
+        }
+        defer func() { generateHashPassFunc = generateHashPass }()
+
+        _, err := service.Reset(d.PasswordResetRequest{Token: token, PassKey: "new-secret"})
+
+        assert.Error(t, err)
+    })
+
+    // mock hash func for the rest of the test to speed up the test
+    generateHashPass := generateHashPassFunc
+    generateHashPassFunc = func(password string) (string, error) {
+        return "hashed", nil
+    }
+    defer func() { generateHashPassFunc = generateHashPass }()
+
+    // EXPECT FAIL token invalid. Simulated by giving unknown token
+    t.Run("EXPECT FAIL token invalid", func(t *testing.T){
+        _, err := service.Reset(d.PasswordResetRequest{Token: "unknown-token", PassKey: "new-secret"})
+
+        assert.Error(t, err)
+        assert.Equal(t, E.New(E.ErrPasswordResetTokenInvalid), err)
+    })
+
+    // EXPECT FAIL datastore error. Simulated by setting wantErr=true
+    t.Run("EXPECT FAIL datastore error", func(t *testing.T){
+        wantErr = true
+        _, err := service.Reset(d.PasswordResetRequest{Token: token, PassKey: "new-secret"})
+        wantErr = false
+
+        assert.Error(t, err)
+        assert.Equal(t, E.New(E.ErrDatabase), err)
+    })
+}
+
+// TestUserPasswordServiceChange will test Change method of user.password service
+func TestUserPasswordServiceChange(t *testing.T) {
+    current, err := auth.HashPassword("old-secret")
+    if err != nil {
+        t.Fatalf("unexpected error occur: %v", err)
+    }
+    principal := d.Principal{UserID: u[0].ID, FamilyID: uuid.NewString()}
+    req := d.PasswordChangeRequest{CurrentPassword: "old-secret", NewPassword: "new-secret"}
+    defer mockPasswordPolicy(config.PasswordPolicy{MinimumLength: 8, MaximumLength: 72})()
+
+    // EXPECT SUCCESS new password is hashed and the current session is kept
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        store := &mockUserPasswordService{t: t, currentPassKey: current}
+        service := NewUserPasswordService(store, nil, nil)
+
+        // actual method call
+        err := service.Change(principal, req)
+
+        // test verification and validation
+        assert.NoError(t, err)
+        assert.True(t, auth.VerifyPassword("new-secret", store.passKey))
+        assert.Equal(t, principal.FamilyID, store.keepFamilyID)
+    })
+
+    cases := []struct{
+        name      string
+        principal d.Principal
+        input     d.PasswordChangeRequest
+        storeErr  bool
+        changeErr bool
+        wantErr   error
+    }{
+        {"EXPECT FAIL data invalid", principal, d.PasswordChangeRequest{NewPassword: "new-secret"}, false, false, E.New(E.ErrDataIsInvalid)},
+        {"EXPECT FAIL password too short", principal, d.PasswordChangeRequest{CurrentPassword: "old-secret", NewPassword: "short"}, false, false,
+            E.NewExt(E.ErrPasswordPolicy, E.FieldErrors{{Field: "new_password", Rule: "min", Message: "must be at least 8 characters"}})},
+        {"EXPECT FAIL current password not match", principal, d.PasswordChangeRequest{CurrentPassword: "wrong-secret", NewPassword: "new-secret"}, false, false, E.New(E.ErrPasswordNotMatch)},
+        {"EXPECT FAIL user not found", d.Principal{UserID: u[1].ID}, req, false, false, E.New(E.ErrDataIsEmpty)},
+        {"EXPECT FAIL get passkey error", principal, req, true, false, E.New(E.ErrDatabase)},
+        {"EXPECT FAIL change error", principal, req, false, true, E.New(E.ErrDatabase)},
+    }
+
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            store := &mockUserPasswordService{t: t, currentPassKey: current, changeErr: tt.changeErr}
+            service := NewUserPasswordService(store, nil, nil)
+
+            // actual method call
+            wantErr = tt.storeErr
+            err := service.Change(tt.principal, tt.input)
+            wantErr = false
+
+            // test verification and validation
+            assert.Equal(t, tt.wantErr, err)
+            assert.Empty(t, store.passKey)
+        })
+    }
+
+    // EXPECT FAIL password policy. Simulated by reusing the current password containing the username
+    t.Run("EXPECT FAIL password policy", func(t *testing.T){
+        defer mockPasswordPolicy(config.PasswordPolicy{MinimumLength: 8, MaximumLength: 72, RejectUserInfo: true, HistoryCount: 3})()
+
+        store := &mockUserPasswordService{t: t, currentPassKey: current, history: []string{current}}
+        userStore := &mockEmailUserStore{NewMockUserService(t), nil, nil}
+        err := NewUserPasswordService(store, userStore, nil).Change(principal,
+            d.PasswordChangeRequest{CurrentPassword: "old-secret", NewPassword: "old-secret"})
+
+        assert.Equal(t, E.NewExt(E.ErrPasswordPolicy, E.FieldErrors{
+            {Field: "new_password", Rule: "history", Message: "must not be one of the last 3 password"},
+        }), err)
+        assert.Empty(t, store.passKey)
+
+        err = NewUserPasswordService(store, userStore, nil).Change(principal,
+            d.PasswordChangeRequest{CurrentPassword: "old-secret", NewPassword: "LEONARD-new"})
+
+        assert.Equal(t, E.NewExt(E.ErrPasswordPolicy, E.FieldErrors{
+            {Field: "new_password", Rule: "user_info", Message: "must not contain the username or email"},
+        }), err)
+        assert.Empty(t, store.passKey)
+    })
+
+    // EXPECT FAIL hash password error. Simulated by mocking auth.HashPassword
+    t.Run("EXPECT FAIL hash password error", func(t *testing.T){
+        generateHashPass := generateHashPassFunc
+        generateHashPassFunc = func(password string) (string, error) {
+            return "", E.New(E.ErrDataIsInvalid)
+        }
+        defer func() { generateHashPassFunc = generateHashPass }()
+
+        store := &mockUserPasswordService{t: t, currentPassKey: current}
+        err := NewUserPasswordService(store, nil, nil).Change(principal, req)
+
+        assert.Error(t, err)
+        assert.Empty(t, store.passKey)
+    })
+}
@@ -24,15 +24,25 @@ type IUserRoleService interface {
     // given id and expect to get UserRoleResponse from the operation
     Get(id int) (*domain.UserRoleResponse, error)
 
-    // Gets will make request to datastore to retreive all user.role data
-    Gets() ([]*domain.UserRoleResponse, error)
+    // Gets will make request to datastore to retreive user.role data matching
+    // the list query along with the list metadata
+    Gets(q domain.ListQuery) ([]*domain.UserRoleResponse, *domain.ListMeta, error)
 
     // Update will make request to datastore to update certain record based on its ID
     // with the given new user.role value
     Update(id int, input domain.UserRoleRequest) (*domain.UserRoleResponse, error)
 
+    // Patch will make request to datastore to apply merge patch to certain record based on its ID
+    Patch(id int, patch domain.MergePatch) (*domain.UserRoleResponse, error)
+
     // Delete will make request to datastore to do (soft) delete to give user.role id record
     Delete(id int) (*domain.UserRoleResponse, error)
+
+    // Restore will make request to datastore to restore (soft) deleted user.role record
+    Restore(id int) (*domain.UserRoleResponse, error)
+
+    // Purge will make request to datastore to permanently remove (soft) deleted user.role record
+    Purge(id int) error
 }
 
 
@@ -77,11 +87,11 @@ func (s *UserRoleService) Get(id int) (*domain.UserRoleResponse, error) {
 
 // Gets is service layer to send request to datastore to retreive all user.role record in
 // user.role response (dto) format
-func (s *UserRoleService) Gets() ([]*domain.UserRoleResponse, error) {
-    // send request to datastore to retreive data with id as requested
-    result, err := s.Store.Gets()
+func (s *UserRoleService) Gets(q domain.ListQuery) ([]*domain.UserRoleResponse, *domain.ListMeta, error) {
+    // send request to datastore to retreive data matching the list query
+    result, meta, err := s.Store.Gets(q)
     if err != nil {
-        return nil, err
+        return nil, nil, err
     }
 
     // convert user.role slice into user.role response dto slice
@@ -91,7 +101,7 @@ func (s *UserRoleService) Gets() ([]*domain.UserRoleResponse, error) {
     }
 
     // return the user.role create operation result (response) to handler/controller layer
-    return urRes, nil
+    return urRes, meta, nil
 }
 
 // Update is service layer to send request to datastore to update certain record based on its id
@@ -105,6 +115,17 @@ func (s *UserRoleService) Update(id int, input domain.UserRoleRequest) (*domain.
     return result.ConvertToResponse(), nil
 }
 
+// Patch is service layer to send request to datastore to apply merge patch to certain record based on its id
+func (s *UserRoleService) Patch(id int, patch domain.MergePatch) (*domain.UserRoleResponse, error) {
+    // send request to datastore to update only the patched field
+    result, err := s.Store.Patch(id, patch)
+    if err != nil {
+        return nil, err
+    }
+
+    return result.ConvertToResponse(), nil
+}
+
 // Delete is service layer to send request to datastore to (soft) delete certain record based on its id
 func (s *UserRoleService) Delete(id int) (*domain.UserRoleResponse, error) {
     // send request to datastore to do delete on certain record
@@ -115,3 +136,20 @@ func (s *UserRoleService) Delete(id int) (*domain.UserRoleResponse, error) {
 
     return result.ConvertToResponse(), nil
 }
+
+// Restore is service layer to send request to datastore to restore (soft) deleted record based on its id
+func (s *UserRoleService) Restore(id int) (*domain.UserRoleResponse, error) {
+    // send request to datastore to restore certain record
+    result, err := s.Store.Restore(id)
+    if err != nil {
+        return nil, err
+    }
+
+    return result.ConvertToResponse(), nil
+}
+
+// Purge is service layer to send request to datastore to permanently remove (soft) deleted
+// record based on its id
+func (s *UserRoleService) Purge(id int) error {
+    return s.Store.Purge(id)
+}
@@ -64,12 +64,12 @@ func (s *mockUserRoleService) Get(id int) (*d.UserRole, error) {
 }
 
 // Gets is mocked Gets method to satisfy IUserRoleStore interface
-func (s *mockUserRoleService) Gets() ([]*d.UserRole, error) {
+func (s *mockUserRoleService) Gets(q d.ListQuery) ([]*d.UserRole, *d.ListMeta, error) {
     if wantErr {
-        return nil, E.New(E.ErrDataIsEmpty)
+        return nil, nil, E.New(E.ErrDataIsEmpty)
     }
 
-    return ur, nil
+    return ur, &d.ListMeta{Total: int64(len(ur)), Page: q.Page, Limit: q.Limit, Sort: q.Sort, Order: q.Order}, nil
 }
 
 // Update is mocked Update method to satisfy IUserRoleStore interface
@@ -81,6 +81,20 @@ func (s *mockUserRoleService) Update(id int, input d.UserRole) (*d.UserRole, err
     return ur[id], nil
 }
 
+// Patch is mocked Patch method to satisfy IUserRoleStore interface
+func (s *mockUserRoleService) Patch(id int, patch d.MergePatch) (*d.UserRole, error) {
+    if wantErr {
+        return nil, E.New(E.ErrDatabase)
+    }
+
+    patched := *ur[id]
+    if _, err := patched.ApplyPatch(patch); err != nil {
+        return nil, E.New(E.ErrDataIsInvalid)
+    }
+
+    return &patched, nil
+}
+
 // Delete is mocked Delete method to satisfy IUserRoleStore interface
 func (s *mockUserRoleService) Delete(id int) (*d.UserRole, error) {
     if wantErr {
@@ -90,6 +104,33 @@ func (s *mockUserRoleService) Delete(id int) (*d.UserRole, error) {
     return ur[id], nil
 }
 
+// Restore is mocked Restore method to satisfy IUserRoleStore interface
+func (s *mockUserRoleService) Restore(id int) (*d.UserRole, error) {
+    if wantErr {
+        return nil, E.New(E.ErrDataAlreadyExist)
+    }
+
+    return ur[id], nil
+}
+
+// Purge is mocked Purge method to satisfy IUserRoleStore interface
+func (s *mockUserRoleService) Purge(id int) error {
+    if wantErr {
+        return E.New(E.ErrDataIsInUse)
+    }
+
+    return nil
+}
+
+// PurgeDeleted is mocked PurgeDeleted method to satisfy IUserRoleStore interface
+func (s *mockUserRoleService) PurgeDeleted(retentionDays int) (int64, error) {
+    if wantErr {
+        return 0, E.New(E.ErrDatabase)
+    }
+
+    return 1, nil
+}
+
 // TestUserRoleServiceCreate will test "Create" method for user.role service
 func TestUserRoleServiceCreate(t *testing.T) {
     // prepare mock and service
@@ -101,7 +142,7 @@ func TestUserRoleServiceCreate(t *testing.T) {
     t.Run("EXPECT SUCCESS", func(t *testing.T){
         var urReq = new(d.UserRoleRequest)
         urReq.RoleName = ur[0].RoleName
-        urReq.Description = ur[0].Description
+        urReq.Description = string(ur[0].Description)
 
         got, err := service.Create(*urReq)
 
@@ -113,7 +154,7 @@ func TestUserRoleServiceCreate(t *testing.T) {
     // error return is ErrDataIsInvalid. Simulation done by removing 'role_name' field
     t.Run("EXPECT FAIL invalid data", func (t *testing.T) {
         var urReq = new(d.UserRoleRequest)
-        urReq.Description = ur[0].Description
+        urReq.Description = string(ur[0].Description)
 
         got, err := service.Create(*urReq)
 
@@ -184,12 +225,13 @@ func TestUserRoleServiceGets(t *testing.T) {
     // prepare mock and service
     mock := NewMockUserRoleService(t)
     service := NewUserRoleService(mock)
+    q := d.ListQuery{Page: 1, Limit: d.ListDefaultLimit, Sort: "id", Order: d.SortAsc}
 
     // EXPECT SUCCESS will simulated normal operation with no error return
     // this simulation expect all process goes as expected
     t.Run("EXPECT SUCCESS", func(t *testing.T){
         // actual method call (method to test)
-        got, err := service.Gets()
+        got, meta, err := service.Gets(q)
 
         var want []*d.UserRoleResponse
         for _, urRes := range ur {
@@ -198,6 +240,7 @@ func TestUserRoleServiceGets(t *testing.T) {
 
         assert.NoError(t, err)
         assert.Equal(t, want, got)
+        assert.Equal(t, int64(len(ur)), meta.Total)
     })
 
     // EXPECT FAIL data is empty error will simulated fail getting record
@@ -208,11 +251,12 @@ func TestUserRoleServiceGets(t *testing.T) {
         wantErr = true
 
         // actual method call (method to test)
-        got, err := service.Gets()
+        got, meta, err := service.Gets(q)
 
         // test validation and verification
         assert.Error(t, err)
         assert.Nil(t, got)
+        assert.Nil(t, meta)
 
         // set 'wantErr' value back to default so another test not affected
         wantErr = false
@@ -230,7 +274,7 @@ func TestUserRoleServiceUpdate(t *testing.T) {
         // prepare new UserRoleRequest instance
         var urReq = new(d.UserRoleRequest)
         urReq.RoleName = ur[0].RoleName
-        urReq.Description = ur[0].Description
+        urReq.Description = string(ur[0].Description)
 
         // actual method call (tested method)
         got, err := store.Update(ur[0].ID, *urReq)
@@ -261,6 +305,31 @@ func TestUserRoleServiceUpdate(t *testing.T) {
     })
 }
 
+// TestUserRoleServicePatch will test "Patch" method for user.role service
+func TestUserRoleServicePatch(t *testing.T) {
+    mock := NewMockUserRoleService(t)
+    service := NewUserRoleService(mock)
+
+    // EXPECT SUCCESS only the patched field is changed
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        got, err := service.Patch(ur[0].ID, d.MergePatch{"description": []byte(`"Visitor role"`)})
+
+        assert.NoError(t, err)
+        assert.Equal(t, ur[0].RoleName, got.RoleName)
+        assert.Equal(t, "Visitor role", got.Description)
+    })
+
+    // EXPECT FAIL database error. Simulated by triggering 'wantErr'
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        wantErr = true
+        got, err := service.Patch(ur[0].ID, d.MergePatch{"description": []byte(`"Visitor role"`)})
+        wantErr = false
+
+        assert.Error(t, err)
+        assert.Nil(t, got)
+    })
+}
+
 // TestUserRoleServiceDelete will test behaviour of Delete method for the user.role service
 func TestUserRoleServiceDelete(t *testing.T) {
     mock := NewMockUserRoleService(t)
@@ -294,3 +363,45 @@ func TestUserRoleServiceDelete(t *testing.T) {
         wantErr = false
     })
 }
+
+// TestUserRoleServiceRestore will test behaviour of Restore method for the user.role service
+func TestUserRoleServiceRestore(t *testing.T) {
+    mock := NewMockUserRoleService(t)
+    store := NewUserRoleService(mock)
+
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        got, err := store.Restore(ur[2].ID)
+
+        assert.NoError(t, err)
+        assert.Equal(t, ur[2].ConvertToResponse(), got)
+    })
+
+    t.Run("EXPECT FAIL role name is taken", func (t *testing.T) {
+        wantErr = true
+        got, err := store.Restore(ur[2].ID)
+        wantErr = false
+
+        assert.Error(t, err)
+        assert.Nil(t, got)
+    })
+}
+
+// TestUserRoleServicePurge will test behaviour of Purge method for the user.role service
+func TestUserRoleServicePurge(t *testing.T) {
+    mock := NewMockUserRoleService(t)
+    store := NewUserRoleService(mock)
+
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        err := store.Purge(ur[2].ID)
+
+        assert.NoError(t, err)
+    })
+
+    t.Run("EXPECT FAIL role is in use", func (t *testing.T) {
+        wantErr = true
+        err := store.Purge(ur[2].ID)
+        wantErr = false
+
+        assert.Error(t, err)
+    })
+}
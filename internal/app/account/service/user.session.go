@@ -0,0 +1,72 @@
+/*
+   service package
+   user.session.go
+   - service/ business layer for user session (signed in device)
+*/
+package service
+
+import (
+	"github.com/google/uuid"
+	ds "github.com/reshimahendra/lbw-go/internal/app/account/datastore"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	"github.com/reshimahendra/lbw-go/internal/pkg/logger"
+)
+
+// IUserSessionService is service layer for user session so the handler layer can
+// communicate with the datastore layer
+type IUserSessionService interface {
+    // Gets will get active session of the user. the session with 'currentID' is marked
+    // as the current session
+    Gets(userID uuid.UUID, currentID string) ([]*d.UserSession, error)
+
+    // Revoke will revoke session of the user, signing the session out
+    Revoke(userID, id uuid.UUID) error
+
+    // RevokeAll will revoke all session of the user except 'keepID' (empty to revoke all)
+    RevokeAll(userID uuid.UUID, keepID string) (int64, error)
+}
+
+// UserSessionService is instance wrapper for IUserSessionStore interface
+type UserSessionService struct {
+    Store ds.IUserSessionStore
+}
+
+// NewUserSessionService is new instance of UserSessionService
+func NewUserSessionService(store ds.IUserSessionStore) *UserSessionService {
+    return &UserSessionService{Store: store}
+}
+
+// Gets will send request to datastore to get active session of the user
+func (s *UserSessionService) Gets(userID uuid.UUID, currentID string) ([]*d.UserSession, error) {
+    sessions, err := s.Store.Gets(userID)
+    if err != nil {
+        return nil, err
+    }
+
+    for _, session := range sessions {
+        session.Current = currentID != "" && session.ID.String() == currentID
+    }
+
+    return sessions, nil
+}
+
+// Revoke will send request to datastore to revoke session of the user
+func (s *UserSessionService) Revoke(userID, id uuid.UUID) error {
+    if err := s.Store.Revoke(userID, id); err != nil {
+        return err
+    }
+    logger.Infof("session %s of user %s revoked", id, userID)
+
+    return nil
+}
+
+// RevokeAll will send request to datastore to revoke all session of the user except 'keepID'
+func (s *UserSessionService) RevokeAll(userID uuid.UUID, keepID string) (int64, error) {
+    count, err := s.Store.RevokeAll(userID, keepID)
+    if err != nil {
+        return 0, err
+    }
+    logger.Infof("%d session of user %s revoked", count, userID)
+
+    return count, nil
+}
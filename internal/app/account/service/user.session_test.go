@@ -0,0 +1,183 @@
+/*
+    package service
+    user.session_test.go
+    - test unit for user.session service
+*/
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockUserSessionService is mocked user.session datastore
+type mockUserSessionService struct {
+    sessions []d.UserSession
+}
+
+// Gets is mocked Gets method to satisfy IUserSessionStore interface
+func (m *mockUserSessionService) Gets(userID uuid.UUID) ([]*d.UserSession, error) {
+    if wantErr {
+        return nil, E.New(E.ErrDatabase)
+    }
+
+    sessions := []*d.UserSession{}
+    for i := range m.sessions {
+        if m.sessions[i].UserID == userID {
+            sessions = append(sessions, &m.sessions[i])
+        }
+    }
+
+    return sessions, nil
+}
+
+// Revoke is mocked Revoke method to satisfy IUserSessionStore interface
+func (m *mockUserSessionService) Revoke(userID, id uuid.UUID) error {
+    for i, session := range m.sessions {
+        if session.ID == id && session.UserID == userID {
+            m.sessions = append(m.sessions[:i], m.sessions[i+1:]...)
+            return nil
+        }
+    }
+
+    return E.New(E.ErrDataIsEmpty)
+}
+
+// RevokeAll is mocked RevokeAll method to satisfy IUserSessionStore interface
+func (m *mockUserSessionService) RevokeAll(userID uuid.UUID, keepID string) (int64, error) {
+    if wantErr {
+        return 0, E.New(E.ErrDatabase)
+    }
+
+    var count int64
+    kept := []d.UserSession{}
+    for _, session := range m.sessions {
+        if session.UserID == userID && session.ID.String() != keepID {
+            count++
+            continue
+        }
+        kept = append(kept, session)
+    }
+    m.sessions = kept
+
+    return count, nil
+}
+
+// newMockUserSessionService will create mocked user.session datastore with the given
+// number of session of the user
+func newMockUserSessionService(userID uuid.UUID, n int) *mockUserSessionService {
+    m := &mockUserSessionService{}
+    for i := 0; i < n; i++ {
+        m.sessions = append(m.sessions, d.UserSession{
+            ID              : uuid.New(),
+            UserID          : userID,
+            UserAgent       : "Mozilla/5.0",
+            IPAddress       : "203.0.113.7",
+            CreatedAt       : time.Now(),
+            LastRefreshedAt : time.Now(),
+        })
+    }
+
+    return m
+}
+
+// TestUserSessionServiceGets will test Gets method of user.session service
+func TestUserSessionServiceGets(t *testing.T) {
+    userID := uuid.New()
+    store := newMockUserSessionService(userID, 2)
+    service := NewUserSessionService(store)
+
+    t.Run("EXPECT SUCCESS current session is marked", func(t *testing.T){
+        got, err := service.Gets(userID, store.sessions[1].ID.String())
+
+        assert.NoError(t, err)
+        assert.Len(t, got, 2)
+        assert.False(t, got[0].Current)
+        assert.True(t, got[1].Current)
+    })
+
+    // EXPECT SUCCESS listed on behalf of other user (admin), no session is current
+    t.Run("EXPECT SUCCESS no current session", func(t *testing.T){
+        got, err := service.Gets(userID, "")
+
+        assert.NoError(t, err)
+        for _, session := range got {
+            assert.False(t, session.Current)
+        }
+    })
+
+    // EXPECT FAIL datastore error. Simulated by setting wantErr=true
+    t.Run("EXPECT FAIL datastore error", func(t *testing.T){
+        wantErr = true
+        got, err := service.Gets(userID, "")
+        wantErr = false
+
+        assert.Nil(t, got)
+        assert.EqualValues(t, E.ErrDatabase, err.(*E.Error).Code)
+    })
+}
+
+// TestUserSessionServiceRevoke will test Revoke method of user.session service
+func TestUserSessionServiceRevoke(t *testing.T) {
+    userID := uuid.New()
+    store := newMockUserSessionService(userID, 2)
+    service := NewUserSessionService(store)
+    id := store.sessions[0].ID
+
+    // other user can not revoke the session
+    err := service.Revoke(uuid.New(), id)
+    assert.EqualValues(t, E.ErrDataIsEmpty, err.(*E.Error).Code)
+
+    assert.NoError(t, service.Revoke(userID, id))
+    assert.Len(t, store.sessions, 1)
+
+    // session can only be revoked once
+    err = service.Revoke(userID, id)
+    assert.EqualValues(t, E.ErrDataIsEmpty, err.(*E.Error).Code)
+}
+
+// TestUserSessionServiceRevokeAll will test RevokeAll method of user.session service
+func TestUserSessionServiceRevokeAll(t *testing.T) {
+    userID := uuid.New()
+
+    t.Run("EXPECT SUCCESS current session is kept", func(t *testing.T){
+        store := newMockUserSessionService(userID, 3)
+        service := NewUserSessionService(store)
+        current := store.sessions[2].ID
+
+        got, err := service.RevokeAll(userID, current.String())
+
+        assert.NoError(t, err)
+        assert.EqualValues(t, 2, got)
+        assert.Len(t, store.sessions, 1)
+        assert.Equal(t, current, store.sessions[0].ID)
+    })
+
+    t.Run("EXPECT SUCCESS all session", func(t *testing.T){
+        store := newMockUserSessionService(userID, 3)
+        service := NewUserSessionService(store)
+
+        got, err := service.RevokeAll(userID, "")
+
+        assert.NoError(t, err)
+        assert.EqualValues(t, 3, got)
+        assert.Empty(t, store.sessions)
+    })
+
+    // EXPECT FAIL datastore error. Simulated by setting wantErr=true
+    t.Run("EXPECT FAIL datastore error", func(t *testing.T){
+        service := NewUserSessionService(newMockUserSessionService(userID, 1))
+
+        wantErr = true
+        got, err := service.RevokeAll(userID, "")
+        wantErr = false
+
+        assert.Zero(t, got)
+        assert.EqualValues(t, E.ErrDatabase, err.(*E.Error).Code)
+    })
+}
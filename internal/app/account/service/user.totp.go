@@ -0,0 +1,391 @@
+/*
+   service package
+   user.totp.go
+   - service/ business layer for user two factor authentication (totp)
+*/
+package service
+
+import (
+	crand "crypto/rand"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	ds "github.com/reshimahendra/lbw-go/internal/app/account/datastore"
+	"github.com/reshimahendra/lbw-go/internal/config"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	"github.com/reshimahendra/lbw-go/internal/pkg/auth"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/helper"
+	"github.com/reshimahendra/lbw-go/internal/pkg/logger"
+	"github.com/reshimahendra/lbw-go/internal/pkg/totp"
+)
+
+const (
+    // recoveryCodeCount is number of recovery code given on confirming two factor authentication
+    recoveryCodeCount = 10
+
+    // recoveryCodeLength is byte length of each recovery code (10 hex character)
+    recoveryCodeLength = 5
+
+    // defaultPendingTokenExpireDuration is fallback valid duration (in minute) of pending token
+    defaultPendingTokenExpireDuration = 5
+)
+
+var (
+    // generateTOTPSecretFunc is func instance of totp.GenerateSecret
+    // it will be used to mock the inner func on test
+    generateTOTPSecretFunc = totp.GenerateSecret
+
+    // createPendingTokenFunc is func instance of auth.CreatePendingToken
+    // it will be used to mock the inner func on test
+    createPendingTokenFunc = auth.CreatePendingToken
+
+    // verifyPendingTokenFunc is func instance of auth.VerifyPendingToken
+    // it will be used to mock the inner func on test
+    verifyPendingTokenFunc = auth.VerifyPendingToken
+
+    // crandReadFunc is func instance of crypto/rand.Read used to generate recovery code
+    // it will be used to mock the inner func on test
+    crandReadFunc = crand.Read
+)
+
+// IUserTOTPService is service layer for user two factor authentication
+type IUserTOTPService interface {
+    // Enroll will create new totp secret for the principal. the secret is not
+    // used on signin until it is confirmed
+    Enroll(principal d.Principal) (*d.UserTOTPEnrollResponse, error)
+
+    // Confirm will verify the code of the enrolled secret, enable the two factor
+    // authentication and give the recovery codes
+    Confirm(userID uuid.UUID, code string) (*d.UserTOTPConfirmResponse, error)
+
+    // IsEnabled will check whether the user has two factor authentication enabled
+    IsEnabled(userID uuid.UUID) (bool, error)
+
+    // IssuePendingToken will create the pending token given on signin of
+    // user with two factor authentication enabled
+    IssuePendingToken(principal d.Principal) (*d.AuthTwoFactorResponse, error)
+
+    // VerifySignin will verify the pending token and the totp or recovery code sent from
+    // the client ip. it return the principal the real token should be issued for
+    VerifySignin(input d.AuthTwoFactorDTO, ip string) (*d.Principal, error)
+}
+
+// UserTOTPService is instance wrapper for IUserTOTPStore interface
+type UserTOTPService struct {
+    // Store is user.totp datastore
+    Store     ds.IUserTOTPStore
+
+    // AuthStore is auth datastore, used to revoke the pending token once it is used
+    AuthStore ds.IAuthStore
+
+    // Attempt is signin attempt service, used to count and throttle wrong code
+    Attempt   ISigninAttemptService
+}
+
+// NewUserTOTPService is new instance of UserTOTPService
+func NewUserTOTPService(st ds.IUserTOTPStore, as ds.IAuthStore, attempt ISigninAttemptService) *UserTOTPService {
+    return &UserTOTPService{Store: st, AuthStore: as, Attempt: attempt}
+}
+
+// Enroll will generate the secret and send request to datastore to save it encrypted
+func (s *UserTOTPService) Enroll(principal d.Principal) (*d.UserTOTPEnrollResponse, error) {
+    issuer, _, key := twoFactorConfig()
+
+    // generate the secret
+    secret, err := generateTOTPSecretFunc()
+    if err != nil {
+        logger.Errorf("generate totp secret fail: %v", err)
+        return nil, E.NewExt(E.ErrEncryption, err)
+    }
+
+    // encrypt the secret, plain secret is never stored
+    encrypted, err := encryptSecret(secret, key)
+    if err != nil {
+        return nil, err
+    }
+
+    // send request to datastore to save the secret
+    created, err := s.Store.Create(principal.UserID, encrypted)
+    if err != nil {
+        return nil, err
+    }
+    if !created {
+        return nil, E.New(E.ErrTwoFactorAlreadyEnabled)
+    }
+
+    return &d.UserTOTPEnrollResponse{
+        Secret          : secret,
+        ProvisioningURI : totp.ProvisioningURI(issuer, principal.Email, secret),
+    }, nil
+}
+
+// Confirm will validate the code against the enrolled secret and send request to
+// datastore to enable it together with the hash of new recovery codes
+func (s *UserTOTPService) Confirm(userID uuid.UUID, code string) (*d.UserTOTPConfirmResponse, error) {
+    // get the enrolled secret
+    userTOTP, err := s.Store.Get(userID)
+    if err != nil {
+        if e, ok := err.(*E.Error); ok && e.Code == E.ErrDataIsEmpty {
+            return nil, E.New(E.ErrTwoFactorNotEnrolled)
+        }
+        return nil, err
+    }
+    if userTOTP.IsEnabled() {
+        return nil, E.New(E.ErrTwoFactorAlreadyEnabled)
+    }
+
+    // validate the code
+    step, err := s.validateCode(userTOTP, code)
+    if err != nil {
+        return nil, err
+    }
+
+    // generate recovery codes, only its hash is saved on the database
+    codes := make([]string, recoveryCodeCount)
+    hashes := make([]string, recoveryCodeCount)
+    for i := range codes {
+        codes[i], err = generateRecoveryCode()
+        if err != nil {
+            logger.Errorf("generate recovery code fail: %v", err)
+            return nil, E.NewExt(E.ErrEncryption, err)
+        }
+        hashes[i] = helper.HashToken(normalizeRecoveryCode(codes[i]))
+    }
+
+    // send request to datastore to enable the secret
+    enabled, err := s.Store.Enable(userID, hashes, step)
+    if err != nil {
+        return nil, err
+    }
+    if !enabled {
+        return nil, E.New(E.ErrTwoFactorAlreadyEnabled)
+    }
+    logger.Infof("two factor authentication enabled for user %s", userID)
+
+    return &d.UserTOTPConfirmResponse{RecoveryCodes: codes}, nil
+}
+
+// IsEnabled will send request to datastore to check whether the user secret is enabled
+func (s *UserTOTPService) IsEnabled(userID uuid.UUID) (bool, error) {
+    userTOTP, err := s.Store.Get(userID)
+    if err != nil {
+        // user never enroll the two factor authentication
+        if e, ok := err.(*E.Error); ok && e.Code == E.ErrDataIsEmpty {
+            return false, nil
+        }
+        return false, err
+    }
+
+    return userTOTP.IsEnabled(), nil
+}
+
+// IssuePendingToken will create short lived pending token for the principal
+func (s *UserTOTPService) IssuePendingToken(principal d.Principal) (*d.AuthTwoFactorResponse, error) {
+    _, expireDuration, _ := twoFactorConfig()
+
+    token, expiresAt, err := createPendingTokenFunc(principal, time.Duration(expireDuration) * time.Minute)
+    if err != nil {
+        logger.Errorf("issue pending token fail: %v", err)
+        return nil, E.New(E.ErrTokenCreate)
+    }
+
+    return &d.AuthTwoFactorResponse{
+        TwoFactorRequired : true,
+        PendingToken      : token,
+        ExpiresAt         : expiresAt,
+    }, nil
+}
+
+// VerifySignin will verify the pending token and the code. 6 digit code is checked
+// as totp code, anything else is checked as recovery code. both can only be used once.
+// wrong code is counted as failed signin, and the failed signin is only cleared once
+// the code is accepted
+func (s *UserTOTPService) VerifySignin(input d.AuthTwoFactorDTO, ip string) (*d.Principal, error) {
+    // verify the pending token
+    principal, metadata, err := verifyPendingTokenFunc(input.PendingToken)
+    if err != nil {
+        logger.Errorf("verify pending token fail: %v", err)
+        return nil, err
+    }
+
+    // reject code on locked user account or before its delay is over
+    if _, err := s.Attempt.Check(d.SigninScopeUser, principal.UserID.String()); err != nil {
+        logger.Errorf("two factor signin fail for user %s: %v", principal.UserID, err)
+        if E.Code(err) == uint(E.ErrAccountLocked) {
+            s.revokePendingToken(*metadata)
+        }
+        return nil, err
+    }
+
+    // get the user secret
+    userTOTP, err := s.Store.Get(principal.UserID)
+    if err != nil {
+        if e, ok := err.(*E.Error); ok && e.Code == E.ErrDataIsEmpty {
+            return nil, E.New(E.ErrTwoFactorNotEnrolled)
+        }
+        return nil, E.NewExt(E.ErrDatabase, err)
+    }
+    if !userTOTP.IsEnabled() {
+        return nil, E.New(E.ErrTwoFactorNotEnrolled)
+    }
+
+    code := strings.TrimSpace(input.Code)
+    var accepted bool
+    if isTOTPCode(code) {
+        step, err := s.validateCode(userTOTP, code)
+        if err != nil {
+            if E.Code(err) == uint(E.ErrTwoFactorCodeInvalid) {
+                return nil, s.codeFailed(*metadata, ip)
+            }
+            return nil, err
+        }
+
+        // step that already used means the code is replayed
+        accepted, err = s.Store.UseStep(principal.UserID, step)
+        if err != nil {
+            return nil, E.NewExt(E.ErrDatabase, err)
+        }
+    } else {
+        accepted, err = s.Store.UseRecoveryCode(principal.UserID, helper.HashToken(normalizeRecoveryCode(code)))
+        if err != nil {
+            return nil, E.NewExt(E.ErrDatabase, err)
+        }
+    }
+    if !accepted {
+        return nil, s.codeFailed(*metadata, ip)
+    }
+
+    // pending token can only be exchanged once
+    if err := s.AuthStore.RevokeToken(*metadata); err != nil {
+        logger.Errorf("revoke pending token fail: %v", err)
+        return nil, E.NewExt(E.ErrTokenCreate, err)
+    }
+
+    // signin is completed, the failed attempt of the user is cleared. the failed attempt of
+    // the client ip is kept until its window is over
+    if err := s.Attempt.Reset(d.SigninScopeUser, principal.UserID.String()); err != nil {
+        logger.Errorf("reset failed signin of user %s fail: %v", principal.UserID, err)
+    }
+
+    return principal, nil
+}
+
+// codeFailed will count the wrong code on the user account and the client ip. once the
+// user account is locked the pending token is revoked, so the signin must be started
+// again with the password. failing to count is only logged since the code is rejected anyway
+func (s *UserTOTPService) codeFailed(metadata d.TokenMetadata, ip string) error {
+    userID := metadata.UserID.String()
+    if err := s.Attempt.Fail(d.SigninScopeUser, userID); err != nil {
+        logger.Errorf("count failed signin of user %s fail: %v", userID, err)
+    }
+    if err := s.Attempt.Fail(d.SigninScopeIP, ip); err != nil {
+        logger.Errorf("count failed signin of ip %s fail: %v", ip, err)
+    }
+
+    if _, err := s.Attempt.Check(d.SigninScopeUser, userID); E.Code(err) == uint(E.ErrAccountLocked) {
+        s.revokePendingToken(metadata)
+        return err
+    }
+
+    return E.New(E.ErrTwoFactorCodeInvalid)
+}
+
+// revokePendingToken will send request to datastore to revoke the pending token. failing
+// to revoke is only logged since the pending token is rejected by the locked account anyway
+func (s *UserTOTPService) revokePendingToken(metadata d.TokenMetadata) {
+    if err := s.AuthStore.RevokeToken(metadata); err != nil {
+        logger.Errorf("revoke pending token fail: %v", err)
+    }
+}
+
+// validateCode will decrypt the secret and validate the code. it return the time step of the code
+func (s *UserTOTPService) validateCode(userTOTP *d.UserTOTP, code string) (int64, error) {
+    _, _, key := twoFactorConfig()
+
+    secret, err := helper.Decrypt(userTOTP.Secret, key)
+    if err != nil {
+        logger.Errorf("decrypt totp secret fail: %v", err)
+        return 0, E.NewExt(E.ErrEncryption, err)
+    }
+
+    step, ok := totp.Validate(secret, strings.TrimSpace(code), time.Now())
+    if !ok {
+        return 0, E.New(E.ErrTwoFactorCodeInvalid)
+    }
+
+    return step, nil
+}
+
+// encryptSecret will encrypt the totp secret with the two factor encryption key
+func encryptSecret(secret, key string) (string, error) {
+    if key == "" {
+        logger.Errorf("two factor encryption key is not set")
+        return "", E.New(E.ErrEncryption)
+    }
+
+    encrypted, err := helper.Encrypt(secret, key)
+    if err != nil {
+        logger.Errorf("encrypt totp secret fail: %v", err)
+        return "", E.NewExt(E.ErrEncryption, err)
+    }
+
+    return encrypted, nil
+}
+
+// generateRecoveryCode will create random recovery code formatted as 'xxxxx-xxxxx'
+func generateRecoveryCode() (string, error) {
+    b := make([]byte, recoveryCodeLength)
+    if _, err := crandReadFunc(b); err != nil {
+        return "", err
+    }
+
+    code := hex.EncodeToString(b)
+    return code[:len(code)/2] + "-" + code[len(code)/2:], nil
+}
+
+// normalizeRecoveryCode will lower the case and remove the separator of the recovery
+// code so the code typed by the user match its hash
+func normalizeRecoveryCode(code string) string {
+    code = strings.ToLower(strings.TrimSpace(code))
+    return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+// isTOTPCode will check whether the code is totp code (6 digit number)
+func isTOTPCode(code string) bool {
+    if len(code) != totp.Digits {
+        return false
+    }
+    for _, r := range code {
+        if r < '0' || r > '9' {
+            return false
+        }
+    }
+
+    return true
+}
+
+// twoFactorConfig will get issuer, pending token expire duration and encryption key
+// from account configuration, falling back to default value when it is not set
+func twoFactorConfig() (string, int64, string) {
+    var (
+        issuer string
+        expireDuration int64 = defaultPendingTokenExpireDuration
+        key string
+    )
+
+    if cfg := config.Get(); cfg != nil {
+        issuer = cfg.Account.TwoFactorIssuer
+        if issuer == "" {
+            issuer = cfg.Server.DomainName
+        }
+        if cfg.Account.TwoFactorPendingTokenExpireDuration > 0 {
+            expireDuration = cfg.Account.TwoFactorPendingTokenExpireDuration
+        }
+        key = cfg.Account.TwoFactorEncryptionKey
+    }
+
+    return issuer, expireDuration, key
+}
@@ -0,0 +1,577 @@
+/*
+    package service
+    user.totp_test.go
+    - test unit for user.totp service
+*/
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/reshimahendra/lbw-go/internal/config"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/helper"
+	"github.com/reshimahendra/lbw-go/internal/pkg/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockUserTOTPService is mocked user.totp datastore
+type mockUserTOTPService struct {
+    t *testing.T
+
+    // totp is the saved totp secret record
+    totp *d.UserTOTP
+
+    // recoveryCodes is saved recovery code hash and whether it is already used
+    recoveryCodes map[string]bool
+}
+
+// NewMockUserTOTPService is new instance of mockUserTOTPService
+func NewMockUserTOTPService(t *testing.T) *mockUserTOTPService {
+    return &mockUserTOTPService{t: t, recoveryCodes: map[string]bool{}}
+}
+
+// Create is mocked Create method to satisfy IUserTOTPStore interface
+func (m *mockUserTOTPService) Create(userID uuid.UUID, secret string) (bool, error) {
+    if wantErr {
+        return false, E.New(E.ErrDatabase)
+    }
+    if m.totp != nil && m.totp.IsEnabled() {
+        return false, nil
+    }
+    m.totp = &d.UserTOTP{UserID: userID, Secret: secret}
+
+    return true, nil
+}
+
+// Get is mocked Get method to satisfy IUserTOTPStore interface
+func (m *mockUserTOTPService) Get(userID uuid.UUID) (*d.UserTOTP, error) {
+    if wantErr {
+        return nil, E.New(E.ErrDatabase)
+    }
+    if m.totp == nil || m.totp.UserID != userID {
+        return nil, E.New(E.ErrDataIsEmpty)
+    }
+
+    return m.totp, nil
+}
+
+// Enable is mocked Enable method to satisfy IUserTOTPStore interface
+func (m *mockUserTOTPService) Enable(userID uuid.UUID, codeHashes []string, step int64) (bool, error) {
+    if m.totp == nil || m.totp.IsEnabled() {
+        return false, nil
+    }
+    now := time.Now()
+    m.totp.EnabledAt = &now
+    m.totp.LastUsedStep = &step
+    for _, hash := range codeHashes {
+        m.recoveryCodes[hash] = false
+    }
+
+    return true, nil
+}
+
+// UseStep is mocked UseStep method to satisfy IUserTOTPStore interface
+func (m *mockUserTOTPService) UseStep(userID uuid.UUID, step int64) (bool, error) {
+    if m.totp.LastUsedStep != nil && *m.totp.LastUsedStep >= step {
+        return false, nil
+    }
+    m.totp.LastUsedStep = &step
+
+    return true, nil
+}
+
+// UseRecoveryCode is mocked UseRecoveryCode method to satisfy IUserTOTPStore interface
+func (m *mockUserTOTPService) UseRecoveryCode(userID uuid.UUID, codeHash string) (bool, error) {
+    used, ok := m.recoveryCodes[codeHash]
+    if !ok || used {
+        return false, nil
+    }
+    m.recoveryCodes[codeHash] = true
+
+    return true, nil
+}
+
+// mockRevokeErrAuthStore is mocked auth datastore failing to revoke token
+type mockRevokeErrAuthStore struct {
+    *mockAuthService
+}
+
+// RevokeToken is mocked RevokeToken method to satisfy IAuthStore interface
+func (m *mockRevokeErrAuthStore) RevokeToken(token d.TokenMetadata) error {
+    return E.New(E.ErrDatabase)
+}
+
+// newMockAttempt will get signin attempt service on top of mocked signin attempt datastore
+func newMockAttempt(t *testing.T) ISigninAttemptService {
+    return NewSigninAttemptService(NewMockSigninAttemptService(t))
+}
+
+// enrollTOTP will enroll and confirm the totp secret of the principal. it return the
+// plain secret and the recovery codes
+func enrollTOTP(t *testing.T, service *UserTOTPService, principal d.Principal) (string, []string) {
+    enroll, err := service.Enroll(principal)
+    require.NoError(t, err)
+
+    // confirm with the code of the previous step so the current step is still unused
+    code, err := totp.Code(enroll.Secret, time.Now().Add(-totp.Period * time.Second))
+    require.NoError(t, err)
+    confirm, err := service.Confirm(principal.UserID, code)
+    require.NoError(t, err)
+
+    return enroll.Secret, confirm.RecoveryCodes
+}
+
+// TestUserTOTPServiceEnroll will test Enroll method behaviour of user.totp service
+func TestUserTOTPServiceEnroll(t *testing.T) {
+    if err := config.Setup(); err != nil {
+        t.Fatalf("unexpected error occur: %v", err)
+    }
+    principal := d.Principal{UserID: u[0].ID, Email: u[0].Email}
+
+    // EXPECT SUCCESS secret saved encrypted
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock := NewMockUserTOTPService(t)
+        service := NewUserTOTPService(mock, NewMockAuthService(t), newMockAttempt(t))
+
+        // actual method call
+        got, err := service.Enroll(principal)
+
+        // test verification and validation
+        assert.NoError(t, err)
+        assert.NotEmpty(t, got.Secret)
+        assert.Contains(t, got.ProvisioningURI, "otpauth://totp/")
+        assert.NotEqual(t, got.Secret, mock.totp.Secret)
+
+        secret, err := helper.Decrypt(mock.totp.Secret, config.Get().Account.TwoFactorEncryptionKey)
+        assert.NoError(t, err)
+        assert.Equal(t, got.Secret, secret)
+    })
+
+    // EXPECT FAIL already enabled. Simulated by enrolling confirmed user
+    t.Run("EXPECT FAIL already enabled", func(t *testing.T){
+        mock := NewMockUserTOTPService(t)
+        service := NewUserTOTPService(mock, NewMockAuthService(t), newMockAttempt(t))
+        enrollTOTP(t, service, principal)
+
+        // actual method call
+        got, err := service.Enroll(principal)
+
+        // test verification and validation
+        assert.Error(t, err)
+        assert.Nil(t, got)
+        assert.Equal(t, E.New(E.ErrTwoFactorAlreadyEnabled), err)
+    })
+
+    // EXPECT FAIL generate secret error. Simulated by mocking totp.GenerateSecret
+    t.Run("EXPECT FAIL generate secret error", func(t *testing.T){
+        generateSecret := generateTOTPSecretFunc
+        generateTOTPSecretFunc = func() (string, error) {
+            return "", errors.New("random source error")
+        }
+        defer func() { generateTOTPSecretFunc = generateSecret }()
+
+        service := NewUserTOTPService(NewMockUserTOTPService(t), NewMockAuthService(t), newMockAttempt(t))
+
+        // actual method call
+        got, err := service.Enroll(principal)
+
+        // test verification and validation
+        assert.Error(t, err)
+        assert.Nil(t, got)
+        assert.Equal(t, uint(E.ErrEncryption), err.(*E.ErrorExt).Code)
+    })
+
+    // EXPECT FAIL database error. Simulated by forcing to return error (set wantErr=true)
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        service := NewUserTOTPService(NewMockUserTOTPService(t), NewMockAuthService(t), newMockAttempt(t))
+
+        // actual method call
+        wantErr = true
+        got, err := service.Enroll(principal)
+        wantErr = false
+
+        // test verification and validation
+        assert.Error(t, err)
+        assert.Nil(t, got)
+        assert.Equal(t, E.New(E.ErrDatabase), err)
+    })
+}
+
+// TestUserTOTPServiceConfirm will test Confirm method behaviour of user.totp service
+func TestUserTOTPServiceConfirm(t *testing.T) {
+    if err := config.Setup(); err != nil {
+        t.Fatalf("unexpected error occur: %v", err)
+    }
+    principal := d.Principal{UserID: u[0].ID, Email: u[0].Email}
+
+    // EXPECT SUCCESS recovery codes given and only its hash saved
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        mock := NewMockUserTOTPService(t)
+        service := NewUserTOTPService(mock, NewMockAuthService(t), newMockAttempt(t))
+        enroll, err := service.Enroll(principal)
+        require.NoError(t, err)
+        code, err := totp.Code(enroll.Secret, time.Now())
+        require.NoError(t, err)
+
+        // actual method call
+        got, err := service.Confirm(principal.UserID, code)
+
+        // test verification and validation
+        assert.NoError(t, err)
+        assert.Len(t, got.RecoveryCodes, recoveryCodeCount)
+        assert.True(t, mock.totp.IsEnabled())
+        for _, c := range got.RecoveryCodes {
+            assert.Contains(t, mock.recoveryCodes, helper.HashToken(normalizeRecoveryCode(c)))
+        }
+    })
+
+    // EXPECT FAIL not enrolled. Simulated by confirming without enrolling
+    t.Run("EXPECT FAIL not enrolled", func(t *testing.T){
+        service := NewUserTOTPService(NewMockUserTOTPService(t), NewMockAuthService(t), newMockAttempt(t))
+
+        // actual method call
+        got, err := service.Confirm(principal.UserID, "123456")
+
+        // test verification and validation
+        assert.Error(t, err)
+        assert.Nil(t, got)
+        assert.Equal(t, E.New(E.ErrTwoFactorNotEnrolled), err)
+    })
+
+    // EXPECT FAIL already enabled. Simulated by confirming twice
+    t.Run("EXPECT FAIL already enabled", func(t *testing.T){
+        service := NewUserTOTPService(NewMockUserTOTPService(t), NewMockAuthService(t), newMockAttempt(t))
+        secret, _ := enrollTOTP(t, service, principal)
+        code, err := totp.Code(secret, time.Now())
+        require.NoError(t, err)
+
+        // actual method call
+        got, err := service.Confirm(principal.UserID, code)
+
+        // test verification and validation
+        assert.Error(t, err)
+        assert.Nil(t, got)
+        assert.Equal(t, E.New(E.ErrTwoFactorAlreadyEnabled), err)
+    })
+
+    // EXPECT FAIL wrong code. Simulated by giving the code of far future time
+    t.Run("EXPECT FAIL wrong code", func(t *testing.T){
+        service := NewUserTOTPService(NewMockUserTOTPService(t), NewMockAuthService(t), newMockAttempt(t))
+        enroll, err := service.Enroll(principal)
+        require.NoError(t, err)
+        code, err := totp.Code(enroll.Secret, time.Now().Add(time.Hour))
+        require.NoError(t, err)
+
+        // actual method call
+        got, err := service.Confirm(principal.UserID, code)
+
+        // test verification and validation
+        assert.Error(t, err)
+        assert.Nil(t, got)
+        assert.Equal(t, E.New(E.ErrTwoFactorCodeInvalid), err)
+    })
+
+    // EXPECT FAIL recovery code generation error. Simulated by mocking crypto/rand.Read
+    t.Run("EXPECT FAIL recovery code generation error", func(t *testing.T){
+        service := NewUserTOTPService(NewMockUserTOTPService(t), NewMockAuthService(t), newMockAttempt(t))
+        enroll, err := service.Enroll(principal)
+        require.NoError(t, err)
+        code, err := totp.Code(enroll.Secret, time.Now())
+        require.NoError(t, err)
+
+        crandRead := crandReadFunc
+        crandReadFunc = func(b []byte) (int, error) {
+            return 0, errors.New("random source error")
+        }
+        defer func() { crandReadFunc = crandRead }()
+
+        // actual method call
+        got, err := service.Confirm(principal.UserID, code)
+
+        // test verification and validation
+        assert.Error(t, err)
+        assert.Nil(t, got)
+        assert.Equal(t, uint(E.ErrEncryption), err.(*E.ErrorExt).Code)
+    })
+}
+
+// TestUserTOTPServiceIsEnabled will test IsEnabled method behaviour of user.totp service
+func TestUserTOTPServiceIsEnabled(t *testing.T) {
+    if err := config.Setup(); err != nil {
+        t.Fatalf("unexpected error occur: %v", err)
+    }
+    principal := d.Principal{UserID: u[0].ID, Email: u[0].Email}
+    service := NewUserTOTPService(NewMockUserTOTPService(t), NewMockAuthService(t), newMockAttempt(t))
+
+    // EXPECT SUCCESS not enrolled
+    t.Run("EXPECT SUCCESS not enrolled", func(t *testing.T){
+        got, err := service.IsEnabled(principal.UserID)
+        assert.NoError(t, err)
+        assert.False(t, got)
+    })
+
+    // EXPECT SUCCESS enrolled but not confirmed
+    t.Run("EXPECT SUCCESS not confirmed", func(t *testing.T){
+        _, err := service.Enroll(principal)
+        require.NoError(t, err)
+
+        got, err := service.IsEnabled(principal.UserID)
+        assert.NoError(t, err)
+        assert.False(t, got)
+    })
+
+    // EXPECT SUCCESS enabled
+    t.Run("EXPECT SUCCESS enabled", func(t *testing.T){
+        enrollTOTP(t, service, principal)
+
+        got, err := service.IsEnabled(principal.UserID)
+        assert.NoError(t, err)
+        assert.True(t, got)
+    })
+
+    // EXPECT FAIL database error. Simulated by forcing to return error (set wantErr=true)
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        wantErr = true
+        got, err := service.IsEnabled(principal.UserID)
+        wantErr = false
+
+        assert.Error(t, err)
+        assert.False(t, got)
+    })
+}
+
+// TestUserTOTPServiceIssuePendingToken will test IssuePendingToken method behaviour of user.totp service
+func TestUserTOTPServiceIssuePendingToken(t *testing.T) {
+    if err := config.Setup(); err != nil {
+        t.Fatalf("unexpected error occur: %v", err)
+    }
+    service := NewUserTOTPService(NewMockUserTOTPService(t), NewMockAuthService(t), newMockAttempt(t))
+
+    // EXPECT SUCCESS
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        got, err := service.IssuePendingToken(d.Principal{UserID: u[0].ID, Email: u[0].Email})
+        assert.NoError(t, err)
+        assert.True(t, got.TwoFactorRequired)
+        assert.NotEmpty(t, got.PendingToken)
+        assert.WithinDuration(t, time.Now().Add(5 * time.Minute), got.ExpiresAt, time.Second)
+    })
+
+    // EXPECT FAIL token create error. Simulated by giving empty user id
+    t.Run("EXPECT FAIL token create error", func(t *testing.T){
+        got, err := service.IssuePendingToken(d.Principal{Email: u[0].Email})
+        assert.Error(t, err)
+        assert.Nil(t, got)
+        assert.Equal(t, E.New(E.ErrTokenCreate), err)
+    })
+}
+
+// TestUserTOTPServiceVerifySignin will test VerifySignin method behaviour of user.totp service
+func TestUserTOTPServiceVerifySignin(t *testing.T) {
+    if err := config.Setup(); err != nil {
+        t.Fatalf("unexpected error occur: %v", err)
+    }
+    principal := d.Principal{UserID: u[0].ID, Email: u[0].Email, RoleID: u[0].RoleID, StatusID: u[0].StatusID}
+    ip := "10.0.0.1"
+
+    // prepare enabled user, the step of the previous period is already used on confirm
+    mock := NewMockUserTOTPService(t)
+    authMock := NewMockAuthService(t)
+    attemptMock := NewMockSigninAttemptService(t)
+    attempt := NewSigninAttemptService(attemptMock)
+    service := NewUserTOTPService(mock, authMock, attempt)
+    secret, recoveryCodes := enrollTOTP(t, service, principal)
+
+    pendingToken := func() string {
+        pending, err := service.IssuePendingToken(principal)
+        require.NoError(t, err)
+        return pending.PendingToken
+    }
+
+    // failed is the counted wrong code of the user, it is cleared after each test
+    // so the delay of the previous wrong code does not throttle the next test
+    failed := func() int {
+        defer func() { attemptMock.attempts = map[string]*d.SigninAttempt{} }()
+        if a, ok := attemptMock.attempts[d.SigninScopeUser+u[0].ID.String()]; ok {
+            return a.FailedCount
+        }
+        return 0
+    }
+
+    // code is the totp code accepted on success test, it is replayed on the next test
+    code, err := totp.Code(secret, time.Now())
+    require.NoError(t, err)
+
+    // EXPECT SUCCESS with totp code, failed attempt of the user is cleared, the failed
+    // attempt of the client ip is kept until its window is over
+    t.Run("EXPECT SUCCESS totp code", func(t *testing.T){
+        require.NoError(t, attempt.Fail(d.SigninScopeIP, ip))
+
+        // actual method call
+        got, err := service.VerifySignin(d.AuthTwoFactorDTO{PendingToken: pendingToken(), Code: code}, ip)
+
+        // test verification and validation
+        assert.NoError(t, err)
+        assert.Equal(t, principal.UserID, got.UserID)
+        assert.Equal(t, principal.RoleID, got.RoleID)
+        assert.Len(t, authMock.revoked, 1)
+        assert.NotContains(t, attemptMock.attempts, d.SigninScopeUser+u[0].ID.String())
+        assert.Contains(t, attemptMock.attempts, d.SigninScopeIP+ip)
+        attemptMock.attempts = map[string]*d.SigninAttempt{}
+    })
+
+    // EXPECT FAIL replayed totp code. Simulated by using the same code twice
+    t.Run("EXPECT FAIL replayed totp code", func(t *testing.T){
+        // actual method call
+        got, err := service.VerifySignin(d.AuthTwoFactorDTO{PendingToken: pendingToken(), Code: code}, ip)
+
+        // test verification and validation
+        assert.Error(t, err)
+        assert.Nil(t, got)
+        assert.Equal(t, E.New(E.ErrTwoFactorCodeInvalid), err)
+        assert.Equal(t, 1, failed())
+    })
+
+    // EXPECT SUCCESS with recovery code typed in upper case
+    t.Run("EXPECT SUCCESS recovery code", func(t *testing.T){
+        // actual method call
+        got, err := service.VerifySignin(d.AuthTwoFactorDTO{
+            PendingToken : pendingToken(),
+            Code         : " " + strings.ToUpper(recoveryCodes[0]) + " ",
+        }, ip)
+
+        // test verification and validation
+        assert.NoError(t, err)
+        assert.Equal(t, principal.UserID, got.UserID)
+    })
+
+    // EXPECT FAIL used recovery code. Simulated by using the same recovery code twice
+    t.Run("EXPECT FAIL used recovery code", func(t *testing.T){
+        // actual method call
+        got, err := service.VerifySignin(d.AuthTwoFactorDTO{PendingToken: pendingToken(), Code: recoveryCodes[0]}, ip)
+
+        // test verification and validation
+        assert.Error(t, err)
+        assert.Nil(t, got)
+        assert.Equal(t, E.New(E.ErrTwoFactorCodeInvalid), err)
+        assert.Equal(t, 1, failed())
+    })
+
+    // EXPECT FAIL wrong totp code. Simulated by giving the code of far future time
+    t.Run("EXPECT FAIL wrong totp code", func(t *testing.T){
+        code, err := totp.Code(secret, time.Now().Add(time.Hour))
+        require.NoError(t, err)
+
+        // actual method call
+        got, err := service.VerifySignin(d.AuthTwoFactorDTO{PendingToken: pendingToken(), Code: code}, ip)
+
+        // test verification and validation
+        assert.Error(t, err)
+        assert.Nil(t, got)
+        assert.Equal(t, E.New(E.ErrTwoFactorCodeInvalid), err)
+        assert.Equal(t, 1, failed())
+    })
+
+    // EXPECT FAIL too many wrong code. the user account is locked and the pending
+    // token is revoked once the wrong code reach the lockout threshold
+    t.Run("EXPECT FAIL too many wrong code", func(t *testing.T){
+        now := mockTimeNow(t)
+        policy := loadSigninPolicy()
+        token := pendingToken()
+        revoked := len(authMock.revoked)
+
+        for i := 1; i < policy.threshold; i++ {
+            _, err := service.VerifySignin(d.AuthTwoFactorDTO{PendingToken: token, Code: "000000"}, ip)
+            assert.Equal(t, E.New(E.ErrTwoFactorCodeInvalid), err)
+
+            // code sent before the delay is over is throttled
+            _, err = service.VerifySignin(d.AuthTwoFactorDTO{PendingToken: token, Code: "000000"}, ip)
+            assert.Equal(t, E.New(E.ErrSigninThrottled), err)
+            *now = now.Add(policy.delayMax)
+        }
+        assert.Len(t, authMock.revoked, revoked)
+
+        // actual method call
+        got, err := service.VerifySignin(d.AuthTwoFactorDTO{PendingToken: token, Code: "000000"}, ip)
+
+        // test verification and validation
+        assert.Nil(t, got)
+        assert.Equal(t, E.New(E.ErrAccountLocked), err)
+        assert.Len(t, authMock.revoked, revoked+1)
+
+        // correct code is rejected while the account is locked
+        got, err = service.VerifySignin(d.AuthTwoFactorDTO{PendingToken: pendingToken(), Code: recoveryCodes[1]}, ip)
+        assert.Nil(t, got)
+        assert.Equal(t, E.New(E.ErrAccountLocked), err)
+        assert.Len(t, authMock.revoked, revoked+2)
+        failed()
+    })
+
+    // EXPECT FAIL invalid pending token. Simulated by giving access token as pending token
+    t.Run("EXPECT FAIL invalid pending token", func(t *testing.T){
+        // actual method call
+        got, err := service.VerifySignin(d.AuthTwoFactorDTO{PendingToken: "invalid-token", Code: recoveryCodes[1]}, ip)
+
+        // test verification and validation
+        assert.Error(t, err)
+        assert.Nil(t, got)
+        assert.Equal(t, E.New(E.ErrTokenInvalid), err)
+    })
+
+    // EXPECT FAIL not enrolled. Simulated by giving pending token of other user
+    t.Run("EXPECT FAIL not enrolled", func(t *testing.T){
+        pending, err := service.IssuePendingToken(d.Principal{UserID: uuid.New(), Email: u[1].Email})
+        require.NoError(t, err)
+
+        // actual method call
+        got, err := service.VerifySignin(d.AuthTwoFactorDTO{PendingToken: pending.PendingToken, Code: recoveryCodes[1]}, ip)
+
+        // test verification and validation
+        assert.Error(t, err)
+        assert.Nil(t, got)
+        assert.Equal(t, E.New(E.ErrTwoFactorNotEnrolled), err)
+    })
+
+    // EXPECT FAIL database error. Simulated by forcing to return error (set wantErr=true)
+    // after the pending token is verified
+    t.Run("EXPECT FAIL database error", func(t *testing.T){
+        token := pendingToken()
+        verifyPendingToken := verifyPendingTokenFunc
+        verifyPendingTokenFunc = func(tokenStr string) (*d.Principal, *d.TokenMetadata, error) {
+            p, metadata, err := verifyPendingToken(tokenStr)
+            wantErr = true
+            return p, metadata, err
+        }
+        defer func() {
+            verifyPendingTokenFunc = verifyPendingToken
+            wantErr = false
+        }()
+
+        // actual method call
+        got, err := service.VerifySignin(d.AuthTwoFactorDTO{PendingToken: token, Code: recoveryCodes[2]}, ip)
+
+        // test verification and validation
+        assert.Error(t, err)
+        assert.Nil(t, got)
+        assert.Equal(t, uint(E.ErrDatabase), E.Code(err))
+    })
+
+    // EXPECT FAIL revoke error. Simulated by auth datastore failing to revoke the pending token
+    t.Run("EXPECT FAIL revoke error", func(t *testing.T){
+        failService := NewUserTOTPService(mock, &mockRevokeErrAuthStore{authMock}, attempt)
+
+        // actual method call
+        got, err := failService.VerifySignin(d.AuthTwoFactorDTO{PendingToken: pendingToken(), Code: recoveryCodes[3]}, ip)
+
+        // test verification and validation
+        assert.Error(t, err)
+        assert.Nil(t, got)
+        assert.Equal(t, uint(E.ErrTokenCreate), err.(*E.ErrorExt).Code)
+    })
+}
@@ -1,10 +1,13 @@
 package service
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/reshimahendra/lbw-go/internal/config"
 	d "github.com/reshimahendra/lbw-go/internal/domain"
 	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
 	"github.com/reshimahendra/lbw-go/internal/pkg/helper"
@@ -77,17 +80,17 @@ func (m *mockUserService) Get(id uuid.UUID) (*d.User, error) {
 }
 
 // Gets is mocked Gets method to satisfy IUserStore interface
-func (m *mockUserService) Gets() ([]*d.User, error) {
+func (m *mockUserService) Gets(q d.ListQuery) ([]*d.User, *d.ListMeta, error) {
     if wantErr {
-        return nil, E.New(E.ErrDataIsEmpty)
+        return nil, nil, E.New(E.ErrDataIsEmpty)
     }
 
-    return u, nil
+    return u, &d.ListMeta{Total: int64(len(u)), Page: q.Page, Limit: q.Limit, Sort: q.Sort, Order: q.Order}, nil
 }
 
 // Update is mocked Update method to satisfy IUserStore interface
 func (m *mockUserService) Update(id uuid.UUID, input d.User) (*d.User, error) {
-    if !input.IsValid() {
+    if input.Username == "" || input.Firstname == "" {
         return nil, E.New(E.ErrDataIsInvalid)
     }
 
@@ -102,6 +105,37 @@ func (m *mockUserService) Update(id uuid.UUID, input d.User) (*d.User, error) {
     return &input, nil
 }
 
+// Patch is mocked Patch method to satisfy IUserStore interface
+func (m *mockUserService) Patch(id uuid.UUID, patch d.MergePatch) (*d.User, error) {
+    if wantErr {
+        return nil, E.New(E.ErrUpdateDataFail)
+    }
+
+    user := *u[0]
+    var nullErr *d.MergePatchNullError
+    if _, err := user.ApplyPatch(patch); errors.As(err, &nullErr) {
+        return nil, E.NewConstraint(E.ErrNotNullViolation, "", nullErr.Member)
+    } else if err != nil || !user.IsValidUpdate() {
+        return nil, E.New(E.ErrDataIsInvalid)
+    }
+
+    return &user, nil
+}
+
+// UpdateProfile is mocked UpdateProfile method to satisfy IUserStore interface
+func (m *mockUserService) UpdateProfile(id uuid.UUID, input d.User) (*d.User, error) {
+    if wantErr {
+        return nil, E.New(E.ErrUpdateDataFail)
+    }
+
+    // status and role of the stored user stay the same
+    input.ID = id
+    input.StatusID = u[0].StatusID
+    input.RoleID = u[0].RoleID
+
+    return &input, nil
+}
+
 // Delete is mocked Delete method to satisfy IUserStore interface
 func (m *mockUserService) Delete(id uuid.UUID) (*d.User, error) {
     if wantErr {
@@ -111,6 +145,33 @@ func (m *mockUserService) Delete(id uuid.UUID) (*d.User, error) {
     return u[0], nil
 }
 
+// Restore is mocked Restore method to satisfy IUserStore interface
+func (m *mockUserService) Restore(id uuid.UUID) (*d.User, error) {
+    if wantErr {
+        return nil, E.New(E.ErrDataIsEmpty)
+    }
+
+    return u[0], nil
+}
+
+// Purge is mocked Purge method to satisfy IUserStore interface
+func (m *mockUserService) Purge(id uuid.UUID) error {
+    if wantErr {
+        return E.New(E.ErrDataIsInUse)
+    }
+
+    return nil
+}
+
+// PurgeDeleted is mocked PurgeDeleted method to satisfy IUserStore interface
+func (m *mockUserService) PurgeDeleted(retentionDays int) (int64, error) {
+    if wantErr {
+        return 0, E.New(E.ErrDatabase)
+    }
+
+    return int64(len(u)), nil
+}
+
 // GetByEmail is mocked GetCredential method to satisfy IUserStore interface
 func (m *mockUserService) GetByEmail(email string) (*d.UserCredential, error) {
     if wantErr {
@@ -122,6 +183,7 @@ func (m *mockUserService) GetByEmail(email string) (*d.UserCredential, error) {
         Username : u[0].Username,
         PassKey : u[0].PassKey,
         StatusID : u[0].StatusID,
+        RoleID : u[0].RoleID,
     }, nil
 }
 
@@ -136,6 +198,7 @@ func (m *mockUserService) GetCredential(username,passkey string) (*d.UserCredent
         Username : u[0].Username,
         PassKey : u[0].PassKey,
         StatusID : u[0].StatusID,
+        RoleID : u[0].RoleID,
     }, nil
 }
 
@@ -148,6 +211,18 @@ func (m *mockUserService) IsUserExist(username,email string) (bool, error) {
     return true, nil
 }
 
+// UpdatePassKey is mocked UpdatePassKey method to satisfy IUserStore interface
+func (m *mockUserService) UpdatePassKey(id uuid.UUID, oldPassKey, newPassKey string) error {
+    if wantErr {
+        return E.New(E.ErrDatabase)
+    }
+    if oldPassKey != u[0].PassKey {
+        return E.New(E.ErrDataIsEmpty)
+    }
+
+    return nil
+}
+
 // TestParseUUID will test the helper function parseUUID
 func TestParseUUID(t *testing.T) {
     // EXPECT SUCCESS will simulated normal operation with no error return
@@ -174,13 +249,19 @@ func TestParseUUID(t *testing.T) {
 func TestUserServiceCreate(t *testing.T) {
     // prepare mock and service
     mock := NewMockUserService(t)
-    service := NewUserService(mock)
+    service := NewUserService(mock, NewPermissionService(NewMockPermissionService()))
+    admin := d.Principal{UserID: uuid.New(), RoleID: d.RoleSuperuser}
+    defer mockPasswordPolicy(config.PasswordPolicy{MinimumLength: 8, MaximumLength: 72, RejectUserInfo: true})()
+
+    // request with password meeting the password policy
+    req := convertToRequest(*u[0])
+    req.PassKey = "lotus-blue-42"
 
     // EXPECT SUCCESS will simulated normal operation with no error return
     // this simulation expect all goes as expected
     t.Run("EXPECT SUCCESS", func(t *testing.T){
         // actual method call
-        got, err := service.Create(*convertToRequest(*u[0]))
+        got, err := service.Create(admin, *req)
 
         // test validation and verification
         assert.NoError(t, err)
@@ -190,6 +271,29 @@ func TestUserServiceCreate(t *testing.T) {
         assert.Equal(t, u[0].Email, got.Email)
     })
 
+    // EXPECT FAIL assign role holding permission the principal does not hold. Simulated by
+    // staff and administrator assigning role with more permission than its own
+    t.Run("EXPECT FAIL role escalation", func (t *testing.T) {
+        staff := d.Principal{UserID: uuid.New(), RoleID: d.RoleStaff}
+        administrator := d.Principal{UserID: uuid.New(), RoleID: d.RoleAdministrator}
+
+        adminReq := *req
+        adminReq.RoleID = d.RoleAdministrator
+        got, err := service.Create(staff, adminReq)
+        assert.Equal(t, E.New(E.ErrForbidden), err)
+        assert.Nil(t, got)
+
+        superReq := *req
+        superReq.RoleID = d.RoleSuperuser
+        got, err = service.Create(administrator, superReq)
+        assert.Equal(t, E.New(E.ErrForbidden), err)
+        assert.Nil(t, got)
+
+        got, err = service.Create(administrator, adminReq)
+        assert.NoError(t, err)
+        assert.NotNil(t, got)
+    })
+
     // EXPECT FAIL invalid data error. Simulated by removing some required data
     t.Run("EXPECT FAIL invalid data error", func (t *testing.T) {
         // prepare invalid data
@@ -198,7 +302,7 @@ func TestUserServiceCreate(t *testing.T) {
         invalidUser.Firstname = ""
 
         // actual method call
-        got, err := service.Create(*invalidUser)
+        got, err := service.Create(admin, *invalidUser)
 
         // test validation and verification
         assert.Error(t, err)
@@ -212,14 +316,31 @@ func TestUserServiceCreate(t *testing.T) {
         invalidUser.Email = "testmailerror.com"
 
         // actual method call
-        got, err := service.Create(*invalidUser)
+        got, err := service.Create(admin, *invalidUser)
 
         // test validation and verification
         assert.Error(t, err)
         assert.Nil(t, got)
     })
 
-    // EXPECT FAIL generate hash password error. Simulated by override helper.HashPassword function
+    // EXPECT FAIL password policy. Simulated by giving short password containing the username
+    t.Run("EXPECT FAIL password policy", func (t *testing.T) {
+        // prepare weak password
+        weakUser := convertToRequest(*u[0])
+        weakUser.PassKey = "leonard"
+
+        // actual method call
+        got, err := service.Create(admin, *weakUser)
+
+        // test validation and verification
+        assert.Nil(t, got)
+        assert.Equal(t, E.NewExt(E.ErrPasswordPolicy, E.FieldErrors{
+            {Field: "passkey", Rule: "min", Message: "must be at least 8 characters"},
+            {Field: "passkey", Rule: "user_info", Message: "must not contain the username or email"},
+        }), err)
+    })
+
+    // EXPECT FAIL generate hash password error. Simulated by override auth.HashPassword function
     t.Run("EXPECT FAIL generate hash pass error", func (t *testing.T) {
         // prepare to override HashPassword
         generateHashPass := generateHashPassFunc
@@ -231,7 +352,7 @@ func TestUserServiceCreate(t *testing.T) {
         }()
 
         // actual method call
-        got, err := service.Create(*convertToRequest(*u[0]))
+        got, err := service.Create(admin, *req)
 
         // test validation and verification
         assert.Error(t, err)
@@ -244,7 +365,7 @@ func TestUserServiceCreate(t *testing.T) {
         // actual method call (method to test)
         // trigger error from the mocked interface
         wantErr = true
-        got, err := service.Create(*convertToRequest(*u[0]))
+        got, err := service.Create(admin, *req)
         wantErr = false
 
         // test validation and verification
@@ -253,11 +374,50 @@ func TestUserServiceCreate(t *testing.T) {
     })
 }
 
+// TestUserServiceSignup will test behaviour of Signup method of user service layer
+func TestUserServiceSignup(t *testing.T) {
+    // prepare mock and service
+    mock := NewMockUserService(t)
+    service := NewUserService(mock, NewPermissionService(NewMockPermissionService()))
+    defer mockPasswordPolicy(config.PasswordPolicy{MinimumLength: 8, MaximumLength: 72})()
+
+    req := d.UserSignupRequest{
+        Username  : u[0].Username,
+        Firstname : u[0].Firstname,
+        Lastname  : string(u[0].Lastname),
+        Email     : u[0].Email,
+        PassKey   : "lotus-blue-42",
+    }
+
+    // EXPECT SUCCESS new user is inactive guest
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        // actual method call
+        got, err := service.Signup(req)
+
+        // test validation and verification
+        assert.NoError(t, err)
+        assert.NotNil(t, got)
+        assert.Equal(t, u[0].Username, got.Username)
+        assert.Equal(t, d.RoleGuest, got.RoleID)
+        assert.Equal(t, 0, got.StatusID)
+    })
+
+    // EXPECT FAIL insert data error. Simulated by forcing Error result with wantErr set to true
+    t.Run("EXPECT FAIL insert data error", func (t *testing.T) {
+        wantErr = true
+        got, err := service.Signup(req)
+        wantErr = false
+
+        assert.Error(t, err)
+        assert.Nil(t, got)
+    })
+}
+
 // TestUserServiceGet will test behaviour of Get method of user service layer
 func TestUserServiceGet(t *testing.T) {
     // prepare mock and service
     mock := NewMockUserService(t)
-    service := NewUserService(mock)
+    service := NewUserService(mock, NewPermissionService(NewMockPermissionService()))
 
     // EXPECT SUCCESS will simulated normal operation with no error return
     // this simulation expect all goes as expected
@@ -289,13 +449,14 @@ func TestUserServiceGet(t *testing.T) {
 func TestUserServiceGets(t *testing.T) {
     // prepare mock and service
     mock := NewMockUserService(t)
-    service := NewUserService(mock)
+    service := NewUserService(mock, NewPermissionService(NewMockPermissionService()))
+    q := d.ListQuery{Page: 1, Limit: d.ListDefaultLimit, Sort: "created_at", Order: d.SortAsc}
 
     // EXPECT SUCCESS will simulated normal operation with no error return
     // this simulation expect all process goes as expected
     t.Run("EXPECT SUCCESS", func(t *testing.T){
         // actual method call (method to test)
-        got, err := service.Gets()
+        got, meta, err := service.Gets(q)
 
         wantUsers := make([]*d.UserResponse, 0)
         for _, user := range u {
@@ -304,6 +465,8 @@ func TestUserServiceGets(t *testing.T) {
 
         assert.NoError(t, err)
         assert.Equal(t, wantUsers, got)
+        assert.Equal(t, int64(len(u)), meta.Total)
+        assert.Equal(t, q.Limit, meta.Limit)
     })
 
     // EXPECT FAIL data is empty error will simulated fail getting record
@@ -313,12 +476,13 @@ func TestUserServiceGets(t *testing.T) {
         // actual method call (method to test)
         // trigger error from the mocked interface
         wantErr = true
-        got, err := service.Gets()
+        got, meta, err := service.Gets(q)
         wantErr = false
 
         // test validation and verification
         assert.Error(t, err)
         assert.Nil(t, got)
+        assert.Nil(t, meta)
     })
 }
 
@@ -326,13 +490,14 @@ func TestUserServiceGets(t *testing.T) {
 func TestUserServiceUpdate(t *testing.T) {
     // prepare mock and service
     mock := NewMockUserService(t)
-    service := NewUserService(mock)
+    service := NewUserService(mock, NewPermissionService(NewMockPermissionService()))
+    admin := d.Principal{UserID: uuid.New(), RoleID: d.RoleSuperuser}
 
     // EXPECT SUCCESS will simulated normal operation with no error return
     // this simulation expect all process goes as expected
     t.Run("EXPECT SUCCESS", func(t *testing.T){
         // actual method call (method to test)
-        got, err := service.Update(u[0].ID.String(), *convertToRequest(*u[0]))
+        got, err := service.Update(admin, u[0].ID.String(), *convertToRequest(*u[0]))
 
         assert.NoError(t, err)
         assert.Equal(t, u[0].ID, got.ID)
@@ -341,81 +506,26 @@ func TestUserServiceUpdate(t *testing.T) {
         assert.Equal(t, u[0].Email, got.Email)
     })
 
-    // EXPECT SUCCESS with new hashed pass generated. 
-    // Simulated by override helper.HashPassword and helper.CheckPasswordHash
-    t.Run("EXPECT SUCCESS new hashed password", func(t *testing.T){
-        // mock checkPassHashFunc (instance func of helper.CheckPasswordHash)
-        checkPassHash := checkPassHashFunc
-        checkPassHashFunc = func(password string, hash string) bool {
-            return true 
-        }
-        // mock generateHashPassFunc (instance func of helper.HashPassword)
+    // EXPECT SUCCESS without passkey. Passkey is not part of the update so the
+    // request is valid without it and the hash func must not be called
+    t.Run("EXPECT SUCCESS without passkey", func(t *testing.T){
+        // mock generateHashPassFunc to fail when it is called
         hashPass := generateHashPassFunc
         generateHashPassFunc = func(password string) (string,error) {
-            return u[0].PassKey, nil
+            return "", E.New(E.ErrPasswordTooShort)
         }
-        defer func() {
-            generateHashPassFunc = hashPass
-            checkPassHashFunc = checkPassHash
-        }()
-
-        // actual method call (method to test)
-        got, err := service.Update(u[0].ID.String(), *convertToRequest(*u[0]))
+        defer func() { generateHashPassFunc = hashPass }()
 
-        assert.NoError(t, err)
-        assert.NotNil(t, got)
-    })
-
-    // EXPECT SUCCESS with old hashed pass.
-    // Simulated by override helper.HashPassword and helper.CheckPasswordHash
-    t.Run("EXPECT SUCCESS old hashed password", func(t *testing.T){
-        // mock checkPassHashFunc (instance func of helper.CheckPasswordHash)
-        checkPassHash := checkPassHashFunc
-        checkPassHashFunc = func(password string, hash string) bool {
-            return false
-        }
-        // mock generateHashPassFunc (instance func of helper.HashPassword)
-        hashPass := generateHashPassFunc
-        generateHashPassFunc = func(password string) (string,error) {
-            return u[0].PassKey, nil
-        }
-        defer func() {
-            generateHashPassFunc = hashPass
-            checkPassHashFunc = checkPassHash
-        }()
+        req := convertToRequest(*u[0])
+        req.PassKey = ""
 
         // actual method call (method to test)
-        got, err := service.Update(u[0].ID.String(), *convertToRequest(*u[0]))
+        got, err := service.Update(admin, u[0].ID.String(), *req)
 
         assert.NoError(t, err)
         assert.NotNil(t, got)
     })
 
-    // EXPECT FAIL with hash pass generated fail.
-    // Simulated by override helper.HashPassword and helper.CheckPasswordHash
-    t.Run("EXPECT FAIL hashed password generate error", func(t *testing.T){
-        // mock checkPassHashFunc (instance func of helper.CheckPasswordHash)
-        checkPassHash := checkPassHashFunc
-        checkPassHashFunc = func(password string, hash string) bool {
-            return false
-        }
-        // mock generateHashPassFunc (instance func of helper.HashPassword)
-        hashPass := generateHashPassFunc
-        generateHashPassFunc = func(password string) (string,error) {
-            return "", E.New(E.ErrPasswordTooShort)
-        }
-        defer func() {
-            generateHashPassFunc = hashPass
-            checkPassHashFunc = checkPassHash
-        }()
-
-        // actual method call (method to test)
-        got, err := service.Update(u[0].ID.String(), *convertToRequest(*u[0]))
-
-        assert.Error(t, err)
-        assert.Nil(t, got)
-    })
-
     // EXPECT FAIL invalid data error. Simulated by giving invalid input data
     t.Run("EXPECT FAIL invalid data error", func(t *testing.T){
         // prepare invalid user data
@@ -423,7 +533,7 @@ func TestUserServiceUpdate(t *testing.T) {
         invalidUser.Firstname = ""
 
         // actual method call (method to test)
-        got, err := service.Update(u[0].ID.String(), *invalidUser)
+        got, err := service.Update(admin, u[0].ID.String(), *invalidUser)
 
         assert.Error(t, err)
         assert.Nil(t, got)
@@ -436,37 +546,257 @@ func TestUserServiceUpdate(t *testing.T) {
         invalidUser.Email = "john.doe.com"
 
         // actual method call (method to test)
-        got, err := service.Update(u[0].ID.String(), *invalidUser)
+        got, err := service.Update(admin, u[0].ID.String(), *invalidUser)
 
         assert.Error(t, err)
         assert.Nil(t, got)
     })
 
-    // EXPECT FAIL get user record for comparison. Simulated by forcing to return error
+    // EXPECT FAIL id invalid. Simulated by sending id that is not uuid
+    t.Run("EXPECT FAIL id invalid", func(t *testing.T){
+        got, err := service.Update(admin, "not-uuid", *convertToRequest(*u[0]))
+
+        assert.Error(t, err)
+        assert.Equal(t, E.New(E.ErrDataIsInvalid), err)
+        assert.Nil(t, got)
+    })
+
+    // EXPECT FAIL update record error. Simulated by forcing to return error
     // by setting wantErr=true
-    t.Run("EXPECT FAIL get user record error", func(t *testing.T){
+    t.Run("EXPECT FAIL update record error", func(t *testing.T){
         // prepare invalid user data
         invalidUser := convertToRequest(*u[0])
-        invalidUser.RoleID = 5
 
         // actual method call (method to test)
         wantErr = true
-        got, err := service.Update(u[0].ID.String(), *convertToRequest(*u[0]))
+        got, err := service.Update(admin, uuid.NewString(), *invalidUser)
         wantErr = false
 
         assert.Error(t, err)
         assert.Nil(t, got)
     })
 
-    // EXPECT FAIL update record error. Simulated by forcing to return error
-    // by setting wantErr=true
-    t.Run("EXPECT FAIL update record error", func(t *testing.T){
-        // prepare invalid user data
-        invalidUser := convertToRequest(*u[0])
+    // the principal updating its own record (u[0])
+    self := d.Principal{UserID: u[0].ID, Email: u[0].Email}
+    selfCases := []struct{
+        name     string
+        statusID int
+        roleID   int
+        wantErr  bool
+        want     error
+    }{
+        {"EXPECT SUCCESS self update keep status and role", u[0].StatusID, u[0].RoleID, false, nil},
+        {"EXPECT FAIL self update change status", u[0].StatusID + 1, u[0].RoleID, false, E.New(E.ErrForbidden)},
+        {"EXPECT FAIL self update change role", u[0].StatusID, u[0].RoleID + 1, false, E.New(E.ErrForbidden)},
+        {"EXPECT FAIL self update get error", u[0].StatusID, u[0].RoleID, true, E.New(E.ErrDataIsEmpty)},
+    }
 
-        // actual method call (method to test)
+    for _, tt := range selfCases {
+        t.Run(tt.name, func(t *testing.T){
+            req := convertToRequest(*u[0])
+            req.StatusID, req.RoleID = tt.statusID, tt.roleID
+
+            wantErr = tt.wantErr
+            got, err := service.Update(self, u[0].ID.String(), *req)
+            wantErr = false
+
+            assert.Equal(t, tt.want, err)
+            assert.Equal(t, tt.want == nil, got != nil)
+        })
+    }
+
+    // EXPECT SUCCESS other user status and role can be changed
+    t.Run("EXPECT SUCCESS change other user role", func(t *testing.T){
+        req := convertToRequest(*u[0])
+        req.RoleID = u[0].RoleID + 1
+
+        got, err := service.Update(admin, u[0].ID.String(), *req)
+
+        assert.NoError(t, err)
+        assert.NotNil(t, got)
+    })
+
+    // EXPECT FAIL assign role holding permission the principal does not hold. the record role
+    // that is kept is not checked
+    roleCases := []struct{
+        name   string
+        roleID int
+        want   error
+    }{
+        {"EXPECT FAIL staff assign administrator role", d.RoleAdministrator, E.New(E.ErrForbidden)},
+        {"EXPECT SUCCESS staff assign guest role", d.RoleGuest, nil},
+        {"EXPECT SUCCESS staff keep superuser role", u[0].RoleID, nil},
+    }
+
+    staff := d.Principal{UserID: uuid.New(), RoleID: d.RoleStaff}
+    for _, tt := range roleCases {
+        t.Run(tt.name, func(t *testing.T){
+            req := convertToRequest(*u[0])
+            req.RoleID = tt.roleID
+
+            got, err := service.Update(staff, u[0].ID.String(), *req)
+
+            assert.Equal(t, tt.want, err)
+            assert.Equal(t, tt.want == nil, got != nil)
+        })
+    }
+}
+
+// TestUserServicePatch will test Patch method behaviour of User service
+func TestUserServicePatch(t *testing.T) {
+    // prepare mock and service
+    mock := NewMockUserService(t)
+    service := NewUserService(mock, NewPermissionService(NewMockPermissionService()))
+    admin := d.Principal{UserID: uuid.New(), RoleID: d.RoleSuperuser}
+
+    // EXPECT SUCCESS only the patched field is changed
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        got, err := service.Patch(admin, u[0].ID.String(), d.MergePatch{"email": []byte(`"new@lotusbw.com"`)})
+
+        assert.NoError(t, err)
+        assert.Equal(t, "new@lotusbw.com", got.Email)
+        assert.Equal(t, u[0].Username, got.Username)
+    })
+
+    cases := []struct{
+        name    string
+        id      string
+        patch   d.MergePatch
+        wantErr error
+    }{
+        {"EXPECT FAIL email invalid", u[0].ID.String(), d.MergePatch{"email": []byte(`"john.doe.com"`)}, E.New(E.ErrEmailIsInvalid)},
+        {"EXPECT FAIL email cleared", u[0].ID.String(), d.MergePatch{"email": []byte(`null`)}, E.NewConstraint(E.ErrNotNullViolation, "", "email")},
+        {"EXPECT FAIL id invalid", "not-uuid", d.MergePatch{}, E.New(E.ErrDataIsInvalid)},
+        {"EXPECT FAIL data invalid", u[0].ID.String(), d.MergePatch{"passkey": []byte(`"secret"`)}, E.New(E.ErrDataIsInvalid)},
+    }
+
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            got, err := service.Patch(admin, tt.id, tt.patch)
+
+            assert.Equal(t, tt.wantErr, err)
+            assert.Nil(t, got)
+        })
+    }
+
+    // EXPECT FAIL datastore error. Simulated by triggering 'wantErr'
+    t.Run("EXPECT FAIL datastore error", func(t *testing.T){
         wantErr = true
-        got, err := service.Update(uuid.NewString(), *invalidUser)
+        got, err := service.Patch(admin, u[0].ID.String(), d.MergePatch{})
+        wantErr = false
+
+        assert.Error(t, err)
+        assert.Nil(t, got)
+    })
+
+    // the principal patching its own record (u[0]), invalid patch is rejected by the datastore
+    self := d.Principal{UserID: u[0].ID, Email: u[0].Email}
+    selfCases := []struct{
+        name    string
+        patch   string
+        wantErr bool
+        want    error
+    }{
+        {"EXPECT SUCCESS self patch keep status and role", fmt.Sprintf(`{"firstname":"Jenny","status_id":%d}`, u[0].StatusID), false, nil},
+        {"EXPECT FAIL self patch change status", fmt.Sprintf(`{"status_id":%d}`, u[0].StatusID+1), false, E.New(E.ErrForbidden)},
+        {"EXPECT FAIL self patch change role", fmt.Sprintf(`{"role_id":%d}`, u[0].RoleID+1), false, E.New(E.ErrForbidden)},
+        {"EXPECT FAIL self patch clear role", `{"role_id":null}`, false, E.NewConstraint(E.ErrNotNullViolation, "", "role_id")},
+        {"EXPECT FAIL self patch get error", `{"firstname":"Jenny"}`, true, E.New(E.ErrDataIsEmpty)},
+    }
+
+    for _, tt := range selfCases {
+        t.Run(tt.name, func(t *testing.T){
+            patch, err := d.DecodeMergePatch([]byte(tt.patch))
+            assert.NoError(t, err)
+
+            wantErr = tt.wantErr
+            got, err := service.Patch(self, u[0].ID.String(), patch)
+            wantErr = false
+
+            assert.Equal(t, tt.want, err)
+            assert.Equal(t, tt.want == nil, got != nil)
+        })
+    }
+
+    // EXPECT FAIL patch role holding permission the principal does not hold
+    staff := d.Principal{UserID: uuid.New(), RoleID: d.RoleStaff}
+    roleCases := []struct{
+        name  string
+        patch string
+        want  error
+    }{
+        {"EXPECT FAIL staff patch administrator role", fmt.Sprintf(`{"role_id":%d}`, d.RoleAdministrator), E.New(E.ErrForbidden)},
+        {"EXPECT SUCCESS staff patch guest role", fmt.Sprintf(`{"role_id":%d}`, d.RoleGuest), nil},
+        {"EXPECT SUCCESS staff patch without role", `{"firstname":"Jenny"}`, nil},
+    }
+
+    for _, tt := range roleCases {
+        t.Run(tt.name, func(t *testing.T){
+            patch, err := d.DecodeMergePatch([]byte(tt.patch))
+            assert.NoError(t, err)
+
+            got, err := service.Patch(staff, u[0].ID.String(), patch)
+
+            assert.Equal(t, tt.want, err)
+            assert.Equal(t, tt.want == nil, got != nil)
+        })
+    }
+}
+
+// TestUserServiceUpdateProfile will test UpdateProfile method behaviour of User service
+func TestUserServiceUpdateProfile(t *testing.T) {
+    // prepare mock and service
+    mock := NewMockUserService(t)
+    service := NewUserService(mock, NewPermissionService(NewMockPermissionService()))
+    req := d.UserProfileRequest{Username: "reshi", Firstname: "Reshi", Lastname: "Mahendra", Email: "reshi@lotusbw.com"}
+
+    // EXPECT SUCCESS will simulated normal operation with no error return
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        got, err := service.UpdateProfile(u[0].ID.String(), req)
+
+        assert.NoError(t, err)
+        assert.Equal(t, u[0].ID, got.ID)
+        assert.Equal(t, req.Username, got.Username)
+        assert.Equal(t, req.Email, got.Email)
+        assert.Equal(t, u[0].StatusID, got.StatusID)
+        assert.Equal(t, u[0].RoleID, got.RoleID)
+    })
+
+    // EXPECT FAIL data invalid. Simulated by sending empty username
+    t.Run("EXPECT FAIL data invalid", func(t *testing.T){
+        invalid := req
+        invalid.Username = ""
+        got, err := service.UpdateProfile(u[0].ID.String(), invalid)
+
+        assert.Error(t, err)
+        assert.Equal(t, E.New(E.ErrDataIsInvalid), err)
+        assert.Nil(t, got)
+    })
+
+    // EXPECT FAIL email invalid. Simulated by sending email without domain
+    t.Run("EXPECT FAIL email invalid", func(t *testing.T){
+        invalid := req
+        invalid.Email = "reshi"
+        got, err := service.UpdateProfile(u[0].ID.String(), invalid)
+
+        assert.Error(t, err)
+        assert.Equal(t, E.New(E.ErrEmailIsInvalid), err)
+        assert.Nil(t, got)
+    })
+
+    // EXPECT FAIL id invalid. Simulated by sending id that is not uuid
+    t.Run("EXPECT FAIL id invalid", func(t *testing.T){
+        got, err := service.UpdateProfile("not-uuid", req)
+
+        assert.Error(t, err)
+        assert.Equal(t, E.New(E.ErrDataIsInvalid), err)
+        assert.Nil(t, got)
+    })
+
+    // EXPECT FAIL datastore error. Simulated by triggering 'wantErr'
+    t.Run("EXPECT FAIL datastore error", func(t *testing.T){
+        wantErr = true
+        got, err := service.UpdateProfile(u[0].ID.String(), req)
         wantErr = false
 
         assert.Error(t, err)
@@ -478,7 +808,7 @@ func TestUserServiceUpdate(t *testing.T) {
 func TestUserServiceDelete(t *testing.T) {
     // prepare mock and service
     mock := NewMockUserService(t)
-    service := NewUserService(mock)
+    service := NewUserService(mock, NewPermissionService(NewMockPermissionService()))
 
     // EXPECT SUCCESS will simulated normal operation with no error return
     // this simulation expect all process goes as expected
@@ -505,11 +835,74 @@ func TestUserServiceDelete(t *testing.T) {
     })
 }
 
+// TestUserServiceRestore will test Restore method behaviour of user service
+func TestUserServiceRestore(t *testing.T) {
+    // prepare mock and service
+    mock := NewMockUserService(t)
+    service := NewUserService(mock, NewPermissionService(NewMockPermissionService()))
+
+    // EXPECT SUCCESS deleted user is restored
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        got, err := service.Restore(u[0].ID.String())
+
+        assert.NoError(t, err)
+        assert.Equal(t, u[0].ID, got.ID)
+    })
+
+    // EXPECT FAIL invalid id
+    t.Run("EXPECT FAIL invalid id", func(t *testing.T){
+        got, err := service.Restore("not-a-uuid")
+
+        assert.Error(t, err)
+        assert.Nil(t, got)
+    })
+
+    // EXPECT FAIL restore record error. Simulated by setting wantErr=true
+    t.Run("EXPECT FAIL restore record error", func(t *testing.T){
+        wantErr = true
+        got, err := service.Restore(u[0].ID.String())
+        wantErr = false
+
+        assert.Error(t, err)
+        assert.Nil(t, got)
+    })
+}
+
+// TestUserServicePurge will test Purge method behaviour of user service
+func TestUserServicePurge(t *testing.T) {
+    // prepare mock and service
+    mock := NewMockUserService(t)
+    service := NewUserService(mock, NewPermissionService(NewMockPermissionService()))
+
+    // EXPECT SUCCESS deleted user is removed
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        err := service.Purge(u[0].ID.String())
+
+        assert.NoError(t, err)
+    })
+
+    // EXPECT FAIL invalid id
+    t.Run("EXPECT FAIL invalid id", func(t *testing.T){
+        err := service.Purge("not-a-uuid")
+
+        assert.Error(t, err)
+    })
+
+    // EXPECT FAIL purge record error. Simulated by setting wantErr=true
+    t.Run("EXPECT FAIL purge record error", func(t *testing.T){
+        wantErr = true
+        err := service.Purge(u[0].ID.String())
+        wantErr = false
+
+        assert.Error(t, err)
+    })
+}
+
 // TestUserServiceGetByEmail will test GetByEmail method behaviour of user service
 func TestUserServiceGetByEmail(t *testing.T) {
     // prepare mock and service
     mock := NewMockUserService(t)
-    service := NewUserService(mock)
+    service := NewUserService(mock, NewPermissionService(NewMockPermissionService()))
 
     // EXPECT SUCCESS will simulated normal operation with no error return
     // this simulation expect all process goes as expected
@@ -540,7 +933,7 @@ func TestUserServiceGetByEmail(t *testing.T) {
 func TestUserServiceGetCredential(t *testing.T) {
     // prepare mock and service
     mock := NewMockUserService(t)
-    service := NewUserService(mock)
+    service := NewUserService(mock, NewPermissionService(NewMockPermissionService()))
 
     // EXPECT SUCCESS will simulated normal operation with no error return
     // this simulation expect all process goes as expected
@@ -571,7 +964,7 @@ func TestUserServiceGetCredential(t *testing.T) {
 func TestIsUserExist(t *testing.T) {
     // prepare mock and service
     mock := NewMockUserService(t)
-    service := NewUserService(mock)
+    service := NewUserService(mock, NewPermissionService(NewMockPermissionService()))
 
     // EXPECT SUCCESS will simulated normal operation with no error return
     // this simulation expect all process goes as expected
@@ -609,10 +1002,56 @@ func convertToRequest(u d.User) *d.UserRequest{
         ID        : u.ID,
         Username  : u.Username,
         Firstname : u.Firstname,
-        Lastname  : u.Lastname,
+        Lastname  : string(u.Lastname),
         Email     : u.Email,
         PassKey   : u.PassKey,
         StatusID  : u.StatusID,
         RoleID    : u.RoleID,
     }
 }
+
+// TestUserServiceRehashPassKey will test behaviour of RehashPassKey method of user service layer
+func TestUserServiceRehashPassKey(t *testing.T) {
+    // prepare mock and service
+    mock := NewMockUserService(t)
+    service := NewUserService(mock, NewPermissionService(NewMockPermissionService()))
+
+    // mock hash func to speed up the test
+    generateHashPass, passNeedRehash := generateHashPassFunc, passNeedRehashFunc
+    generateHashPassFunc = func(password string) (string, error) {
+        if password == "" {
+            return "", E.New(E.ErrDataIsInvalid)
+        }
+        return "rehashed", nil
+    }
+    passNeedRehashFunc = func(hash string) bool {
+        return hash != "rehashed"
+    }
+    defer func() { generateHashPassFunc, passNeedRehashFunc = generateHashPass, passNeedRehash }()
+
+    cases := []struct{
+        name     string
+        password string
+        passKey  string
+        storeErr bool
+        wantErr  error
+    }{
+        {"EXPECT SUCCESS outdated passkey is replaced", "secret", u[0].PassKey, false, nil},
+        {"EXPECT SUCCESS current passkey is kept", "secret", "rehashed", true, nil},
+        {"EXPECT FAIL hash password error", "", u[0].PassKey, false, E.New(E.ErrDataIsInvalid)},
+        {"EXPECT FAIL passkey is changed", "secret", "changed", false, E.New(E.ErrDataIsEmpty)},
+        {"EXPECT FAIL datastore error", "secret", u[0].PassKey, true, E.New(E.ErrDatabase)},
+    }
+
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            // actual method call
+            wantErr = tt.storeErr
+            err := service.RehashPassKey(u[0].ID, tt.password, tt.passKey)
+            wantErr = false
+
+            // test validation and verification
+            assert.Equal(t, tt.wantErr, err)
+        })
+    }
+}
@@ -2,5 +2,111 @@ package config
 
 // AccountConfiguration is configuration setup for user account
 type Account struct {
-    MinimumPasswordLength int
+    // PasswordPolicy is rule the user password must meet on signup, password change and reset
+    PasswordPolicy PasswordPolicy
+
+    // PasswordHash is algorithm and parameter to hash the user password
+    PasswordHash PasswordHash
+
+    // ActivationTokenExpireDuration is valid duration (in hour) of account activation token
+    ActivationTokenExpireDuration int64
+
+    // ActivationURL is the activation link sent to the user, token will be appended as query
+    ActivationURL string
+
+    // PasswordResetTokenExpireDuration is valid duration (in hour) of password reset token
+    PasswordResetTokenExpireDuration int64
+
+    // PasswordResetURL is the password reset link sent to the user, token will be appended as query
+    PasswordResetURL string
+
+    // TwoFactorIssuer is issuer name shown on the authenticator app, default to the server domain name
+    TwoFactorIssuer string
+
+    // TwoFactorPendingTokenExpireDuration is valid duration (in minute) of the token
+    // given on signin to complete the two factor authentication
+    TwoFactorPendingTokenExpireDuration int64
+
+    // TwoFactorEncryptionKey is key to encrypt the two factor secret stored on the database
+    TwoFactorEncryptionKey string
+
+    // SigninLockoutThreshold is number of consecutive failed signin on a user account before it is locked
+    SigninLockoutThreshold int
+
+    // SigninIPLockoutThreshold is number of consecutive failed signin from a client ip before it is locked.
+    // it should be higher than SigninLockoutThreshold since many users may share the same ip
+    SigninIPLockoutThreshold int
+
+    // SigninLockoutDuration is duration (in minute) the user account or client ip is locked
+    SigninLockoutDuration int64
+
+    // SigninDelayBase is delay (in second) after the first failed signin on a user account,
+    // the delay is doubled on each next failed signin
+    SigninDelayBase int64
+
+    // SigninDelayMax is the longest delay (in second) between failed signin on a user account
+    SigninDelayMax int64
+
+    // DeletedRetentionDays is number of days (soft) deleted user and role is kept before it is
+    // purged permanently. zero disable the scheduled purge
+    DeletedRetentionDays int
+}
+
+// PasswordPolicy is rule of the user password. zero value of the rule disable it,
+// except the length which fall back to default length
+type PasswordPolicy struct {
+    // MinimumLength is minimum number of character of the password, default to 8
+    MinimumLength int
+
+    // MaximumLength is maximum number of character of the password, default to 72. bcrypt
+    // only use the first 72 byte of the password, so it is also limited to 72 byte on bcrypt
+    MaximumLength int
+
+    // RequireUppercase is to require at least one uppercase letter
+    RequireUppercase bool
+
+    // RequireLowercase is to require at least one lowercase letter
+    RequireLowercase bool
+
+    // RequireDigit is to require at least one digit
+    RequireDigit bool
+
+    // RequireSymbol is to require at least one character that is not letter nor digit
+    RequireSymbol bool
+
+    // RejectUserInfo is to reject password containing the username or the email (local part)
+    RejectUserInfo bool
+
+    // BreachedListFile is path of file listing common or breached password (one per line,
+    // line starting with '#' is ignored) the password must not be one of
+    BreachedListFile string
+
+    // HistoryCount is number of the last password (including the current one) that can not
+    // be reused on password change and reset
+    HistoryCount int
+}
+
+// PasswordHash is algorithm and parameter to hash the user password. passkey hashed with
+// other algorithm or parameter is hashed again on the next successful signin
+type PasswordHash struct {
+    // Algorithm is 'bcrypt' or 'argon2id', default to bcrypt
+    Algorithm string
+
+    // BcryptCost is cost of bcrypt hash (4 - 31), default to 12
+    BcryptCost int
+
+    // Argon2Memory is memory (in KiB) used by argon2id hash, default to 65536 (64 MiB)
+    Argon2Memory uint32
+
+    // Argon2Iterations is number of pass over the memory of argon2id hash, default to 3
+    Argon2Iterations uint32
+
+    // Argon2Parallelism is number of thread of argon2id hash, default to 4
+    Argon2Parallelism uint8
+
+    // Argon2SaltLength is byte length of the random salt of argon2id hash, default to 16
+    Argon2SaltLength uint32
+
+    // Argon2KeyLength is byte length of argon2id hash, default to 32
+    Argon2KeyLength uint32
 }
@@ -7,7 +7,9 @@ package config
 import (
 	"path/filepath"
 	"runtime"
+	"strings"
 
+	"github.com/mitchellh/mapstructure"
 	"github.com/reshimahendra/lbw-go/internal/pkg/logger"
 	"github.com/spf13/viper"
 )
@@ -38,6 +40,12 @@ type Configuration struct{
 
     // Logger is logger configuration
     Logger Logger
+
+    // Mail is outgoing mail configuration
+    Mail Mail
+
+    // OIDC is openid connect (social login) configuration
+    OIDC OIDC
 }
 
 // Get will get configuration setting
@@ -63,7 +71,7 @@ func Setup() (err error) {
     }
 
     // unmarshal from the yaml file to Configuration struct
-    if err = viperUnmarshal(&c); err != nil {
+    if err = viperUnmarshal(&c, matchKeyName); err != nil {
         logger.Errorf("error unable to decode config into struct: %v\n", err)
         return err
     }
@@ -72,3 +80,11 @@ func Setup() (err error) {
 
     return
 }
+
+// matchKeyName will make the configuration key match the struct field regardless of its case
+// and underscore, so both 'minimum_length' and 'minimumLength' key is decoded to MinimumLength
+func matchKeyName(dc *mapstructure.DecoderConfig) {
+    dc.MatchName = func(mapKey, fieldName string) bool {
+        return strings.EqualFold(strings.ReplaceAll(mapKey, "_", ""), fieldName)
+    }
+}
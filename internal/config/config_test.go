@@ -31,6 +31,7 @@ var (
         Port                       : "8000",
         SecureKey                  : "secure-key-is-a-secret",
         MinimumSecureKeyLength     : 16,
+        SigningMethod              : "HS256",
         AccessTokenExpireDuration  : 1,
         RefreshTokenExpireDuration : 1,
         LimitCountPerRequest       : 1,
@@ -42,7 +43,32 @@ var (
 
     // wantAccount is temporary account configuration test value
     wantAccount = Account{
-        MinimumPasswordLength : 8,
+        PasswordPolicy                   : PasswordPolicy{
+            MinimumLength    : 8,
+            MaximumLength    : 72,
+            RequireUppercase : true,
+            RequireLowercase : true,
+            RequireDigit     : true,
+            RejectUserInfo   : true,
+            HistoryCount     : 5,
+        },
+        PasswordHash                     : PasswordHash{
+            Algorithm  : "bcrypt",
+            BcryptCost : 12,
+        },
+        ActivationTokenExpireDuration    : 24,
+        ActivationURL                    : "https://lotusbw.com/account/activate",
+        PasswordResetTokenExpireDuration : 1,
+        PasswordResetURL                 : "https://lotusbw.com/account/password/reset",
+        TwoFactorIssuer                  : "Lotus",
+        TwoFactorPendingTokenExpireDuration : 5,
+        TwoFactorEncryptionKey           : "s3cr3t-2fa-encryption-key",
+        SigninLockoutThreshold           : 5,
+        SigninIPLockoutThreshold         : 20,
+        SigninLockoutDuration            : 15,
+        SigninDelayBase                  : 1,
+        SigninDelayMax                   : 30,
+        DeletedRetentionDays             : 30,
     }
 
     // wantLog is temporary logger configuration test value
@@ -52,6 +78,21 @@ var (
         AccessLogName   : ".access.log",
     }
 
+    // wantMail is temporary mail configuration test value
+    wantMail = Mail{
+        SmtpServer     : "",
+        SmtpPort       : "587",
+        SmtpUsername   : "",
+        SmtpPassword   : "",
+        SenderEmail    : "noreply@lotusbw.com",
+        SenderIdentity : "Lotus BW",
+    }
+
+    // wantOIDC is temporary oidc configuration test value
+    wantOIDC = OIDC{
+        StateExpireDuration : 10,
+    }
+
 
     // mock func
     viperReadInConfigFunc = viperReadInConfig
@@ -107,4 +148,20 @@ func TestGet(t *testing.T) {
     assert.Equal(t, wantServer, cfg.Server)
     assert.Equal(t, wantAccount, cfg.Account)
     assert.Equal(t, wantLog, cfg.Logger)
+    assert.Equal(t, wantMail, cfg.Mail)
+    assert.Equal(t, wantOIDC, cfg.OIDC)
+}
+
+// TestMatchKeyName is test for decoding configuration key regardless of its case and underscore
+func TestMatchKeyName(t *testing.T) {
+    v := viper.New()
+    v.Set("minimum_length", 10)
+    v.Set("requireDigit", true)
+    v.Set("breachedlistfile", "breached.txt")
+
+    var got PasswordPolicy
+    err := v.Unmarshal(&got, matchKeyName)
+
+    assert.NoError(t, err)
+    assert.Equal(t, PasswordPolicy{MinimumLength: 10, RequireDigit: true, BreachedListFile: "breached.txt"}, got)
 }
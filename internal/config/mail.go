@@ -0,0 +1,34 @@
+/*
+   package config
+   mail.go
+   - main configuration for outgoing mail (smtp)
+*/
+package config
+
+// Mail is configuration setup for outgoing mail
+type Mail struct {
+    // SmtpServer is smtp server hostname. when empty, mail will only be written to the log
+    SmtpServer     string
+
+    // SmtpPort is port used by the smtp server
+    SmtpPort       string
+
+    // SmtpUsername is username used to authenticate to the smtp server
+    SmtpUsername   string
+
+    // SmtpPassword is password used to authenticate to the smtp server
+    SmtpPassword   string
+
+    // SenderEmail is email address used as the mail sender
+    SenderEmail    string
+
+    // SenderIdentity is sender name that shown on 'from' header
+    SenderIdentity string
+}
+
+// IsValid is to check whether mail configuration is valid to send mail via smtp
+func (m *Mail) IsValid() bool {
+    return m.SmtpServer != "" &&
+        m.SmtpPort != "" &&
+        m.SenderEmail != ""
+}
@@ -0,0 +1,22 @@
+/*
+   package config
+   mail_test.go
+   - test unit for mail
+*/
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestMailConfig is for testing mail config behaviour
+func TestMailConfig(t *testing.T) {
+    // smtp server is not set on the test configuration
+    assert.Equal(t, false, wantMail.IsValid())
+
+    tmpMail := wantMail
+    tmpMail.SmtpServer = "smtp.lotusbw.com"
+    assert.Equal(t, true, tmpMail.IsValid())
+}
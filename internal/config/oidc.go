@@ -0,0 +1,71 @@
+/*
+   package config
+   oidc.go
+   - main configuration for openid connect (social login) provider
+*/
+package config
+
+// OIDC is configuration setup for signin with openid connect provider
+type OIDC struct {
+    // StateExpireDuration is valid duration (in minute) of the signin state
+    // given when the user is sent to the provider
+    StateExpireDuration int64
+
+    // Providers is all openid connect provider the user can signin with
+    Providers           []OIDCProvider
+}
+
+// OIDCProvider is configuration of an openid connect provider
+type OIDCProvider struct {
+    // Name is name of the provider used on the signin url, ex: google
+    Name             string
+
+    // Issuer is issuer url of the provider. the provider endpoint is discovered
+    // from '{issuer}/.well-known/openid-configuration' when it is not set below
+    Issuer           string
+
+    // ClientID is client id of our application registered on the provider
+    ClientID         string
+
+    // ClientSecret is client secret of our application registered on the provider
+    ClientSecret     string
+
+    // RedirectURL is url the provider send the user back to with the authorization code
+    RedirectURL      string
+
+    // Scopes is scope requested to the provider, 'openid', 'email' and 'profile' by default
+    Scopes           []string
+
+    // AuthorizationURL is authorization endpoint of the provider
+    AuthorizationURL string
+
+    // TokenURL is token endpoint of the provider
+    TokenURL         string
+
+    // JWKSURL is url of the provider public key to verify the id token
+    JWKSURL          string
+}
+
+// Provider will get the openid connect provider with the given name
+func (o *OIDC) Provider(name string) (OIDCProvider, bool) {
+    for _, p := range o.Providers {
+        if p.Name == name {
+            return p, true
+        }
+    }
+
+    return OIDCProvider{}, false
+}
+
+// IsValid is to check whether the provider configuration is complete to signin with
+func (p *OIDCProvider) IsValid() bool {
+    return p.Name != "" &&
+        p.Issuer != "" &&
+        p.ClientID != "" &&
+        p.RedirectURL != ""
+}
+
+// NeedDiscovery is to check whether the provider endpoint must be discovered from its issuer
+func (p *OIDCProvider) NeedDiscovery() bool {
+    return p.AuthorizationURL == "" || p.TokenURL == "" || p.JWKSURL == ""
+}
@@ -0,0 +1,40 @@
+/*
+   package config
+   oidc_test.go
+   - test unit for oidc
+*/
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestOIDCConfig is for testing oidc config behaviour
+func TestOIDCConfig(t *testing.T) {
+    provider := OIDCProvider{
+        Name        : "google",
+        Issuer      : "https://accounts.google.com",
+        ClientID    : "lbw-go",
+        RedirectURL : "https://lotusbw.com/account/oidc/google/callback",
+    }
+    oidc := OIDC{Providers: []OIDCProvider{provider}}
+
+    got, ok := oidc.Provider("google")
+    assert.True(t, ok)
+    assert.Equal(t, provider, got)
+    assert.True(t, got.IsValid())
+    assert.True(t, got.NeedDiscovery())
+
+    got.AuthorizationURL = "https://accounts.google.com/o/oauth2/v2/auth"
+    got.TokenURL = "https://oauth2.googleapis.com/token"
+    got.JWKSURL = "https://www.googleapis.com/oauth2/v3/certs"
+    assert.False(t, got.NeedDiscovery())
+
+    got.ClientID = ""
+    assert.False(t, got.IsValid())
+
+    _, ok = oidc.Provider("github")
+    assert.False(t, ok)
+}
@@ -24,6 +24,24 @@ type Server struct {
     // MinimumSecureKeyLength is the minimum length required for the secret key
     MinimumSecureKeyLength     int
 
+    // SigningMethod is algorithm to sign auth token, value is "HS256" (default), "RS256" or "EdDSA"
+    // HS256 sign the token with SecureKey, the others sign with the private key on SigningKeyFile
+    SigningMethod              string
+
+    // SigningKeyID is key id ('kid' header) of the signing key
+    SigningKeyID               string
+
+    // SigningKeyFile is path to PEM encoded private key for RS256 or EdDSA signing method
+    SigningKeyFile             string
+
+    // SigningKeys is key ring to sign and verify auth token. when it is set, it replace
+    // the single key of SigningMethod, SigningKeyID, SigningKeyFile and SecureKey
+    SigningKeys                []SigningKey
+
+    // ActiveSigningKeyID is id of the key on SigningKeys used to sign new token,
+    // the other keys are only used to verify token signed before they were rotated
+    ActiveSigningKeyID         string
+
     // AccessTokenExpireDuration is valid duration for the token before expired
     AccessTokenExpireDuration  int64
 
@@ -43,6 +61,22 @@ type Server struct {
     WelcomeMessage             bool
 }
 
+// SigningKey is configuration of a key on the signing key ring
+type SigningKey struct {
+    // ID is key id, it is written to the 'kid' header of the token
+    ID                         string
+
+    // Method is signing algorithm of the key, value is "HS256", "RS256" or "EdDSA"
+    Method                     string
+
+    // Secret is secret of HS256 key
+    Secret                     string
+
+    // File is path to PEM encoded key of RS256 or EdDSA key. verify only key
+    // can use public key, while the active key must use private key
+    File                       string
+}
+
 // SetMode is to set server mode 
 func (s *Server) SetMode(mode string) (error) {
     mode = strings.ToLower(mode)
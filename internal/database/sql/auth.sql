@@ -0,0 +1,251 @@
+-- DROP TABLE public.revoked_token;
+CREATE TABLE public.revoked_token (
+	id uuid NOT NULL, -- token id (jti claim) of the revoked token
+	expires_at timestamp NOT NULL, -- token expiration datetime, record can be purged after it
+	revoked_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT revoked_token_pk PRIMARY KEY (id)
+);
+CREATE INDEX revoked_token_expires_at_idx ON public.revoked_token (expires_at);
+COMMENT ON TABLE public.revoked_token IS 'denylist of revoked (signed out) auth token';
+
+-- Column comments
+COMMENT ON COLUMN public.revoked_token.id IS 'token id (jti claim) of the revoked token';
+COMMENT ON COLUMN public.revoked_token.expires_at IS 'token expiration datetime, record can be purged after it';
+
+-- Permissions
+ALTER TABLE public.revoked_token OWNER TO lotus;
+GRANT ALL ON TABLE public.revoked_token TO lotus;
+-- ----------------------------------------------
+
+
+
+-- DROP TABLE public.revoked_user_token;
+CREATE TABLE public.revoked_user_token (
+	user_id uuid NOT NULL,
+	revoked_before timestamptz NOT NULL, -- any token of the user issued on or before it (second precision) is revoked
+	CONSTRAINT revoked_user_token_pk PRIMARY KEY (user_id),
+	CONSTRAINT revoked_user_token_users_fk FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+COMMENT ON TABLE public.revoked_user_token IS 'cutoff datetime to revoke all outstanding auth token of the user';
+
+-- Column comments
+COMMENT ON COLUMN public.revoked_user_token.revoked_before IS 'any token of the user issued on or before it (second precision) is revoked';
+
+-- Permissions
+ALTER TABLE public.revoked_user_token OWNER TO lotus;
+GRANT ALL ON TABLE public.revoked_user_token TO lotus;
+-- ----------------------------------------------
+
+
+
+-- DROP TABLE public.refresh_token_family;
+CREATE TABLE public.refresh_token_family (
+	id uuid NOT NULL, -- family id (fid claim) shared by all refresh token rotated from the same signin
+	user_id uuid NOT NULL,
+	created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	revoked_at timestamp NULL, -- all token of the family is rejected once it is set
+	CONSTRAINT refresh_token_family_pk PRIMARY KEY (id),
+	CONSTRAINT refresh_token_family_users_fk FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX refresh_token_family_user_id_idx ON public.refresh_token_family (user_id);
+COMMENT ON TABLE public.refresh_token_family IS 'refresh token family, created on signin and shared by its rotated refresh token';
+
+-- Column comments
+COMMENT ON COLUMN public.refresh_token_family.id IS 'family id (fid claim) shared by all refresh token rotated from the same signin';
+COMMENT ON COLUMN public.refresh_token_family.revoked_at IS 'all token of the family is rejected once it is set';
+
+-- Permissions
+ALTER TABLE public.refresh_token_family OWNER TO lotus;
+GRANT ALL ON TABLE public.refresh_token_family TO lotus;
+-- ----------------------------------------------
+
+
+
+-- DROP TABLE public.refresh_token;
+CREATE TABLE public.refresh_token (
+	id uuid NOT NULL, -- token id (jti claim) of the refresh token
+	family_id uuid NOT NULL,
+	expires_at timestamp NOT NULL, -- token expiration datetime, record can be purged after it
+	created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	used_at timestamp NULL, -- set when the token is rotated, using it again revoke its family
+	CONSTRAINT refresh_token_pk PRIMARY KEY (id),
+	CONSTRAINT refresh_token_family_fk FOREIGN KEY (family_id) REFERENCES public.refresh_token_family(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX refresh_token_family_id_idx ON public.refresh_token (family_id);
+CREATE INDEX refresh_token_expires_at_idx ON public.refresh_token (expires_at);
+COMMENT ON TABLE public.refresh_token IS 'issued refresh token, each token can only be used once';
+
+-- Column comments
+COMMENT ON COLUMN public.refresh_token.id IS 'token id (jti claim) of the refresh token';
+COMMENT ON COLUMN public.refresh_token.expires_at IS 'token expiration datetime, record can be purged after it';
+COMMENT ON COLUMN public.refresh_token.used_at IS 'set when the token is rotated, using it again revoke its family';
+
+-- Permissions
+ALTER TABLE public.refresh_token OWNER TO lotus;
+GRANT ALL ON TABLE public.refresh_token TO lotus;
+-- ----------------------------------------------
+
+
+
+-- DROP TABLE public.user_session;
+CREATE TABLE public.user_session (
+	id uuid NOT NULL, -- id of the refresh token family started by the signin
+	user_id uuid NOT NULL,
+	user_agent varchar(255) NOT NULL DEFAULT '', -- user agent of the client on the last signin or refresh
+	ip_address varchar(45) NOT NULL DEFAULT '', -- ip address of the client on the last signin or refresh
+	created_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	last_refreshed_at timestamptz NOT NULL DEFAULT CURRENT_TIMESTAMP, -- datetime the token of the session was last issued
+	CONSTRAINT user_session_pk PRIMARY KEY (id),
+	CONSTRAINT user_session_refresh_token_family_fk FOREIGN KEY (id) REFERENCES public.refresh_token_family(id) ON DELETE CASCADE ON UPDATE CASCADE,
+	CONSTRAINT user_session_users_fk FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX user_session_user_id_idx ON public.user_session (user_id);
+COMMENT ON TABLE public.user_session IS 'signed in session (device) of the user, one per refresh token family';
+
+-- Column comments
+COMMENT ON COLUMN public.user_session.id IS 'id of the refresh token family started by the signin';
+COMMENT ON COLUMN public.user_session.user_agent IS 'user agent of the client on the last signin or refresh';
+COMMENT ON COLUMN public.user_session.ip_address IS 'ip address of the client on the last signin or refresh';
+COMMENT ON COLUMN public.user_session.last_refreshed_at IS 'datetime the token of the session was last issued';
+
+-- Permissions
+ALTER TABLE public.user_session OWNER TO lotus;
+GRANT ALL ON TABLE public.user_session TO lotus;
+-- ----------------------------------------------
+
+
+
+-- DROP TABLE public.signing_key;
+CREATE TABLE public.signing_key (
+	id varchar(64) NOT NULL, -- key id ('kid' header) of the key on server.signingkeys
+	active bool NOT NULL DEFAULT false, -- whether the key sign new token, only one key is active
+	deactivated_at timestamptz NULL, -- datetime the key stop signing new token
+	retired_at timestamptz NULL, -- datetime the key was removed from the key ring
+	CONSTRAINT signing_key_pk PRIMARY KEY (id)
+);
+COMMENT ON TABLE public.signing_key IS 'state of the auth token signing key ring shared by all server instance';
+
+-- Column comments
+COMMENT ON COLUMN public.signing_key.id IS 'key id (''kid'' header) of the key on server.signingkeys';
+COMMENT ON COLUMN public.signing_key.active IS 'whether the key sign new token, only one key is active';
+COMMENT ON COLUMN public.signing_key.deactivated_at IS 'datetime the key stop signing new token';
+COMMENT ON COLUMN public.signing_key.retired_at IS 'datetime the key was removed from the key ring';
+
+-- Permissions
+ALTER TABLE public.signing_key OWNER TO lotus;
+GRANT ALL ON TABLE public.signing_key TO lotus;
+-- ----------------------------------------------
+
+
+
+-- DROP TABLE public.signin_attempt;
+CREATE TABLE public.signin_attempt (
+	"scope" varchar(8) NOT NULL, -- 'user' for attempt on user account, 'ip' for attempt from client ip
+	subject varchar(64) NOT NULL, -- user id or client ip address
+	failed_count int4 NOT NULL DEFAULT 0, -- consecutive failed attempt since the last lock or successful signin
+	last_failed_at timestamp NOT NULL, -- datetime of the last failed attempt
+	locked_until timestamp NULL, -- signin is rejected until this datetime
+	CONSTRAINT signin_attempt_pk PRIMARY KEY ("scope",subject)
+);
+COMMENT ON TABLE public.signin_attempt IS 'failed signin attempt per user account and per client ip';
+
+-- Column comments
+COMMENT ON COLUMN public.signin_attempt."scope" IS '''user'' for attempt on user account, ''ip'' for attempt from client ip';
+COMMENT ON COLUMN public.signin_attempt.subject IS 'user id or client ip address';
+COMMENT ON COLUMN public.signin_attempt.failed_count IS 'consecutive failed attempt since the last lock or successful signin';
+COMMENT ON COLUMN public.signin_attempt.last_failed_at IS 'datetime of the last failed attempt';
+COMMENT ON COLUMN public.signin_attempt.locked_until IS 'signin is rejected until this datetime';
+
+-- Permissions
+ALTER TABLE public.signin_attempt OWNER TO lotus;
+GRANT ALL ON TABLE public.signin_attempt TO lotus;
+-- ----------------------------------------------
+
+
+
+-- DROP TABLE public.user_api_key;
+CREATE TABLE public.user_api_key (
+	id uuid NOT NULL,
+	user_id uuid NOT NULL,
+	"name" varchar(50) NOT NULL, -- label given by the owner to recognise the key
+	prefix varchar(12) NOT NULL, -- first characters of the key, shown on the key list
+	key_hash varchar(64) NOT NULL, -- sha256 hash of the key, the key itself is only shown once
+	scopes _text NOT NULL DEFAULT '{}'::text[], -- permission code the key is allowed to use
+	expires_at timestamp NULL, -- key expiration datetime, the key never expire when it is null
+	last_used_at timestamp NULL, -- datetime the key was last used to authorize a request
+	created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	revoked_at timestamp NULL, -- the key is rejected once it is set
+	CONSTRAINT user_api_key_pk PRIMARY KEY (id),
+	CONSTRAINT user_api_key_hash_un UNIQUE (key_hash),
+	CONSTRAINT user_api_key_users_fk FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX user_api_key_user_id_idx ON public.user_api_key (user_id);
+COMMENT ON TABLE public.user_api_key IS 'long-lived api key (personal access token) of the user for machine client';
+
+-- Column comments
+COMMENT ON COLUMN public.user_api_key."name" IS 'label given by the owner to recognise the key';
+COMMENT ON COLUMN public.user_api_key.prefix IS 'first characters of the key, shown on the key list';
+COMMENT ON COLUMN public.user_api_key.key_hash IS 'sha256 hash of the key, the key itself is only shown once';
+COMMENT ON COLUMN public.user_api_key.scopes IS 'permission code the key is allowed to use';
+COMMENT ON COLUMN public.user_api_key.expires_at IS 'key expiration datetime, the key never expire when it is null';
+COMMENT ON COLUMN public.user_api_key.last_used_at IS 'datetime the key was last used to authorize a request';
+COMMENT ON COLUMN public.user_api_key.revoked_at IS 'the key is rejected once it is set';
+
+-- Permissions
+ALTER TABLE public.user_api_key OWNER TO lotus;
+GRANT ALL ON TABLE public.user_api_key TO lotus;
+-- ----------------------------------------------
+
+
+
+-- DROP TABLE public.oidc_state;
+CREATE TABLE public.oidc_state (
+	state_hash varchar(64) NOT NULL, -- sha256 hash of the state sent to the provider
+	provider varchar(30) NOT NULL, -- name of the openid connect provider on the configuration
+	code_verifier varchar(128) NOT NULL, -- pkce code verifier, only its challenge is sent to the provider
+	nonce varchar(64) NOT NULL, -- nonce the id token must carry
+	expires_at timestamp NOT NULL, -- state expiration datetime, record can be purged after it
+	created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT oidc_state_pk PRIMARY KEY (state_hash)
+);
+CREATE INDEX oidc_state_expires_at_idx ON public.oidc_state (expires_at);
+COMMENT ON TABLE public.oidc_state IS 'pending openid connect signin, each state can only be used once';
+
+-- Column comments
+COMMENT ON COLUMN public.oidc_state.state_hash IS 'sha256 hash of the state sent to the provider';
+COMMENT ON COLUMN public.oidc_state.provider IS 'name of the openid connect provider on the configuration';
+COMMENT ON COLUMN public.oidc_state.code_verifier IS 'pkce code verifier, only its challenge is sent to the provider';
+COMMENT ON COLUMN public.oidc_state.nonce IS 'nonce the id token must carry';
+COMMENT ON COLUMN public.oidc_state.expires_at IS 'state expiration datetime, record can be purged after it';
+
+-- Permissions
+ALTER TABLE public.oidc_state OWNER TO lotus;
+GRANT ALL ON TABLE public.oidc_state TO lotus;
+-- ----------------------------------------------
+
+
+
+-- DROP TABLE public.user_identity;
+CREATE TABLE public.user_identity (
+	provider varchar(30) NOT NULL, -- name of the openid connect provider on the configuration
+	subject varchar(255) NOT NULL, -- id of the user on the provider (sub claim)
+	user_id uuid NOT NULL,
+	email varchar(100) NOT NULL, -- email given by the provider when the identity was linked
+	created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	last_signin_at timestamp NULL, -- datetime of the last signin with the identity
+	CONSTRAINT user_identity_pk PRIMARY KEY (provider,subject),
+	CONSTRAINT user_identity_users_fk FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX user_identity_user_id_idx ON public.user_identity (user_id);
+COMMENT ON TABLE public.user_identity IS 'identity of the user on openid connect provider (social login)';
+
+-- Column comments
+COMMENT ON COLUMN public.user_identity.provider IS 'name of the openid connect provider on the configuration';
+COMMENT ON COLUMN public.user_identity.subject IS 'id of the user on the provider (sub claim)';
+COMMENT ON COLUMN public.user_identity.email IS 'email given by the provider when the identity was linked';
+COMMENT ON COLUMN public.user_identity.last_signin_at IS 'datetime of the last signin with the identity';
+
+-- Permissions
+ALTER TABLE public.user_identity OWNER TO lotus;
+GRANT ALL ON TABLE public.user_identity TO lotus;
+-- ----------------------------------------------
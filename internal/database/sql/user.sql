@@ -6,11 +6,13 @@ CREATE TABLE public.user_role (
 	created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
 	updated_at timestamp NULL,
 	deleted_at timestamp NULL,
-	CONSTRAINT user_role_name_un UNIQUE (role_name),
 	CONSTRAINT user_role_pk PRIMARY KEY (id)
 );
 COMMENT ON TABLE public.user_role IS 'user role containing role hold by the user';
 
+-- role name is unique among the role that is not (soft) deleted
+CREATE UNIQUE INDEX user_role_name_un ON public.user_role (role_name) WHERE deleted_at IS NULL;
+
 -- Permissions
 ALTER TABLE public.user_role OWNER TO lotus;
 GRANT ALL ON TABLE public.user_role TO lotus;
@@ -18,6 +20,60 @@ GRANT ALL ON TABLE public.user_role TO lotus;
 
 
 
+-- DROP TABLE public.permission;
+CREATE TABLE public.permission (
+	code varchar(50) NOT NULL, -- permission code in 'resource:action' format
+	description text NULL,
+	created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT permission_pk PRIMARY KEY (code)
+);
+COMMENT ON TABLE public.permission IS 'permission catalogue';
+
+-- Column comments
+COMMENT ON COLUMN public.permission.code IS 'permission code in ''resource:action'' format';
+
+-- Permissions
+ALTER TABLE public.permission OWNER TO lotus;
+GRANT ALL ON TABLE public.permission TO lotus;
+
+INSERT INTO public.permission (code,description) VALUES
+	('role:read','read user role and its permission'),
+	('role:write','create, update and delete user role and grant or revoke its permission'),
+	('user:read','read other user'),
+	('user:write','create, update and delete other user'),
+	('key:manage','promote and retire auth token signing key'),
+	('mail:config:read','read mail app configuration'),
+	('mail:config:write','change mail app configuration'),
+	('audit:read','read audit log of the account app')
+ON CONFLICT (code) DO NOTHING;
+-- ----------------------------------------------
+
+
+
+-- DROP TABLE public.role_permission;
+CREATE TABLE public.role_permission (
+	role_id int2 NOT NULL,
+	permission_code varchar(50) NOT NULL,
+	granted_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT role_permission_pk PRIMARY KEY (role_id,permission_code),
+	CONSTRAINT role_permission_user_role_fk FOREIGN KEY (role_id) REFERENCES public.user_role(id) ON DELETE CASCADE ON UPDATE CASCADE,
+	CONSTRAINT role_permission_permission_fk FOREIGN KEY (permission_code) REFERENCES public.permission(code) ON DELETE CASCADE ON UPDATE CASCADE
+);
+COMMENT ON TABLE public.role_permission IS 'permission granted to user role';
+
+-- Permissions
+ALTER TABLE public.role_permission OWNER TO lotus;
+GRANT ALL ON TABLE public.role_permission TO lotus;
+
+-- default grant of superuser (1) and administrator (2)
+INSERT INTO public.role_permission (role_id,permission_code)
+	SELECT r.id, p.code FROM public.user_role r CROSS JOIN public.permission p
+	WHERE r.id = 1 OR (r.id = 2 AND p.code <> 'key:manage')
+ON CONFLICT (role_id,permission_code) DO NOTHING;
+-- ----------------------------------------------
+
+
+
 -- DROP TABLE public.user_status;
 CREATE TABLE public.user_status (
 	id int2 NOT NULL,
@@ -43,22 +99,25 @@ CREATE TABLE public.users (
 	firstname varchar(30) NOT NULL,
 	lastname varchar(30) NULL,
 	email varchar(100) NOT NULL,
-	passkey varchar(100) NOT NULL,
+	passkey varchar(255) NOT NULL, -- password hash on PHC format (bcrypt or argon2id)
 	status_id int2 NOT NULL DEFAULT 0, -- user status
 	role_id int2 NOT NULL DEFAULT 0, -- user role on system
 	created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
 	updated_at timestamp NULL,
 	activated_at timestamp NULL, -- account activation datetime
 	deleted_at timestamp NULL, -- account (soft) delete datetime
-	CONSTRAINT users_email_un UNIQUE (email),
 	CONSTRAINT users_pk PRIMARY KEY (id),
-	CONSTRAINT users_username_un UNIQUE (username),
 	CONSTRAINT users_user_role_fk FOREIGN KEY (role_id) REFERENCES public.user_role(id) ON DELETE SET NULL ON UPDATE CASCADE,
 	CONSTRAINT users_user_status_fk FOREIGN KEY (status_id) REFERENCES public.user_status(id) ON DELETE SET NULL ON UPDATE CASCADE
 );
 COMMENT ON TABLE public.users IS 'User table';
 
+-- username and email are unique among the user that is not (soft) deleted
+CREATE UNIQUE INDEX users_email_un ON public.users (email) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX users_username_un ON public.users (username) WHERE deleted_at IS NULL;
+
 -- Column comments
+COMMENT ON COLUMN public.users.passkey IS 'password hash on PHC format (bcrypt or argon2id)';
 COMMENT ON COLUMN public.users.status_id IS 'user status';
 COMMENT ON COLUMN public.users.role_id IS 'user role on system';
 COMMENT ON COLUMN public.users.activated_at IS 'account activation datetime';
@@ -68,3 +127,165 @@ COMMENT ON COLUMN public.users.deleted_at IS 'account (soft) delete datetime';
 ALTER TABLE public.users OWNER TO lotus;
 GRANT ALL ON TABLE public.users TO lotus;
 -- ----------------------------------------------
+
+
+
+-- DROP TABLE public.user_activation;
+CREATE TABLE public.user_activation (
+	token_hash varchar(64) NOT NULL, -- sha256 hash of the activation token sent to the user
+	user_id uuid NOT NULL,
+	expires_at timestamp NOT NULL, -- activation token expiration datetime
+	used_at timestamp NULL, -- datetime the token was used, token is single use
+	created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT user_activation_pk PRIMARY KEY (token_hash),
+	CONSTRAINT user_activation_users_fk FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX user_activation_user_id_idx ON public.user_activation (user_id);
+COMMENT ON TABLE public.user_activation IS 'account activation token';
+
+-- Column comments
+COMMENT ON COLUMN public.user_activation.token_hash IS 'sha256 hash of the activation token sent to the user';
+COMMENT ON COLUMN public.user_activation.expires_at IS 'activation token expiration datetime';
+COMMENT ON COLUMN public.user_activation.used_at IS 'datetime the token was used, token is single use';
+
+-- Permissions
+ALTER TABLE public.user_activation OWNER TO lotus;
+GRANT ALL ON TABLE public.user_activation TO lotus;
+-- ----------------------------------------------
+
+
+
+-- DROP TABLE public.user_password_reset;
+CREATE TABLE public.user_password_reset (
+	token_hash varchar(64) NOT NULL, -- sha256 hash of the password reset token sent to the user
+	user_id uuid NOT NULL,
+	expires_at timestamp NOT NULL, -- password reset token expiration datetime
+	used_at timestamp NULL, -- datetime the token was used, token is single use
+	created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT user_password_reset_pk PRIMARY KEY (token_hash),
+	CONSTRAINT user_password_reset_users_fk FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX user_password_reset_user_id_idx ON public.user_password_reset (user_id);
+COMMENT ON TABLE public.user_password_reset IS 'password reset token';
+
+-- Column comments
+COMMENT ON COLUMN public.user_password_reset.token_hash IS 'sha256 hash of the password reset token sent to the user';
+COMMENT ON COLUMN public.user_password_reset.expires_at IS 'password reset token expiration datetime';
+COMMENT ON COLUMN public.user_password_reset.used_at IS 'datetime the token was used, token is single use';
+
+-- Permissions
+ALTER TABLE public.user_password_reset OWNER TO lotus;
+GRANT ALL ON TABLE public.user_password_reset TO lotus;
+-- ----------------------------------------------
+
+
+
+-- DROP TABLE public.user_password_history;
+CREATE TABLE public.user_password_history (
+	id bigserial NOT NULL,
+	user_id uuid NOT NULL,
+	passkey varchar(255) NOT NULL, -- hashed passkey replaced on password change or reset
+	created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP, -- datetime the passkey was replaced
+	CONSTRAINT user_password_history_pk PRIMARY KEY (id),
+	CONSTRAINT user_password_history_users_fk FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX user_password_history_user_id_idx ON public.user_password_history (user_id, created_at);
+COMMENT ON TABLE public.user_password_history IS 'previous passkey of the user, checked against password reuse';
+
+-- Column comments
+COMMENT ON COLUMN public.user_password_history.passkey IS 'hashed passkey replaced on password change or reset';
+COMMENT ON COLUMN public.user_password_history.created_at IS 'datetime the passkey was replaced';
+
+-- Permissions
+ALTER TABLE public.user_password_history OWNER TO lotus;
+GRANT ALL ON TABLE public.user_password_history TO lotus;
+-- ----------------------------------------------
+
+
+
+-- DROP TABLE public.user_totp;
+CREATE TABLE public.user_totp (
+	user_id uuid NOT NULL,
+	secret text NOT NULL, -- encrypted totp secret
+	last_used_step int8 NULL, -- time step of the last accepted code, the same code can not be used twice
+	enabled_at timestamp NULL, -- datetime the two factor authentication was confirmed
+	created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT user_totp_pk PRIMARY KEY (user_id),
+	CONSTRAINT user_totp_users_fk FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+COMMENT ON TABLE public.user_totp IS 'user two factor authentication (totp) secret';
+
+-- Column comments
+COMMENT ON COLUMN public.user_totp.secret IS 'encrypted totp secret';
+COMMENT ON COLUMN public.user_totp.last_used_step IS 'time step of the last accepted code, the same code can not be used twice';
+COMMENT ON COLUMN public.user_totp.enabled_at IS 'datetime the two factor authentication was confirmed';
+
+-- Permissions
+ALTER TABLE public.user_totp OWNER TO lotus;
+GRANT ALL ON TABLE public.user_totp TO lotus;
+-- ----------------------------------------------
+
+
+
+-- DROP TABLE public.user_recovery_code;
+CREATE TABLE public.user_recovery_code (
+	code_hash varchar(64) NOT NULL, -- sha256 hash of the recovery code given to the user
+	user_id uuid NOT NULL,
+	used_at timestamp NULL, -- datetime the code was used, code is single use
+	created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT user_recovery_code_pk PRIMARY KEY (code_hash),
+	CONSTRAINT user_recovery_code_users_fk FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX user_recovery_code_user_id_idx ON public.user_recovery_code (user_id);
+COMMENT ON TABLE public.user_recovery_code IS 'two factor authentication recovery code';
+
+-- Column comments
+COMMENT ON COLUMN public.user_recovery_code.code_hash IS 'sha256 hash of the recovery code given to the user';
+COMMENT ON COLUMN public.user_recovery_code.used_at IS 'datetime the code was used, code is single use';
+
+-- Permissions
+ALTER TABLE public.user_recovery_code OWNER TO lotus;
+GRANT ALL ON TABLE public.user_recovery_code TO lotus;
+-- ----------------------------------------------
+
+
+
+-- DROP TABLE public.audit_log;
+CREATE TABLE public.audit_log (
+	id bigserial NOT NULL,
+	actor_id uuid NULL, -- id of the user making the change, empty when the change is not made by signed in user
+	"action" varchar(32) NOT NULL, -- what was done to the target (create, update, delete, ...)
+	target_type varchar(32) NOT NULL, -- type of the changed record (user, role, ...)
+	target_id varchar(64) NOT NULL, -- id of the changed record
+	changes jsonb NOT NULL DEFAULT '{}'::jsonb, -- changed field with its value before and after the change
+	ip_address varchar(45) NOT NULL DEFAULT '', -- ip address of the client making the change
+	created_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	CONSTRAINT audit_log_pk PRIMARY KEY (id)
+);
+CREATE INDEX audit_log_created_at_idx ON public.audit_log (created_at,id);
+CREATE INDEX audit_log_actor_id_idx ON public.audit_log (actor_id);
+CREATE INDEX audit_log_target_idx ON public.audit_log (target_type,target_id);
+COMMENT ON TABLE public.audit_log IS 'append-only record of change made on the account app. actor and target is not referenced so the record outlive the purged user';
+
+-- Column comments
+COMMENT ON COLUMN public.audit_log.actor_id IS 'id of the user making the change, empty when the change is not made by signed in user';
+COMMENT ON COLUMN public.audit_log."action" IS 'what was done to the target (create, update, delete, ...)';
+COMMENT ON COLUMN public.audit_log.target_type IS 'type of the changed record (user, role, ...)';
+COMMENT ON COLUMN public.audit_log.target_id IS 'id of the changed record';
+COMMENT ON COLUMN public.audit_log.changes IS 'changed field with its value before and after the change';
+COMMENT ON COLUMN public.audit_log.ip_address IS 'ip address of the client making the change';
+
+-- audit record can only be appended
+CREATE OR REPLACE FUNCTION public.audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_append_only_trg BEFORE UPDATE OR DELETE OR TRUNCATE ON public.audit_log
+	FOR EACH STATEMENT EXECUTE FUNCTION public.audit_log_append_only();
+
+-- Permissions
+ALTER TABLE public.audit_log OWNER TO lotus;
+GRANT ALL ON TABLE public.audit_log TO lotus;
+-- ----------------------------------------------
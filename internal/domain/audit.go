@@ -0,0 +1,181 @@
+/*
+    package domain
+    audit.go
+    - containing audit log (record of change made on the account app) model
+*/
+package domain
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+    // AuditActionCreate is action of creating the target
+    AuditActionCreate = "create"
+
+    // AuditActionUpdate is action of updating the target
+    AuditActionUpdate = "update"
+
+    // AuditActionDelete is action of (soft) deleting the target
+    AuditActionDelete = "delete"
+
+    // AuditActionRestore is action of restoring (soft) deleted target
+    AuditActionRestore = "restore"
+
+    // AuditActionPurge is action of permanently removing (soft) deleted target
+    AuditActionPurge = "purge"
+
+    // AuditActionGrant is action of granting permission to the target
+    AuditActionGrant = "grant"
+
+    // AuditActionRevoke is action of revoking the target or permission of the target
+    AuditActionRevoke = "revoke"
+
+    // AuditActionActivate is action of activating user account
+    AuditActionActivate = "activate"
+
+    // AuditActionPasswordChange is action of changing password of the target
+    AuditActionPasswordChange = "password_change"
+
+    // AuditActionPasswordReset is action of resetting password of the target
+    AuditActionPasswordReset = "password_reset"
+
+    // AuditActionEnable2FA is action of enabling two factor authentication of the target
+    AuditActionEnable2FA = "enable_2fa"
+
+    // AuditActionRevokeSessions is action of revoking all session of the target user
+    AuditActionRevokeSessions = "revoke_sessions"
+
+    // AuditActionPromote is action of promoting signing key
+    AuditActionPromote = "promote"
+
+    // AuditActionRetire is action of retiring signing key
+    AuditActionRetire = "retire"
+
+    // AuditTargetUser is target type of user record
+    AuditTargetUser = "user"
+
+    // AuditTargetRole is target type of user.role record
+    AuditTargetRole = "role"
+
+    // AuditTargetAPIKey is target type of user api key
+    AuditTargetAPIKey = "api_key"
+
+    // AuditTargetSession is target type of user session
+    AuditTargetSession = "session"
+
+    // AuditTargetSigningKey is target type of auth token signing key
+    AuditTargetSigningKey = "signing_key"
+
+    // auditNameLength is max length of the audit action and target type
+    auditNameLength = 32
+
+    // auditTargetIDLength is max length of the audit target id
+    auditTargetIDLength = 64
+
+    // auditIPAddressLength is max length of the client ip address kept on the audit log
+    auditIPAddressLength = 45
+)
+
+// AuditSortFields is audit log field that can be used to sort audit log list,
+// the first field is the default sort field
+var AuditSortFields = []string{"created_at"}
+
+// AuditChange is value of the field before and after the change
+type AuditChange struct {
+    // Before is value of the field before the change, it is empty on creation
+    Before interface{} `json:"before"`
+
+    // After is value of the field after the change, it is empty on removal
+    After  interface{} `json:"after"`
+}
+
+// AuditChanges is changed field of the target with its value before and after the change
+type AuditChanges map[string]AuditChange
+
+// NewAuditChanges will create the changed field of the target by comparing its json
+// representation before and after the change. nil 'before' is creation, nil 'after' is removal
+func NewAuditChanges(before, after interface{}) AuditChanges {
+    b, a := auditFields(before), auditFields(after)
+
+    changes := AuditChanges{}
+    for field, value := range b {
+        if !reflect.DeepEqual(value, a[field]) {
+            changes[field] = AuditChange{Before: value, After: a[field]}
+        }
+    }
+    for field, value := range a {
+        if _, ok := b[field]; !ok {
+            changes[field] = AuditChange{After: value}
+        }
+    }
+
+    return changes
+}
+
+// auditFields will get json field of the value, value that is not json object has no field
+func auditFields(v interface{}) map[string]interface{} {
+    fields := map[string]interface{}{}
+    if v == nil {
+        return fields
+    }
+
+    b, err := json.Marshal(v)
+    if err != nil {
+        return fields
+    }
+    _ = json.Unmarshal(b, &fields)
+
+    return fields
+}
+
+// AuditLog is model for append-only record of change made on the account app
+type AuditLog struct {
+    // ID is id of the audit log, it is in the order the change was recorded
+    ID         int64        `json:"id"`
+
+    // ActorID is id of the user making the change, it is empty when the change is
+    // not made by signed in user
+    ActorID    *uuid.UUID   `json:"actor_id"`
+
+    // Action is what was done to the target
+    Action     string       `json:"action"`
+
+    // TargetType is type of the changed record
+    TargetType string       `json:"target_type"`
+
+    // TargetID is id of the changed record
+    TargetID   string       `json:"target_id"`
+
+    // Changes is changed field of the target with its value before and after the change
+    Changes    AuditChanges `json:"changes"`
+
+    // IPAddress is ip address of the client making the change
+    IPAddress  string       `json:"ip_address"`
+
+    // CreatedAt is the datetime the change was made
+    CreatedAt  time.Time    `json:"created_at"`
+}
+
+// NewAuditLog will create audit log of the action on the target, the changes is taken
+// from the target value before and after the action
+func NewAuditLog(action, targetType, targetID string, before, after interface{}) *AuditLog {
+    return &AuditLog{
+        Action     : action,
+        TargetType : targetType,
+        TargetID   : targetID,
+        Changes    : NewAuditChanges(before, after),
+    }
+}
+
+// IsValid is to check whether audit log is valid to be recorded
+func (a *AuditLog) IsValid() bool {
+    return a.Action != "" && len(a.Action) <= auditNameLength &&
+        a.TargetType != "" && len(a.TargetType) <= auditNameLength &&
+        a.TargetID != "" && len(a.TargetID) <= auditTargetIDLength &&
+        len(a.IPAddress) <= auditIPAddressLength
+}
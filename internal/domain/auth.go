@@ -1,11 +1,15 @@
 package domain
 
-import "time"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 // AuthLoginDTO is 'DTO' (Data Transfer Object) to verify user on login
 type AuthLoginDTO struct {
-    Email    string `json:"email"`
-    Passkey  string `json:"passkey"`
+    Email    string `json:"email" binding:"required,email"`
+    Passkey  string `json:"passkey" binding:"required"`
 }
 
 // AuthLoginResponse is 'DTO' (Data Transfer Object) to 'Response'
@@ -16,13 +20,195 @@ type AuthLoginResponse struct {
     TransmissionKey string  `json:"transmission_key"`
 }
 
+// AuthTwoFactorResponse is 'DTO' (Data Transfer Object) to 'Response' on 'login'
+// of user with two factor authentication enabled
+type AuthTwoFactorResponse struct {
+    // TwoFactorRequired is always true, the signin must be completed with the two factor code
+    TwoFactorRequired bool      `json:"two_factor_required"`
+
+    // PendingToken is short lived token to be exchanged with the real token on the second step
+    PendingToken      string    `json:"pending_token"`
+
+    // ExpiresAt is the pending token expiration datetime
+    ExpiresAt         time.Time `json:"expires_at"`
+}
+
+// AuthTwoFactorDTO is 'DTO' (Data Transfer Object) to complete the signin
+// with the two factor code
+type AuthTwoFactorDTO struct {
+    // PendingToken is the token given on the first signin step
+    PendingToken string `json:"pending_token" binding:"required"`
+
+    // Code is the code shown on the authenticator app or one of the recovery codes
+    Code         string `json:"code" binding:"required"`
+}
+
+const (
+    // SigninScopeUser is scope of signin attempt on a user account, its subject is the user id
+    SigninScopeUser = "user"
+
+    // SigninScopeIP is scope of signin attempt from a client ip, its subject is the ip address
+    SigninScopeIP = "ip"
+)
+
+// SigninAttempt is model of failed signin attempt on a user account or from a client ip
+type SigninAttempt struct {
+    // Scope is SigninScopeUser or SigninScopeIP
+    Scope        string     `json:"scope"`
+
+    // Subject is the user id or the client ip address
+    Subject      string     `json:"subject"`
+
+    // FailedCount is consecutive failed attempt since the last lock or successful signin
+    FailedCount  int        `json:"failed_count"`
+
+    // LastFailedAt is datetime of the last failed attempt
+    LastFailedAt time.Time  `json:"last_failed_at"`
+
+    // LockedUntil is datetime the signin is rejected until
+    LockedUntil  *time.Time `json:"locked_until"`
+}
+
 // TokenDetailsDTO is 'DTO' (data Transfer Object) containing
 // details of token expiration time
 type TokenDetailsDTO struct {
     AccessToken     string  `json:"access_token"`
     RefreshToken    string  `json:"refresh_token"`
+    AccessTokenID   string  `json:"-"`
+    RefreshTokenID  string  `json:"-"`
+    FamilyID        string  `json:"-"`
     AtExpiresTime   time.Time
     RtExpiresTime   time.Time
     TransmissionKey string  `json:"transmission_key"`
 }
 
+// SignoutRequest is 'DTO' (Data Transfer Object) containing the refresh token
+// that should be revoked together with the access token upon signout
+type SignoutRequest struct {
+    RefreshToken    string  `json:"refresh_token"`
+}
+
+// TokenMetadata is token identity extracted from the token claims
+// it is used to record the revoked token on the denylist
+type TokenMetadata struct {
+    // ID is the token id ('jti' claim)
+    ID          string
+
+    // UserID is id of the token owner ('sub' claim)
+    UserID      uuid.UUID
+
+    // FamilyID is id of the refresh token family the token belong to ('fid' claim)
+    FamilyID    string
+
+    // IssuedAt is the token creation datetime ('iat' claim)
+    IssuedAt    time.Time
+
+    // ExpiresAt is the token expiration datetime ('exp' claim)
+    ExpiresAt   time.Time
+}
+
+// Principal is identity of the authenticated user (the token owner)
+// it is placed on the request context by the authorization middleware
+type Principal struct {
+    // UserID is id of the user ('sub' claim)
+    UserID      uuid.UUID   `json:"user_id"`
+
+    // Email is email of the user
+    Email       string      `json:"email"`
+
+    // RoleID is role id held by the user
+    RoleID      int         `json:"role_id"`
+
+    // StatusID is user status when the token was issued
+    StatusID    int         `json:"status_id"`
+
+    // TokenID is id of the token used on the request ('jti' claim)
+    TokenID     string      `json:"-"`
+
+    // FamilyID is id of the refresh token family of the token ('fid' claim)
+    // token created for principal with empty family id will start a new family
+    FamilyID    string      `json:"-"`
+
+    // APIKeyID is id of the api key used on the request, it is empty when
+    // the request is authorized with auth token
+    APIKeyID    string      `json:"-"`
+
+    // Scopes is permission code the api key is allowed to use. the effective
+    // permission of api key is the role permission limited to its scopes
+    Scopes      []string    `json:"-"`
+}
+
+// IsAPIKey will check whether the principal is authorized with api key
+func (p *Principal) IsAPIKey() bool {
+    return p.APIKeyID != ""
+}
+
+// JWK is public key in JSON Web Key format (RFC 7517) used to verify the auth token
+type JWK struct {
+    // Kty is key type, "RSA", "EC" or "OKP" (Ed25519)
+    Kty         string      `json:"kty"`
+
+    // Use is intended use of the key, always "sig"
+    Use         string      `json:"use"`
+
+    // Kid is key id, it match the 'kid' header of the token signed by the key
+    Kid         string      `json:"kid,omitempty"`
+
+    // Alg is signing algorithm of the key, "RS256" or "EdDSA"
+    Alg         string      `json:"alg"`
+
+    // N is modulus of the RSA public key
+    N           string      `json:"n,omitempty"`
+
+    // E is exponent of the RSA public key
+    E           string      `json:"e,omitempty"`
+
+    // Crv is curve of the EC or OKP public key
+    Crv         string      `json:"crv,omitempty"`
+
+    // X is the OKP public key, or x coordinate of the EC public key
+    X           string      `json:"x,omitempty"`
+
+    // Y is y coordinate of the EC public key
+    Y           string      `json:"y,omitempty"`
+}
+
+// JWKS is set of public key published on /.well-known/jwks.json
+type JWKS struct {
+    Keys        []JWK       `json:"keys"`
+}
+
+// SigningKeyResponse is 'DTO' (Data Transfer Object) containing the state
+// of a key on the signing key ring
+type SigningKeyResponse struct {
+    // ID is key id ('kid' header)
+    ID              string      `json:"id"`
+
+    // Alg is signing algorithm of the key
+    Alg             string      `json:"alg"`
+
+    // Active is whether the key sign new token
+    Active          bool        `json:"active"`
+
+    // CanSign is whether the key has private key so it can be promoted
+    CanSign         bool        `json:"can_sign"`
+
+    // DeactivatedAt is time the key stop signing new token
+    DeactivatedAt   *time.Time  `json:"deactivated_at,omitempty"`
+}
+
+// SigningKeyState is persisted state of a key on the signing key ring. it is shared by
+// all server instance, so promoting and retiring the key survive restart
+type SigningKeyState struct {
+    // ID is key id ('kid' header)
+    ID              string
+
+    // Active is whether the key sign new token
+    Active          bool
+
+    // DeactivatedAt is time the key stop signing new token
+    DeactivatedAt   *time.Time
+
+    // RetiredAt is time the key was removed from the key ring
+    RetiredAt       *time.Time
+}
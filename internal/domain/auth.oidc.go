@@ -0,0 +1,70 @@
+/*
+    package domain
+    auth.oidc.go
+    - containing openid connect (social login) model, request dto and response dto struct
+*/
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OIDCState is model for pending signin with openid connect provider
+type OIDCState struct {
+    // StateHash is sha256 hash of the state sent to the provider
+    StateHash    string    `json:"-"`
+
+    // Provider is name of the provider
+    Provider     string    `json:"provider"`
+
+    // CodeVerifier is the pkce code verifier, only its challenge is sent to the provider
+    CodeVerifier string    `json:"-"`
+
+    // Nonce is nonce the id token must carry
+    Nonce        string    `json:"-"`
+
+    // ExpiresAt is the datetime the state expired
+    ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// UserIdentity is model for identity of the user on openid connect provider
+type UserIdentity struct {
+    // Provider is name of the provider
+    Provider  string    `json:"provider"`
+
+    // Subject is id of the user on the provider
+    Subject   string    `json:"subject"`
+
+    // UserID is id of the user owning the identity
+    UserID    uuid.UUID `json:"user_id"`
+
+    // Email is email given by the provider when the identity was linked
+    Email     string    `json:"email"`
+}
+
+// OIDCAuthorizeResponse is response dto containing the url to send the user to the provider
+type OIDCAuthorizeResponse struct {
+    // AuthorizationURL is url of the provider to signin
+    AuthorizationURL string `json:"authorization_url"`
+
+    // State is the state the provider send back to the callback. client may keep it
+    // to make sure the callback belong to the signin it started
+    State            string `json:"state"`
+}
+
+// OIDCCallbackRequest is request dto sent back by the provider to the callback
+type OIDCCallbackRequest struct {
+    // Code is the authorization code
+    Code             string `form:"code"`
+
+    // State is the state sent to the provider
+    State            string `form:"state"`
+
+    // Error is error code when the user or the provider reject the signin
+    Error            string `form:"error"`
+
+    // ErrorDescription is description of the error
+    ErrorDescription string `form:"error_description"`
+}
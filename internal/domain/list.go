@@ -0,0 +1,194 @@
+/*
+    package domain
+    list.go
+    - containing list query (pagination, sorting and filtering) and list metadata
+*/
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+    // ListDefaultLimit is number of record per page when the limit is not requested
+    ListDefaultLimit = 20
+
+    // ListMaxLimit is maximum number of record per page
+    ListMaxLimit = 100
+
+    // SortAsc is ascending sort order
+    SortAsc = "asc"
+
+    // SortDesc is descending sort order
+    SortDesc = "desc"
+)
+
+var (
+    // UserSortFields is user field that can be used to sort user list,
+    // the first field is the default sort field
+    UserSortFields = []string{"created_at", "username", "email", "firstname"}
+
+    // UserRoleSortFields is user.role field that can be used to sort user.role list,
+    // the first field is the default sort field
+    UserRoleSortFields = []string{"id", "role_name", "created_at"}
+
+    // UserTrashSortFields is field that can be used to sort (soft) deleted user list,
+    // the first field is the default sort field
+    UserTrashSortFields = []string{"deleted_at", "created_at", "username", "email", "firstname"}
+
+    // UserRoleTrashSortFields is field that can be used to sort (soft) deleted user.role list,
+    // the first field is the default sort field
+    UserRoleTrashSortFields = []string{"deleted_at", "id", "role_name", "created_at"}
+)
+
+// ListQuery is request to get list of record with pagination, sorting and filtering
+type ListQuery struct {
+    // Page is requested page number (starting from 1), it is ignored when Cursor is set
+    Page        int
+
+    // Limit is number of record per page
+    Limit       int
+
+    // Cursor is keyset of the last record of previous page, the list start after it
+    Cursor      *ListCursor
+
+    // Sort is field to sort the list
+    Sort        string
+
+    // Order is sort order ("asc" or "desc")
+    Order       string
+
+    // StatusID is filter of user status (user list only)
+    StatusID    *int
+
+    // RoleID is filter of user role (user list only)
+    RoleID      *int
+
+    // CreatedFrom is filter of record created at or after the datetime
+    CreatedFrom *time.Time
+
+    // CreatedTo is filter of record created before the datetime
+    CreatedTo   *time.Time
+
+    // Username is filter of username prefix (user list only)
+    Username    string
+
+    // Email is filter of email prefix (user list only)
+    Email       string
+
+    // Deleted is to list the (soft) deleted record instead of the active one
+    Deleted     bool
+
+    // ActorID is filter of user making the change (audit log list only)
+    ActorID     *uuid.UUID
+
+    // Action is filter of the action (audit log list only)
+    Action      string
+
+    // TargetType is filter of the changed record type (audit log list only)
+    TargetType  string
+
+    // TargetID is filter of the changed record id (audit log list only)
+    TargetID    string
+}
+
+// IsValid is to check whether list query is valid for list that can be sorted by the given fields
+func (q *ListQuery) IsValid(sortFields []string) bool {
+    validSort := false
+    for _, field := range sortFields {
+        if q.Sort == field {
+            validSort = true
+            break
+        }
+    }
+
+    // cursor must be created for the same sort field and order
+    if q.Cursor != nil && (q.Cursor.Sort != q.Sort || q.Cursor.Order != q.Order) {
+        return false
+    }
+
+    // creation date range must not be reversed
+    if q.CreatedFrom != nil && q.CreatedTo != nil && q.CreatedTo.Before(*q.CreatedFrom) {
+        return false
+    }
+
+    // offset of the page must not overflow, page is ignored on cursor pagination
+    return validSort &&
+        (q.Order == SortAsc || q.Order == SortDesc) &&
+        q.Page >= 1 &&
+        q.Limit >= 1 && q.Limit <= ListMaxLimit &&
+        (q.Cursor != nil || q.Page-1 <= math.MaxInt/q.Limit)
+}
+
+// Offset is number of record to skip for the requested page. keyset (cursor)
+// pagination does not skip any record
+func (q *ListQuery) Offset() int {
+    if q.Cursor != nil || q.Page < 1 {
+        return 0
+    }
+
+    return (q.Page - 1) * q.Limit
+}
+
+// ListCursor is keyset of the last record of a page. it is sent to the client
+// as opaque string to get the next page
+type ListCursor struct {
+    // Sort is sort field of the list the cursor created for
+    Sort  string `json:"s"`
+
+    // Order is sort order of the list the cursor created for
+    Order string `json:"o"`
+
+    // Value is sort field value of the last record
+    Value string `json:"v"`
+
+    // ID is id of the last record, it break the tie of records with the same sort value
+    ID    string `json:"id"`
+}
+
+// Encode will encode the cursor into opaque string
+func (lc *ListCursor) Encode() string {
+    b, _ := json.Marshal(lc)
+    return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeListCursor will decode opaque string into list cursor
+func DecodeListCursor(s string) (*ListCursor, error) {
+    b, err := base64.RawURLEncoding.DecodeString(s)
+    if err != nil {
+        return nil, err
+    }
+
+    lc := new(ListCursor)
+    if err := json.Unmarshal(b, lc); err != nil {
+        return nil, err
+    }
+
+    return lc, nil
+}
+
+// ListMeta is metadata of the list sent along with the list record
+type ListMeta struct {
+    // Total is number of record matching the filter
+    Total      int64  `json:"total"`
+
+    // Page is current page number, it is empty on keyset (cursor) pagination
+    Page       int    `json:"page,omitempty"`
+
+    // Limit is number of record per page
+    Limit      int    `json:"limit"`
+
+    // Sort is field the list sorted by
+    Sort       string `json:"sort"`
+
+    // Order is sort order of the list
+    Order      string `json:"order"`
+
+    // NextCursor is cursor to get the next page, it is empty on the last page
+    NextCursor string `json:"next_cursor,omitempty"`
+}
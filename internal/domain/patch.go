@@ -0,0 +1,122 @@
+/*
+    package domain
+    patch.go
+    - containing JSON merge patch (RFC 7396) document of partial update
+*/
+package domain
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// MergePatchContentType is media type of JSON merge patch document
+const MergePatchContentType = "application/merge-patch+json"
+
+// MergePatch is JSON merge patch document (RFC 7396) of a flat record. member with
+// null value clear the field of nullable column, absent member leave the field untouched
+type MergePatch map[string]json.RawMessage
+
+// MergePatchNullError is error of null member on the field of column that require a value
+type MergePatchNullError struct {
+    // Member is name of the null member
+    Member string
+}
+
+// Error method for displaying error string for 'MergePatchNullError' struct
+func (e *MergePatchNullError) Error() string {
+    return fmt.Sprintf("member '%s' can not be null", e.Member)
+}
+
+// NullString is string of nullable column. empty string is saved as NULL
+// and NULL is read as empty string
+type NullString string
+
+// Scan will read the column value, NULL is read as empty string
+func (s *NullString) Scan(src interface{}) error {
+    switch v := src.(type) {
+    case nil:
+        *s = ""
+    case string:
+        *s = NullString(v)
+    case []byte:
+        *s = NullString(v)
+    default:
+        return fmt.Errorf("can not scan %T into NullString", src)
+    }
+
+    return nil
+}
+
+// Value will get the column value, empty string is saved as NULL
+func (s NullString) Value() (driver.Value, error) {
+    if s == "" {
+        return nil, nil
+    }
+
+    return string(s), nil
+}
+
+// DecodeMergePatch will decode JSON merge patch document, the document must be JSON object
+func DecodeMergePatch(b []byte) (MergePatch, error) {
+    var p MergePatch
+    if err := json.Unmarshal(b, &p); err != nil {
+        return nil, err
+    }
+    if p == nil {
+        return nil, errors.New("merge patch must be json object")
+    }
+
+    return p, nil
+}
+
+// Has is to check whether the patch contain the member
+func (p MergePatch) Has(name string) bool {
+    _, ok := p[name]
+    return ok
+}
+
+// IsNull is to check whether the patch contain the member with null value
+func (p MergePatch) IsNull(name string) bool {
+    v, ok := p[name]
+    return ok && bytes.Equal(bytes.TrimSpace(v), []byte("null"))
+}
+
+// Apply will apply the patch to the fields (json member name and pointer to the field).
+// it return the patched member names in sorted order. unknown member or member value of
+// wrong type will return error and the fields may be partially patched. null member on
+// the field that is not nullable (driver.Valuer) return *MergePatchNullError
+func (p MergePatch) Apply(fields map[string]interface{}) ([]string, error) {
+    names := make([]string, 0, len(p))
+    for name := range p {
+        if _, ok := fields[name]; !ok {
+            return nil, fmt.Errorf("member '%s' can not be patched", name)
+        }
+        names = append(names, name)
+    }
+    sort.Strings(names)
+
+    for _, name := range names {
+        // null member clear the field of nullable column to its zero value, which
+        // is saved as NULL
+        if p.IsNull(name) {
+            if _, ok := reflect.ValueOf(fields[name]).Elem().Interface().(driver.Valuer); !ok {
+                return nil, &MergePatchNullError{Member: name}
+            }
+            v := reflect.ValueOf(fields[name]).Elem()
+            v.Set(reflect.Zero(v.Type()))
+            continue
+        }
+
+        if err := json.Unmarshal(p[name], fields[name]); err != nil {
+            return nil, fmt.Errorf("member '%s' is invalid: %v", name, err)
+        }
+    }
+
+    return names, nil
+}
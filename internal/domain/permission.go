@@ -0,0 +1,68 @@
+/*
+    package domain
+    permission.go
+    - containing permission catalogue and permission model granted to user.role
+*/
+package domain
+
+const (
+    // PermRoleRead is permission to read user.role record and its permission
+    PermRoleRead = "role:read"
+
+    // PermRoleWrite is permission to create, update and delete user.role record and
+    // to grant or revoke its permission
+    PermRoleWrite = "role:write"
+
+    // PermUserRead is permission to read other user record
+    PermUserRead = "user:read"
+
+    // PermUserWrite is permission to create, update and delete other user record
+    PermUserWrite = "user:write"
+
+    // PermKeyManage is permission to promote and retire auth token signing key
+    PermKeyManage = "key:manage"
+
+    // PermMailConfigRead is permission to read mail app configuration
+    PermMailConfigRead = "mail:config:read"
+
+    // PermMailConfigWrite is permission to change mail app configuration
+    PermMailConfigWrite = "mail:config:write"
+
+    // PermAuditRead is permission to read audit log of the account app
+    PermAuditRead = "audit:read"
+)
+
+// RolePermissions is default permissions granted to each role. it is used when the
+// permission is not loaded from the database and as the database seed
+var RolePermissions = map[int][]string{
+    RoleSuperuser     : {PermAuditRead, PermKeyManage, PermMailConfigRead, PermMailConfigWrite, PermRoleRead, PermRoleWrite, PermUserRead, PermUserWrite},
+    RoleAdministrator : {PermAuditRead, PermMailConfigRead, PermMailConfigWrite, PermRoleRead, PermRoleWrite, PermUserRead, PermUserWrite},
+}
+
+// Permission is permission model of the permission catalogue
+type Permission struct {
+    // Code is the permission code in 'resource:action' format, it is its primary key
+    Code        string      `json:"code"`
+
+    // Description is the short description of the permission
+    Description string      `json:"description,omitempty"`
+}
+
+// IsValidPermissionCode is to check whether permission code is in 'resource:action' format
+func IsValidPermissionCode(code string) bool {
+    if len(code) == 0 || len(code) > 50 || code[0] == ':' || code[len(code)-1] == ':' {
+        return false
+    }
+
+    colon := false
+    for _, r := range code {
+        switch {
+        case r == ':':
+            colon = true
+        case (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '_':
+            return false
+        }
+    }
+
+    return colon
+}
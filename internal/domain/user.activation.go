@@ -0,0 +1,30 @@
+/*
+    package domain
+    user.activation.go
+    - containing user.activation model and request dto struct
+*/
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserActivation is model for account activation token
+type UserActivation struct {
+    // TokenHash is sha256 hash of the token sent to the user
+    TokenHash string    `json:"-"`
+
+    // UserID is id of the user that need activation
+    UserID    uuid.UUID `json:"user_id"`
+
+    // ExpiresAt is the datetime the token expired
+    ExpiresAt time.Time `json:"expires_at"`
+}
+
+// UserActivationRequest is request dto to resend activation mail
+type UserActivationRequest struct {
+    // Email is the registered email of the user
+    Email string `json:"email" binding:"required,max=100,email"`
+}
@@ -0,0 +1,92 @@
+/*
+    package domain
+    user.apikey.go
+    - containing user.apikey (personal access token) model and request dto struct
+*/
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+    // APIKeyPrefix is prefix of every api key. it tell the api key apart from the
+    // auth token when both are sent on the 'Authorization: Bearer' header
+    APIKeyPrefix = "lbw_"
+
+    // APIKeyDisplayLength is length of the key prefix kept to recognise the key
+    APIKeyDisplayLength = 12
+
+    // apiKeyNameLength is max length of the api key name
+    apiKeyNameLength = 50
+)
+
+// APIKey is model for long-lived api key of the user used by machine client
+type APIKey struct {
+    // ID is id of the api key
+    ID         uuid.UUID  `json:"id"`
+
+    // UserID is id of the key owner
+    UserID     uuid.UUID  `json:"user_id"`
+
+    // Name is label given by the owner to recognise the key
+    Name       string     `json:"name"`
+
+    // Prefix is first characters of the key, shown on the key list
+    Prefix     string     `json:"prefix"`
+
+    // KeyHash is sha256 hash of the key given to the user
+    KeyHash    string     `json:"-"`
+
+    // Scopes is permission code the key is allowed to use
+    Scopes     []string   `json:"scopes"`
+
+    // ExpiresAt is the datetime the key expired, the key never expire when it is nil
+    ExpiresAt  *time.Time `json:"expires_at"`
+
+    // LastUsedAt is the datetime the key was last used to authorize a request
+    LastUsedAt *time.Time `json:"last_used_at"`
+
+    // CreatedAt is the datetime the key was created
+    CreatedAt  time.Time  `json:"created_at"`
+}
+
+// APIKeyRequest is request dto to create new api key
+type APIKeyRequest struct {
+    // Name is label to recognise the key
+    Name      string     `json:"name" binding:"required,notblank,max=50"`
+
+    // Scopes is permission code the key is allowed to use. the owner can only
+    // give permission its role hold
+    Scopes    []string   `json:"scopes" binding:"dive,permission"`
+
+    // ExpiresAt is optional expiration datetime of the key
+    ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// IsValid will check whether the api key request is valid
+func (r *APIKeyRequest) IsValid() bool {
+    name := strings.TrimSpace(r.Name)
+    if name == "" || len(name) > apiKeyNameLength {
+        return false
+    }
+
+    for _, scope := range r.Scopes {
+        if !IsValidPermissionCode(scope) {
+            return false
+        }
+    }
+
+    return true
+}
+
+// APIKeyCreateResponse is response dto of the new api key. the key is only shown once
+type APIKeyCreateResponse struct {
+    APIKey
+
+    // Key is the api key to be sent on the 'X-API-Key' or 'Authorization: Bearer' header
+    Key string `json:"key"`
+}
@@ -23,8 +23,8 @@ type User struct {
     // FirstName is the first name of the user
     Firstname   string    `json:"firstname"`
 
-    // LastName is the last name for the user
-    Lastname    string    `json:"lastname"`
+    // LastName is the last name for the user, empty last name is saved as NULL
+    Lastname    NullString `json:"lastname"`
 
     // email is the valid email of the user
     Email       string    `json:"email"`
@@ -61,20 +61,65 @@ func (u *User) IsValid() bool {
             u.Email       != ""
 }
 
+// IsValidUpdate is to check whether User data is valid to be updated. passkey
+// is not required since it can not be changed by update
+func (u *User) IsValidUpdate() bool {
+    return  u.Username    != "" &&
+            u.Firstname   != "" &&
+            u.Email       != ""
+}
+
+// PatchFields is fields of User that can be changed by merge patch. the json member
+// name is also the column name of the field
+func (u *User) PatchFields() map[string]interface{} {
+    return map[string]interface{}{
+        "username"  : &u.Username,
+        "firstname" : &u.Firstname,
+        "lastname"  : &u.Lastname,
+        "email"     : &u.Email,
+        "status_id" : &u.StatusID,
+        "role_id"   : &u.RoleID,
+    }
+}
+
+// ApplyPatch will apply merge patch to User and return the patched field names
+func (u *User) ApplyPatch(p MergePatch) ([]string, error) {
+    return p.Apply(u.PatchFields())
+}
+
+// ConvertToRequest will convert User model to request dto so it can be validated by
+// the request rule. passkey is left out since it is never sent back
+func (u *User) ConvertToRequest() *UserRequest{
+    return &UserRequest{
+        ID        : u.ID,
+        Username  : u.Username,
+        Firstname : u.Firstname,
+        Lastname  : string(u.Lastname),
+        Email     : u.Email,
+        StatusID  : u.StatusID,
+        RoleID    : u.RoleID,
+    }
+}
+
 // ConvertToResponse will convert User model to response dto format
 func (u *User) ConvertToResponse() *UserResponse{
     // return UserResponse data
-    return &UserResponse{
+    res := &UserResponse{
         ID        : u.ID,
         Username  : u.Username,
         Firstname : u.Firstname,
-        Lastname  : u.Lastname,
+        Lastname  : string(u.Lastname),
         Email     : u.Email,
         StatusID  : u.StatusID,
         RoleID    : u.RoleID,
         CreatedAt : u.CreatedAt,
         UpdatedAt : u.UpdatedAt,
     }
+    if !u.DeletedAt.IsZero() {
+        res.DeletedAt = &u.DeletedAt
+    }
+
+    return res
 }
 
 // ConvertToCredential will convert user data to credential format
@@ -84,6 +129,7 @@ func (u *User) ConvertToCredential() *UserCredential{
         Username : u.Username,
         PassKey : u.PassKey,
         StatusID : u.StatusID,
+        RoleID : u.RoleID,
     }
 }
 
@@ -105,26 +151,27 @@ type UserRequest struct {
     ID          uuid.UUID `json:"id"`
 
     // Username is the username for the user, value must be unique
-    Username    string    `json:"username"`
+    Username    string    `json:"username" binding:"required,max=30,username"`
 
     // FirstName is the first name of the user
-    Firstname   string    `json:"firstname"`
+    Firstname   string    `json:"firstname" binding:"required,notblank,max=30"`
 
     // LastName is the last name for the user
-    Lastname    string    `json:"lastname,omitempty"`
+    Lastname    string    `json:"lastname,omitempty" binding:"max=30"`
 
     // email is the valid email of the user
-    Email       string    `json:"email"`
+    Email       string    `json:"email" binding:"required,max=100,email"`
 
-    // PassKey is the password for the account
+    // PassKey is the password for the account. it is only required to create the
+    // user (see IsValid) since update does not change it
     PassKey     string    `json:"passkey"`
 
     // StatusID is id of status held by user
     // ("0=inactive", "1=active", "2=suspended", "3=banned")
-    StatusID    int       `json:"status_id"`
+    StatusID    int       `json:"status_id" binding:"min=0"`
 
     // RoleID is role given to the user on the system
-    RoleID      int       `json:"role_id"`
+    RoleID      int       `json:"role_id" binding:"min=0"`
 }
 
 // IsValid() method will check whether the user request data is validity
@@ -137,13 +184,21 @@ func (u *UserRequest) IsValid() bool {
             u.Email       != ""
 }
 
+// IsValidUpdate will check whether the user request data is valid for update.
+// passkey is not required since it can not be changed by update
+func (u *UserRequest) IsValidUpdate() bool {
+    return  u.Username    != "" &&
+            u.Firstname   != "" &&
+            u.Email       != ""
+}
+
 // RequestToUser will convert user request dto to User
 func (u *UserRequest) RequestToUser() *User{
     return &User{
         ID        : u.ID,
         Username  : u.Username,
         Firstname : u.Firstname,
-        Lastname  : u.Lastname,
+        Lastname  : NullString(u.Lastname),
         Email     : u.Email,
         PassKey   : u.PassKey,
         StatusID  : u.StatusID,
@@ -151,6 +206,71 @@ func (u *UserRequest) RequestToUser() *User{
     }
 }
 
+// UserSignupRequest is request dto of anonymous user to create its own account. status
+// and role are not part of it, new account always start as inactive guest
+type UserSignupRequest struct {
+    // Username is the username for the user, value must be unique
+    Username    string    `json:"username" binding:"required,max=30,username"`
+
+    // FirstName is the first name of the user
+    Firstname   string    `json:"firstname" binding:"required,notblank,max=30"`
+
+    // LastName is the last name for the user
+    Lastname    string    `json:"lastname,omitempty" binding:"max=30"`
+
+    // email is the valid email of the user
+    Email       string    `json:"email" binding:"required,max=100,email"`
+
+    // PassKey is the password for the account
+    PassKey     string    `json:"passkey" binding:"required"`
+}
+
+// ToUserRequest will convert user signup request dto to UserRequest of inactive guest user
+func (u *UserSignupRequest) ToUserRequest() *UserRequest {
+    return &UserRequest{
+        Username  : u.Username,
+        Firstname : u.Firstname,
+        Lastname  : u.Lastname,
+        Email     : u.Email,
+        PassKey   : u.PassKey,
+        StatusID  : 0,
+        RoleID    : RoleGuest,
+    }
+}
+
+// UserProfileRequest is request dto of the user to update its own profile.
+// status, role and password are not part of the profile
+type UserProfileRequest struct {
+    // Username is the username for the user, value must be unique
+    Username    string    `json:"username" binding:"required,max=30,username"`
+
+    // FirstName is the first name of the user
+    Firstname   string    `json:"firstname" binding:"required,notblank,max=30"`
+
+    // LastName is the last name for the user
+    Lastname    string    `json:"lastname,omitempty" binding:"max=30"`
+
+    // email is the valid email of the user
+    Email       string    `json:"email" binding:"required,max=100,email"`
+}
+
+// IsValid will check whether the user profile request data is valid
+func (u *UserProfileRequest) IsValid() bool {
+    return  u.Username  != "" &&
+            u.Firstname != "" &&
+            u.Email     != ""
+}
+
+// RequestToUser will convert user profile request dto to User
+func (u *UserProfileRequest) RequestToUser() *User{
+    return &User{
+        Username  : u.Username,
+        Firstname : u.Firstname,
+        Lastname  : NullString(u.Lastname),
+        Email     : u.Email,
+    }
+}
+
 // UserResponse is User response dto
 type UserResponse struct {
     // ID is the table primary key with uuid type
@@ -179,6 +299,9 @@ type UserResponse struct {
 
     // UpdatedAt is the last updated datetime of the record
     UpdatedAt time.Time     `json:"updated_at"`
+
+    // DeletedAt is the datetime record was deleted, it is only set on deleted record
+    DeletedAt *time.Time    `json:"deleted_at,omitempty"`
 }
 
 // UserCredential is struct holding user credential data
@@ -192,6 +315,9 @@ type UserCredential struct {
     // Status is status held by user
     StatusID  int           `json:"status_id"`
 
+    // RoleID is role given to the user on the system
+    RoleID    int           `json:"role_id"`
+
     // PassKey is the password for the account
     PassKey   string    `json:"passkey"`
 }
@@ -0,0 +1,53 @@
+/*
+    package domain
+    user.password.go
+    - containing user.password reset model, reset and change request dto struct
+*/
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserPasswordReset is model for password reset token
+type UserPasswordReset struct {
+    // TokenHash is sha256 hash of the token sent to the user
+    TokenHash string    `json:"-"`
+
+    // UserID is id of the user requesting password reset
+    UserID    uuid.UUID `json:"user_id"`
+
+    // ExpiresAt is the datetime the token expired
+    ExpiresAt time.Time `json:"expires_at"`
+}
+
+// PasswordForgotRequest is request dto to request password reset mail
+type PasswordForgotRequest struct {
+    // Email is the registered email of the user
+    Email   string `json:"email" binding:"required,max=100,email"`
+}
+
+// PasswordResetRequest is request dto to reset password with the reset token
+type PasswordResetRequest struct {
+    // Token is the password reset token sent to the user
+    Token   string `json:"token" binding:"required"`
+
+    // PassKey is the new password for the account
+    PassKey string `json:"passkey" binding:"required"`
+}
+
+// PasswordChangeRequest is request dto of the user to change its own password
+type PasswordChangeRequest struct {
+    // CurrentPassword is the current password of the account
+    CurrentPassword string `json:"current_password" binding:"required"`
+
+    // NewPassword is the new password for the account
+    NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// IsValid will check whether the password change request data is valid
+func (p *PasswordChangeRequest) IsValid() bool {
+    return p.CurrentPassword != "" && p.NewPassword != ""
+}
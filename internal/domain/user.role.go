@@ -9,6 +9,24 @@ import (
 	"time"
 )
 
+const (
+    // RoleGuest is role id for guest, it is the default role of new user
+    RoleGuest = 0
+
+    // RoleSuperuser is role id for superuser, have all access to system resources
+    RoleSuperuser = 1
+
+    // RoleAdministrator is role id for administrator, have access to all services
+    // resources and app management
+    RoleAdministrator = 2
+
+    // RoleCurrator is role id for currator, the maintainer of resources
+    RoleCurrator = 3
+
+    // RoleStaff is role id for staff, mostly a content producer and editor
+    RoleStaff = 4
+)
+
 // UserRole is User Role model
 type UserRole struct {
     // ID is user.role id. it is its primary key
@@ -17,8 +35,8 @@ type UserRole struct {
     // RoleName is user.role role name
     RoleName    string      `json:"role_name"`
 
-    // Description is the short description of the role
-    Description string      `json:"description,omitempty"`
+    // Description is the short description of the role, empty description is saved as NULL
+    Description NullString  `json:"description,omitempty"`
 
     // CreatedAt is the creation datetime of the role
     CreatedAt   time.Time   `json:"created_at"`
@@ -35,21 +53,40 @@ func (ur *UserRole) IsValid() bool{
     return ur.RoleName != ""
 }
 
+// PatchFields is fields of user.role that can be changed by merge patch. the json
+// member name is also the column name of the field
+func (ur *UserRole) PatchFields() map[string]interface{} {
+    return map[string]interface{}{
+        "role_name"   : &ur.RoleName,
+        "description" : &ur.Description,
+    }
+}
+
+// ApplyPatch will apply merge patch to user.role and return the patched field names
+func (ur *UserRole) ApplyPatch(p MergePatch) ([]string, error) {
+    return p.Apply(ur.PatchFields())
+}
+
 // ConvertToResponse will convert user.role model to response dto
 func (ur *UserRole) ConvertToResponse() *UserRoleResponse{
-    return &UserRoleResponse{
+    res := &UserRoleResponse{
         ID          : ur.ID,
         RoleName    : ur.RoleName,
-        Description : ur.Description,
+        Description : string(ur.Description),
         CreatedAt   : ur.CreatedAt,
         UpdatedAt   : ur.UpdatedAt,
     }
+    if !ur.DeletedAt.IsZero() {
+        res.DeletedAt = &ur.DeletedAt
+    }
+
+    return res
 }
 
 // UserRoleRequest is user.role request dto
 type UserRoleRequest struct {
     // RoleName is user.role role name
-    RoleName    string      `json:"role_name"`
+    RoleName    string      `json:"role_name" binding:"required,notblank,max=30"`
 
     // Description is the short description of the role
     Description string      `json:"description,omitempty"`
@@ -59,7 +96,7 @@ type UserRoleRequest struct {
 func (ur *UserRoleRequest) ConvertToUserRole() *UserRole{
     return &UserRole{
         RoleName : ur.RoleName,
-        Description : ur.Description,
+        Description : NullString(ur.Description),
     }
 }
 
@@ -75,4 +112,5 @@ type UserRoleResponse struct {
     Description string      `json:"description,omitempty"`
     CreatedAt   time.Time   `json:"created_at"`
     UpdatedAt   time.Time   `json:"updated_at"`
+    DeletedAt   *time.Time  `json:"deleted_at,omitempty"`
 }
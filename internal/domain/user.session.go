@@ -0,0 +1,66 @@
+/*
+    package domain
+    user.session.go
+    - containing user.session (signed in session/ device) model
+*/
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+    // sessionUserAgentLength is max length of the user agent kept on the session
+    sessionUserAgentLength = 255
+
+    // sessionIPAddressLength is max length of the client ip address kept on the session
+    sessionIPAddressLength = 45
+)
+
+// SessionClient is the client (device) signing in or refreshing the session
+type SessionClient struct {
+    // UserAgent is 'User-Agent' header sent by the client
+    UserAgent string
+
+    // IPAddress is ip address of the client
+    IPAddress string
+}
+
+// NewSessionClient will create SessionClient, the value is cut to fit the session record
+func NewSessionClient(userAgent, ipAddress string) SessionClient {
+    if len(userAgent) > sessionUserAgentLength {
+        userAgent = userAgent[:sessionUserAgentLength]
+    }
+    if len(ipAddress) > sessionIPAddressLength {
+        ipAddress = ipAddress[:sessionIPAddressLength]
+    }
+
+    return SessionClient{UserAgent: userAgent, IPAddress: ipAddress}
+}
+
+// UserSession is model for signed in session of the user. each signin start a new
+// session, it is linked to the refresh token family of the signin
+type UserSession struct {
+    // ID is id of the session, it is the id of its refresh token family
+    ID              uuid.UUID `json:"id"`
+
+    // UserID is id of the session owner
+    UserID          uuid.UUID `json:"user_id"`
+
+    // UserAgent is user agent of the client on the last signin or refresh
+    UserAgent       string    `json:"user_agent"`
+
+    // IPAddress is ip address of the client on the last signin or refresh
+    IPAddress       string    `json:"ip_address"`
+
+    // CreatedAt is the datetime of the signin
+    CreatedAt       time.Time `json:"created_at"`
+
+    // LastRefreshedAt is the datetime the token of the session was last refreshed
+    LastRefreshedAt time.Time `json:"last_refreshed_at"`
+
+    // Current tell whether it is the session making the request
+    Current         bool      `json:"current" db:"-"`
+}
@@ -0,0 +1,55 @@
+/*
+    package domain
+    user.totp.go
+    - containing user.totp (two factor authentication) model and request dto struct
+*/
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserTOTP is model for user two factor authentication (totp) secret
+type UserTOTP struct {
+    // UserID is id of the secret owner
+    UserID       uuid.UUID  `json:"user_id"`
+
+    // Secret is the encrypted totp secret
+    Secret       string     `json:"-"`
+
+    // LastUsedStep is time step of the last accepted code
+    LastUsedStep *int64     `json:"-"`
+
+    // EnabledAt is the datetime the two factor authentication was confirmed
+    EnabledAt    *time.Time `json:"enabled_at"`
+}
+
+// IsEnabled will check whether the two factor authentication already confirmed
+func (t *UserTOTP) IsEnabled() bool {
+    return t.EnabledAt != nil
+}
+
+// UserTOTPEnrollResponse is response dto containing the new secret to be added
+// into the authenticator app
+type UserTOTPEnrollResponse struct {
+    // Secret is the totp secret encoded as base32
+    Secret          string `json:"secret"`
+
+    // ProvisioningURI is 'otpauth' uri of the secret (usually shown as qr code)
+    ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// UserTOTPConfirmRequest is request dto to confirm the enrolled secret
+type UserTOTPConfirmRequest struct {
+    // Code is the code shown on the authenticator app
+    Code string `json:"code" binding:"required,len=6,numeric"`
+}
+
+// UserTOTPConfirmResponse is response dto containing the recovery codes.
+// the codes are only shown once
+type UserTOTPConfirmResponse struct {
+    // RecoveryCodes is single use code to signin when the authenticator app is not available
+    RecoveryCodes []string `json:"recovery_codes"`
+}
@@ -0,0 +1,54 @@
+/*
+   Middleware to record audit log of the change made by the request
+*/
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	"github.com/reshimahendra/lbw-go/internal/pkg/helper"
+	"github.com/reshimahendra/lbw-go/internal/pkg/logger"
+)
+
+// auditRecorder is recorder used by Audit to keep the audit log
+var auditRecorder IAuditRecorder
+
+// IAuditRecorder is interface to keep audit log of the change
+type IAuditRecorder interface {
+	// Record will keep the audit log
+	Record(entry d.AuditLog) error
+}
+
+// SetAuditRecorder will register the recorder used by Audit. audit log is not kept
+// when no recorder is registered
+func SetAuditRecorder(recorder IAuditRecorder) {
+	auditRecorder = recorder
+}
+
+// Audit is middleware to record the audit log put on the request context by the handler
+// (see helper.SetAudit) once the request succeed, request whose handler put an error on the
+// context (by 'c.Error') is not succeed even when the error is not sent yet. the caller (when it is signed in) and
+// its ip address is recorded along with the change
+func Audit() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		entry, ok := helper.GetAudit(c)
+		if !ok || auditRecorder == nil || len(c.Errors) > 0 || c.Writer.Status() >= http.StatusBadRequest {
+			return
+		}
+
+		if principal, ok := helper.GetPrincipal(c); ok && entry.ActorID == nil {
+			actorID := principal.UserID
+			entry.ActorID = &actorID
+		}
+		entry.IPAddress = c.ClientIP()
+
+		// the change is already made, failing to record it is only logged
+		if err := auditRecorder.Record(*entry); err != nil {
+			logger.Errorf("fail recording audit log of %s %s %s: %v", entry.Action, entry.TargetType, entry.TargetID, err)
+		}
+	}
+}
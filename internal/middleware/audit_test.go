@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	"github.com/reshimahendra/lbw-go/internal/pkg/helper"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockAuditRecorder is mock for IAuditRecorder keeping the recorded audit log
+type mockAuditRecorder struct {
+	entries []d.AuditLog
+	err     error
+}
+
+// Record is mock to keep the audit log
+func (m *mockAuditRecorder) Record(entry d.AuditLog) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.entries = append(m.entries, entry)
+
+	return nil
+}
+
+// newTestAuditRouter will create router with Audit middleware. the handler put audit
+// log on the request context and respond with the given status
+func newTestAuditRouter(principal *d.Principal, status int, entry *d.AuditLog) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.DELETE("/", func(c *gin.Context) {
+		if principal != nil {
+			helper.SetPrincipal(c, principal)
+		}
+		c.Next()
+	}, Audit(), func(c *gin.Context) {
+		if entry != nil {
+			helper.SetAudit(c, entry)
+		}
+		c.Status(status)
+	})
+
+	return r
+}
+
+// serveTestAuditRouter will send request from the client ip to the router
+func serveTestAuditRouter(r *gin.Engine) {
+	req, _ := http.NewRequest(http.MethodDelete, "/", nil)
+	req.RemoteAddr = "203.0.113.7:52114"
+	r.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+// TestAudit will test behaviour of Audit middleware
+func TestAudit(t *testing.T) {
+	defer SetAuditRecorder(nil)
+	principal := &d.Principal{UserID: uuid.New(), RoleID: d.RoleAdministrator}
+
+	t.Run("EXPECT SUCCESS actor and ip is recorded", func(t *testing.T) {
+		recorder := &mockAuditRecorder{}
+		SetAuditRecorder(recorder)
+
+		serveTestAuditRouter(newTestAuditRouter(principal, http.StatusOK, d.NewAuditLog(d.AuditActionDelete, d.AuditTargetRole, "3", nil, nil)))
+
+		assert.Len(t, recorder.entries, 1)
+		assert.Equal(t, principal.UserID, *recorder.entries[0].ActorID)
+		assert.Equal(t, "203.0.113.7", recorder.entries[0].IPAddress)
+		assert.Equal(t, d.AuditActionDelete, recorder.entries[0].Action)
+	})
+
+	// EXPECT SUCCESS actor set by the handler (such as on signup) is kept
+	t.Run("EXPECT SUCCESS actor set by handler", func(t *testing.T) {
+		recorder := &mockAuditRecorder{}
+		SetAuditRecorder(recorder)
+		entry := d.NewAuditLog(d.AuditActionCreate, d.AuditTargetUser, uuid.NewString(), nil, nil)
+		actorID := uuid.New()
+		entry.ActorID = &actorID
+
+		serveTestAuditRouter(newTestAuditRouter(nil, http.StatusOK, entry))
+
+		assert.Len(t, recorder.entries, 1)
+		assert.Equal(t, actorID, *recorder.entries[0].ActorID)
+	})
+
+	t.Run("EXPECT SUCCESS not signed in", func(t *testing.T) {
+		recorder := &mockAuditRecorder{}
+		SetAuditRecorder(recorder)
+
+		serveTestAuditRouter(newTestAuditRouter(nil, http.StatusOK, d.NewAuditLog(d.AuditActionPasswordReset, d.AuditTargetUser, uuid.NewString(), nil, nil)))
+
+		assert.Len(t, recorder.entries, 1)
+		assert.Nil(t, recorder.entries[0].ActorID)
+	})
+
+	t.Run("EXPECT SUCCESS failed request is not recorded", func(t *testing.T) {
+		recorder := &mockAuditRecorder{}
+		SetAuditRecorder(recorder)
+
+		serveTestAuditRouter(newTestAuditRouter(principal, http.StatusConflict, d.NewAuditLog(d.AuditActionDelete, d.AuditTargetRole, "3", nil, nil)))
+
+		assert.Empty(t, recorder.entries)
+	})
+
+	// EXPECT SUCCESS error put on the context (not sent yet) fail the request
+	t.Run("EXPECT SUCCESS request with context error is not recorded", func(t *testing.T) {
+		recorder := &mockAuditRecorder{}
+		SetAuditRecorder(recorder)
+
+		gin.SetMode(gin.TestMode)
+		r := gin.New()
+		r.DELETE("/", Audit(), func(c *gin.Context) {
+			helper.SetAudit(c, d.NewAuditLog(d.AuditActionDelete, d.AuditTargetRole, "3", nil, nil))
+			_ = c.Error(errors.New("unexpected error"))
+		})
+		serveTestAuditRouter(r)
+
+		assert.Empty(t, recorder.entries)
+	})
+
+	t.Run("EXPECT SUCCESS request without change is not recorded", func(t *testing.T) {
+		recorder := &mockAuditRecorder{}
+		SetAuditRecorder(recorder)
+
+		serveTestAuditRouter(newTestAuditRouter(principal, http.StatusOK, nil))
+
+		assert.Empty(t, recorder.entries)
+	})
+
+	// EXPECT SUCCESS failing to record does not change the response
+	t.Run("EXPECT SUCCESS recorder error", func(t *testing.T) {
+		SetAuditRecorder(&mockAuditRecorder{err: errors.New("unexpected error")})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodDelete, "/", nil)
+		newTestAuditRouter(principal, http.StatusOK, d.NewAuditLog(d.AuditActionDelete, d.AuditTargetRole, "3", nil, nil)).ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
@@ -4,16 +4,37 @@
 package middleware
 
 import (
-	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
 	"github.com/reshimahendra/lbw-go/internal/pkg/auth"
 	"github.com/reshimahendra/lbw-go/internal/pkg/helper"
 	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/logger"
 )
 
-// Authorize is middleware to prevent unauthorized access
+// apiKeyHeader is request header carrying the api key of machine client
+const apiKeyHeader = "X-API-Key"
+
+// apiKeyAuthenticator is authenticator used by Authorize to get the owner of the api key
+var apiKeyAuthenticator IAPIKeyAuthenticator
+
+// IAPIKeyAuthenticator is interface to get the principal (key owner) of an api key
+type IAPIKeyAuthenticator interface {
+	// AuthenticateAPIKey will get the principal of the given api key
+	AuthenticateAPIKey(key string) (*d.Principal, error)
+}
+
+// SetAPIKeyAuthenticator will register the authenticator used by Authorize. api key
+// is rejected when no authenticator is registered
+func SetAPIKeyAuthenticator(authenticator IAPIKeyAuthenticator) {
+	apiKeyAuthenticator = authenticator
+}
+
+// Authorize is middleware to prevent unauthorized access. the caller is identified by
+// the auth token or the api key on the 'Authorization: Bearer' header, or by the api
+// key on the 'X-API-Key' header
 func Authorize() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var tokenStr string
@@ -23,22 +44,72 @@ func Authorize() gin.HandlerFunc {
 			tokenStr = strArr[1]
 		}
 
+		// api key is told apart from the auth token by its prefix
+		if key := c.GetHeader(apiKeyHeader); key != "" {
+			tokenStr = key
+		}
+		if strings.HasPrefix(tokenStr, d.APIKeyPrefix) {
+			authorizeAPIKey(c, tokenStr)
+			return
+		}
+
 		if tokenStr == "" {
-            e := E.New(E.ErrTokenNotFound)
-            helper.APIErrorResponse(c, http.StatusUnauthorized, e)
+			abort(c, E.New(E.ErrTokenNotFound))
 			return
 		}
 
 		token, err := auth.TokenValid(tokenStr)
 		if err != nil {
-            helper.APIErrorResponse(c, http.StatusUnauthorized, err)
+			abort(c, err)
+			return
+		}
+
+		// put the token owner on the request context so handler can know who is calling
+		principal, err := auth.ExtractPrincipal(token)
+		if err != nil {
+			abort(c, err)
 			return
 		}
+		helper.SetPrincipal(c, principal)
 
-		if err != nil && !token.Valid {
-            helper.APIErrorResponse(c, http.StatusUnauthorized, err)
-            return
+		c.Next()
+	}
+}
+
+// authorizeAPIKey will put the owner of the api key on the request context
+func authorizeAPIKey(c *gin.Context, key string) {
+	if apiKeyAuthenticator == nil {
+		abort(c, E.New(E.ErrTokenInvalid))
+		return
+	}
+
+	principal, err := apiKeyAuthenticator.AuthenticateAPIKey(key)
+	if err != nil {
+		// failing to check the key is server error (see errors.Status), otherwise the key is rejected
+		abort(c, err)
+		return
+	}
+	helper.SetPrincipal(c, principal)
+
+	c.Next()
+}
+
+// DenyAPIKey is middleware to reject caller authorized with api key, so api key can not
+// be used to manage the account credential. it must be used after Authorize middleware
+func DenyAPIKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := helper.GetPrincipal(c)
+		if !ok {
+			abort(c, E.New(E.ErrTokenNotFound))
+			return
+		}
+
+		if principal.IsAPIKey() {
+			logger.Errorf("api key %s is denied access to %s", principal.APIKeyID, c.FullPath())
+			abort(c, E.New(E.ErrForbidden))
+			return
 		}
 
+		c.Next()
 	}
 }
@@ -0,0 +1,162 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/reshimahendra/lbw-go/internal/config"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	"github.com/reshimahendra/lbw-go/internal/pkg/auth"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/helper"
+	"github.com/stretchr/testify/assert"
+)
+
+// validTestAPIKey is the only api key accepted by mockAPIKeyAuthenticator
+const validTestAPIKey = d.APIKeyPrefix + "valid"
+
+// mockAPIKeyAuthenticator is mock for IAPIKeyAuthenticator
+type mockAPIKeyAuthenticator struct {
+	wantErr bool
+}
+
+// AuthenticateAPIKey is mock to get staff principal of the valid api key
+func (m mockAPIKeyAuthenticator) AuthenticateAPIKey(key string) (*d.Principal, error) {
+	if m.wantErr {
+		return nil, E.New(E.ErrDatabase)
+	}
+	if key != validTestAPIKey {
+		return nil, E.New(E.ErrTokenInvalid)
+	}
+
+	return &d.Principal{
+		UserID   : uuid.New(),
+		RoleID   : d.RoleAdministrator,
+		StatusID : 1,
+		APIKeyID : uuid.NewString(),
+		Scopes   : []string{d.PermUserRead, d.PermKeyManage},
+	}, nil
+}
+
+// serveAuthorizeRouter will send request with the given header to router guarded by
+// Authorize and the given middleware, and return the response status
+func serveAuthorizeRouter(header, value string, mw ...gin.HandlerFunc) int {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ErrorHandler())
+	handlers := append([]gin.HandlerFunc{Authorize()}, mw...)
+	handlers = append(handlers, func(c *gin.Context) {
+		if _, ok := helper.GetPrincipal(c); !ok {
+			c.Status(http.StatusTeapot)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+	r.GET("/", handlers...)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	if header != "" {
+		req.Header.Set(header, value)
+	}
+	r.ServeHTTP(w, req)
+
+	return w.Code
+}
+
+// TestAuthorizeAPIKey will test behaviour of Authorize middleware with api key
+func TestAuthorizeAPIKey(t *testing.T) {
+	SetAPIKeyAuthenticator(mockAPIKeyAuthenticator{})
+	defer SetAPIKeyAuthenticator(nil)
+
+	t.Run("EXPECT SUCCESS api key header", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serveAuthorizeRouter(apiKeyHeader, validTestAPIKey))
+	})
+
+	t.Run("EXPECT SUCCESS bearer api key", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serveAuthorizeRouter("Authorization", "Bearer "+validTestAPIKey))
+	})
+
+	t.Run("EXPECT FAIL token not found", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, serveAuthorizeRouter("", ""))
+	})
+
+	t.Run("EXPECT FAIL invalid api key", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, serveAuthorizeRouter(apiKeyHeader, d.APIKeyPrefix+"revoked"))
+	})
+
+	t.Run("EXPECT FAIL authenticator error", func(t *testing.T) {
+		SetAPIKeyAuthenticator(mockAPIKeyAuthenticator{wantErr: true})
+		defer SetAPIKeyAuthenticator(mockAPIKeyAuthenticator{})
+
+		assert.Equal(t, http.StatusInternalServerError, serveAuthorizeRouter(apiKeyHeader, validTestAPIKey))
+	})
+
+	t.Run("EXPECT FAIL authenticator not registered", func(t *testing.T) {
+		SetAPIKeyAuthenticator(nil)
+		defer SetAPIKeyAuthenticator(mockAPIKeyAuthenticator{})
+
+		assert.Equal(t, http.StatusUnauthorized, serveAuthorizeRouter(apiKeyHeader, validTestAPIKey))
+	})
+}
+
+// TestAuthorizeToken will test behaviour of Authorize middleware with auth token
+func TestAuthorizeToken(t *testing.T) {
+	if err := config.Setup(); err != nil {
+		t.Fatalf("unexpected error occur: %v", err)
+	}
+
+	token, err := auth.CreateToken(d.Principal{UserID: uuid.New(), Email: "aabi@basd.com", StatusID: 1})
+	assert.NoError(t, err)
+
+	t.Run("EXPECT SUCCESS access token", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, serveAuthorizeRouter("Authorization", "Bearer "+token.AccessToken))
+	})
+
+	t.Run("EXPECT FAIL refresh token", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, serveAuthorizeRouter("Authorization", "Bearer "+token.RefreshToken))
+	})
+}
+
+// TestAPIKeyPermission will test permission of api key is limited to its scopes
+func TestAPIKeyPermission(t *testing.T) {
+	SetAPIKeyAuthenticator(mockAPIKeyAuthenticator{})
+	defer SetAPIKeyAuthenticator(nil)
+
+	t.Run("EXPECT SUCCESS permission on scopes", func(t *testing.T) {
+		code := serveAuthorizeRouter(apiKeyHeader, validTestAPIKey, RequirePermission(d.PermUserRead))
+		assert.Equal(t, http.StatusOK, code)
+	})
+
+	t.Run("EXPECT FAIL permission not on scopes", func(t *testing.T) {
+		code := serveAuthorizeRouter(apiKeyHeader, validTestAPIKey, RequirePermission(d.PermUserWrite))
+		assert.Equal(t, http.StatusForbidden, code)
+	})
+
+	// scope the role does not hold (administrator has no key:manage) is not granted
+	t.Run("EXPECT FAIL permission not held by role", func(t *testing.T) {
+		code := serveAuthorizeRouter(apiKeyHeader, validTestAPIKey, RequirePermission(d.PermKeyManage))
+		assert.Equal(t, http.StatusForbidden, code)
+	})
+
+	t.Run("EXPECT FAIL api key denied", func(t *testing.T) {
+		code := serveAuthorizeRouter(apiKeyHeader, validTestAPIKey, DenyAPIKey())
+		assert.Equal(t, http.StatusForbidden, code)
+	})
+}
+
+// TestDenyAPIKey will test behaviour of DenyAPIKey middleware
+func TestDenyAPIKey(t *testing.T) {
+	t.Run("EXPECT SUCCESS", func(t *testing.T) {
+		r := newTestRouter(d.RoleStaff, DenyAPIKey())
+		assert.Equal(t, http.StatusOK, serveTestRouter(r))
+	})
+
+	t.Run("EXPECT FAIL principal not found", func(t *testing.T) {
+		r := newTestRouter(-1, DenyAPIKey())
+		assert.Equal(t, http.StatusUnauthorized, serveTestRouter(r))
+	})
+}
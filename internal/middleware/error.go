@@ -0,0 +1,27 @@
+/*
+   Middleware to send the error put on the request context by the handler
+*/
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/reshimahendra/lbw-go/internal/pkg/helper"
+)
+
+// ErrorHandler is middleware to send the error put on the request context (by 'c.Error')
+// once the request is handled. the http status and the message is taken from the error
+// code (see errors.Status), the error detail is only logged on the server
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		helper.APIContextErrorResponse(c)
+	}
+}
+
+// abort will put the error on the request context and stop the remaining handler,
+// the error is sent by ErrorHandler middleware
+func abort(c *gin.Context, err error) {
+	c.Error(err)
+	c.Abort()
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// serveErrorRouter will send request to router with ErrorHandler middleware, the given
+// handler run before the last handler that respond with status ok
+func serveErrorRouter(h gin.HandlerFunc) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(ErrorHandler())
+	r.GET("/", h, func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest(http.MethodGet, "/", nil)
+	r.ServeHTTP(w, req)
+
+	return w
+}
+
+// TestErrorHandler will test behaviour of ErrorHandler middleware
+func TestErrorHandler(t *testing.T) {
+	t.Run("EXPECT SUCCESS no error", func(t *testing.T) {
+		w := serveErrorRouter(func(c *gin.Context) {})
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("EXPECT SUCCESS error is sent with its status", func(t *testing.T) {
+		w := serveErrorRouter(func(c *gin.Context) {
+			abort(c, E.New(E.ErrDataNotFound))
+		})
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Contains(t, w.Body.String(), E.ErrDataNotFoundMsg)
+	})
+
+	t.Run("EXPECT SUCCESS error detail is not sent", func(t *testing.T) {
+		w := serveErrorRouter(func(c *gin.Context) {
+			abort(c, E.NewExt(E.ErrDatabase, errors.New("relation users does not exist")))
+		})
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.NotContains(t, w.Body.String(), "relation users")
+	})
+
+	t.Run("EXPECT SUCCESS unknown error is server error", func(t *testing.T) {
+		w := serveErrorRouter(func(c *gin.Context) {
+			abort(c, errors.New("unexpected error"))
+		})
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Contains(t, w.Body.String(), E.ErrServerInternalMsg)
+	})
+}
@@ -0,0 +1,137 @@
+/*
+   Middleware to restrict access based on the caller role and permission
+*/
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/helper"
+	"github.com/reshimahendra/lbw-go/internal/pkg/logger"
+)
+
+// permissionProvider is provider used by RequirePermission to get the role permissions
+var permissionProvider IPermissionProvider = staticPermissionProvider{}
+
+// IPermissionProvider is interface to get permissions granted to a role
+type IPermissionProvider interface {
+	// RolePermissions will get all permission granted to the role with given id
+	RolePermissions(roleID int) ([]string, error)
+}
+
+// SetPermissionProvider will register the provider used by RequirePermission
+func SetPermissionProvider(provider IPermissionProvider) {
+	permissionProvider = provider
+}
+
+// staticPermissionProvider is permission provider based on domain.RolePermissions
+type staticPermissionProvider struct{}
+
+// RolePermissions will get permissions of the role from domain.RolePermissions
+func (staticPermissionProvider) RolePermissions(roleID int) ([]string, error) {
+	return d.RolePermissions[roleID], nil
+}
+
+// RequireRole is middleware to allow access only for caller holding one of the given role.
+// it must be used after Authorize middleware
+func RequireRole(roleIDs ...int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := helper.GetPrincipal(c)
+		if !ok {
+			abort(c, E.New(E.ErrTokenNotFound))
+			return
+		}
+
+		for _, roleID := range roleIDs {
+			if principal.RoleID == roleID {
+				c.Next()
+				return
+			}
+		}
+
+		logger.Errorf("user %s with role %d is denied access to %s", principal.UserID, principal.RoleID, c.FullPath())
+		abort(c, E.New(E.ErrForbidden))
+	}
+}
+
+// LoadPermissions is middleware to load the effective permission of the caller role into
+// the request context, so the handler can check it with helper.HasPermission. the permission
+// is loaded once per request. it must be used after Authorize middleware
+func LoadPermissions() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := loadPermissions(c); ok {
+			c.Next()
+		}
+	}
+}
+
+// RequirePermission is middleware to allow access only for caller whose role is granted
+// all of the given permission. it must be used after Authorize middleware
+func RequirePermission(perms ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		granted, ok := loadPermissions(c)
+		if !ok {
+			return
+		}
+
+		principal, _ := helper.GetPrincipal(c)
+		for _, perm := range perms {
+			if !hasPermission(granted, perm) {
+				logger.Errorf("user %s with role %d is denied %s permission", principal.UserID, principal.RoleID, perm)
+				abort(c, E.New(E.ErrForbidden))
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// loadPermissions will get the effective permission of the caller role from the request
+// context, or from the permission provider when it is not loaded yet. permission of caller
+// authorized with api key is limited to the key scopes. it abort the request and
+// return false when the permission can not be loaded
+func loadPermissions(c *gin.Context) ([]string, bool) {
+	principal, ok := helper.GetPrincipal(c)
+	if !ok {
+		abort(c, E.New(E.ErrTokenNotFound))
+		return nil, false
+	}
+
+	if granted, ok := helper.GetPermissions(c); ok {
+		return granted, true
+	}
+
+	granted, err := permissionProvider.RolePermissions(principal.RoleID)
+	if err != nil {
+		logger.Errorf("fail getting permission of role %d: %v", principal.RoleID, err)
+		abort(c, err)
+		return nil, false
+	}
+
+	// api key can only use the role permission on its scopes
+	if principal.IsAPIKey() {
+		scoped := []string{}
+		for _, perm := range granted {
+			if hasPermission(principal.Scopes, perm) {
+				scoped = append(scoped, perm)
+			}
+		}
+		granted = scoped
+	}
+	helper.SetPermissions(c, granted)
+
+	return granted, true
+}
+
+// hasPermission will check whether the permission is on the granted permission list
+func hasPermission(granted []string, perm string) bool {
+	for _, g := range granted {
+		if g == perm {
+			return true
+		}
+	}
+
+	return false
+}
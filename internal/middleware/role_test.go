@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	"github.com/reshimahendra/lbw-go/internal/pkg/helper"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockPermissionProvider is mock for IPermissionProvider
+type mockPermissionProvider struct{}
+
+// RolePermissions is mock to simulate fail getting role permissions
+func (mockPermissionProvider) RolePermissions(roleID int) ([]string, error) {
+    return nil, errors.New("unexpected error")
+}
+
+// countPermissionProvider is mock for IPermissionProvider counting the permission loading
+type countPermissionProvider struct {
+    count int
+}
+
+// RolePermissions is mock to get permissions of the role from domain.RolePermissions
+func (p *countPermissionProvider) RolePermissions(roleID int) ([]string, error) {
+    p.count++
+    return d.RolePermissions[roleID], nil
+}
+
+// newTestRouter will create router with the given middleware and set principal with
+// given role when roleID is not negative
+func newTestRouter(roleID int, mw gin.HandlerFunc) *gin.Engine {
+    gin.SetMode(gin.TestMode)
+    r := gin.New()
+    r.Use(ErrorHandler())
+    r.GET("/", func(c *gin.Context) {
+        if roleID >= 0 {
+            helper.SetPrincipal(c, &d.Principal{UserID: uuid.New(), Email: "leo@gmail.com", RoleID: roleID, StatusID: 1})
+        }
+        c.Next()
+    }, mw, func(c *gin.Context) {
+        c.Status(http.StatusOK)
+    })
+
+    return r
+}
+
+// serveTestRouter will send request to the router and return the response status
+func serveTestRouter(r *gin.Engine) int {
+    w := httptest.NewRecorder()
+    req, _ := http.NewRequest(http.MethodGet, "/", nil)
+    r.ServeHTTP(w, req)
+
+    return w.Code
+}
+
+// TestRequireRole will test behaviour of RequireRole middleware
+func TestRequireRole(t *testing.T) {
+    t.Run("EXPECT SUCCESS", func(t *testing.T) {
+        r := newTestRouter(d.RoleAdministrator, RequireRole(d.RoleSuperuser, d.RoleAdministrator))
+        assert.Equal(t, http.StatusOK, serveTestRouter(r))
+    })
+
+    t.Run("EXPECT FAIL role not allowed", func(t *testing.T) {
+        r := newTestRouter(d.RoleGuest, RequireRole(d.RoleSuperuser, d.RoleAdministrator))
+        assert.Equal(t, http.StatusForbidden, serveTestRouter(r))
+    })
+
+    t.Run("EXPECT FAIL principal not found", func(t *testing.T) {
+        r := newTestRouter(-1, RequireRole(d.RoleSuperuser))
+        assert.Equal(t, http.StatusUnauthorized, serveTestRouter(r))
+    })
+}
+
+// TestRequirePermission will test behaviour of RequirePermission middleware
+func TestRequirePermission(t *testing.T) {
+    t.Run("EXPECT SUCCESS", func(t *testing.T) {
+        r := newTestRouter(d.RoleSuperuser, RequirePermission(d.PermUserRead, d.PermUserWrite))
+        assert.Equal(t, http.StatusOK, serveTestRouter(r))
+    })
+
+    t.Run("EXPECT FAIL permission not granted", func(t *testing.T) {
+        r := newTestRouter(d.RoleStaff, RequirePermission(d.PermRoleWrite))
+        assert.Equal(t, http.StatusForbidden, serveTestRouter(r))
+    })
+
+    t.Run("EXPECT FAIL principal not found", func(t *testing.T) {
+        r := newTestRouter(-1, RequirePermission(d.PermRoleRead))
+        assert.Equal(t, http.StatusUnauthorized, serveTestRouter(r))
+    })
+
+    t.Run("EXPECT FAIL permission provider error", func(t *testing.T) {
+        SetPermissionProvider(mockPermissionProvider{})
+        defer SetPermissionProvider(staticPermissionProvider{})
+
+        r := newTestRouter(d.RoleSuperuser, RequirePermission(d.PermRoleRead))
+        assert.Equal(t, http.StatusInternalServerError, serveTestRouter(r))
+    })
+}
+
+// TestLoadPermissions will test the effective permission is loaded into request context once
+func TestLoadPermissions(t *testing.T) {
+    t.Run("EXPECT SUCCESS loaded once", func(t *testing.T) {
+        provider := &countPermissionProvider{}
+        SetPermissionProvider(provider)
+        defer SetPermissionProvider(staticPermissionProvider{})
+
+        gin.SetMode(gin.TestMode)
+        r := gin.New()
+        var granted bool
+        r.GET("/", func(c *gin.Context) {
+            helper.SetPrincipal(c, &d.Principal{UserID: uuid.New(), RoleID: d.RoleAdministrator, StatusID: 1})
+            c.Next()
+        }, LoadPermissions(), RequirePermission(d.PermUserRead), RequirePermission(d.PermRoleRead), func(c *gin.Context) {
+            granted = helper.HasPermission(c, d.PermUserWrite)
+            c.Status(http.StatusOK)
+        })
+
+        assert.Equal(t, http.StatusOK, serveTestRouter(r))
+        assert.Equal(t, 1, provider.count)
+        assert.True(t, granted)
+    })
+
+    t.Run("EXPECT FAIL principal not found", func(t *testing.T) {
+        r := newTestRouter(-1, LoadPermissions())
+        assert.Equal(t, http.StatusUnauthorized, serveTestRouter(r))
+    })
+
+    t.Run("EXPECT FAIL permission provider error", func(t *testing.T) {
+        SetPermissionProvider(mockPermissionProvider{})
+        defer SetPermissionProvider(staticPermissionProvider{})
+
+        r := newTestRouter(d.RoleSuperuser, LoadPermissions())
+        assert.Equal(t, http.StatusInternalServerError, serveTestRouter(r))
+    })
+}
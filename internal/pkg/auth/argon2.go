@@ -0,0 +1,181 @@
+/*
+   Argon2id password hasher
+*/
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+const (
+    // default parameter of argon2id hash (RFC 9106 second recommended option)
+    defaultArgon2Memory      = 64 * 1024
+    defaultArgon2Iterations  = 3
+    defaultArgon2Parallelism = 4
+    defaultArgon2SaltLength  = 16
+    defaultArgon2KeyLength   = 32
+
+    // argon2idPrefix is prefix of argon2id hash on PHC format
+    argon2idPrefix = "$argon2id$"
+
+    // bound of argon2id parameter accepted from the stored hash. argon2.IDKey panic on
+    // zero parallelism, and the upper bound keep a tampered hash from exhausting the server
+    maxArgon2Memory     = 4 * 1024 * 1024
+    maxArgon2Iterations = 64
+    minArgon2SaltLength = 8
+    minArgon2KeyLength  = 16
+    maxArgon2KeyLength  = 1024
+)
+
+// randReadFunc is func instance of rand.Read, it will be used to mock the salt on test
+var randReadFunc = rand.Read
+
+// Argon2idHasher is password hasher with argon2id algorithm. the hash is PHC format
+// string: $argon2id$v=19$m=65536,t=3,p=4$salt$hash (salt and hash is unpadded base64)
+type Argon2idHasher struct {
+    // Memory is memory (in KiB) used to hash the password
+    Memory      uint32
+
+    // Iterations is number of pass over the memory
+    Iterations  uint32
+
+    // Parallelism is number of thread used to hash the password
+    Parallelism uint8
+
+    // SaltLength is byte length of the random salt
+    SaltLength  uint32
+
+    // KeyLength is byte length of the hash
+    KeyLength   uint32
+}
+
+// argon2idHash is decoded argon2id hash
+type argon2idHash struct {
+    version int
+    Argon2idHasher
+    salt    []byte
+    key     []byte
+}
+
+// NewArgon2idHasher will create argon2id password hasher, zero or out of range parameter fall
+// back to default value, so the hash can always be verified
+func NewArgon2idHasher(memory, iterations uint32, parallelism uint8, saltLength, keyLength uint32) *Argon2idHasher {
+    h := &Argon2idHasher{memory, iterations, parallelism, saltLength, keyLength}
+    if h.Parallelism == 0 {
+        h.Parallelism = defaultArgon2Parallelism
+    }
+    if h.Memory < 8*uint32(h.Parallelism) || h.Memory > maxArgon2Memory {
+        h.Memory = defaultArgon2Memory
+    }
+    if h.Iterations == 0 || h.Iterations > maxArgon2Iterations {
+        h.Iterations = defaultArgon2Iterations
+    }
+    if h.SaltLength < minArgon2SaltLength {
+        h.SaltLength = defaultArgon2SaltLength
+    }
+    if h.KeyLength < minArgon2KeyLength || h.KeyLength > maxArgon2KeyLength {
+        h.KeyLength = defaultArgon2KeyLength
+    }
+
+    return h
+}
+
+// Hash will hash the password with argon2id and random salt
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+    salt := make([]byte, h.SaltLength)
+    if _, err := randReadFunc(salt); err != nil {
+        return "", err
+    }
+
+    key := argon2.IDKey([]byte(password), salt, h.Iterations, h.Memory, h.Parallelism, h.KeyLength)
+
+    return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version,
+        h.Memory, h.Iterations, h.Parallelism,
+        base64.RawStdEncoding.EncodeToString(salt),
+        base64.RawStdEncoding.EncodeToString(key),
+    ), nil
+}
+
+// Verify will check whether the password match the argon2id hash, the parameter is taken from the hash
+func (h *Argon2idHasher) Verify(password, hash string) bool {
+    decoded, err := decodeArgon2id(hash)
+    if err != nil {
+        return false
+    }
+
+    key := argon2.IDKey([]byte(password), decoded.salt, decoded.Iterations, decoded.Memory,
+        decoded.Parallelism, uint32(len(decoded.key)))
+
+    return subtle.ConstantTimeCompare(key, decoded.key) == 1
+}
+
+// Identify will check whether the hash is argon2id hash
+func (h *Argon2idHasher) Identify(hash string) bool {
+    return strings.HasPrefix(hash, argon2idPrefix)
+}
+
+// NeedRehash will check whether the hash is not argon2id hash of the current version and parameter
+func (h *Argon2idHasher) NeedRehash(hash string) bool {
+    decoded, err := decodeArgon2id(hash)
+    if err != nil {
+        return true
+    }
+
+    return decoded.version != argon2.Version ||
+        decoded.Memory != h.Memory ||
+        decoded.Iterations != h.Iterations ||
+        decoded.Parallelism != h.Parallelism ||
+        decoded.SaltLength != h.SaltLength ||
+        decoded.KeyLength != h.KeyLength
+}
+
+// decodeArgon2id will decode the argon2id hash on PHC format
+func decodeArgon2id(hash string) (*argon2idHash, error) {
+    parts := strings.Split(hash, "$")
+    if len(parts) != 6 || parts[1] != "argon2id" {
+        return nil, fmt.Errorf("invalid argon2id hash format")
+    }
+
+    decoded := new(argon2idHash)
+    if _, err := fmt.Sscanf(parts[2], "v=%d", &decoded.version); err != nil {
+        return nil, fmt.Errorf("invalid argon2id hash version: %v", err)
+    }
+    if decoded.version != argon2.Version {
+        return nil, fmt.Errorf("unsupported argon2id hash version: %d", decoded.version)
+    }
+    if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &decoded.Memory, &decoded.Iterations, &decoded.Parallelism); err != nil {
+        return nil, fmt.Errorf("invalid argon2id hash parameter: %v", err)
+    }
+
+    // memory must be at least 8 KiB per thread (RFC 9106)
+    if decoded.Parallelism == 0 ||
+        decoded.Iterations == 0 || decoded.Iterations > maxArgon2Iterations ||
+        decoded.Memory < 8*uint32(decoded.Parallelism) || decoded.Memory > maxArgon2Memory {
+        return nil, fmt.Errorf("invalid argon2id hash parameter: m=%d,t=%d,p=%d",
+            decoded.Memory, decoded.Iterations, decoded.Parallelism)
+    }
+
+    var err error
+    if decoded.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+        return nil, fmt.Errorf("invalid argon2id hash salt: %v", err)
+    }
+    if decoded.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+        return nil, fmt.Errorf("invalid argon2id hash key: %v", err)
+    }
+    if len(decoded.salt) < minArgon2SaltLength {
+        return nil, fmt.Errorf("invalid argon2id hash salt: too short salt")
+    }
+    if len(decoded.key) < minArgon2KeyLength || len(decoded.key) > maxArgon2KeyLength {
+        return nil, fmt.Errorf("invalid argon2id hash key: invalid key length")
+    }
+    decoded.SaltLength = uint32(len(decoded.salt))
+    decoded.KeyLength = uint32(len(decoded.key))
+
+    return decoded, nil
+}
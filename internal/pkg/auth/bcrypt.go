@@ -0,0 +1,71 @@
+/*
+   Bcrypt password hasher
+*/
+package auth
+
+import (
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+    // defaultBcryptCost is fallback cost of bcrypt hash
+    defaultBcryptCost = 12
+
+    // bcryptMaximumPasswordBytes is byte length of the password used by bcrypt,
+    // the rest of the password is ignored
+    bcryptMaximumPasswordBytes = 72
+)
+
+// BcryptHasher is password hasher with bcrypt algorithm. bcrypt hash
+// ($2a$cost$salt+hash) is used as is since it predate the PHC format
+type BcryptHasher struct {
+    // Cost is cost of the hash, each increment double the hashing time
+    Cost int
+}
+
+// NewBcryptHasher will create bcrypt password hasher, invalid cost fall back to default cost
+func NewBcryptHasher(cost int) *BcryptHasher {
+    if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+        cost = defaultBcryptCost
+    }
+
+    return &BcryptHasher{Cost: cost}
+}
+
+// Hash will hash the password with bcrypt
+func (h *BcryptHasher) Hash(password string) (string, error) {
+    hash, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+    if err != nil {
+        return "", err
+    }
+
+    return string(hash), nil
+}
+
+// Verify will check whether the password match the bcrypt hash
+func (h *BcryptHasher) Verify(password, hash string) bool {
+    return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// Identify will check whether the hash is bcrypt hash
+func (h *BcryptHasher) Identify(hash string) bool {
+    for _, prefix := range []string{"$2a$", "$2b$", "$2y$"} {
+        if strings.HasPrefix(hash, prefix) {
+            return true
+        }
+    }
+
+    return false
+}
+
+// NeedRehash will check whether the hash is not bcrypt hash with the current cost
+func (h *BcryptHasher) NeedRehash(hash string) bool {
+    if !h.Identify(hash) {
+        return true
+    }
+
+    cost, err := bcrypt.Cost([]byte(hash))
+    return err != nil || cost != h.Cost
+}
@@ -8,25 +8,165 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
 	"github.com/reshimahendra/lbw-go/internal/config"
 	d "github.com/reshimahendra/lbw-go/internal/domain"
 	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
 	"github.com/reshimahendra/lbw-go/internal/pkg/helper"
 	"github.com/reshimahendra/lbw-go/internal/pkg/logger"
 )
+// refreshAudiencePrefix is prefix of the refresh token audience. the different
+// audience make sure the refresh token is rejected as access token and vice versa
+const refreshAudiencePrefix = "refresh:"
+
 var (
     // generateSecureKey is instance func of helper.GenerateSecureKey
     generateSecureKeyFunc = helper.GenerateSecureKey
+
+    // revocationChecker is checker used by TokenValid to reject revoked token
+    revocationChecker IRevocationChecker
 )
 
-// CreateToken will 'create' a jwt token
-func CreateToken(email string) (*d.TokenDetailsDTO, error) {
+// IRevocationChecker is interface to check whether a token already revoked
+// (placed on the denylist or issued before its user tokens were revoked) before it is expired
+type IRevocationChecker interface {
+    // IsTokenRevoked will check whether token with given token metadata is revoked
+    IsTokenRevoked(token d.TokenMetadata) (bool, error)
+}
+
+// SetRevocationChecker will register the checker used by TokenValid to reject revoked token
+func SetRevocationChecker(checker IRevocationChecker) {
+    revocationChecker = checker
+}
+
+// Claims is typed jwt claims of our auth token. it carry the identity
+// of the token owner (principal) on top of the standard registered claims
+type Claims struct {
+    // Email is email of the token owner
+    Email    string `json:"email"`
+
+    // RoleID is role id held by the token owner
+    RoleID   int    `json:"role_id"`
+
+    // StatusID is user status of the token owner when the token was issued
+    StatusID int    `json:"status_id"`
+
+    // FamilyID is id of the refresh token family. all token rotated from
+    // the same signin share the same family id
+    FamilyID string `json:"fid"`
+
+    // StandardClaims is registered claims (sub, iat, nbf, exp, jti, iss, aud)
+    jwt.StandardClaims
+}
+
+// Valid will validate the standard claims (exp, iat, nbf) and make sure
+// the token was issued by and for our server
+func (c *Claims) Valid() error {
+    if err := c.StandardClaims.Valid(); err != nil {
+        return err
+    }
+
+    domainName := config.Get().Server.DomainName
+    if !c.VerifyIssuer(domainName, true) || !c.VerifyAudience(domainName, true) {
+        return E.New(E.ErrTokenInvalid)
+    }
+
+    return nil
+}
+
+// refreshClaims is claims of the refresh token. it carry the same identity as the
+// access token but it can only be used to get new token
+type refreshClaims struct {
+    Claims
+}
+
+// Valid will validate the standard claims (exp, iat, nbf) and make sure
+// the token was issued by our server for refreshing the token
+func (c *refreshClaims) Valid() error {
+    if err := c.StandardClaims.Valid(); err != nil {
+        return err
+    }
+
+    domainName := config.Get().Server.DomainName
+    if !c.VerifyIssuer(domainName, true) || !c.VerifyAudience(refreshAudiencePrefix+domainName, true) {
+        return E.New(E.ErrTokenInvalid)
+    }
+
+    return nil
+}
+
+// Principal will convert the claims to principal (the current user)
+func (c *Claims) Principal() (*d.Principal, error) {
+    userID, err := uuid.Parse(c.Subject)
+    if err != nil {
+        return nil, E.New(E.ErrTokenInvalid)
+    }
+
+    return &d.Principal{
+        UserID   : userID,
+        Email    : c.Email,
+        RoleID   : c.RoleID,
+        StatusID : c.StatusID,
+        TokenID  : c.Id,
+        FamilyID : c.FamilyID,
+    }, nil
+}
+
+// Metadata will get token id, user id, family id, issued time and expiration time of the claims
+func (c *Claims) Metadata() (*d.TokenMetadata, error) {
+    // token id is required to put the token on denylist
+    if c.Id == "" {
+        return nil, E.New(E.ErrTokenInvalid)
+    }
+
+    // user id is required to check whether the user tokens were revoked
+    userID, err := uuid.Parse(c.Subject)
+    if err != nil {
+        return nil, E.New(E.ErrTokenInvalid)
+    }
+
+    return &d.TokenMetadata{
+        ID        : c.Id,
+        UserID    : userID,
+        FamilyID  : c.FamilyID,
+        IssuedAt  : time.Unix(c.IssuedAt, 0).UTC(),
+        ExpiresAt : time.Unix(c.ExpiresAt, 0).UTC(),
+    }, nil
+}
+
+// newClaims will create claims for the given principal with given token id and expiration time
+func newClaims(principal d.Principal, tokenID string, issuedAt, expiresAt time.Time, domainName string) *Claims {
+    return &Claims{
+        Email    : principal.Email,
+        RoleID   : principal.RoleID,
+        StatusID : principal.StatusID,
+        FamilyID : principal.FamilyID,
+        StandardClaims : jwt.StandardClaims{
+            Subject   : principal.UserID.String(),
+            Id        : tokenID,
+            IssuedAt  : issuedAt.Unix(),
+            NotBefore : issuedAt.Unix(),
+            ExpiresAt : expiresAt.Unix(),
+            Issuer    : domainName,
+            Audience  : domainName,
+        },
+    }
+}
+
+// CreateToken will 'create' a jwt token for the given principal (the token owner)
+func CreateToken(principal d.Principal) (*d.TokenDetailsDTO, error) {
     // check email validity
-    if !helper.EmailIsValid(email) {
+    if !helper.EmailIsValid(principal.Email) {
         e := E.New(E.ErrEmailIsInvalid)
         return nil, e
     }
 
+    // check user id validity
+    if principal.UserID == uuid.Nil {
+        e := E.New(E.ErrTokenCreate)
+        return nil, e
+    }
+
     // load server configuration
     config := config.Get()
 
@@ -36,35 +176,43 @@ func CreateToken(email string) (*d.TokenDetailsDTO, error) {
         token = new(d.TokenDetailsDTO)
     )
 
-    token.AtExpiresTime = time.Now().Add(
+    issuedAt := time.Now()
+    token.AtExpiresTime = issuedAt.Add(
         time.Duration(config.Server.AccessTokenExpireDuration) * time.Hour)
-    token.RtExpiresTime = time.Now().Add(
+    token.RtExpiresTime = issuedAt.Add(
         time.Duration(config.Server.RefreshTokenExpireDuration) * time.Hour)
 
-    // Construct token
-    atClaims := jwt.MapClaims{}
-    atClaims["email"]     = email
-    atClaims["user_uuid"] = "user_uuid"
-    atClaims["exp"]       = time.Now().Add(time.Hour * 48).Unix()
+    // each token get its own id so it can be revoked individually
+    token.AccessTokenID  = uuid.NewString()
+    token.RefreshTokenID = uuid.NewString()
 
-    aToken := jwt.NewWithClaims(jwt.SigningMethodHS256, atClaims)
-    
-    token.AccessToken, err = aToken.SignedString([]byte(config.Server.SecureKey))
+    // token created on signin start a new refresh token family,
+    // while token created on refresh stay on its family
+    if principal.FamilyID == "" {
+        principal.FamilyID = uuid.NewString()
+    }
+    token.FamilyID = principal.FamilyID
+
+    // get the active key of the ring to sign the token
+    ring, err := currentKeyRing()
+    if err != nil {
+        logger.Errorf("error occur while loading signing key: %v\n", err)
+        return nil, err
+    }
+    key := ring.Active()
+
+    // Construct token
+    atClaims := newClaims(principal, token.AccessTokenID, issuedAt, token.AtExpiresTime, config.Server.DomainName)
+    token.AccessToken, err = key.sign(atClaims)
     if err != nil {
         logger.Errorf("error occur while creating access token: %v\n", err)
         return nil, err
     }
 
     // Construct refresh token 
-    rtClaims := jwt.MapClaims{}
-    rtClaims["email"]     = email
-    rtClaims["user_uuid"] = "user_uuid"
-    rtClaims["exp"]       = time.Now().Add(time.Hour * 96).Unix()
-
-    rToken := jwt.NewWithClaims(jwt.SigningMethodHS256, rtClaims)
-
-    // rt := NewJwtToken(rToken)
-    token.RefreshToken, err = rToken.SignedString([]byte(config.Server.SecureKey))
+    rtClaims := &refreshClaims{*newClaims(principal, token.RefreshTokenID, issuedAt, token.RtExpiresTime, config.Server.DomainName)}
+    rtClaims.Audience = refreshAudiencePrefix + config.Server.DomainName
+    token.RefreshToken, err = key.sign(rtClaims)
     if err != nil {
         logger.Errorf("error occur while creating refresh token: %v\n", err)
         return nil, err
@@ -81,16 +229,33 @@ func CreateToken(email string) (*d.TokenDetailsDTO, error) {
     return token, err
 }
 
-// verifyToken will verify the given token 
-func verifyToken(token string) (*jwt.Token, error) {
-    config := config.Get()
-    verifiedToken, err := jwt.Parse(token, func (verifiedToken *jwt.Token) (interface{}, error) {
-        if _, ok := verifiedToken.Method.(*jwt.SigningMethodHMAC); !ok {
+// keyFunc will pick the key of the ring to verify the token
+func keyFunc(ring *KeyRing) jwt.Keyfunc {
+    return func (verifiedToken *jwt.Token) (interface{}, error) {
+        // pick the key by 'kid' header, token without it was signed by the key without id
+        kid, _ := verifiedToken.Header["kid"].(string)
+        key, ok := ring.Key(kid)
+        if !ok {
+            return nil, fmt.Errorf("Unexpected key id: %v", verifiedToken.Header["kid"])
+        }
+
+        // only accept token signed with the algorithm of the key
+        if verifiedToken.Method.Alg() != key.Method.Alg() {
             return nil, fmt.Errorf("Unexpected signing method: %v", verifiedToken.Header["alg"])
         }
-        return []byte(config.Server.SecureKey), nil
-    })
+        return key.VerifyKey, nil
+    }
+}
+
+// verifyToken will verify the given token against the given claims type
+func verifyToken(token string, claims jwt.Claims) (*jwt.Token, error) {
+    ring, err := currentKeyRing()
+    if err != nil {
+        logger.Errorf("error occur while loading signing key: %v\n", err)
+        return nil, E.New(E.ErrTokenInvalid)
+    }
 
+    verifiedToken, err := jwt.ParseWithClaims(token, claims, keyFunc(ring))
     if err != nil {
         e := E.New(E.ErrTokenInvalid)
         return verifiedToken, e 
@@ -99,13 +264,25 @@ func verifyToken(token string) (*jwt.Token, error) {
     return verifiedToken, nil
 }
 
-// TokenValid will check whether the 'given' token was valid or not
+// TokenValid will check whether the 'given' access token was valid or not.
+// refresh token and pending token is rejected
 func TokenValid(bearerToken string) (*jwt.Token, error) {
+    return tokenValid(bearerToken, &Claims{})
+}
+
+// RefreshTokenValid will check whether the 'given' refresh token was valid or not.
+// access token and pending token is rejected
+func RefreshTokenValid(refreshToken string) (*jwt.Token, error) {
+    return tokenValid(refreshToken, &refreshClaims{})
+}
+
+// tokenValid will check whether the token was valid token of the given claims type
+func tokenValid(tokenStr string, claims jwt.Claims) (*jwt.Token, error) {
     // new invalid token error
     e := E.New(E.ErrTokenInvalid)
     
     // check token validity
-    token, err := verifyToken(bearerToken)
+    token, err := verifyToken(tokenStr, claims)
     if err != nil {
         if token != nil {
             return token, e
@@ -117,5 +294,64 @@ func TokenValid(bearerToken string) (*jwt.Token, error) {
         return nil, e
     }
 
+    // reject token that already revoked
+    if revocationChecker != nil {
+        metadata, err := ExtractTokenMetadata(token)
+        if err != nil {
+            return nil, e
+        }
+
+        if err := checkRevoked(*metadata); err != nil {
+            return nil, err
+        }
+    }
+
     return token, nil
 }
+
+// checkRevoked will check the token against the revocation checker
+func checkRevoked(metadata d.TokenMetadata) error {
+    revoked, err := revocationChecker.IsTokenRevoked(metadata)
+    if err != nil {
+        logger.Errorf("error occur while checking token revocation: %v\n", err)
+        return E.New(E.ErrTokenInvalid)
+    }
+    if revoked {
+        return E.New(E.ErrTokenRevoked)
+    }
+
+    return nil
+}
+
+// ExtractTokenMetadata will get token id, user id, family id, issued time and expiration time
+// from the given token claims
+func ExtractTokenMetadata(token *jwt.Token) (*d.TokenMetadata, error) {
+    claims, ok := claimsOf(token)
+    if !ok {
+        return nil, E.New(E.ErrTokenInvalid)
+    }
+
+    return claims.Metadata()
+}
+
+// ExtractPrincipal will get the principal (token owner) from the given token claims
+func ExtractPrincipal(token *jwt.Token) (*d.Principal, error) {
+    claims, ok := claimsOf(token)
+    if !ok {
+        return nil, E.New(E.ErrTokenInvalid)
+    }
+
+    return claims.Principal()
+}
+
+// claimsOf will get the typed claims of access or refresh token
+func claimsOf(token *jwt.Token) (*Claims, bool) {
+    switch claims := token.Claims.(type) {
+    case *Claims:
+        return claims, true
+    case *refreshClaims:
+        return &claims.Claims, true
+    }
+
+    return nil, false
+}
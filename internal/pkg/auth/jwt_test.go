@@ -2,9 +2,12 @@ package auth
 
 import (
 	"testing"
+	"time"
 
 	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
 	"github.com/reshimahendra/lbw-go/internal/config"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
     E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
 	"github.com/stretchr/testify/assert"
 )
@@ -35,11 +38,13 @@ var (
 func TestCreateToken(t *testing.T) {
     cases := []struct{
         name, email string
+        userID uuid.UUID
         wantErr bool
     }{
-        {"EXPECT SUCCESS", "test@gmail.com", false},
-        {"EXPECT FAIL email invalid", "", true},
-        {"EXPECT FAIL secure key fail", "test@gmail.com", true},
+        {"EXPECT SUCCESS", "test@gmail.com", uuid.New(), false},
+        {"EXPECT FAIL email invalid", "", uuid.New(), true},
+        {"EXPECT FAIL user id invalid", "test@gmail.com", uuid.Nil, true},
+        {"EXPECT FAIL secure key fail", "test@gmail.com", uuid.New(), true},
     }
 
     err := config.Setup()
@@ -59,13 +64,13 @@ func TestCreateToken(t *testing.T) {
                 defer func() { generateSecureKeyFunc = genSecureKey }()
 
                 // actual test
-                got, err := CreateToken(tt.email)
+                got, err := CreateToken(d.Principal{UserID: tt.userID, Email: tt.email})
 
                 assert.Error(t, err)
                 assert.Nil(t, got)
             } else {
                 // actual test
-                got, err := CreateToken(tt.email)
+                got, err := CreateToken(d.Principal{UserID: tt.userID, Email: tt.email})
 
                 assert.NoError(t, err)
                 assert.NotNil(t, got)
@@ -75,7 +80,7 @@ func TestCreateToken(t *testing.T) {
 }
 
 func TestVerifyToken(t *testing.T) {
-    aNewTok, _ := CreateToken("aabi@basd.com")
+    aNewTok, _ := CreateToken(d.Principal{UserID: uuid.New(), Email: "aabi@basd.com"})
     cases := []struct{
         name,token string
         wantErr bool
@@ -94,7 +99,7 @@ func TestVerifyToken(t *testing.T) {
     for _, tt := range cases {
         t.Run(tt.name, func(t *testing.T){
             // actual test
-            got, err := verifyToken(tt.token)
+            got, err := verifyToken(tt.token, &Claims{})
             if tt.wantErr {
                 assert.Error(t, err)
                 assert.Nil(t, got)
@@ -107,12 +112,13 @@ func TestVerifyToken(t *testing.T) {
 }
 
 func TestTokenValid(t *testing.T) {
-    aNewTok, _ := CreateToken("aabi@basd.com")
+    aNewTok, _ := CreateToken(d.Principal{UserID: uuid.New(), Email: "aabi@basd.com"})
     cases := []struct{
         name,token string
         wantErr bool
     }{
         {"EXPECT SUCCESS", aNewTok.AccessToken, false},
+        {"EXPECT FAIL refresh token", aNewTok.RefreshToken, true},
         {"EXPECT FAIL invalid token", aTok+"a", true},
         {"EXPECT FAIL", "'", true},
     }
@@ -137,3 +143,254 @@ func TestTokenValid(t *testing.T) {
         })
     }
 }
+
+// TestRefreshTokenValid will test refresh token validation, access token must be rejected
+func TestRefreshTokenValid(t *testing.T) {
+    err := config.Setup()
+    if err != nil {
+        t.Fatalf("unexpected error occur: %v\n", err)
+    }
+
+    aNewTok, err := CreateToken(d.Principal{UserID: uuid.New(), Email: "aabi@basd.com"})
+    assert.NoError(t, err)
+
+    pending, _, err := CreatePendingToken(d.Principal{UserID: uuid.New(), Email: "aabi@basd.com"}, time.Minute)
+    assert.NoError(t, err)
+
+    cases := []struct{
+        name,token string
+        wantErr bool
+    }{
+        {"EXPECT SUCCESS", aNewTok.RefreshToken, false},
+        {"EXPECT FAIL access token", aNewTok.AccessToken, true},
+        {"EXPECT FAIL pending token", pending, true},
+        {"EXPECT FAIL invalid token", "'", true},
+    }
+
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            // actual test
+            got, err := RefreshTokenValid(tt.token)
+            if tt.wantErr {
+                assert.Equal(t, E.New(E.ErrTokenInvalid), err)
+                return
+            }
+
+            assert.NoError(t, err)
+            metadata, err := ExtractTokenMetadata(got)
+            assert.NoError(t, err)
+            assert.Equal(t, aNewTok.RefreshTokenID, metadata.ID)
+        })
+    }
+}
+
+// mockRevocationChecker is mocked IRevocationChecker
+type mockRevocationChecker struct {
+    revoked bool
+    err     error
+}
+
+// IsTokenRevoked is mocked IsTokenRevoked method to satisfy IRevocationChecker interface
+func (m *mockRevocationChecker) IsTokenRevoked(token d.TokenMetadata) (bool, error) {
+    return m.revoked, m.err
+}
+
+// TestTokenValidRevoked will test TokenValid behaviour with registered revocation checker
+func TestTokenValidRevoked(t *testing.T) {
+    err := config.Setup()
+    if err != nil {
+        t.Fatalf("unexpected error occur: %v\n", err)
+    }
+
+    aNewTok, err := CreateToken(d.Principal{UserID: uuid.New(), Email: "aabi@basd.com"})
+    assert.NoError(t, err)
+
+    cases := []struct{
+        name string
+        checker *mockRevocationChecker
+        wantErr error
+    }{
+        {"EXPECT SUCCESS token not revoked", &mockRevocationChecker{}, nil},
+        {"EXPECT FAIL token revoked", &mockRevocationChecker{revoked: true}, E.New(E.ErrTokenRevoked)},
+        {"EXPECT FAIL checker error", &mockRevocationChecker{err: E.New(E.ErrDatabase)}, E.New(E.ErrTokenInvalid)},
+    }
+
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            SetRevocationChecker(tt.checker)
+            defer SetRevocationChecker(nil)
+
+            // actual test
+            got, err := TokenValid(aNewTok.AccessToken)
+            if tt.wantErr != nil {
+                assert.Error(t, err)
+                assert.Equal(t, tt.wantErr, err)
+                assert.Nil(t, got)
+            } else {
+                assert.NoError(t, err)
+                assert.NotNil(t, got)
+            }
+        })
+    }
+}
+
+// TestExtractTokenMetadata will test token metadata extraction from token claims
+func TestExtractTokenMetadata(t *testing.T) {
+    err := config.Setup()
+    if err != nil {
+        t.Fatalf("unexpected error occur: %v\n", err)
+    }
+
+    userID := uuid.New()
+    aNewTok, err := CreateToken(d.Principal{UserID: userID, Email: "aabi@basd.com"})
+    assert.NoError(t, err)
+
+    // EXPECT SUCCESS token id and expiration time match with the created token
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        token, err := TokenValid(aNewTok.AccessToken)
+        assert.NoError(t, err)
+
+        got, err := ExtractTokenMetadata(token)
+        assert.NoError(t, err)
+        assert.Equal(t, aNewTok.AccessTokenID, got.ID)
+        assert.Equal(t, userID, got.UserID)
+        assert.Equal(t, aNewTok.FamilyID, got.FamilyID)
+        assert.False(t, got.IssuedAt.IsZero())
+        assert.Equal(t, aNewTok.AtExpiresTime.Unix(), got.ExpiresAt.Unix())
+    })
+
+    // EXPECT FAIL token with untyped claims
+    t.Run("EXPECT FAIL claims invalid", func(t *testing.T){
+        token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"jti": uuid.NewString()})
+
+        got, err := ExtractTokenMetadata(token)
+        assert.Error(t, err)
+        assert.Nil(t, got)
+    })
+
+    // EXPECT FAIL token without 'jti' claim
+    t.Run("EXPECT FAIL token id not found", func(t *testing.T){
+        token := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{
+            StandardClaims: jwt.StandardClaims{Subject: uuid.NewString(), ExpiresAt: 1},
+        })
+
+        got, err := ExtractTokenMetadata(token)
+        assert.Error(t, err)
+        assert.Nil(t, got)
+    })
+
+    // EXPECT FAIL token without valid 'sub' claim
+    t.Run("EXPECT FAIL user id invalid", func(t *testing.T){
+        token := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{
+            StandardClaims: jwt.StandardClaims{Id: uuid.NewString(), Subject: "user_uuid", ExpiresAt: 1},
+        })
+
+        got, err := ExtractTokenMetadata(token)
+        assert.Error(t, err)
+        assert.Nil(t, got)
+    })
+}
+
+// TestExtractPrincipal will test principal extraction from token claims
+func TestExtractPrincipal(t *testing.T) {
+    err := config.Setup()
+    if err != nil {
+        t.Fatalf("unexpected error occur: %v\n", err)
+    }
+
+    want := d.Principal{UserID: uuid.New(), Email: "aabi@basd.com", RoleID: 2, StatusID: 1}
+    aNewTok, err := CreateToken(want)
+    assert.NoError(t, err)
+
+    // EXPECT SUCCESS principal match with the token owner
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        token, err := TokenValid(aNewTok.AccessToken)
+        assert.NoError(t, err)
+
+        got, err := ExtractPrincipal(token)
+        assert.NoError(t, err)
+        assert.Equal(t, want.UserID, got.UserID)
+        assert.Equal(t, want.Email, got.Email)
+        assert.Equal(t, want.RoleID, got.RoleID)
+        assert.Equal(t, want.StatusID, got.StatusID)
+        assert.Equal(t, aNewTok.AccessTokenID, got.TokenID)
+        assert.NotEmpty(t, got.FamilyID)
+    })
+
+    // EXPECT SUCCESS token created for principal with family id stay on the family
+    t.Run("EXPECT SUCCESS keep family id", func(t *testing.T){
+        rotated, err := CreateToken(d.Principal{UserID: want.UserID, Email: want.Email, FamilyID: aNewTok.FamilyID})
+        assert.NoError(t, err)
+
+        token, err := RefreshTokenValid(rotated.RefreshToken)
+        assert.NoError(t, err)
+
+        got, err := ExtractPrincipal(token)
+        assert.NoError(t, err)
+        assert.Equal(t, aNewTok.FamilyID, rotated.FamilyID)
+        assert.Equal(t, aNewTok.FamilyID, got.FamilyID)
+        assert.Equal(t, rotated.RefreshTokenID, got.TokenID)
+    })
+
+    // EXPECT FAIL token with untyped claims
+    t.Run("EXPECT FAIL claims invalid", func(t *testing.T){
+        token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": want.UserID.String()})
+
+        got, err := ExtractPrincipal(token)
+        assert.Error(t, err)
+        assert.Nil(t, got)
+    })
+
+    // EXPECT FAIL token without valid 'sub' claim
+    t.Run("EXPECT FAIL user id invalid", func(t *testing.T){
+        token := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{
+            StandardClaims: jwt.StandardClaims{Subject: "user_uuid"},
+        })
+
+        got, err := ExtractPrincipal(token)
+        assert.Error(t, err)
+        assert.Nil(t, got)
+    })
+}
+
+// TestClaimsValid will test issuer and audience validation of the claims
+func TestClaimsValid(t *testing.T) {
+    err := config.Setup()
+    if err != nil {
+        t.Fatalf("unexpected error occur: %v\n", err)
+    }
+
+    domainName := config.Get().Server.DomainName
+    now := time.Now()
+    cases := []struct{
+        name, issuer, audience string
+        nbf int64
+        wantErr bool
+    }{
+        {"EXPECT SUCCESS", domainName, domainName, now.Unix(), false},
+        {"EXPECT FAIL issuer invalid", "other.com", domainName, now.Unix(), true},
+        {"EXPECT FAIL audience invalid", domainName, "other.com", now.Unix(), true},
+        {"EXPECT FAIL not valid yet", domainName, domainName, now.Add(time.Hour).Unix(), true},
+    }
+
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            claims := &Claims{
+                StandardClaims: jwt.StandardClaims{
+                    Subject   : uuid.NewString(),
+                    Issuer    : tt.issuer,
+                    Audience  : tt.audience,
+                    NotBefore : tt.nbf,
+                    ExpiresAt : now.Add(time.Hour).Unix(),
+                },
+            }
+
+            err := claims.Valid()
+            if tt.wantErr {
+                assert.Error(t, err)
+            } else {
+                assert.NoError(t, err)
+            }
+        })
+    }
+}
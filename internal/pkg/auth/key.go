@@ -0,0 +1,537 @@
+/*
+   Signing key ring to sign and verify auth token
+*/
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/reshimahendra/lbw-go/internal/config"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/logger"
+)
+
+var (
+    // keyRing is key ring loaded on SetupKeyRing. when it is not set, the key ring
+    // is loaded from the current server configuration on its first use
+    keyRing *KeyRing
+
+    // keyRingMu is guard for keyRing setup
+    keyRingMu sync.Mutex
+
+    // keyRingStore is store of the key ring state registered on SetKeyRingStore
+    keyRingStore IKeyRingStore
+
+    // keyRingSyncInterval is interval the key ring state is reloaded from keyRingStore,
+    // so promote and retire on other server instance is picked up
+    keyRingSyncInterval = time.Minute
+
+    // timeNowFunc is instance func of time.Now
+    timeNowFunc = time.Now
+)
+
+// SigningKey is key used to sign and verify auth token
+type SigningKey struct {
+    // ID is key id, it is set as 'kid' header of the token
+    ID            string
+
+    // Method is jwt signing method of the key
+    Method        jwt.SigningMethod
+
+    // SignKey is key to sign the token (secret for HS256, private key for RS256/EdDSA)
+    // it is nil for verify only key loaded from public key
+    SignKey       interface{}
+
+    // VerifyKey is key to verify the token (secret for HS256, public key for RS256/EdDSA)
+    VerifyKey     interface{}
+
+    // DeactivatedAt is time the key stop signing new token. it is zero for the active key
+    DeactivatedAt time.Time
+}
+
+// KeyRing is set of signing key. only the active key sign new token,
+// all keys on the ring verify the token signed by them
+type KeyRing struct {
+    mu       sync.RWMutex
+    active   *SigningKey
+    keys     map[string]*SigningKey
+    store    IKeyRingStore
+    syncedAt time.Time
+}
+
+// IKeyRingStore is interface to persist the key ring state, so promote and retire
+// survive restart and are shared by all server instance
+type IKeyRingStore interface {
+    // Record will record the key not recorded yet. the active key is only recorded as
+    // active when no key is active yet, other key is recorded as deactivated at the given time
+    Record(ids []string, activeID string, at time.Time) error
+
+    // Gets will get the state of all recorded keys
+    Gets() ([]d.SigningKeyState, error)
+
+    // Promote will make the key the active key, the previous active key is deactivated at the given time
+    Promote(kid string, at time.Time) error
+
+    // Retire will mark the key as retired at the given time
+    Retire(kid string, at time.Time) error
+}
+
+// SetKeyRingStore will register the store of the key ring state
+func SetKeyRingStore(store IKeyRingStore) {
+    keyRingMu.Lock()
+    defer keyRingMu.Unlock()
+
+    keyRingStore = store
+}
+
+// LoadSigningKey will load signing key from the key configuration
+func LoadSigningKey(cfg config.SigningKey) (*SigningKey, error) {
+    key := &SigningKey{ID: cfg.ID}
+
+    switch strings.ToUpper(cfg.Method) {
+    case "", jwt.SigningMethodHS256.Alg():
+        if cfg.Secret == "" {
+            return nil, E.New(E.ErrSigningKey)
+        }
+        key.Method = jwt.SigningMethodHS256
+        key.SignKey = []byte(cfg.Secret)
+        key.VerifyKey = []byte(cfg.Secret)
+    case jwt.SigningMethodRS256.Alg():
+        pem, err := os.ReadFile(cfg.File)
+        if err != nil {
+            logger.Errorf("fail reading signing key file: %v", err)
+            return nil, E.NewExt(E.ErrSigningKey, err)
+        }
+
+        key.Method = jwt.SigningMethodRS256
+        if privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(pem); err == nil {
+            key.SignKey = privateKey
+            key.VerifyKey = &privateKey.PublicKey
+        } else if publicKey, err := jwt.ParseRSAPublicKeyFromPEM(pem); err == nil {
+            key.VerifyKey = publicKey
+        } else {
+            logger.Errorf("fail parsing RSA signing key: %v", err)
+            return nil, E.NewExt(E.ErrSigningKey, err)
+        }
+    case strings.ToUpper(jwt.SigningMethodEdDSA.Alg()):
+        pem, err := os.ReadFile(cfg.File)
+        if err != nil {
+            logger.Errorf("fail reading signing key file: %v", err)
+            return nil, E.NewExt(E.ErrSigningKey, err)
+        }
+
+        key.Method = jwt.SigningMethodEdDSA
+        if privateKey, err := jwt.ParseEdPrivateKeyFromPEM(pem); err == nil {
+            key.SignKey = privateKey
+            key.VerifyKey = privateKey.(ed25519.PrivateKey).Public()
+        } else if publicKey, err := jwt.ParseEdPublicKeyFromPEM(pem); err == nil {
+            key.VerifyKey = publicKey
+        } else {
+            logger.Errorf("fail parsing Ed25519 signing key: %v", err)
+            return nil, E.NewExt(E.ErrSigningKey, err)
+        }
+    default:
+        return nil, E.New(E.ErrSigningKey)
+    }
+
+    return key, nil
+}
+
+// LoadKeyRing will load the key ring of the server configuration. server without
+// key ring use the single key of SigningMethod, SigningKeyID, SigningKeyFile and SecureKey
+func LoadKeyRing(cfg config.Server) (*KeyRing, error) {
+    keys := cfg.SigningKeys
+    activeID := cfg.ActiveSigningKeyID
+
+    if len(keys) == 0 {
+        key := config.SigningKey{
+            ID     : cfg.SigningKeyID,
+            Method : cfg.SigningMethod,
+            File   : cfg.SigningKeyFile,
+        }
+
+        // HS256 key use the secure key, it must meet the minimum length
+        if method := strings.ToUpper(cfg.SigningMethod); method == "" || method == jwt.SigningMethodHS256.Alg() {
+            secret, err := cfg.GetSecureKey()
+            if err != nil {
+                return nil, err
+            }
+            key.Secret = secret
+        }
+
+        keys = []config.SigningKey{key}
+        activeID = cfg.SigningKeyID
+    }
+
+    ring := &KeyRing{keys: map[string]*SigningKey{}}
+    loadedAt := timeNowFunc()
+    for _, keyCfg := range keys {
+        // key id is required to pick the key on the ring
+        if _, ok := ring.keys[keyCfg.ID]; ok || (keyCfg.ID == "" && len(keys) > 1) {
+            logger.Errorf("signing key id %q is empty or duplicated", keyCfg.ID)
+            return nil, E.New(E.ErrSigningKey)
+        }
+
+        key, err := LoadSigningKey(keyCfg)
+        if err != nil {
+            return nil, err
+        }
+
+        // the time verify only key was deactivated is unknown, count it from the time it is
+        // loaded until the recorded time is applied on Sync
+        if key.ID != activeID {
+            key.DeactivatedAt = loadedAt
+        }
+        ring.keys[key.ID] = key
+    }
+
+    // active key must be on the ring and able to sign the token
+    active, ok := ring.keys[activeID]
+    if !ok || active.SignKey == nil {
+        logger.Errorf("active signing key %q not found or can not sign token", activeID)
+        return nil, E.New(E.ErrSigningKey)
+    }
+    ring.active = active
+
+    return ring, nil
+}
+
+// Active will get the key used to sign new token
+func (r *KeyRing) Active() *SigningKey {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    return r.active
+}
+
+// Key will get the key with the given key id
+func (r *KeyRing) Key(kid string) (*SigningKey, bool) {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    key, ok := r.keys[kid]
+    return key, ok
+}
+
+// Keys will get all keys on the ring ordered by its id
+func (r *KeyRing) Keys() []*SigningKey {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    keys := make([]*SigningKey, 0, len(r.keys))
+    for _, key := range r.keys {
+        keys = append(keys, key)
+    }
+    sort.Slice(keys, func(i, j int) bool { return keys[i].ID < keys[j].ID })
+
+    return keys
+}
+
+// Promote will make the key with the given key id the active key. the previous
+// active key stay on the ring to verify token signed before the promotion
+func (r *KeyRing) Promote(kid string) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    key, ok := r.keys[kid]
+    if !ok {
+        return E.New(E.ErrDataNotFound)
+    }
+
+    // verify only key can not sign token
+    if key.SignKey == nil {
+        return E.New(E.ErrSigningKeyVerifyOnly)
+    }
+
+    if key != r.active {
+        now := timeNowFunc()
+        if r.store != nil {
+            if err := r.store.Promote(kid, now); err != nil {
+                return err
+            }
+        }
+
+        r.active.DeactivatedAt = now
+        key.DeactivatedAt = time.Time{}
+        r.active = key
+    }
+
+    return nil
+}
+
+// Retire will remove the key with the given key id from the ring. only key that
+// stop signing token longer than maxLifetime ago can be retired, so no valid
+// token signed by the key is left
+func (r *KeyRing) Retire(kid string, maxLifetime time.Duration) error {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    key, ok := r.keys[kid]
+    if !ok {
+        return E.New(E.ErrDataNotFound)
+    }
+
+    now := timeNowFunc()
+    if key == r.active || now.Before(key.DeactivatedAt.Add(maxLifetime)) {
+        return E.New(E.ErrSigningKeyInUse)
+    }
+
+    if r.store != nil {
+        if err := r.store.Retire(kid, now); err != nil {
+            return err
+        }
+    }
+    delete(r.keys, kid)
+
+    return nil
+}
+
+// Sync will record the keys on the ring that are not recorded yet and apply the recorded
+// state to the ring: the recorded active key sign new token, the recorded deactivation
+// time is kept across restart and the retired key is removed from the ring
+func (r *KeyRing) Sync() error {
+    r.mu.RLock()
+    store := r.store
+    activeID := r.active.ID
+    ids := make([]string, 0, len(r.keys))
+    for id := range r.keys {
+        ids = append(ids, id)
+    }
+    r.mu.RUnlock()
+
+    if store == nil {
+        return nil
+    }
+
+    // the store is not called while holding the lock, so token operation is not blocked
+    now := timeNowFunc()
+    if err := store.Record(ids, activeID, now); err != nil {
+        return err
+    }
+    states, err := store.Gets()
+    if err != nil {
+        return err
+    }
+
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    // recorded active key must be on the ring and able to sign the token
+    for _, state := range states {
+        if !state.Active {
+            continue
+        }
+
+        key, ok := r.keys[state.ID]
+        if !ok || key.SignKey == nil {
+            logger.Errorf("recorded active signing key %q not found or can not sign token", state.ID)
+            break
+        }
+        if key != r.active {
+            r.active.DeactivatedAt = now
+            key.DeactivatedAt = time.Time{}
+            r.active = key
+        }
+        break
+    }
+
+    for _, state := range states {
+        key, ok := r.keys[state.ID]
+        if !ok || key == r.active {
+            continue
+        }
+
+        if state.RetiredAt != nil {
+            delete(r.keys, state.ID)
+            continue
+        }
+        if state.DeactivatedAt != nil {
+            key.DeactivatedAt = *state.DeactivatedAt
+        }
+    }
+
+    return nil
+}
+
+// syncIfDue will attach the store to the ring and sync the ring state once
+// keyRingSyncInterval has passed since the last sync
+func (r *KeyRing) syncIfDue(store IKeyRingStore) {
+    r.mu.Lock()
+    if store == nil {
+        r.mu.Unlock()
+        return
+    }
+    if r.store != store {
+        r.store = store
+        r.syncedAt = time.Time{}
+    }
+
+    // sync time is set before the sync, so the ring is synced once on concurrent call
+    now := timeNowFunc()
+    if now.Sub(r.syncedAt) < keyRingSyncInterval {
+        r.mu.Unlock()
+        return
+    }
+    r.syncedAt = now
+    r.mu.Unlock()
+
+    if err := r.Sync(); err != nil {
+        logger.Errorf("fail syncing signing key ring: %v", err)
+    }
+}
+
+// SetupKeyRing will load the key ring once so it is not loaded on every token operation
+func SetupKeyRing(cfg config.Server) error {
+    ring, err := LoadKeyRing(cfg)
+    if err != nil {
+        return err
+    }
+
+    keyRingMu.Lock()
+    keyRing = ring
+    keyRingMu.Unlock()
+
+    return nil
+}
+
+// currentKeyRing will get the key ring loaded on SetupKeyRing, or load it
+// from the current server configuration when it is not set. the ring state
+// is synced with the registered key ring store
+func currentKeyRing() (*KeyRing, error) {
+    keyRingMu.Lock()
+    if keyRing == nil {
+        ring, err := LoadKeyRing(config.Get().Server)
+        if err != nil {
+            keyRingMu.Unlock()
+            return nil, err
+        }
+        keyRing = ring
+    }
+    ring, store := keyRing, keyRingStore
+    keyRingMu.Unlock()
+
+    ring.syncIfDue(store)
+
+    return ring, nil
+}
+
+// sign will sign the claims with the signing key and put the key id on the token header
+func (k *SigningKey) sign(claims jwt.Claims) (string, error) {
+    token := jwt.NewWithClaims(k.Method, claims)
+    if k.ID != "" {
+        token.Header["kid"] = k.ID
+    }
+
+    return token.SignedString(k.SignKey)
+}
+
+// JWK will convert the public key to JSON Web Key. symmetric (HS256) key
+// is secret, so it can not be published
+func (k *SigningKey) JWK() (*d.JWK, bool) {
+    switch key := k.VerifyKey.(type) {
+    case *rsa.PublicKey:
+        return &d.JWK{
+            Kty : "RSA",
+            Use : "sig",
+            Kid : k.ID,
+            Alg : k.Method.Alg(),
+            N   : base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+            E   : base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+        }, true
+    case ed25519.PublicKey:
+        return &d.JWK{
+            Kty : "OKP",
+            Use : "sig",
+            Kid : k.ID,
+            Alg : k.Method.Alg(),
+            Crv : "Ed25519",
+            X   : base64.RawURLEncoding.EncodeToString(key),
+        }, true
+    }
+
+    return nil, false
+}
+
+// PublicJWKS will get the public key set of all keys on the ring to verify our auth token
+func PublicJWKS() (*d.JWKS, error) {
+    ring, err := currentKeyRing()
+    if err != nil {
+        return nil, err
+    }
+
+    jwks := &d.JWKS{Keys: []d.JWK{}}
+    for _, key := range ring.Keys() {
+        if jwk, ok := key.JWK(); ok {
+            jwks.Keys = append(jwks.Keys, *jwk)
+        }
+    }
+
+    return jwks, nil
+}
+
+// SigningKeys will get the state of all keys on the ring
+func SigningKeys() ([]*d.SigningKeyResponse, error) {
+    ring, err := currentKeyRing()
+    if err != nil {
+        return nil, err
+    }
+
+    active := ring.Active()
+    res := []*d.SigningKeyResponse{}
+    for _, key := range ring.Keys() {
+        info := &d.SigningKeyResponse{
+            ID       : key.ID,
+            Alg      : key.Method.Alg(),
+            Active   : key == active,
+            CanSign  : key.SignKey != nil,
+        }
+        if !key.DeactivatedAt.IsZero() {
+            deactivatedAt := key.DeactivatedAt
+            info.DeactivatedAt = &deactivatedAt
+        }
+        res = append(res, info)
+    }
+
+    return res, nil
+}
+
+// PromoteSigningKey will make the key with the given key id sign new token
+func PromoteSigningKey(kid string) error {
+    ring, err := currentKeyRing()
+    if err != nil {
+        return err
+    }
+
+    return ring.Promote(kid)
+}
+
+// RetireSigningKey will remove the key with the given key id from the ring
+// once the longest token lifetime has passed since it was deactivated
+func RetireSigningKey(kid string) error {
+    ring, err := currentKeyRing()
+    if err != nil {
+        return err
+    }
+
+    return ring.Retire(kid, maxTokenLifetime(config.Get().Server))
+}
+
+// maxTokenLifetime will get the longest lifetime of access and refresh token
+func maxTokenLifetime(cfg config.Server) time.Duration {
+    lifetime := cfg.AccessTokenExpireDuration
+    if cfg.RefreshTokenExpireDuration > lifetime {
+        lifetime = cfg.RefreshTokenExpireDuration
+    }
+
+    return time.Duration(lifetime) * time.Hour
+}
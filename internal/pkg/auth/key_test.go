@@ -0,0 +1,508 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+	"github.com/reshimahendra/lbw-go/internal/config"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeTestKey will write the private key as PKCS8 PEM file on test temp dir
+func writeTestKey(t *testing.T, name string, key interface{}) string {
+    t.Helper()
+
+    der, err := x509.MarshalPKCS8PrivateKey(key)
+    if err != nil {
+        t.Fatalf("unexpected error occur: %v", err)
+    }
+
+    return writeTestPEM(t, name, &pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+// writeTestPublicKey will write the public key as PKIX PEM file on test temp dir
+func writeTestPublicKey(t *testing.T, name string, key interface{}) string {
+    t.Helper()
+
+    der, err := x509.MarshalPKIXPublicKey(key)
+    if err != nil {
+        t.Fatalf("unexpected error occur: %v", err)
+    }
+
+    return writeTestPEM(t, name, &pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+// writeTestPEM will write the PEM block on test temp dir
+func writeTestPEM(t *testing.T, name string, block *pem.Block) string {
+    t.Helper()
+
+    path := filepath.Join(t.TempDir(), name)
+    if err := os.WriteFile(path, pem.EncodeToMemory(block), 0600); err != nil {
+        t.Fatalf("unexpected error occur: %v", err)
+    }
+
+    return path
+}
+
+// TestLoadSigningKey will test signing key loading for each signing method
+func TestLoadSigningKey(t *testing.T) {
+    rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+    assert.NoError(t, err)
+    edPublic, edKey, err := ed25519.GenerateKey(rand.Reader)
+    assert.NoError(t, err)
+
+    rsaFile := writeTestKey(t, "rsa.pem", rsaKey)
+    edFile := writeTestKey(t, "ed25519.pem", edKey)
+
+    cases := []struct{
+        name string
+        cfg config.SigningKey
+        wantMethod jwt.SigningMethod
+        wantCanSign bool
+        wantErr bool
+    }{
+        {"EXPECT SUCCESS default HS256", config.SigningKey{ID: "hs-1", Secret: "secure-key-is-a-secret"}, jwt.SigningMethodHS256, true, false},
+        {"EXPECT SUCCESS RS256", config.SigningKey{ID: "rsa-1", Method: "RS256", File: rsaFile}, jwt.SigningMethodRS256, true, false},
+        {"EXPECT SUCCESS EdDSA", config.SigningKey{ID: "ed-1", Method: "EdDSA", File: edFile}, jwt.SigningMethodEdDSA, true, false},
+        {"EXPECT SUCCESS RS256 public key", config.SigningKey{ID: "rsa-2", Method: "RS256", File: writeTestPublicKey(t, "rsa.pub", &rsaKey.PublicKey)}, jwt.SigningMethodRS256, false, false},
+        {"EXPECT SUCCESS EdDSA public key", config.SigningKey{ID: "ed-2", Method: "EdDSA", File: writeTestPublicKey(t, "ed25519.pub", edPublic)}, jwt.SigningMethodEdDSA, false, false},
+        {"EXPECT FAIL HS256 without secret", config.SigningKey{ID: "hs-1", Method: "HS256"}, nil, false, true},
+        {"EXPECT FAIL key file not found", config.SigningKey{Method: "RS256", File: "not-found.pem"}, nil, false, true},
+        {"EXPECT FAIL RS256 with Ed25519 key", config.SigningKey{Method: "RS256", File: edFile}, nil, false, true},
+        {"EXPECT FAIL EdDSA with RSA key", config.SigningKey{Method: "EdDSA", File: rsaFile}, nil, false, true},
+        {"EXPECT FAIL unknown signing method", config.SigningKey{Method: "ES256"}, nil, false, true},
+    }
+
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            // actual test
+            got, err := LoadSigningKey(tt.cfg)
+            if tt.wantErr {
+                assert.Error(t, err)
+                assert.Nil(t, got)
+            } else {
+                assert.NoError(t, err)
+                assert.Equal(t, tt.wantMethod, got.Method)
+                assert.Equal(t, tt.cfg.ID, got.ID)
+                assert.Equal(t, tt.wantCanSign, got.SignKey != nil)
+            }
+        })
+    }
+}
+
+// TestLoadKeyRing will test key ring loading from server configuration
+func TestLoadKeyRing(t *testing.T) {
+    _, edKey, err := ed25519.GenerateKey(rand.Reader)
+    assert.NoError(t, err)
+    edFile := writeTestKey(t, "ed25519.pem", edKey)
+    edPublicFile := writeTestPublicKey(t, "ed25519.pub", edKey.Public())
+
+    ring := []config.SigningKey{
+        {ID: "hs-1", Method: "HS256", Secret: "secure-key-is-a-secret"},
+        {ID: "ed-1", Method: "EdDSA", File: edFile},
+        {ID: "ed-2", Method: "EdDSA", File: edPublicFile},
+    }
+
+    cases := []struct{
+        name string
+        cfg config.Server
+        wantActive string
+        wantKeys int
+        wantErr bool
+    }{
+        {"EXPECT SUCCESS single key", config.Server{SecureKey: "secure-key-is-a-secret", MinimumSecureKeyLength: 16}, "", 1, false},
+        {"EXPECT SUCCESS key ring", config.Server{SigningKeys: ring, ActiveSigningKeyID: "ed-1"}, "ed-1", 3, false},
+        {"EXPECT FAIL secure key too short", config.Server{SecureKey: "short", MinimumSecureKeyLength: 16}, "", 0, true},
+        {"EXPECT FAIL active key not found", config.Server{SigningKeys: ring, ActiveSigningKeyID: "ed-9"}, "", 0, true},
+        {"EXPECT FAIL active key can not sign", config.Server{SigningKeys: ring, ActiveSigningKeyID: "ed-2"}, "", 0, true},
+        {"EXPECT FAIL duplicated key id", config.Server{SigningKeys: append(ring, ring[0]), ActiveSigningKeyID: "ed-1"}, "", 0, true},
+        {"EXPECT FAIL invalid key", config.Server{SigningKeys: []config.SigningKey{{ID: "es-1", Method: "ES256"}}, ActiveSigningKeyID: "es-1"}, "", 0, true},
+    }
+
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            // actual test
+            got, err := LoadKeyRing(tt.cfg)
+            if tt.wantErr {
+                assert.Error(t, err)
+                assert.Nil(t, got)
+            } else {
+                assert.NoError(t, err)
+                assert.Equal(t, tt.wantActive, got.Active().ID)
+                assert.Len(t, got.Keys(), tt.wantKeys)
+                assert.True(t, got.Active().DeactivatedAt.IsZero())
+            }
+        })
+    }
+}
+
+// TestKeyRingRotation will test key promotion and retirement on the key ring
+func TestKeyRingRotation(t *testing.T) {
+    err := config.Setup()
+    if err != nil {
+        t.Fatalf("unexpected error occur: %v\n", err)
+    }
+
+    _, edKey, err := ed25519.GenerateKey(rand.Reader)
+    assert.NoError(t, err)
+
+    cfg := config.Get().Server
+    cfg.SigningKeys = []config.SigningKey{
+        {ID: "hs-1", Method: "HS256", Secret: "secure-key-is-a-secret"},
+        {ID: "ed-1", Method: "EdDSA", File: writeTestKey(t, "ed25519.pem", edKey)},
+        {ID: "ed-2", Method: "EdDSA", File: writeTestPublicKey(t, "ed25519.pub", edKey.Public())},
+    }
+    cfg.ActiveSigningKeyID = "hs-1"
+
+    assert.NoError(t, SetupKeyRing(cfg))
+    defer func() { keyRing = nil }()
+
+    // token signed by the active key carry its key id
+    oldToken, err := CreateToken(d.Principal{UserID: uuid.New(), Email: "aabi@basd.com"})
+    assert.NoError(t, err)
+    token, err := TokenValid(oldToken.AccessToken)
+    assert.NoError(t, err)
+    assert.Equal(t, "hs-1", token.Header["kid"])
+
+    // EXPECT SUCCESS promoted key sign new token and the old key keep verifying
+    t.Run("EXPECT SUCCESS promote", func(t *testing.T){
+        assert.NoError(t, PromoteSigningKey("ed-1"))
+
+        newToken, err := CreateToken(d.Principal{UserID: uuid.New(), Email: "aabi@basd.com"})
+        assert.NoError(t, err)
+        token, err := TokenValid(newToken.AccessToken)
+        assert.NoError(t, err)
+        assert.Equal(t, "ed-1", token.Header["kid"])
+
+        _, err = TokenValid(oldToken.AccessToken)
+        assert.NoError(t, err)
+
+        keys, err := SigningKeys()
+        assert.NoError(t, err)
+        assert.Equal(t, []string{"ed-1", "ed-2", "hs-1"}, []string{keys[0].ID, keys[1].ID, keys[2].ID})
+        assert.True(t, keys[0].Active)
+        assert.Nil(t, keys[0].DeactivatedAt)
+        assert.NotNil(t, keys[2].DeactivatedAt)
+    })
+
+    // EXPECT FAIL promote unknown and verify only key
+    t.Run("EXPECT FAIL promote", func(t *testing.T){
+        assert.Equal(t, E.New(E.ErrDataNotFound), PromoteSigningKey("ed-9"))
+        assert.Equal(t, E.New(E.ErrSigningKeyVerifyOnly), PromoteSigningKey("ed-2"))
+    })
+
+    // EXPECT FAIL retire active key and key deactivated within token lifetime
+    t.Run("EXPECT FAIL retire key in use", func(t *testing.T){
+        assert.Equal(t, E.New(E.ErrSigningKeyInUse), RetireSigningKey("ed-1"))
+        assert.Equal(t, E.New(E.ErrSigningKeyInUse), RetireSigningKey("hs-1"))
+        assert.Equal(t, E.New(E.ErrDataNotFound), RetireSigningKey("ed-9"))
+    })
+
+    // EXPECT SUCCESS retire key after the longest token lifetime has passed
+    t.Run("EXPECT SUCCESS retire", func(t *testing.T){
+        timeNow := timeNowFunc
+        timeNowFunc = func() time.Time {
+            return time.Now().Add(maxTokenLifetime(cfg) + time.Minute)
+        }
+        defer func() { timeNowFunc = timeNow }()
+
+        assert.NoError(t, RetireSigningKey("hs-1"))
+
+        // token signed by the retired key is rejected
+        _, err := TokenValid(oldToken.AccessToken)
+        assert.Error(t, err)
+
+        _, ok := keyRing.Key("hs-1")
+        assert.False(t, ok)
+    })
+}
+
+// mockKeyRingStore is in memory key ring store mock, it share the state like the
+// database shared by server instance
+type mockKeyRingStore struct {
+    states map[string]*d.SigningKeyState
+    err    error
+}
+
+// Record will mock Record method of key ring store
+func (m *mockKeyRingStore) Record(ids []string, activeID string, at time.Time) error {
+    if m.err != nil {
+        return m.err
+    }
+
+    hasActive := false
+    for _, state := range m.states {
+        hasActive = hasActive || state.Active
+    }
+    for _, id := range ids {
+        if _, ok := m.states[id]; ok {
+            continue
+        }
+
+        state := &d.SigningKeyState{ID: id, Active: id == activeID && !hasActive}
+        if !state.Active {
+            deactivatedAt := at
+            state.DeactivatedAt = &deactivatedAt
+        }
+        m.states[id] = state
+    }
+
+    return nil
+}
+
+// Gets will mock Gets method of key ring store
+func (m *mockKeyRingStore) Gets() ([]d.SigningKeyState, error) {
+    if m.err != nil {
+        return nil, m.err
+    }
+
+    states := []d.SigningKeyState{}
+    for _, state := range m.states {
+        states = append(states, *state)
+    }
+    sort.Slice(states, func(i, j int) bool { return states[i].ID < states[j].ID })
+
+    return states, nil
+}
+
+// Promote will mock Promote method of key ring store
+func (m *mockKeyRingStore) Promote(kid string, at time.Time) error {
+    if m.err != nil {
+        return m.err
+    }
+
+    for _, state := range m.states {
+        if state.Active && state.ID != kid {
+            deactivatedAt := at
+            state.Active, state.DeactivatedAt = false, &deactivatedAt
+        }
+    }
+    m.states[kid] = &d.SigningKeyState{ID: kid, Active: true}
+
+    return nil
+}
+
+// Retire will mock Retire method of key ring store
+func (m *mockKeyRingStore) Retire(kid string, at time.Time) error {
+    if m.err != nil {
+        return m.err
+    }
+
+    if state, ok := m.states[kid]; ok && !state.Active && state.RetiredAt == nil {
+        retiredAt := at
+        state.RetiredAt = &retiredAt
+    }
+
+    return nil
+}
+
+// TestKeyRingStore will test the key ring state persisted on the key ring store
+func TestKeyRingStore(t *testing.T) {
+    err := config.Setup()
+    if err != nil {
+        t.Fatalf("unexpected error occur: %v\n", err)
+    }
+
+    _, edKey, err := ed25519.GenerateKey(rand.Reader)
+    assert.NoError(t, err)
+
+    cfg := config.Get().Server
+    cfg.SigningKeys = []config.SigningKey{
+        {ID: "hs-1", Method: "HS256", Secret: "secure-key-is-a-secret"},
+        {ID: "ed-1", Method: "EdDSA", File: writeTestKey(t, "ed25519.pem", edKey)},
+        {ID: "ed-2", Method: "EdDSA", File: writeTestPublicKey(t, "ed25519.pub", edKey.Public())},
+    }
+    cfg.ActiveSigningKeyID = "hs-1"
+
+    // loadRing will load the key ring like a starting server instance
+    loadRing := func(t *testing.T, store IKeyRingStore) *KeyRing {
+        ring, err := LoadKeyRing(cfg)
+        assert.NoError(t, err)
+        ring.store = store
+        assert.NoError(t, ring.Sync())
+
+        return ring
+    }
+
+    // EXPECT SUCCESS deactivation time of verify only key is kept across restart
+    t.Run("EXPECT SUCCESS deactivation time kept", func(t *testing.T){
+        store := &mockKeyRingStore{states: map[string]*d.SigningKeyState{}}
+        first := loadRing(t, store)
+        assert.Equal(t, "hs-1", first.Active().ID)
+        assert.True(t, store.states["hs-1"].Active)
+        assert.Len(t, store.states, 3)
+
+        timeNow := timeNowFunc
+        timeNowFunc = func() time.Time { return time.Now().Add(time.Hour) }
+        defer func() { timeNowFunc = timeNow }()
+
+        restarted := loadRing(t, store)
+        key, ok := restarted.Key("ed-2")
+        assert.True(t, ok)
+        assert.Equal(t, *store.states["ed-2"].DeactivatedAt, key.DeactivatedAt)
+    })
+
+    // EXPECT SUCCESS promote and retire survive restart and is shared by other instance
+    t.Run("EXPECT SUCCESS promote and retire persisted", func(t *testing.T){
+        store := &mockKeyRingStore{states: map[string]*d.SigningKeyState{}}
+        ring, other := loadRing(t, store), loadRing(t, store)
+
+        assert.NoError(t, ring.Promote("ed-1"))
+        assert.True(t, store.states["ed-1"].Active)
+        assert.False(t, store.states["hs-1"].Active)
+
+        // other instance pick up the active key on sync
+        assert.Equal(t, "hs-1", other.Active().ID)
+        assert.NoError(t, other.Sync())
+        assert.Equal(t, "ed-1", other.Active().ID)
+
+        timeNow := timeNowFunc
+        timeNowFunc = func() time.Time {
+            return time.Now().Add(maxTokenLifetime(cfg) + time.Minute)
+        }
+        defer func() { timeNowFunc = timeNow }()
+
+        assert.NoError(t, ring.Retire("hs-1", maxTokenLifetime(cfg)))
+        assert.NotNil(t, store.states["hs-1"].RetiredAt)
+
+        // restarted instance keep the promoted key active and the retired key removed,
+        // although the configuration still name the old key as the active key
+        restarted := loadRing(t, store)
+        assert.Equal(t, "ed-1", restarted.Active().ID)
+        _, ok := restarted.Key("hs-1")
+        assert.False(t, ok)
+    })
+
+    // EXPECT FAIL store error keep the ring unchanged
+    t.Run("EXPECT FAIL store error", func(t *testing.T){
+        store := &mockKeyRingStore{states: map[string]*d.SigningKeyState{}}
+        ring := loadRing(t, store)
+
+        store.err = E.New(E.ErrDatabase)
+        assert.Equal(t, E.New(E.ErrDatabase), ring.Promote("ed-1"))
+        assert.Equal(t, "hs-1", ring.Active().ID)
+        assert.Equal(t, E.New(E.ErrDatabase), ring.Sync())
+    })
+
+    // EXPECT SUCCESS registered store is synced on key ring use
+    t.Run("EXPECT SUCCESS sync on use", func(t *testing.T){
+        store := &mockKeyRingStore{states: map[string]*d.SigningKeyState{}}
+        assert.NoError(t, store.Promote("ed-1", time.Now()))
+
+        assert.NoError(t, SetupKeyRing(cfg))
+        SetKeyRingStore(store)
+        defer func() { keyRing, keyRingStore = nil, nil }()
+
+        keys, err := SigningKeys()
+        assert.NoError(t, err)
+        assert.Equal(t, "ed-1", keys[0].ID)
+        assert.True(t, keys[0].Active)
+        assert.Len(t, store.states, 3)
+    })
+}
+
+// TestAsymmetricToken will test token creation and verification with RS256 and EdDSA key
+func TestAsymmetricToken(t *testing.T) {
+    err := config.Setup()
+    if err != nil {
+        t.Fatalf("unexpected error occur: %v\n", err)
+    }
+
+    rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+    assert.NoError(t, err)
+    _, edKey, err := ed25519.GenerateKey(rand.Reader)
+    assert.NoError(t, err)
+
+    // token signed with the shared secret (HS256)
+    hsToken, err := CreateToken(d.Principal{UserID: uuid.New(), Email: "aabi@basd.com"})
+    assert.NoError(t, err)
+
+    cases := []struct{
+        name string
+        cfg config.Server
+        wantKty string
+    }{
+        {"EXPECT SUCCESS RS256", config.Server{SigningMethod: "RS256", SigningKeyID: "rsa-1", SigningKeyFile: writeTestKey(t, "rsa.pem", rsaKey)}, "RSA"},
+        {"EXPECT SUCCESS EdDSA", config.Server{SigningMethod: "EdDSA", SigningKeyID: "ed-1", SigningKeyFile: writeTestKey(t, "ed25519.pem", edKey)}, "OKP"},
+    }
+
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            assert.NoError(t, SetupKeyRing(tt.cfg))
+            defer func() { keyRing = nil }()
+
+            // token is signed with the key and carry its key id
+            got, err := CreateToken(d.Principal{UserID: uuid.New(), Email: "aabi@basd.com"})
+            assert.NoError(t, err)
+
+            token, err := TokenValid(got.AccessToken)
+            assert.NoError(t, err)
+            assert.Equal(t, tt.cfg.SigningKeyID, token.Header["kid"])
+
+            // the public key is published with the same key id
+            jwks, err := PublicJWKS()
+            assert.NoError(t, err)
+            assert.Len(t, jwks.Keys, 1)
+            assert.Equal(t, tt.wantKty, jwks.Keys[0].Kty)
+            assert.Equal(t, tt.cfg.SigningKeyID, jwks.Keys[0].Kid)
+
+            // token signed with other key is rejected
+            _, err = TokenValid(hsToken.AccessToken)
+            assert.Error(t, err)
+        })
+    }
+
+    // EXPECT SUCCESS RSA public key published on JWKS match the signing key
+    t.Run("EXPECT SUCCESS RSA jwk", func(t *testing.T){
+        key := &SigningKey{ID: "rsa-1", Method: jwt.SigningMethodRS256, VerifyKey: &rsaKey.PublicKey}
+
+        got, ok := key.JWK()
+        assert.True(t, ok)
+        assert.Equal(t, base64.RawURLEncoding.EncodeToString(rsaKey.PublicKey.N.Bytes()), got.N)
+        assert.Equal(t, "AQAB", got.E)
+    })
+
+    // EXPECT SUCCESS HS256 secret is never published
+    t.Run("EXPECT SUCCESS HS256 not published", func(t *testing.T){
+        jwks, err := PublicJWKS()
+        assert.NoError(t, err)
+        assert.Empty(t, jwks.Keys)
+    })
+
+    // EXPECT FAIL token with unknown key id
+    t.Run("EXPECT FAIL key id not match", func(t *testing.T){
+        cfg := config.Get().Server
+        cfg.SigningKeyID = "hs-1"
+        assert.NoError(t, SetupKeyRing(cfg))
+        defer func() { keyRing = nil }()
+
+        got, err := CreateToken(d.Principal{UserID: uuid.New(), Email: "aabi@basd.com"})
+        assert.NoError(t, err)
+
+        cfg.SigningKeyID = "hs-2"
+        assert.NoError(t, SetupKeyRing(cfg))
+        _, err = TokenValid(got.AccessToken)
+        assert.Error(t, err)
+    })
+
+    // EXPECT FAIL setup with invalid key keep the loaded key ring
+    t.Run("EXPECT FAIL setup key ring error", func(t *testing.T){
+        keyRing = nil
+        err := SetupKeyRing(config.Server{SigningMethod: "ES256"})
+        assert.Equal(t, E.New(E.ErrSigningKey), err)
+        assert.Nil(t, keyRing)
+    })
+}
@@ -0,0 +1,146 @@
+/*
+   Password hashing of the user passkey, stored as PHC format string
+*/
+package auth
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/reshimahendra/lbw-go/internal/config"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+)
+
+const (
+    // PasswordHashBcrypt is algorithm name of bcrypt password hasher
+    PasswordHashBcrypt = "bcrypt"
+
+    // PasswordHashArgon2id is algorithm name of argon2id password hasher
+    PasswordHashArgon2id = "argon2id"
+)
+
+var (
+    // dummyPassKey is hash of random password made by the hasher of 'cfg', see DummyPasswordHash
+    dummyPassKey struct {
+        sync.Mutex
+        cfg  config.PasswordHash
+        hash string
+    }
+)
+
+// PasswordHasher is algorithm to hash and verify the user password
+type PasswordHasher interface {
+    // Hash will hash the password into PHC format string
+    Hash(password string) (string, error)
+
+    // Verify will check whether the password match the hash, the parameter is taken from the hash
+    Verify(password, hash string) bool
+
+    // Identify will check whether the hash is made by the algorithm of the hasher
+    Identify(hash string) bool
+
+    // NeedRehash will check whether the hash is not made by the hasher with its current parameter
+    NeedRehash(hash string) bool
+}
+
+// NewPasswordHasher will create the password hasher of the configured algorithm,
+// unset parameter fall back to its default value
+func NewPasswordHasher(cfg config.PasswordHash) (PasswordHasher, error) {
+    switch cfg.Algorithm {
+    case "", PasswordHashBcrypt:
+        return NewBcryptHasher(cfg.BcryptCost), nil
+    case PasswordHashArgon2id:
+        return NewArgon2idHasher(cfg.Argon2Memory, cfg.Argon2Iterations, cfg.Argon2Parallelism,
+            cfg.Argon2SaltLength, cfg.Argon2KeyLength), nil
+    }
+
+    return nil, E.NewExt(E.ErrServerInternal, fmt.Errorf("unknown password hash algorithm '%s'", cfg.Algorithm))
+}
+
+// HashPassword will hash the password with the configured password hasher
+func HashPassword(password string) (string, error) {
+    hasher, err := currentPasswordHasher()
+    if err != nil {
+        return "", err
+    }
+
+    return hasher.Hash(password)
+}
+
+// VerifyPassword will check whether the password match the hash made by any supported
+// algorithm, so passkey hashed before the algorithm is changed can still be verified
+func VerifyPassword(password, hash string) bool {
+    for _, hasher := range []PasswordHasher{new(BcryptHasher), new(Argon2idHasher)} {
+        if hasher.Identify(hash) {
+            return hasher.Verify(password, hash)
+        }
+    }
+
+    return false
+}
+
+// PasswordNeedRehash will check whether the hash is not made with the configured
+// algorithm and parameter, so it should be hashed again once the password is known
+func PasswordNeedRehash(hash string) bool {
+    hasher, err := currentPasswordHasher()
+    if err != nil {
+        return false
+    }
+
+    return hasher.NeedRehash(hash)
+}
+
+// PasswordMaximumBytes will get the maximum byte length of the password used by the configured
+// hasher, it is zero when the whole password is used
+func PasswordMaximumBytes() int {
+    hasher, err := currentPasswordHasher()
+    if err != nil {
+        return 0
+    }
+
+    if _, ok := hasher.(*BcryptHasher); ok {
+        return bcryptMaximumPasswordBytes
+    }
+
+    return 0
+}
+
+// DummyPasswordHash will get hash of random password made by the configured hasher. verifying
+// the password of unknown user against it take as long as verifying the real passkey, so the
+// response time does not tell whether the user exist. it is hashed once per hasher configuration
+func DummyPasswordHash() string {
+    cfg := currentPasswordHashConfig()
+
+    dummyPassKey.Lock()
+    defer dummyPassKey.Unlock()
+
+    if dummyPassKey.hash == "" || dummyPassKey.cfg != cfg {
+        hasher, err := NewPasswordHasher(cfg)
+        if err != nil {
+            return ""
+        }
+        hash, err := hasher.Hash(uuid.NewString())
+        if err != nil {
+            return ""
+        }
+        dummyPassKey.cfg, dummyPassKey.hash = cfg, hash
+    }
+
+    return dummyPassKey.hash
+}
+
+// currentPasswordHasher will get the password hasher of account configuration
+func currentPasswordHasher() (PasswordHasher, error) {
+    return NewPasswordHasher(currentPasswordHashConfig())
+}
+
+// currentPasswordHashConfig will get the password hash configuration of the account
+func currentPasswordHashConfig() config.PasswordHash {
+    var cfg config.PasswordHash
+    if c := config.Get(); c != nil {
+        cfg = c.Account.PasswordHash
+    }
+
+    return cfg
+}
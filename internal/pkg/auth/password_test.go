@@ -0,0 +1,234 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/reshimahendra/lbw-go/internal/config"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNewPasswordHasher will test creating password hasher of the configured algorithm
+func TestNewPasswordHasher(t *testing.T) {
+    cases := []struct{
+        name string
+        cfg  config.PasswordHash
+        want PasswordHasher
+    }{
+        {"EXPECT SUCCESS default", config.PasswordHash{}, &BcryptHasher{Cost: defaultBcryptCost}},
+        {"EXPECT SUCCESS bcrypt", config.PasswordHash{Algorithm: "bcrypt", BcryptCost: 10}, &BcryptHasher{Cost: 10}},
+        {"EXPECT SUCCESS bcrypt invalid cost", config.PasswordHash{Algorithm: "bcrypt", BcryptCost: 99}, &BcryptHasher{Cost: defaultBcryptCost}},
+        {"EXPECT SUCCESS argon2id", config.PasswordHash{Algorithm: "argon2id", Argon2Memory: 1024, Argon2Iterations: 1},
+            &Argon2idHasher{Memory: 1024, Iterations: 1, Parallelism: 4, SaltLength: 16, KeyLength: 32}},
+    }
+
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            got, err := NewPasswordHasher(tt.cfg)
+
+            assert.NoError(t, err)
+            assert.Equal(t, tt.want, got)
+        })
+    }
+
+    // EXPECT SUCCESS out of range argon2id parameter fall back to default value
+    t.Run("EXPECT SUCCESS argon2id out of range", func(t *testing.T){
+        got := NewArgon2idHasher(maxArgon2Memory+1, maxArgon2Iterations+1, 1, 4, 8)
+
+        assert.Equal(t, &Argon2idHasher{Memory: defaultArgon2Memory, Iterations: defaultArgon2Iterations,
+            Parallelism: 1, SaltLength: defaultArgon2SaltLength, KeyLength: defaultArgon2KeyLength}, got)
+    })
+
+    t.Run("EXPECT FAIL unknown algorithm", func(t *testing.T){
+        got, err := NewPasswordHasher(config.PasswordHash{Algorithm: "md5"})
+
+        assert.Nil(t, got)
+        assert.Equal(t, uint(E.ErrServerInternal), E.Code(err))
+    })
+}
+
+// TestBcryptHasher will test hashing and verifying password with bcrypt
+func TestBcryptHasher(t *testing.T) {
+    hasher := NewBcryptHasher(4)
+
+    hash, err := hasher.Hash("s3cr3t-password")
+    require.NoError(t, err)
+
+    assert.True(t, strings.HasPrefix(hash, "$2a$04$"))
+    assert.True(t, hasher.Identify(hash))
+    assert.True(t, hasher.Verify("s3cr3t-password", hash))
+    assert.False(t, hasher.Verify("wrong-password", hash))
+    assert.False(t, hasher.NeedRehash(hash))
+
+    // outdated cost or other algorithm need rehash
+    assert.True(t, NewBcryptHasher(5).NeedRehash(hash))
+    assert.True(t, hasher.NeedRehash("$argon2id$v=19$m=1024,t=1,p=1$c2FsdA$a2V5"))
+    assert.False(t, hasher.Identify("$argon2id$v=19$m=1024,t=1,p=1$c2FsdA$a2V5"))
+
+    // EXPECT SUCCESS legacy passkey hashed with cost 14 before the hasher is configurable
+    t.Run("EXPECT SUCCESS legacy hash", func(t *testing.T){
+        cases := []struct{
+            name, pass, hash string
+            want bool
+        }{
+            {"EXPECT SUCCESS alpha numeric", "1234abcd", "$2a$14$./GeWe7L5mWgMxg6aa2owurPQQogskmMbpE8o2omFW4/q.W4fOtwe", true},
+            {"EXPECT SUCCESS mix char", "Aj6@1_=8", "$2a$14$CRqt9LW2L.ir9dyMC6G7iOw2ilwzk.r.nkj2PEu1T/j37xBX7xJHO", true},
+            {"EXPECT FAIL wrong password", "nopass", "$2a$14$./GeWe7L5mWgMxg6aa2owurPQQogskmMbpE8o2omFW4/q.W4fOtwe", false},
+            {"EXPECT FAIL not a hash", "nopass", "should-be-not-matching", false},
+        }
+
+        for _, tt := range cases {
+            t.Run(tt.name, func(t *testing.T){
+                assert.Equal(t, tt.want, hasher.Verify(tt.pass, tt.hash))
+                assert.True(t, hasher.NeedRehash(tt.hash))
+            })
+        }
+    })
+
+    // EXPECT SUCCESS hash of the password with mix char and maximum length
+    t.Run("EXPECT SUCCESS mix char", func(t *testing.T){
+        for _, password := range []string{"Aj6@1_=8", strings.Repeat("a", 72)} {
+            hash, err := hasher.Hash(password)
+
+            assert.NoError(t, err)
+            assert.True(t, hasher.Verify(password, hash))
+        }
+    })
+}
+
+// TestArgon2idHasher will test hashing and verifying password with argon2id
+func TestArgon2idHasher(t *testing.T) {
+    hasher := NewArgon2idHasher(1024, 1, 1, 8, 16)
+
+    hash, err := hasher.Hash("s3cr3t-password")
+    require.NoError(t, err)
+
+    assert.Regexp(t, `^\$argon2id\$v=19\$m=1024,t=1,p=1\$[A-Za-z0-9+/]{11}\$[A-Za-z0-9+/]{22}$`, hash)
+    assert.True(t, hasher.Identify(hash))
+    assert.True(t, hasher.Verify("s3cr3t-password", hash))
+    assert.False(t, hasher.Verify("wrong-password", hash))
+    assert.False(t, hasher.NeedRehash(hash))
+
+    // hash is salted, hashing the same password twice give different hash
+    other, err := hasher.Hash("s3cr3t-password")
+    require.NoError(t, err)
+    assert.NotEqual(t, hash, other)
+
+    // the parameter is taken from the hash, so it is verified by hasher with other parameter
+    assert.True(t, NewArgon2idHasher(2048, 2, 2, 16, 32).Verify("s3cr3t-password", hash))
+
+    // outdated parameter or other algorithm need rehash
+    assert.True(t, NewArgon2idHasher(2048, 1, 1, 8, 16).NeedRehash(hash))
+    assert.True(t, NewArgon2idHasher(1024, 2, 1, 8, 16).NeedRehash(hash))
+    assert.True(t, NewArgon2idHasher(1024, 1, 2, 8, 16).NeedRehash(hash))
+    assert.True(t, NewArgon2idHasher(1024, 1, 1, 16, 16).NeedRehash(hash))
+    assert.True(t, NewArgon2idHasher(1024, 1, 1, 8, 32).NeedRehash(hash))
+    assert.True(t, hasher.NeedRehash("$2a$04$t5Bf3SLtsyazg2nzQ57HyeDMLsHGvm2x/VyjmM5XGojiPj4WmWDhi"))
+
+    t.Run("EXPECT FAIL malformed hash", func(t *testing.T){
+        for _, malformed := range []string{
+            "",
+            "!",
+            "$argon2i$v=19$m=1024,t=1,p=1$c2FsdA$a2V5",
+            "$argon2id$v=x$m=1024,t=1,p=1$c2FsdA$a2V5",
+            "$argon2id$v=19$m=x,t=1,p=1$c2FsdA$a2V5",
+            "$argon2id$v=19$m=1024,t=1,p=1$!$a2V5",
+            "$argon2id$v=19$m=1024,t=1,p=1$c2FsdA$!",
+            "$argon2id$v=19$m=1024,t=1,p=1$c2FsdA$",
+        } {
+            assert.False(t, hasher.Verify("s3cr3t-password", malformed), malformed)
+            assert.True(t, hasher.NeedRehash(malformed), malformed)
+        }
+    })
+
+    // EXPECT FAIL parameter out of range is rejected before hashing, argon2 panic on zero thread
+    t.Run("EXPECT FAIL invalid parameter", func(t *testing.T){
+        salt, key := "c2FsdHNhbHQ", "a2V5a2V5a2V5a2V5a2V5aw"
+        for _, invalid := range []string{
+            "$argon2id$v=16$m=1024,t=1,p=1$" + salt + "$" + key,
+            "$argon2id$v=19$m=1024,t=1,p=0$" + salt + "$" + key,
+            "$argon2id$v=19$m=1024,t=0,p=1$" + salt + "$" + key,
+            "$argon2id$v=19$m=0,t=1,p=1$" + salt + "$" + key,
+            "$argon2id$v=19$m=7,t=1,p=1$" + salt + "$" + key,
+            "$argon2id$v=19$m=1024,t=1,p=256$" + salt + "$" + key,
+            "$argon2id$v=19$m=1024,t=65,p=1$" + salt + "$" + key,
+            "$argon2id$v=19$m=4194305,t=1,p=1$" + salt + "$" + key,
+            "$argon2id$v=19$m=1024,t=1,p=1$c2FsdA$" + key,
+            "$argon2id$v=19$m=1024,t=1,p=1$" + salt + "$a2V5",
+        } {
+            assert.NotPanics(t, func(){
+                assert.False(t, hasher.Verify("s3cr3t-password", invalid), invalid)
+            })
+            assert.True(t, hasher.NeedRehash(invalid), invalid)
+        }
+    })
+
+    t.Run("EXPECT FAIL random salt error", func(t *testing.T){
+        randRead := randReadFunc
+        randReadFunc = func(b []byte) (int, error) {
+            return 0, fmt.Errorf("entropy exhausted")
+        }
+        defer func() { randReadFunc = randRead }()
+
+        hash, err := hasher.Hash("s3cr3t-password")
+
+        assert.Error(t, err)
+        assert.Empty(t, hash)
+    })
+}
+
+// TestPassword will test hashing and verifying password with the configured hasher
+func TestPassword(t *testing.T) {
+    err := config.Setup()
+    require.NoError(t, err)
+
+    cfg := config.Get()
+    passwordHash := cfg.Account.PasswordHash
+    defer func() { cfg.Account.PasswordHash = passwordHash }()
+
+    // passkey hashed with bcrypt before the algorithm is changed
+    cfg.Account.PasswordHash = config.PasswordHash{Algorithm: "bcrypt", BcryptCost: 4}
+    bcryptHash, err := HashPassword("s3cr3t-password")
+    require.NoError(t, err)
+    assert.False(t, PasswordNeedRehash(bcryptHash))
+
+    // changing the algorithm keep the old passkey verified and mark it to be rehashed
+    cfg.Account.PasswordHash = config.PasswordHash{Algorithm: "argon2id", Argon2Memory: 1024, Argon2Iterations: 1, Argon2Parallelism: 1}
+    argon2Hash, err := HashPassword("s3cr3t-password")
+    require.NoError(t, err)
+
+    assert.True(t, strings.HasPrefix(argon2Hash, "$argon2id$"))
+    assert.True(t, VerifyPassword("s3cr3t-password", bcryptHash))
+    assert.True(t, VerifyPassword("s3cr3t-password", argon2Hash))
+    assert.False(t, VerifyPassword("wrong-password", argon2Hash))
+    assert.False(t, VerifyPassword("s3cr3t-password", "!"))
+    assert.True(t, PasswordNeedRehash(bcryptHash))
+    assert.False(t, PasswordNeedRehash(argon2Hash))
+
+    // only bcrypt limit the byte length of the password
+    assert.Equal(t, 0, PasswordMaximumBytes())
+    cfg.Account.PasswordHash = config.PasswordHash{Algorithm: "bcrypt", BcryptCost: 4}
+    assert.Equal(t, 72, PasswordMaximumBytes())
+
+    // dummy hash is made by the configured hasher once and match no password
+    dummy := DummyPasswordHash()
+    assert.True(t, strings.HasPrefix(dummy, "$2a$04$"))
+    assert.Equal(t, dummy, DummyPasswordHash())
+    assert.False(t, VerifyPassword("s3cr3t-password", dummy))
+    assert.False(t, PasswordNeedRehash(dummy))
+
+    t.Run("EXPECT FAIL unknown algorithm", func(t *testing.T){
+        cfg.Account.PasswordHash = config.PasswordHash{Algorithm: "md5"}
+
+        hash, err := HashPassword("s3cr3t-password")
+
+        assert.Error(t, err)
+        assert.Empty(t, hash)
+        assert.False(t, PasswordNeedRehash(bcryptHash))
+        assert.Equal(t, 0, PasswordMaximumBytes())
+        assert.Empty(t, DummyPasswordHash())
+    })
+}
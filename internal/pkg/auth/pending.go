@@ -0,0 +1,111 @@
+/*
+   Pending token given on signin of user with two factor authentication enabled
+*/
+package auth
+
+import (
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+	"github.com/reshimahendra/lbw-go/internal/config"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/logger"
+)
+
+// pendingAudiencePrefix is prefix of the pending token audience. the different
+// audience make sure the pending token is rejected as access or refresh token
+const pendingAudiencePrefix = "2fa:"
+
+// pendingClaims is claims of the pending token. it only prove the password was
+// verified, the two factor code must be verified before the real token is issued
+type pendingClaims struct {
+    Claims
+}
+
+// Valid will validate the standard claims (exp, iat, nbf) and make sure
+// the token was issued by our server for the second signin step
+func (c *pendingClaims) Valid() error {
+    if err := c.StandardClaims.Valid(); err != nil {
+        return err
+    }
+
+    domainName := config.Get().Server.DomainName
+    if !c.VerifyIssuer(domainName, true) || !c.VerifyAudience(pendingAudiencePrefix+domainName, true) {
+        return E.New(E.ErrTokenInvalid)
+    }
+
+    return nil
+}
+
+// CreatePendingToken will create short lived pending token for the principal
+// whose password is verified but still need the two factor code
+func CreatePendingToken(principal d.Principal, expiresIn time.Duration) (string, time.Time, error) {
+    if principal.UserID == uuid.Nil {
+        return "", time.Time{}, E.New(E.ErrTokenCreate)
+    }
+
+    // get the active key of the ring to sign the token
+    ring, err := currentKeyRing()
+    if err != nil {
+        logger.Errorf("error occur while loading signing key: %v\n", err)
+        return "", time.Time{}, err
+    }
+
+    domainName := config.Get().Server.DomainName
+    issuedAt := time.Now()
+    expiresAt := issuedAt.Add(expiresIn)
+
+    claims := &pendingClaims{*newClaims(principal, uuid.NewString(), issuedAt, expiresAt, domainName)}
+    claims.Audience = pendingAudiencePrefix + domainName
+
+    token, err := ring.Active().sign(claims)
+    if err != nil {
+        logger.Errorf("error occur while creating pending token: %v\n", err)
+        return "", time.Time{}, err
+    }
+
+    return token, expiresAt, nil
+}
+
+// VerifyPendingToken will verify the pending token and get its principal and metadata.
+// the metadata is used to revoke the pending token once the signin is completed
+func VerifyPendingToken(tokenStr string) (*d.Principal, *d.TokenMetadata, error) {
+    e := E.New(E.ErrTokenInvalid)
+
+    ring, err := currentKeyRing()
+    if err != nil {
+        logger.Errorf("error occur while loading signing key: %v\n", err)
+        return nil, nil, e
+    }
+
+    token, err := jwt.ParseWithClaims(tokenStr, &pendingClaims{}, keyFunc(ring))
+    if err != nil || !token.Valid {
+        return nil, nil, e
+    }
+
+    claims, ok := token.Claims.(*pendingClaims)
+    if !ok {
+        return nil, nil, e
+    }
+
+    metadata, err := claims.Metadata()
+    if err != nil {
+        return nil, nil, err
+    }
+
+    // reject pending token that already used or revoked
+    if revocationChecker != nil {
+        if err := checkRevoked(*metadata); err != nil {
+            return nil, nil, err
+        }
+    }
+
+    principal, err := claims.Principal()
+    if err != nil {
+        return nil, nil, err
+    }
+
+    return principal, metadata, nil
+}
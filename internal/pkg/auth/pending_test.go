@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/reshimahendra/lbw-go/internal/config"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCreatePendingToken will test pending token creation
+func TestCreatePendingToken(t *testing.T) {
+    err := config.Setup()
+    if err != nil {
+        t.Fatalf("unexpected error occur: %v\n", err)
+    }
+
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        principal := d.Principal{UserID: uuid.New(), Email: "aabi@basd.com", RoleID: 4, StatusID: 1}
+        token, expiresAt, err := CreatePendingToken(principal, 5*time.Minute)
+        assert.NoError(t, err)
+        assert.NotEmpty(t, token)
+        assert.WithinDuration(t, time.Now().Add(5*time.Minute), expiresAt, time.Second)
+    })
+
+    t.Run("EXPECT FAIL empty user id", func(t *testing.T){
+        token, _, err := CreatePendingToken(d.Principal{Email: "aabi@basd.com"}, 5*time.Minute)
+        assert.Error(t, err)
+        assert.Equal(t, E.New(E.ErrTokenCreate), err)
+        assert.Empty(t, token)
+    })
+}
+
+// TestVerifyPendingToken will test pending token verification
+func TestVerifyPendingToken(t *testing.T) {
+    err := config.Setup()
+    if err != nil {
+        t.Fatalf("unexpected error occur: %v\n", err)
+    }
+
+    principal := d.Principal{UserID: uuid.New(), Email: "aabi@basd.com", RoleID: 4, StatusID: 1}
+    pendingTok, _, err := CreatePendingToken(principal, 5*time.Minute)
+    require.NoError(t, err)
+
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        got, metadata, err := VerifyPendingToken(pendingTok)
+        assert.NoError(t, err)
+        assert.Equal(t, principal.UserID, got.UserID)
+        assert.Equal(t, principal.Email, got.Email)
+        assert.Equal(t, principal.RoleID, got.RoleID)
+        assert.NotEmpty(t, metadata.ID)
+    })
+
+    t.Run("EXPECT FAIL pending token used as access token", func(t *testing.T){
+        _, err := TokenValid(pendingTok)
+        assert.Error(t, err)
+        assert.Equal(t, E.New(E.ErrTokenInvalid), err)
+    })
+
+    t.Run("EXPECT FAIL access token used as pending token", func(t *testing.T){
+        token, err := CreateToken(principal)
+        require.NoError(t, err)
+
+        got, _, err := VerifyPendingToken(token.AccessToken)
+        assert.Error(t, err)
+        assert.Equal(t, E.New(E.ErrTokenInvalid), err)
+        assert.Nil(t, got)
+    })
+
+    t.Run("EXPECT FAIL expired token", func(t *testing.T){
+        expiredTok, _, err := CreatePendingToken(principal, -time.Minute)
+        require.NoError(t, err)
+
+        got, _, err := VerifyPendingToken(expiredTok)
+        assert.Error(t, err)
+        assert.Nil(t, got)
+    })
+
+    t.Run("EXPECT FAIL malformed token", func(t *testing.T){
+        got, _, err := VerifyPendingToken("not.a.token")
+        assert.Error(t, err)
+        assert.Nil(t, got)
+    })
+
+    t.Run("EXPECT FAIL token revoked", func(t *testing.T){
+        SetRevocationChecker(&mockRevocationChecker{revoked: true})
+        defer SetRevocationChecker(nil)
+
+        got, _, err := VerifyPendingToken(pendingTok)
+        assert.Error(t, err)
+        assert.Equal(t, E.New(E.ErrTokenRevoked), err)
+        assert.Nil(t, got)
+    })
+}
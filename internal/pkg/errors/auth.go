@@ -57,6 +57,90 @@ const (
     // ErrTokenNotFound is error code for no token found
     // msg = "token not found"
     ErrTokenNotFound
+
+    // ErrTokenRevoked is error code for token that already revoked (signout)
+    // msg = "token has been revoked"
+    ErrTokenRevoked
+
+    // ErrActivationTokenInvalid is error code for unknown, used or expired activation token
+    // msg = "activation token invalid or expired"
+    ErrActivationTokenInvalid
+
+    // ErrActivationMail is error code for failing to send activation mail
+    // msg = "could not send activation mail"
+    ErrActivationMail
+
+    // ErrPasswordResetTokenInvalid is error code for unknown, used or expired password reset token
+    // msg = "password reset token invalid or expired"
+    ErrPasswordResetTokenInvalid
+
+    // ErrForbidden is error code for authorized user that has no permission to the resource
+    // msg = "permission denied"
+    ErrForbidden
+
+    // ErrRefreshTokenReused is error code for refresh token that already rotated being used again
+    // msg = "refresh token reused, session has been revoked"
+    ErrRefreshTokenReused
+
+    // ErrSigningKey is error code for signing key that invalid or could not be loaded
+    // msg = "signing key invalid or could not be loaded"
+    ErrSigningKey
+
+    // ErrSigningKeyInUse is error code for retiring signing key that still sign or may still verify token
+    // msg = "signing key is still in use"
+    ErrSigningKeyInUse
+
+    // ErrTwoFactorAlreadyEnabled is error code for enrolling two factor authentication that already enabled
+    // msg = "two factor authentication already enabled"
+    ErrTwoFactorAlreadyEnabled
+
+    // ErrTwoFactorNotEnrolled is error code for confirming two factor authentication that never enrolled
+    // msg = "two factor authentication not enrolled"
+    ErrTwoFactorNotEnrolled
+
+    // ErrTwoFactorCodeInvalid is error code for wrong, reused or expired two factor code
+    // msg = "two factor code invalid"
+    ErrTwoFactorCodeInvalid
+
+    // ErrEncryption is error code for failing to encrypt or decrypt secret data
+    // msg = "could not encrypt or decrypt secret data"
+    ErrEncryption
+
+    // ErrAccountLocked is error code for signin on account that temporarily locked after too many failed attempt
+    // msg = "account is temporarily locked, try again later"
+    ErrAccountLocked
+
+    // ErrSigninThrottled is error code for signin attempted too soon after failed attempt
+    // msg = "too many signin attempts, try again later"
+    ErrSigninThrottled
+
+    // ErrOIDCProviderNotFound is error code for signin with unknown openid connect provider
+    // msg = "signin provider not found"
+    ErrOIDCProviderNotFound
+
+    // ErrOIDCStateInvalid is error code for unknown, used or expired openid connect signin state
+    // msg = "signin state invalid or expired"
+    ErrOIDCStateInvalid
+
+    // ErrOIDCExchange is error code for failing to exchange the authorization code with the provider
+    // msg = "could not exchange authorization code with the provider"
+    ErrOIDCExchange
+
+    // ErrOIDCTokenInvalid is error code for id token that fail the verification
+    // msg = "id token invalid"
+    ErrOIDCTokenInvalid
+
+    // ErrOIDCEmailNotVerified is error code for signin with email not verified by the provider
+    // msg = "email is not verified by the provider"
+    ErrOIDCEmailNotVerified
+
+    // ErrSigningKeyVerifyOnly is error code for promoting signing key that can only verify token
+    // msg = "signing key can only verify token"
+    ErrSigningKeyVerifyOnly
+
+    // ErrPasswordPolicy is error code for password that does not meet the password policy
+    // msg = "password does not meet the password policy"
+    ErrPasswordPolicy
 )
 
 const (
@@ -112,4 +196,88 @@ const (
     // ErrTokenNotFoundMsg is error code for no token found
     // msg = "token not found"
     ErrTokenNotFoundMsg = "token not found"
+
+    // ErrTokenRevokedMsg is error message for token that already revoked (signout)
+    // msg = "token has been revoked"
+    ErrTokenRevokedMsg = "token has been revoked"
+
+    // ErrActivationTokenInvalidMsg is error message for unknown, used or expired activation token
+    // msg = "activation token invalid or expired"
+    ErrActivationTokenInvalidMsg = "activation token invalid or expired"
+
+    // ErrActivationMailMsg is error message for failing to send activation mail
+    // msg = "could not send activation mail"
+    ErrActivationMailMsg = "could not send activation mail"
+
+    // ErrPasswordResetTokenInvalidMsg is error message for unknown, used or expired password reset token
+    // msg = "password reset token invalid or expired"
+    ErrPasswordResetTokenInvalidMsg = "password reset token invalid or expired"
+
+    // ErrForbiddenMsg is error message for authorized user that has no permission to the resource
+    // msg = "permission denied"
+    ErrForbiddenMsg = "permission denied"
+
+    // ErrRefreshTokenReusedMsg is error message for refresh token that already rotated being used again
+    // msg = "refresh token reused, session has been revoked"
+    ErrRefreshTokenReusedMsg = "refresh token reused, session has been revoked"
+
+    // ErrSigningKeyMsg is error message for signing key that invalid or could not be loaded
+    // msg = "signing key invalid or could not be loaded"
+    ErrSigningKeyMsg = "signing key invalid or could not be loaded"
+
+    // ErrSigningKeyInUseMsg is error message for retiring signing key that still sign or may still verify token
+    // msg = "signing key is still in use"
+    ErrSigningKeyInUseMsg = "signing key is still in use"
+
+    // ErrTwoFactorAlreadyEnabledMsg is error message for enrolling two factor authentication that already enabled
+    // msg = "two factor authentication already enabled"
+    ErrTwoFactorAlreadyEnabledMsg = "two factor authentication already enabled"
+
+    // ErrTwoFactorNotEnrolledMsg is error message for confirming two factor authentication that never enrolled
+    // msg = "two factor authentication not enrolled"
+    ErrTwoFactorNotEnrolledMsg = "two factor authentication not enrolled"
+
+    // ErrTwoFactorCodeInvalidMsg is error message for wrong, reused or expired two factor code
+    // msg = "two factor code invalid"
+    ErrTwoFactorCodeInvalidMsg = "two factor code invalid"
+
+    // ErrEncryptionMsg is error message for failing to encrypt or decrypt secret data
+    // msg = "could not encrypt or decrypt secret data"
+    ErrEncryptionMsg = "could not encrypt or decrypt secret data"
+
+    // ErrAccountLockedMsg is error message for signin on account that temporarily locked after too many failed attempt
+    // msg = "account is temporarily locked, try again later"
+    ErrAccountLockedMsg = "account is temporarily locked, try again later"
+
+    // ErrSigninThrottledMsg is error message for signin attempted too soon after failed attempt
+    // msg = "too many signin attempts, try again later"
+    ErrSigninThrottledMsg = "too many signin attempts, try again later"
+
+    // ErrOIDCProviderNotFoundMsg is error message for signin with unknown openid connect provider
+    // msg = "signin provider not found"
+    ErrOIDCProviderNotFoundMsg = "signin provider not found"
+
+    // ErrOIDCStateInvalidMsg is error message for unknown, used or expired openid connect signin state
+    // msg = "signin state invalid or expired"
+    ErrOIDCStateInvalidMsg = "signin state invalid or expired"
+
+    // ErrOIDCExchangeMsg is error message for failing to exchange the authorization code with the provider
+    // msg = "could not exchange authorization code with the provider"
+    ErrOIDCExchangeMsg = "could not exchange authorization code with the provider"
+
+    // ErrOIDCTokenInvalidMsg is error message for id token that fail the verification
+    // msg = "id token invalid"
+    ErrOIDCTokenInvalidMsg = "id token invalid"
+
+    // ErrOIDCEmailNotVerifiedMsg is error message for signin with email not verified by the provider
+    // msg = "email is not verified by the provider"
+    ErrOIDCEmailNotVerifiedMsg = "email is not verified by the provider"
+
+    // ErrSigningKeyVerifyOnlyMsg is error message for promoting signing key that can only verify token
+    // msg = "signing key can only verify token"
+    ErrSigningKeyVerifyOnlyMsg = "signing key can only verify token"
+
+    // ErrPasswordPolicyMsg is error message for password that does not meet the password policy
+    // msg = "password does not meet the password policy"
+    ErrPasswordPolicyMsg = "password does not meet the password policy"
 )
@@ -4,6 +4,8 @@
 */
 package errors
 
+import "fmt"
+
 const (
     // ErrDatabase is error code for database error
     // msg = "database error" 
@@ -58,6 +60,35 @@ const (
     // msg = "data already exist"
     ErrDataAlreadyExist
 
+    // ErrDataIsInUse is error code when triying to remove data that is still referenced
+    // by other data, for example 'Foreign Key' constraint
+    // msg = "data is still in use"
+    ErrDataIsInUse
+
+    // ErrUniqueViolation is error code when saved data value is already taken by other data
+    // ('Unique Constraint' violation)
+    // msg = "data value is already taken"
+    ErrUniqueViolation
+
+    // ErrForeignKeyViolation is error code when saved data reference to data that is not exist
+    // ('Foreign Key' constraint violation)
+    // msg = "data reference is not found"
+    ErrForeignKeyViolation
+
+    // ErrCheckViolation is error code when saved data value is not allowed
+    // ('Check Constraint' violation)
+    // msg = "data value is not allowed"
+    ErrCheckViolation
+
+    // ErrSerializationFailure is error code when data could not be saved since it is
+    // changed concurrently, the request can be retried
+    // msg = "data is changed concurrently"
+    ErrSerializationFailure
+
+    // ErrNotNullViolation is error code when saved data value is null on column that
+    // require a value ('Not Null' constraint violation)
+    // msg = "data value is required"
+    ErrNotNullViolation
 )
 
 const (
@@ -112,4 +143,50 @@ const (
     // ErrDataExist is error code when triying to save data on an already exist data
     // msg = "data already exist"
     ErrDataAlreadyExistMsg = "data already exist"
+
+    // ErrDataIsInUseMsg is error message when triying to remove data that is still referenced
+    // msg = "data is still in use"
+    ErrDataIsInUseMsg = "data is still in use"
+
+    // ErrUniqueViolationMsg is error message when saved data value is already taken by other data
+    // msg = "data value is already taken"
+    ErrUniqueViolationMsg = "data value is already taken"
+
+    // ErrForeignKeyViolationMsg is error message when saved data reference to data that is not exist
+    // msg = "data reference is not found"
+    ErrForeignKeyViolationMsg = "data reference is not found"
+
+    // ErrCheckViolationMsg is error message when saved data value is not allowed
+    // msg = "data value is not allowed"
+    ErrCheckViolationMsg = "data value is not allowed"
+
+    // ErrSerializationFailureMsg is error message when data could not be saved since it is
+    // changed concurrently
+    // msg = "data is changed concurrently"
+    ErrSerializationFailureMsg = "data is changed concurrently"
+
+    // ErrNotNullViolationMsg is error message when saved data value is null on column
+    // that require a value
+    // msg = "data value is required"
+    ErrNotNullViolationMsg = "data value is required"
 )
+
+// Constraint is detail of the violated database constraint, it is passed as 'Err' field
+// of 'ErrorExt' so the client know which field cause the error
+type Constraint struct {
+    // Name is name of the violated constraint
+    Name  string `json:"constraint,omitempty"`
+
+    // Field is name of the field (column) violating the constraint
+    Field string `json:"field,omitempty"`
+}
+
+// Error method for displaying error string for 'Constraint' struct
+func (c *Constraint) Error() string {
+    return fmt.Sprintf("Constraint: %s, Field: %s", c.Name, c.Field)
+}
+
+// NewConstraint will create new 'ErrorExt' error instance of the violated database constraint
+func NewConstraint(code uint, name, field string) error {
+    return NewExt(code, &Constraint{Name: name, Field: field})
+}
@@ -48,6 +48,18 @@ func NewExt(code uint, e error) error {
     }
 }
 
+// Code will get error code of 'Error' or 'ErrorExt' error, it is zero for other error
+func Code(err error) uint {
+    switch e := err.(type) {
+    case *Error:
+        return e.Code
+    case *ErrorExt:
+        return e.Code
+    }
+
+    return 0
+}
+
 // Message wiil return error message
 func Message(code uint) (message string) {
     switch code {
@@ -56,6 +68,7 @@ func Message(code uint) (message string) {
         case ErrServerMode              : message = ErrServerModeMsg 
         case ErrServerHost              : message = ErrServerHostMsg 
         case ErrServerPort              : message = ErrServerPortMsg 
+        case ErrServerInternal          : message = ErrServerInternalMsg
 
         // database error
         case ErrDatabase                : message = ErrDatabaseMsg 
@@ -71,6 +84,12 @@ func Message(code uint) (message string) {
         case ErrUpdateDataFail          : message = ErrUpdateDataFailMsg 
         case ErrDeleteDataFail          : message = ErrDeleteDataFailMsg
         case ErrDataAlreadyExist        : message = ErrDataAlreadyExistMsg
+        case ErrDataIsInUse             : message = ErrDataIsInUseMsg
+        case ErrUniqueViolation         : message = ErrUniqueViolationMsg
+        case ErrForeignKeyViolation     : message = ErrForeignKeyViolationMsg
+        case ErrCheckViolation          : message = ErrCheckViolationMsg
+        case ErrSerializationFailure    : message = ErrSerializationFailureMsg
+        case ErrNotNullViolation        : message = ErrNotNullViolationMsg
         
         // auth error
         case ErrSignUp                  : message = ErrSignUpMsg 
@@ -85,6 +104,27 @@ func Message(code uint) (message string) {
         case ErrTokenRefresh            : message = ErrTokenRefreshMsg
         case ErrTokenInvalid            : message = ErrTokenInvalidMsg
         case ErrTokenNotFound           : message = ErrTokenNotFoundMsg
+        case ErrTokenRevoked            : message = ErrTokenRevokedMsg
+        case ErrActivationTokenInvalid  : message = ErrActivationTokenInvalidMsg
+        case ErrActivationMail          : message = ErrActivationMailMsg
+        case ErrPasswordResetTokenInvalid : message = ErrPasswordResetTokenInvalidMsg
+        case ErrForbidden               : message = ErrForbiddenMsg
+        case ErrRefreshTokenReused      : message = ErrRefreshTokenReusedMsg
+        case ErrSigningKey              : message = ErrSigningKeyMsg
+        case ErrSigningKeyInUse         : message = ErrSigningKeyInUseMsg
+        case ErrTwoFactorAlreadyEnabled : message = ErrTwoFactorAlreadyEnabledMsg
+        case ErrTwoFactorNotEnrolled    : message = ErrTwoFactorNotEnrolledMsg
+        case ErrTwoFactorCodeInvalid    : message = ErrTwoFactorCodeInvalidMsg
+        case ErrEncryption              : message = ErrEncryptionMsg
+        case ErrAccountLocked           : message = ErrAccountLockedMsg
+        case ErrSigninThrottled         : message = ErrSigninThrottledMsg
+        case ErrOIDCProviderNotFound    : message = ErrOIDCProviderNotFoundMsg
+        case ErrOIDCStateInvalid        : message = ErrOIDCStateInvalidMsg
+        case ErrOIDCExchange            : message = ErrOIDCExchangeMsg
+        case ErrOIDCTokenInvalid        : message = ErrOIDCTokenInvalidMsg
+        case ErrOIDCEmailNotVerified    : message = ErrOIDCEmailNotVerifiedMsg
+        case ErrSigningKeyVerifyOnly    : message = ErrSigningKeyVerifyOnlyMsg
+        case ErrPasswordPolicy          : message = ErrPasswordPolicyMsg
 
         // handler error
         case ErrParamIsEmpty        : message = ErrParamIsEmptyMsg
@@ -99,19 +139,3 @@ func Message(code uint) (message string) {
 
     return
 }
-
-// ValidationError is a 'Request' error detail generator 
-// it will break given error value into detailed error message 
-// so we know which field is the cause of error upon handling request
-// func ValidationError(err error) (eMessage *[]string) {
-//     // Create list of error message
-//     eMsg := []string{}
-//     for _, e := range err.(validator.ValidationErrors) {
-//         msg := fmt.Sprintf("Field error :'%s', condition: '%s'", e.Field(), e.ActualTag())
-//         eMsg = append(eMsg, msg)
-//     }
-//
-//     eMessage = &eMsg
-//
-//     return
-// }
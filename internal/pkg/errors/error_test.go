@@ -1,9 +1,15 @@
 package errors
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
 	"testing"
 
+	"github.com/go-playground/validator/v10"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -45,6 +51,7 @@ func TestError(t *testing.T) {
         {ErrServerMode, ErrServerModeMsg},
         {ErrServerHost, ErrServerHostMsg},
         {ErrServerPort, ErrServerPortMsg},
+        {ErrServerInternal, ErrServerInternalMsg},
         {ErrDatabase, ErrDatabaseMsg},
         {ErrDatabaseConfiguration, ErrDatabaseConfigurationMsg},
         {ErrDatabaseTransactionNil, ErrDatabaseTransactionNilMsg},
@@ -58,6 +65,12 @@ func TestError(t *testing.T) {
         {ErrUpdateDataFail, ErrUpdateDataFailMsg},
         {ErrDeleteDataFail, ErrDeleteDataFailMsg},
         {ErrDataAlreadyExist, ErrDataAlreadyExistMsg},
+        {ErrDataIsInUse, ErrDataIsInUseMsg},
+        {ErrUniqueViolation, ErrUniqueViolationMsg},
+        {ErrForeignKeyViolation, ErrForeignKeyViolationMsg},
+        {ErrCheckViolation, ErrCheckViolationMsg},
+        {ErrSerializationFailure, ErrSerializationFailureMsg},
+        {ErrNotNullViolation, ErrNotNullViolationMsg},
         {ErrParamIsEmpty, ErrParamIsEmptyMsg},
         {ErrParamIsInvalid, ErrParamIsInvalidMsg},
         {ErrUsernameIsInvalid, ErrUsernameIsInvalidMsg},
@@ -96,6 +109,27 @@ func TestErrorExt(t *testing.T) {
         {ErrTokenRefresh, ErrTokenRefreshMsg},
         {ErrTokenInvalid, ErrTokenInvalidMsg},
         {ErrTokenNotFound, ErrTokenNotFoundMsg},
+        {ErrTokenRevoked, ErrTokenRevokedMsg},
+        {ErrActivationTokenInvalid, ErrActivationTokenInvalidMsg},
+        {ErrActivationMail, ErrActivationMailMsg},
+        {ErrPasswordResetTokenInvalid, ErrPasswordResetTokenInvalidMsg},
+        {ErrForbidden, ErrForbiddenMsg},
+        {ErrRefreshTokenReused, ErrRefreshTokenReusedMsg},
+        {ErrSigningKey, ErrSigningKeyMsg},
+        {ErrSigningKeyInUse, ErrSigningKeyInUseMsg},
+        {ErrTwoFactorAlreadyEnabled, ErrTwoFactorAlreadyEnabledMsg},
+        {ErrTwoFactorNotEnrolled, ErrTwoFactorNotEnrolledMsg},
+        {ErrTwoFactorCodeInvalid, ErrTwoFactorCodeInvalidMsg},
+        {ErrEncryption, ErrEncryptionMsg},
+        {ErrAccountLocked, ErrAccountLockedMsg},
+        {ErrSigninThrottled, ErrSigninThrottledMsg},
+        {ErrOIDCProviderNotFound, ErrOIDCProviderNotFoundMsg},
+        {ErrOIDCStateInvalid, ErrOIDCStateInvalidMsg},
+        {ErrOIDCExchange, ErrOIDCExchangeMsg},
+        {ErrOIDCTokenInvalid, ErrOIDCTokenInvalidMsg},
+        {ErrOIDCEmailNotVerified, ErrOIDCEmailNotVerifiedMsg},
+        {ErrSigningKeyVerifyOnly, ErrSigningKeyVerifyOnlyMsg},
+        {ErrPasswordPolicy, ErrPasswordPolicyMsg},
     }
 
     for _, tt := range cases {
@@ -115,6 +149,80 @@ func TestErrorExt(t *testing.T) {
     }
 }
 
+// TestNewConstraint is for testing error of the violated database constraint
+func TestNewConstraint(t *testing.T) {
+    err := NewConstraint(ErrUniqueViolation, "users_email_key", "email")
+
+    assert.Error(t, err)
+    assert.Equal(t, uint(ErrUniqueViolation), err.(*ErrorExt).Code)
+    assert.Equal(t, ErrUniqueViolationMsg, err.(*ErrorExt).Message)
+    assert.Equal(t, &Constraint{Name: "users_email_key", Field: "email"}, err.(*ErrorExt).Err)
+    assert.Equal(t, "Constraint: users_email_key, Field: email", err.(*ErrorExt).Err.(error).Error())
+}
+
+// TestCode is for testing getting code of the custom error
+func TestCode(t *testing.T) {
+    assert.Equal(t, uint(ErrDataIsEmpty), Code(New(ErrDataIsEmpty)))
+    assert.Equal(t, uint(ErrCheckViolation), Code(NewConstraint(ErrCheckViolation, "user_role_name_check", "")))
+    assert.Equal(t, uint(0), Code(fmt.Errorf("unknown error")))
+    assert.Equal(t, uint(0), Code(nil))
+}
+
+// TestStatus is for testing http status of the error code
+func TestStatus(t *testing.T) {
+    cases := []struct{
+        code uint
+        want int
+    }{
+        {ErrRequestDataInvalid, http.StatusBadRequest},
+        {ErrDataIsInvalid, http.StatusBadRequest},
+        {ErrPasswordPolicy, http.StatusBadRequest},
+        {ErrSignIn, http.StatusUnauthorized},
+        {ErrTokenNotFound, http.StatusUnauthorized},
+        {ErrForbidden, http.StatusForbidden},
+        {ErrDataIsEmpty, http.StatusNotFound},
+        {ErrUniqueViolation, http.StatusConflict},
+        {ErrUserAlreadyRegistered, http.StatusConflict},
+        {ErrCheckViolation, http.StatusUnprocessableEntity},
+        {ErrNotNullViolation, http.StatusUnprocessableEntity},
+        {ErrAccountLocked, http.StatusLocked},
+        {ErrSigninThrottled, http.StatusTooManyRequests},
+        {ErrOIDCExchange, http.StatusBadGateway},
+        {ErrDatabase, http.StatusInternalServerError},
+        {ErrTokenCreate, http.StatusInternalServerError},
+        {1, http.StatusInternalServerError},
+    }
+
+    for _, tt := range cases {
+        t.Run(Message(tt.code), func(t *testing.T){
+            assert.Equal(t, tt.want, Status(tt.code))
+        })
+    }
+}
+
+// TestPublic is for testing client-safe error of the error sent on the response
+func TestPublic(t *testing.T) {
+    cases := []struct{
+        name string
+        err  error
+        want error
+    }{
+        {"EXPECT SUCCESS error", &Error{Code: ErrDataIsEmpty, Message: "no row in result set"}, New(ErrDataIsEmpty)},
+        {"EXPECT SUCCESS constraint detail is kept", NewConstraint(ErrUniqueViolation, "users_email_key", "email"),
+            NewConstraint(ErrUniqueViolation, "users_email_key", "email")},
+        {"EXPECT SUCCESS field error is kept", NewExt(ErrRequestDataInvalid, FieldErrors{{Field: "email", Rule: "required", Message: "is required"}}),
+            NewExt(ErrRequestDataInvalid, FieldErrors{{Field: "email", Rule: "required", Message: "is required"}})},
+        {"EXPECT SUCCESS internal detail is removed", NewExt(ErrTokenCreate, fmt.Errorf("key not found")), New(ErrTokenCreate)},
+        {"EXPECT SUCCESS unknown error", fmt.Errorf("connection lost"), New(ErrServerInternal)},
+    }
+
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            assert.Equal(t, tt.want, Public(tt.err))
+        })
+    }
+}
+
 // TestValidationError will test input validation on user request data
 func TestValidationError(t *testing.T) {
     // struct with validation input 
@@ -123,7 +231,38 @@ func TestValidationError(t *testing.T) {
         Name        string  `json:"name" binding:"required"`
         Email       string  `json:"email" binding:"required,email"`
         Qty         int     `json:"quantity,default=0" binding:"number"`
-        Description string  `json:"description"`
+        Description string  `json:"description" binding:"max=10"`
     }
 
-} 
+    // validator used by gin binding, the field is named by its json member
+    v := validator.New()
+    v.SetTagName("binding")
+    v.RegisterTagNameFunc(func(f reflect.StructField) string {
+        return strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+    })
+
+    t.Run("EXPECT SUCCESS field error", func(t *testing.T){
+        err := ValidationError(v.Struct(goods{ID: 1, Email: "invalid", Description: "more than ten"}))
+
+        assert.Equal(t, NewExt(ErrRequestDataInvalid, FieldErrors{
+            {Field: "name", Rule: "required", Message: "is required"},
+            {Field: "email", Rule: "email", Message: "must be a valid email"},
+            {Field: "description", Rule: "max", Message: "must be at most 10 characters"},
+        }), err)
+    })
+
+    t.Run("EXPECT SUCCESS json type error", func(t *testing.T){
+        var g goods
+        err := ValidationError(json.Unmarshal([]byte(`{"id":"one"}`), &g))
+
+        assert.Equal(t, NewExt(ErrRequestDataInvalid, FieldErrors{
+            {Field: "id", Rule: "type", Message: "must be int"},
+        }), err)
+    })
+
+    t.Run("EXPECT SUCCESS other error", func(t *testing.T){
+        err := ValidationError(io.EOF)
+
+        assert.Equal(t, NewExt(ErrRequestDataInvalid, io.EOF), err)
+    })
+}
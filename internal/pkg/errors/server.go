@@ -21,6 +21,10 @@ const (
     // ErrServerPort is error code for server port error
     // msg = "server port is unknown or already used"
     ErrServerPort
+
+    // ErrServerInternal is error code for unexpected error while processing the request
+    // msg = "internal server error"
+    ErrServerInternal
 )
 
 const (
@@ -39,4 +43,8 @@ const (
     // ErrServerPort is error code for server port error
     // msg = "server port is unknown or already used"
     ErrServerPortMsg = "server port is unknown or already used"
+
+    // ErrServerInternalMsg is error message for unexpected error while processing the request
+    // msg = "internal server error"
+    ErrServerInternalMsg = "internal server error"
 )
@@ -0,0 +1,98 @@
+/*
+    package errors
+    status.go
+    - http status of the error code and client-safe error to be sent on the response
+*/
+package errors
+
+import (
+	"net/http"
+)
+
+// Status will return http status of the error code, unknown code is server error
+func Status(code uint) (status int) {
+    switch code {
+        // request error
+        case ErrParamIsEmpty,
+            ErrParamIsInvalid,
+            ErrUsernameIsInvalid,
+            ErrEmailIsInvalid,
+            ErrRequestDataInvalid,
+            ErrDataIsInvalid,
+            ErrPasswordTooShort,
+            ErrPasswordPolicy,
+            ErrActivationTokenInvalid,
+            ErrPasswordResetTokenInvalid,
+            ErrOIDCStateInvalid         : status = http.StatusBadRequest
+
+        // unauthenticated error
+        case ErrSignIn,
+            ErrUserNotActive,
+            ErrTokenInvalid,
+            ErrTokenNotFound,
+            ErrTokenRevoked,
+            ErrRefreshTokenReused,
+            ErrOIDCTokenInvalid         : status = http.StatusUnauthorized
+
+        // forbidden error
+        case ErrForbidden,
+            ErrPasswordNotMatch,
+            ErrOIDCEmailNotVerified     : status = http.StatusForbidden
+
+        // not found error
+        case ErrDataIsEmpty,
+            ErrDataNotFound,
+            ErrUserNotRegistered,
+            ErrTwoFactorNotEnrolled,
+            ErrOIDCProviderNotFound     : status = http.StatusNotFound
+
+        // conflict error
+        case ErrDataAlreadyExist,
+            ErrDataIsInUse,
+            ErrUniqueViolation,
+            ErrSerializationFailure,
+            ErrUserAlreadyRegistered,
+            ErrSigningKeyInUse,
+            ErrTwoFactorAlreadyEnabled  : status = http.StatusConflict
+
+        // unprocessable error
+        case ErrForeignKeyViolation,
+            ErrCheckViolation,
+            ErrNotNullViolation,
+            ErrTwoFactorCodeInvalid,
+            ErrSigningKeyVerifyOnly     : status = http.StatusUnprocessableEntity
+
+        // throttled error
+        case ErrAccountLocked           : status = http.StatusLocked
+        case ErrSigninThrottled         : status = http.StatusTooManyRequests
+
+        // upstream error
+        case ErrOIDCExchange            : status = http.StatusBadGateway
+
+        // server error and unknown error
+        default                         : status = http.StatusInternalServerError
+    }
+
+    return
+}
+
+// Public will get client-safe error of the given error to be sent on the response. the message
+// is taken from the error code, detail of 'ErrorExt' is only kept when it is meant for the
+// client (violated constraint and field failing the validation), and error that is not
+// 'Error' nor 'ErrorExt' is reported as ErrServerInternal
+func Public(err error) error {
+    switch e := err.(type) {
+    case *Error:
+        return New(e.Code)
+    case *ErrorExt:
+        switch e.Err.(type) {
+        case *Constraint, FieldErrors:
+            if Status(e.Code) < http.StatusInternalServerError {
+                return &ErrorExt{Code: e.Code, Message: Message(e.Code), Err: e.Err}
+            }
+        }
+        return New(e.Code)
+    }
+
+    return New(ErrServerInternal)
+}
@@ -0,0 +1,101 @@
+/*
+   package errors
+   validation.go
+   - field detail of the request data failing the validation
+*/
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError is detail of the request field failing the validation rule
+type FieldError struct {
+    // Field is name of the field (json member) failing the rule
+    Field   string `json:"field"`
+
+    // Rule is name of the failed validation rule, such as 'required' or 'max'
+    Rule    string `json:"rule"`
+
+    // Message is human readable description of the failed rule
+    Message string `json:"message"`
+}
+
+// FieldErrors is list of the request field failing the validation, it is passed as 'Err'
+// field of 'ErrorExt' so the client know which field cause the error
+type FieldErrors []FieldError
+
+// Error method for displaying error string for 'FieldErrors'
+func (f FieldErrors) Error() string {
+    msgs := make([]string, len(f))
+    for i, e := range f {
+        msgs[i] = fmt.Sprintf("Field error: '%s', rule: '%s'", e.Field, e.Rule)
+    }
+
+    return strings.Join(msgs, "; ")
+}
+
+// ValidationError is a 'Request' error detail generator. it will break the given binding
+// error into the field failing the validation so we know which field is the cause of
+// error upon handling request. error that is not validation error (such as malformed
+// json) is kept as internal detail
+func ValidationError(err error) error {
+    switch e := err.(type) {
+    case validator.ValidationErrors:
+        fields := make(FieldErrors, len(e))
+        for i, fe := range e {
+            fields[i] = FieldError{Field: fe.Field(), Rule: fe.Tag(), Message: ruleMessage(fe)}
+        }
+        return NewExt(ErrRequestDataInvalid, fields)
+    case *json.UnmarshalTypeError:
+        if e.Field != "" {
+            return NewExt(ErrRequestDataInvalid, FieldErrors{
+                {Field: e.Field, Rule: "type", Message: fmt.Sprintf("must be %s", e.Type)},
+            })
+        }
+    }
+
+    return NewExt(ErrRequestDataInvalid, err)
+}
+
+// ruleMessage will get human readable description of the failed validation rule
+func ruleMessage(fe validator.FieldError) string {
+    // length rule count character of string and item of list
+    unit := ""
+    switch fe.Kind() {
+    case reflect.String:
+        unit = " characters"
+    case reflect.Slice, reflect.Array, reflect.Map:
+        unit = " items"
+    }
+
+    switch fe.Tag() {
+    case "required":
+        return "is required"
+    case "notblank":
+        return "must not be blank"
+    case "email":
+        return "must be a valid email"
+    case "username":
+        return "must start with letter or number and only contain letter, number, dot, underscore or dash"
+    case "permission":
+        return "must be permission code in 'resource:action' format"
+    case "max":
+        return fmt.Sprintf("must be at most %s%s", fe.Param(), unit)
+    case "min":
+        return fmt.Sprintf("must be at least %s%s", fe.Param(), unit)
+    case "len":
+        return fmt.Sprintf("must be exactly %s%s", fe.Param(), unit)
+    case "oneof":
+        return fmt.Sprintf("must be one of '%s'", fe.Param())
+    case "numeric":
+        return "must only contain number"
+    }
+
+    return fmt.Sprintf("must satisfy '%s' rule", fe.Tag())
+}
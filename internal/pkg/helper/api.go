@@ -5,6 +5,8 @@ package helper
 
 import (
 	"github.com/gin-gonic/gin"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/logger"
 )
 
 // Response is response helper to send to client/ user
@@ -13,6 +15,7 @@ type Response struct{
     Method  string      `json:"method"`
     Message string      `json:"message"`
     Data    interface{} `json:"data"`
+    Meta    interface{} `json:"meta,omitempty"`
 }
 
 // ErrorResponse is a response that containing error 
@@ -46,6 +49,25 @@ func APIResponse(c *gin.Context, statusCode int, message string, data interface{
     }
 }
 
+// APIListResponse will send JSON response of list record to the client along with
+// the list metadata (total record, pagination and next cursor)
+func APIListResponse(c *gin.Context, statusCode int, message string, data, meta interface{}) {
+    // prepare the response before sending to the client
+    res := Response{
+        Status  : statusCode,
+        Method  : c.Request.Method,
+        Message : message,
+        Data    : data,
+        Meta    : meta,
+    }
+
+    // Send wrapped data to client
+    c.JSON(
+        statusCode,
+        res,
+    )
+}
+
 // APIErrorResponse will send JSON response with error value to the client 
 func APIErrorResponse(c *gin.Context, statusCode int, err interface{}) {
     // Prepare the data before sending to the client
@@ -63,3 +85,19 @@ func APIErrorResponse(c *gin.Context, statusCode int, err interface{}) {
 
     defer c.AbortWithStatus(statusCode)
 }
+
+// APIContextErrorResponse will send the last error put on the request context (by
+// 'c.Error') to the client unless the response is already sent. the http status and the
+// message is taken from the error code, the error detail is only logged on the server
+func APIContextErrorResponse(c *gin.Context) {
+    if len(c.Errors) == 0 || c.Writer.Written() {
+        return
+    }
+
+    err := c.Errors.Last().Err
+    for _, e := range c.Errors {
+        logger.Errorf("%s %s fail: %v", c.Request.Method, c.Request.URL.Path, e.Err)
+    }
+
+    APIErrorResponse(c, E.Status(E.Code(err)), E.Public(err))
+}
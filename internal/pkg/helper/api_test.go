@@ -77,3 +77,66 @@ func TestApiErrorResponse(t *testing.T) {
         t.Fatalf("expecting status '%d' but got '%d'", http.StatusBadRequest, w.Code)
     }
 }
+
+// TestAPIListResponse will test list response wrapper function
+func TestAPIListResponse(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+    w := httptest.NewRecorder()
+    c, _ := gin.CreateTestContext(w)
+    c.Request, _ = http.NewRequest(http.MethodGet, "/test", nil)
+
+    APIListResponse(c, http.StatusOK, "success", []string{"a", "b"}, map[string]int{"total": 2})
+
+    if w.Code != http.StatusOK {
+        t.Fatalf("expecting status '%d' but got '%d'", http.StatusOK, w.Code)
+    }
+    if !strings.Contains(w.Body.String(), `"meta":{"total":2}`) {
+        t.Fatalf("expecting list metadata on response but got '%s'", w.Body.String())
+    }
+}
+
+// TestAPIContextErrorResponse will test sending error put on the request context
+func TestAPIContextErrorResponse(t *testing.T) {
+    cases := []struct{
+        name string
+        err  error
+        want int
+    }{
+        {"EXPECT SUCCESS no error", nil, http.StatusOK},
+        {"EXPECT SUCCESS not found", e.New(e.ErrDataNotFound), http.StatusNotFound},
+        {"EXPECT SUCCESS conflict", e.NewConstraint(e.ErrUniqueViolation, "users_email_key", "email"), http.StatusConflict},
+        {"EXPECT SUCCESS unknown error", fmt.Errorf("connection lost"), http.StatusInternalServerError},
+    }
+
+    gin.SetMode(gin.TestMode)
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            w := httptest.NewRecorder()
+            c, _ := gin.CreateTestContext(w)
+            c.Request, _ = http.NewRequest(http.MethodGet, "/test", nil)
+            if tt.err != nil {
+                _ = c.Error(tt.err)
+            }
+
+            APIContextErrorResponse(c)
+
+            if w.Code != tt.want {
+                t.Fatalf("expecting status '%d' but got '%d'", tt.want, w.Code)
+            }
+            if tt.want == http.StatusInternalServerError && strings.Contains(w.Body.String(), "connection lost") {
+                t.Fatalf("expecting internal error detail is not sent but got '%s'", w.Body.String())
+            }
+        })
+    }
+
+    // response that is already sent is left untouched
+    w := httptest.NewRecorder()
+    c, _ := gin.CreateTestContext(w)
+    c.Request, _ = http.NewRequest(http.MethodGet, "/test", nil)
+    APIErrorResponse(c, http.StatusBadRequest, e.New(e.ErrRequestDataInvalid))
+    _ = c.Error(e.New(e.ErrDataNotFound))
+    APIContextErrorResponse(c)
+    if w.Code != http.StatusBadRequest {
+        t.Fatalf("expecting status '%d' but got '%d'", http.StatusBadRequest, w.Code)
+    }
+}
@@ -0,0 +1,34 @@
+/*
+   Package helper for handling the audit log of the change made by the request
+*/
+package helper
+
+import (
+	"github.com/gin-gonic/gin"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+)
+
+// auditKey is the key of the audit log on gin context
+const auditKey = "audit"
+
+// SetAudit will put audit log of the change made by the request on the request context.
+// it is recorded by the Audit middleware once the request succeed
+func SetAudit(c *gin.Context, entry *d.AuditLog) {
+    c.Set(auditKey, entry)
+}
+
+// GetAudit will get audit log of the change made by the request from the request context.
+// it return false when the request make no change
+func GetAudit(c *gin.Context) (*d.AuditLog, bool) {
+    value, exists := c.Get(auditKey)
+    if !exists {
+        return nil, false
+    }
+
+    entry, ok := value.(*d.AuditLog)
+    if !ok || entry == nil {
+        return nil, false
+    }
+
+    return entry, true
+}
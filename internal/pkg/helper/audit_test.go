@@ -0,0 +1,45 @@
+package helper
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestAudit will test SetAudit and GetAudit on gin context
+func TestAudit(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        c, _ := gin.CreateTestContext(httptest.NewRecorder())
+        want := d.NewAuditLog(d.AuditActionDelete, d.AuditTargetRole, "3", nil, nil)
+
+        SetAudit(c, want)
+        got, ok := GetAudit(c)
+
+        assert.True(t, ok)
+        assert.Equal(t, want, got)
+    })
+
+    t.Run("EXPECT FAIL audit not set", func(t *testing.T){
+        c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+        got, ok := GetAudit(c)
+
+        assert.False(t, ok)
+        assert.Nil(t, got)
+    })
+
+    t.Run("EXPECT FAIL audit invalid type", func(t *testing.T){
+        c, _ := gin.CreateTestContext(httptest.NewRecorder())
+        c.Set(auditKey, "audit")
+
+        got, ok := GetAudit(c)
+
+        assert.False(t, ok)
+        assert.Nil(t, got)
+    })
+}
@@ -2,14 +2,15 @@ package helper
 
 import (
 	crand "crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	mRand "math/rand"
 	"net/mail"
 	"time"
 
 	e "github.com/reshimahendra/lbw-go/internal/pkg/errors"
 	"github.com/spf13/viper"
-	"golang.org/x/crypto/bcrypt"
 )
 
 var crandRead = crand.Read
@@ -66,18 +67,23 @@ func GenerateSecureKey(length int) (string, error) {
     return encryptKey, nil
 }
 
-// HashPassword will generated hashed password so it wont easily be roken by unauthorized person
-func HashPassword(password string) (string, error) {
-    bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
-    hashed := string(bytes)
-    return hashed, err
+// GenerateToken will create url safe random token with given byte length.
+// the token is meant to be sent to the user (ex: via email) and only its hash
+// (see HashToken) is stored on the database
+func GenerateToken(length int) (string, error) {
+    tok := make([]byte, length)
+    if _, err := crandRead(tok); err != nil {
+        return "", err
+    }
+
+    return base64.RawURLEncoding.EncodeToString(tok), nil
 }
 
-// CheckPasswordHash will compare 'hashed' password with the 'input' password
-func CheckPasswordHash(password, hash string) bool {
-    err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+// HashToken will hash the given token with sha256 and return it as hex string
+func HashToken(token string) string {
+    sum := sha256.Sum256([]byte(token))
 
-    return err == nil
+    return hex.EncodeToString(sum[:])
 }
 
 // EmailIsValid will check whether given email was valid
@@ -86,13 +92,3 @@ func EmailIsValid(email string) bool {
 
     return err == nil
 }
-
-// PasswordTooShort will check whether password length is not match the minimum 
-// password lenght for the user account
-func PasswordTooShort(password string) bool {
-    minLength := viper.GetInt("account.minimumPasswordLength")
-    if minLength == 0 {
-        minLength = 8
-    }
-    return len(password) < minLength 
-}
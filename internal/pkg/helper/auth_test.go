@@ -109,68 +109,40 @@ func TestGenerateSecureKey(t *testing.T) {
     }
 } 
 
-// TestHashPassword is for testing the hash password generator
-func TestHashPassword(t *testing.T) {
-    cases := []struct{
-        name,password string
-    }{
-        {"EXPECT SUCCESS alpha numeric", "1234abcd"},
-        {"EXPECT SUCCESS mix char", "Aj6@1_=8"},
-    }
-
-    if testing.Short() {
-        for _, tt := range cases {
-            got, err:= HashPassword(tt.password)
-            
-            // run the test table
-            t.Run(tt.name, func(t *testing.T){
-                assert.NoError(t, err)
-                assert.NotEmpty(t, got)                
-            })
-        }
-   }
-}
-
-// TestCheckPasswordHash to test whether hash pass match with the plain 
-// pass before it hashed
-func TestCheckPasswordHash(t *testing.T) {
-    cases := []struct{
-        name, pass, hashPass string
-        want bool
-    }{
-        {"SuccessA", "1234abcd", "$2a$14$./GeWe7L5mWgMxg6aa2owurPQQogskmMbpE8o2omFW4/q.W4fOtwe", true},
-        {"SuccessB", "Aj6@1_=8", "$2a$14$CRqt9LW2L.ir9dyMC6G7iOw2ilwzk.r.nkj2PEu1T/j37xBX7xJHO", true},
-        {"FailA", "nopass", "should-be-not-matching", false},
-    }
-
-    if testing.Short() {
-        for _, tt := range cases {
-            t.Run(tt.name, func(t *testing.T){
-                got := CheckPasswordHash(tt.pass, tt.hashPass)
-                assert.Equal(t, got, tt.want)
-            })
+// TestGenerateToken will test the random token generator
+func TestGenerateToken(t *testing.T) {
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        got, err := GenerateToken(32)
+        assert.NoError(t, err)
+        assert.NotEmpty(t, got)
+
+        // each call should give different token
+        other, err := GenerateToken(32)
+        assert.NoError(t, err)
+        assert.NotEqual(t, got, other)
+    })
+
+    t.Run("EXPECT FAIL random read error", func(t *testing.T){
+        // mock inner function (crand)
+        crandRead = func(b []byte) (n int, err error) {
+            return 0, errors.New(errors.ErrDataIsInvalid)
         }
-    }
+        defer func() {
+            crandRead = crandReadFunc
+        }()
+
+        got, err := GenerateToken(32)
+        assert.Error(t, err)
+        assert.Equal(t, "", got)
+    })
 }
 
-// TestPasswordTooShort is testing whether password is too short
-func TestPasswordTooShort(t *testing.T) {
-    cases := []struct{
-        name, pass string
-        want bool
-    }{
-        {"SuccessA", "sweethome", false},
-        {"SuccessB", "aBc%58kjh1", false},
-        {"FailA", "fail", true},
-        {"FailB", "", true},
-    }
-
-    for _, tt := range cases{
-        t.Run(tt.name, func(t *testing.T){
-            got := PasswordTooShort(tt.pass)
-            assert.Equal(t, got, tt.want)
-        })
-    }
+// TestHashToken will test the token hashing
+func TestHashToken(t *testing.T) {
+    got := HashToken("token")
+    assert.Equal(t, "3c469e9d6c5875d37a43f353d4f88e61fcf812c66eee3457465a40b0da4153e0", got)
+    assert.Equal(t, got, HashToken("token"))
+    assert.NotEqual(t, got, HashToken("other-token"))
 }
 
 // TestEmailIsValid is for testing the validity of inputed mail
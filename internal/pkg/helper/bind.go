@@ -0,0 +1,94 @@
+/*
+   Package helper for binding and validating request data by its 'binding' struct tag
+*/
+package helper
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+)
+
+var (
+    // usernamePattern is pattern of valid username, it start with letter or number and
+    // only contain letter, number, dot, underscore or dash
+    usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+    // validatorOnce is to register the custom rule on the gin validator only once
+    validatorOnce sync.Once
+)
+
+// BindJSON will bind the json request body into obj and validate it by its 'binding' tag.
+// the field failing the validation is listed on the returned error (see errors.ValidationError)
+func BindJSON(c *gin.Context, obj interface{}) error {
+    validatorOnce.Do(setupValidator)
+    if err := c.ShouldBindJSON(obj); err != nil {
+        return E.ValidationError(err)
+    }
+
+    return nil
+}
+
+// BindQuery will bind the query string into obj and validate it by its 'binding' tag
+func BindQuery(c *gin.Context, obj interface{}) error {
+    validatorOnce.Do(setupValidator)
+    if err := c.ShouldBindQuery(obj); err != nil {
+        return E.ValidationError(err)
+    }
+
+    return nil
+}
+
+// Validate will validate obj by its 'binding' tag the same way as BindJSON. it is used on
+// data that is not bound from the request as a whole, such as record merged with merge patch
+func Validate(obj interface{}) error {
+    validatorOnce.Do(setupValidator)
+    if err := binding.Validator.ValidateStruct(obj); err != nil {
+        return E.ValidationError(err)
+    }
+
+    return nil
+}
+
+// setupValidator will make the gin validator name the field by its json member (or query
+// parameter) and register the custom rule:
+//  * notblank   : string is not empty nor only white space
+//  * username   : valid username (see usernamePattern)
+//  * permission : permission code in 'resource:action' format
+func setupValidator() {
+    v, ok := binding.Validator.Engine().(*validator.Validate)
+    if !ok {
+        return
+    }
+
+    v.RegisterTagNameFunc(fieldName)
+    _ = v.RegisterValidation("notblank", func(fl validator.FieldLevel) bool {
+        return strings.TrimSpace(fl.Field().String()) != ""
+    })
+    _ = v.RegisterValidation("username", func(fl validator.FieldLevel) bool {
+        return usernamePattern.MatchString(fl.Field().String())
+    })
+    _ = v.RegisterValidation("permission", func(fl validator.FieldLevel) bool {
+        return d.IsValidPermissionCode(fl.Field().String())
+    })
+}
+
+// fieldName will get name of the struct field on the request, it is the json member or
+// the query parameter name, and the struct field name when it has neither
+func fieldName(f reflect.StructField) string {
+    for _, tag := range []string{"json", "form"} {
+        name := strings.SplitN(f.Tag.Get(tag), ",", 2)[0]
+        if name != "" && name != "-" {
+            return name
+        }
+    }
+
+    return f.Name
+}
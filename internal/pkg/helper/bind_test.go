@@ -0,0 +1,109 @@
+package helper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestBindJSON will test binding and validating json request body
+func TestBindJSON(t *testing.T) {
+    type request struct {
+        Username string   `json:"username" binding:"required,max=10,username"`
+        Name     string   `json:"name" binding:"required,notblank"`
+        Scopes   []string `json:"scopes" binding:"dive,permission"`
+    }
+    cases := []struct{
+        name string
+        body string
+        want error
+    }{
+        {"EXPECT SUCCESS", `{"username":"john.doe","name":"John","scopes":["user:read"]}`, nil},
+        {"EXPECT FAIL required field missing", `{"name":"John"}`,
+            E.NewExt(E.ErrRequestDataInvalid, E.FieldErrors{{Field: "username", Rule: "required", Message: "is required"}})},
+        {"EXPECT FAIL blank field", `{"username":"john","name":"   "}`,
+            E.NewExt(E.ErrRequestDataInvalid, E.FieldErrors{{Field: "name", Rule: "notblank", Message: "must not be blank"}})},
+        {"EXPECT FAIL invalid username", `{"username":".john","name":"John"}`,
+            E.NewExt(E.ErrRequestDataInvalid, E.FieldErrors{{Field: "username", Rule: "username", Message: "must start with letter or number and only contain letter, number, dot, underscore or dash"}})},
+        {"EXPECT FAIL too long", `{"username":"john.doe.smith","name":"John"}`,
+            E.NewExt(E.ErrRequestDataInvalid, E.FieldErrors{{Field: "username", Rule: "max", Message: "must be at most 10 characters"}})},
+        {"EXPECT FAIL invalid permission", `{"username":"john","name":"John","scopes":["user"]}`,
+            E.NewExt(E.ErrRequestDataInvalid, E.FieldErrors{{Field: "scopes[0]", Rule: "permission", Message: "must be permission code in 'resource:action' format"}})},
+    }
+
+    gin.SetMode(gin.TestMode)
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            c, _ := gin.CreateTestContext(httptest.NewRecorder())
+            c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+
+            req := new(request)
+            err := BindJSON(c, req)
+
+            assert.Equal(t, tt.want, err)
+        })
+    }
+
+    t.Run("EXPECT FAIL malformed json", func(t *testing.T){
+        c, _ := gin.CreateTestContext(httptest.NewRecorder())
+        c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"username":`))
+
+        err := BindJSON(c, new(request))
+
+        assert.Equal(t, uint(E.ErrRequestDataInvalid), E.Code(err))
+        assert.Equal(t, E.New(E.ErrRequestDataInvalid), E.Public(err))
+    })
+}
+
+// TestValidate will test validating data that is not bound from the request
+func TestValidate(t *testing.T) {
+    type record struct {
+        Username string `json:"username" binding:"required,max=10,username"`
+        Email    string `json:"email" binding:"required,email"`
+    }
+
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        assert.NoError(t, Validate(&record{Username: "john.doe", Email: "john@lotusbw.com"}))
+    })
+
+    t.Run("EXPECT FAIL invalid field", func(t *testing.T){
+        err := Validate(&record{Username: "john doe", Email: "john@lotusbw.com"})
+
+        assert.Equal(t, E.NewExt(E.ErrRequestDataInvalid, E.FieldErrors{
+            {Field: "username", Rule: "username", Message: "must start with letter or number and only contain letter, number, dot, underscore or dash"},
+        }), err)
+    })
+}
+
+// TestBindQuery will test binding and validating query string
+func TestBindQuery(t *testing.T) {
+    type query struct {
+        Limit int `form:"limit" binding:"min=1"`
+    }
+
+    gin.SetMode(gin.TestMode)
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        c, _ := gin.CreateTestContext(httptest.NewRecorder())
+        c.Request = httptest.NewRequest(http.MethodGet, "/?limit=5", nil)
+
+        q := new(query)
+        err := BindQuery(c, q)
+
+        assert.NoError(t, err)
+        assert.Equal(t, 5, q.Limit)
+    })
+
+    t.Run("EXPECT FAIL invalid value", func(t *testing.T){
+        c, _ := gin.CreateTestContext(httptest.NewRecorder())
+        c.Request = httptest.NewRequest(http.MethodGet, "/?limit=0", nil)
+
+        err := BindQuery(c, new(query))
+
+        assert.Equal(t, E.NewExt(E.ErrRequestDataInvalid, E.FieldErrors{{Field: "limit", Rule: "min", Message: "must be at least 1"}}), err)
+    })
+}
@@ -0,0 +1,68 @@
+/*
+   Package helper for encrypting data stored on the database
+*/
+package helper
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+)
+
+// errCipherTextTooShort is error when the cipher text is shorter than its nonce
+var errCipherTextTooShort = errors.New("cipher text too short")
+
+// newGCM will create AES-256-GCM cipher with the sha256 hash of the given key
+func newGCM(key string) (cipher.AEAD, error) {
+    sum := sha256.Sum256([]byte(key))
+    block, err := aes.NewCipher(sum[:])
+    if err != nil {
+        return nil, err
+    }
+
+    return cipher.NewGCM(block)
+}
+
+// Encrypt will encrypt the plain text with AES-256-GCM using the given key.
+// the random nonce is prepended to the cipher text and encoded as base64
+func Encrypt(plainText, key string) (string, error) {
+    gcm, err := newGCM(key)
+    if err != nil {
+        return "", err
+    }
+
+    nonce := make([]byte, gcm.NonceSize())
+    if _, err := crandRead(nonce); err != nil {
+        return "", err
+    }
+
+    sealed := gcm.Seal(nonce, nonce, []byte(plainText), nil)
+    return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt will decrypt the cipher text created by Encrypt using the given key
+func Decrypt(cipherText, key string) (string, error) {
+    gcm, err := newGCM(key)
+    if err != nil {
+        return "", err
+    }
+
+    sealed, err := base64.StdEncoding.DecodeString(cipherText)
+    if err != nil {
+        return "", err
+    }
+
+    if len(sealed) < gcm.NonceSize() {
+        return "", errCipherTextTooShort
+    }
+
+    nonce, data := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+    plain, err := gcm.Open(nil, nonce, data, nil)
+    if err != nil {
+        return "", err
+    }
+
+    return string(plain), nil
+}
@@ -0,0 +1,60 @@
+package helper
+
+import (
+	"testing"
+
+	"github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestEncryptDecrypt will test encrypting and decrypting the text
+func TestEncryptDecrypt(t *testing.T) {
+    const key = "s3cr3t-encryption-key"
+
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        cipherText, err := Encrypt("JBSWY3DPEHPK3PXP", key)
+        assert.NoError(t, err)
+        assert.NotContains(t, cipherText, "JBSWY3DPEHPK3PXP")
+
+        // random nonce make each cipher text different
+        other, err := Encrypt("JBSWY3DPEHPK3PXP", key)
+        assert.NoError(t, err)
+        assert.NotEqual(t, cipherText, other)
+
+        got, err := Decrypt(cipherText, key)
+        assert.NoError(t, err)
+        assert.Equal(t, "JBSWY3DPEHPK3PXP", got)
+    })
+
+    t.Run("EXPECT FAIL wrong key", func(t *testing.T){
+        cipherText, err := Encrypt("JBSWY3DPEHPK3PXP", key)
+        assert.NoError(t, err)
+
+        got, err := Decrypt(cipherText, "other-key")
+        assert.Error(t, err)
+        assert.Equal(t, "", got)
+    })
+
+    t.Run("EXPECT FAIL cipher text not base64", func(t *testing.T){
+        _, err := Decrypt("not base64!", key)
+        assert.Error(t, err)
+    })
+
+    t.Run("EXPECT FAIL cipher text too short", func(t *testing.T){
+        _, err := Decrypt("AAAA", key)
+        assert.Error(t, err)
+    })
+
+    t.Run("EXPECT FAIL random source error", func(t *testing.T){
+        crandRead = func(b []byte) (n int, err error) {
+            return 0, errors.New(errors.ErrDataIsInvalid)
+        }
+        defer func() {
+            crandRead = crandReadFunc
+        }()
+
+        got, err := Encrypt("JBSWY3DPEHPK3PXP", key)
+        assert.Error(t, err)
+        assert.Equal(t, "", got)
+    })
+}
@@ -0,0 +1,130 @@
+/*
+   Package helper for reading list query (pagination, sorting and filtering) from request
+*/
+package helper
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+)
+
+// dateLayout is layout of date only filter value
+const dateLayout = "2006-01-02"
+
+// GetListQuery will read list query from the request query parameter. the list can be
+// sorted by the given sort fields, the first one is used when sort is not requested
+func GetListQuery(c *gin.Context, sortFields []string) (*d.ListQuery, error) {
+    q := &d.ListQuery{
+        Page       : 1,
+        Limit      : d.ListDefaultLimit,
+        Order      : d.SortAsc,
+        Username   : c.Query("username"),
+        Email      : c.Query("email"),
+        Action     : c.Query("action"),
+        TargetType : c.Query("target_type"),
+        TargetID   : c.Query("target_id"),
+    }
+    if len(sortFields) > 0 {
+        q.Sort = sortFields[0]
+    }
+    if sort := c.Query("sort"); sort != "" {
+        q.Sort = sort
+    }
+    if order := c.Query("order"); order != "" {
+        q.Order = strings.ToLower(order)
+    }
+
+    var err error
+    if q.Page, err = queryInt(c, "page", q.Page); err != nil {
+        return nil, err
+    }
+    if q.Limit, err = queryInt(c, "limit", q.Limit); err != nil {
+        return nil, err
+    }
+    if q.StatusID, err = queryIntPtr(c, "status_id"); err != nil {
+        return nil, err
+    }
+    if q.RoleID, err = queryIntPtr(c, "role_id"); err != nil {
+        return nil, err
+    }
+    if q.CreatedFrom, err = queryTime(c, "created_from"); err != nil {
+        return nil, err
+    }
+    if q.CreatedTo, err = queryTime(c, "created_to"); err != nil {
+        return nil, err
+    }
+
+    if actorID := c.Query("actor_id"); actorID != "" {
+        id, err := uuid.Parse(actorID)
+        if err != nil {
+            return nil, E.New(E.ErrParamIsInvalid)
+        }
+        q.ActorID = &id
+    }
+
+    if cursor := c.Query("cursor"); cursor != "" {
+        if q.Cursor, err = d.DecodeListCursor(cursor); err != nil {
+            return nil, E.New(E.ErrParamIsInvalid)
+        }
+    }
+
+    if !q.IsValid(sortFields) {
+        return nil, E.New(E.ErrParamIsInvalid)
+    }
+
+    return q, nil
+}
+
+// queryInt will read integer query parameter, it return the default value when it is not set
+func queryInt(c *gin.Context, key string, def int) (int, error) {
+    value := c.Query(key)
+    if value == "" {
+        return def, nil
+    }
+
+    i, err := strconv.Atoi(value)
+    if err != nil {
+        return 0, E.New(E.ErrParamIsInvalid)
+    }
+
+    return i, nil
+}
+
+// queryIntPtr will read optional integer query parameter
+func queryIntPtr(c *gin.Context, key string) (*int, error) {
+    if c.Query(key) == "" {
+        return nil, nil
+    }
+
+    i, err := queryInt(c, key, 0)
+    if err != nil {
+        return nil, err
+    }
+
+    return &i, nil
+}
+
+// queryTime will read optional datetime query parameter in RFC3339 or date only
+// format (start of the day in UTC)
+func queryTime(c *gin.Context, key string) (*time.Time, error) {
+    value := c.Query(key)
+    if value == "" {
+        return nil, nil
+    }
+
+    t, err := time.Parse(time.RFC3339, value)
+    if err != nil {
+        if t, err = time.Parse(dateLayout, value); err != nil {
+            return nil, E.New(E.ErrParamIsInvalid)
+        }
+    }
+    t = t.UTC()
+
+    return &t, nil
+}
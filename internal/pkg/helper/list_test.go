@@ -0,0 +1,109 @@
+package helper
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// listContext will create gin test context with the given request query
+func listContext(query string) *gin.Context {
+    c, _ := gin.CreateTestContext(httptest.NewRecorder())
+    c.Request, _ = http.NewRequest(http.MethodGet, "/users?"+query, nil)
+    return c
+}
+
+// TestGetListQuery will test reading list query from request query parameter
+func TestGetListQuery(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+
+    t.Run("EXPECT SUCCESS default value", func(t *testing.T){
+        got, err := GetListQuery(listContext(""), d.UserSortFields)
+
+        require.NoError(t, err)
+        assert.Equal(t, 1, got.Page)
+        assert.Equal(t, d.ListDefaultLimit, got.Limit)
+        assert.Equal(t, d.UserSortFields[0], got.Sort)
+        assert.Equal(t, d.SortAsc, got.Order)
+        assert.Nil(t, got.Cursor)
+        assert.Nil(t, got.StatusID)
+        assert.Nil(t, got.CreatedFrom)
+    })
+
+    t.Run("EXPECT SUCCESS all parameter", func(t *testing.T){
+        query := "page=3&limit=10&sort=username&order=DESC&status_id=1&role_id=2" +
+            "&created_from=2022-01-01&created_to=2022-02-01T10:00:00%2B07:00&username=re&email=reshi%40"
+        got, err := GetListQuery(listContext(query), d.UserSortFields)
+
+        require.NoError(t, err)
+        assert.Equal(t, 3, got.Page)
+        assert.Equal(t, 10, got.Limit)
+        assert.Equal(t, 20, got.Offset())
+        assert.Equal(t, "username", got.Sort)
+        assert.Equal(t, d.SortDesc, got.Order)
+        assert.Equal(t, 1, *got.StatusID)
+        assert.Equal(t, 2, *got.RoleID)
+        assert.Equal(t, time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC), *got.CreatedFrom)
+        assert.Equal(t, time.Date(2022, 2, 1, 3, 0, 0, 0, time.UTC), *got.CreatedTo)
+        assert.Equal(t, "re", got.Username)
+        assert.Equal(t, "reshi@", got.Email)
+    })
+
+    t.Run("EXPECT SUCCESS audit log filter", func(t *testing.T){
+        actorID := "0e9c3b4f-8f5a-4a52-9d7e-2b1f6c3a9d10"
+        query := "actor_id=" + actorID + "&action=update&target_type=user&target_id=42"
+        got, err := GetListQuery(listContext(query), d.AuditSortFields)
+
+        require.NoError(t, err)
+        assert.Equal(t, actorID, got.ActorID.String())
+        assert.Equal(t, "update", got.Action)
+        assert.Equal(t, "user", got.TargetType)
+        assert.Equal(t, "42", got.TargetID)
+    })
+
+    t.Run("EXPECT SUCCESS cursor", func(t *testing.T){
+        cursor := &d.ListCursor{Sort: "created_at", Order: d.SortAsc, Value: "2022-01-01T00:00:00Z", ID: "1"}
+        got, err := GetListQuery(listContext("page=5&cursor="+cursor.Encode()), d.UserSortFields)
+
+        require.NoError(t, err)
+        assert.Equal(t, cursor, got.Cursor)
+        assert.Equal(t, 0, got.Offset())
+    })
+
+    cases := []struct{
+        name  string
+        query string
+    }{
+        {"EXPECT FAIL page is not number", "page=one"},
+        {"EXPECT FAIL page below one", "page=0"},
+        {"EXPECT FAIL page offset overflow", "page=9223372036854775807"},
+        {"EXPECT FAIL page offset overflow on maximum limit", "page=92233720368547760&limit=100"},
+        {"EXPECT FAIL limit above maximum", "limit=1000"},
+        {"EXPECT FAIL unknown sort field", "sort=passkey"},
+        {"EXPECT FAIL unknown sort order", "order=up"},
+        {"EXPECT FAIL status is not number", "status_id=active"},
+        {"EXPECT FAIL role is not number", "role_id=admin"},
+        {"EXPECT FAIL invalid date", "created_from=yesterday"},
+        {"EXPECT FAIL invalid end date", "created_to=tomorrow"},
+        {"EXPECT FAIL reversed date range", "created_from=2022-02-01&created_to=2022-01-01"},
+        {"EXPECT FAIL invalid cursor", "cursor=not-a-cursor"},
+        {"EXPECT FAIL actor is not uuid", "actor_id=admin"},
+        {"EXPECT FAIL cursor of other sort", "sort=email&cursor=" +
+            (&d.ListCursor{Sort: "username", Order: d.SortAsc}).Encode()},
+    }
+
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            got, err := GetListQuery(listContext(tt.query), d.UserSortFields)
+
+            assert.Error(t, err)
+            assert.Nil(t, got)
+        })
+    }
+}
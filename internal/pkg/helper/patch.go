@@ -0,0 +1,30 @@
+/*
+   Package helper for reading JSON merge patch (RFC 7396) document from request
+*/
+package helper
+
+import (
+	"github.com/gin-gonic/gin"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+)
+
+// GetMergePatch will read JSON merge patch document from the request body. the request
+// content type must be merge patch or plain json and the document must be json object
+func GetMergePatch(c *gin.Context) (d.MergePatch, error) {
+    if ct := c.ContentType(); ct != d.MergePatchContentType && ct != gin.MIMEJSON {
+        return nil, E.New(E.ErrRequestDataInvalid)
+    }
+
+    body, err := c.GetRawData()
+    if err != nil {
+        return nil, E.New(E.ErrRequestDataInvalid)
+    }
+
+    patch, err := d.DecodeMergePatch(body)
+    if err != nil {
+        return nil, E.New(E.ErrRequestDataInvalid)
+    }
+
+    return patch, nil
+}
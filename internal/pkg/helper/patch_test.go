@@ -0,0 +1,63 @@
+package helper
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// patchContext will create gin test context with the given request body and content type
+func patchContext(contentType, body string) *gin.Context {
+    c, _ := gin.CreateTestContext(httptest.NewRecorder())
+    c.Request, _ = http.NewRequest(http.MethodPatch, "/users/1", bytes.NewBufferString(body))
+    c.Request.Header.Set("Content-Type", contentType)
+    return c
+}
+
+// TestGetMergePatch will test reading merge patch document from request body
+func TestGetMergePatch(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+
+    t.Run("EXPECT SUCCESS merge patch content type", func(t *testing.T){
+        got, err := GetMergePatch(patchContext(d.MergePatchContentType, `{"email":"reshi@lotusbw.com","lastname":null}`))
+
+        require.NoError(t, err)
+        assert.Equal(t, `"reshi@lotusbw.com"`, string(got["email"]))
+        assert.True(t, got.Has("lastname"))
+        assert.False(t, got.Has("username"))
+    })
+
+    t.Run("EXPECT SUCCESS json content type", func(t *testing.T){
+        got, err := GetMergePatch(patchContext("application/json; charset=utf-8", `{}`))
+
+        require.NoError(t, err)
+        assert.Empty(t, got)
+    })
+
+    cases := []struct{
+        name        string
+        contentType string
+        body        string
+    }{
+        {"EXPECT FAIL unsupported content type", "text/plain", `{"email":"reshi@lotusbw.com"}`},
+        {"EXPECT FAIL malformed json", d.MergePatchContentType, `{"email":`},
+        {"EXPECT FAIL document is not object", d.MergePatchContentType, `["email"]`},
+        {"EXPECT FAIL document is null", d.MergePatchContentType, `null`},
+    }
+
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            got, err := GetMergePatch(patchContext(tt.contentType, tt.body))
+
+            assert.Equal(t, E.New(E.ErrRequestDataInvalid), err)
+            assert.Nil(t, got)
+        })
+    }
+}
@@ -0,0 +1,68 @@
+/*
+   Package helper for handling the authenticated user (principal) on request context
+*/
+package helper
+
+import (
+	"github.com/gin-gonic/gin"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+)
+
+const (
+    // principalKey is the key of the principal on gin context
+    principalKey = "principal"
+
+    // permissionsKey is the key of the principal effective permission on gin context
+    permissionsKey = "permissions"
+)
+
+// SetPrincipal will put the principal (the authenticated user) on the request context
+func SetPrincipal(c *gin.Context, principal *d.Principal) {
+    c.Set(principalKey, principal)
+}
+
+// GetPrincipal will get the principal (the authenticated user) from the request context.
+// it return false when the request is not authorized
+func GetPrincipal(c *gin.Context) (*d.Principal, bool) {
+    value, exists := c.Get(principalKey)
+    if !exists {
+        return nil, false
+    }
+
+    principal, ok := value.(*d.Principal)
+    if !ok || principal == nil {
+        return nil, false
+    }
+
+    return principal, true
+}
+
+// SetPermissions will put the effective permission of the principal role on the request context
+func SetPermissions(c *gin.Context, perms []string) {
+    c.Set(permissionsKey, perms)
+}
+
+// GetPermissions will get the effective permission of the principal role from the request
+// context. it return false when the permission is not loaded yet
+func GetPermissions(c *gin.Context) ([]string, bool) {
+    value, exists := c.Get(permissionsKey)
+    if !exists {
+        return nil, false
+    }
+
+    perms, ok := value.([]string)
+    return perms, ok
+}
+
+// HasPermission will check whether the principal role is granted the permission. it
+// return false when the permission is not loaded yet
+func HasPermission(c *gin.Context, perm string) bool {
+    perms, _ := GetPermissions(c)
+    for _, p := range perms {
+        if p == perm {
+            return true
+        }
+    }
+
+    return false
+}
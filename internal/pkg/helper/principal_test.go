@@ -0,0 +1,74 @@
+package helper
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPrincipal will test SetPrincipal and GetPrincipal on gin context
+func TestPrincipal(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        c, _ := gin.CreateTestContext(httptest.NewRecorder())
+        want := &d.Principal{UserID: uuid.New(), Email: "leo@gmail.com", RoleID: 1, StatusID: 1}
+
+        SetPrincipal(c, want)
+        got, ok := GetPrincipal(c)
+
+        assert.True(t, ok)
+        assert.Equal(t, want, got)
+    })
+
+    t.Run("EXPECT FAIL principal not set", func(t *testing.T){
+        c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+        got, ok := GetPrincipal(c)
+
+        assert.False(t, ok)
+        assert.Nil(t, got)
+    })
+
+    t.Run("EXPECT FAIL principal invalid type", func(t *testing.T){
+        c, _ := gin.CreateTestContext(httptest.NewRecorder())
+        c.Set(principalKey, "principal")
+
+        got, ok := GetPrincipal(c)
+
+        assert.False(t, ok)
+        assert.Nil(t, got)
+    })
+}
+
+// TestPermissions will test SetPermissions, GetPermissions and HasPermission on gin context
+func TestPermissions(t *testing.T) {
+    gin.SetMode(gin.TestMode)
+
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        c, _ := gin.CreateTestContext(httptest.NewRecorder())
+        want := []string{d.PermUserRead, d.PermRoleRead}
+
+        SetPermissions(c, want)
+        got, ok := GetPermissions(c)
+
+        assert.True(t, ok)
+        assert.Equal(t, want, got)
+        assert.True(t, HasPermission(c, d.PermRoleRead))
+        assert.False(t, HasPermission(c, d.PermRoleWrite))
+    })
+
+    t.Run("EXPECT FAIL permission not loaded", func(t *testing.T){
+        c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+        got, ok := GetPermissions(c)
+
+        assert.False(t, ok)
+        assert.Nil(t, got)
+        assert.False(t, HasPermission(c, d.PermUserRead))
+    })
+}
@@ -0,0 +1,103 @@
+/*
+   package mailer
+   mailer.go
+   - pluggable mail sender used by the app (activation mail, etc)
+   NOTE of sender:
+       * LogMailer  : only write the mail to the log (development/ no smtp configured)
+       * SMTPMailer : send the mail via smtp server
+*/
+package mailer
+
+import (
+	"fmt"
+	"net/smtp"
+	"strings"
+
+	"github.com/reshimahendra/lbw-go/internal/config"
+	"github.com/reshimahendra/lbw-go/internal/pkg/logger"
+)
+
+var (
+    // smtpSendMailFunc is func instance of smtp.SendMail
+    // it will be used to mock the inner func on test
+    smtpSendMailFunc = smtp.SendMail
+)
+
+// IMailer is interface for sending mail, any mail sender must implement it
+type IMailer interface {
+    // Send will send mail with given subject and body to the recipient
+    Send(to, subject, body string) error
+}
+
+// New will create mailer based on the given mail configuration.
+// it fall back to LogMailer when smtp is not configured
+func New(cfg config.Mail) IMailer {
+    if !cfg.IsValid() {
+        logger.Infof("smtp is not configured, mail will be written to the log")
+        return NewLogMailer()
+    }
+
+    return NewSMTPMailer(cfg)
+}
+
+// LogMailer is mailer that only write the mail to the log
+type LogMailer struct {}
+
+// NewLogMailer is new instance of LogMailer
+func NewLogMailer() *LogMailer {
+    return &LogMailer{}
+}
+
+// Send will write the mail to the log instead of sending it
+func (m *LogMailer) Send(to, subject, body string) error {
+    logger.Infof("mail to: %s, subject: %s, body: %s", to, subject, body)
+
+    return nil
+}
+
+// SMTPMailer is mailer that send the mail via smtp server
+type SMTPMailer struct {
+    // Config is mail configuration holding the smtp setting
+    Config config.Mail
+}
+
+// NewSMTPMailer is new instance of SMTPMailer
+func NewSMTPMailer(cfg config.Mail) *SMTPMailer {
+    return &SMTPMailer{Config: cfg}
+}
+
+// Send will send the mail via smtp server
+func (m *SMTPMailer) Send(to, subject, body string) error {
+    // use smtp auth only when username is set
+    var auth smtp.Auth
+    if m.Config.SmtpUsername != "" {
+        auth = smtp.PlainAuth("", m.Config.SmtpUsername, m.Config.SmtpPassword, m.Config.SmtpServer)
+    }
+
+    addr := fmt.Sprintf("%s:%s", m.Config.SmtpServer, m.Config.SmtpPort)
+    if err := smtpSendMailFunc(addr, auth, m.Config.SenderEmail, []string{to}, m.message(to, subject, body)); err != nil {
+        logger.Errorf("fail sending mail to %s: %v", to, err)
+        return err
+    }
+
+    return nil
+}
+
+// message will compose the mail header and body
+func (m *SMTPMailer) message(to, subject, body string) []byte {
+    from := m.Config.SenderEmail
+    if m.Config.SenderIdentity != "" {
+        from = fmt.Sprintf("%s <%s>", m.Config.SenderIdentity, m.Config.SenderEmail)
+    }
+
+    var msg strings.Builder
+    msg.WriteString("From: " + from + "\r\n")
+    msg.WriteString("To: " + to + "\r\n")
+    msg.WriteString("Subject: " + subject + "\r\n")
+    msg.WriteString("MIME-Version: 1.0\r\n")
+    msg.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+    msg.WriteString("\r\n")
+    msg.WriteString(body)
+
+    return []byte(msg.String())
+}
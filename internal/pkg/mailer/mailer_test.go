@@ -0,0 +1,84 @@
+/*
+   package mailer
+   mailer_test.go
+   - test unit for mailer
+*/
+package mailer
+
+import (
+	"net/smtp"
+	"testing"
+
+	"github.com/reshimahendra/lbw-go/internal/config"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+    // cfg is mail configuration mock data
+    cfg = config.Mail{
+        SmtpServer     : "smtp.lotusbw.com",
+        SmtpPort       : "587",
+        SmtpUsername   : "reshi",
+        SmtpPassword   : "secret",
+        SenderEmail    : "noreply@lotusbw.com",
+        SenderIdentity : "Lotus BW",
+    }
+
+    // smtpSendMail is original smtp.SendMail func
+    smtpSendMail = smtpSendMailFunc
+)
+
+// TestNew will test mailer selection based on the configuration
+func TestNew(t *testing.T) {
+    t.Run("EXPECT SUCCESS smtp mailer", func(t *testing.T){
+        got := New(cfg)
+        assert.IsType(t, &SMTPMailer{}, got)
+    })
+
+    t.Run("EXPECT SUCCESS log mailer", func(t *testing.T){
+        got := New(config.Mail{})
+        assert.IsType(t, &LogMailer{}, got)
+    })
+}
+
+// TestLogMailerSend will test Send method of LogMailer
+func TestLogMailerSend(t *testing.T) {
+    err := NewLogMailer().Send("leo@gmail.com", "subject", "body")
+    assert.NoError(t, err)
+}
+
+// TestSMTPMailerSend will test Send method of SMTPMailer
+func TestSMTPMailerSend(t *testing.T) {
+    defer func() { smtpSendMailFunc = smtpSendMail }()
+
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        var (
+            gotAddr, gotFrom string
+            gotTo []string
+            gotMsg []byte
+        )
+        smtpSendMailFunc = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+            gotAddr, gotFrom, gotTo, gotMsg = addr, from, to, msg
+            return nil
+        }
+
+        err := NewSMTPMailer(cfg).Send("leo@gmail.com", "subject", "body")
+        assert.NoError(t, err)
+        assert.Equal(t, "smtp.lotusbw.com:587", gotAddr)
+        assert.Equal(t, cfg.SenderEmail, gotFrom)
+        assert.Equal(t, []string{"leo@gmail.com"}, gotTo)
+        assert.Contains(t, string(gotMsg), "From: Lotus BW <noreply@lotusbw.com>\r\n")
+        assert.Contains(t, string(gotMsg), "Subject: subject\r\n")
+        assert.Contains(t, string(gotMsg), "\r\n\r\nbody")
+    })
+
+    t.Run("EXPECT FAIL send error", func(t *testing.T){
+        smtpSendMailFunc = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+            return E.New(E.ErrActivationMail)
+        }
+
+        err := NewSMTPMailer(cfg).Send("leo@gmail.com", "subject", "body")
+        assert.Error(t, err)
+    })
+}
@@ -0,0 +1,149 @@
+/*
+   package oidc
+   idtoken.go
+   - claims of the id token issued by openid connect provider
+*/
+package oidc
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// IDToken is claims of the id token identifying the user signed in on the provider
+type IDToken struct {
+    // Issuer is issuer url of the provider ('iss' claim)
+    Issuer            string   `json:"iss"`
+
+    // Subject is id of the user on the provider ('sub' claim), it never change
+    Subject           string   `json:"sub"`
+
+    // Audience is client id the token is issued for ('aud' claim)
+    Audience          audience `json:"aud"`
+
+    // AuthorizedParty is client id the token is issued to ('azp' claim)
+    AuthorizedParty   string   `json:"azp"`
+
+    // ExpiresAt is unix time the token expired ('exp' claim)
+    ExpiresAt         int64    `json:"exp"`
+
+    // IssuedAt is unix time the token was issued ('iat' claim)
+    IssuedAt          int64    `json:"iat"`
+
+    // Nonce is the nonce sent on the authorization request
+    Nonce             string   `json:"nonce"`
+
+    // Email is email of the user
+    Email             string   `json:"email"`
+
+    // EmailVerified is whether the provider verified the user own the email
+    EmailVerified     flexBool `json:"email_verified"`
+
+    // Name is full name of the user
+    Name              string   `json:"name"`
+
+    // GivenName is first name of the user
+    GivenName         string   `json:"given_name"`
+
+    // FamilyName is last name of the user
+    FamilyName        string   `json:"family_name"`
+
+    // PreferredUsername is username the user prefer to be called
+    PreferredUsername string   `json:"preferred_username"`
+}
+
+// Valid will validate the token time (exp, iat), tolerating clockSkew difference with the provider
+func (t *IDToken) Valid() error {
+    now := timeNowFunc()
+    if t.ExpiresAt == 0 || now.After(time.Unix(t.ExpiresAt, 0).Add(clockSkew)) {
+        return fmt.Errorf("token is expired")
+    }
+    if t.IssuedAt != 0 && now.Add(clockSkew).Before(time.Unix(t.IssuedAt, 0)) {
+        return fmt.Errorf("token used before issued")
+    }
+
+    return nil
+}
+
+// IsEmailVerified will check whether the provider verified the user own the email
+func (t *IDToken) IsEmailVerified() bool {
+    return t.Email != "" && bool(t.EmailVerified)
+}
+
+// verify will make sure the token is issued by the issuer for the client with the given nonce
+// (OpenID Connect Core 3.1.3.7)
+func (t *IDToken) verify(issuer, clientID, nonce string) error {
+    if t.Issuer != issuer {
+        return fmt.Errorf("issuer %q does not match %q", t.Issuer, issuer)
+    }
+    if !t.Audience.contains(clientID) {
+        return fmt.Errorf("token is not issued for client %q", clientID)
+    }
+    if len(t.Audience) > 1 && t.AuthorizedParty != clientID {
+        return fmt.Errorf("token is not issued to client %q", clientID)
+    }
+    if t.Subject == "" {
+        return fmt.Errorf("token has no subject")
+    }
+    if nonce == "" || t.Nonce != nonce {
+        return fmt.Errorf("nonce does not match")
+    }
+
+    return nil
+}
+
+// audience is 'aud' claim, it is either single string or array of string
+type audience []string
+
+// UnmarshalJSON will decode the single string or array of string audience
+func (a *audience) UnmarshalJSON(b []byte) error {
+    var single string
+    if err := json.Unmarshal(b, &single); err == nil {
+        *a = audience{single}
+        return nil
+    }
+
+    var multi []string
+    if err := json.Unmarshal(b, &multi); err != nil {
+        return err
+    }
+    *a = multi
+
+    return nil
+}
+
+// contains will check whether the client id is on the audience
+func (a audience) contains(clientID string) bool {
+    for _, aud := range a {
+        if aud == clientID {
+            return true
+        }
+    }
+
+    return false
+}
+
+// flexBool is boolean claim that some provider send as string ("true")
+type flexBool bool
+
+// UnmarshalJSON will decode boolean or string boolean claim
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+    var v interface{}
+    if err := json.Unmarshal(data, &v); err != nil {
+        return err
+    }
+
+    switch value := v.(type) {
+    case bool:
+        *b = flexBool(value)
+    case string:
+        parsed, _ := strconv.ParseBool(value)
+        *b = flexBool(parsed)
+    default:
+        *b = false
+    }
+
+    return nil
+}
@@ -0,0 +1,386 @@
+/*
+   package oidc
+   oidc.go
+   - openid connect relying party (authorization code flow with pkce) used for social login
+   NOTE of method:
+       * AuthCodeURL   : get the url to send the user to the provider
+       * Exchange      : exchange the authorization code with the id token
+       * VerifyIDToken : verify the id token against the provider public key (jwks)
+*/
+package oidc
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/reshimahendra/lbw-go/internal/config"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/logger"
+)
+
+const (
+    // discoveryPath is path of the provider metadata relative to its issuer
+    discoveryPath = "/.well-known/openid-configuration"
+
+    // httpTimeout is timeout of every request to the provider
+    httpTimeout = 10 * time.Second
+
+    // clockSkew is tolerated clock difference with the provider when checking the id token time
+    clockSkew = time.Minute
+
+    // jwksRefreshInterval is shortest interval to refetch the provider key set when the
+    // id token is signed with unknown key (the provider rotated its key)
+    jwksRefreshInterval = time.Minute
+)
+
+var (
+    // defaultScopes is scope requested when the provider has no scope configured
+    defaultScopes = []string{"openid", "email", "profile"}
+
+    // timeNowFunc is func instance of time.Now
+    // it will be used to mock the inner func on test
+    timeNowFunc = time.Now
+
+    // signingMethods is algorithm accepted to sign the id token
+    signingMethods = []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512", "EdDSA"}
+)
+
+// IProvider is interface of openid connect provider the user sign in with
+type IProvider interface {
+    // AuthCodeURL will get the url to send the user to the provider. verifier is
+    // the pkce code verifier, only its challenge is sent to the provider
+    AuthCodeURL(state, nonce, verifier string) (string, error)
+
+    // Exchange will exchange the authorization code and the pkce code verifier with the id token
+    Exchange(code, verifier string) (string, error)
+
+    // VerifyIDToken will verify the id token and get its claims
+    VerifyIDToken(rawIDToken, nonce string) (*IDToken, error)
+}
+
+// Provider is openid connect provider based on its configuration
+type Provider struct {
+    // Config is configuration of the provider
+    Config    config.OIDCProvider
+
+    // Client is http client used to send request to the provider
+    Client    *http.Client
+
+    mu        sync.Mutex
+    endpoint  *metadata
+    keys      map[string]interface{}
+    fetchedAt time.Time
+}
+
+// metadata is provider metadata published on its discovery document
+type metadata struct {
+    Issuer                string `json:"issuer"`
+    AuthorizationEndpoint string `json:"authorization_endpoint"`
+    TokenEndpoint         string `json:"token_endpoint"`
+    JWKSURI               string `json:"jwks_uri"`
+}
+
+// tokenResponse is response of the provider token endpoint
+type tokenResponse struct {
+    IDToken          string `json:"id_token"`
+    Error            string `json:"error"`
+    ErrorDescription string `json:"error_description"`
+}
+
+// NewProvider is new instance of Provider
+func NewProvider(cfg config.OIDCProvider) *Provider {
+    return &Provider{
+        Config : cfg,
+        Client : &http.Client{Timeout: httpTimeout},
+    }
+}
+
+// CodeChallenge will get the pkce 'S256' code challenge of the code verifier
+func CodeChallenge(verifier string) string {
+    sum := sha256.Sum256([]byte(verifier))
+
+    return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// AuthCodeURL will get the url of the provider authorization endpoint to send the user to
+func (p *Provider) AuthCodeURL(state, nonce, verifier string) (string, error) {
+    endpoint, err := p.metadata()
+    if err != nil {
+        return "", err
+    }
+
+    scopes := p.Config.Scopes
+    if len(scopes) == 0 {
+        scopes = defaultScopes
+    }
+
+    query := url.Values{
+        "response_type"         : {"code"},
+        "client_id"             : {p.Config.ClientID},
+        "redirect_uri"          : {p.Config.RedirectURL},
+        "scope"                 : {strings.Join(scopes, " ")},
+        "state"                 : {state},
+        "nonce"                 : {nonce},
+        "code_challenge"        : {CodeChallenge(verifier)},
+        "code_challenge_method" : {"S256"},
+    }
+
+    separator := "?"
+    if strings.Contains(endpoint.AuthorizationEndpoint, "?") {
+        separator = "&"
+    }
+
+    return endpoint.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Exchange will send the authorization code and the pkce code verifier to the provider
+// token endpoint and get the id token
+func (p *Provider) Exchange(code, verifier string) (string, error) {
+    endpoint, err := p.metadata()
+    if err != nil {
+        return "", err
+    }
+
+    form := url.Values{
+        "grant_type"    : {"authorization_code"},
+        "code"          : {code},
+        "redirect_uri"  : {p.Config.RedirectURL},
+        "client_id"     : {p.Config.ClientID},
+        "code_verifier" : {verifier},
+    }
+    req, err := http.NewRequest(http.MethodPost, endpoint.TokenEndpoint, strings.NewReader(form.Encode()))
+    if err != nil {
+        logger.Errorf("oidc %s token request fail: %v", p.Config.Name, err)
+        return "", E.New(E.ErrOIDCExchange)
+    }
+    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+    req.Header.Set("Accept", "application/json")
+    if p.Config.ClientSecret != "" {
+        req.SetBasicAuth(url.QueryEscape(p.Config.ClientID), url.QueryEscape(p.Config.ClientSecret))
+    }
+
+    resp, err := p.Client.Do(req)
+    if err != nil {
+        logger.Errorf("oidc %s token request fail: %v", p.Config.Name, err)
+        return "", E.New(E.ErrOIDCExchange)
+    }
+    defer resp.Body.Close()
+
+    var token tokenResponse
+    if err := json.NewDecoder(resp.Body).Decode(&token); err != nil || resp.StatusCode != http.StatusOK {
+        logger.Errorf("oidc %s token request fail: status %d, %s %s %v", p.Config.Name, resp.StatusCode, token.Error, token.ErrorDescription, err)
+        return "", E.New(E.ErrOIDCExchange)
+    }
+    if token.IDToken == "" {
+        logger.Errorf("oidc %s token response has no id token", p.Config.Name)
+        return "", E.New(E.ErrOIDCExchange)
+    }
+
+    return token.IDToken, nil
+}
+
+// VerifyIDToken will verify the id token signature against the provider key set, and make
+// sure it is issued by the provider for our client with the given nonce and not expired
+func (p *Provider) VerifyIDToken(rawIDToken, nonce string) (*IDToken, error) {
+    endpoint, err := p.metadata()
+    if err != nil {
+        return nil, err
+    }
+
+    claims := new(IDToken)
+    parser := &jwt.Parser{ValidMethods: signingMethods}
+    token, err := parser.ParseWithClaims(rawIDToken, claims, func(t *jwt.Token) (interface{}, error) {
+        kid, _ := t.Header["kid"].(string)
+        return p.publicKey(kid)
+    })
+    if err != nil || !token.Valid {
+        logger.Errorf("oidc %s id token invalid: %v", p.Config.Name, err)
+        return nil, E.New(E.ErrOIDCTokenInvalid)
+    }
+
+    if err := claims.verify(endpoint.Issuer, p.Config.ClientID, nonce); err != nil {
+        logger.Errorf("oidc %s id token invalid: %v", p.Config.Name, err)
+        return nil, E.New(E.ErrOIDCTokenInvalid)
+    }
+
+    return claims, nil
+}
+
+// metadata will get the provider endpoint from its configuration, or discover it
+// from the provider issuer. the discovered endpoint is cached
+func (p *Provider) metadata() (*metadata, error) {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+
+    if p.endpoint != nil {
+        return p.endpoint, nil
+    }
+
+    if !p.Config.NeedDiscovery() {
+        p.endpoint = &metadata{
+            Issuer                : p.Config.Issuer,
+            AuthorizationEndpoint : p.Config.AuthorizationURL,
+            TokenEndpoint         : p.Config.TokenURL,
+            JWKSURI               : p.Config.JWKSURL,
+        }
+        return p.endpoint, nil
+    }
+
+    endpoint := new(metadata)
+    if err := p.getJSON(strings.TrimSuffix(p.Config.Issuer, "/")+discoveryPath, endpoint); err != nil {
+        logger.Errorf("oidc %s discovery fail: %v", p.Config.Name, err)
+        return nil, E.New(E.ErrOIDCExchange)
+    }
+
+    // the discovered issuer must be the configured one (OpenID Connect Discovery 4.3)
+    if endpoint.Issuer != p.Config.Issuer {
+        logger.Errorf("oidc %s discovery fail: issuer %q does not match %q", p.Config.Name, endpoint.Issuer, p.Config.Issuer)
+        return nil, E.New(E.ErrOIDCExchange)
+    }
+
+    // explicitly configured endpoint take precedence over the discovered one
+    if p.Config.AuthorizationURL != "" {
+        endpoint.AuthorizationEndpoint = p.Config.AuthorizationURL
+    }
+    if p.Config.TokenURL != "" {
+        endpoint.TokenEndpoint = p.Config.TokenURL
+    }
+    if p.Config.JWKSURL != "" {
+        endpoint.JWKSURI = p.Config.JWKSURL
+    }
+    p.endpoint = endpoint
+
+    return p.endpoint, nil
+}
+
+// publicKey will get the provider public key with the given key id. the key set is
+// refetched when the key is not found, at most once per jwksRefreshInterval
+func (p *Provider) publicKey(kid string) (interface{}, error) {
+    p.mu.Lock()
+    defer p.mu.Unlock()
+
+    if key, ok := p.lookupKey(kid); ok {
+        return key, nil
+    }
+
+    if p.keys != nil && timeNowFunc().Sub(p.fetchedAt) < jwksRefreshInterval {
+        return nil, fmt.Errorf("unknown signing key %q", kid)
+    }
+
+    jwks := new(d.JWKS)
+    if err := p.getJSON(p.endpoint.JWKSURI, jwks); err != nil {
+        return nil, err
+    }
+
+    keys := map[string]interface{}{}
+    for _, jwk := range jwks.Keys {
+        if jwk.Use != "" && jwk.Use != "sig" {
+            continue
+        }
+        key, err := parseJWK(jwk)
+        if err != nil {
+            logger.Errorf("oidc %s skip key %q: %v", p.Config.Name, jwk.Kid, err)
+            continue
+        }
+        keys[jwk.Kid] = key
+    }
+    p.keys = keys
+    p.fetchedAt = timeNowFunc()
+
+    if key, ok := p.lookupKey(kid); ok {
+        return key, nil
+    }
+
+    return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// lookupKey will find the key with the given key id on the cached key set. token
+// without key id can only be verified when the provider has a single key
+func (p *Provider) lookupKey(kid string) (interface{}, bool) {
+    if kid == "" && len(p.keys) == 1 {
+        for _, key := range p.keys {
+            return key, true
+        }
+    }
+
+    key, ok := p.keys[kid]
+    return key, ok
+}
+
+// getJSON will send get request to the provider and decode its json response
+func (p *Provider) getJSON(uri string, v interface{}) error {
+    resp, err := p.Client.Get(uri)
+    if err != nil {
+        return err
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode != http.StatusOK {
+        return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, uri)
+    }
+
+    return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// parseJWK will convert the JSON Web Key to rsa, ecdsa or ed25519 public key
+func parseJWK(jwk d.JWK) (interface{}, error) {
+    switch jwk.Kty {
+    case "RSA":
+        n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+        if err != nil {
+            return nil, err
+        }
+        e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+        if err != nil {
+            return nil, err
+        }
+        return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+    case "EC":
+        var curve elliptic.Curve
+        switch jwk.Crv {
+        case "P-256":
+            curve = elliptic.P256()
+        case "P-384":
+            curve = elliptic.P384()
+        case "P-521":
+            curve = elliptic.P521()
+        default:
+            return nil, fmt.Errorf("unsupported curve %q", jwk.Crv)
+        }
+        x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+        if err != nil {
+            return nil, err
+        }
+        y, err := base64.RawURLEncoding.DecodeString(jwk.Y)
+        if err != nil {
+            return nil, err
+        }
+        key := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+        if !curve.IsOnCurve(key.X, key.Y) {
+            return nil, fmt.Errorf("point is not on curve %q", jwk.Crv)
+        }
+        return key, nil
+    case "OKP":
+        x, err := base64.RawURLEncoding.DecodeString(jwk.X)
+        if err != nil || jwk.Crv != "Ed25519" || len(x) != ed25519.PublicKeySize {
+            return nil, fmt.Errorf("unsupported okp key")
+        }
+        return ed25519.PublicKey(x), nil
+    }
+
+    return nil, fmt.Errorf("unsupported key type %q", jwk.Kty)
+}
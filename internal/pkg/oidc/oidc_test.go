@@ -0,0 +1,237 @@
+/*
+   package oidc
+   oidc_test.go
+   - test unit for openid connect relying party against local stand-in provider
+*/
+package oidc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/reshimahendra/lbw-go/internal/config"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+	E "github.com/reshimahendra/lbw-go/internal/pkg/errors"
+	"github.com/reshimahendra/lbw-go/internal/pkg/oidc/oidctest"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+    testClientID     = "lbw-go"
+    testClientSecret = "lbw-go-secret"
+    testRedirectURL  = "https://lotusbw.com/account/oidc/test/callback"
+    testVerifier     = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+)
+
+// newTestProvider will start stand-in provider and create Provider of it
+func newTestProvider(t *testing.T) (*oidctest.Server, *Provider) {
+    t.Helper()
+
+    idp := oidctest.NewServer(testClientID, testClientSecret)
+    t.Cleanup(idp.Close)
+
+    return idp, NewProvider(config.OIDCProvider{
+        Name         : "test",
+        Issuer       : idp.Issuer(),
+        ClientID     : testClientID,
+        ClientSecret : testClientSecret,
+        RedirectURL  : testRedirectURL,
+    })
+}
+
+// TestCodeChallenge will test pkce code challenge with the example of RFC 7636 appendix B
+func TestCodeChallenge(t *testing.T) {
+    assert.Equal(t, "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM", CodeChallenge(testVerifier))
+}
+
+// TestProviderFlow will test the whole authorization code flow with pkce
+func TestProviderFlow(t *testing.T) {
+    idp, provider := newTestProvider(t)
+
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        authURL, err := provider.AuthCodeURL("state-1", "nonce-1", testVerifier)
+        assert.NoError(t, err)
+
+        u, _ := url.Parse(authURL)
+        assert.Equal(t, idp.Issuer()+"/authorize", u.Scheme+"://"+u.Host+u.Path)
+        assert.Equal(t, "openid email profile", u.Query().Get("scope"))
+        assert.Equal(t, testRedirectURL, u.Query().Get("redirect_uri"))
+        assert.Empty(t, u.Query().Get("code_verifier"))
+
+        code, state, err := idp.Authorize(authURL, jwt.MapClaims{"sub": "248289761001", "given_name": "Leo"})
+        assert.NoError(t, err)
+        assert.Equal(t, "state-1", state)
+
+        rawIDToken, err := provider.Exchange(code, testVerifier)
+        assert.NoError(t, err)
+
+        got, err := provider.VerifyIDToken(rawIDToken, "nonce-1")
+        assert.NoError(t, err)
+        assert.Equal(t, "248289761001", got.Subject)
+        assert.Equal(t, "Leo", got.GivenName)
+        assert.True(t, got.IsEmailVerified())
+    })
+
+    t.Run("EXPECT FAIL wrong code verifier", func(t *testing.T){
+        authURL, _ := provider.AuthCodeURL("state-1", "nonce-1", testVerifier)
+        code, _, _ := idp.Authorize(authURL, nil)
+
+        _, err := provider.Exchange(code, "stolen-code-without-its-verifier")
+        assert.EqualValues(t, E.ErrOIDCExchange, err.(*E.Error).Code)
+    })
+
+    t.Run("EXPECT FAIL code used twice", func(t *testing.T){
+        authURL, _ := provider.AuthCodeURL("state-1", "nonce-1", testVerifier)
+        code, _, _ := idp.Authorize(authURL, nil)
+
+        _, err := provider.Exchange(code, testVerifier)
+        assert.NoError(t, err)
+
+        _, err = provider.Exchange(code, testVerifier)
+        assert.EqualValues(t, E.ErrOIDCExchange, err.(*E.Error).Code)
+    })
+
+    t.Run("EXPECT FAIL wrong client secret", func(t *testing.T){
+        authURL, _ := provider.AuthCodeURL("state-1", "nonce-1", testVerifier)
+        code, _, _ := idp.Authorize(authURL, nil)
+
+        provider.Config.ClientSecret = "wrong"
+        defer func() { provider.Config.ClientSecret = testClientSecret }()
+
+        _, err := provider.Exchange(code, testVerifier)
+        assert.EqualValues(t, E.ErrOIDCExchange, err.(*E.Error).Code)
+    })
+}
+
+// TestProviderDiscovery will test the provider endpoint discovery
+func TestProviderDiscovery(t *testing.T) {
+    t.Run("EXPECT FAIL issuer mismatch", func(t *testing.T){
+        idp, provider := newTestProvider(t)
+        provider.Config.Issuer = idp.Issuer() + "/"
+
+        _, err := provider.AuthCodeURL("state-1", "nonce-1", testVerifier)
+        assert.EqualValues(t, E.ErrOIDCExchange, err.(*E.Error).Code)
+    })
+
+    t.Run("EXPECT FAIL provider unreachable", func(t *testing.T){
+        idp, provider := newTestProvider(t)
+        idp.Close()
+
+        _, err := provider.AuthCodeURL("state-1", "nonce-1", testVerifier)
+        assert.EqualValues(t, E.ErrOIDCExchange, err.(*E.Error).Code)
+    })
+
+    t.Run("EXPECT SUCCESS configured endpoint", func(t *testing.T){
+        provider := NewProvider(config.OIDCProvider{
+            Name             : "test",
+            Issuer           : "https://idp.lotusbw.com",
+            ClientID         : testClientID,
+            RedirectURL      : testRedirectURL,
+            Scopes           : []string{"openid", "email"},
+            AuthorizationURL : "https://idp.lotusbw.com/authorize?prompt=login",
+            TokenURL         : "https://idp.lotusbw.com/token",
+            JWKSURL          : "https://idp.lotusbw.com/jwks",
+        })
+
+        authURL, err := provider.AuthCodeURL("state-1", "nonce-1", testVerifier)
+        assert.NoError(t, err)
+
+        u, _ := url.Parse(authURL)
+        assert.Equal(t, "login", u.Query().Get("prompt"))
+        assert.Equal(t, "openid email", u.Query().Get("scope"))
+        assert.Equal(t, CodeChallenge(testVerifier), u.Query().Get("code_challenge"))
+    })
+}
+
+// TestProviderVerifyIDToken will test the id token verification
+func TestProviderVerifyIDToken(t *testing.T) {
+    idp, provider := newTestProvider(t)
+    expired := time.Now().Add(-2 * time.Hour).Unix()
+
+    cases := []struct{
+        name   string
+        claims jwt.MapClaims
+        nonce  string
+    }{
+        {"EXPECT FAIL nonce mismatch", jwt.MapClaims{"nonce": "nonce-1"}, "nonce-2"},
+        {"EXPECT FAIL empty nonce", jwt.MapClaims{}, ""},
+        {"EXPECT FAIL other issuer", jwt.MapClaims{"nonce": "nonce-1", "iss": "https://evil.com"}, "nonce-1"},
+        {"EXPECT FAIL other audience", jwt.MapClaims{"nonce": "nonce-1", "aud": "other-client"}, "nonce-1"},
+        {"EXPECT FAIL multiple audience without azp", jwt.MapClaims{"nonce": "nonce-1", "aud": []string{testClientID, "other"}}, "nonce-1"},
+        {"EXPECT FAIL expired", jwt.MapClaims{"nonce": "nonce-1", "exp": expired}, "nonce-1"},
+        {"EXPECT FAIL no subject", jwt.MapClaims{"nonce": "nonce-1", "sub": ""}, "nonce-1"},
+    }
+
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            raw, err := idp.SignIDToken(tt.claims)
+            assert.NoError(t, err)
+
+            got, err := provider.VerifyIDToken(raw, tt.nonce)
+            assert.Nil(t, got)
+            assert.EqualValues(t, E.ErrOIDCTokenInvalid, err.(*E.Error).Code)
+        })
+    }
+
+    t.Run("EXPECT SUCCESS multiple audience with azp", func(t *testing.T){
+        raw, _ := idp.SignIDToken(jwt.MapClaims{"nonce": "nonce-1", "aud": []string{testClientID, "other"}, "azp": testClientID, "email_verified": "true"})
+
+        got, err := provider.VerifyIDToken(raw, "nonce-1")
+        assert.NoError(t, err)
+        assert.True(t, got.IsEmailVerified())
+    })
+
+    t.Run("EXPECT FAIL signed with unknown key", func(t *testing.T){
+        key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+        token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+            "iss": idp.Issuer(), "aud": testClientID, "sub": "1", "nonce": "nonce-1",
+            "exp": time.Now().Add(time.Hour).Unix(),
+        })
+        token.Header["kid"] = idp.KeyID
+        raw, _ := token.SignedString(key)
+
+        got, err := provider.VerifyIDToken(raw, "nonce-1")
+        assert.Nil(t, got)
+        assert.EqualValues(t, E.ErrOIDCTokenInvalid, err.(*E.Error).Code)
+    })
+
+    t.Run("EXPECT FAIL unsigned token", func(t *testing.T){
+        token := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{
+            "iss": idp.Issuer(), "aud": testClientID, "sub": "1", "nonce": "nonce-1",
+            "exp": time.Now().Add(time.Hour).Unix(),
+        })
+        raw, _ := token.SignedString(jwt.UnsafeAllowNoneSignatureType)
+
+        got, err := provider.VerifyIDToken(raw, "nonce-1")
+        assert.Nil(t, got)
+        assert.EqualValues(t, E.ErrOIDCTokenInvalid, err.(*E.Error).Code)
+    })
+}
+
+// TestParseJWK will test converting JSON Web Key to public key
+func TestParseJWK(t *testing.T) {
+    key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+    ec := d.JWK{
+        Kty : "EC",
+        Crv : "P-256",
+        X   : base64.RawURLEncoding.EncodeToString(key.X.Bytes()),
+        Y   : base64.RawURLEncoding.EncodeToString(key.Y.Bytes()),
+    }
+
+    got, err := parseJWK(ec)
+    assert.NoError(t, err)
+    assert.True(t, key.PublicKey.Equal(got))
+
+    ec.Y = ec.X
+    _, err = parseJWK(ec)
+    assert.Error(t, err)
+
+    _, err = parseJWK(d.JWK{Kty: "oct"})
+    assert.Error(t, err)
+}
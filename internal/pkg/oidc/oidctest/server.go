@@ -0,0 +1,224 @@
+/*
+   package oidctest
+   server.go
+   - local stand-in openid connect provider (identity provider) to test the signin flow
+   NOTE of method:
+       * Authorize   : simulate the user approving the signin on the provider
+       * SignIDToken : sign id token with the provider key
+*/
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	d "github.com/reshimahendra/lbw-go/internal/domain"
+)
+
+// Server is openid connect provider serving its discovery document, key set and token endpoint
+type Server struct {
+    *httptest.Server
+
+    // ClientID is client id registered on the provider
+    ClientID     string
+
+    // ClientSecret is client secret registered on the provider
+    ClientSecret string
+
+    // KeyID is key id ('kid' header) of the provider signing key
+    KeyID        string
+
+    key          *rsa.PrivateKey
+    mu           sync.Mutex
+    grants       map[string]grant
+}
+
+// grant is authorization code issued to the client and waiting to be exchanged
+type grant struct {
+    challenge   string
+    nonce       string
+    redirectURI string
+    claims      jwt.MapClaims
+}
+
+// NewServer will start new provider for the given client. it must be closed after use
+func NewServer(clientID, clientSecret string) *Server {
+    key, err := rsa.GenerateKey(rand.Reader, 2048)
+    if err != nil {
+        panic(fmt.Sprintf("oidctest: generate key fail: %v", err))
+    }
+
+    s := &Server{
+        ClientID     : clientID,
+        ClientSecret : clientSecret,
+        KeyID        : "oidctest-key",
+        key          : key,
+        grants       : map[string]grant{},
+    }
+
+    mux := http.NewServeMux()
+    mux.HandleFunc("/.well-known/openid-configuration", s.discovery)
+    mux.HandleFunc("/jwks", s.jwks)
+    mux.HandleFunc("/token", s.token)
+    s.Server = httptest.NewServer(mux)
+
+    return s
+}
+
+// Issuer is issuer url of the provider
+func (s *Server) Issuer() string {
+    return s.URL
+}
+
+// Authorize will simulate the user approving the signin on the provider authorization url.
+// it return the authorization code and the state sent back to the client redirect url.
+// claims is added to (or override) the default claims of the id token
+func (s *Server) Authorize(authURL string, claims jwt.MapClaims) (code, state string, err error) {
+    u, err := url.Parse(authURL)
+    if err != nil {
+        return "", "", err
+    }
+
+    q := u.Query()
+    switch {
+    case q.Get("response_type") != "code":
+        return "", "", fmt.Errorf("unsupported response type %q", q.Get("response_type"))
+    case q.Get("client_id") != s.ClientID:
+        return "", "", fmt.Errorf("unknown client %q", q.Get("client_id"))
+    case q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "":
+        return "", "", fmt.Errorf("pkce code challenge is required")
+    }
+
+    code = randomString()
+    s.mu.Lock()
+    s.grants[code] = grant{
+        challenge   : q.Get("code_challenge"),
+        nonce       : q.Get("nonce"),
+        redirectURI : q.Get("redirect_uri"),
+        claims      : claims,
+    }
+    s.mu.Unlock()
+
+    return code, q.Get("state"), nil
+}
+
+// SignIDToken will sign id token with the provider key. the default claims (iss, aud, sub,
+// email, iat, exp) is added when it is not on the given claims
+func (s *Server) SignIDToken(claims jwt.MapClaims) (string, error) {
+    now := time.Now()
+    token := jwt.MapClaims{
+        "iss"            : s.URL,
+        "aud"            : s.ClientID,
+        "sub"            : "oidctest-subject",
+        "email"          : "leo@oidctest.com",
+        "email_verified" : true,
+        "iat"            : now.Unix(),
+        "exp"            : now.Add(time.Hour).Unix(),
+    }
+    for k, v := range claims {
+        token[k] = v
+    }
+
+    t := jwt.NewWithClaims(jwt.SigningMethodRS256, token)
+    t.Header["kid"] = s.KeyID
+
+    return t.SignedString(s.key)
+}
+
+// discovery will serve the provider metadata
+func (s *Server) discovery(w http.ResponseWriter, r *http.Request) {
+    writeJSON(w, http.StatusOK, map[string]interface{}{
+        "issuer"                                : s.URL,
+        "authorization_endpoint"                : s.URL + "/authorize",
+        "token_endpoint"                        : s.URL + "/token",
+        "jwks_uri"                              : s.URL + "/jwks",
+        "code_challenge_methods_supported"      : []string{"S256"},
+        "id_token_signing_alg_values_supported" : []string{"RS256"},
+    })
+}
+
+// jwks will serve the provider public key set
+func (s *Server) jwks(w http.ResponseWriter, r *http.Request) {
+    writeJSON(w, http.StatusOK, d.JWKS{Keys: []d.JWK{{
+        Kty : "RSA",
+        Use : "sig",
+        Kid : s.KeyID,
+        Alg : "RS256",
+        N   : base64.RawURLEncoding.EncodeToString(s.key.N.Bytes()),
+        E   : base64.RawURLEncoding.EncodeToString(big.NewInt(int64(s.key.E)).Bytes()),
+    }}})
+}
+
+// token will exchange the authorization code with the id token after checking the
+// client credential and the pkce code verifier
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+    if r.Method != http.MethodPost || r.ParseForm() != nil {
+        writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+        return
+    }
+
+    clientID, clientSecret, _ := r.BasicAuth()
+    if clientID != s.ClientID || clientSecret != s.ClientSecret {
+        writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+        return
+    }
+
+    s.mu.Lock()
+    g, ok := s.grants[r.PostForm.Get("code")]
+    delete(s.grants, r.PostForm.Get("code"))
+    s.mu.Unlock()
+
+    sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+    switch {
+    case r.PostForm.Get("grant_type") != "authorization_code",
+        !ok,
+        g.redirectURI != r.PostForm.Get("redirect_uri"),
+        g.challenge != base64.RawURLEncoding.EncodeToString(sum[:]):
+        writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+        return
+    }
+
+    claims := jwt.MapClaims{"nonce": g.nonce}
+    for k, v := range g.claims {
+        claims[k] = v
+    }
+    idToken, err := s.SignIDToken(claims)
+    if err != nil {
+        writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+        return
+    }
+
+    writeJSON(w, http.StatusOK, map[string]interface{}{
+        "access_token" : randomString(),
+        "token_type"   : "Bearer",
+        "expires_in"   : 3600,
+        "id_token"     : idToken,
+    })
+}
+
+// writeJSON will write the value as json response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(status)
+    json.NewEncoder(w).Encode(v)
+}
+
+// randomString will create random hex string used as code and access token
+func randomString() string {
+    b := make([]byte, 16)
+    rand.Read(b)
+
+    return hex.EncodeToString(b)
+}
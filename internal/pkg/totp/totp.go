@@ -0,0 +1,116 @@
+/*
+   Time-based one time password (TOTP, RFC 6238) for two factor authentication
+*/
+package totp
+
+import (
+	"crypto/hmac"
+	crand "crypto/rand"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+    // Digits is number of digit of the generated code
+    Digits = 6
+
+    // Period is valid duration (in second) of each code
+    Period = 30
+
+    // Skew is number of period before and after the current period that is still accepted
+    // to tolerate the clock drift of the user device
+    Skew = 1
+
+    // secretLength is byte length of the generated secret (160 bit, as recommended by RFC 4226)
+    secretLength = 20
+)
+
+var (
+    // crandRead is instance func of crypto/rand.Read
+    crandRead = crand.Read
+
+    // b32 is base32 encoding used by authenticator app (no padding)
+    b32 = base32.StdEncoding.WithPadding(base32.NoPadding)
+)
+
+// GenerateSecret will create new random secret encoded as base32
+func GenerateSecret() (string, error) {
+    secret := make([]byte, secretLength)
+    if _, err := crandRead(secret); err != nil {
+        return "", err
+    }
+
+    return b32.EncodeToString(secret), nil
+}
+
+// Step will get the time step (counter) of the given time
+func Step(t time.Time) int64 {
+    return t.Unix() / Period
+}
+
+// Code will generate the code of the secret at the given time
+func Code(secret string, t time.Time) (string, error) {
+    return codeAt(secret, Step(t))
+}
+
+// Validate will check the code against the secret on the current step and its skew.
+// it return the matched step so the caller can reject the same step being used twice
+func Validate(secret, code string, t time.Time) (int64, bool) {
+    if len(code) != Digits {
+        return 0, false
+    }
+
+    current := Step(t)
+    for i := -Skew; i <= Skew; i++ {
+        step := current + int64(i)
+        expected, err := codeAt(secret, step)
+        if err != nil {
+            return 0, false
+        }
+        if hmac.Equal([]byte(expected), []byte(code)) {
+            return step, true
+        }
+    }
+
+    return 0, false
+}
+
+// ProvisioningURI will create 'otpauth' uri of the secret, it is usually shown as
+// qr code so the user can add the account into the authenticator app
+func ProvisioningURI(issuer, account, secret string) string {
+    query := url.Values{}
+    query.Set("secret", secret)
+    query.Set("issuer", issuer)
+    query.Set("algorithm", "SHA1")
+    query.Set("digits", fmt.Sprint(Digits))
+    query.Set("period", fmt.Sprint(Period))
+
+    label := url.PathEscape(issuer + ":" + account)
+    return fmt.Sprintf("otpauth://totp/%s?%s", label, query.Encode())
+}
+
+// codeAt will generate the code (HOTP, RFC 4226) of the secret at the given step
+func codeAt(secret string, step int64) (string, error) {
+    key, err := b32.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+    if err != nil {
+        return "", err
+    }
+
+    counter := make([]byte, 8)
+    binary.BigEndian.PutUint64(counter, uint64(step))
+
+    mac := hmac.New(sha1.New, key)
+    mac.Write(counter)
+    sum := mac.Sum(nil)
+
+    // dynamic truncation
+    offset := sum[len(sum)-1] & 0x0f
+    value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+    return fmt.Sprintf("%0*d", Digits, value%1000000), nil
+}
@@ -0,0 +1,125 @@
+package totp
+
+import (
+	"encoding/base32"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rfcSecret is secret of the RFC 6238 SHA1 test vector ("12345678901234567890") encoded as base32
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+// TestCode will test the code generator against the RFC 6238 test vector
+func TestCode(t *testing.T) {
+    cases := []struct{
+        name string
+        unix int64
+        want string
+    }{
+        {"EXPECT SUCCESS T=59", 59, "287082"},
+        {"EXPECT SUCCESS T=1111111109", 1111111109, "081804"},
+        {"EXPECT SUCCESS T=1234567890", 1234567890, "005924"},
+        {"EXPECT SUCCESS T=2000000000", 2000000000, "279037"},
+    }
+
+    for _, tt := range cases {
+        t.Run(tt.name, func(t *testing.T){
+            got, err := Code(rfcSecret, time.Unix(tt.unix, 0))
+            assert.NoError(t, err)
+            assert.Equal(t, tt.want, got)
+        })
+    }
+
+    t.Run("EXPECT FAIL secret is not base32", func(t *testing.T){
+        _, err := Code("not-base32!", time.Now())
+        assert.Error(t, err)
+    })
+}
+
+// TestValidate will test the code validation
+func TestValidate(t *testing.T) {
+    now := time.Unix(1111111109, 0)
+
+    t.Run("EXPECT SUCCESS current step", func(t *testing.T){
+        step, ok := Validate(rfcSecret, "081804", now)
+        assert.True(t, ok)
+        assert.Equal(t, Step(now), step)
+    })
+
+    t.Run("EXPECT SUCCESS previous step within skew", func(t *testing.T){
+        step, ok := Validate(rfcSecret, "081804", now.Add(Period*time.Second))
+        assert.True(t, ok)
+        assert.Equal(t, Step(now), step)
+    })
+
+    t.Run("EXPECT FAIL step outside skew", func(t *testing.T){
+        _, ok := Validate(rfcSecret, "081804", now.Add(2*Period*time.Second))
+        assert.False(t, ok)
+    })
+
+    t.Run("EXPECT FAIL wrong code", func(t *testing.T){
+        _, ok := Validate(rfcSecret, "000000", now)
+        assert.False(t, ok)
+    })
+
+    t.Run("EXPECT FAIL code length", func(t *testing.T){
+        _, ok := Validate(rfcSecret, "81804", now)
+        assert.False(t, ok)
+    })
+
+    t.Run("EXPECT FAIL invalid secret", func(t *testing.T){
+        _, ok := Validate("not-base32!", "081804", now)
+        assert.False(t, ok)
+    })
+}
+
+// TestGenerateSecret will test the secret generator
+func TestGenerateSecret(t *testing.T) {
+    t.Run("EXPECT SUCCESS", func(t *testing.T){
+        secret, err := GenerateSecret()
+        require.NoError(t, err)
+        assert.NotContains(t, secret, "=")
+
+        key, err := b32.DecodeString(secret)
+        assert.NoError(t, err)
+        assert.Len(t, key, secretLength)
+
+        // generated secret can be used to create code
+        code, err := Code(secret, time.Now())
+        assert.NoError(t, err)
+        assert.Len(t, code, Digits)
+    })
+
+    t.Run("EXPECT FAIL random source error", func(t *testing.T){
+        crandReadFunc := crandRead
+        crandRead = func(b []byte) (int, error) {
+            return 0, errors.New("random source error")
+        }
+        defer func() { crandRead = crandReadFunc }()
+
+        secret, err := GenerateSecret()
+        assert.Error(t, err)
+        assert.Empty(t, secret)
+    })
+}
+
+// TestProvisioningURI will test the otpauth uri
+func TestProvisioningURI(t *testing.T) {
+    got := ProvisioningURI("Lotus", "user@mail.com", "JBSWY3DPEHPK3PXP")
+    assert.True(t, strings.HasPrefix(got, "otpauth://totp/Lotus:user@mail.com?"))
+
+    uri, err := url.Parse(got)
+    require.NoError(t, err)
+    query := uri.Query()
+    assert.Equal(t, "JBSWY3DPEHPK3PXP", query.Get("secret"))
+    assert.Equal(t, "Lotus", query.Get("issuer"))
+    assert.Equal(t, "SHA1", query.Get("algorithm"))
+    assert.Equal(t, "6", query.Get("digits"))
+    assert.Equal(t, "30", query.Get("period"))
+}